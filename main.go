@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Azure/gocover/pkg/cmd"
 	"github.com/Azure/gocover/pkg/gocover"
@@ -20,8 +23,13 @@ var (
 )
 
 func main() {
+	// the run is cancelled on interrupt or termination, such as the timeout of the CI job,
+	// so that the git operations, the database writes and the publishers stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	command := cmd.NewGoCoverCommand(version, commit, date)
-	if err := command.Execute(); err != nil {
+	err := command.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		exitCode := gocover.GeneralErrorExitCode
 		var e *gocover.GoCoverError
 		if errors.As(err, &e) {
@@ -8,6 +8,7 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/cover"
@@ -15,38 +16,54 @@ import (
 
 var (
 	// IgnoreRegexp the regexp for the gocover ignore pattern.
-	// Two kinds of ignore pattern are supported:
+	// Four kinds of ignore pattern are supported:
 	// - block
 	// - file
+	// - next
+	// - line
 	//
 	// This regexp matches the lines that
-	// starts with any characters, then follows `//+gocover:ignore:` and following either `file` or `block`,
+	// starts with any characters, then follows `//+gocover:ignore:` and following one of `file`, `block`, `next` and `line`,
 	// then comments about the intention.
-	IgnoreRegexp = regexp.MustCompile(`.*//\s*\+gocover:ignore:(file|block)(\s*)(.*)`)
+	IgnoreRegexp = regexp.MustCompile(`.*//\s*\+gocover:ignore:(file|block|next|line)(\s*)(.*)`)
+
+	// IssueRegexp the regexp for the issue that the ignore annotation is linked to in its comments,
+	// such as `//+gocover:ignore:block issue=Azure/gocover#123 blocked by the upstream fix`.
+	IssueRegexp = regexp.MustCompile(`(^|\s)issue=(\S*)`)
+
+	// issueReferenceRegexp matches the issue reference in {owner}/{repo}#{number} format.
+	issueReferenceRegexp = regexp.MustCompile(`^([\w.-]+/[\w.-]+)#([0-9]+)$`)
 
 	ErrCommentsRequired      = errors.New("comments required")
 	ErrWrongAnnotationFormat = errors.New("wrong ignore annotation format")
+	ErrWrongIssueFormat      = errors.New("issue should be in {owner}/{repo}#{number} format")
 )
 
 // IgnoreType indicates the type of the ignore profile.
 // - FILE_IGNORE means the profile ignore the whole input file.
 // - BLOCK_IGNORE means the profile ignore several code block of the input file.
+// - NEXT_IGNORE is the annotation that ignores the statement on the next line, whose blocks are kept in a BLOCK_IGNORE profile.
+// - LINE_IGNORE is the annotation after the statement that it ignores on the same line, kept like NEXT_IGNORE.
 type IgnoreType string
 
 const (
 	FILE_IGNORE  IgnoreType = "file"
 	BLOCK_IGNORE IgnoreType = "block"
+	NEXT_IGNORE  IgnoreType = "next"
+	LINE_IGNORE  IgnoreType = "line"
 )
 
 // IgnoreProfile represents the ignore profiling data for a specific file.
 type IgnoreProfile struct {
 	// type of the ignore profile.
-	// when it's BLOCK_IGNORE, IgnoreBlocks contains the concrete ignore data.
+	// when it's BLOCK_IGNORE, IgnoreBlocks and IgnoreLines contain the concrete ignore data.
 	Type         IgnoreType
 	Filename     string
 	IgnoreBlocks map[cover.ProfileBlock]*IgnoreBlock
-	Comments     string // comments about file ignore
-	Annotation   string // concrete ignore pattern
+	IgnoreLines  map[int]*IgnoreBlock // next-line and same-line ignores by the line of the statements they ignore
+	Comments     string               // comments about file ignore
+	Annotation   string               // concrete ignore pattern
+	Issue        *Issue               // issue that the file ignore is linked to, it's nil if there is none
 }
 
 // IgnoreBlock represents a single block of ignore profiling data.
@@ -56,10 +73,41 @@ type IgnoreBlock struct {
 	Contents             []string // ignore contents
 	Lines                []int    // corresponding code line number of the ignore contents
 	Comments             string   // comments about block ignore
+	Issue                *Issue   // issue that the block ignore is linked to, it's nil if there is none
+}
+
+// Issue is the tracker issue that an ignore annotation is linked to, such as the blocker of the tests,
+// so that the annotation is cleaned up when the issue is closed.
+type Issue struct {
+	Repository string // repository in {owner}/{repo} format
+	Number     int
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("%s#%d", i.Repository, i.Number)
+}
+
+// ParseIssue parses the issue of the comments of an ignore annotation, it returns nil if there is no issue,
+// and ErrWrongIssueFormat if the issue is not in issue={owner}/{repo}#{number} format.
+func ParseIssue(comments string) (*Issue, error) {
+	match := IssueRegexp.FindStringSubmatch(comments)
+	if match == nil {
+		return nil, nil
+	}
+	reference := issueReferenceRegexp.FindStringSubmatch(match[2])
+	if reference == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongIssueFormat, match[2])
+	}
+	number, err := strconv.Atoi(reference[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongIssueFormat, match[2])
+	}
+	return &Issue{Repository: reference[1], Number: number}, nil
 }
 
 // ParseIgnoreProfiles parses ignore profile data in the specified file with the help of go unit test cover profile,
 // and returns a ignore profile. The ProfileBlock in the cover profile is already sorted.
+// The short spellings of the annotations, `//gocover:ignore-next` and the trailing `//gocover:ignore`, are honored as well.
 func ParseIgnoreProfiles(fileName string, coverProfile *cover.Profile) (*IgnoreProfile, error) {
 	pf, err := os.Open(fileName)
 	if err != nil {
@@ -67,7 +115,7 @@ func ParseIgnoreProfiles(fileName string, coverProfile *cover.Profile) (*IgnoreP
 	}
 	defer pf.Close()
 
-	profile, err := parseIgnoreProfilesFromReader(pf, coverProfile)
+	profile, err := parseIgnoreProfilesFromReader(pf, coverProfile, translateAlias)
 	if err != nil {
 		return nil, fmt.Errorf("%w in %s", err, fileName)
 	}
@@ -76,7 +124,9 @@ func ParseIgnoreProfiles(fileName string, coverProfile *cover.Profile) (*IgnoreP
 }
 
 // parseIgnoreProfilesFromReader parses ignore profile data from the Reader and returns a ignore profile.
-func parseIgnoreProfilesFromReader(rd io.Reader, coverProfile *cover.Profile) (*IgnoreProfile, error) {
+// The lines are translated to the gocover annotations by translate before they're parsed if it's not nil,
+// the annotations keep the original lines.
+func parseIgnoreProfilesFromReader(rd io.Reader, coverProfile *cover.Profile, translate func(string) string) (*IgnoreProfile, error) {
 	s := bufio.NewScanner(rd)
 	s.Split(bufio.ScanLines)
 	var fileLines []string
@@ -87,6 +137,7 @@ func parseIgnoreProfilesFromReader(rd io.Reader, coverProfile *cover.Profile) (*
 	profile := &IgnoreProfile{
 		Type:         BLOCK_IGNORE,
 		IgnoreBlocks: make(map[cover.ProfileBlock]*IgnoreBlock),
+		IgnoreLines:  make(map[int]*IgnoreBlock),
 	}
 
 	sort.Sort(blocksByStart(coverProfile.Blocks))
@@ -94,7 +145,11 @@ func parseIgnoreProfilesFromReader(rd io.Reader, coverProfile *cover.Profile) (*
 	totalLines := len(fileLines)
 	i := 0
 	for i < totalLines {
-		ignoreKind, comments, err := parseIgnoreAnnotation(fileLines[i], i+1)
+		text := fileLines[i]
+		if translate != nil {
+			text = translate(text)
+		}
+		ignoreKind, comments, err := parseIgnoreAnnotation(text, i+1)
 		if err != nil {
 			return nil, err
 		}
@@ -109,12 +164,32 @@ func parseIgnoreProfilesFromReader(rd io.Reader, coverProfile *cover.Profile) (*
 			profile.Type = FILE_IGNORE
 			profile.Annotation = fileLines[i]
 			profile.Comments = comments
+			profile.Issue = parseIssue(comments)
 			profile.IgnoreBlocks = nil
+			profile.IgnoreLines = nil
 			break
 		} else if ignoreKind == "block" { // block
 			// ignoreOnBlock returns the endline of cover profile block
 			// as index of fileLines starts from 0, the endline is actually the next index that waiting handling.
 			i = ignoreOnBlock(fileLines, profile, coverProfile, i+1, fileLines[i], comments)
+		} else if ignoreKind == "next" {
+			if !strings.HasPrefix(strings.TrimSpace(fileLines[i]), "//") {
+				return nil, fmt.Errorf(
+					"%w for annotation '%s' at line %d, put the next-line annotation on its own line above the statement",
+					ErrWrongAnnotationFormat, fileLines[i], i+1,
+				)
+			}
+			ignoreOnLine(fileLines, profile, coverProfile, nextCodeLine(fileLines, i+1), i+1, fileLines[i], comments)
+			i++
+		} else if ignoreKind == "line" {
+			if strings.HasPrefix(strings.TrimSpace(fileLines[i]), "//") {
+				return nil, fmt.Errorf(
+					"%w for annotation '%s' at line %d, put the line annotation after the statement, or use +gocover:ignore:next on its own line",
+					ErrWrongAnnotationFormat, fileLines[i], i+1,
+				)
+			}
+			ignoreOnLine(fileLines, profile, coverProfile, i+1, i+1, fileLines[i], comments)
+			i++
 		} else {
 			//+gocover:ignore:block won't reach here
 			i++
@@ -160,7 +235,7 @@ func ignoreOnBlock(fileLines []string, profile *IgnoreProfile, coverProfile *cov
 	profileBlock := &coverProfile.Blocks[idx]
 
 	if _, ok := profile.IgnoreBlocks[*profileBlock]; !ok {
-		ignoreBlock := &IgnoreBlock{Annotation: patternText, Comments: comments, AnnotationLineNumber: patternLineNumber}
+		ignoreBlock := &IgnoreBlock{Annotation: patternText, Comments: comments, AnnotationLineNumber: patternLineNumber, Issue: parseIssue(comments)}
 
 		// Record the ignore code profile contents
 		for i := profileBlock.StartLine; i <= profileBlock.EndLine; i++ {
@@ -175,6 +250,39 @@ func ignoreOnBlock(fileLines []string, profile *IgnoreProfile, coverProfile *cov
 	return profileBlock.EndLine - 1
 }
 
+// ignoreOnLine records the statements of the line for the annotation, which is the first line of code after
+// the next-line annotation, or the line of the same-line annotation. Nothing is ignored if no cover profile block contains the line.
+func ignoreOnLine(fileLines []string, profile *IgnoreProfile, coverProfile *cover.Profile, line int, patternLineNumber int, patternText string, comments string) {
+	if line == 0 {
+		return
+	}
+	for _, b := range coverProfile.Blocks {
+		if b.StartLine <= line && line <= b.EndLine {
+			profile.IgnoreLines[line] = &IgnoreBlock{
+				Annotation:           patternText,
+				AnnotationLineNumber: patternLineNumber,
+				Contents:             []string{fileLines[line-1]},
+				Lines:                []int{line},
+				Comments:             comments,
+				Issue:                parseIssue(comments),
+			}
+			return
+		}
+	}
+}
+
+// nextCodeLine returns the number of the first line after the line that is neither blank nor a line comment,
+// the lines are numbered from 1. It returns 0 if there is no such line.
+func nextCodeLine(fileLines []string, lineNumber int) int {
+	for i := lineNumber; i < len(fileLines); i++ {
+		text := strings.TrimSpace(fileLines[i])
+		if text != "" && !strings.HasPrefix(text, "//") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
 func parseIgnoreAnnotation(line string, lineNumber int) (string, string, error) {
 	match := IgnoreRegexp.FindStringSubmatch(line)
 	// not match, continue next line
@@ -201,6 +309,16 @@ func parseIgnoreAnnotation(line string, lineNumber int) (string, string, error)
 	return kind, trimmedComments, nil
 }
 
+// parseIssue returns the issue of the comments, the malformed issue doesn't fail the conversion
+// and is reported by the annotation linter instead.
+func parseIssue(comments string) *Issue {
+	issue, err := ParseIssue(comments)
+	if err != nil {
+		return nil
+	}
+	return issue
+}
+
 type blocksByStart []cover.ProfileBlock
 
 func (b blocksByStart) Len() int      { return len(b) }
@@ -3,6 +3,7 @@ package annotation
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -190,4 +191,90 @@ func TestParseIgnoreProfiles(t *testing.T) {
 		assertion.Equal(ignoreProfile.Annotation, "//+gocover:ignore:file ignore this file")
 	})
 
+	t.Run("ignore next annotation", func(t *testing.T) {
+		src := `package foo
+
+func foo(x int) int {
+	if x > 0 {
+		return x
+	}
+	//+gocover:ignore:next issue=Azure/gocover#1 cannot be reached
+
+	panic("unreachable")
+}
+
+func bar() { //+gocover:ignore:next after the code
+}
+`
+		profile := &cover.Profile{Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 21, EndLine: 4, EndCol: 11, NumStmt: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1},
+			{StartLine: 9, StartCol: 2, EndLine: 9, EndCol: 22, NumStmt: 1},
+		}}
+		_, err := parseIgnoreProfilesFromReader(strings.NewReader(src), profile, nil)
+		assert.ErrorIs(t, err, ErrWrongAnnotationFormat)
+
+		ignoreProfile, err := parseIgnoreProfilesFromReader(strings.NewReader(strings.SplitAfter(src, "}\n\n")[0]), profile, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, BLOCK_IGNORE, ignoreProfile.Type)
+		assert.Empty(t, ignoreProfile.IgnoreBlocks)
+		assert.Equal(t, map[int]*IgnoreBlock{9: {
+			Annotation:           "\t//+gocover:ignore:next issue=Azure/gocover#1 cannot be reached",
+			AnnotationLineNumber: 7,
+			Contents:             []string{"\tpanic(\"unreachable\")"},
+			Lines:                []int{9},
+			Comments:             "issue=Azure/gocover#1 cannot be reached",
+			Issue:                &Issue{Repository: "Azure/gocover", Number: 1},
+		}}, ignoreProfile.IgnoreLines)
+	})
+
+	t.Run("ignore line annotation", func(t *testing.T) {
+		src := `package foo
+
+func foo(x int) int {
+	if x > 0 { //+gocover:ignore:line the condition only
+		return x
+	}
+	//+gocover:ignore:line on its own line
+	return 0
+}
+`
+		profile := &cover.Profile{Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 21, EndLine: 4, EndCol: 11, NumStmt: 1},
+			{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1},
+			{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 10, NumStmt: 1},
+		}}
+		_, err := parseIgnoreProfilesFromReader(strings.NewReader(src), profile, nil)
+		assert.ErrorIs(t, err, ErrWrongAnnotationFormat)
+
+		ignoreProfile, err := parseIgnoreProfilesFromReader(strings.NewReader(strings.SplitAfter(src, "}\n")[0]), profile, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, ignoreProfile.IgnoreBlocks)
+		assert.Len(t, ignoreProfile.IgnoreLines, 1)
+		assert.Equal(t, []int{4}, ignoreProfile.IgnoreLines[4].Lines)
+		assert.Equal(t, 4, ignoreProfile.IgnoreLines[4].AnnotationLineNumber)
+		assert.Equal(t, "the condition only", ignoreProfile.IgnoreLines[4].Comments)
+	})
+}
+
+func TestParseIssue(t *testing.T) {
+	for _, testCase := range []struct {
+		comments string
+		issue    *Issue
+		err      error
+	}{
+		{comments: "issue=Azure/gocover#123 blocked by the upstream fix", issue: &Issue{Repository: "Azure/gocover", Number: 123}},
+		{comments: "blocked by issue=foo/bar.go#7", issue: &Issue{Repository: "foo/bar.go", Number: 7}},
+		{comments: "no issue here"},
+		{comments: "reissue=foo/bar#1 is not an issue"},
+		{comments: "issue=gocover#123", err: ErrWrongIssueFormat},
+		{comments: "issue=foo/bar#abc", err: ErrWrongIssueFormat},
+		{comments: "issue=", err: ErrWrongIssueFormat},
+	} {
+		t.Run(testCase.comments, func(t *testing.T) {
+			issue, err := ParseIssue(testCase.comments)
+			assert.ErrorIs(t, err, testCase.err)
+			assert.Equal(t, testCase.issue, issue)
+		})
+	}
 }
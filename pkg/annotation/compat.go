@@ -0,0 +1,86 @@
+package annotation
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// CompatibleMarkers are the ignore markers of other coverage tools that are honored
+// as the gocover ignore annotations when the compatible markers are enabled.
+var CompatibleMarkers = []string{"//coverage:ignore", "// nocover", "//nolint:gocover"}
+
+// aliasRegexp matches the lines with the short spellings of the gocover annotations, `//gocover:ignore-next reason`
+// on its own line and `code() //gocover:ignore reason` after the code like `//nolint`.
+var aliasRegexp = regexp.MustCompile(`^(.*?)//\s*gocover:ignore(-next)?(\s.*)?$`)
+
+// compatibleMarkerRegexp matches the lines with a compatible marker, the marker follows `//` and optional spaces,
+// and the nolint marker is matched if gocover is one of its linters, such as `//nolint:errcheck,gocover`.
+var compatibleMarkerRegexp = regexp.MustCompile(`^(.*?)//\s*(coverage:ignore|nocover|nolint:(?:\S*,)?gocover(?:,\S*)?)(\s.*)?$`)
+
+// ParseCompatibleIgnoreProfiles parses ignore profile data like ParseIgnoreProfiles,
+// and it also honors the CompatibleMarkers as the gocover ignore annotations:
+// - the marker on its own line ignores the statement on the next line, like +gocover:ignore:next.
+// - the marker after the code that opens a block ignores the block, like +gocover:ignore:block.
+// - the marker after other code ignores the statement of the line, like +gocover:ignore:line.
+func ParseCompatibleIgnoreProfiles(fileName string, coverProfile *cover.Profile) (*IgnoreProfile, error) {
+	pf, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer pf.Close()
+
+	profile, err := parseIgnoreProfilesFromReader(pf, coverProfile, translateMarker)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, fileName)
+	}
+	profile.Filename = fileName
+	return profile, nil
+}
+
+// translateAlias returns the gocover ignore annotation of the line with a short spelling, the reason is kept
+// as the comments of the annotation. `//gocover:ignore-next` is the next-line annotation wherever it is, so it fails
+// after the code like +gocover:ignore:next, and `//gocover:ignore` is the line annotation after the code,
+// or the next-line annotation on its own line. It returns the line as it is if the line has no short spelling.
+func translateAlias(line string) string {
+	match := aliasRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return line
+	}
+
+	kind := LINE_IGNORE
+	if match[2] != "" || strings.TrimSpace(match[1]) == "" {
+		kind = NEXT_IGNORE
+	}
+	return fmt.Sprintf("%s//+gocover:ignore:%s %s", match[1], kind, strings.TrimSpace(match[3]))
+}
+
+// translateMarker returns the gocover ignore annotation of the line with a compatible marker or a short spelling,
+// the marker is kept as the comments of the annotation. It returns the line as it is
+// if the line has no compatible marker or is already a gocover annotation.
+func translateMarker(line string) string {
+	if IgnoreRegexp.MatchString(line) {
+		return line
+	}
+	if translated := translateAlias(line); translated != line {
+		return translated
+	}
+	match := compatibleMarkerRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return line
+	}
+
+	code := strings.TrimSpace(match[1])
+	kind := LINE_IGNORE
+	switch {
+	case code == "":
+		kind = NEXT_IGNORE
+	case strings.HasSuffix(code, "{"):
+		kind = BLOCK_IGNORE
+	}
+	comments := strings.TrimSpace(match[2] + match[3])
+	return fmt.Sprintf("%s//+gocover:ignore:%s %s", match[1], kind, comments)
+}
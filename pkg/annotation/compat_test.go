@@ -0,0 +1,117 @@
+package annotation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestTranslateMarker(t *testing.T) {
+	testSuites := []struct {
+		input  string
+		expect string
+	}{
+		{input: "	// nocover", expect: "	//+gocover:ignore:next nocover"},
+		{input: "	//coverage:ignore unreachable", expect: "	//+gocover:ignore:next coverage:ignore unreachable"},
+		{input: "	if err != nil { //nolint:gocover", expect: "	if err != nil { //+gocover:ignore:block nolint:gocover"},
+		{input: "	return x //nolint:errcheck,gocover // trivial", expect: "	return x //+gocover:ignore:line nolint:errcheck,gocover // trivial"},
+		{input: "	return x //nolint:errcheck", expect: "	return x //nolint:errcheck"},
+		{input: "	return x //nolint:gocovery", expect: "	return x //nolint:gocovery"},
+		{input: "	// nocoverage", expect: "	// nocoverage"},
+		{input: "	//+gocover:ignore:next nocover", expect: "	//+gocover:ignore:next nocover"},
+		{input: "	//gocover:ignore-next unreachable", expect: "	//+gocover:ignore:next unreachable"},
+	}
+
+	for _, testCase := range testSuites {
+		assert.Equal(t, testCase.expect, translateMarker(testCase.input), testCase.input)
+	}
+}
+
+func TestTranslateAlias(t *testing.T) {
+	testSuites := []struct {
+		input  string
+		expect string
+	}{
+		{input: "	//gocover:ignore-next unreachable", expect: "	//+gocover:ignore:next unreachable"},
+		{input: "	// gocover:ignore-next", expect: "	//+gocover:ignore:next "},
+		{input: "	panic(x) //gocover:ignore-next unreachable", expect: "	panic(x) //+gocover:ignore:next unreachable"},
+		{input: "	//gocover:ignore-nextline unreachable", expect: "	//gocover:ignore-nextline unreachable"},
+		{input: "	return x //gocover:ignore trivial", expect: "	return x //+gocover:ignore:line trivial"},
+		{input: "	//gocover:ignore unreachable", expect: "	//+gocover:ignore:next unreachable"},
+		{input: "	return x //gocover:ignored trivial", expect: "	return x //gocover:ignored trivial"},
+		{input: "	//+gocover:ignore:next unreachable", expect: "	//+gocover:ignore:next unreachable"},
+		{input: "	// nocover", expect: "	// nocover"},
+	}
+
+	for _, testCase := range testSuites {
+		assert.Equal(t, testCase.expect, translateAlias(testCase.input), testCase.input)
+	}
+}
+
+func TestParseCompatibleIgnoreProfiles(t *testing.T) {
+	src := `package foo
+
+func foo(x int) int {
+	if x > 0 { //coverage:ignore
+		return x
+	}
+	// nocover
+	return 0
+}
+`
+	profile := &cover.Profile{Blocks: []cover.ProfileBlock{
+		{StartLine: 3, StartCol: 21, EndLine: 4, EndCol: 11, NumStmt: 1},
+		{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1},
+		{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 10, NumStmt: 1},
+	}}
+
+	ignoreProfile, err := parseIgnoreProfilesFromReader(strings.NewReader(src), profile, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, ignoreProfile.IgnoreBlocks)
+	assert.Empty(t, ignoreProfile.IgnoreLines)
+
+	ignoreProfile, err = parseIgnoreProfilesFromReader(strings.NewReader(src), profile, translateMarker)
+	assert.NoError(t, err)
+	assert.Len(t, ignoreProfile.IgnoreBlocks, 1)
+	block := ignoreProfile.IgnoreBlocks[profile.Blocks[1]]
+	if assert.NotNil(t, block) {
+		assert.Equal(t, "	if x > 0 { //coverage:ignore", block.Annotation)
+		assert.Equal(t, "coverage:ignore", block.Comments)
+	}
+	assert.Len(t, ignoreProfile.IgnoreLines, 1)
+	if assert.NotNil(t, ignoreProfile.IgnoreLines[8]) {
+		assert.Equal(t, "	// nocover", ignoreProfile.IgnoreLines[8].Annotation)
+		assert.Equal(t, 7, ignoreProfile.IgnoreLines[8].AnnotationLineNumber)
+	}
+}
+
+func TestParseIgnoreProfilesAlias(t *testing.T) {
+	src := `package foo
+
+func foo(x int) int {
+	if x > 0 {
+		return x
+	}
+	//gocover:ignore-next unreachable
+	return 0
+}
+`
+	profile := &cover.Profile{Blocks: []cover.ProfileBlock{
+		{StartLine: 3, StartCol: 21, EndLine: 4, EndCol: 11, NumStmt: 1},
+		{StartLine: 4, StartCol: 11, EndLine: 6, EndCol: 3, NumStmt: 1},
+		{StartLine: 8, StartCol: 2, EndLine: 8, EndCol: 10, NumStmt: 1},
+	}}
+
+	ignoreProfile, err := parseIgnoreProfilesFromReader(strings.NewReader(src), profile, translateAlias)
+	assert.NoError(t, err)
+	assert.Len(t, ignoreProfile.IgnoreLines, 1)
+	if assert.NotNil(t, ignoreProfile.IgnoreLines[8]) {
+		assert.Equal(t, "	//gocover:ignore-next unreachable", ignoreProfile.IgnoreLines[8].Annotation)
+		assert.Equal(t, "unreachable", ignoreProfile.IgnoreLines[8].Comments)
+	}
+
+	_, err = parseIgnoreProfilesFromReader(strings.NewReader(strings.Replace(src, "unreachable", "", 1)), profile, translateAlias)
+	assert.ErrorIs(t, err, ErrCommentsRequired)
+}
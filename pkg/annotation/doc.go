@@ -1,10 +1,17 @@
 // Package annotation provides the utils for filtering.
 //
-// There are two kinds of ignore.
+// There are four kinds of ignore.
 // 1. Ignore the whole go file.
 //    `//+gocover:ignore:file`
 // 2. Ignore a go code block.
 //    `//+gocover:ignore:block`
+// 3. Ignore the statement on the next line of code, the annotation is on its own line.
+//    `//+gocover:ignore:next`
+// 4. Ignore the statements of the line, the annotation is after them on the same line.
+//    `//+gocover:ignore:line`
+//
+// The markers of other coverage tools in CompatibleMarkers are honored as the annotations
+// by ParseCompatibleIgnoreProfiles.
 //
 //   Code block concept comes from the go coverage profile, the detail can be found at
 //   https://cs.opensource.google/go/x/tools/+/master:cover/profile.go;drc=81efdbcac4736176ac97c60577b0069f76414c44;l=28
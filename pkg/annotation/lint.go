@@ -0,0 +1,218 @@
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The kinds of the problems of the annotations.
+const (
+	UnknownDirectiveProblem = "unknown directive"
+	ArgumentProblem         = "argument"
+	PositionProblem         = "position"
+)
+
+// directiveRegexp matches the gocover directive of a line comment, such as `//+gocover:ignore:block`.
+var directiveRegexp = regexp.MustCompile(`//\s*\+gocover:(\S*)`)
+
+// AnnotationProblem is a problem of an annotation found in a line of the go file.
+type AnnotationProblem struct {
+	Line    int
+	Kind    string
+	Message string
+}
+
+func (p *AnnotationProblem) String() string {
+	return fmt.Sprintf("%d: %s: %s", p.Line, p.Kind, p.Message)
+}
+
+// Lint checks the annotations of the go file for the problems that make them fail or ignore unexpected statements,
+// such as the unknown directives, the missing comments, the malformed issues, the block annotations out of any function body,
+// the next-line annotations that are not on their own lines or not followed by any statement,
+// the same-line annotations that are not after any statement,
+// and the annotations in the block comments or string literals which are still recognized.
+// The problems are sorted by line, the file is read if src is nil.
+func Lint(fileName string, src []byte) ([]*AnnotationProblem, error) {
+	if src == nil {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		src = data
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var bodies []*ast.BlockStmt
+	// statements are the lines that the statements start at.
+	statements := make(map[int]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if s, ok := n.(ast.Stmt); ok {
+			if _, ok := s.(*ast.BlockStmt); !ok {
+				statements[fset.Position(s.Pos()).Line] = true
+			}
+		}
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				bodies = append(bodies, n.Body)
+			}
+		case *ast.FuncLit:
+			bodies = append(bodies, n.Body)
+		}
+		return true
+	})
+	inBody := func(pos token.Pos) bool {
+		for _, body := range bodies {
+			if body.Lbrace < pos && pos < body.Rbrace {
+				return true
+			}
+		}
+		return false
+	}
+
+	sourceLines := strings.Split(string(src), "\n")
+	var problems []*AnnotationProblem
+	// commented are the lines of the annotations in the comments, the others are out of any comment.
+	commented := make(map[int]bool)
+	fileAnnotation := 0
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			line := fset.Position(c.Slash).Line
+			if strings.HasPrefix(c.Text, "/*") {
+				for i, text := range strings.Split(c.Text, "\n") {
+					if IgnoreRegexp.MatchString(text) {
+						commented[line+i] = true
+						problems = append(problems, &AnnotationProblem{
+							Line:    line + i,
+							Kind:    PositionProblem,
+							Message: "annotation in a block comment, use a line comment instead",
+						})
+					}
+				}
+				continue
+			}
+
+			match := directiveRegexp.FindStringSubmatch(c.Text)
+			if match == nil {
+				continue
+			}
+			commented[line] = true
+
+			if !IgnoreRegexp.MatchString(c.Text) {
+				problems = append(problems, &AnnotationProblem{
+					Line:    line,
+					Kind:    UnknownDirectiveProblem,
+					Message: fmt.Sprintf("+gocover:%s, use +gocover:ignore:file, +gocover:ignore:block, +gocover:ignore:next or +gocover:ignore:line", match[1]),
+				})
+				continue
+			}
+
+			kind, comments, err := parseIgnoreAnnotation(c.Text, line)
+			if err == nil {
+				_, err = ParseIssue(comments)
+			}
+			if err != nil {
+				problems = append(problems, &AnnotationProblem{Line: line, Kind: ArgumentProblem, Message: argumentMessage(err)})
+				continue
+			}
+
+			switch IgnoreType(kind) {
+			case FILE_IGNORE:
+				if fileAnnotation != 0 {
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: fmt.Sprintf("duplicated file annotation, the file is ignored at line %d already", fileAnnotation),
+					})
+					continue
+				}
+				fileAnnotation = line
+			case BLOCK_IGNORE:
+				if !inBody(c.Slash) {
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: "block annotation out of any function body, put it into the block to ignore",
+					})
+				}
+			case NEXT_IGNORE:
+				position := fset.Position(c.Slash)
+				switch {
+				case strings.TrimSpace(sourceLines[line-1][:position.Column-1]) != "":
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: "next-line annotation after the code, put it on its own line above the statement to ignore",
+					})
+				case !inBody(c.Slash):
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: "next-line annotation out of any function body, put it above the statement to ignore",
+					})
+				case !statements[nextCodeLine(sourceLines, line)]:
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: "next-line annotation without any statement on the next line of code",
+					})
+				}
+			case LINE_IGNORE:
+				position := fset.Position(c.Slash)
+				switch {
+				case strings.TrimSpace(sourceLines[line-1][:position.Column-1]) == "":
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: "line annotation on its own line, put it after the statement to ignore or use +gocover:ignore:next",
+					})
+				case !inBody(c.Slash) || !statements[line]:
+					problems = append(problems, &AnnotationProblem{
+						Line:    line,
+						Kind:    PositionProblem,
+						Message: "line annotation without any statement on the line",
+					})
+				}
+			}
+		}
+	}
+
+	for i, text := range sourceLines {
+		if IgnoreRegexp.MatchString(text) && !commented[i+1] {
+			problems = append(problems, &AnnotationProblem{
+				Line:    i + 1,
+				Kind:    PositionProblem,
+				Message: "annotation out of any comment, such as in a string literal, it's still recognized",
+			})
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// argumentMessage returns the message of the error of parsing the annotation without the line of it.
+func argumentMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrCommentsRequired):
+		return "comments required after the annotation"
+	case errors.Is(err, ErrWrongAnnotationFormat):
+		return "use at least one space to separate the annotation and the comments"
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,133 @@
+package annotation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("valid annotations", func(t *testing.T) {
+		problems, err := Lint("foo.go", []byte(`//+gocover:ignore:file generated code
+package foo
+
+func Foo(err error) error {
+	if err != nil {
+		//+gocover:ignore:block cannot be reached in tests
+		return err
+	}
+	go func() {
+		//+gocover:ignore:block background job
+		println()
+	}()
+	//+gocover:ignore:next defensive check
+
+	panic("unreachable")
+}
+`))
+		assert.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		problems, err := Lint("foo.go", []byte(`package foo
+
+//+gocover:ignore:block out of function
+//+gocover:ignore:func unknown directive
+
+/*
+//+gocover:ignore:file in a block comment
+*/
+
+const annotation = "//+gocover:ignore:file in a string"
+
+func Foo() {
+	//+gocover:ignore:block
+	//+gocover:ignore:block:comments
+	//+gocover:ignore:file first
+	//+gocover:ignore:file second
+}
+`))
+		assert.NoError(t, err)
+
+		var actual []string
+		for _, p := range problems {
+			actual = append(actual, p.String())
+		}
+		assert.Equal(t, []string{
+			"3: position: block annotation out of any function body, put it into the block to ignore",
+			"4: unknown directive: +gocover:ignore:func, use +gocover:ignore:file, +gocover:ignore:block, +gocover:ignore:next or +gocover:ignore:line",
+			"7: position: annotation in a block comment, use a line comment instead",
+			"10: position: annotation out of any comment, such as in a string literal, it's still recognized",
+			"13: argument: comments required after the annotation",
+			"14: argument: use at least one space to separate the annotation and the comments",
+			"16: position: duplicated file annotation, the file is ignored at line 15 already",
+		}, actual)
+	})
+
+	t.Run("issue", func(t *testing.T) {
+		problems, err := Lint("foo.go", []byte(`package foo
+
+func Foo() {
+	//+gocover:ignore:block issue=Azure/gocover#12 flaky upstream
+	println()
+	//+gocover:ignore:block issue=#12 flaky upstream
+	println()
+}
+`))
+		assert.NoError(t, err)
+		assert.Len(t, problems, 1)
+		assert.Equal(t, "6: argument: issue should be in {owner}/{repo}#{number} format: #12", problems[0].String())
+	})
+
+	t.Run("next-line annotations", func(t *testing.T) {
+		problems, err := Lint("foo.go", []byte(`package foo
+
+//+gocover:ignore:next out of function
+var x = 1
+
+func Foo() {
+	println() //+gocover:ignore:next after the code
+	println()
+	//+gocover:ignore:next nothing after it
+}
+`))
+		assert.NoError(t, err)
+
+		var actual []string
+		for _, p := range problems {
+			actual = append(actual, p.String())
+		}
+		assert.Equal(t, []string{
+			"3: position: next-line annotation out of any function body, put it above the statement to ignore",
+			"7: position: next-line annotation after the code, put it on its own line above the statement to ignore",
+			"9: position: next-line annotation without any statement on the next line of code",
+		}, actual)
+	})
+
+	t.Run("line annotations", func(t *testing.T) {
+		problems, err := Lint("foo.go", []byte(`package foo
+
+func Foo() {
+	println() //+gocover:ignore:line debug output
+	//+gocover:ignore:line on its own line
+	println()
+} //+gocover:ignore:line after the body
+`))
+		assert.NoError(t, err)
+
+		var actual []string
+		for _, p := range problems {
+			actual = append(actual, p.String())
+		}
+		assert.Equal(t, []string{
+			"5: position: line annotation on its own line, put it after the statement to ignore or use +gocover:ignore:next",
+			"7: position: line annotation without any statement on the line",
+		}, actual)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := Lint("foo.go", []byte("package foo\n\nfunc Foo() {\n"))
+		assert.Error(t, err)
+	})
+}
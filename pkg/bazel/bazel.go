@@ -0,0 +1,160 @@
+package bazel
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// Translator translates the file names of bazel to the file names of go.
+type Translator struct {
+	// Workspace is the absolute path of the bazel workspace.
+	Workspace string
+	// ModuleDir is the directory of the go module relative to the workspace.
+	ModuleDir string
+	// ModulePath is the module path declared in go.mod.
+	ModulePath string
+}
+
+// Translate returns the file name prefixed with the module path, it returns false if the file is not in the module,
+// such as the files of the external repositories. The file is translated in these steps:
+//
+//	/root/.cache/bazel/_bazel_root/{hash}/execroot/{workspace}/pkg/foo.go -> pkg/foo.go
+//	bazel-out/k8-fastbuild/bin/pkg/foo.go                                  -> pkg/foo.go
+//	{workspace}/pkg/foo.go                                                 -> pkg/foo.go
+//	pkg/foo.go                                                             -> {module path}/pkg/foo.go
+//
+// The file name that is already prefixed with the module path, such as the importpath of rules_go, is kept.
+func (t *Translator) Translate(file string) (string, bool) {
+	file = filepath.ToSlash(file)
+	if t.ModulePath != "" && strings.HasPrefix(file, t.ModulePath+"/") {
+		return file, true
+	}
+
+	if i := strings.LastIndex(file, "/execroot/"); i >= 0 {
+		// the first element after execroot is the name of the workspace.
+		rest := file[i+len("/execroot/"):]
+		j := strings.Index(rest, "/")
+		if j < 0 {
+			return "", false
+		}
+		file = rest[j+1:]
+	} else if workspace := filepath.ToSlash(t.Workspace); workspace != "" && strings.HasPrefix(file, workspace+"/") {
+		file = strings.TrimPrefix(file, workspace+"/")
+	}
+	file = strings.TrimPrefix(file, "./")
+	file = trimOutputDir(file)
+
+	if path.IsAbs(file) || strings.HasPrefix(file, "external/") {
+		return "", false
+	}
+
+	moduleDir := path.Clean(filepath.ToSlash(t.ModuleDir))
+	if moduleDir != "." {
+		if !strings.HasPrefix(file, moduleDir+"/") {
+			return "", false
+		}
+		file = strings.TrimPrefix(file, moduleDir+"/")
+	}
+	return path.Join(t.ModulePath, file), true
+}
+
+// trimOutputDir trims the output directory of bazel from the generated file, such as bazel-out/k8-fastbuild/bin/.
+func trimOutputDir(file string) string {
+	if !strings.HasPrefix(file, "bazel-out/") {
+		return file
+	}
+	elems := strings.SplitN(file, "/", 4)
+	if len(elems) == 4 && (elems[2] == "bin" || elems[2] == "genfiles") {
+		return elems[3]
+	}
+	return file
+}
+
+// Import reads the coverage data files in lcov or go format, and returns the cover profiles of the files in the module
+// sorted by file name, the profiles of the same file are merged. The files that are not in the module are returned as skipped.
+func Import(files []string, t *Translator) (profiles []*cover.Profile, skipped []string, err error) {
+	byName := make(map[string]*cover.Profile)
+	skip := make(map[string]bool)
+	for _, file := range files {
+		parsed, err := parseFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range parsed {
+			name, ok := t.Translate(p.FileName)
+			if !ok {
+				if !skip[p.FileName] {
+					skip[p.FileName] = true
+					skipped = append(skipped, p.FileName)
+				}
+				continue
+			}
+			m, ok := byName[name]
+			if !ok {
+				m = &cover.Profile{FileName: name, Mode: p.Mode}
+				byName[name] = m
+				profiles = append(profiles, m)
+			}
+			if m.Mode != p.Mode {
+				// the counts of set mode are 0 or 1, which are valid counts as well.
+				m.Mode = "count"
+			}
+			m.Blocks = append(m.Blocks, p.Blocks...)
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].FileName < profiles[j].FileName
+	})
+	return profiles, skipped, nil
+}
+
+// parseFile parses the coverage data file, it's in go format if it starts with the mode line, otherwise in lcov format.
+func parseFile(file string) ([]*cover.Profile, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read coverage data: %w", err)
+	}
+
+	var profiles []*cover.Profile
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("mode:")) {
+		profiles, err = cover.ParseProfilesFromReader(bytes.NewReader(data))
+	} else {
+		profiles, err = parseLcov(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse coverage data %s: %w", file, err)
+	}
+	return profiles, nil
+}
+
+// WriteProfiles writes the cover profiles in go format, the mode is count if the profiles have different modes.
+func WriteProfiles(w io.Writer, profiles []*cover.Profile) error {
+	mode := "count"
+	for i, p := range profiles {
+		if i == 0 {
+			mode = p.Mode
+		} else if p.Mode != mode {
+			mode = "count"
+			break
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "mode: %s\n", mode)
+	for _, p := range profiles {
+		for _, b := range p.Blocks {
+			fmt.Fprintf(bw, "%s:%d.%d,%d.%d %d %d\n", p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,136 @@
+package bazel
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestTranslate(t *testing.T) {
+	translator := &Translator{Workspace: "/home/user/repo", ModuleDir: "./", ModulePath: "github.com/foo/bar"}
+	for input, expected := range map[string]string{
+		"pkg/foo/foo.go":                    "github.com/foo/bar/pkg/foo/foo.go",
+		"./pkg/foo/foo.go":                  "github.com/foo/bar/pkg/foo/foo.go",
+		"main.go":                           "github.com/foo/bar/main.go",
+		"github.com/foo/bar/pkg/foo/foo.go": "github.com/foo/bar/pkg/foo/foo.go",
+		"/home/user/repo/pkg/foo/foo.go":    "github.com/foo/bar/pkg/foo/foo.go",
+		"/root/.cache/bazel/_bazel_root/0123/execroot/_main/pkg/foo/foo.go":                                "github.com/foo/bar/pkg/foo/foo.go",
+		"/root/.cache/bazel/_bazel_root/0123/sandbox/linux-sandbox/1/execroot/_main/pkg/foo/foo.go":        "github.com/foo/bar/pkg/foo/foo.go",
+		"bazel-out/k8-fastbuild/bin/pkg/foo/gen.go":                                                        "github.com/foo/bar/pkg/foo/gen.go",
+		"/root/.cache/bazel/_bazel_root/0123/execroot/_main/bazel-out/darwin-fastbuild/bin/pkg/foo/gen.go": "github.com/foo/bar/pkg/foo/gen.go",
+	} {
+		t.Run(input, func(t *testing.T) {
+			result, ok := translator.Translate(input)
+			assert.True(t, ok)
+			assert.Equal(t, expected, result)
+		})
+	}
+
+	for _, input := range []string{
+		"external/com_github_pkg_errors/errors.go",
+		"/root/.cache/bazel/_bazel_root/0123/execroot/_main/external/org_golang_x_sys/unix/syscall.go",
+		"/usr/local/go/src/fmt/print.go",
+		"/root/.cache/bazel/_bazel_root/0123/execroot",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, ok := translator.Translate(input)
+			assert.False(t, ok)
+		})
+	}
+
+	t.Run("module dir", func(t *testing.T) {
+		translator := &Translator{Workspace: "/home/user/repo", ModuleDir: "services/api", ModulePath: "github.com/foo/api"}
+		result, ok := translator.Translate("services/api/handler/handler.go")
+		assert.True(t, ok)
+		assert.Equal(t, "github.com/foo/api/handler/handler.go", result)
+
+		_, ok = translator.Translate("services/web/main.go")
+		assert.False(t, ok)
+	})
+}
+
+func TestParseLcov(t *testing.T) {
+	t.Run("records", func(t *testing.T) {
+		profiles, err := parseLcov(strings.NewReader(`TN:
+SF:pkg/foo/foo.go
+FN:3,Foo
+FNDA:1,Foo
+DA:3,1
+DA:4,0,checksum
+LH:1
+LF:2
+end_of_record
+SF:pkg/bar/bar.go
+DA:5,3
+end_of_record
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, []*cover.Profile{
+			{FileName: "pkg/foo/foo.go", Mode: "count", Blocks: []cover.ProfileBlock{
+				{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: lcovLineEndCol, NumStmt: 1, Count: 1},
+				{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: lcovLineEndCol, NumStmt: 1, Count: 0},
+			}},
+			{FileName: "pkg/bar/bar.go", Mode: "count", Blocks: []cover.ProfileBlock{
+				{StartLine: 5, StartCol: 1, EndLine: 5, EndCol: lcovLineEndCol, NumStmt: 1, Count: 3},
+			}},
+		}, profiles)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"DA before SF":  "DA:1,1\n",
+			"no count":      "SF:a.go\nDA:1\n",
+			"invalid line":  "SF:a.go\nDA:x,1\n",
+			"invalid count": "SF:a.go\nDA:1,-1\n",
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := parseLcov(strings.NewReader(content))
+				assert.ErrorIs(t, err, ErrInvalidLcov)
+			})
+		}
+	})
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	lcov := filepath.Join(dir, "_coverage_report.dat")
+	assert.NoError(t, os.WriteFile(lcov, []byte(`SF:pkg/foo/foo.go
+DA:3,1
+end_of_record
+SF:external/com_github_pkg_errors/errors.go
+DA:10,1
+end_of_record
+`), 0644))
+	profile := filepath.Join(dir, "coverage.dat")
+	assert.NoError(t, os.WriteFile(profile, []byte(`mode: set
+github.com/foo/bar/pkg/bar/bar.go:3.16,5.2 1 1
+github.com/foo/bar/pkg/foo/foo.go:4.1,4.10 1 0
+`), 0644))
+
+	profiles, skipped, err := Import([]string{lcov, profile}, &Translator{Workspace: dir, ModuleDir: ".", ModulePath: "github.com/foo/bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"external/com_github_pkg_errors/errors.go"}, skipped)
+	assert.Len(t, profiles, 2)
+	assert.Equal(t, "github.com/foo/bar/pkg/bar/bar.go", profiles[0].FileName)
+	assert.Equal(t, "set", profiles[0].Mode)
+	assert.Equal(t, "github.com/foo/bar/pkg/foo/foo.go", profiles[1].FileName)
+	// the profiles of the file in different modes are merged in count mode.
+	assert.Equal(t, "count", profiles[1].Mode)
+	assert.Len(t, profiles[1].Blocks, 2)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteProfiles(&buf, profiles))
+	written, err := cover.ParseProfilesFromReader(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, written, 2)
+	assert.Equal(t, "count", written[0].Mode)
+	assert.Equal(t, profiles[1].Blocks, written[1].Blocks)
+
+	_, _, err = Import([]string{filepath.Join(dir, "missing.dat")}, &Translator{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,8 @@
+// Package bazel imports the coverage data produced by `bazel coverage` for go targets,
+// so that it can be analyzed like the cover profiles of go test.
+//
+// The coverage data is either in lcov format, such as the combined report of --combined_report=lcov
+// and the coverage.dat of the tests of recent rules_go, or in the cover profile format of go.
+// The file names are translated from the paths of bazel, such as the paths in the exec root,
+// the output directories and the workspace, to the file names of go, which are prefixed with the module path.
+package bazel
@@ -0,0 +1,75 @@
+package bazel
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+var ErrInvalidLcov = errors.New("invalid lcov data")
+
+// lcovLineEndCol is the end column of the block of a line in lcov, which has the line numbers only,
+// so that the block covers every statement that starts in the line.
+const lcovLineEndCol = 1 << 20
+
+// parseLcov parses the lcov data into cover profiles in count mode, each executed line of a file is a block.
+// Only the source files and their line records are read, the functions and the branches are skipped.
+func parseLcov(r io.Reader) ([]*cover.Profile, error) {
+	var result []*cover.Profile
+	var current *cover.Profile
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			current = &cover.Profile{FileName: strings.TrimPrefix(line, "SF:"), Mode: "count"}
+			result = append(result, current)
+		case strings.HasPrefix(line, "DA:"):
+			if current == nil {
+				return nil, fmt.Errorf("%w: line %d: DA before SF", ErrInvalidLcov, n)
+			}
+			block, err := parseLcovLine(strings.TrimPrefix(line, "DA:"))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidLcov, n, err)
+			}
+			current.Blocks = append(current.Blocks, block)
+		case line == "end_of_record":
+			current = nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseLcovLine parses the DA record, which is {line},{count} with an optional checksum.
+func parseLcovLine(record string) (cover.ProfileBlock, error) {
+	fields := strings.Split(record, ",")
+	if len(fields) < 2 {
+		return cover.ProfileBlock{}, fmt.Errorf("DA:%s should be {line},{count}", record)
+	}
+	line, err := strconv.Atoi(fields[0])
+	if err != nil || line <= 0 {
+		return cover.ProfileBlock{}, fmt.Errorf("DA:%s has invalid line number", record)
+	}
+	count, err := strconv.Atoi(fields[1])
+	if err != nil || count < 0 {
+		return cover.ProfileBlock{}, fmt.Errorf("DA:%s has invalid count", record)
+	}
+	return cover.ProfileBlock{
+		StartLine: line,
+		StartCol:  1,
+		EndLine:   line,
+		EndCol:    lcovLineEndCol,
+		NumStmt:   1,
+		Count:     count,
+	}, nil
+}
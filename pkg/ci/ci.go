@@ -0,0 +1,182 @@
+package ci
+
+import (
+	"strings"
+)
+
+// Provider is the CI system that gocover runs in.
+type Provider string
+
+const (
+	GitHubActions  Provider = "github-actions"
+	GitLab         Provider = "gitlab"
+	AzurePipelines Provider = "azure-pipelines"
+	Bitbucket      Provider = "bitbucket"
+	Jenkins        Provider = "jenkins"
+	Buildkite      Provider = "buildkite"
+)
+
+// Environment contains the information of the CI run.
+type Environment struct {
+	// Provider is the CI system.
+	Provider Provider `json:"provider,omitempty"`
+	// BaseBranch is the target branch of the pull request, it's empty if the run is not for a pull request.
+	BaseBranch string `json:"baseBranch,omitempty"`
+	// Commit is the commit SHA that the run builds.
+	Commit string `json:"commit,omitempty"`
+	// Branch is the branch that the run builds, it's the source branch for a pull request.
+	Branch string `json:"branch,omitempty"`
+	// PullRequest is the number of the pull request, it's empty if the run is not for a pull request.
+	PullRequest string `json:"pullRequest,omitempty"`
+	// BuildID is the id of the run in the CI system.
+	BuildID string `json:"buildId,omitempty"`
+}
+
+// provider describes how to detect a CI system and read its environment variables.
+// For the values that have several environment variables, the first one that is set is used.
+type provider struct {
+	name Provider
+	// detect is the environment variable that is set in the CI system.
+	detect string
+	// baseBranch is the environment variable of the target branch of the pull request.
+	baseBranch  string
+	commit      []string
+	branch      []string
+	pullRequest []string
+	buildID     []string
+}
+
+var providers = []provider{
+	{
+		name:        GitHubActions,
+		detect:      "GITHUB_ACTIONS",
+		baseBranch:  "GITHUB_BASE_REF",
+		commit:      []string{"GITHUB_SHA"},
+		branch:      []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME"},
+		pullRequest: []string{"GITHUB_REF"},
+		buildID:     []string{"GITHUB_RUN_ID"},
+	},
+	{
+		name:        GitLab,
+		detect:      "GITLAB_CI",
+		baseBranch:  "CI_MERGE_REQUEST_TARGET_BRANCH_NAME",
+		commit:      []string{"CI_COMMIT_SHA"},
+		branch:      []string{"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_REF_NAME"},
+		pullRequest: []string{"CI_MERGE_REQUEST_IID"},
+		buildID:     []string{"CI_PIPELINE_ID"},
+	},
+	{
+		name:        AzurePipelines,
+		detect:      "TF_BUILD",
+		baseBranch:  "SYSTEM_PULLREQUEST_TARGETBRANCH",
+		commit:      []string{"BUILD_SOURCEVERSION"},
+		branch:      []string{"SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH"},
+		pullRequest: []string{"SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", "SYSTEM_PULLREQUEST_PULLREQUESTID"},
+		buildID:     []string{"BUILD_BUILDID"},
+	},
+	{
+		name:        Bitbucket,
+		detect:      "BITBUCKET_BUILD_NUMBER",
+		baseBranch:  "BITBUCKET_PR_DESTINATION_BRANCH",
+		commit:      []string{"BITBUCKET_COMMIT"},
+		branch:      []string{"BITBUCKET_BRANCH"},
+		pullRequest: []string{"BITBUCKET_PR_ID"},
+		buildID:     []string{"BITBUCKET_BUILD_NUMBER"},
+	},
+	{
+		name:        Jenkins,
+		detect:      "JENKINS_URL",
+		baseBranch:  "CHANGE_TARGET",
+		commit:      []string{"GIT_COMMIT"},
+		branch:      []string{"CHANGE_BRANCH", "BRANCH_NAME"},
+		pullRequest: []string{"CHANGE_ID"},
+		buildID:     []string{"BUILD_ID"},
+	},
+	{
+		name:        Buildkite,
+		detect:      "BUILDKITE",
+		baseBranch:  "BUILDKITE_PULL_REQUEST_BASE_BRANCH",
+		commit:      []string{"BUILDKITE_COMMIT"},
+		branch:      []string{"BUILDKITE_BRANCH"},
+		pullRequest: []string{"BUILDKITE_PULL_REQUEST"},
+		buildID:     []string{"BUILDKITE_BUILD_ID"},
+	},
+}
+
+// Detect detects the CI system from the environment variables that getenv returns, such as os.Getenv.
+// It returns nil if it doesn't run in a known CI system.
+func Detect(getenv func(string) string) *Environment {
+	for _, p := range providers {
+		if getenv(p.detect) == "" {
+			continue
+		}
+		return &Environment{
+			Provider:    p.name,
+			BaseBranch:  branchName(getenv(p.baseBranch)),
+			Commit:      lookup(getenv, p.commit),
+			Branch:      branchName(lookup(getenv, p.branch)),
+			PullRequest: pullRequestNumber(lookup(getenv, p.pullRequest)),
+			BuildID:     lookup(getenv, p.buildID),
+		}
+	}
+	return nil
+}
+
+// Merge returns the environment whose values are overridden by the non-empty values of override.
+// It returns nil if both of them are nil or empty, so that nothing is populated outside CI.
+func (e *Environment) Merge(override *Environment) *Environment {
+	result := &Environment{}
+	if e != nil {
+		*result = *e
+	}
+	if override != nil {
+		result.Provider = Provider(pick(string(override.Provider), string(result.Provider)))
+		result.BaseBranch = pick(override.BaseBranch, result.BaseBranch)
+		result.Commit = pick(override.Commit, result.Commit)
+		result.Branch = pick(override.Branch, result.Branch)
+		result.PullRequest = pick(override.PullRequest, result.PullRequest)
+		result.BuildID = pick(override.BuildID, result.BuildID)
+	}
+	if *result == (Environment{}) {
+		return nil
+	}
+	return result
+}
+
+func pick(override, value string) string {
+	if override != "" {
+		return override
+	}
+	return value
+}
+
+// lookup returns the value of the first environment variable that is set.
+func lookup(getenv func(string) string, keys []string) string {
+	for _, key := range keys {
+		if v := getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// branchName trims the prefix of the branch reference, such as "refs/heads/main" in Azure Pipelines.
+func branchName(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// pullRequestNumber returns the number of the pull request. GitHub Actions only has the number
+// in the reference "refs/pull/{number}/merge", which is another reference when it's not a pull request,
+// and Buildkite sets "false" when it's not a pull request.
+func pullRequestNumber(v string) string {
+	if v == "false" {
+		return ""
+	}
+	if !strings.HasPrefix(v, "refs/") {
+		return v
+	}
+	if tokens := strings.Split(v, "/"); len(tokens) >= 3 && tokens[1] == "pull" {
+		return tokens[2]
+	}
+	return ""
+}
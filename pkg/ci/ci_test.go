@@ -0,0 +1,97 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	getenv := func(env map[string]string) func(string) string {
+		return func(key string) string { return env[key] }
+	}
+
+	t.Run("not in CI", func(t *testing.T) {
+		assert.Nil(t, Detect(getenv(nil)))
+	})
+
+	t.Run("pull request", func(t *testing.T) {
+		testSuites := []struct {
+			env      map[string]string
+			expected *Environment
+		}{
+			{
+				env:      map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_BASE_REF": "main"},
+				expected: &Environment{Provider: GitHubActions, BaseBranch: "main"},
+			},
+			{
+				env:      map[string]string{"GITLAB_CI": "true", "CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "develop"},
+				expected: &Environment{Provider: GitLab, BaseBranch: "develop"},
+			},
+			{
+				env:      map[string]string{"TF_BUILD": "True", "SYSTEM_PULLREQUEST_TARGETBRANCH": "refs/heads/release/v1"},
+				expected: &Environment{Provider: AzurePipelines, BaseBranch: "release/v1"},
+			},
+			{
+				env:      map[string]string{"BITBUCKET_BUILD_NUMBER": "12", "BITBUCKET_PR_DESTINATION_BRANCH": "master"},
+				expected: &Environment{Provider: Bitbucket, BaseBranch: "master", BuildID: "12"},
+			},
+			{
+				env:      map[string]string{"JENKINS_URL": "https://jenkins", "CHANGE_TARGET": "master"},
+				expected: &Environment{Provider: Jenkins, BaseBranch: "master"},
+			},
+			{
+				env:      map[string]string{"BUILDKITE": "true", "BUILDKITE_PULL_REQUEST_BASE_BRANCH": "main"},
+				expected: &Environment{Provider: Buildkite, BaseBranch: "main"},
+			},
+		}
+
+		for _, testCase := range testSuites {
+			assert.Equal(t, testCase.expected, Detect(getenv(testCase.env)))
+		}
+	})
+
+	t.Run("not a pull request", func(t *testing.T) {
+		env := Detect(getenv(map[string]string{"GITHUB_ACTIONS": "true"}))
+		assert.Equal(t, &Environment{Provider: GitHubActions}, env)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		env := Detect(getenv(map[string]string{
+			"GITHUB_ACTIONS":  "true",
+			"GITHUB_SHA":      "0123456789",
+			"GITHUB_HEAD_REF": "feature",
+			"GITHUB_REF_NAME": "12/merge",
+			"GITHUB_REF":      "refs/pull/12/merge",
+			"GITHUB_RUN_ID":   "345",
+		}))
+		assert.Equal(t, "0123456789", env.Commit)
+		assert.Equal(t, "feature", env.Branch)
+		assert.Equal(t, "12", env.PullRequest)
+		assert.Equal(t, "345", env.BuildID)
+
+		env = Detect(getenv(map[string]string{
+			"TF_BUILD":           "True",
+			"BUILD_SOURCEBRANCH": "refs/heads/main",
+		}))
+		assert.Equal(t, "main", env.Branch)
+
+		env = Detect(getenv(map[string]string{"BUILDKITE": "true", "BUILDKITE_PULL_REQUEST": "false"}))
+		assert.Equal(t, "", env.PullRequest)
+	})
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("override the detected values", func(t *testing.T) {
+		env := &Environment{Provider: GitLab, Commit: "0123456789", BuildID: "345"}
+		merged := env.Merge(&Environment{Commit: "abcdef", PullRequest: "12"})
+		assert.Equal(t, &Environment{Provider: GitLab, Commit: "abcdef", PullRequest: "12", BuildID: "345"}, merged)
+		assert.Equal(t, "0123456789", env.Commit)
+	})
+
+	t.Run("not in CI", func(t *testing.T) {
+		var env *Environment
+		assert.Nil(t, env.Merge(&Environment{}))
+		assert.Equal(t, &Environment{BuildID: "345"}, env.Merge(&Environment{BuildID: "345"}))
+	})
+}
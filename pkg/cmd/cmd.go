@@ -2,11 +2,24 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/config"
 	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/gittool"
 	"github.com/Azure/gocover/pkg/gocover"
+	"github.com/Azure/gocover/pkg/httpclient"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/retry"
+	"github.com/Azure/gocover/pkg/webhook"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -63,41 +76,600 @@ gocover test --coverage-mode diff --compare-branch=origin/master --outputdir /tm
 
 # Run unit tests and generate full coverage result on the whole module.
 gocover test --coverage-mode full --outputdir /tmp
+`
+
+	impactLong = `Analyze which tests cover the changed statements.
+
+Run each test of the module separately, and map the tests to the changed statements they cover,
+so that developers know which tests to extend for the uncovered changes.
+`
+
+	impactExample = `# Run each test and map the tests to the changed statements compared with origin/master.
+gocover impact --compare-branch=origin/master --outputdir /tmp
+
+# Use the cover profiles that already generated for each test.
+gocover impact --compare-branch=origin/master --test-profile TestFoo=foo.out --test-profile TestBar=bar.out
+`
+
+	flakyLong = `Detect the statements whose coverage differs between repeated runs.
+
+Given several cover profiles from repeated runs of the same commit, report the statements that are covered
+in some runs but not in others. Such statements usually depend on time or randomness, and make the diff coverage flaky.
+`
+
+	flakyExample = `# Detect flaky coverage over all the statements.
+gocover flaky --cover-profile run1.out --cover-profile run2.out --cover-profile run3.out
+
+# Detect flaky coverage over the changed statements, and return an error code if any is found.
+gocover flaky --cover-profile run1.out --cover-profile run2.out --compare-branch=origin/master --fail-on-flaky
+`
+
+	aggregateLong = `Roll up the coverage results of several repositories.
+
+The coverage results are the history records generated by the full command with --history-dir flag,
+a history directory can be used instead of a record file, and the latest full coverage record is used.
+Each result can be labeled with the team that owns the repository, to report the coverage per team.
+`
+
+	aggregateExample = `# Roll up the latest coverage of the repositories and report the coverage per team.
+gocover aggregate --result platform=service-a/.gocover/history --result platform=service-b/.gocover/history --result data=pipeline.json --outputdir /tmp
+`
+
+	lspLong = `Start a language server that publishes diagnostics for the uncovered changed lines.
+
+The server communicates with the editor over stdin and stdout. The diagnostics are calculated
+from the cover profiles when the editor is initialized, and each time a file is opened or saved,
+so that the editor shows the coverage gaps of the latest cover profiles inline.
+`
+
+	lspExample = `# Start the language server with the cover profile that is regenerated while developing.
+gocover lsp --cover-profile coverage.out --compare-branch origin/master
+`
+
+	showLong = `Print a source file to the terminal with the coverage state of each line.
+
+Each line is marked and colored by its state: ✓ covered in green, ✗ uncovered in red, ◐ partially covered in yellow
+and ○ ignored in gray. With --changed flag, only the changed hunks of the file are printed, and the states are of diff coverage.
+The colors are disabled with --no-color flag or NO_COLOR environment variable.
+`
+
+	showExample = `# Print the file with its full coverage.
+gocover show pkg/foo/foo.go --cover-profile coverage.out
+
+# Print the changed hunks of the file compared with origin/master.
+gocover show pkg/foo/foo.go --cover-profile coverage.out --changed --compare-branch origin/master
+`
+
+	historyLong = `Query the coverage results in the history directory.
+
+The history records are stored by the full command with --history-dir flag, they are filtered by
+module, branch, coverage mode and date range, and printed as a table or exported in json or csv format.
+With --package flag, the coverage of the package is reported instead of the total coverage.
+`
+
+	historyExample = `# Print the full coverage of pkg/x and its sub packages on main branch since May.
+gocover history --history-dir .gocover/history --branch main --coverage-mode full --since 2024-05-01 --package pkg/x/...
+
+# Export the results of May in csv format.
+gocover history --history-dir .gocover/history --since 2024-05-01 --until 2024-05-31 --format csv --output history.csv
+`
+
+	serveLong = `Serve the latest coverage in the history directory as OpenMetrics at /metrics.
+
+The history records are read on each scrape, so the runs stored by the full command with --history-dir flag
+are exposed without restart. The gauges are of the latest run of each module, coverage mode and run labels,
+the total coverage is followed by the coverage of each package, and the run labels are added as metric labels.
+`
+
+	serveExample = `# Serve the coverage of main branch for Prometheus to scrape.
+gocover serve --history-dir .gocover/history --branch main --address :9184
+`
+
+	initLong = `Inspect the repository and write a starter .gocover.yaml to its root, then print a CI snippet that runs gocover.
+
+The modules are found by their go.mod files, the CI provider by the CI files of the repository such as .github/workflows,
+and the existing coverage commands by the go test commands with -coverprofile in the CI files and the build scripts.
+The build tags of the test harnesses, such as integration and e2e, are excluded in the configuration.
+The configuration file that exists is kept unless --force flag is set.
+`
+
+	initExample = `# Scaffold the configuration of the repository in the working directory.
+gocover init
+
+# Scaffold the configuration with the snippet of GitLab CI, overwriting the configuration file that exists.
+gocover init --repository-path ./app --ci gitlab --force
+`
+
+	doctorLong = `Check the environment that the full and diff commands depend on, and print the fix of each problem.
+
+The checks are the git repository and whether it's a shallow clone, the compare branch and its common ancestor with HEAD,
+the cover profiles and the packages of the sampled files in them, the db clients when --data-collection-enabled is set,
+and the scopes of GITHUB_TOKEN. The checks whose inputs are not set are skipped, and the command fails if any check fails.
+`
+
+	doctorExample = `# Check the environment of the diff coverage in CI.
+gocover doctor --cover-profile coverage.out --compare-branch origin/main
+
+# Check the kusto db client as well.
+gocover doctor --cover-profile coverage.out --data-collection-enabled --store-type Kusto --endpoint https://example.kusto.windows.net --database gocover
+`
+
+	bazelLong = `Import the coverage data produced by bazel coverage for go targets into a go cover profile.
+
+The coverage data is in lcov format, such as the combined report of --combined_report=lcov, or in go format.
+The file names are translated from the paths of the exec root, the output directories and the workspace
+to the file names prefixed with the module path, the files out of the module such as external repositories are skipped.
+The cover profile is analyzed by the full and diff commands, lcov has no columns so that the statements are covered by lines.
+`
+
+	bazelExample = `# Import the combined report and check the diff coverage.
+bazel coverage --combined_report=lcov //...
+gocover bazel --coverage-report bazel-out/_coverage/_coverage_report.dat --output coverage.out
+gocover diff --cover-profile coverage.out --compare-branch origin/master
+`
+
+	validateLong = `Check the cover profiles for the problems before they fail or skew a gating run.
+
+The malformed lines, the mode lines that differ, the blocks that overlap and the files that cannot be found
+are printed with their line numbers as {profile}:{line}: {kind}: {message}, and it returns exit code 14 if there is any problem.
+The files of the module are found under the module directory, the other files are found by importing their packages.
+`
+
+	validateExample = `# Check the cover profile before the diff coverage is calculated.
+gocover validate coverage.out && gocover diff --cover-profile coverage.out
+`
+
+	lintAnnotationsLong = `Check the ignore annotations of the go files for the problems before they fail or skew the coverage.
+
+The unknown directives such as //+gocover:ignore:func, the annotations without comments or the separating space,
+the block annotations out of any function body, the next-line annotations that are not on their own lines or not followed
+by any statement, the line annotations that are not after any statement, the duplicated file annotations and the annotations in the block comments or string literals,
+which are still recognized, are printed as {file}:{line}: {kind}: {message}.
+It returns exit code 15 if there is any problem. The vendor and testdata directories and the ones that start with "." or "_" are skipped.
+`
+
+	lintAnnotationsExample = `# Check the annotations of the module.
+gocover lint-annotations ./
+
+# Check the annotations of the packages.
+gocover lint-annotations pkg/foo pkg/bar/bar.go
+`
+
+	scaffoldLong = `Generate the table test skeletons of the functions that are not fully covered.
+
+Each source file with uncovered functions gets a {file}_scaffold_test.go next to it, which has a table test of each function
+with the parameters, the receiver and the wanted results as the fields of the test cases, so only the cases are left to fill in.
+With --changed flag, only the uncovered functions that are changed compared with the compare branch are scaffolded.
+The generic functions, init, main and the functions whose tests exist are skipped, and the skeleton files that exist are kept.
+`
+
+	scaffoldExample = `# Write the test skeletons of the uncovered functions.
+gocover scaffold --cover-profile coverage.out
+
+# Print the test skeletons of the uncovered functions changed since origin/master.
+gocover scaffold --cover-profile coverage.out --changed --compare-branch origin/master --print
 `
 )
 
 var (
 	dbOption         = &dbclient.DBOption{}
+	ciOverride       = &ci.Environment{}
+	githubOverride   = &github.ClientOption{}
+	httpOption       = &httpclient.Option{}
+	httpClient       = http.DefaultClient
+	caBundle         []byte
+	runLabelFlags    map[string]string
+	webhookURLs      []string
 	timeoutInSeconds int
+	configFile       string
+	storeTypes       []string
+	toolVersion      string
+	optionalStores   []string
+	fileConfig       = &config.Config{}
+	retryPolicy      retry.Policy
 )
 
 const (
 	FlagVerbose             = "verbose"
 	FlagVerboseShort        = "v"
+	FlagLogFormat           = "log-format"
+	FlagLogLevel            = "log-level"
+	FlagConfig              = "config"
+	FlagRetryMaxAttempts    = "retry-max-attempts"
+	FlagRetryInitialBackoff = "retry-initial-backoff"
+	FlagRetryMaxBackoff     = "retry-max-backoff"
+	FlagRetryJitter         = "retry-jitter"
+	FlagPathCase            = "path-case"
+	FlagGitHubAPIURL        = "github-api-url"
+	FlagGitHubServerURL     = "github-server-url"
+	FlagGitHubAPIVersion    = "github-api-version"
+	FlagProxy               = "proxy"
+	FlagCABundle            = "ca-bundle"
+	FlagClientCert          = "client-cert"
+	FlagClientKey           = "client-key"
 	defaultTimeoutInSeconds = 60 * 60 // 3600 seconds, 1 hour
 )
 
-func createLogger(cmd *cobra.Command) *logrus.Logger {
+const (
+	FlagKustoAuth                 = "kusto-auth"
+	FlagManagedIdentityResourceID = "managed-identity-resource-id"
+	FlagEndpoint                  = "endpoint"
+	FlagDatabase                  = "database"
+	FlagCoverageEvent             = "coverage-event"
+	FlagIgnoreEvent               = "ignore-event"
+	FlagStoreType                 = "store-type"
+	FlagStoreOptional             = "store-optional"
+	FlagStoreDir                  = "store-dir"
+)
+
+const (
+	FlagExcludeFunctions  = "exclude-functions"
+	FlagExcludeBuildTags  = "exclude-build-tags"
+	FlagProgress          = "progress"
+	FlagMixedCoverModes   = "mixed-covermodes"
+	FlagCompatibleMarkers = "compatible-ignore-markers"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+var ErrUnknownLogFormat = errors.New("unknown log format")
+
+// validateLogFlags checks the log format and the log level before any command runs.
+func validateLogFlags(cmd *cobra.Command) error {
+	format, _ := cmd.Flags().GetString(FlagLogFormat)
+	if format != logFormatText && format != logFormatJSON {
+		return fmt.Errorf("%w: %s, should be %s or %s", ErrUnknownLogFormat, format, logFormatText, logFormatJSON)
+	}
+	level, _ := cmd.Flags().GetString(FlagLogLevel)
+	if _, err := logrus.ParseLevel(level); err != nil {
+		return err
+	}
+	return nil
+}
+
+// loadRetryPolicy builds the retry policy of the external clients, the flags override the configuration file.
+func loadRetryPolicy(cmd *cobra.Command, c *config.Config) (retry.Policy, error) {
+	policy := retry.DefaultPolicy()
+	c.Retry.Apply(&policy)
+
+	flags := cmd.Flags()
+	if flags.Changed(FlagRetryMaxAttempts) {
+		policy.MaxAttempts, _ = flags.GetInt(FlagRetryMaxAttempts)
+	}
+	if flags.Changed(FlagRetryInitialBackoff) {
+		policy.InitialBackoff, _ = flags.GetDuration(FlagRetryInitialBackoff)
+	}
+	if flags.Changed(FlagRetryMaxBackoff) {
+		policy.MaxBackoff, _ = flags.GetDuration(FlagRetryMaxBackoff)
+	}
+	if flags.Changed(FlagRetryJitter) {
+		policy.Jitter, _ = flags.GetFloat64(FlagRetryJitter)
+	}
+	return policy, policy.Validate()
+}
+
+// applyKustoConfig sets the kusto options that are not passed as flags from the configuration file.
+func applyKustoConfig(cmd *cobra.Command, c *config.Config) {
+	o := &dbOption.KustoOption
+	if !cmd.Flags().Changed(FlagKustoAuth) && c.Kusto.Auth != "" {
+		o.AuthMethod = dbclient.AuthMethod(c.Kusto.Auth)
+	}
+	if !cmd.Flags().Changed(FlagManagedIdentityResourceID) && c.Kusto.ManagedIdentityResourceID != "" {
+		o.ManagedIdentityResouceID = c.Kusto.ManagedIdentityResourceID
+	}
+	for flag, value := range map[string]struct {
+		option *string
+		config string
+	}{
+		FlagEndpoint:      {&o.Endpoint, c.Kusto.Endpoint},
+		FlagDatabase:      {&o.Database, c.Kusto.Database},
+		FlagCoverageEvent: {&o.CoverageEvent, c.Kusto.CoverageTable},
+		FlagIgnoreEvent:   {&o.IgnoreEvent, c.Kusto.IgnoreTable},
+	} {
+		if !cmd.Flags().Changed(flag) && value.config != "" {
+			*value.option = value.config
+		}
+	}
+	o.ColumnNames = mergeColumns(c.Kusto.Columns, o.ColumnNames)
+	o.StaticColumns = mergeColumns(c.Kusto.StaticColumns, o.StaticColumns)
+}
+
+// applyStoreConfig sets the db types and the options of the db clients from the flags and the configuration file.
+func applyStoreConfig(cmd *cobra.Command, c *config.Config) {
+	if !cmd.Flags().Changed(FlagStoreType) && len(c.Store.Types) != 0 {
+		storeTypes = c.Store.Types
+	}
+	if !cmd.Flags().Changed(FlagStoreOptional) && len(c.Store.Optional) != 0 {
+		optionalStores = c.Store.Optional
+	}
+	if !cmd.Flags().Changed(FlagStoreDir) && c.Store.Dir != "" {
+		dbOption.FileOption.Dir = c.Store.Dir
+	}
+
+	dbOption.DbTypes, dbOption.OptionalDbTypes = nil, nil
+	for _, t := range storeTypes {
+		dbOption.DbTypes = append(dbOption.DbTypes, dbclient.ClientType(t))
+	}
+	for _, t := range optionalStores {
+		dbOption.OptionalDbTypes = append(dbOption.OptionalDbTypes, dbclient.ClientType(t))
+	}
+}
+
+// applyExcludeConfig sets the excluded functions and build tags from the configuration file if they are not passed as flags.
+func applyExcludeConfig(cmd *cobra.Command, functions *[]string, buildTags *[]string) {
+	if !cmd.Flags().Changed(FlagExcludeFunctions) && len(fileConfig.Exclude.Functions) != 0 {
+		*functions = fileConfig.Exclude.Functions
+	}
+	if !cmd.Flags().Changed(FlagExcludeBuildTags) && len(fileConfig.Exclude.BuildTags) != 0 {
+		*buildTags = fileConfig.Exclude.BuildTags
+	}
+}
+
+// applyMixedCoverModesConfig sets how the cover profiles of different modes are combined from the configuration file
+// if it's not passed as a flag.
+func applyMixedCoverModesConfig(cmd *cobra.Command, mixedModes *string) {
+	if !cmd.Flags().Changed(FlagMixedCoverModes) && fileConfig.MixedCoverModes != "" {
+		*mixedModes = fileConfig.MixedCoverModes
+	}
+}
+
+// applyCompatibleMarkersConfig honors the ignore markers of other coverage tools if the configuration file enables them
+// and the flag is not passed.
+func applyCompatibleMarkersConfig(cmd *cobra.Command, compatible *bool) {
+	if !cmd.Flags().Changed(FlagCompatibleMarkers) && fileConfig.CompatibleIgnoreMarkers {
+		*compatible = true
+	}
+}
+
+// progressBar returns the progress bar of the conversion on the standard error if the progress flag is set,
+// it returns nil otherwise.
+func progressBar(cmd *cobra.Command) parser.ProgressFunc {
+	if progress, _ := cmd.Flags().GetBool(FlagProgress); !progress {
+		return nil
+	}
+	return gocover.NewProgressBar(cmd.ErrOrStderr())
+}
+
+// mergeColumns merges the columns of the configuration file and the flags, the flags take precedence.
+func mergeColumns(config map[string]string, flags map[string]string) map[string]string {
+	if len(config) == 0 {
+		return flags
+	}
+	merged := make(map[string]string, len(config)+len(flags))
+	for k, v := range config {
+		merged[k] = v
+	}
+	for k, v := range flags {
+		merged[k] = v
+	}
+	return merged
+}
+
+// createLogger creates the logger of the command. When the log format is json,
+// every entry carries the run-scoped fields: repository, branch, commit and profile paths.
+func createLogger(cmd *cobra.Command, repositoryPath string, profiles []string) logrus.FieldLogger {
 	logger := logrus.New()
+
+	if level, err := cmd.Flags().GetString(FlagLogLevel); err == nil {
+		if l, err := logrus.ParseLevel(level); err == nil {
+			logger.SetLevel(l)
+		}
+	}
 	verbose, err := cmd.Flags().GetBool(FlagVerbose)
 	if err != nil {
 		// no verbose flag on the command, It's OK.
 		verbose = false
 	}
-	if verbose {
+	// an explicit log level takes precedence over --verbose, such as --log-level trace.
+	if verbose && !cmd.Flags().Changed(FlagLogLevel) {
 		logger.SetLevel(logrus.DebugLevel)
 	}
-	return logger
+
+	format, _ := cmd.Flags().GetString(FlagLogFormat)
+	if format != logFormatJSON {
+		return logger
+	}
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	return logger.WithFields(runFields(repositoryPath, profiles))
+}
+
+// runFields returns the fields that identify the run, the git fields are omitted
+// when the repository path is not a git repository.
+func runFields(repositoryPath string, profiles []string) logrus.Fields {
+	fields := logrus.Fields{}
+	if len(profiles) != 0 {
+		fields["profiles"] = profiles
+	}
+	if repositoryPath == "" {
+		return fields
+	}
+
+	if abs, err := filepath.Abs(repositoryPath); err == nil {
+		repositoryPath = abs
+	}
+	fields["repo"] = repositoryPath
+
+	gitClient, err := gittool.NewGitClient(repositoryPath)
+	if err != nil {
+		return fields
+	}
+	commit, branch, err := gitClient.HeadCommit()
+	if err != nil {
+		return fields
+	}
+	fields["commit"] = commit
+	if branch != "" {
+		fields["branch"] = branch
+	}
+	return fields
+}
+
+// detectCompareBranch sets the compare branch to the target branch of the pull request
+// when it runs in CI and the compare branch is not provided.
+func detectCompareBranch(cmd *cobra.Command, compareBranch *string, remote string, logger logrus.FieldLogger) {
+	if cmd.Flags().Changed("compare-branch") {
+		return
+	}
+	env := ci.Detect(os.Getenv)
+	if env == nil || env.BaseBranch == "" {
+		return
+	}
+	if remote == "" {
+		remote = gocover.DefaultFetchRemote
+	}
+	*compareBranch = remote + "/" + env.BaseBranch
+	logger.Infof("compare with %s, which is the target branch of the pull request in %s", *compareBranch, env.Provider)
+}
+
+// The environment variables of the secrets of the fetch credentials, which are not passed as flags to keep them out of the logs.
+const (
+	fetchTokenEnv         = "GOCOVER_FETCH_TOKEN"
+	fetchSSHPassphraseEnv = "GOCOVER_FETCH_SSH_PASSPHRASE"
+)
+
+// addFetchAuthFlags adds the flags of the credentials to fetch the compare branch from the remote.
+func addFetchAuthFlags(cmd *cobra.Command, auth *gittool.FetchAuth) {
+	cmd.Flags().StringVar(&auth.Username, "fetch-username", "", "username of the "+fetchTokenEnv+" token to fetch from an https remote, default is "+gittool.DefaultTokenUsername+", such as oauth2 for gitlab")
+	cmd.Flags().StringVar(&auth.Netrc, "fetch-netrc", "", "netrc file whose login and password of the remote host are used to fetch from an https remote when "+fetchTokenEnv+" is not set, such as ~/.netrc")
+	cmd.Flags().StringVar(&auth.SSHKey, "fetch-ssh-key", "", "private key file to fetch from an ssh remote instead of the ssh agent, it's decrypted with "+fetchSSHPassphraseEnv+" if it's set")
+}
+
+// fetchAuth returns the credentials to fetch from the remote, the secrets are read from the environment variables.
+func fetchAuth(auth *gittool.FetchAuth) *gittool.FetchAuth {
+	auth.Token = os.Getenv(fetchTokenEnv)
+	auth.SSHKeyPassphrase = os.Getenv(fetchSSHPassphraseEnv)
+	auth.CABundle = caBundle
+	auth.Proxy = httpOption.Proxy
+	return auth
+}
+
+// detectCI returns the information of the CI run that is detected from the environment variables,
+// which is overridden by the ci flags.
+func detectCI() *ci.Environment {
+	return ci.Detect(os.Getenv).Merge(ciOverride)
+}
+
+// runLabels returns the labels of the run from the configuration file and the flags, the flags take precedence.
+func runLabels() map[string]string {
+	return mergeColumns(fileConfig.RunLabels, runLabelFlags)
+}
+
+// githubOption returns the option of the github api from the environment variables of github actions,
+// the api of the flags or the configuration file replaces the api of the environment variables.
+func githubOption() *github.ClientOption {
+	o := github.NewClientOptionFromEnv(os.Getenv)
+	if githubOverride.APIURL != "" || githubOverride.ServerURL != "" {
+		o.APIURL, o.ServerURL = githubOverride.APIURL, githubOverride.ServerURL
+	}
+	o.APIVersion = githubOverride.APIVersion
+	o.Retry = retryPolicy
+	o.HTTPClient = httpClient
+	return o
+}
+
+// loadHTTPClient creates the http client of the calls to the external services from the flags and the configuration file,
+// the flags take precedence. The kusto sdk keeps its own client unless the proxy or the certificates are set.
+func loadHTTPClient(cmd *cobra.Command, c *config.Config) error {
+	for flag, value := range map[string]struct {
+		option *string
+		config string
+	}{
+		FlagProxy:      {&httpOption.Proxy, c.HTTP.Proxy},
+		FlagCABundle:   {&httpOption.CABundle, c.HTTP.CABundle},
+		FlagClientCert: {&httpOption.ClientCert, c.HTTP.ClientCert},
+		FlagClientKey:  {&httpOption.ClientKey, c.HTTP.ClientKey},
+	} {
+		if !cmd.Flags().Changed(flag) && value.config != "" {
+			*value.option = value.config
+		}
+	}
+
+	var err error
+	if httpClient, err = httpclient.New(httpOption); err != nil {
+		return err
+	}
+	if caBundle, err = httpOption.CABundleData(); err != nil {
+		return err
+	}
+	if !httpOption.IsZero() {
+		dbOption.KustoOption.HTTPClient = httpClient
+	}
+	return nil
+}
+
+// applyGitHubConfig sets the github api options that are not passed as flags from the configuration file.
+func applyGitHubConfig(cmd *cobra.Command, c *config.Config) {
+	for flag, value := range map[string]struct {
+		option *string
+		config string
+	}{
+		FlagGitHubAPIURL:     {&githubOverride.APIURL, c.GitHub.APIURL},
+		FlagGitHubServerURL:  {&githubOverride.ServerURL, c.GitHub.ServerURL},
+		FlagGitHubAPIVersion: {&githubOverride.APIVersion, c.GitHub.APIVersion},
+	} {
+		if !cmd.Flags().Changed(flag) && value.config != "" {
+			*value.option = value.config
+		}
+	}
+}
+
+// webhookSecretEnv is the environment variable of the key that signs the body posted to the --webhook-url webhooks.
+const webhookSecretEnv = "GOCOVER_WEBHOOK_SECRET"
+
+// webhookOption returns the webhooks of the configuration file and the flags, the secrets are read from the environment variables.
+func webhookOption() *webhook.ClientOption {
+	o := &webhook.ClientOption{Retry: retryPolicy, HTTPClient: httpClient}
+	for _, w := range fileConfig.Webhooks {
+		h := &webhook.Hook{URL: w.URL, Headers: w.Headers, Body: w.Body}
+		if w.SecretEnv != "" {
+			h.Secret = os.Getenv(w.SecretEnv)
+		}
+		o.Hooks = append(o.Hooks, h)
+	}
+	for _, url := range webhookURLs {
+		o.Hooks = append(o.Hooks, &webhook.Hook{URL: url, Secret: os.Getenv(webhookSecretEnv)})
+	}
+	return o
 }
 
 // NewGoCoverCommand creates a command object for generating diff coverage reporter.
 func NewGoCoverCommand(version, commit, date string) *cobra.Command {
+	toolVersion = version
 
 	cmd := &cobra.Command{
 		Use:          "gocover",
 		Short:        "coverage tool for go code",
 		SilenceUsage: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateLogFlags(cmd); err != nil {
+				return err
+			}
+			pathCase, _ := cmd.Flags().GetString(FlagPathCase)
+			if err := gittool.SetPathCase(gittool.PathCase(pathCase)); err != nil {
+				return err
+			}
+			c, err := config.Load(configFile, cmd.Flags().Changed(FlagConfig))
+			if err != nil {
+				return err
+			}
+			fileConfig = c
+			if retryPolicy, err = loadRetryPolicy(cmd, c); err != nil {
+				return err
+			}
+			dbOption.KustoOption.Retry = retryPolicy
+			applyKustoConfig(cmd, c)
+			applyStoreConfig(cmd, c)
+			applyGitHubConfig(cmd, c)
+			if err := loadHTTPClient(cmd, c); err != nil {
+				return err
+			}
+			// the doctor reports the problems of the db options with their fixes instead of failing here
+			if cmd.Name() == "doctor" {
+				return nil
+			}
 			if err := dbOption.Validate(); err != nil {
 				return err
 			}
@@ -106,41 +678,100 @@ func NewGoCoverCommand(version, commit, date string) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().BoolP(FlagVerbose, FlagVerboseShort, false, "verbose output")
+	cmd.PersistentFlags().String(FlagLogFormat, logFormatText, `log format, "text" or "json", every json entry carries the repository, branch, commit and profile paths of the run`)
+	cmd.PersistentFlags().String(FlagLogLevel, logrus.InfoLevel.String(), "log level, one of: panic, fatal, error, warn, info, debug, trace, --verbose sets it to debug unless it is set explicitly")
+	cmd.PersistentFlags().String(FlagPathCase, string(gittool.PathCaseAuto), `how the file paths of the cover profiles and the diffs are compared, "auto", "sensitive" or "insensitive", auto is insensitive on Windows and macOS`)
+	cmd.PersistentFlags().StringVar(&configFile, FlagConfig, config.DefaultFile, "configuration file, the flags override the values in it, it's skipped if the default file doesn't exist")
+
+	cmd.PersistentFlags().Int(FlagRetryMaxAttempts, retry.DefaultMaxAttempts, "max attempts of the calls to the external services such as kusto, 1 disables retry")
+	cmd.PersistentFlags().Duration(FlagRetryInitialBackoff, retry.DefaultInitialBackoff, "wait time before the first retry, it's doubled after each retry by default")
+	cmd.PersistentFlags().Duration(FlagRetryMaxBackoff, retry.DefaultMaxBackoff, "max wait time between retries")
+	cmd.PersistentFlags().Float64(FlagRetryJitter, retry.DefaultJitter, "fraction of the wait time that is randomized, from 0 to 1")
+
+	cmd.PersistentFlags().StringVar(&httpOption.Proxy, FlagProxy, "", "url of the proxy of the calls to the external services, such as http://proxy.example.com:8080, it overrides HTTPS_PROXY and HTTP_PROXY, and the hosts of NO_PROXY are still called directly")
+	cmd.PersistentFlags().StringVar(&httpOption.CABundle, FlagCABundle, "", "PEM file of the CA certificates that the external services and the git remotes are verified with in addition to the system roots, such as the CA of a TLS-intercepting proxy")
+	cmd.PersistentFlags().StringVar(&httpOption.ClientCert, FlagClientCert, "", "PEM file of the client certificate of mutual TLS with the external services, it's set together with --client-key")
+	cmd.PersistentFlags().StringVar(&httpOption.ClientKey, FlagClientKey, "", "PEM file of the private key of --client-cert")
+
+	cmd.PersistentFlags().StringVar(&githubOverride.APIURL, FlagGitHubAPIURL, "", "url of the github api, such as https://github.example.com/api/v3 of a GitHub Enterprise Server, it overrides GITHUB_API_URL")
+	cmd.PersistentFlags().StringVar(&githubOverride.ServerURL, FlagGitHubServerURL, "", "url of the GitHub Enterprise Server, such as https://github.example.com, whose api is {server}/api/v3, it overrides GITHUB_SERVER_URL")
+	cmd.PersistentFlags().StringVar(&githubOverride.APIVersion, FlagGitHubAPIVersion, "", fmt.Sprintf(`X-GitHub-Api-Version header of the github api calls, default is %s, "%s" omits the header for GitHub Enterprise Server before 3.9`, github.DefaultAPIVersion, github.NoAPIVersion))
 
 	cmd.PersistentFlags().BoolVar(&dbOption.DataCollectionEnabled, "data-collection-enabled", false, "whether or not enable collecting coverage data")
-	cmd.PersistentFlags().StringVar((*string)(&dbOption.DbType), "store-type", string(dbclient.None), "db client type")
-	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.Endpoint, "endpoint", "", "kusto endpoint")
-	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.Database, "database", "", "kusto database")
-	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.CoverageEvent, "coverage-event", "", "kusto event for coverage")
-	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.IgnoreEvent, "ignore-event", "", "kusto event for ignore information")
-	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.ManagedIdentityResouceID, "managed-identity-resource-id", "", "managed identity resource id for auth for kusto")
+	cmd.PersistentFlags().StringSliceVar(&storeTypes, FlagStoreType, nil, `db client types, "Kusto" or "File", the data is stored to each of them even if some of them fail`)
+	cmd.PersistentFlags().StringSliceVar(&optionalStores, FlagStoreOptional, nil, "db client types whose failures are logged without failing the command, such as the new db when migrating between dbs")
+	cmd.PersistentFlags().StringVar(&dbOption.FileOption.Dir, FlagStoreDir, "", "directory that the File db client appends the coverage.jsonl and ignoreprofile.jsonl to")
+	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.Endpoint, FlagEndpoint, "", "kusto endpoint")
+	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.Database, FlagDatabase, "", "kusto database")
+	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.CoverageEvent, FlagCoverageEvent, "", "kusto event for coverage")
+	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.IgnoreEvent, FlagIgnoreEvent, "", "kusto event for ignore information")
+	cmd.PersistentFlags().StringVar((*string)(&dbOption.KustoOption.AuthMethod), FlagKustoAuth, "", `auth method for kusto, one of: service-principal, managed-identity, azure-cli, device-code, default is managed-identity if --managed-identity-resource-id is set, otherwise service-principal`)
+	cmd.PersistentFlags().StringVar(&dbOption.KustoOption.ManagedIdentityResouceID, FlagManagedIdentityResourceID, "", "managed identity resource id for auth for kusto, the system-assigned managed identity is used if it's empty")
 	cmd.PersistentFlags().StringSliceVar(&dbOption.KustoOption.CustomColumns, "custom-columns", []string{}, "custom kusto columns, format: {column}:{datatype}:{value}")
+	cmd.PersistentFlags().StringToStringVar(&dbOption.KustoOption.StaticColumns, "static-columns", nil, "kusto string columns with the same value for all the records, format: {column}={value},{column}={value}, such as team=platform")
+	cmd.PersistentFlags().StringToStringVar(&dbOption.KustoOption.ColumnNames, "column-names", nil, "rename the kusto columns to the columns of the existing tables, format: {column}={name},{column}={name}, such as coverage=CoveragePercent")
+	cmd.PersistentFlags().BoolVar(&dbOption.KustoOption.CIColumns, "ci-columns", false, "store the ci information in kusto columns ciProvider, commit, branch, pullRequest and buildId")
+	cmd.PersistentFlags().BoolVar(&dbOption.KustoOption.LabelsColumn, "labels-column", false, "store the labels of the run in the dynamic kusto column labels")
 	cmd.PersistentFlags().IntVar(&timeoutInSeconds, "timeout", defaultTimeoutInSeconds, "execute timeout in seconds")
 
+	cmd.PersistentFlags().StringVar((*string)(&ciOverride.Provider), "ci-provider", "", "ci provider of the run, detected from the environment variables if it's empty")
+	cmd.PersistentFlags().StringVar(&ciOverride.Commit, "ci-commit", "", "commit sha of the run, detected from the environment variables of ci if it's empty")
+	cmd.PersistentFlags().StringVar(&ciOverride.Branch, "ci-branch", "", "branch of the run, detected from the environment variables of ci if it's empty")
+	cmd.PersistentFlags().StringVar(&ciOverride.PullRequest, "ci-pull-request", "", "pull request number of the run, detected from the environment variables of ci if it's empty")
+	cmd.PersistentFlags().StringVar(&ciOverride.BuildID, "ci-build-id", "", "build id of the run, detected from the environment variables of ci if it's empty")
+	cmd.PersistentFlags().StringToStringVar(&runLabelFlags, "run-labels", nil, "key/value labels of the run, format: {key}={value},{key}={value}, such as service=api,environment=staging, which are stored in the db records, the history records and the json report")
+	cmd.PersistentFlags().StringSliceVar(&webhookURLs, "webhook-url", nil, "webhook urls that the json result of the full and diff coverage is posted to, the body is signed with the "+webhookSecretEnv+" environment variable if it's set, the headers and body templates are set in the configuration file")
+
 	cmd.AddCommand(newDiffCoverageCommand())
 	cmd.AddCommand(newFullCoverageCommand())
 	cmd.AddCommand(newGoCoverTestCommand())
+	cmd.AddCommand(newTestImpactCommand())
+	cmd.AddCommand(newFlakyCoverageCommand())
+	cmd.AddCommand(newAggregateCommand())
+	cmd.AddCommand(newHistoryCommand())
+	cmd.AddCommand(newLSPCommand())
+	cmd.AddCommand(newServeCommand())
+	cmd.AddCommand(newShowCommand())
+	cmd.AddCommand(newBazelCommand())
+	cmd.AddCommand(newValidateCommand())
+	cmd.AddCommand(newLintAnnotationsCommand())
+	cmd.AddCommand(newScaffoldCommand())
+	cmd.AddCommand(newInitCommand())
+	cmd.AddCommand(newDoctorCommand())
 	cmd.AddCommand(newVersionCommand(version, commit, date))
 	return cmd
 }
 
 func newDiffCoverageCommand() *cobra.Command {
 	o := gocover.NewDiffOption()
+	auth := &gittool.FetchAuth{}
 	cmd := &cobra.Command{
 		Use:     "diff",
 		Short:   "generate diff coverage for go code unit test",
 		Long:    diffLong,
 		Example: diffExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			o.Logger = createLogger(cmd)
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			detectCompareBranch(cmd, &o.CompareBranch, o.FetchRemote, o.Logger)
 			o.DbOption = dbOption
+			dbOption.FileOption.Compression = compression.Algorithm(o.Compression)
+			o.CI = detectCI()
+			o.RunLabels = runLabels()
+			o.FetchAuth = fetchAuth(auth)
+			o.GitHubOption = githubOption()
+			o.WebhookOption = webhookOption()
+			o.ToolVersion = toolVersion
+			applyExcludeConfig(cmd, &o.ExcludeFunctions, &o.ExcludeBuildTags)
+			applyMixedCoverModesConfig(cmd, &o.MixedCoverModes)
+			applyCompatibleMarkersConfig(cmd, &o.CompatibleMarkers)
+			o.Progress = progressBar(cmd)
 
 			diff, err := gocover.NewDiffCover(o)
 			if err != nil {
 				return fmt.Errorf("NewDiffCover: %w", err)
 			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutInSeconds*time.Second)
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
 			defer cancel()
 
 			if err := diff.Run(ctx); err != nil {
@@ -151,16 +782,70 @@ func newDiffCoverageCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, `coverage profile produced by 'go test'`)
-	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, `branch to compare`)
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, `coverage profile produced by 'go test', use format {label}={profile} to report coverage for each label`)
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, `branch to compare, defaults to the target branch of the pull request when it runs in CI`)
 	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", `the root directory of git repository`)
 	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
-	cmd.Flags().StringVar(&o.ReportFormat, "format", o.ReportFormat, "format of the diff coverage report, one of: html, json, markdown")
+	cmd.Flags().StringSliceVar(&o.ReportFormats, "output-format", o.ReportFormats, "formats of the coverage report generated from a single parse, any of: html, json, markdown, cobertura, legacy")
+	cmd.Flags().StringSliceVar(&o.ReportFormats, "format", o.ReportFormats, "formats of the coverage report")
+	cmd.Flags().MarkDeprecated("format", "use --output-format instead")
 	cmd.Flags().StringSliceVar(&o.Excludes, "excludes", []string{}, "exclude files for diff coverage calucation")
+	cmd.Flags().StringSliceVar(&o.ExcludeFunctions, FlagExcludeFunctions, []string{}, `regular expressions of the function names excluded from coverage calculation, such as "String$" or "^Must", methods are named T.N`)
+	cmd.Flags().StringSliceVar(&o.ExcludeBuildTags, FlagExcludeBuildTags, []string{}, "exclude the files that are built only with any of the build tags from coverage calculation, such as integration or tools")
+	cmd.Flags().StringSliceVar(&o.IncludePackages, "packages", []string{}, "import path patterns of the packages in the cover profiles that are analyzed, such as github.com/foo/service-a/..., all packages are analyzed if it's empty")
+	cmd.Flags().StringSliceVar(&o.SkipPackages, "skip-packages", []string{}, "import path patterns of the packages in the cover profiles that are not analyzed at all, such as github.com/foo/.../mock")
+	cmd.Flags().StringVar(&o.RepoRoot, "repo-root", "", "directory that the files of the cover profiles are resolved in by the module path in go.mod of --module-dir, so that the packages don't need to be importable, such as in the CI images without the module cache")
+	cmd.Flags().StringVar(&o.Overlay, "overlay", "", "json file of the go build -overlay flag, the files replaced in the instrumented build are read from their replacements")
+	cmd.Flags().BoolVar(&o.SkipUnresolved, "skip-unresolved-files", false, "skip the files of the cover profiles that cannot be located instead of failing, they are reported as skipped files")
+	cmd.Flags().BoolVar(&o.ContinueOnError, "continue-on-error", false, "convert the rest of the files when a file fails to convert, the errors of all the failed files are reported at the end")
+	cmd.Flags().BoolVar(&o.IgnoreFileErrors, "ignore-file-errors", false, "don't fail on the errors of the files reported by --continue-on-error")
+	cmd.Flags().Bool(FlagProgress, false, "show the progress of converting the files of the cover profiles on the standard error")
+	cmd.Flags().IntVar(&o.MemoryLimit, "spill-statements", 0, "spill the results of the files converted after the given number of statements to a temporary directory until the conversion ends, it doesn't bound the memory of the run, 0 disables the spill")
+	cmd.Flags().IntVar(&o.MemoryLimit, "max-memory-statements", 0, "statements that the results are spilled after")
+	cmd.Flags().MarkDeprecated("max-memory-statements", "use --spill-statements instead, it doesn't bound the memory of the run")
+	cmd.Flags().StringVar(&o.IgnorePolicy, "ignore-policy", string(gocover.ExcludeIgnored), `how the ignored statements count for coverage, "exclude" excludes them from both the covered and the effective statements, "count" counts them as the other statements`)
+	cmd.Flags().StringVar(&o.MixedCoverModes, FlagMixedCoverModes, string(parser.NormalizeMixedModes), `how the cover profiles of covermode set are combined with the profiles of count or atomic, "normalize" reduces all the hit counts to whether the blocks are reached, "reject" fails the run, count and atomic are always combined as count`)
+	cmd.Flags().BoolVar(&o.CompatibleMarkers, FlagCompatibleMarkers, false, "honor the ignore markers of other coverage tools, //coverage:ignore, // nocover and //nolint:gocover, as the gocover ignore annotations")
+	cmd.Flags().StringVar(&o.ClosedIssues, "check-ignore-issues", "", `check the issues that the ignore annotations are linked to by issue={owner}/{repo}#{number}, "warn" or "fail" if any of them is closed, it needs GITHUB_TOKEN and GITHUB_REPOSITORY`)
+	cmd.Flags().BoolVar(&o.TrackingIssue, "tracking-issue", false, "open or update a github issue of the uncovered changed functions of the merged pull request, assigned to the author, the pull request is --ci-pull-request or the one of the commit, it needs GITHUB_TOKEN and GITHUB_REPOSITORY")
 	cmd.Flags().StringVarP(&o.OutputDir, "outputdir", "o", o.OutputDir, "diff coverage output directory")
 	cmd.Flags().Float64Var(&o.CoverageBaseline, "coverage-baseline", o.CoverageBaseline, "returns an error code if coverage or quality score is less than coverage baseline")
+	cmd.Flags().StringVar(&o.Gate, "gate", "", `expression of the coverage requirement that replaces the coverage baseline and floor, such as 'diff >= 80 && full >= 70 && pkg("pkg/api") >= 90'`)
+	cmd.Flags().StringVar(&o.Ownership, "ownership", "", `yaml file that maps the teams to the path globs they own and their coverage budgets, the coverage is reported by team and the run fails if any team is below its budget`)
+	cmd.Flags().StringSliceVar(&o.TestOutputs, "test-json", nil, "files of the go test -json output, the results of the tests are reported by package and the failed and skipped tests are linked to the uncovered functions they're named after")
 	cmd.Flags().StringVar(&o.ReportName, "report-name", "coverage", "diff coverage report name")
+	cmd.Flags().StringVar(&o.Compression, "compression", "", `compress the json report and the File db records with "gzip" or "zstd"`)
 	cmd.Flags().StringVar(&o.Style, "style", "colorful", "coverage report code format style, refer to https://pygments.org/docs/styles for more information")
+	cmd.Flags().IntVar(&o.TopUncovered, "top-uncovered", 0, "report the given number of functions that have the most uncovered lines")
+	cmd.Flags().IntVar(&o.TopHot, "top-hot", 0, "report the given number of functions and statements that are executed most frequently, it needs cover profiles of count or atomic covermode")
+	cmd.Flags().BoolVar(&o.FoldClosures, "fold-closures", false, "fold the coverage of function literals into their enclosing functions instead of reporting them separately")
+	cmd.Flags().StringVar(&o.Anonymize, "anonymize", "", `anonymize file paths, package names and function names in the report, "hash" or "alias"`)
+	cmd.Flags().StringSliceVar(&o.Templates, "template", []string{}, "go template files that the custom reports are generated from, the report of {name}.tmpl is written to {name} in the output directory")
+	cmd.Flags().BoolVar(&o.Treemap, "treemap", false, "generate a treemap report besides the html report, the size is the effective lines and the color is the coverage")
+	cmd.Flags().BoolVar(&o.JUnit, "junit", false, "generate a junit xml report besides the html report, the test case fails if its coverage is less than coverage baseline")
+	cmd.Flags().BoolVar(&o.LinesReport, "lines-report", false, "generate a json report of the state of each line for editor plugins")
+	cmd.Flags().BoolVar(&o.DirectoryTree, "directory-tree", false, "aggregate the coverage by directory in a collapsible tree of the html report, and print the tree to the console")
+	cmd.Flags().BoolVar(&o.SideBySide, "side-by-side", false, "show the changed files side by side in the html report of diff coverage, the added lines are colored by their coverage states")
+	cmd.Flags().BoolVar(&o.AnnotatedDiff, "annotated-diff", false, "write the unified diff of the changes to stdout, the added lines are marked with their coverage states, ✓ covered, ✗ uncovered, ◐ partially covered and ○ ignored")
+	cmd.Flags().StringVar(&o.CacheDir, "cache-dir", "", "directory that caches the conversion result of each file, the file is converted again only when its source, cover profile blocks or changes differ, and the resolved packages until go.mod changes")
+	cmd.Flags().BoolVar(&o.TeamCity, "teamcity", false, "write teamcity service messages of the coverage statistics and the uncovered lines to stdout")
+	cmd.Flags().StringVar(&o.AzureDevOpsDir, "azure-devops-dir", "", "write {dir}/coverage-cobertura.xml and {dir}/html/index.html for the summaryFileLocation and reportDirectory of the PublishCodeCoverageResults task of azure pipelines")
+	cmd.Flags().BoolVar(&o.SummaryLine, "summary-line", false, `print the coverage as "total coverage: 83.2% of statements", or "diff coverage: ..." for diff coverage, which the coverage regex of gitlab can scrape`)
+	cmd.Flags().BoolVar(&o.NoStepSummary, "no-step-summary", false, "do not write the markdown report to $GITHUB_STEP_SUMMARY in github actions")
+	cmd.Flags().BoolVar(&o.CommitStatus, "commit-status", false, "set the github commit status of the commit with the coverage percent, it needs GITHUB_TOKEN and GITHUB_REPOSITORY environment variables, the commit is --ci-commit or HEAD")
+	cmd.Flags().StringVar(&o.StatusContext, "status-context", "", "context of the github commit status, default is gocover/diff or gocover/full")
+	cmd.Flags().StringVar(&o.DetailsURL, "details-url", "", "details url of the github commit status, such as the url of the uploaded html report")
+	cmd.Flags().StringVar(&o.AttestationKey, "attestation-key", "", "ed25519 private key file in PKCS #8 PEM format, which signs the attestation {report-name}-attestation.json of the reports, the tool version, the digests of the cover profiles and the git commit")
+	cmd.Flags().StringVar(&o.NewCodeSince, "new-code-since", "", "check the code changed since the date (2006-01-02), time (RFC3339) or git ref instead of the compare branch, all the files are compared with the ref or the last commit before the date")
+	cmd.Flags().StringSliceVar(&o.Authors, "author", nil, "check only the changed lines authored by the names or emails, the authors of the lines are found by git blame")
+	cmd.Flags().StringSliceVar(&o.StackBases, "stack-base", nil, "ordered base refs of a stacked pull request, such as origin/feature-a,origin/main, the first one that exists and has a common ancestor with HEAD is compared with instead of the compare branch")
+	cmd.Flags().StringVar(&o.Since, "since", "", "check only the changed lines committed since the date (2006-01-02) or time (RFC3339), the commits of the lines are found by git blame")
+	cmd.Flags().StringVar(&o.Until, "until", "", "check only the changed lines committed until the date (2006-01-02), which is included, or before the time (RFC3339)")
+	cmd.Flags().BoolVar(&o.AttributeCommits, "attribute-commits", false, "report the commits that introduce the uncovered changed lines, which are found by git blame")
+	cmd.Flags().StringVar(&o.FetchRemote, "fetch-remote", o.FetchRemote, "remote to fetch the compare branch from when it's missing in a shallow clone, disabled if it's empty")
+	addFetchAuthFlags(cmd, auth)
+	cmd.Flags().BoolVar(&o.WeakCoverage, "weak-coverage", false, "report the changed statements that are reached only once, requires count or atomic cover mode")
+	cmd.Flags().BoolVar(&o.DetectMoves, "detect-moves", false, "detect the renamed files and the code moved within or across files, the moved statements don't count for diff coverage and are reported as moved lines")
 
 	cmd.MarkFlagRequired("cover-profile")
 
@@ -176,15 +861,25 @@ func newFullCoverageCommand() *cobra.Command {
 		Long:    fullLong,
 		Example: fullExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			o.Logger = createLogger(cmd)
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
 			o.DbOption = dbOption
+			dbOption.FileOption.Compression = compression.Algorithm(o.Compression)
+			o.CI = detectCI()
+			o.RunLabels = runLabels()
+			o.GitHubOption = githubOption()
+			o.WebhookOption = webhookOption()
+			o.ToolVersion = toolVersion
+			applyExcludeConfig(cmd, &o.ExcludeFunctions, &o.ExcludeBuildTags)
+			applyMixedCoverModesConfig(cmd, &o.MixedCoverModes)
+			applyCompatibleMarkersConfig(cmd, &o.CompatibleMarkers)
+			o.Progress = progressBar(cmd)
 
 			full, err := gocover.NewFullCover(o)
 			if err != nil {
 				return fmt.Errorf("NewFullCover: %w", err)
 			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutInSeconds*time.Second)
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
 			defer cancel()
 
 			if err := full.Run(ctx); err != nil {
@@ -195,15 +890,63 @@ func newFullCoverageCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, `coverage profiles produced by 'go test'`)
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, `coverage profiles produced by 'go test', use format {label}={profile} to report coverage for each label`)
 	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", `the root directory of git repository`)
 	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
-	cmd.Flags().StringVar(&o.ReportFormat, "format", o.ReportFormat, "format of the diff coverage report, one of: html, json, markdown")
+	cmd.Flags().StringSliceVar(&o.ReportFormats, "output-format", o.ReportFormats, "formats of the coverage report generated from a single parse, any of: html, json, markdown, cobertura, legacy")
+	cmd.Flags().StringSliceVar(&o.ReportFormats, "format", o.ReportFormats, "formats of the coverage report")
+	cmd.Flags().MarkDeprecated("format", "use --output-format instead")
 	cmd.Flags().StringSliceVar(&o.Excludes, "excludes", []string{}, "exclude files for diff coverage calucation")
+	cmd.Flags().StringSliceVar(&o.ExcludeFunctions, FlagExcludeFunctions, []string{}, `regular expressions of the function names excluded from coverage calculation, such as "String$" or "^Must", methods are named T.N`)
+	cmd.Flags().StringSliceVar(&o.ExcludeBuildTags, FlagExcludeBuildTags, []string{}, "exclude the files that are built only with any of the build tags from coverage calculation, such as integration or tools")
+	cmd.Flags().StringSliceVar(&o.IncludePackages, "packages", []string{}, "import path patterns of the packages in the cover profiles that are analyzed, such as github.com/foo/service-a/..., all packages are analyzed if it's empty")
+	cmd.Flags().StringSliceVar(&o.SkipPackages, "skip-packages", []string{}, "import path patterns of the packages in the cover profiles that are not analyzed at all, such as github.com/foo/.../mock")
+	cmd.Flags().StringVar(&o.RepoRoot, "repo-root", "", "directory that the files of the cover profiles are resolved in by the module path in go.mod of --module-dir, so that the packages don't need to be importable, such as in the CI images without the module cache")
+	cmd.Flags().StringVar(&o.Overlay, "overlay", "", "json file of the go build -overlay flag, the files replaced in the instrumented build are read from their replacements")
+	cmd.Flags().BoolVar(&o.SkipUnresolved, "skip-unresolved-files", false, "skip the files of the cover profiles that cannot be located instead of failing, they are reported as skipped files")
+	cmd.Flags().BoolVar(&o.ContinueOnError, "continue-on-error", false, "convert the rest of the files when a file fails to convert, the errors of all the failed files are reported at the end")
+	cmd.Flags().BoolVar(&o.IgnoreFileErrors, "ignore-file-errors", false, "don't fail on the errors of the files reported by --continue-on-error")
+	cmd.Flags().Bool(FlagProgress, false, "show the progress of converting the files of the cover profiles on the standard error")
+	cmd.Flags().IntVar(&o.MemoryLimit, "spill-statements", 0, "spill the results of the files converted after the given number of statements to a temporary directory until the conversion ends, it doesn't bound the memory of the run, 0 disables the spill")
+	cmd.Flags().IntVar(&o.MemoryLimit, "max-memory-statements", 0, "statements that the results are spilled after")
+	cmd.Flags().MarkDeprecated("max-memory-statements", "use --spill-statements instead, it doesn't bound the memory of the run")
+	cmd.Flags().StringVar(&o.IgnorePolicy, "ignore-policy", string(gocover.ExcludeIgnored), `how the ignored statements count for coverage, "exclude" excludes them from both the covered and the effective statements, "count" counts them as the other statements`)
+	cmd.Flags().StringVar(&o.MixedCoverModes, FlagMixedCoverModes, string(parser.NormalizeMixedModes), `how the cover profiles of covermode set are combined with the profiles of count or atomic, "normalize" reduces all the hit counts to whether the blocks are reached, "reject" fails the run, count and atomic are always combined as count`)
+	cmd.Flags().BoolVar(&o.CompatibleMarkers, FlagCompatibleMarkers, false, "honor the ignore markers of other coverage tools, //coverage:ignore, // nocover and //nolint:gocover, as the gocover ignore annotations")
+	cmd.Flags().StringVar(&o.ClosedIssues, "check-ignore-issues", "", `check the issues that the ignore annotations are linked to by issue={owner}/{repo}#{number}, "warn" or "fail" if any of them is closed, it needs GITHUB_TOKEN and GITHUB_REPOSITORY`)
 	cmd.Flags().StringVarP(&o.OutputDir, "outputdir", "o", o.OutputDir, "diff coverage output directory")
-	cmd.Flags().Float64Var(&o.CoverageBaseline, "coverage-baseline", o.CoverageBaseline, "returns an error code if coverage or quality score is less than coverage baseline")
+	cmd.Flags().Float64Var(&o.CoverageFloor, "coverage-floor", 0, "returns an error code if full coverage is less than coverage floor, disabled if it's zero")
+	cmd.Flags().Float64Var(&o.CoverageFloor, "coverage-baseline", 0, "full coverage that an error code is returned below")
+	cmd.Flags().MarkDeprecated("coverage-baseline", "use --coverage-floor instead")
+	cmd.Flags().StringVar(&o.Gate, "gate", "", `expression of the coverage requirement that replaces the coverage baseline and floor, such as 'diff >= 80 && full >= 70 && pkg("pkg/api") >= 90'`)
+	cmd.Flags().StringVar(&o.Ownership, "ownership", "", `yaml file that maps the teams to the path globs they own and their coverage budgets, the coverage is reported by team and the run fails if any team is below its budget`)
+	cmd.Flags().StringSliceVar(&o.TestOutputs, "test-json", nil, "files of the go test -json output, the results of the tests are reported by package and the failed and skipped tests are linked to the uncovered functions they're named after")
 	cmd.Flags().StringVar(&o.ReportName, "report-name", "coverage", "diff coverage report name")
 	cmd.Flags().StringVar(&o.Style, "style", "colorful", "coverage report code format style, refer to https://pygments.org/docs/styles for more information")
+	cmd.Flags().IntVar(&o.TopUncovered, "top-uncovered", 0, "report the given number of functions that have the most uncovered lines")
+	cmd.Flags().IntVar(&o.TopHot, "top-hot", 0, "report the given number of functions and statements that are executed most frequently, it needs cover profiles of count or atomic covermode")
+	cmd.Flags().BoolVar(&o.FoldClosures, "fold-closures", false, "fold the coverage of function literals into their enclosing functions instead of reporting them separately")
+	cmd.Flags().StringVar(&o.Anonymize, "anonymize", "", `anonymize file paths, package names and function names in the report, "hash" or "alias"`)
+	cmd.Flags().StringVar(&o.HistoryDir, "history-dir", "", "directory that stores the result of each run for comparing across runs, disabled if it's empty")
+	cmd.Flags().IntVar(&o.NeverCoveredRuns, "never-covered-runs", 0, "report the functions that have no coverage in the given number of latest runs, requires history-dir")
+	cmd.Flags().StringVar(&o.Compression, "compression", "", `compress the history records, the json report and the File db records with "gzip" or "zstd", the history records are decompressed on read whichever algorithm is used`)
+	cmd.Flags().StringVar(&o.BaseRef, "base-ref", "", "compare the coverage with the stored result of the merge base of HEAD and the ref, and report the regressed packages and files, requires history-dir")
+	cmd.Flags().IntVar(&o.TrendRuns, "trend-runs", 0, "chart the coverage of the given number of latest runs of the branch in {report-name}-trend.svg and .png, and in the html report, requires history-dir")
+	cmd.Flags().StringSliceVar(&o.TrendPackages, "trend-package", []string{}, "package whose coverage is charted besides the total coverage, the import path or the path relative to the module, and pkg/x/... includes the sub packages")
+	cmd.Flags().StringSliceVar(&o.Templates, "template", []string{}, "go template files that the custom reports are generated from, the report of {name}.tmpl is written to {name} in the output directory")
+	cmd.Flags().BoolVar(&o.Treemap, "treemap", false, "generate a treemap report besides the html report, the size is the effective lines and the color is the coverage")
+	cmd.Flags().BoolVar(&o.JUnit, "junit", false, "generate a junit xml report besides the html report, the test case fails if its coverage is less than coverage baseline")
+	cmd.Flags().BoolVar(&o.LinesReport, "lines-report", false, "generate a json report of the state of each line for editor plugins")
+	cmd.Flags().BoolVar(&o.DirectoryTree, "directory-tree", false, "aggregate the coverage by directory in a collapsible tree of the html report, and print the tree to the console")
+	cmd.Flags().StringVar(&o.CacheDir, "cache-dir", "", "directory that caches the conversion result of each file, the file is converted again only when its source, cover profile blocks or changes differ, and the resolved packages until go.mod changes")
+	cmd.Flags().BoolVar(&o.TeamCity, "teamcity", false, "write teamcity service messages of the coverage statistics and the uncovered lines to stdout")
+	cmd.Flags().StringVar(&o.AzureDevOpsDir, "azure-devops-dir", "", "write {dir}/coverage-cobertura.xml and {dir}/html/index.html for the summaryFileLocation and reportDirectory of the PublishCodeCoverageResults task of azure pipelines")
+	cmd.Flags().BoolVar(&o.SummaryLine, "summary-line", false, `print the coverage as "total coverage: 83.2% of statements", or "diff coverage: ..." for diff coverage, which the coverage regex of gitlab can scrape`)
+	cmd.Flags().BoolVar(&o.NoStepSummary, "no-step-summary", false, "do not write the markdown report to $GITHUB_STEP_SUMMARY in github actions")
+	cmd.Flags().BoolVar(&o.CommitStatus, "commit-status", false, "set the github commit status of the commit with the coverage percent, it needs GITHUB_TOKEN and GITHUB_REPOSITORY environment variables, the commit is --ci-commit or HEAD")
+	cmd.Flags().StringVar(&o.StatusContext, "status-context", "", "context of the github commit status, default is gocover/diff or gocover/full")
+	cmd.Flags().StringVar(&o.DetailsURL, "details-url", "", "details url of the github commit status, such as the url of the uploaded html report")
+	cmd.Flags().StringVar(&o.AttestationKey, "attestation-key", "", "ed25519 private key file in PKCS #8 PEM format, which signs the attestation {report-name}-attestation.json of the reports, the tool version, the digests of the cover profiles and the git commit")
 
 	cmd.MarkFlagRequired("cover-profile")
 
@@ -212,6 +955,7 @@ func newFullCoverageCommand() *cobra.Command {
 
 func newGoCoverTestCommand() *cobra.Command {
 	o := gocover.NewGoCoverTestOption()
+	auth := &gittool.FetchAuth{}
 
 	cmd := &cobra.Command{
 		Use:     "test",
@@ -219,12 +963,24 @@ func newGoCoverTestCommand() *cobra.Command {
 		Long:    gocoverTestLong,
 		Example: gocoverTestExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			o.Logger = createLogger(cmd)
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			detectCompareBranch(cmd, &o.CompareBranch, o.FetchRemote, o.Logger)
 			o.DbOption = dbOption
+			dbOption.FileOption.Compression = compression.Algorithm(o.Compression)
+			o.CI = detectCI()
+			o.RunLabels = runLabels()
+			o.FetchAuth = fetchAuth(auth)
+			o.GitHubOption = githubOption()
+			o.WebhookOption = webhookOption()
+			o.ToolVersion = toolVersion
+			applyExcludeConfig(cmd, &o.ExcludeFunctions, &o.ExcludeBuildTags)
+			applyMixedCoverModesConfig(cmd, &o.MixedCoverModes)
+			applyCompatibleMarkersConfig(cmd, &o.CompatibleMarkers)
+			o.Progress = progressBar(cmd)
 			o.StdOut = cmd.OutOrStdout()
 			o.StdErr = cmd.ErrOrStderr()
 
-			ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutInSeconds*time.Second)
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
 			defer cancel()
 
 			t, err := gocover.NewGoCoverTestExecutor(o)
@@ -236,18 +992,595 @@ func newGoCoverTestCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, `coverage profile produced by 'go test'`)
-	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, `branch to compare`)
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, `branch to compare, defaults to the target branch of the pull request when it runs in CI`)
 	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", `the root directory of git repository`)
 	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
-	cmd.Flags().StringVar(&o.ReportFormat, "format", o.ReportFormat, "format of the diff coverage report, one of: html, json, markdown")
+	cmd.Flags().StringSliceVar(&o.ReportFormats, "output-format", o.ReportFormats, "formats of the coverage report generated from a single parse, any of: html, json, markdown, cobertura, legacy")
+	cmd.Flags().StringSliceVar(&o.ReportFormats, "format", o.ReportFormats, "formats of the coverage report")
+	cmd.Flags().MarkDeprecated("format", "use --output-format instead")
 	cmd.Flags().StringSliceVar(&o.Excludes, "excludes", []string{}, "exclude files for diff coverage calucation")
+	cmd.Flags().StringSliceVar(&o.ExcludeFunctions, FlagExcludeFunctions, []string{}, `regular expressions of the function names excluded from coverage calculation, such as "String$" or "^Must", methods are named T.N`)
+	cmd.Flags().StringSliceVar(&o.ExcludeBuildTags, FlagExcludeBuildTags, []string{}, "exclude the files that are built only with any of the build tags from coverage calculation, such as integration or tools")
+	cmd.Flags().StringSliceVar(&o.SkipPackages, "skip-packages", []string{}, "import path patterns of the packages in the cover profiles that are not analyzed at all, such as github.com/foo/.../mock")
 	cmd.Flags().StringVarP(&o.OutputDir, "outputdir", "o", o.OutputDir, "diff coverage output directory")
 	cmd.Flags().Float64Var(&o.CoverageBaseline, "coverage-baseline", o.CoverageBaseline, "returns an error code if coverage or quality score is less than coverage baseline")
 	cmd.Flags().StringVar(&o.ReportName, "report-name", "coverage", "diff coverage report name")
 	cmd.Flags().StringVar(&o.Style, "style", "colorful", "coverage report code format style, refer to https://pygments.org/docs/styles for more information")
-	cmd.Flags().StringVar((*string)(&o.CoverageMode), "coverage-mode", string(gocover.FullCoverage), `mode for coverage, "full" or "diff"`)
+	cmd.Flags().IntVar(&o.TopUncovered, "top-uncovered", 0, "report the given number of functions that have the most uncovered lines")
+	cmd.Flags().IntVar(&o.TopHot, "top-hot", 0, "report the given number of functions and statements that are executed most frequently, it needs cover profiles of count or atomic covermode")
+	cmd.Flags().BoolVar(&o.FoldClosures, "fold-closures", false, "fold the coverage of function literals into their enclosing functions instead of reporting them separately")
+	cmd.Flags().StringVar(&o.Anonymize, "anonymize", "", `anonymize file paths, package names and function names in the report, "hash" or "alias"`)
+	cmd.Flags().StringVar(&o.HistoryDir, "history-dir", "", "directory that stores the result of each run for comparing across runs, disabled if it's empty")
+	cmd.Flags().IntVar(&o.NeverCoveredRuns, "never-covered-runs", 0, "report the functions that have no coverage in the given number of latest runs, requires history-dir")
+	cmd.Flags().StringVar(&o.Compression, "compression", "", `compress the history records, the json report and the File db records with "gzip" or "zstd", the history records are decompressed on read whichever algorithm is used`)
+	cmd.Flags().StringVar(&o.BaseRef, "base-ref", "", "compare the coverage with the stored result of the merge base of HEAD and the ref, and report the regressed packages and files, requires history-dir")
+	cmd.Flags().IntVar(&o.TrendRuns, "trend-runs", 0, "chart the coverage of the given number of latest runs of the branch in {report-name}-trend.svg and .png, and in the html report, requires history-dir")
+	cmd.Flags().StringSliceVar(&o.TrendPackages, "trend-package", []string{}, "package whose coverage is charted besides the total coverage, the import path or the path relative to the module, and pkg/x/... includes the sub packages")
+	cmd.Flags().StringSliceVar(&o.Templates, "template", []string{}, "go template files that the custom reports are generated from, the report of {name}.tmpl is written to {name} in the output directory")
+	cmd.Flags().BoolVar(&o.Treemap, "treemap", false, "generate a treemap report besides the html report, the size is the effective lines and the color is the coverage")
+	cmd.Flags().BoolVar(&o.JUnit, "junit", false, "generate a junit xml report besides the html report, the test case fails if its coverage is less than coverage baseline")
+	cmd.Flags().BoolVar(&o.LinesReport, "lines-report", false, "generate a json report of the state of each line for editor plugins")
+	cmd.Flags().BoolVar(&o.DirectoryTree, "directory-tree", false, "aggregate the coverage by directory in a collapsible tree of the html report, and print the tree to the console")
+	cmd.Flags().BoolVar(&o.SideBySide, "side-by-side", false, "show the changed files side by side in the html report of diff coverage, the added lines are colored by their coverage states")
+	cmd.Flags().BoolVar(&o.AnnotatedDiff, "annotated-diff", false, "write the unified diff of the changes to stdout, the added lines are marked with their coverage states, ✓ covered, ✗ uncovered, ◐ partially covered and ○ ignored")
+	cmd.Flags().StringVar(&o.CacheDir, "cache-dir", "", "directory that caches the conversion result of each file, the file is converted again only when its source, cover profile blocks or changes differ, and the resolved packages until go.mod changes")
+	cmd.Flags().BoolVar(&o.TeamCity, "teamcity", false, "write teamcity service messages of the coverage statistics and the uncovered lines to stdout")
+	cmd.Flags().StringVar(&o.AzureDevOpsDir, "azure-devops-dir", "", "write {dir}/coverage-cobertura.xml and {dir}/html/index.html for the summaryFileLocation and reportDirectory of the PublishCodeCoverageResults task of azure pipelines")
+	cmd.Flags().BoolVar(&o.SummaryLine, "summary-line", false, `print the coverage as "total coverage: 83.2% of statements", or "diff coverage: ..." for diff coverage, which the coverage regex of gitlab can scrape`)
+	cmd.Flags().BoolVar(&o.NoStepSummary, "no-step-summary", false, "do not write the markdown report to $GITHUB_STEP_SUMMARY in github actions")
+	cmd.Flags().BoolVar(&o.CommitStatus, "commit-status", false, "set the github commit status of the commit with the coverage percent, it needs GITHUB_TOKEN and GITHUB_REPOSITORY environment variables, the commit is --ci-commit or HEAD")
+	cmd.Flags().StringVar(&o.StatusContext, "status-context", "", "context of the github commit status, default is gocover/diff or gocover/full")
+	cmd.Flags().StringVar(&o.DetailsURL, "details-url", "", "details url of the github commit status, such as the url of the uploaded html report")
+	cmd.Flags().StringVar(&o.AttestationKey, "attestation-key", "", "ed25519 private key file in PKCS #8 PEM format, which signs the attestation {report-name}-attestation.json of the reports, the tool version, the digests of the cover profiles and the git commit")
+	cmd.Flags().StringVar(&o.NewCodeSince, "new-code-since", "", "check the code changed since the date (2006-01-02), time (RFC3339) or git ref instead of the compare branch, all the files are compared with the ref or the last commit before the date")
+	cmd.Flags().StringSliceVar(&o.StackBases, "stack-base", nil, "ordered base refs of a stacked pull request in diff coverage mode, the first one that exists and has a common ancestor with HEAD is compared with instead of the compare branch")
+	cmd.Flags().StringSliceVar(&o.Authors, "author", nil, "check only the changed lines authored by the names or emails in diff coverage mode, the authors of the lines are found by git blame")
+	cmd.Flags().StringVar(&o.Since, "since", "", "check only the changed lines committed since the date (2006-01-02) or time (RFC3339) in diff coverage mode, the commits of the lines are found by git blame")
+	cmd.Flags().StringVar(&o.Until, "until", "", "check only the changed lines committed until the date (2006-01-02), which is included, or before the time (RFC3339) in diff coverage mode")
+	cmd.Flags().BoolVar(&o.AttributeCommits, "attribute-commits", false, "report the commits that introduce the uncovered changed lines in diff coverage mode, which are found by git blame")
+	cmd.Flags().StringVar(&o.FetchRemote, "fetch-remote", o.FetchRemote, "remote to fetch the compare branch from when it's missing in a shallow clone, disabled if it's empty")
+	addFetchAuthFlags(cmd, auth)
+	cmd.Flags().BoolVar(&o.WeakCoverage, "weak-coverage", false, "report the changed statements that are reached only once, requires count or atomic cover mode")
+	cmd.Flags().BoolVar(&o.DetectMoves, "detect-moves", false, "detect the renamed files and the code moved within or across files, the moved statements don't count for diff coverage and are reported as moved lines")
+	cmd.Flags().StringVar((*string)(&o.CoverageMode), "coverage-mode", string(gocover.FullCoverage), `mode for coverage, "full", "diff" or "all", "all" evaluates and reports both full and diff coverage`)
+	cmd.Flags().Float64Var(&o.CoverageFloor, "coverage-floor", 0, "returns an error code if full coverage is less than coverage floor, disabled if it's zero")
+	cmd.Flags().StringVar(&o.Gate, "gate", "", `expression of the coverage requirement that replaces the coverage baseline and floor, such as 'diff >= 80 && full >= 70 && pkg("pkg/api") >= 90'`)
+	cmd.Flags().StringVar(&o.Ownership, "ownership", "", `yaml file that maps the teams to the path globs they own and their coverage budgets, the coverage is reported by team and the run fails if any team is below its budget`)
+	cmd.Flags().StringSliceVar(&o.TestOutputs, "test-json", nil, "files of the go test -json output, the results of the tests are reported by package and the failed and skipped tests are linked to the uncovered functions they're named after. The output of the tests is added when they run with -json")
 	cmd.Flags().StringVar((*string)(&o.ExecutorMode), "executor-mode", string(gocover.GoExecutor), `unit test mode, "go" or "ginkgo"`)
 	cmd.Flags().StringSliceVar(&o.GinkgoFlags, "ginkgo-flags", []string{"-r", "-trace", "-cover", "-coverpkg=./..."}, "ginkgo flags")
 	cmd.Flags().StringSliceVar(&o.GoFlags, "go-flags", []string{}, "go flags")
+	cmd.Flags().StringSliceVar(&o.Packages, "packages", o.Packages, "packages that go test runs on")
+	cmd.Flags().StringVar(&o.CoverMode, "covermode", o.CoverMode, `go test covermode, one of: set, count, atomic, default is "atomic" if -race flag is set, otherwise "set"`)
+	cmd.Flags().StringVar(&o.CoverPkg, "coverpkg", o.CoverPkg, "go test coverpkg, packages that coverage analysis applies to")
+	cmd.Flags().StringVar(&o.Overlay, "overlay", "", "json file of the go build -overlay flag, go test runs with it and the replaced files are read from their replacements")
+	cmd.Flags().BoolVar(&o.SkipUnresolved, "skip-unresolved-files", false, "skip the files of the cover profiles that cannot be located instead of failing, they are reported as skipped files")
+	cmd.Flags().BoolVar(&o.ContinueOnError, "continue-on-error", false, "convert the rest of the files when a file fails to convert, the errors of all the failed files are reported at the end")
+	cmd.Flags().BoolVar(&o.IgnoreFileErrors, "ignore-file-errors", false, "don't fail on the errors of the files reported by --continue-on-error")
+	cmd.Flags().Bool(FlagProgress, false, "show the progress of converting the files of the cover profiles on the standard error")
+	cmd.Flags().IntVar(&o.MemoryLimit, "spill-statements", 0, "spill the results of the files converted after the given number of statements to a temporary directory until the conversion ends, it doesn't bound the memory of the run, 0 disables the spill")
+	cmd.Flags().IntVar(&o.MemoryLimit, "max-memory-statements", 0, "statements that the results are spilled after")
+	cmd.Flags().MarkDeprecated("max-memory-statements", "use --spill-statements instead, it doesn't bound the memory of the run")
+	cmd.Flags().StringVar(&o.IgnorePolicy, "ignore-policy", string(gocover.ExcludeIgnored), `how the ignored statements count for coverage, "exclude" excludes them from both the covered and the effective statements, "count" counts them as the other statements`)
+	cmd.Flags().StringVar(&o.MixedCoverModes, FlagMixedCoverModes, string(parser.NormalizeMixedModes), `how the cover profiles of covermode set are combined with the profiles of count or atomic, "normalize" reduces all the hit counts to whether the blocks are reached, "reject" fails the run, count and atomic are always combined as count`)
+	cmd.Flags().BoolVar(&o.CompatibleMarkers, FlagCompatibleMarkers, false, "honor the ignore markers of other coverage tools, //coverage:ignore, // nocover and //nolint:gocover, as the gocover ignore annotations")
+	cmd.Flags().StringVar(&o.ClosedIssues, "check-ignore-issues", "", `check the issues that the ignore annotations are linked to by issue={owner}/{repo}#{number}, "warn" or "fail" if any of them is closed, it needs GITHUB_TOKEN and GITHUB_REPOSITORY`)
+	cmd.Flags().BoolVar(&o.TrackingIssue, "tracking-issue", false, "open or update a github issue of the uncovered changed functions of the merged pull request, assigned to the author, the pull request is --ci-pull-request or the one of the commit, it needs GITHUB_TOKEN and GITHUB_REPOSITORY")
+	return cmd
+}
+
+func newTestImpactCommand() *cobra.Command {
+	o := gocover.NewTestImpactOption()
+
+	cmd := &cobra.Command{
+		Use:     "impact",
+		Short:   "analyze which tests cover the changed statements",
+		Long:    impactLong,
+		Example: impactExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.TestProfiles)
+			detectCompareBranch(cmd, &o.CompareBranch, "", o.Logger)
+			o.StdOut = cmd.OutOrStdout()
+			o.StdErr = cmd.ErrOrStderr()
+
+			impact, err := gocover.NewTestImpact(o)
+			if err != nil {
+				return fmt.Errorf("NewTestImpact: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := impact.Run(ctx); err != nil {
+				return fmt.Errorf("analyze test impact: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, `branch to compare, defaults to the target branch of the pull request when it runs in CI`)
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", `the root directory of git repository`)
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+	cmd.Flags().StringSliceVar(&o.Excludes, "excludes", []string{}, "exclude files for test impact analysis")
+	cmd.Flags().StringVarP(&o.OutputDir, "outputdir", "o", o.OutputDir, "test impact output directory")
+	cmd.Flags().StringSliceVar(&o.GoFlags, "go-flags", []string{}, "go flags")
+	cmd.Flags().StringSliceVar(&o.TestProfiles, "test-profile", []string{}, "cover profile generated for a single test, format: {test}={profile}")
+	return cmd
+}
+
+func newFlakyCoverageCommand() *cobra.Command {
+	o := gocover.NewFlakyOption()
+
+	cmd := &cobra.Command{
+		Use:     "flaky",
+		Short:   "detect the statements whose coverage differs between repeated runs",
+		Long:    flakyLong,
+		Example: flakyExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			o.StdOut = cmd.OutOrStdout()
+
+			flaky, err := gocover.NewFlakyCoverage(o)
+			if err != nil {
+				return fmt.Errorf("NewFlakyCoverage: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := flaky.Run(ctx); err != nil {
+				return fmt.Errorf("detect flaky coverage: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, `coverage profiles produced by repeated runs of 'go test'`)
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, `branch to compare, only check the changed statements if it's set`)
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", `the root directory of git repository`)
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+	cmd.Flags().StringSliceVar(&o.Excludes, "excludes", []string{}, "exclude files for flaky coverage detection")
+	cmd.Flags().StringVarP(&o.OutputDir, "outputdir", "o", o.OutputDir, "flaky coverage output directory")
+	cmd.Flags().BoolVar(&o.FailOnFlaky, "fail-on-flaky", false, "returns an error code if any flaky statement is found")
+
+	cmd.MarkFlagRequired("cover-profile")
+
+	return cmd
+}
+
+func newAggregateCommand() *cobra.Command {
+	o := gocover.NewAggregateOption()
+
+	cmd := &cobra.Command{
+		Use:     "aggregate",
+		Short:   "roll up the coverage results of several repositories",
+		Long:    aggregateLong,
+		Example: aggregateExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, "", nil)
+			o.StdOut = cmd.OutOrStdout()
+
+			aggregate, err := gocover.NewAggregate(o)
+			if err != nil {
+				return fmt.Errorf("NewAggregate: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := aggregate.Run(ctx); err != nil {
+				return fmt.Errorf("aggregate coverage: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.Results, "result", []string{}, "coverage result of a repository, format is [{team}=]{path}, path is a history record file or a history directory")
+	cmd.Flags().StringVarP(&o.OutputDir, "outputdir", "o", o.OutputDir, "aggregate coverage output directory")
+	cmd.Flags().StringVar(&o.ReportName, "report-name", o.ReportName, "aggregate coverage report name")
+	cmd.Flags().StringVar(&o.Compression, "compression", "", `compress the json report with "gzip" or "zstd"`)
+
+	cmd.MarkFlagRequired("result")
+
+	return cmd
+}
+
+func newHistoryCommand() *cobra.Command {
+	o := gocover.NewHistoryOption()
+
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "query the coverage results in the history directory",
+		Long:    historyLong,
+		Example: historyExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, "", nil)
+			o.StdOut = cmd.OutOrStdout()
+
+			query, err := gocover.NewHistoryQuery(o)
+			if err != nil {
+				return fmt.Errorf("NewHistoryQuery: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := query.Run(ctx); err != nil {
+				return fmt.Errorf("query history: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.HistoryDir, "history-dir", "", "directory of the history records that are stored by the full command")
+	cmd.Flags().StringVar(&o.ModulePath, "module", "", "module path of the records, all modules if it's empty")
+	cmd.Flags().StringVar(&o.Branch, "branch", "", "branch of the records, all branches if it's empty")
+	cmd.Flags().StringVar(&o.CoverageMode, "coverage-mode", "", "coverage mode of the records, full or diff, all modes if it's empty")
+	cmd.Flags().StringVar(&o.Since, "since", "", "query the records since the date (2006-01-02) or time (RFC3339)")
+	cmd.Flags().StringVar(&o.Until, "until", "", "query the records until the date (2006-01-02), which is included, or before the time (RFC3339)")
+	cmd.Flags().StringVar(&o.Package, "package", "", `report the coverage of the package instead of the total coverage, the import path or the path relative to the module, use "pkg/x/..." to include the sub packages`)
+	cmd.Flags().IntVar(&o.Limit, "limit", 0, "query the given number of the latest records, all records if it's zero")
+	cmd.Flags().StringVar(&o.Format, "format", o.Format, "output format, table, json or csv")
+	cmd.Flags().StringVar(&o.Output, "output", "", "file that the records are exported to, stdout if it's empty")
+
+	cmd.MarkFlagRequired("history-dir")
+
+	return cmd
+}
+
+func newServeCommand() *cobra.Command {
+	o := gocover.NewServeOption()
+
+	cmd := &cobra.Command{
+		Use:     "serve",
+		Short:   "serve the latest coverage in the history directory as OpenMetrics",
+		Long:    serveLong,
+		Example: serveExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, "", nil)
+
+			server, err := gocover.NewServe(o)
+			if err != nil {
+				return fmt.Errorf("NewServe: %w", err)
+			}
+
+			// the server runs until it's interrupted or terminated, so no timeout is applied
+			if err := server.Run(cmd.Context()); err != nil {
+				return fmt.Errorf("serve: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.HistoryDir, "history-dir", "", "directory of the history records")
+	cmd.Flags().StringVar(&o.Address, "address", o.Address, "address that the server listens on")
+	cmd.Flags().StringVar(&o.ModulePath, "module", "", "module path of the records, all modules if it's empty")
+	cmd.Flags().StringVar(&o.Branch, "branch", "", "branch of the records, all branches if it's empty")
+
+	cmd.MarkFlagRequired("history-dir")
+
+	return cmd
+}
+
+func newLSPCommand() *cobra.Command {
+	o := gocover.NewLSPOption()
+
+	cmd := &cobra.Command{
+		Use:     "lsp",
+		Short:   "start a language server that publishes diagnostics for uncovered changed lines",
+		Long:    lspLong,
+		Example: lspExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			detectCompareBranch(cmd, &o.CompareBranch, o.FetchRemote, o.Logger)
+			o.StdIn = cmd.InOrStdin()
+			o.StdOut = cmd.OutOrStdout()
+
+			server, err := gocover.NewLSP(o)
+			if err != nil {
+				return fmt.Errorf("NewLSP: %w", err)
+			}
+
+			// the language server runs until the editor exits, so no timeout is applied
+			if err := server.Run(cmd.Context()); err != nil {
+				return fmt.Errorf("language server: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, "coverage profile produced by 'go test'")
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, "branch to compare, defaults to the target branch of the pull request when it runs in CI")
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of git repository")
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+	cmd.Flags().StringSliceVar(&o.Excludes, "excludes", []string{}, "exclude files for diff coverage calucation")
+	cmd.Flags().StringVar(&o.NewCodeSince, "new-code-since", "", "check the code changed since the date (2006-01-02), time (RFC3339) or git ref instead of the compare branch, all the files are compared with the ref or the last commit before the date")
+	cmd.Flags().StringVar(&o.FetchRemote, "fetch-remote", o.FetchRemote, "remote to fetch the compare branch from when it's missing in a shallow clone, disabled if it's empty")
+
+	cmd.MarkFlagRequired("cover-profile")
+
+	return cmd
+}
+
+func newShowCommand() *cobra.Command {
+	o := gocover.NewShowOption()
+
+	cmd := &cobra.Command{
+		Use:     "show <file>",
+		Short:   "print a source file with the coverage state of each line to the terminal",
+		Long:    showLong,
+		Example: showExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			if o.Changed {
+				detectCompareBranch(cmd, &o.CompareBranch, o.FetchRemote, o.Logger)
+			}
+			o.File = args[0]
+			o.NoColor = o.NoColor || os.Getenv("NO_COLOR") != ""
+			o.StdOut = cmd.OutOrStdout()
+
+			show, err := gocover.NewShow(o)
+			if err != nil {
+				return fmt.Errorf("NewShow: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := show.Run(ctx); err != nil {
+				return fmt.Errorf("show coverage: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, "coverage profile produced by 'go test'")
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of git repository")
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+	cmd.Flags().BoolVar(&o.Changed, "changed", false, "print only the changed hunks of the file with the diff coverage")
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, "branch to compare with --changed flag, defaults to the target branch of the pull request when it runs in CI")
+	cmd.Flags().StringVar(&o.NewCodeSince, "new-code-since", "", "check the code changed since the date (2006-01-02), time (RFC3339) or git ref instead of the compare branch, all the files are compared with the ref or the last commit before the date")
+	cmd.Flags().StringVar(&o.FetchRemote, "fetch-remote", o.FetchRemote, "remote to fetch the compare branch from when it's missing in a shallow clone, disabled if it's empty")
+	cmd.Flags().BoolVar(&o.NoColor, "no-color", false, "print the markers of the coverage states without colors")
+
+	cmd.MarkFlagRequired("cover-profile")
+
+	return cmd
+}
+
+func newBazelCommand() *cobra.Command {
+	o := gocover.NewBazelOption()
+
+	cmd := &cobra.Command{
+		Use:     "bazel",
+		Short:   "import the coverage data of bazel coverage into a go cover profile",
+		Long:    bazelLong,
+		Example: bazelExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverageReports)
+
+			importer, err := gocover.NewBazelImporter(o)
+			if err != nil {
+				return fmt.Errorf("NewBazelImporter: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := importer.Run(ctx); err != nil {
+				return fmt.Errorf("import bazel coverage: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.CoverageReports, "coverage-report", []string{}, "coverage data produced by 'bazel coverage', in lcov or go format, such as bazel-out/_coverage/_coverage_report.dat")
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of the bazel workspace")
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the workspace")
+	cmd.Flags().StringVar(&o.Output, "output", o.Output, "go cover profile that the coverage is written to")
+
+	cmd.MarkFlagRequired("coverage-report")
+
+	return cmd
+}
+
+func newValidateCommand() *cobra.Command {
+	o := &gocover.ValidateOption{}
+
+	cmd := &cobra.Command{
+		Use:     "validate <profile>...",
+		Short:   "check the cover profiles for malformed lines, unknown files, overlapping blocks and mode mismatches",
+		Long:    validateLong,
+		Example: validateExample,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.CoverProfiles = args
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			o.StdOut = cmd.OutOrStdout()
+
+			validate, err := gocover.NewValidate(o)
+			if err != nil {
+				return fmt.Errorf("NewValidate: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := validate.Run(ctx); err != nil {
+				return fmt.Errorf("validate cover profiles: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of git repository")
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+
+	return cmd
+}
+
+func newLintAnnotationsCommand() *cobra.Command {
+	o := &gocover.LintAnnotationsOption{}
+
+	cmd := &cobra.Command{
+		Use:     "lint-annotations [path]...",
+		Short:   "check the ignore annotations for unknown directives, missing comments and wrong positions",
+		Long:    lintAnnotationsLong,
+		Example: lintAnnotationsExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Paths = args
+			o.Logger = createLogger(cmd, "", nil)
+			o.StdOut = cmd.OutOrStdout()
+
+			lint, err := gocover.NewLintAnnotations(o)
+			if err != nil {
+				return fmt.Errorf("NewLintAnnotations: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := lint.Run(ctx); err != nil {
+				return fmt.Errorf("lint annotations: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newDoctorCommand() *cobra.Command {
+	o := gocover.NewDoctorOption()
+	auth := &gittool.FetchAuth{}
+
+	cmd := &cobra.Command{
+		Use:     "doctor",
+		Short:   "check the environment of the runs and print the fixes of the problems",
+		Long:    doctorLong,
+		Example: doctorExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			detectCompareBranch(cmd, &o.CompareBranch, o.FetchRemote, o.Logger)
+			o.FetchAuth = fetchAuth(auth)
+			o.DbOption = dbOption
+			o.GitHubOption = githubOption()
+			o.StdOut = cmd.OutOrStdout()
+
+			doctor, err := gocover.NewDoctor(o)
+			if err != nil {
+				return fmt.Errorf("NewDoctor: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := doctor.Run(ctx); err != nil {
+				return fmt.Errorf("doctor: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, "coverage profile produced by 'go test'")
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of git repository")
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, "branch to check, defaults to the target branch of the pull request when it runs in CI, skipped if it's empty")
+	cmd.Flags().StringVar(&o.FetchRemote, "fetch-remote", o.FetchRemote, "remote to fetch the compare branch from when it's missing in a shallow clone, disabled if it's empty")
+	cmd.Flags().IntVar(&o.SampleFiles, "sample-files", o.SampleFiles, "number of the files in the cover profiles whose packages are resolved")
+	addFetchAuthFlags(cmd, auth)
+
+	return cmd
+}
+
+func newInitCommand() *cobra.Command {
+	o := &gocover.InitOption{}
+
+	cmd := &cobra.Command{
+		Use:     "init",
+		Short:   "write a starter configuration file and print a CI snippet for the repository",
+		Long:    initLong,
+		Example: initExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, nil)
+			o.StdOut = cmd.OutOrStdout()
+
+			initConfig, err := gocover.NewInit(o)
+			if err != nil {
+				return fmt.Errorf("NewInit: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := initConfig.Run(ctx); err != nil {
+				return fmt.Errorf("init configuration: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of git repository")
+	cmd.Flags().StringVar(&o.CIProvider, "ci", "", "CI provider of the snippet, one of github-actions, gitlab, azure-pipelines, bitbucket, jenkins and buildkite, detected from the CI files if it's empty")
+	cmd.Flags().BoolVar(&o.Force, "force", false, "overwrite the configuration file that exists")
+
+	return cmd
+}
+
+func newScaffoldCommand() *cobra.Command {
+	o := gocover.NewScaffoldOption()
+
+	cmd := &cobra.Command{
+		Use:     "scaffold",
+		Short:   "generate the table test skeletons of the uncovered functions",
+		Long:    scaffoldLong,
+		Example: scaffoldExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Logger = createLogger(cmd, o.RepositoryPath, o.CoverProfiles)
+			if o.Changed {
+				detectCompareBranch(cmd, &o.CompareBranch, o.FetchRemote, o.Logger)
+			}
+			o.StdOut = cmd.OutOrStdout()
+
+			scaffold, err := gocover.NewScaffold(o)
+			if err != nil {
+				return fmt.Errorf("NewScaffold: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), defaultTimeoutInSeconds*time.Second)
+			defer cancel()
+
+			if err := scaffold.Run(ctx); err != nil {
+				return fmt.Errorf("scaffold tests: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.CoverProfiles, "cover-profile", []string{}, "coverage profile produced by 'go test'")
+	cmd.Flags().StringVar(&o.RepositoryPath, "repository-path", "./", "the root directory of git repository")
+	cmd.Flags().StringVar(&o.ModuleDir, "module-dir", "./", "module directory contains go.mod file that relative to the project")
+	cmd.Flags().BoolVar(&o.Changed, "changed", false, "scaffold only the uncovered functions that are changed compared with the compare branch")
+	cmd.Flags().StringVar(&o.CompareBranch, "compare-branch", o.CompareBranch, "branch to compare with --changed flag, defaults to the target branch of the pull request when it runs in CI")
+	cmd.Flags().StringVar(&o.NewCodeSince, "new-code-since", "", "check the code changed since the date (2006-01-02), time (RFC3339) or git ref instead of the compare branch, all the files are compared with the ref or the last commit before the date")
+	cmd.Flags().StringVar(&o.FetchRemote, "fetch-remote", o.FetchRemote, "remote to fetch the compare branch from when it's missing in a shallow clone, disabled if it's empty")
+	cmd.Flags().BoolVar(&o.Print, "print", false, "print the test skeletons to stdout instead of writing the files")
+
+	cmd.MarkFlagRequired("cover-profile")
+
 	return cmd
 }
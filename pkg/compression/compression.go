@@ -0,0 +1,133 @@
+// Package compression compresses the stored json reports and history records,
+// the data is decompressed transparently on read whichever algorithm it's compressed with.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Algorithm is the compression algorithm.
+type Algorithm string
+
+const (
+	None Algorithm = ""
+	Gzip Algorithm = "gzip"
+	Zstd Algorithm = "zstd"
+)
+
+var ErrUnknownAlgorithm = errors.New(`supported compression algorithms are "gzip" and "zstd", unknown compression algorithm`)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Parse parses the name of the algorithm, empty name means no compression.
+func Parse(name string) (Algorithm, error) {
+	switch a := Algorithm(name); a {
+	case None, Gzip, Zstd:
+		return a, nil
+	default:
+		return None, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, name)
+	}
+}
+
+// Extension returns the file extension of the algorithm, which is appended to the file name.
+func (a Algorithm) Extension() string {
+	switch a {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// Compress compresses the data with the algorithm.
+func Compress(a Algorithm, data []byte) ([]byte, error) {
+	switch a {
+	case None:
+		return data, nil
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("gzip compress: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("gzip compress: %w", err)
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd compress: %w", err)
+		}
+		defer w.Close()
+		return w.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, a)
+	}
+}
+
+// Decompress detects the algorithm by the magic number of the data and decompresses it,
+// the data that is not compressed is returned as it is.
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip decompress: %w", err)
+		}
+		defer r.Close()
+		result, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip decompress: %w", err)
+		}
+		return result, nil
+	case bytes.HasPrefix(data, zstdMagic):
+		r, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd decompress: %w", err)
+		}
+		defer r.Close()
+		result, err := r.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd decompress: %w", err)
+		}
+		return result, nil
+	default:
+		return data, nil
+	}
+}
+
+// ReadFile reads the file and decompresses its contents.
+func ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Decompress(data)
+}
+
+// WriteFile compresses the data with the algorithm and writes it to the file,
+// the extension of the algorithm is appended to the file name. It returns the name of the written file.
+func WriteFile(name string, data []byte, perm os.FileMode, a Algorithm) (string, error) {
+	compressed, err := Compress(a, data)
+	if err != nil {
+		return "", err
+	}
+	name += a.Extension()
+	if err := os.WriteFile(name, compressed, perm); err != nil {
+		return "", err
+	}
+	return name, nil
+}
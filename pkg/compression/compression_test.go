@@ -0,0 +1,68 @@
+package compression
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		for _, name := range []string{"", "gzip", "zstd"} {
+			a, err := Parse(name)
+			assert.NoError(t, err)
+			assert.Equal(t, Algorithm(name), a)
+		}
+
+		_, err := Parse("lz4")
+		assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+	})
+}
+
+func TestCompress(t *testing.T) {
+	data := []byte(`{"modulePath":"github.com/Azure/gocover","coveragePercent":80}`)
+
+	for _, a := range []Algorithm{None, Gzip, Zstd} {
+		t.Run(string(a), func(t *testing.T) {
+			compressed, err := Compress(a, data)
+			assert.NoError(t, err)
+			if a != None {
+				assert.NotEqual(t, data, compressed)
+			}
+
+			decompressed, err := Decompress(compressed)
+			assert.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+		})
+	}
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		_, err := Compress("lz4", data)
+		assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+	})
+
+	t.Run("broken data", func(t *testing.T) {
+		_, err := Decompress(append([]byte{}, gzipMagic...))
+		assert.Error(t, err)
+	})
+}
+
+func TestFile(t *testing.T) {
+	t.Run("write and read file", func(t *testing.T) {
+		dir := t.TempDir()
+		data := []byte(`{"foo":"bar"}`)
+
+		name, err := WriteFile(filepath.Join(dir, "report.json"), data, 0644, Zstd)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "report.json.zst"), name)
+
+		result, err := ReadFile(name)
+		assert.NoError(t, err)
+		assert.Equal(t, data, result)
+
+		_, err = ReadFile(filepath.Join(dir, "nonexist.json"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,161 @@
+// Package config loads the gocover configuration file, which keeps the settings
+// that are shared by the runs of a repository, so that they are not passed as flags in each pipeline.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Azure/gocover/pkg/retry"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the configuration file that is loaded from the working directory when it exists.
+const DefaultFile = ".gocover.yaml"
+
+// Config is the content of the configuration file. The flags override the values of the configuration file.
+type Config struct {
+	// Retry is the retry policy of the calls to the external services.
+	Retry Retry `yaml:"retry"`
+	// Kusto is the settings of the kusto db client.
+	Kusto Kusto `yaml:"kusto"`
+	// GitHub is the settings of the GitHub API, such as the API of a GitHub Enterprise Server.
+	GitHub GitHub `yaml:"github"`
+	// HTTP is how the external services are called, such as through a proxy with a custom CA.
+	HTTP HTTP `yaml:"http"`
+	// Store is the db clients that the coverage data is stored to.
+	Store Store `yaml:"store"`
+	// Exclude is the code that is excluded from coverage calculation.
+	Exclude Exclude `yaml:"exclude"`
+	// RunLabels are the key/value labels of the runs, such as the service, which are merged with the labels of the flags.
+	RunLabels map[string]string `yaml:"runLabels"`
+	// Webhooks are the webhooks that the json result of the full and diff commands is posted to.
+	Webhooks []Webhook `yaml:"webhooks"`
+	// MixedCoverModes is how the cover profiles of the set mode are combined with the count or atomic profiles,
+	// "normalize" or "reject".
+	MixedCoverModes string `yaml:"mixedCoverModes"`
+	// CompatibleIgnoreMarkers honors the ignore markers of other coverage tools as the gocover ignore annotations.
+	CompatibleIgnoreMarkers bool `yaml:"compatibleIgnoreMarkers"`
+}
+
+// Webhook is a webhook in the configuration file.
+type Webhook struct {
+	URL string `yaml:"url"`
+	// Headers are the headers of the request, the values are go templates of the json result,
+	// such as 'Bearer {{ env "WEBHOOK_TOKEN" }}'.
+	Headers map[string]string `yaml:"headers"`
+	// Body is the go template of the body, the fields of the json result are referred to by their json names,
+	// such as {{ .coveragePercent }}. The json result is posted if it's empty.
+	Body string `yaml:"body"`
+	// SecretEnv is the environment variable of the key that signs the body, the body is not signed if it's empty,
+	// so that the secret is not kept in the repository.
+	SecretEnv string `yaml:"secretEnv"`
+}
+
+// Exclude is the code that is excluded from coverage calculation in the configuration file.
+type Exclude struct {
+	// Functions are the regular expressions of the function names, such as "String$" and "^Must".
+	// The methods are named T.N, and the function literals are excluded along with their enclosing functions.
+	Functions []string `yaml:"functions"`
+	// BuildTags are the build tags whose files are excluded, such as integration and tools.
+	BuildTags []string `yaml:"buildTags"`
+}
+
+// Store is the db clients in the configuration file, the data is stored to each of them.
+type Store struct {
+	// Types are the db types, such as Kusto and File.
+	Types []string `yaml:"types"`
+	// Optional are the db types whose failures don't fail the run.
+	Optional []string `yaml:"optional"`
+	// Dir is the directory of the File db.
+	Dir string `yaml:"dir"`
+}
+
+// Kusto is the settings of the kusto db client in the configuration file, empty values are not set.
+type Kusto struct {
+	// Auth is the auth method, one of service-principal, managed-identity, azure-cli and device-code.
+	Auth                      string `yaml:"auth"`
+	ManagedIdentityResourceID string `yaml:"managedIdentityResourceId"`
+
+	Endpoint      string `yaml:"endpoint"`
+	Database      string `yaml:"database"`
+	CoverageTable string `yaml:"coverageTable"`
+	IgnoreTable   string `yaml:"ignoreTable"`
+	// Columns maps the names of the columns to the names in the tables.
+	Columns map[string]string `yaml:"columns"`
+	// StaticColumns are the string columns with the same value for all the records.
+	StaticColumns map[string]string `yaml:"staticColumns"`
+}
+
+// GitHub is the settings of the GitHub API in the configuration file, empty values are not set.
+type GitHub struct {
+	// APIURL is the url of the API, such as https://github.example.com/api/v3.
+	APIURL string `yaml:"apiUrl"`
+	// ServerURL is the url of the GitHub Enterprise Server, whose API is {server}/api/v3.
+	ServerURL string `yaml:"serverUrl"`
+	// APIVersion is the X-GitHub-Api-Version header, "none" omits it.
+	APIVersion string `yaml:"apiVersion"`
+}
+
+// HTTP is the settings of the http client of the external services in the configuration file, empty values are not set.
+type HTTP struct {
+	// Proxy is the url of the proxy, which overrides HTTPS_PROXY and HTTP_PROXY.
+	Proxy string `yaml:"proxy"`
+	// CABundle is the PEM file of the CA certificates that are trusted in addition to the system roots.
+	CABundle string `yaml:"caBundle"`
+	// ClientCert and ClientKey are the PEM files of the client certificate of mutual TLS.
+	ClientCert string `yaml:"clientCert"`
+	ClientKey  string `yaml:"clientKey"`
+}
+
+// Retry is the retry policy in the configuration file, the values that are not set keep the defaults.
+type Retry struct {
+	MaxAttempts    *int           `yaml:"maxAttempts"`
+	InitialBackoff *time.Duration `yaml:"initialBackoff"`
+	MaxBackoff     *time.Duration `yaml:"maxBackoff"`
+	Multiplier     *float64       `yaml:"multiplier"`
+	Jitter         *float64       `yaml:"jitter"`
+}
+
+// Apply sets the values of the retry policy that are set in the configuration file.
+func (r *Retry) Apply(p *retry.Policy) {
+	if r.MaxAttempts != nil {
+		p.MaxAttempts = *r.MaxAttempts
+	}
+	if r.InitialBackoff != nil {
+		p.InitialBackoff = *r.InitialBackoff
+	}
+	if r.MaxBackoff != nil {
+		p.MaxBackoff = *r.MaxBackoff
+	}
+	if r.Multiplier != nil {
+		p.Multiplier = *r.Multiplier
+	}
+	if r.Jitter != nil {
+		p.Jitter = *r.Jitter
+	}
+}
+
+// Load reads the configuration file. An empty configuration is returned
+// when the file doesn't exist and it's not required, such as the default file.
+func Load(path string, required bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	c := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("file not exist", func(t *testing.T) {
+		c, err := Load(filepath.Join(dir, DefaultFile), false)
+		assert.NoError(t, err)
+		assert.Equal(t, &Config{}, c)
+
+		_, err = Load(filepath.Join(dir, DefaultFile), true)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := filepath.Join(dir, "empty.yaml")
+		assert.NoError(t, os.WriteFile(path, nil, 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, &Config{}, c)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		path := filepath.Join(dir, "retry.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("retry:\n  maxAttempts: 5\n  initialBackoff: 500ms\n  jitter: 0\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+
+		p := retry.DefaultPolicy()
+		c.Retry.Apply(&p)
+		assert.Equal(t, 5, p.MaxAttempts)
+		assert.Equal(t, 500*time.Millisecond, p.InitialBackoff)
+		assert.Equal(t, retry.DefaultMaxBackoff, p.MaxBackoff)
+		assert.Equal(t, float64(retry.DefaultMultiplier), p.Multiplier)
+		assert.Equal(t, float64(0), p.Jitter)
+	})
+
+	t.Run("kusto", func(t *testing.T) {
+		path := filepath.Join(dir, "kusto.yaml")
+		data := "kusto:\n  auth: azure-cli\n  database: db\n  coverageTable: Coverage\n" +
+			"  columns:\n    coverage: CoveragePercent\n  staticColumns:\n    team: platform\n"
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, Kusto{
+			Auth:          "azure-cli",
+			Database:      "db",
+			CoverageTable: "Coverage",
+			Columns:       map[string]string{"coverage": "CoveragePercent"},
+			StaticColumns: map[string]string{"team": "platform"},
+		}, c.Kusto)
+	})
+
+	t.Run("store", func(t *testing.T) {
+		path := filepath.Join(dir, "store.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("store:\n  types: [Kusto, File]\n  optional: [File]\n  dir: coverage\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, Store{Types: []string{"Kusto", "File"}, Optional: []string{"File"}, Dir: "coverage"}, c.Store)
+	})
+
+	t.Run("github", func(t *testing.T) {
+		path := filepath.Join(dir, "github.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("github:\n  serverUrl: https://github.example.com\n  apiVersion: none\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, GitHub{ServerURL: "https://github.example.com", APIVersion: "none"}, c.GitHub)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		path := filepath.Join(dir, "http.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("http:\n  proxy: http://proxy.example.com:8080\n  caBundle: /etc/ssl/corp.pem\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, HTTP{Proxy: "http://proxy.example.com:8080", CABundle: "/etc/ssl/corp.pem"}, c.HTTP)
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		path := filepath.Join(dir, "exclude.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("exclude:\n  functions: ['String$', '^Must']\n  buildTags: [integration]\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, Exclude{Functions: []string{"String$", "^Must"}, BuildTags: []string{"integration"}}, c.Exclude)
+	})
+
+	t.Run("run labels", func(t *testing.T) {
+		path := filepath.Join(dir, "labels.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("runLabels:\n  service: api\n  suite: unit\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"service": "api", "suite": "unit"}, c.RunLabels)
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		path := filepath.Join(dir, "webhooks.yaml")
+		data := "webhooks:\n  - url: https://example.com/hook\n    headers:\n      X-Team: platform\n" +
+			"    body: '{\"coverage\": {{ .coveragePercent }}}'\n    secretEnv: WEBHOOK_SECRET\n"
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []Webhook{{
+			URL:       "https://example.com/hook",
+			Headers:   map[string]string{"X-Team": "platform"},
+			Body:      `{"coverage": {{ .coveragePercent }}}`,
+			SecretEnv: "WEBHOOK_SECRET",
+		}}, c.Webhooks)
+	})
+
+	t.Run("mixed cover modes", func(t *testing.T) {
+		path := filepath.Join(dir, "modes.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("mixedCoverModes: reject\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.Equal(t, "reject", c.MixedCoverModes)
+	})
+
+	t.Run("compatible ignore markers", func(t *testing.T) {
+		path := filepath.Join(dir, "markers.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("compatibleIgnoreMarkers: true\n"), 0644))
+		c, err := Load(path, true)
+		assert.NoError(t, err)
+		assert.True(t, c.CompatibleIgnoreMarkers)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := filepath.Join(dir, "unknown.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte("retry:\n  attempts: 5\n"), 0644))
+		_, err := Load(path, true)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,81 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-kusto-go/kusto/data/table"
+	"github.com/Azure/azure-kusto-go/kusto/kql"
+)
+
+var ErrTableNotFound = errors.New("kusto table is not found")
+
+// Check verifies that the data can be stored to the db of the type without storing any data.
+// The File db creates a file in its directory, and the Kusto db lists the tables of the database
+// with the credential of the auth method and checks the coverage and ignore tables exist.
+func (o *DBOption) Check(ctx context.Context, dbType ClientType) error {
+	switch dbType {
+	case File:
+		if err := o.FileOption.Validate(); err != nil {
+			return err
+		}
+		return o.FileOption.check()
+	case Kusto:
+		if err := o.KustoOption.Validate(); err != nil {
+			return err
+		}
+		return o.KustoOption.check(ctx)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedDBType, dbType)
+	}
+}
+
+func (o *FileOption) check() error {
+	if err := os.MkdirAll(o.Dir, os.ModePerm); err != nil {
+		return fmt.Errorf("create store dir: %w", err)
+	}
+	f, err := os.CreateTemp(o.Dir, ".gocover-check-*")
+	if err != nil {
+		return fmt.Errorf("write store dir: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (o *KustoOption) check(ctx context.Context) error {
+	client, err := o.newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	iter, err := client.Mgmt(ctx, o.Database, kql.New(".show tables"))
+	if err != nil { //+gocover:ignore:block cannot test kusto connection without enough credentials
+		return fmt.Errorf("list tables of %s: %w", o.Database, err)
+	}
+	defer iter.Stop()
+
+	tables := make(map[string]bool)
+	err = iter.Do(func(row *table.Row) error {
+		var t struct {
+			TableName string
+		}
+		if err := row.ToStruct(&t); err != nil {
+			return err
+		}
+		tables[t.TableName] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list tables of %s: %w", o.Database, err)
+	}
+
+	for _, name := range []string{o.CoverageEvent, o.IgnoreEvent} {
+		if !tables[name] {
+			return fmt.Errorf("%w: %s in %s", ErrTableNotFound, name, o.Database)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "store")
+		o := &DBOption{FileOption: FileOption{Dir: dir}}
+		if err := o.Check(context.Background(), File); err != nil {
+			t.Fatalf("should return nil, but return %s", err)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("should return nil, but return %s", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expect the store dir to be empty, but get %d files", len(entries))
+		}
+	})
+
+	t.Run("file without dir", func(t *testing.T) {
+		o := &DBOption{}
+		if err := o.Check(context.Background(), File); !errors.Is(err, ErrFlagRequired) {
+			t.Errorf("should return %s, but return %v", ErrFlagRequired, err)
+		}
+	})
+
+	t.Run("kusto without credential", func(t *testing.T) {
+		t.Setenv(tenantIDKey, "")
+		o := &DBOption{KustoOption: KustoOption{AuthMethod: AuthServicePrincipal}}
+		if err := o.Check(context.Background(), Kusto); !errors.Is(err, ErrEnvRequired) {
+			t.Errorf("should return %s, but return %v", ErrEnvRequired, err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		o := &DBOption{}
+		if err := o.Check(context.Background(), None); !errors.Is(err, ErrUnsupportedDBType) {
+			t.Errorf("should return %s, but return %v", ErrUnsupportedDBType, err)
+		}
+	})
+}
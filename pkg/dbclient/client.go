@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Azure/gocover/pkg/ci"
 	"github.com/sirupsen/logrus"
 )
 
@@ -14,6 +15,8 @@ type ClientType string
 const (
 	None  ClientType = "None"
 	Kusto ClientType = "Kusto"
+	// File appends the data as json lines to the files in a directory.
+	File ClientType = "File"
 )
 
 // DbClient interface for storing gocover data.
@@ -37,7 +40,9 @@ type CoverageData struct {
 	ModulePath             string    `json:"modulePath"`             // module name, which is declared in go.mod
 	FilePath               string    `json:"filePath"`               // file path for a concrete file or directory
 
-	Extra map[string]interface{} // extra data that passing accordingly
+	CI     *ci.Environment        `json:"ci,omitempty"`     // information of the CI run, nil when it doesn't run in CI
+	Labels map[string]string      `json:"labels,omitempty"` // key/value labels of the run, such as service=api
+	Extra  map[string]interface{} // extra data that passing accordingly
 }
 
 type IgnoreProfileData struct {
@@ -52,15 +57,26 @@ type IgnoreProfileData struct {
 	Contents         string    `json:"contents"`         // ignore annotation contents
 	IgnoreType       string    `json:"ignoreType"`       // ignore annotation type
 
-	Extra map[string]interface{} // extra data that passing accordingly
+	CI     *ci.Environment        `json:"ci,omitempty"`     // information of the CI run, nil when it doesn't run in CI
+	Labels map[string]string      `json:"labels,omitempty"` // key/value labels of the run, such as service=api
+	Extra  map[string]interface{} // extra data that passing accordingly
 }
 
-var ErrUnsupportedDBType = errors.New(`supportted type are "Kusto", unsupported DB client type`)
+var (
+	ErrUnsupportedDBType = errors.New(`supportted type are "Kusto" and "File", unsupported DB client type`)
+	ErrUnknownOptionalDB = errors.New("optional db type is not one of the db types")
+)
 
 type DBOption struct {
 	DataCollectionEnabled bool
-	DbType                ClientType
-	KustoOption           KustoOption
+	// DbTypes are the db clients that the data is stored to, the data is stored
+	// to each of them even if some of them fail.
+	DbTypes []ClientType
+	// OptionalDbTypes are the db types whose failures are logged without failing the run,
+	// such as the new db when migrating between dbs.
+	OptionalDbTypes []ClientType
+	KustoOption     KustoOption
+	FileOption      FileOption
 }
 
 func (o *DBOption) Validate() error {
@@ -68,18 +84,79 @@ func (o *DBOption) Validate() error {
 		return nil
 	}
 
-	if o.DbType == Kusto {
-		return o.KustoOption.Validate()
+	if len(o.DbTypes) == 0 {
+		return fmt.Errorf("%w: %s", ErrUnsupportedDBType, None)
+	}
+	for _, dbType := range o.DbTypes {
+		var err error
+		switch dbType {
+		case Kusto:
+			err = o.KustoOption.Validate()
+		case File:
+			err = o.FileOption.Validate()
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnsupportedDBType, dbType)
+		}
+		if err != nil {
+			return err
+		}
 	}
-	return fmt.Errorf("%w: %s", ErrUnsupportedDBType, o.DbType)
+	for _, dbType := range o.OptionalDbTypes {
+		if !o.hasDbType(dbType) {
+			return fmt.Errorf("%w: %s", ErrUnknownOptionalDB, dbType)
+		}
+	}
+	return nil
 }
 
+// GetDbClient creates the db client of the db types, the data is stored to all of them
+// when there are more than one db types.
 func (o *DBOption) GetDbClient(logger logrus.FieldLogger) (DbClient, error) {
-	switch o.DbType {
+	var sinks []*sink
+	for _, dbType := range o.DbTypes {
+		client, err := o.newDbClient(dbType, logger)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, &sink{dbType: dbType, client: client, optional: o.isOptional(dbType)})
+	}
+
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDBType, None)
+	}
+	if len(sinks) == 1 && !sinks[0].optional {
+		return sinks[0].client, nil
+	}
+	return &multiClient{sinks: sinks, logger: logger.WithField("source", "multiClient")}, nil
+}
+
+func (o *DBOption) newDbClient(dbType ClientType, logger logrus.FieldLogger) (DbClient, error) {
+	switch dbType {
 	case Kusto:
 		o.KustoOption.Logger = logger
 		return NewKustoClient(&o.KustoOption)
+	case File:
+		o.FileOption.Logger = logger
+		return NewFileClient(&o.FileOption)
 	default:
-		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDBType, o.DbType)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDBType, dbType)
+	}
+}
+
+func (o *DBOption) hasDbType(dbType ClientType) bool {
+	for _, t := range o.DbTypes {
+		if t == dbType {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *DBOption) isOptional(dbType ClientType) bool {
+	for _, t := range o.OptionalDbTypes {
+		if t == dbType {
+			return true
+		}
 	}
+	return false
 }
@@ -1,6 +1,7 @@
 package dbclient
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/sirupsen/logrus"
@@ -9,7 +10,7 @@ import (
 func TestDBOption(t *testing.T) {
 	t.Run("Validate", func(t *testing.T) {
 		// disable data collection
-		o := &DBOption{DataCollectionEnabled: false, DbType: None}
+		o := &DBOption{DataCollectionEnabled: false, DbTypes: []ClientType{None}}
 		if err := o.Validate(); err != nil {
 			t.Errorf("disable data collection, should pass with nil, but get %s", err)
 		}
@@ -21,16 +22,33 @@ func TestDBOption(t *testing.T) {
 		}
 
 		// missing kusto related information
-		o.DbType = Kusto
+		o.DbTypes = []ClientType{Kusto}
 		if err := o.Validate(); err == nil {
 			t.Error("does not provide appropriate information, should return error, but get nil")
 		}
+
+		// missing file related information
+		o.DbTypes = []ClientType{File}
+		if err := o.Validate(); err == nil {
+			t.Error("does not provide store dir, should return error, but get nil")
+		}
+
+		o.FileOption.Dir = t.TempDir()
+		if err := o.Validate(); err != nil {
+			t.Errorf("should pass with nil, but get %s", err)
+		}
+
+		// optional db type should be one of the db types
+		o.OptionalDbTypes = []ClientType{Kusto}
+		if err := o.Validate(); !errors.Is(err, ErrUnknownOptionalDB) {
+			t.Errorf("should return error %s, but get %v", ErrUnknownOptionalDB, err)
+		}
 	})
 
 	t.Run("GetDbClient", func(t *testing.T) {
 		t.Run("NewKustoClient", func(t *testing.T) {
 			o := &DBOption{
-				DbType: Kusto,
+				DbTypes: []ClientType{Kusto},
 				KustoOption: KustoOption{
 					tenantID:      "testTenantID",
 					clientID:      "testClientID",
@@ -51,8 +69,29 @@ func TestDBOption(t *testing.T) {
 			}
 		})
 
+		t.Run("multiple db clients", func(t *testing.T) {
+			o := &DBOption{
+				DbTypes:         []ClientType{File, File},
+				OptionalDbTypes: []ClientType{File},
+				FileOption:      FileOption{Dir: t.TempDir()},
+			}
+			client, err := o.GetDbClient(logrus.New())
+			if err != nil {
+				t.Errorf("should return nil, but return %s", err)
+			}
+			if multi, ok := client.(*multiClient); !ok || len(multi.sinks) != 2 {
+				t.Errorf("should return the client of 2 db clients, but return %T", client)
+			}
+
+			o.DbTypes, o.OptionalDbTypes = []ClientType{File}, nil
+			client, _ = o.GetDbClient(logrus.New())
+			if _, ok := client.(*FileClient); !ok {
+				t.Errorf("should return the file client, but return %T", client)
+			}
+		})
+
 		t.Run("default unsupported", func(t *testing.T) {
-			o := &DBOption{DbType: None}
+			o := &DBOption{DbTypes: []ClientType{None}}
 			_, err := o.GetDbClient(logrus.New())
 			if err == nil {
 				t.Error("unsupported dbtype should return error, but return nil")
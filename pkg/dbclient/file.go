@@ -0,0 +1,120 @@
+package dbclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	coverageDataFile      = "coverage.jsonl"
+	ignoreProfileDataFile = "ignoreprofile.jsonl"
+)
+
+// FileOption is the option of the file client.
+type FileOption struct {
+	// Dir is the directory that the json lines files are appended to,
+	// such as a mounted blob container or the artifact directory of the pipeline.
+	Dir string
+	// Compression compresses each batch of the appended records, the extension of the algorithm is appended to the file names.
+	// The compressed batches are concatenated, which is still a valid gzip or zstd stream.
+	Compression compression.Algorithm
+	Logger      logrus.FieldLogger
+}
+
+// Validate checks the validation of the input on file option.
+func (o *FileOption) Validate() error {
+	if o.Dir == "" {
+		return fmt.Errorf("%s %w", "store-dir", ErrFlagRequired)
+	}
+	return nil
+}
+
+// NewFileClient creates the client that appends the data as json lines to the files in the directory.
+func NewFileClient(option *FileOption) (DbClient, error) {
+	if err := os.MkdirAll(option.Dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	return &FileClient{
+		dir:         option.Dir,
+		compression: option.Compression,
+		logger:      option.Logger.WithField("source", "FileClient"),
+	}, nil
+}
+
+// FileClient appends the coverage data and the ignore profile data to the json lines files,
+// one file for each kind of data.
+type FileClient struct {
+	dir         string
+	compression compression.Algorithm
+	logger      logrus.FieldLogger
+}
+
+var _ DbClient = (*FileClient)(nil)
+
+func (client *FileClient) StoreCoverageDataFromFile(ctx context.Context, data []*CoverageData) error {
+	records := make([]interface{}, 0, len(data))
+	for _, d := range data {
+		// the extra data is set by the kusto client for its custom columns.
+		record := *d
+		record.Extra = nil
+		records = append(records, &record)
+	}
+	return client.append(coverageDataFile, records)
+}
+
+func (client *FileClient) StoreIgnoreProfileDataFromFile(ctx context.Context, data []*IgnoreProfileData) error {
+	records := make([]interface{}, 0, len(data))
+	for _, d := range data {
+		record := *d
+		record.Extra = nil
+		records = append(records, &record)
+	}
+	return client.append(ignoreProfileDataFile, records)
+}
+
+func (client *FileClient) StoreCoverageData(ctx context.Context, data *CoverageData) error {
+	return client.StoreCoverageDataFromFile(ctx, []*CoverageData{data})
+}
+
+func (client *FileClient) StoreIgnoreProfileData(ctx context.Context, data *IgnoreProfileData) error {
+	return client.StoreIgnoreProfileDataFromFile(ctx, []*IgnoreProfileData{data})
+}
+
+// append appends the records to the file in the directory, one json object per line.
+func (client *FileClient) append(name string, records []interface{}) error {
+	path := filepath.Join(client.dir, name+client.compression.Extension())
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return fmt.Errorf("encode %s: %w", path, err)
+		}
+	}
+	data, err := compression.Compress(client.compression, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("compress %s: %w", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", path, err)
+	}
+
+	client.logger.Debugf("append %d records to %s", len(records), path)
+	return nil
+}
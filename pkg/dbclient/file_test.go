@@ -0,0 +1,94 @@
+package dbclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFileClient(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+	client, err := NewFileClient(&FileOption{Dir: dir, Logger: logrus.New()})
+	if err != nil {
+		t.Fatalf("should return nil, but return %s", err)
+	}
+	ctx := context.Background()
+
+	data := []*CoverageData{
+		{FilePath: "github.com/Azure/gocover/pkg", Extra: map[string]interface{}{"team": "platform"}},
+		{FilePath: "github.com/Azure/gocover/pkg/foo.go"},
+	}
+	if err := client.StoreCoverageDataFromFile(ctx, data); err != nil {
+		t.Errorf("should return nil, but return %s", err)
+	}
+	if err := client.StoreCoverageData(ctx, &CoverageData{FilePath: "github.com/Azure/gocover"}); err != nil {
+		t.Errorf("should return nil, but return %s", err)
+	}
+	if err := client.StoreIgnoreProfileData(ctx, &IgnoreProfileData{FilePath: "github.com/Azure/gocover/pkg/foo.go"}); err != nil {
+		t.Errorf("should return nil, but return %s", err)
+	}
+
+	lines := readLines(t, filepath.Join(dir, coverageDataFile))
+	if len(lines) != 3 {
+		t.Fatalf("expect 3 coverage records, but get %d", len(lines))
+	}
+	var d CoverageData
+	if err := json.Unmarshal([]byte(lines[0]), &d); err != nil {
+		t.Errorf("should unmarshal the record, but get %s", err)
+	}
+	if d.FilePath != "github.com/Azure/gocover/pkg" || d.Extra != nil {
+		t.Errorf("unexpected record %s", lines[0])
+	}
+	if data[0].Extra == nil {
+		t.Error("the data should not be modified")
+	}
+
+	if lines := readLines(t, filepath.Join(dir, ignoreProfileDataFile)); len(lines) != 1 {
+		t.Errorf("expect 1 ignore profile record, but get %d", len(lines))
+	}
+}
+
+func TestFileClientCompression(t *testing.T) {
+	dir := t.TempDir()
+	client, err := NewFileClient(&FileOption{Dir: dir, Compression: compression.Gzip, Logger: logrus.New()})
+	if err != nil {
+		t.Fatalf("should return nil, but return %s", err)
+	}
+	ctx := context.Background()
+
+	for _, path := range []string{"github.com/Azure/gocover", "github.com/Azure/gocover/pkg"} {
+		if err := client.StoreCoverageData(ctx, &CoverageData{FilePath: path}); err != nil {
+			t.Errorf("should return nil, but return %s", err)
+		}
+	}
+
+	data, err := compression.ReadFile(filepath.Join(dir, coverageDataFile+".gz"))
+	if err != nil {
+		t.Fatalf("should read the compressed records, but get %s", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("expect 2 coverage records, but get %d", len(lines))
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %s", path, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
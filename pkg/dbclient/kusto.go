@@ -9,12 +9,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/Azure/azure-kusto-go/kusto"
+	kustoerrors "github.com/Azure/azure-kusto-go/kusto/data/errors"
 	"github.com/Azure/azure-kusto-go/kusto/ingest"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/gocover/pkg/retry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,6 +27,24 @@ var (
 	ErrEnvRequired        = errors.New("environment is required for kusto db")
 	ErrFlagRequired       = errors.New("flag is required for kusto db")
 	ErrFormatCustomColumn = errors.New("wrong format, kusto custom column format is {column}:{datatype}:{value}")
+	ErrUnknownColumn      = errors.New("unknown kusto column")
+	ErrUnknownAuthMethod  = errors.New(`supported kusto auth methods are "service-principal", "managed-identity", "azure-cli" and "device-code", unknown auth method`)
+)
+
+// AuthMethod is the method to authenticate on kusto.
+type AuthMethod string
+
+const (
+	// AuthServicePrincipal authenticates with the client secret of the service principal,
+	// which is read from the environment variables KUSTO_TENANT_ID, KUSTO_CLIENT_ID and KUSTO_CLIENT_SECRET.
+	AuthServicePrincipal AuthMethod = "service-principal"
+	// AuthManagedIdentity authenticates with the managed identity of the resource ManagedIdentityResouceID,
+	// or the system-assigned managed identity when it's empty.
+	AuthManagedIdentity AuthMethod = "managed-identity"
+	// AuthAzureCLI authenticates with the account that logs in with `az login`.
+	AuthAzureCLI AuthMethod = "azure-cli"
+	// AuthDeviceCode authenticates interactively, the user opens the url and enters the code that are logged.
+	AuthDeviceCode AuthMethod = "device-code"
 )
 
 const (
@@ -34,22 +57,9 @@ const (
 )
 
 func NewKustoClient(option *KustoOption) (DbClient, error) {
-	var kcsb *kusto.ConnectionStringBuilder
-	if option.ManagedIdentityResouceID != "" {
-		msiCred, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
-			ID: azidentity.ResourceID(option.ManagedIdentityResouceID),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("new managed identity credential: %w", err)
-		}
-		kcsb = kusto.NewConnectionStringBuilder(option.Endpoint).WithTokenCredential(msiCred)
-	} else {
-		kcsb = kusto.NewConnectionStringBuilder(option.Endpoint).WithAadAppKey(option.clientID, option.clientSecret, option.tenantID)
-	}
-
-	kustoClient, err := kusto.New(kcsb)
+	kustoClient, err := option.newClient()
 	if err != nil {
-		return nil, fmt.Errorf("new kusto: %w", err)
+		return nil, err
 	}
 
 	coverageIngestor, err := ingest.New(kustoClient, option.Database, option.CoverageEvent)
@@ -69,6 +79,8 @@ func NewKustoClient(option *KustoOption) (DbClient, error) {
 		ignoreIngestor:   ignoreIngestor,
 		mappings:         option.extraMappings,
 		extraData:        option.extraData,
+		columns:          option.ColumnNames,
+		retry:            kustoRetryPolicy(option.Retry),
 		logger:           option.Logger.WithField("source", "KustoClient"),
 	}, nil
 
@@ -80,7 +92,11 @@ type KustoClient struct {
 	ignoreIngestor   ingest.Ingestor
 	mappings         []mapping
 	extraData        map[string]interface{}
-	logger           logrus.FieldLogger
+	// columns maps the names of the columns to the names in the tables.
+	columns map[string]string
+	// retry is the retry policy of the ingestion, the zero value doesn't retry.
+	retry  retry.Policy
+	logger logrus.FieldLogger
 }
 
 var _ DbClient = (*KustoClient)(nil)
@@ -112,18 +128,21 @@ func (client *KustoClient) StoreCoverageDataFromFile(ctx context.Context, data [
 		_ = os.Remove(file.Name())
 	}()
 
-	mappings := append(basicCoverageMappings, client.mappings...)
+	mappings := client.columnMappings(basicCoverageMappings)
 	mappingsBytes, err := json.Marshal(mappings)
 	if err != nil {
 		return fmt.Errorf("mappings json marshal: %w", err)
 	}
 
-	_, err = client.coverageIngestor.FromFile(
-		ctx, file.Name(),
-		ingest.FileFormat(ingest.JSON),
-		ingest.IngestionMapping(mappingsBytes, ingest.JSON),
-		ingest.ReportResultToTable(),
-	)
+	err = client.retry.Do(ctx, client.logger, func(ctx context.Context) error {
+		_, err := client.coverageIngestor.FromFile(
+			ctx, file.Name(),
+			ingest.FileFormat(ingest.JSON),
+			ingest.IngestionMapping(mappingsBytes, ingest.JSON),
+			ingest.ReportResultToTable(),
+		)
+		return err
+	})
 
 	client.logger.Debugf("send coverage data file %s to kusto", file.Name())
 	return err
@@ -156,18 +175,21 @@ func (client *KustoClient) StoreIgnoreProfileDataFromFile(ctx context.Context, d
 		_ = os.Remove(file.Name())
 	}()
 
-	mappings := append(basicIgnoreProfileMappings, client.mappings...)
+	mappings := client.columnMappings(basicIgnoreProfileMappings)
 	mappingsBytes, err := json.Marshal(mappings)
 	if err != nil {
 		return fmt.Errorf("mappings json marshal: %w", err)
 	}
 
-	_, err = client.ignoreIngestor.FromFile(
-		ctx, file.Name(),
-		ingest.FileFormat(ingest.JSON),
-		ingest.IngestionMapping(mappingsBytes, ingest.JSON),
-		ingest.ReportResultToTable(),
-	)
+	err = client.retry.Do(ctx, client.logger, func(ctx context.Context) error {
+		_, err := client.ignoreIngestor.FromFile(
+			ctx, file.Name(),
+			ingest.FileFormat(ingest.JSON),
+			ingest.IngestionMapping(mappingsBytes, ingest.JSON),
+			ingest.ReportResultToTable(),
+		)
+		return err
+	})
 	client.logger.Debugf("send ignore profile data file %s to kusto", file.Name())
 	return err
 }
@@ -181,7 +203,8 @@ func (client *KustoClient) StoreCoverageData(ctx context.Context, data *Coverage
 	err = store(ctx,
 		client.coverageIngestor,
 		dataBytes,
-		append(basicCoverageMappings, client.mappings...),
+		client.columnMappings(basicCoverageMappings),
+		client.retry,
 		client.logger.WithField("ingestor", "coverage"),
 	)
 	if err != nil {
@@ -199,7 +222,8 @@ func (client *KustoClient) StoreIgnoreProfileData(ctx context.Context, data *Ign
 	err = store(ctx,
 		client.ignoreIngestor,
 		dataBytes,
-		append(basicIgnoreProfileMappings, client.mappings...),
+		client.columnMappings(basicIgnoreProfileMappings),
+		client.retry,
 		client.logger.WithField("ingestor", "ignoreProfile"),
 	)
 	if err != nil {
@@ -208,10 +232,25 @@ func (client *KustoClient) StoreIgnoreProfileData(ctx context.Context, data *Ign
 	return nil
 }
 
+// columnMappings returns the basic mappings followed by the extra mappings,
+// the columns are renamed to the names in the tables.
+func (client *KustoClient) columnMappings(basic []mapping) []mapping {
+	mappings := make([]mapping, 0, len(basic)+len(client.mappings))
+	mappings = append(mappings, basic...)
+	mappings = append(mappings, client.mappings...)
+	for i, m := range mappings {
+		if name, ok := client.columns[m.Column]; ok {
+			mappings[i].Column = name
+		}
+	}
+	return mappings
+}
+
 func store(ctx context.Context,
 	ingestor ingest.Ingestor,
 	dataBytes []byte,
 	mappings []mapping,
+	policy retry.Policy,
 	logger logrus.FieldLogger,
 ) error {
 	mappingsBytes, err := json.Marshal(mappings)
@@ -219,12 +258,15 @@ func store(ctx context.Context,
 		return fmt.Errorf("mappings json marshal: %w", err)
 	}
 
-	_, err = ingestor.FromReader(
-		ctx,
-		bytes.NewReader(dataBytes),
-		ingest.FileFormat(ingest.JSON),
-		ingest.IngestionMapping(mappingsBytes, ingest.JSON),
-	)
+	err = policy.Do(ctx, logger, func(ctx context.Context) error {
+		_, err := ingestor.FromReader(
+			ctx,
+			bytes.NewReader(dataBytes),
+			ingest.FileFormat(ingest.JSON),
+			ingest.IngestionMapping(mappingsBytes, ingest.JSON),
+		)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("ingestor from reader %w", err)
 	}
@@ -233,6 +275,105 @@ func store(ctx context.Context,
 	return nil
 }
 
+// newClient creates the kusto client of the endpoint with the credential of the auth method.
+func (o *KustoOption) newClient() (*kusto.Client, error) {
+	kcsb := kusto.NewConnectionStringBuilder(o.Endpoint)
+	if o.authMethod() == AuthServicePrincipal && o.HTTPClient == nil {
+		kcsb = kcsb.WithAadAppKey(o.clientID, o.clientSecret, o.tenantID)
+	} else {
+		cred, err := o.tokenCredential()
+		if err != nil {
+			return nil, err
+		}
+		kcsb = kcsb.WithTokenCredential(cred)
+	}
+
+	var options []kusto.Option
+	if o.HTTPClient != nil {
+		options = append(options, kusto.WithHttpClient(o.HTTPClient))
+	}
+	client, err := kusto.New(kcsb, options...)
+	if err != nil {
+		return nil, fmt.Errorf("new kusto: %w", err)
+	}
+	return client, nil
+}
+
+// tokenCredential creates the credential of the auth method, the service principal is authenticated by the kusto sdk
+// unless HTTPClient is set.
+func (o *KustoOption) tokenCredential() (azcore.TokenCredential, error) {
+	switch o.authMethod() {
+	case AuthServicePrincipal:
+		cred, err := azidentity.NewClientSecretCredential(o.tenantID, o.clientID, o.clientSecret,
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: o.clientOptions()})
+		if err != nil {
+			return nil, fmt.Errorf("new client secret credential: %w", err)
+		}
+		return cred, nil
+	case AuthManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: o.clientOptions()}
+		if o.ManagedIdentityResouceID != "" {
+			options.ID = azidentity.ResourceID(o.ManagedIdentityResouceID)
+		}
+		cred, err := azidentity.NewManagedIdentityCredential(options)
+		if err != nil {
+			return nil, fmt.Errorf("new managed identity credential: %w", err)
+		}
+		return cred, nil
+	case AuthAzureCLI:
+		cred, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: o.tenantID})
+		if err != nil {
+			return nil, fmt.Errorf("new azure cli credential: %w", err)
+		}
+		return cred, nil
+	case AuthDeviceCode:
+		cred, err := azidentity.NewDeviceCodeCredential(&azidentity.DeviceCodeCredentialOptions{
+			ClientOptions: o.clientOptions(),
+			TenantID:      o.tenantID,
+			ClientID:      o.clientID,
+			UserPrompt: func(ctx context.Context, message azidentity.DeviceCodeMessage) error {
+				if o.Logger != nil {
+					o.Logger.Warn(message.Message)
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("new device code credential: %w", err)
+		}
+		return cred, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAuthMethod, o.AuthMethod)
+	}
+}
+
+// clientOptions returns the options of the azure sdk that send the requests of the credentials with HTTPClient.
+func (o *KustoOption) clientOptions() azcore.ClientOptions {
+	if o.HTTPClient == nil {
+		return azcore.ClientOptions{}
+	}
+	return azcore.ClientOptions{Transport: o.HTTPClient}
+}
+
+// kustoRetryPolicy returns the policy that retries the throttled requests, the server errors
+// and the errors that kusto regards as transient.
+func kustoRetryPolicy(policy retry.Policy) retry.Policy {
+	policy.Retryable = kustoRetryable
+	return policy
+}
+
+func kustoRetryable(err error) bool {
+	var httpErr *kustoerrors.HttpError
+	if errors.As(err, &httpErr) {
+		return httpErr.IsThrottled() || httpErr.StatusCode >= 500 || kustoerrors.Retry(err)
+	}
+	var kustoErr *kustoerrors.Error
+	if errors.As(err, &kustoErr) {
+		return kustoerrors.Retry(err)
+	}
+	return retry.Retryable(err)
+}
+
 // KustoOption wraps the credential and kusto server information for building kusto client.
 type KustoOption struct {
 	UseKusto      bool
@@ -241,8 +382,25 @@ type KustoOption struct {
 	CoverageEvent string
 	IgnoreEvent   string
 	CustomColumns []string
-	Logger        logrus.FieldLogger
-
+	// StaticColumns are the string columns with the same value for all the records, such as the team or the service.
+	StaticColumns map[string]string
+	// ColumnNames maps the names of the columns to the names in the tables, such as coverage to CoveragePercent,
+	// so that the data is ingested into the existing tables.
+	ColumnNames map[string]string
+	// CIColumns stores the information of the CI run in the columns of ciMappings,
+	// the columns should exist in the tables.
+	CIColumns bool
+	// LabelsColumn stores the labels of the run in the dynamic column labels, the column should exist in the tables.
+	LabelsColumn bool
+	// Retry is the retry policy of the ingestion.
+	Retry  retry.Policy
+	Logger logrus.FieldLogger
+	// HTTPClient calls kusto and the identity endpoints of the credentials, the defaults of the sdk are used if it's nil.
+	HTTPClient *http.Client
+
+	// AuthMethod is the method to authenticate on kusto. When it's empty, the managed identity is used
+	// if ManagedIdentityResouceID is set, otherwise the service principal is used.
+	AuthMethod               AuthMethod
 	ManagedIdentityResouceID string
 
 	tenantID     string
@@ -255,7 +413,8 @@ type KustoOption struct {
 
 // Validate checks the validation of the input on kusto option.
 func (o *KustoOption) Validate() error {
-	if o.ManagedIdentityResouceID == "" {
+	switch o.authMethod() {
+	case AuthServicePrincipal:
 		if o.tenantID = os.Getenv(tenantIDKey); o.tenantID == "" {
 			return fmt.Errorf("%s %w", tenantIDKey, ErrEnvRequired)
 		}
@@ -267,6 +426,14 @@ func (o *KustoOption) Validate() error {
 		if o.clientSecret = os.Getenv(clientSecretKey); o.clientSecret == "" {
 			return fmt.Errorf("%s %w", clientSecretKey, ErrEnvRequired)
 		}
+	case AuthManagedIdentity:
+	case AuthAzureCLI, AuthDeviceCode:
+		// the tenant and the client are optional, the default tenant of the account
+		// and the client of azure cli are used when they are not set.
+		o.tenantID = os.Getenv(tenantIDKey)
+		o.clientID = os.Getenv(clientIDKey)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAuthMethod, o.AuthMethod)
 	}
 
 	if o.Endpoint == "" {
@@ -282,6 +449,13 @@ func (o *KustoOption) Validate() error {
 		return fmt.Errorf("%s %w", "ignore-event", ErrFlagRequired)
 	}
 
+	if o.CIColumns {
+		o.extraMappings = append(o.extraMappings, ciMappings...)
+	}
+	if o.LabelsColumn {
+		o.extraMappings = append(o.extraMappings, labelsMappings...)
+	}
+
 	// each custom column has format: {column}:{datatype}:{value}
 	// token 0: column name
 	// token 1: datatype
@@ -331,9 +505,61 @@ func (o *KustoOption) Validate() error {
 		}
 	}
 
+	columns := make([]string, 0, len(o.StaticColumns))
+	for column := range o.StaticColumns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		o.extraMappings = append(o.extraMappings, mapping{
+			Column:   column,
+			Datatype: "string",
+			Properties: properties{
+				Path: fmt.Sprintf("$.Extra.%s", column),
+			},
+		})
+		if o.extraData == nil {
+			o.extraData = make(map[string]interface{})
+		}
+		o.extraData[column] = o.StaticColumns[column]
+	}
+
+	for column, name := range o.ColumnNames {
+		if !isKnownColumn(column) {
+			return fmt.Errorf("%w: %s", ErrUnknownColumn, column)
+		}
+		if name == "" {
+			return fmt.Errorf("%w: the name of column %s is empty", ErrUnknownColumn, column)
+		}
+	}
+
 	return nil
 }
 
+// isKnownColumn checks whether the column is one of the columns that gocover ingests.
+func isKnownColumn(column string) bool {
+	for _, mappings := range [][]mapping{basicCoverageMappings, basicIgnoreProfileMappings, ciMappings, labelsMappings} {
+		for _, m := range mappings {
+			if m.Column == column {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authMethod returns the auth method, which defaults to the managed identity
+// when the managed identity resource id is set, otherwise the service principal.
+func (o *KustoOption) authMethod() AuthMethod {
+	if o.AuthMethod != "" {
+		return o.AuthMethod
+	}
+	if o.ManagedIdentityResouceID != "" {
+		return AuthManagedIdentity
+	}
+	return AuthServicePrincipal
+}
+
 // properties used for kusto transform on json data.
 type properties struct {
 	Path      string `json:"Path"`
@@ -500,3 +726,53 @@ var basicIgnoreProfileMappings = []mapping{
 		},
 	},
 }
+
+// ciMappings gives the mappings for the information of the CI run, which are used when CIColumns is enabled.
+var ciMappings = []mapping{
+	{
+		Column:   "ciProvider",
+		Datatype: "string",
+		Properties: properties{
+			Path: "$.ci.provider",
+		},
+	},
+	{
+		Column:   "commit",
+		Datatype: "string",
+		Properties: properties{
+			Path: "$.ci.commit",
+		},
+	},
+	{
+		Column:   "branch",
+		Datatype: "string",
+		Properties: properties{
+			Path: "$.ci.branch",
+		},
+	},
+	{
+		Column:   "pullRequest",
+		Datatype: "string",
+		Properties: properties{
+			Path: "$.ci.pullRequest",
+		},
+	},
+	{
+		Column:   "buildId",
+		Datatype: "string",
+		Properties: properties{
+			Path: "$.ci.buildId",
+		},
+	},
+}
+
+// labelsMappings gives the mapping for the labels of the run, which is used when LabelsColumn is enabled.
+var labelsMappings = []mapping{
+	{
+		Column:   "labels",
+		Datatype: "dynamic",
+		Properties: properties{
+			Path: "$.labels",
+		},
+	},
+}
@@ -1,14 +1,18 @@
 package dbclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"io"
+	"net/http"
 	"os"
 	"testing"
 
+	kustoerrors "github.com/Azure/azure-kusto-go/kusto/data/errors"
 	"github.com/Azure/azure-kusto-go/kusto/ingest"
+	"github.com/Azure/gocover/pkg/retry"
 	"github.com/sirupsen/logrus"
 )
 
@@ -113,6 +117,144 @@ func TestKustoOptionValidate(t *testing.T) {
 	})
 }
 
+func TestKustoOptionColumns(t *testing.T) {
+	newOption := func() *KustoOption {
+		return &KustoOption{
+			AuthMethod:    AuthAzureCLI,
+			Endpoint:      "https://fake.kusto.windows.net",
+			Database:      "database",
+			CoverageEvent: "cover-event",
+			IgnoreEvent:   "ignore-event",
+		}
+	}
+
+	t.Run("static columns", func(t *testing.T) {
+		o := newOption()
+		o.StaticColumns = map[string]string{"team": "platform", "service": "gocover"}
+		if err := o.Validate(); err != nil {
+			t.Errorf("should success, but get %s", err)
+		}
+		if len(o.extraMappings) != 2 || o.extraMappings[0].Column != "service" || o.extraMappings[1].Properties.Path != "$.Extra.team" {
+			t.Errorf("unexpected mappings %v", o.extraMappings)
+		}
+		if o.extraData["team"] != "platform" || o.extraData["service"] != "gocover" {
+			t.Errorf("unexpected data %v", o.extraData)
+		}
+	})
+
+	t.Run("labels column", func(t *testing.T) {
+		o := newOption()
+		o.LabelsColumn = true
+		o.ColumnNames = map[string]string{"labels": "Tags"}
+		if err := o.Validate(); err != nil {
+			t.Errorf("should success, but get %s", err)
+		}
+		if len(o.extraMappings) != 1 || o.extraMappings[0].Datatype != "dynamic" || o.extraMappings[0].Properties.Path != "$.labels" {
+			t.Errorf("unexpected mappings %v", o.extraMappings)
+		}
+	})
+
+	t.Run("column names", func(t *testing.T) {
+		o := newOption()
+		o.ColumnNames = map[string]string{"coverage": "CoveragePercent", "commit": "Sha"}
+		if err := o.Validate(); err != nil {
+			t.Errorf("should success, but get %s", err)
+		}
+
+		o.ColumnNames = map[string]string{"percent": "CoveragePercent"}
+		if err := o.Validate(); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("should return %s, but get %v", ErrUnknownColumn, err)
+		}
+
+		o.ColumnNames = map[string]string{"coverage": ""}
+		if err := o.Validate(); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("should return %s, but get %v", ErrUnknownColumn, err)
+		}
+	})
+
+	t.Run("column mappings", func(t *testing.T) {
+		client := KustoClient{
+			mappings: ciMappings,
+			columns:  map[string]string{"coverage": "CoveragePercent", "commit": "Sha"},
+		}
+		mappings := client.columnMappings(basicCoverageMappings)
+		if len(mappings) != len(basicCoverageMappings)+len(ciMappings) {
+			t.Errorf("expect %d mappings, but get %d", len(basicCoverageMappings)+len(ciMappings), len(mappings))
+		}
+		columns := make(map[string]string)
+		for _, m := range mappings {
+			columns[m.Properties.Path] = m.Column
+		}
+		if columns["$.coverage"] != "CoveragePercent" || columns["$.ci.commit"] != "Sha" || columns["$.totalLines"] != "totalLines" {
+			t.Errorf("unexpected columns %v", columns)
+		}
+		if basicCoverageMappings[1].Column != "coverage" {
+			t.Errorf("basic mappings should not be renamed, but get %s", basicCoverageMappings[1].Column)
+		}
+	})
+}
+
+func TestKustoOptionAuthMethod(t *testing.T) {
+	oldTenantID := os.Getenv(tenantIDKey)
+	oldClientID := os.Getenv(clientIDKey)
+	defer func() {
+		os.Setenv(tenantIDKey, oldTenantID)
+		os.Setenv(clientIDKey, oldClientID)
+	}()
+	os.Setenv(tenantIDKey, "tenant-id")
+	os.Unsetenv(clientIDKey)
+
+	newOption := func(method AuthMethod) *KustoOption {
+		return &KustoOption{
+			AuthMethod:    method,
+			Endpoint:      "https://fake.kusto.windows.net",
+			Database:      "database",
+			CoverageEvent: "cover-event",
+			IgnoreEvent:   "ignore-event",
+			Logger:        logrus.New(),
+		}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		o := newOption("")
+		if o.authMethod() != AuthServicePrincipal {
+			t.Errorf("should default to %s, but get %s", AuthServicePrincipal, o.authMethod())
+		}
+		o.ManagedIdentityResouceID = "id"
+		if o.authMethod() != AuthManagedIdentity {
+			t.Errorf("should default to %s, but get %s", AuthManagedIdentity, o.authMethod())
+		}
+	})
+
+	t.Run("token credential", func(t *testing.T) {
+		for _, method := range []AuthMethod{AuthManagedIdentity, AuthAzureCLI, AuthDeviceCode} {
+			o := newOption(method)
+			if err := o.Validate(); err != nil {
+				t.Errorf("%s should not require credentials, but get %s", method, err)
+			}
+			if _, err := o.tokenCredential(); err != nil {
+				t.Errorf("%s should create credential, but get %s", method, err)
+			}
+		}
+
+		o := newOption(AuthDeviceCode)
+		_ = o.Validate()
+		if o.tenantID != "tenant-id" {
+			t.Errorf("expect tenant id of option %s, but %s", "tenant-id", o.tenantID)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		o := newOption("password")
+		if err := o.Validate(); !errors.Is(err, ErrUnknownAuthMethod) {
+			t.Errorf("should return %s, but get %v", ErrUnknownAuthMethod, err)
+		}
+		if _, err := o.tokenCredential(); !errors.Is(err, ErrUnknownAuthMethod) {
+			t.Errorf("should return %s, but get %v", ErrUnknownAuthMethod, err)
+		}
+	})
+}
+
 func TestKustoClient(t *testing.T) {
 	logger := logrus.New()
 	ctx := context.Background()
@@ -190,6 +332,75 @@ func TestKustoClient(t *testing.T) {
 		})
 	})
 
+	t.Run("retry", func(t *testing.T) {
+		calls := 0
+		throttledIngestor := &mockIngestor{
+			fromReaderFn: func(ctx context.Context, reader io.Reader, options ...ingest.FileOption) (*ingest.Result, error) {
+				calls++
+				if calls == 1 {
+					return nil, kustoerrors.HTTP(kustoerrors.OpIngestStream, "429 Too Many Requests", http.StatusTooManyRequests, io.NopCloser(&bytes.Buffer{}), "")
+				}
+				return &ingest.Result{}, nil
+			},
+		}
+		client := KustoClient{
+			coverageIngestor: throttledIngestor,
+			ignoreIngestor:   throttledIngestor,
+			mappings:         []mapping{},
+			extraData:        map[string]interface{}{},
+			retry:            kustoRetryPolicy(retry.Policy{MaxAttempts: 2, Multiplier: 1}),
+			logger:           logger,
+		}
+		if err := client.StoreCoverageData(ctx, &CoverageData{}); err != nil {
+			t.Errorf("should return nil, but return %s", err)
+		}
+		if calls != 2 {
+			t.Errorf("should call ingestor 2 times, but call %d times", calls)
+		}
+	})
+
+}
+
+func TestKustoRetryable(t *testing.T) {
+	testSuites := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "throttled",
+			err:      kustoerrors.HTTP(kustoerrors.OpIngestStream, "429 Too Many Requests", http.StatusTooManyRequests, io.NopCloser(&bytes.Buffer{}), ""),
+			expected: true,
+		},
+		{
+			name:     "server error",
+			err:      kustoerrors.HTTP(kustoerrors.OpIngestStream, "503 Service Unavailable", http.StatusServiceUnavailable, io.NopCloser(&bytes.Buffer{}), ""),
+			expected: true,
+		},
+		{
+			name:     "client arguments",
+			err:      kustoerrors.ES(kustoerrors.OpIngestStream, kustoerrors.KClientArgs, "bad mapping"),
+			expected: false,
+		},
+		{
+			name:     "network error",
+			err:      errors.New("connection reset by peer"),
+			expected: true,
+		},
+		{
+			name:     "context canceled",
+			err:      context.Canceled,
+			expected: false,
+		},
+	}
+
+	for _, ts := range testSuites {
+		t.Run(ts.name, func(t *testing.T) {
+			if actual := kustoRetryable(ts.err); actual != ts.expected {
+				t.Errorf("should return %v, but return %v", ts.expected, actual)
+			}
+		})
+	}
 }
 
 type mockIngestor struct {
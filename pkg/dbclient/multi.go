@@ -0,0 +1,67 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sink is a db client that the data is stored to.
+type sink struct {
+	dbType ClientType
+	client DbClient
+	// optional indicates that the failure of the client is logged but not returned.
+	optional bool
+}
+
+// multiClient stores the data to all the db clients, the failure of a client
+// doesn't stop the data from being stored to the other clients.
+type multiClient struct {
+	sinks  []*sink
+	logger logrus.FieldLogger
+}
+
+var _ DbClient = (*multiClient)(nil)
+
+func (c *multiClient) StoreCoverageDataFromFile(ctx context.Context, data []*CoverageData) error {
+	return c.store(func(client DbClient) error {
+		return client.StoreCoverageDataFromFile(ctx, data)
+	})
+}
+
+func (c *multiClient) StoreIgnoreProfileDataFromFile(ctx context.Context, data []*IgnoreProfileData) error {
+	return c.store(func(client DbClient) error {
+		return client.StoreIgnoreProfileDataFromFile(ctx, data)
+	})
+}
+
+func (c *multiClient) StoreCoverageData(ctx context.Context, data *CoverageData) error {
+	return c.store(func(client DbClient) error {
+		return client.StoreCoverageData(ctx, data)
+	})
+}
+
+func (c *multiClient) StoreIgnoreProfileData(ctx context.Context, data *IgnoreProfileData) error {
+	return c.store(func(client DbClient) error {
+		return client.StoreIgnoreProfileData(ctx, data)
+	})
+}
+
+// store calls fn with each db client, the errors of the required clients are joined.
+func (c *multiClient) store(fn func(client DbClient) error) error {
+	var errs []error
+	for _, s := range c.sinks {
+		err := fn(s.client)
+		if err == nil {
+			continue
+		}
+		if s.optional {
+			c.logger.Warnf("store to optional db %s: %s", s.dbType, err)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", s.dbType, err))
+	}
+	return errors.Join(errs...)
+}
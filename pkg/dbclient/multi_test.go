@@ -0,0 +1,61 @@
+package dbclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Azure/azure-kusto-go/kusto/ingest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMultiClient(t *testing.T) {
+	ctx := context.Background()
+	errStore := errors.New("store failed")
+
+	newKustoClient := func(calls *int, err error) DbClient {
+		ingestor := &mockIngestor{
+			fromReaderFn: func(ctx context.Context, reader io.Reader, options ...ingest.FileOption) (*ingest.Result, error) {
+				*calls++
+				return &ingest.Result{}, err
+			},
+		}
+		return &KustoClient{coverageIngestor: ingestor, ignoreIngestor: ingestor, logger: logrus.New()}
+	}
+
+	t.Run("failure does not stop other clients", func(t *testing.T) {
+		var failed, succeeded int
+		client := &multiClient{
+			sinks: []*sink{
+				{dbType: Kusto, client: newKustoClient(&failed, errStore)},
+				{dbType: Kusto, client: newKustoClient(&succeeded, nil)},
+			},
+			logger: logrus.New(),
+		}
+		err := client.StoreCoverageData(ctx, &CoverageData{})
+		if !errors.Is(err, errStore) {
+			t.Errorf("should return error %s, but return %v", errStore, err)
+		}
+		if failed != 1 || succeeded != 1 {
+			t.Errorf("each client should be called once, but called %d and %d times", failed, succeeded)
+		}
+	})
+
+	t.Run("optional client", func(t *testing.T) {
+		var failed, succeeded int
+		client := &multiClient{
+			sinks: []*sink{
+				{dbType: Kusto, client: newKustoClient(&failed, errStore), optional: true},
+				{dbType: Kusto, client: newKustoClient(&succeeded, nil)},
+			},
+			logger: logrus.New(),
+		}
+		if err := client.StoreIgnoreProfileData(ctx, &IgnoreProfileData{}); err != nil {
+			t.Errorf("the failure of optional client should be ignored, but return %s", err)
+		}
+		if failed != 1 || succeeded != 1 {
+			t.Errorf("each client should be called once, but called %d and %d times", failed, succeeded)
+		}
+	})
+}
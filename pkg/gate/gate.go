@@ -0,0 +1,383 @@
+// Package gate evaluates the coverage gate expressions, such as `diff >= 80 && full >= 70 && pkg("pkg/api") >= 90`,
+// so that a single configurable gate replaces the chained checks of the coverage metrics in the pipelines.
+//
+// An expression compares the metrics and the numbers with >=, >, <=, <, == and !=, and combines the comparisons
+// with &&, || and !, the comparisons can be grouped with parentheses. A metric is a name such as diff,
+// or a name with a string argument such as pkg("pkg/api").
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidExpression = errors.New("invalid gate expression")
+	ErrUnknownMetric     = errors.New("unknown metric")
+)
+
+// Metrics provides the values of the metrics in the expression.
+type Metrics interface {
+	// Metric returns the value of the metric, arg is empty if the metric has no argument.
+	Metric(name string, arg string) (float64, error)
+}
+
+// Value is the value of a metric that is evaluated.
+type Value struct {
+	// Metric is the metric as it's written in the expression, such as pkg("pkg/api").
+	Metric string
+	Value  float64
+}
+
+// Result is the result of the evaluation.
+type Result struct {
+	// Passed indicates the expression is true.
+	Passed bool
+	// Values are the values of the metrics in the order they are evaluated.
+	Values []*Value
+}
+
+// String returns the values of the metrics, such as `diff = 75.00, full = 71.20`.
+func (r *Result) String() string {
+	var s []string
+	for _, v := range r.Values {
+		s = append(s, fmt.Sprintf("%s = %.2f", v.Metric, v.Value))
+	}
+	return strings.Join(s, ", ")
+}
+
+// Expression is a parsed gate expression.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses the gate expression.
+func Parse(expression string) (*Expression, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidExpression, err)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidExpression, err)
+	}
+	return &Expression{source: expression, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate evaluates the expression with the metrics, the operands of && and || are evaluated
+// from left to right and stop as soon as the result is known.
+func (e *Expression) Evaluate(metrics Metrics) (*Result, error) {
+	result := &Result{}
+	passed, err := e.root.evaluate(metrics, result)
+	if err != nil {
+		return nil, err
+	}
+	result.Passed = passed
+	return result, nil
+}
+
+// node is a boolean node of the expression.
+type node interface {
+	evaluate(metrics Metrics, result *Result) (bool, error)
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) evaluate(metrics Metrics, result *Result) (bool, error) {
+	left, err := n.left.evaluate(metrics, result)
+	if err != nil || !left {
+		return false, err
+	}
+	return n.right.evaluate(metrics, result)
+}
+
+type orNode struct{ left, right node }
+
+func (n *orNode) evaluate(metrics Metrics, result *Result) (bool, error) {
+	left, err := n.left.evaluate(metrics, result)
+	if err != nil || left {
+		return left, err
+	}
+	return n.right.evaluate(metrics, result)
+}
+
+type notNode struct{ operand node }
+
+func (n *notNode) evaluate(metrics Metrics, result *Result) (bool, error) {
+	v, err := n.operand.evaluate(metrics, result)
+	return !v, err
+}
+
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n *compareNode) evaluate(metrics Metrics, result *Result) (bool, error) {
+	left, err := n.left.value(metrics, result)
+	if err != nil {
+		return false, err
+	}
+	right, err := n.right.value(metrics, result)
+	if err != nil {
+		return false, err
+	}
+
+	switch n.op {
+	case ">=":
+		return left >= right, nil
+	case ">":
+		return left > right, nil
+	case "<=":
+		return left <= right, nil
+	case "<":
+		return left < right, nil
+	case "==":
+		return left == right, nil
+	default:
+		return left != right, nil
+	}
+}
+
+// operand is a number or a metric of the comparison.
+type operand struct {
+	number float64
+	metric string
+	name   string
+	arg    string
+}
+
+func (o operand) value(metrics Metrics, result *Result) (float64, error) {
+	if o.metric == "" {
+		return o.number, nil
+	}
+	v, err := metrics.Metric(o.name, o.arg)
+	if err != nil {
+		return 0, fmt.Errorf("metric %s: %w", o.metric, err)
+	}
+	result.Values = append(result.Values, &Value{Metric: o.metric, Value: v})
+	return v, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if t := p.next(); t.kind != kind || t.text != text {
+		return fmt.Errorf("expect %q, but get %s", text, t)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is(tokenOperator, "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is(tokenOperator, "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().is(tokenOperator, "!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	if p.peek().is(tokenPunct, "(") {
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenPunct, ")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.next()
+	if op.kind != tokenOperator || !isComparison(op.text) {
+		return nil, fmt.Errorf("expect comparison operator, but get %s", op)
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: op.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid number %s", t.text)
+		}
+		return operand{number: v}, nil
+	case tokenIdent:
+		if !p.peek().is(tokenPunct, "(") {
+			return operand{metric: t.text, name: t.text}, nil
+		}
+		p.next()
+		arg := p.next()
+		if arg.kind != tokenString {
+			return operand{}, fmt.Errorf("expect string argument of %s, but get %s", t.text, arg)
+		}
+		if err := p.expect(tokenPunct, ")"); err != nil {
+			return operand{}, err
+		}
+		return operand{metric: fmt.Sprintf("%s(%q)", t.text, arg.text), name: t.text, arg: arg.text}, nil
+	default:
+		return operand{}, fmt.Errorf("expect metric or number, but get %s", t)
+	}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case ">=", ">", "<=", "<", "==", "!=":
+		return true
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenString
+	tokenOperator
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q at %d", t.text, t.pos)
+}
+
+// tokenize splits the expression into tokens, the last token is tokenEOF.
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), pos: i})
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: s[i:j], pos: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: s[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{">=", "<=", "==", "!=", "&&", "||", ">", "<", "!"} {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(s)}), nil
+}
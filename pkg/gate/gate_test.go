@@ -0,0 +1,78 @@
+package gate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type metrics map[string]float64
+
+func (m metrics) Metric(name string, arg string) (float64, error) {
+	key := name
+	if arg != "" {
+		key = fmt.Sprintf("%s:%s", name, arg)
+	}
+	v, ok := m[key]
+	if !ok {
+		return 0, ErrUnknownMetric
+	}
+	return v, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	m := metrics{"diff": 85, "full": 65.5, "pkg:pkg/api": 92}
+
+	for _, testCase := range []struct {
+		expression string
+		passed     bool
+		values     string
+	}{
+		{expression: "diff >= 80", passed: true, values: "diff = 85.00"},
+		{expression: `diff >= 80 && full >= 70 && pkg("pkg/api") >= 90`, passed: false, values: "diff = 85.00, full = 65.50"},
+		{expression: `full >= 70 || pkg("pkg/api") >= 90`, passed: true, values: `full = 65.50, pkg("pkg/api") = 92.00`},
+		{expression: "!(diff < 80) && 60 <= full", passed: true, values: "diff = 85.00, full = 65.50"},
+		{expression: "diff == 85 && full != 65.5", passed: false, values: "diff = 85.00, full = 65.50"},
+		{expression: "(diff > 90 || full > 60) && diff > full", passed: true, values: "diff = 85.00, full = 65.50, diff = 85.00, full = 65.50"},
+	} {
+		t.Run(testCase.expression, func(t *testing.T) {
+			e, err := Parse(testCase.expression)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expression, e.String())
+
+			result, err := e.Evaluate(m)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.passed, result.Passed)
+			assert.Equal(t, testCase.values, result.String())
+		})
+	}
+
+	t.Run("unknown metric", func(t *testing.T) {
+		e, err := Parse(`pkg("pkg/other") >= 90`)
+		assert.NoError(t, err)
+		_, err = e.Evaluate(m)
+		assert.ErrorIs(t, err, ErrUnknownMetric)
+	})
+}
+
+func TestParse(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"diff",
+		"diff >=",
+		"diff >= 80 &&",
+		"diff >= 80 full >= 70",
+		"(diff >= 80",
+		`pkg(api) >= 90`,
+		`pkg("api >= 90`,
+		"diff >= 8.0.0",
+		"diff => 80",
+		"diff >= 80 & full >= 70",
+	} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := Parse(expression)
+			assert.ErrorIs(t, err, ErrInvalidExpression)
+		})
+	}
+}
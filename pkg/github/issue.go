@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// The states of the issues.
+const (
+	IssueStateOpen   = "open"
+	IssueStateClosed = "closed"
+)
+
+// Issue is the issue of a repository, see https://docs.github.com/en/rest/issues/issues.
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// IssueRequest is the request to create or update an issue, the empty fields are not changed on update.
+type IssueRequest struct {
+	Title     string   `json:"title,omitempty"`
+	Body      string   `json:"body,omitempty"`
+	State     string   `json:"state,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// GetIssue gets the issue of the repository in {owner}/{repo} format, which can differ from the repository of the client.
+func (c *Client) GetIssue(ctx context.Context, repository string, number int) (*Issue, error) {
+	url := fmt.Sprintf("%s/repos/%s/issues/%d", c.apiURL, repository, number)
+	issue := &Issue{}
+	err := c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		return c.send(ctx, http.MethodGet, url, nil, http.StatusOK, issue)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get issue %s#%d: %w", repository, number, err)
+	}
+	return issue, nil
+}
+
+// issuesPerPage is the page size of listing the issues, which is the maximum of GitHub.
+const issuesPerPage = 100
+
+// ListOpenIssues lists all the open issues of the repository of the client that have the label, page by page,
+// the pull requests are listed as issues by GitHub as well.
+func (c *Client) ListOpenIssues(ctx context.Context, label string) ([]*Issue, error) {
+	var issues []*Issue
+	for page := 1; ; page++ {
+		query := url.Values{
+			"state":    {IssueStateOpen},
+			"labels":   {label},
+			"per_page": {strconv.Itoa(issuesPerPage)},
+			"page":     {strconv.Itoa(page)},
+		}
+		endpoint := fmt.Sprintf("%s/repos/%s/issues?%s", c.apiURL, c.repository, query.Encode())
+		var pageIssues []*Issue
+		err := c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+			pageIssues = nil
+			return c.send(ctx, http.MethodGet, endpoint, nil, http.StatusOK, &pageIssues)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list issues: %w", err)
+		}
+		issues = append(issues, pageIssues...)
+		// the last page is not full.
+		if len(pageIssues) < issuesPerPage {
+			return issues, nil
+		}
+	}
+}
+
+// CreateIssue opens an issue in the repository of the client.
+func (c *Client) CreateIssue(ctx context.Context, request *IssueRequest) (*Issue, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/issues", c.apiURL, c.repository)
+	issue := &Issue{}
+	err = c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		return c.send(ctx, http.MethodPost, url, body, http.StatusCreated, issue)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create issue: %w", err)
+	}
+	c.logger.Infof("create issue #%d: %s", issue.Number, issue.HTMLURL)
+	return issue, nil
+}
+
+// UpdateIssue updates the issue of the repository of the client, such as the body or the state.
+func (c *Client) UpdateIssue(ctx context.Context, number int, request *IssueRequest) (*Issue, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/issues/%d", c.apiURL, c.repository, number)
+	issue := &Issue{}
+	err = c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		return c.send(ctx, http.MethodPatch, url, body, http.StatusOK, issue)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update issue #%d: %w", number, err)
+	}
+	c.logger.Infof("update issue #%d: %s", issue.Number, issue.HTMLURL)
+	return issue, nil
+}
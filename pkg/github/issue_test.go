@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIssue(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.URL.Path == "/repos/foo/bar/issues/404" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"number": 12, "state": "closed", "html_url": "https://github.com/foo/bar/issues/12"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}, logrus.New())
+	assert.NoError(t, err)
+
+	t.Run("closed", func(t *testing.T) {
+		issue, err := c.GetIssue(context.Background(), "foo/bar", 12)
+		assert.NoError(t, err)
+		assert.Equal(t, "/repos/foo/bar/issues/12", path)
+		assert.Equal(t, &Issue{Number: 12, State: IssueStateClosed, HTMLURL: "https://github.com/foo/bar/issues/12"}, issue)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := c.GetIssue(context.Background(), "foo/bar", 404)
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	})
+}
+
+func TestListOpenIssuesPages(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		count := 1
+		if page == "1" {
+			count = issuesPerPage
+		}
+		issues := make([]*Issue, count)
+		for i := range issues {
+			issues[i] = &Issue{Number: i + 1, State: IssueStateOpen}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(issues))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}, logrus.New())
+	assert.NoError(t, err)
+
+	issues, err := c.ListOpenIssues(context.Background(), "gocover-uncovered")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, pages)
+	assert.Len(t, issues, issuesPerPage+1)
+}
+
+func TestCreateAndUpdateIssue(t *testing.T) {
+	var (
+		method  string
+		path    string
+		request IssueRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.RequestURI()
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[{"number": 3, "title": "Uncovered new code of #12", "state": "open"}]`))
+			return
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = w.Write([]byte(`{"number": 3}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}, logrus.New())
+	assert.NoError(t, err)
+
+	t.Run("list", func(t *testing.T) {
+		issues, err := c.ListOpenIssues(context.Background(), "gocover-uncovered")
+		assert.NoError(t, err)
+		assert.Equal(t, "/repos/owner/repo/issues?labels=gocover-uncovered&page=1&per_page=100&state=open", path)
+		assert.Equal(t, []*Issue{{Number: 3, Title: "Uncovered new code of #12", State: IssueStateOpen}}, issues)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		issue, err := c.CreateIssue(context.Background(), &IssueRequest{Title: "title", Body: "body", Assignees: []string{"octocat"}})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, issue.Number)
+		assert.Equal(t, http.MethodPost, method)
+		assert.Equal(t, "/repos/owner/repo/issues", path)
+		assert.Equal(t, IssueRequest{Title: "title", Body: "body", Assignees: []string{"octocat"}}, request)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		request = IssueRequest{}
+		_, err := c.UpdateIssue(context.Background(), 3, &IssueRequest{State: IssueStateClosed})
+		assert.NoError(t, err)
+		assert.Equal(t, http.MethodPatch, method)
+		assert.Equal(t, "/repos/owner/repo/issues/3", path)
+		assert.Equal(t, IssueRequest{State: IssueStateClosed}, request)
+	})
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// User is the account of a user.
+type User struct {
+	Login string `json:"login"`
+}
+
+// PullRequest is the pull request of a repository, see https://docs.github.com/en/rest/pulls/pulls.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	User    *User  `json:"user"`
+	// MergedAt is the time that the pull request is merged, it's nil if the pull request is not merged.
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// Merged returns whether the pull request is merged.
+func (p *PullRequest) Merged() bool {
+	return p.MergedAt != nil
+}
+
+// GetPullRequest gets the pull request of the repository of the client.
+func (c *Client) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d", c.apiURL, c.repository, number)
+	pull := &PullRequest{}
+	err := c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		return c.send(ctx, http.MethodGet, url, nil, http.StatusOK, pull)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get pull request #%d: %w", number, err)
+	}
+	return pull, nil
+}
+
+// PullRequestsOfCommit lists the pull requests that the commit is associated with, such as the merged pull request of a merge commit.
+// The open pull requests that contain the commit are listed as well.
+func (c *Client) PullRequestsOfCommit(ctx context.Context, commit string) ([]*PullRequest, error) {
+	if commit == "" {
+		return nil, ErrNoCommit
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/commits/%s/pulls", c.apiURL, c.repository, commit)
+	var pulls []*PullRequest
+	err := c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		return c.send(ctx, http.MethodGet, url, nil, http.StatusOK, &pulls)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pull requests of %s: %w", commit, err)
+	}
+	return pulls, nil
+}
@@ -0,0 +1,47 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/pulls/12":
+			_, _ = w.Write([]byte(`{"number": 12, "user": {"login": "octocat"}, "merged_at": "2024-05-01T10:00:00Z"}`))
+		case "/repos/owner/repo/commits/abc123/pulls":
+			_, _ = w.Write([]byte(`[{"number": 12, "user": {"login": "octocat"}}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}, logrus.New())
+	assert.NoError(t, err)
+
+	t.Run("get", func(t *testing.T) {
+		pull, err := c.GetPullRequest(context.Background(), 12)
+		assert.NoError(t, err)
+		mergedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, &PullRequest{Number: 12, User: &User{Login: "octocat"}, MergedAt: &mergedAt}, pull)
+		assert.True(t, pull.Merged())
+	})
+
+	t.Run("of commit", func(t *testing.T) {
+		pulls, err := c.PullRequestsOfCommit(context.Background(), "abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, []*PullRequest{{Number: 12, User: &User{Login: "octocat"}}}, pulls)
+		assert.False(t, pulls[0].Merged())
+
+		_, err = c.PullRequestsOfCommit(context.Background(), "")
+		assert.ErrorIs(t, err, ErrNoCommit)
+	})
+}
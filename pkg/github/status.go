@@ -0,0 +1,245 @@
+// Package github sets the commit statuses, and reads and writes the issues and the pull requests with the GitHub REST API,
+// see https://docs.github.com/en/rest/commits/statuses for more information.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/retry"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAPIURL is the API URL of github.com, GitHub Enterprise Server uses https://{host}/api/v3.
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultAPIVersion is the version of the REST API that is requested by the X-GitHub-Api-Version header.
+	DefaultAPIVersion = "2022-11-28"
+	// NoAPIVersion omits the X-GitHub-Api-Version header, for GitHub Enterprise Server before 3.9 that doesn't version the API.
+	NoAPIVersion = "none"
+
+	// enterpriseAPIPath is the path of the REST API on GitHub Enterprise Server.
+	enterpriseAPIPath = "/api/v3"
+
+	// maxDescriptionLength is the max length of the status description that GitHub accepts.
+	maxDescriptionLength = 140
+)
+
+var (
+	ErrNoRepository     = errors.New("github repository is not set, it should be in {owner}/{repo} format")
+	ErrNoToken          = errors.New("github token is not set")
+	ErrNoCommit         = errors.New("commit of the status is not set")
+	ErrUnexpectedStatus = errors.New("unexpected status code of github api")
+)
+
+// State is the state of the commit status.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateSuccess State = "success"
+	StateFailure State = "failure"
+	StateError   State = "error"
+)
+
+// Status is the commit status.
+type Status struct {
+	State State `json:"state"`
+	// TargetURL is the link of the details of the status.
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Context distinguishes the status from the statuses of the other systems, such as gocover/diff.
+	Context string `json:"context"`
+}
+
+// ClientOption contains the information to call the GitHub API.
+type ClientOption struct {
+	// APIURL is the URL of the GitHub API, it's the API of ServerURL if it's empty.
+	APIURL string
+	// ServerURL is the URL of the server, such as https://github.example.com, whose API is {server}/api/v3
+	// for a GitHub Enterprise Server. The API is DefaultAPIURL if both of them are empty.
+	ServerURL string
+	// APIVersion is the X-GitHub-Api-Version header, it's DefaultAPIVersion if it's empty and omitted if it's NoAPIVersion.
+	APIVersion string
+	// Repository is the repository in {owner}/{repo} format.
+	Repository string
+	// Token is the token that has the permission to write the commit statuses.
+	Token string
+	// EnterpriseToken is the token of a GitHub Enterprise Server, which is used if Token is empty
+	// and the API is not github.com, so that the tokens of github.com and the server can be set side by side.
+	EnterpriseToken string
+	// Retry is the retry policy of the calls, the zero value doesn't retry.
+	Retry retry.Policy
+	// HTTPClient is http.DefaultClient if it's nil.
+	HTTPClient *http.Client
+}
+
+// NewClientOptionFromEnv reads the option from the environment variables of GitHub Actions,
+// which are GITHUB_API_URL, GITHUB_SERVER_URL, GITHUB_REPOSITORY and GITHUB_TOKEN.
+// The enterprise token is GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN like the gh cli.
+func NewClientOptionFromEnv(getenv func(string) string) *ClientOption {
+	o := &ClientOption{
+		APIURL:          getenv("GITHUB_API_URL"),
+		ServerURL:       getenv("GITHUB_SERVER_URL"),
+		Repository:      getenv("GITHUB_REPOSITORY"),
+		Token:           getenv("GITHUB_TOKEN"),
+		EnterpriseToken: getenv("GH_ENTERPRISE_TOKEN"),
+	}
+	if o.EnterpriseToken == "" {
+		o.EnterpriseToken = getenv("GITHUB_ENTERPRISE_TOKEN")
+	}
+	return o
+}
+
+// HasToken reports whether the token of the API is set.
+func (o *ClientOption) HasToken() bool {
+	return o.token() != ""
+}
+
+// Enterprise reports whether the API is a GitHub Enterprise Server rather than github.com.
+func (o *ClientOption) Enterprise() bool {
+	return o.apiURL() != DefaultAPIURL
+}
+
+// token returns Token, or EnterpriseToken for a GitHub Enterprise Server if Token is empty.
+func (o *ClientOption) token() string {
+	if o.Token == "" && o.Enterprise() {
+		return o.EnterpriseToken
+	}
+	return o.Token
+}
+
+// apiURL returns the URL of the API without the trailing slash.
+func (o *ClientOption) apiURL() string {
+	if o.APIURL != "" {
+		return strings.TrimSuffix(o.APIURL, "/")
+	}
+	server := strings.TrimSuffix(o.ServerURL, "/")
+	if server == "" {
+		return DefaultAPIURL
+	}
+	if u, err := url.Parse(server); err == nil {
+		switch strings.ToLower(u.Host) {
+		case "github.com", "www.github.com":
+			return DefaultAPIURL
+		}
+	}
+	return server + enterpriseAPIPath
+}
+
+// Client is the client of the GitHub API.
+type Client struct {
+	apiURL     string
+	apiVersion string
+	repository string
+	token      string
+	retry      retry.Policy
+	httpClient *http.Client
+	logger     logrus.FieldLogger
+}
+
+// NewClient creates the client.
+func NewClient(o *ClientOption, logger logrus.FieldLogger) (*Client, error) {
+	if strings.Count(o.Repository, "/") != 1 {
+		return nil, fmt.Errorf("%w: %q", ErrNoRepository, o.Repository)
+	}
+	token := o.token()
+	if token == "" {
+		return nil, ErrNoToken
+	}
+
+	apiVersion := o.APIVersion
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+	httpClient := o.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		apiURL:     o.apiURL(),
+		apiVersion: apiVersion,
+		repository: o.Repository,
+		token:      token,
+		retry:      o.Retry,
+		httpClient: httpClient,
+		logger:     logger,
+	}, nil
+}
+
+// CreateStatus sets the status of the commit, the status of the same context replaces the previous one.
+// The description is truncated to the max length that GitHub accepts.
+func (c *Client) CreateStatus(ctx context.Context, commit string, status *Status) error {
+	if commit == "" {
+		return ErrNoCommit
+	}
+
+	s := *status
+	if r := []rune(s.Description); len(r) > maxDescriptionLength {
+		s.Description = string(r[:maxDescriptionLength-3]) + "..."
+	}
+	body, err := json.Marshal(&s)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/statuses/%s", c.apiURL, c.repository, commit)
+	err = c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		return c.send(ctx, http.MethodPost, url, body, http.StatusCreated, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("create status of %s: %w", commit, err)
+	}
+	c.logger.Infof("set commit status %s of %s: %s", s.Context, commit, s.State)
+	return nil
+}
+
+// send sends the request with the json body if it's not nil, and decodes the response into v if it's not nil.
+// The client errors other than rate limiting are not retried.
+func (c *Client) send(ctx context.Context, method string, url string, body []byte, expected int, v interface{}) error {
+	resp, err := c.do(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return retry.UnexpectedStatus(ErrUnexpectedStatus, resp)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return retry.Permanent(fmt.Errorf("json decode: %w", err))
+	}
+	return nil
+}
+
+// do sends the request with the json body if it's not nil, the caller closes the body of the response.
+func (c *Client) do(ctx context.Context, method string, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, retry.Permanent(fmt.Errorf("new request: %w", err))
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.apiVersion != NoAPIVersion {
+		req.Header.Set("X-GitHub-Api-Version", c.apiVersion)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient.Do(req)
+}
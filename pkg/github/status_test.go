@@ -0,0 +1,136 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/retry"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Run("no repository", func(t *testing.T) {
+		_, err := NewClient(&ClientOption{Repository: "repo", Token: "token"}, logrus.New())
+		assert.ErrorIs(t, err, ErrNoRepository)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		_, err := NewClient(&ClientOption{Repository: "owner/repo"}, logrus.New())
+		assert.ErrorIs(t, err, ErrNoToken)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		env := map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_TOKEN": "token"}
+		c, err := NewClient(NewClientOptionFromEnv(func(k string) string { return env[k] }), logrus.New())
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultAPIURL, c.apiURL)
+		assert.Equal(t, "owner/repo", c.repository)
+		assert.Equal(t, DefaultAPIVersion, c.apiVersion)
+	})
+
+	t.Run("enterprise server", func(t *testing.T) {
+		env := map[string]string{
+			"GITHUB_SERVER_URL":   "https://github.example.com/",
+			"GITHUB_REPOSITORY":   "owner/repo",
+			"GH_ENTERPRISE_TOKEN": "enterprise",
+		}
+		o := NewClientOptionFromEnv(func(k string) string { return env[k] })
+		assert.True(t, o.Enterprise())
+		c, err := NewClient(o, logrus.New())
+		assert.NoError(t, err)
+		assert.Equal(t, "https://github.example.com/api/v3", c.apiURL)
+		assert.Equal(t, "enterprise", c.token)
+
+		o.ServerURL = "https://github.com"
+		assert.False(t, o.Enterprise())
+		assert.False(t, o.HasToken())
+		_, err = NewClient(o, logrus.New())
+		assert.ErrorIs(t, err, ErrNoToken)
+
+		o.APIURL = "https://github.example.com/api/v3/"
+		c, err = NewClient(o, logrus.New())
+		assert.NoError(t, err)
+		assert.Equal(t, "https://github.example.com/api/v3", c.apiURL)
+		assert.Equal(t, "enterprise", c.token)
+	})
+
+	t.Run("api version", func(t *testing.T) {
+		var versions []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			versions = append(versions, r.Header.Get("X-GitHub-Api-Version"))
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		for _, version := range []string{"", NoAPIVersion, "2026-03-10"} {
+			c, err := NewClient(&ClientOption{APIURL: server.URL, APIVersion: version, Repository: "owner/repo", Token: "token"}, logrus.New())
+			assert.NoError(t, err)
+			assert.NoError(t, c.CreateStatus(context.Background(), "abc123", &Status{State: StateSuccess, Context: "gocover/diff"}))
+		}
+		assert.Equal(t, []string{DefaultAPIVersion, "", "2026-03-10"}, versions)
+	})
+}
+
+func TestCreateStatus(t *testing.T) {
+	var (
+		requests int
+		status   Status
+		path     string
+		auth     string
+		code     = http.StatusCreated
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	policy := retry.Policy{MaxAttempts: 3, Multiplier: 1}
+	c, err := NewClient(&ClientOption{APIURL: server.URL + "/", Repository: "owner/repo", Token: "token", Retry: policy}, logrus.New())
+	assert.NoError(t, err)
+
+	t.Run("created", func(t *testing.T) {
+		requests = 0
+		err := c.CreateStatus(context.Background(), "abc123", &Status{
+			State:       StateSuccess,
+			TargetURL:   "https://example.com/coverage.html",
+			Description: strings.Repeat("x", 200),
+			Context:     "gocover/diff",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, "/repos/owner/repo/statuses/abc123", path)
+		assert.Equal(t, "Bearer token", auth)
+		assert.Equal(t, StateSuccess, status.State)
+		assert.Equal(t, "https://example.com/coverage.html", status.TargetURL)
+		assert.Equal(t, "gocover/diff", status.Context)
+		assert.Len(t, status.Description, maxDescriptionLength)
+	})
+
+	t.Run("server error is retried", func(t *testing.T) {
+		requests, code = 0, http.StatusBadGateway
+		err := c.CreateStatus(context.Background(), "abc123", &Status{State: StateFailure, Context: "gocover/diff"})
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("client error is not retried", func(t *testing.T) {
+		requests, code = 0, http.StatusNotFound
+		err := c.CreateStatus(context.Background(), "abc123", &Status{State: StateFailure, Context: "gocover/diff"})
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("no commit", func(t *testing.T) {
+		err := c.CreateStatus(context.Background(), "", &Status{State: StateSuccess, Context: "gocover/diff"})
+		assert.ErrorIs(t, err, ErrNoCommit)
+	})
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/retry"
+)
+
+// scopesHeader is the header of the OAuth scopes of a classic token in the responses.
+const scopesHeader = "X-OAuth-Scopes"
+
+// TokenScopes is what the token is granted in the repository.
+type TokenScopes struct {
+	// Scopes are the OAuth scopes of a classic token, such as repo and repo:status.
+	Scopes []string
+	// Listed is false for the fine-grained tokens and the GITHUB_TOKEN of GitHub Actions,
+	// whose permissions are not listed by the API.
+	Listed bool
+	// Push reports whether the token can write the repository, it's false if the API doesn't return the permissions.
+	Push bool
+}
+
+// Has reports whether any of the scopes is granted.
+func (s *TokenScopes) Has(scopes ...string) bool {
+	for _, granted := range s.Scopes {
+		for _, scope := range scopes {
+			if granted == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TokenScopes reads the repository with the token, and returns the scopes and the permissions of the token.
+func (c *Client) TokenScopes(ctx context.Context) (*TokenScopes, error) {
+	var repository struct {
+		Permissions struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	scopes := &TokenScopes{}
+
+	url := fmt.Sprintf("%s/repos/%s", c.apiURL, c.repository)
+	err := c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		resp, err := c.do(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return retry.UnexpectedStatus(ErrUnexpectedStatus, resp)
+		}
+		// the header is empty rather than missing for a classic token without scopes
+		if values := resp.Header.Values(scopesHeader); values != nil {
+			scopes.Listed = true
+			for _, scope := range strings.Split(values[0], ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					scopes.Scopes = append(scopes.Scopes, scope)
+				}
+			}
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
+			return retry.Permanent(fmt.Errorf("json decode: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get repository %s: %w", c.repository, err)
+	}
+	scopes.Push = repository.Permissions.Push
+	return scopes, nil
+}
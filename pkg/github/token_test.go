@@ -0,0 +1,60 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenScopes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer classic":
+			w.Header().Set(scopesHeader, "repo:status, read:org")
+			_, _ = w.Write([]byte(`{"permissions": {"admin": false, "push": true, "pull": true}}`))
+		case "Bearer empty":
+			w.Header().Set(scopesHeader, "")
+			_, _ = w.Write([]byte(`{"permissions": {"push": false, "pull": true}}`))
+		case "Bearer fine-grained":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	scopesOf := func(token string) (*TokenScopes, error) {
+		c, err := NewClient(&ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: token}, logrus.New())
+		assert.NoError(t, err)
+		return c.TokenScopes(context.Background())
+	}
+
+	t.Run("classic", func(t *testing.T) {
+		scopes, err := scopesOf("classic")
+		assert.NoError(t, err)
+		assert.Equal(t, &TokenScopes{Scopes: []string{"repo:status", "read:org"}, Listed: true, Push: true}, scopes)
+		assert.True(t, scopes.Has("repo", "repo:status"))
+		assert.False(t, scopes.Has("repo"))
+	})
+
+	t.Run("no scopes", func(t *testing.T) {
+		scopes, err := scopesOf("empty")
+		assert.NoError(t, err)
+		assert.Equal(t, &TokenScopes{Listed: true}, scopes)
+	})
+
+	t.Run("fine-grained", func(t *testing.T) {
+		scopes, err := scopesOf("fine-grained")
+		assert.NoError(t, err)
+		assert.Equal(t, &TokenScopes{}, scopes)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, err := scopesOf("bad")
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+	})
+}
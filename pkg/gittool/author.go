@@ -0,0 +1,99 @@
+package gittool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+// LineAuthor is the author of the commit that last modified a line.
+type LineAuthor struct {
+	Name  string
+	Email string
+	// When is the author date of the commit.
+	When time.Time
+	// Commit is the hash of the commit.
+	Commit string
+}
+
+// Matches reports whether the author is any of the identities, which are the names or the emails
+// of the authors compared case-insensitively, such as "Jane Doe" or "jane@example.com".
+func (a *LineAuthor) Matches(identities []string) bool {
+	for _, identity := range identities {
+		identity = strings.TrimSpace(identity)
+		if strings.EqualFold(identity, a.Name) || strings.EqualFold(identity, a.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// InPeriod reports whether the line is authored in the period [since, until),
+// the period is open on the side whose time is zero.
+func (a *LineAuthor) InPeriod(since, until time.Time) bool {
+	if !since.IsZero() && a.When.Before(since) {
+		return false
+	}
+	return until.IsZero() || a.When.Before(until)
+}
+
+func (g *gitClient) Blame(ctx context.Context, fileName string) ([]*LineAuthor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	head, err := g.repository.Head()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD %w", err)
+	}
+	headCommit, err := g.repository.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD commit %w", err)
+	}
+
+	result, err := gogit.Blame(headCommit, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("blame %s %w", fileName, err)
+	}
+	authors := make([]*LineAuthor, 0, len(result.Lines))
+	for _, line := range result.Lines {
+		authors = append(authors, &LineAuthor{Name: line.AuthorName, Email: line.Author, When: line.Date, Commit: line.Hash.String()})
+	}
+	return authors, nil
+}
+
+// FilterLines keeps the added lines of the change whose authors are kept by keep, such as the lines of some authors,
+// the added sections are split around the lines that are dropped. The authors are indexed by the line numbers
+// of the file from 1, the lines without author are dropped. It returns nil if none of the added lines is kept.
+func FilterLines(change *Change, authors []*LineAuthor, keep func(*LineAuthor) bool) *Change {
+	var sections []*Section
+	for _, s := range change.Sections {
+		var current *Section
+		for line := s.StartLine; line <= s.EndLine; line++ {
+			if line > len(authors) || !keep(authors[line-1]) {
+				current = nil
+				continue
+			}
+			if current == nil {
+				current = &Section{Operation: s.Operation, StartLine: line}
+				sections = append(sections, current)
+			}
+			current.EndLine = line
+			current.Count++
+			current.Contents = append(current.Contents, s.Contents[line-s.StartLine])
+		}
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+
+	return &Change{
+		FileName: change.FileName,
+		Mode:     change.Mode,
+		Sections: sections,
+		Deleted:  change.Deleted,
+	}
+}
@@ -0,0 +1,129 @@
+package gittool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestBlame(t *testing.T) {
+	repositoryPath, repository, clean := temporalRepository("")
+	defer clean()
+
+	worktree, err := repository.Worktree()
+	checkError(err)
+	commit := func(contents string, name, email string) {
+		err := os.WriteFile(filepath.Join(repositoryPath, "foo.go"), []byte(contents), 0644)
+		checkError(err)
+		_, err = worktree.Add("foo.go")
+		checkError(err)
+		_, err = worktree.Commit("change foo", &gogit.CommitOptions{
+			Author: &object.Signature{Name: name, Email: email, When: time.Now()},
+		})
+		checkError(err)
+	}
+	commit("package foo\n\nfunc Foo() {}\n", "foo", "foo@bar.org")
+	commit("package foo\n\nfunc Foo() {}\n\nfunc Bar() {}\n", "Jane Doe", "jane@bar.org")
+
+	g := &gitClient{repositoryPath: repositoryPath, repository: repository}
+	authors, err := g.Blame(context.Background(), "foo.go")
+	if err != nil {
+		t.Fatalf("should not return error, but get: %s", err)
+	}
+	for _, a := range authors {
+		if a.When.IsZero() || len(a.Commit) != 40 {
+			t.Errorf("expect the date and the commit of the line, but get %v", a)
+		}
+		a.When, a.Commit = time.Time{}, ""
+	}
+	foo := &LineAuthor{Name: "foo", Email: "foo@bar.org"}
+	jane := &LineAuthor{Name: "Jane Doe", Email: "jane@bar.org"}
+	if expected := []*LineAuthor{foo, foo, foo, jane, jane}; !reflect.DeepEqual(authors, expected) {
+		t.Errorf("expect authors %v, but get %v", expected, authors)
+	}
+}
+
+func TestFilterLines(t *testing.T) {
+	foo := &LineAuthor{Name: "foo", Email: "foo@bar.org"}
+	jane := &LineAuthor{Name: "Jane Doe", Email: "jane@bar.org"}
+	authors := []*LineAuthor{foo, jane, jane, foo, jane, foo}
+	change := &Change{
+		FileName: "foo.go",
+		Mode:     ModifyMode,
+		Sections: []*Section{
+			{Operation: Add, StartLine: 2, EndLine: 5, Count: 4, Contents: []string{"b", "c", "d", "e"}},
+			{Operation: Add, StartLine: 6, EndLine: 6, Count: 1, Contents: []string{"f"}},
+		},
+	}
+
+	matches := func(identities ...string) func(*LineAuthor) bool {
+		return func(a *LineAuthor) bool {
+			return a.Matches(identities)
+		}
+	}
+
+	t.Run("matches", func(t *testing.T) {
+		if !jane.Matches([]string{"JANE@bar.org"}) || !jane.Matches([]string{"someone", " jane doe"}) {
+			t.Error("jane should match her name or email")
+		}
+		if jane.Matches([]string{"jane"}) {
+			t.Error("jane should not match a part of her name")
+		}
+	})
+
+	t.Run("split the sections", func(t *testing.T) {
+		expected := &Change{
+			FileName: "foo.go",
+			Mode:     ModifyMode,
+			Sections: []*Section{
+				{Operation: Add, StartLine: 2, EndLine: 3, Count: 2, Contents: []string{"b", "c"}},
+				{Operation: Add, StartLine: 5, EndLine: 5, Count: 1, Contents: []string{"e"}},
+			},
+		}
+		if filtered := FilterLines(change, authors, matches("jane@bar.org")); !reflect.DeepEqual(filtered, expected) {
+			t.Errorf("expect change %v, but get %v", expected, filtered)
+		}
+	})
+
+	t.Run("no line of the authors", func(t *testing.T) {
+		if filtered := FilterLines(change, authors, matches("bar")); filtered != nil {
+			t.Errorf("expect nil change, but get %v", filtered)
+		}
+	})
+
+	t.Run("lines without author", func(t *testing.T) {
+		filtered := FilterLines(change, authors[:2], matches("Jane Doe"))
+		expected := []*Section{{Operation: Add, StartLine: 2, EndLine: 2, Count: 1, Contents: []string{"b"}}}
+		if !reflect.DeepEqual(filtered.Sections, expected) {
+			t.Errorf("expect sections %v, but get %v", expected, filtered.Sections)
+		}
+	})
+}
+
+func TestInPeriod(t *testing.T) {
+	a := &LineAuthor{When: time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)}
+	for name, testCase := range map[string]struct {
+		since    time.Time
+		until    time.Time
+		expected bool
+	}{
+		"open":         {expected: true},
+		"since":        {since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), expected: true},
+		"after since":  {since: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		"until":        {until: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), expected: true},
+		"before until": {until: time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)},
+		"in period":    {since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), until: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), expected: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := a.InPeriod(testCase.since, testCase.until); actual != testCase.expected {
+				t.Errorf("expect %t, but get %t", testCase.expected, actual)
+			}
+		})
+	}
+}
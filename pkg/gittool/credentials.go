@@ -0,0 +1,159 @@
+package gittool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// DefaultTokenUsername is the username of the token for the https remotes, which GitHub and Azure Repos accept with any token.
+const DefaultTokenUsername = "x-access-token"
+
+var ErrFetchAuth = errors.New("authentication of the remote failed")
+
+// FetchAuth is the credentials to fetch the revisions from the remote. Without them, the https remotes are fetched
+// anonymously, and the ssh remotes use the keys of the ssh agent at SSH_AUTH_SOCK.
+type FetchAuth struct {
+	// Token authenticates the https remotes with Username, which is DefaultTokenUsername if it's empty,
+	// such as "oauth2" for GitLab.
+	Token    string
+	Username string
+	// Netrc is the netrc file that the login and the password of the host of an https remote are read from
+	// when Token is empty, such as ~/.netrc.
+	Netrc string
+	// SSHKey is the private key file that authenticates the ssh remotes instead of the ssh agent,
+	// SSHKeyPassphrase decrypts it if it's encrypted.
+	SSHKey           string
+	SSHKeyPassphrase string
+	// CABundle is the PEM certificates that the https remotes are verified with in addition to the system roots.
+	CABundle []byte
+	// Proxy is the url of the proxy of the https remotes, which replaces HTTPS_PROXY and NO_PROXY.
+	Proxy string
+}
+
+// transportOptions sets the CA bundle and the proxy of the https remote url to the fetch options.
+func (a *FetchAuth) transportOptions(url string, o *gogit.FetchOptions) {
+	if a == nil {
+		return
+	}
+	o.CABundle = a.CABundle
+	if a.Proxy == "" {
+		return
+	}
+	if endpoint, err := transport.NewEndpoint(url); err == nil && (endpoint.Protocol == "http" || endpoint.Protocol == "https") {
+		o.ProxyOptions = transport.ProxyOptions{URL: a.Proxy}
+	}
+}
+
+// method returns the auth method of the remote url, nil is returned to use the default of the transport.
+func (a *FetchAuth) method(url string) (transport.AuthMethod, error) {
+	if a == nil || url == "" {
+		return nil, nil
+	}
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("remote url %s: %w", url, err)
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		if a.SSHKey == "" {
+			return nil, nil
+		}
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+		auth, err := gitssh.NewPublicKeysFromFile(user, a.SSHKey, a.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("%w: ssh key %s: %w", ErrFetchAuth, a.SSHKey, err)
+		}
+		return auth, nil
+	case "http", "https":
+		if a.Token != "" {
+			username := a.Username
+			if username == "" {
+				username = DefaultTokenUsername
+			}
+			return &githttp.BasicAuth{Username: username, Password: a.Token}, nil
+		}
+		if a.Netrc == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(a.Netrc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: netrc: %w", ErrFetchAuth, err)
+		}
+		if login, password, ok := netrcCredentials(string(data), endpoint.Host); ok {
+			return &githttp.BasicAuth{Username: login, Password: password}, nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// netrcCredentials returns the login and the password of the machine in the netrc contents,
+// the default entry is used if none of the machines matches. The macro definitions are not supported.
+func netrcCredentials(contents string, host string) (string, string, bool) {
+	var (
+		login, password string
+		current         string // the machine of the entry being read, "" for the default entry
+		inEntry, found  bool
+	)
+	fields := strings.Fields(contents)
+	for i := 0; i < len(fields); i++ {
+		value := ""
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		switch fields[i] {
+		case "machine":
+			if found {
+				return login, password, true
+			}
+			current, inEntry, login, password = value, true, "", ""
+			found = current == host
+			i++
+		case "default":
+			if found {
+				return login, password, true
+			}
+			current, inEntry, login, password = "", true, "", ""
+			found = true
+		case "login":
+			if inEntry {
+				login = value
+			}
+			i++
+		case "password":
+			if inEntry {
+				password = value
+			}
+			i++
+		case "account":
+			i++
+		}
+	}
+	return login, password, found && (login != "" || password != "")
+}
+
+// isAuthError reports whether the fetch failed for the credentials, such as missing or rejected ones.
+func isAuthError(err error) bool {
+	if errors.Is(err, ErrFetchAuth) || errors.Is(err, transport.ErrAuthenticationRequired) ||
+		errors.Is(err, transport.ErrAuthorizationFailed) {
+		return true
+	}
+	message := err.Error()
+	for _, s := range []string{"unable to authenticate", "SSH_AUTH_SOCK", "knownhosts"} {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package gittool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+func TestNetrcCredentials(t *testing.T) {
+	contents := `machine github.com login octocat password secret
+machine gitlab.com
+  login oauth2
+  password glpat
+default login anonymous password guest
+`
+	testSuites := []struct {
+		host     string
+		login    string
+		password string
+		found    bool
+	}{
+		{host: "github.com", login: "octocat", password: "secret", found: true},
+		{host: "gitlab.com", login: "oauth2", password: "glpat", found: true},
+		{host: "example.com", login: "anonymous", password: "guest", found: true},
+	}
+	for _, ts := range testSuites {
+		login, password, found := netrcCredentials(contents, ts.host)
+		if login != ts.login || password != ts.password || found != ts.found {
+			t.Errorf("%s: expect %q %q %v, but get %q %q %v", ts.host, ts.login, ts.password, ts.found, login, password, found)
+		}
+	}
+
+	if _, _, found := netrcCredentials("machine github.com login octocat password secret\n", "gitlab.com"); found {
+		t.Error("gitlab.com should not be found without default entry")
+	}
+}
+
+func TestFetchAuthMethod(t *testing.T) {
+	netrc := filepath.Join(t.TempDir(), ".netrc")
+	checkError(os.WriteFile(netrc, []byte("machine example.com login foo password bar\n"), 0600))
+
+	t.Run("nil", func(t *testing.T) {
+		var a *FetchAuth
+		if auth, err := a.method("https://example.com/repo.git"); auth != nil || err != nil {
+			t.Errorf("expect no auth, but get %v %v", auth, err)
+		}
+	})
+
+	t.Run("token", func(t *testing.T) {
+		auth, err := (&FetchAuth{Token: "token", Username: "oauth2", Netrc: netrc}).method("https://example.com/repo.git")
+		checkError(err)
+		if basic, ok := auth.(*githttp.BasicAuth); !ok || basic.Username != "oauth2" || basic.Password != "token" {
+			t.Errorf("expect the token as basic auth, but get %v", auth)
+		}
+	})
+
+	t.Run("netrc", func(t *testing.T) {
+		auth, err := (&FetchAuth{Netrc: netrc}).method("https://example.com/repo.git")
+		checkError(err)
+		if basic, ok := auth.(*githttp.BasicAuth); !ok || basic.Username != "foo" || basic.Password != "bar" {
+			t.Errorf("expect the netrc credentials as basic auth, but get %v", auth)
+		}
+		if auth, _ := (&FetchAuth{Netrc: netrc}).method("https://github.com/repo.git"); auth != nil {
+			t.Errorf("expect no auth of the host out of netrc, but get %v", auth)
+		}
+	})
+
+	t.Run("ssh agent", func(t *testing.T) {
+		if auth, err := (&FetchAuth{Token: "token"}).method("git@github.com:owner/repo.git"); auth != nil || err != nil {
+			t.Errorf("expect the default of ssh transport, but get %v %v", auth, err)
+		}
+	})
+
+	t.Run("missing ssh key", func(t *testing.T) {
+		_, err := (&FetchAuth{SSHKey: filepath.Join(t.TempDir(), "id_ed25519")}).method("ssh://git@github.com/owner/repo.git")
+		if !errors.Is(err, ErrFetchAuth) {
+			t.Errorf("expect error %s, but get %v", ErrFetchAuth, err)
+		}
+	})
+}
+
+func TestFetchAuthTransportOptions(t *testing.T) {
+	a := &FetchAuth{CABundle: []byte("certificates"), Proxy: "http://proxy.example.com:8080"}
+
+	o := &gogit.FetchOptions{}
+	a.transportOptions("https://example.com/repo.git", o)
+	if string(o.CABundle) != "certificates" || o.ProxyOptions.URL != "http://proxy.example.com:8080" {
+		t.Errorf("expect the CA bundle and the proxy of the https remote, but get %+v", o)
+	}
+
+	o = &gogit.FetchOptions{}
+	a.transportOptions("git@github.com:owner/repo.git", o)
+	if o.ProxyOptions.URL != "" {
+		t.Errorf("expect no http proxy of the ssh remote, but get %s", o.ProxyOptions.URL)
+	}
+
+	o = &gogit.FetchOptions{}
+	(*FetchAuth)(nil).transportOptions("https://example.com/repo.git", o)
+	if o.CABundle != nil || o.ProxyOptions.URL != "" {
+		t.Errorf("expect the defaults of the transport without the auth, but get %+v", o)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	if !isAuthError(transport.ErrAuthenticationRequired) || !isAuthError(transport.ErrAuthorizationFailed) {
+		t.Error("the authentication errors of the transport should be auth errors")
+	}
+	if !isAuthError(errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey]")) {
+		t.Error("the ssh handshake error should be an auth error")
+	}
+	if isAuthError(transport.ErrRepositoryNotFound) {
+		t.Error("repository not found should not be an auth error")
+	}
+}
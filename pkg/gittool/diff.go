@@ -3,23 +3,35 @@ package gittool
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
 	gogitobj "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
-// NewGitClient creates a git client instance for git diff.
+var (
+	ErrNoCommitBefore = errors.New("no commit before the time")
+	ErrNoMergeBase    = errors.New("no common ancestor of HEAD and the revision")
+)
+
+// NewGitClient creates a git client instance for git diff, the symlinks of the repository path are resolved.
 func NewGitClient(
 	repositoryPath string,
 ) (GitClient, error) {
+	repositoryPath = RealPath(repositoryPath)
 	repository, err := gogit.PlainOpen(repositoryPath)
 	if err != nil {
 		return nil, err
@@ -30,85 +42,346 @@ func NewGitClient(
 	}, nil
 }
 
+// GitClient reads the changes and the commits of the repository.
+// The methods that read the trees or the history, or fetch from the remote, stop with the error of the context
+// once it's done, so a long run can be cancelled.
 type GitClient interface {
 	// DiffChangesFromCommitted returns the diff changes between HEAD and compared branch commit.
-	DiffChangesFromCommitted(compareBranch string) ([]*Change, error)
+	DiffChangesFromCommitted(ctx context.Context, compareBranch string) ([]*Change, error)
+	// StreamChangesFromCommitted calls fn with each diff change between HEAD and compared branch commit
+	// as soon as its patch is computed, so the changes are not in order. It stops once fn returns an error.
+	StreamChangesFromCommitted(ctx context.Context, compareBranch string, fn func(*Change) error) error
+	// HeadCommit returns the hash of HEAD commit and the current branch name,
+	// the branch name is empty when HEAD is detached.
+	HeadCommit() (string, string, error)
+	// CommitBefore returns the hash of the latest commit in HEAD history that is committed before the time.
+	CommitBefore(ctx context.Context, t time.Time) (string, error)
+	// MergeBase returns the hash of the best common ancestor of HEAD and the revision,
+	// it equals to executing command `git merge-base HEAD {revision}`.
+	MergeBase(revision string) (string, error)
+	// EnsureRevision makes sure that the commit of the revision exists, and fetches its branch from the remote
+	// if it's missing, fetching is disabled if remote is empty.
+	EnsureRevision(ctx context.Context, revision string, remote string) error
+	// IsShallow reports whether the repository is a shallow clone, whose history is truncated.
+	IsShallow() (bool, error)
+	// SetFetchAuth sets the credentials that EnsureRevision fetches the remote with,
+	// the transport defaults are used if it's nil.
+	SetFetchAuth(auth *FetchAuth)
+	// SetDetectMoves enables the detection of the renamed files and the code moved within or across files,
+	// the moved code of the changes is kept in Moved. The changes are streamed only once all of them are built,
+	// as the code may be moved from any of the files.
+	SetDetectMoves(detect bool)
+	// Renames returns the new paths of the files that are renamed or moved between the revision and HEAD
+	// by their paths in the revision, the renames are detected by the similarity of the contents like `git diff -M`.
+	Renames(ctx context.Context, revision string) (map[string]string, error)
+	// Blame returns the authors of the lines of the file at HEAD, the first one is of line 1,
+	// it equals to executing command `git blame HEAD -- {fileName}`.
+	Blame(ctx context.Context, fileName string) ([]*LineAuthor, error)
 }
 
 type gitClient struct {
 	repository     *gogit.Repository
 	repositoryPath string
+	auth           *FetchAuth // credentials of fetching the remote, the transport defaults are used if it's nil
+	detectMoves    bool       // detects the renamed files and the moved code, refer to SetDetectMoves
 }
 
 var _ GitClient = (*gitClient)(nil)
 
-func (g *gitClient) DiffChangesFromCommitted(compareBranch string) ([]*Change, error) {
-	changes, err := g.diffChanges(compareBranch)
+func (g *gitClient) DiffChangesFromCommitted(ctx context.Context, compareBranch string) ([]*Change, error) {
+	var indexed []indexedChange
+	err := g.streamChanges(ctx, compareBranch, func(index int, change *Change) error {
+		indexed = append(indexed, indexedChange{index: index, change: change})
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("execute diff: %w", err)
+		return nil, err
 	}
 
+	// keep the order of the changes as git diff outputs
+	sort.Slice(indexed, func(i, j int) bool {
+		return indexed[i].index < indexed[j].index
+	})
 	var diffChanges []*Change
-	for _, change := range changes {
-		patch, err := change.Patch()
-		if err != nil {
-			return nil, fmt.Errorf("get patch: %w", err)
-		}
-		filePatches := patch.FilePatches()
-		if len(filePatches) < 1 {
-			return nil, errors.New("no patch found")
+	for _, c := range indexed {
+		diffChanges = append(diffChanges, c.change)
+	}
+	return diffChanges, nil
+}
+
+func (g *gitClient) StreamChangesFromCommitted(ctx context.Context, compareBranch string, fn func(*Change) error) error {
+	return g.streamChanges(ctx, compareBranch, func(_ int, change *Change) error {
+		return fn(change)
+	})
+}
+
+// indexedChange is the change, or the error of building it, with its index in the git diff output.
+type indexedChange struct {
+	index  int
+	change *Change
+	err    error
+}
+
+// streamChanges computes the patches of the changed files concurrently, and calls fn with the change
+// and its index in the git diff output as soon as the change is ready.
+// The changes that don't need to be checked, such as deleted or non-go files, are omitted.
+//
+// The storage of go-git is not safe for concurrent use, so each worker opens the repository by itself,
+// and reads the trees of the change from its own repository. The workers stop once the context is done.
+func (g *gitClient) streamChanges(ctx context.Context, compareBranch string, fn func(int, *Change) error) error {
+	changes, err := g.diffChanges(ctx, compareBranch)
+	if err != nil {
+		// go-git returns its own error of the cancellation, which doesn't wrap the context error.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
 		}
+		return fmt.Errorf("execute diff: %w", err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
 
-		diffChange, err := g.buildChangeFromPatch(filePatches[0])
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(changes) {
+		workers = len(changes)
+	}
+	repositories := make([]*gogit.Repository, workers)
+	for i := range repositories {
+		repositories[i], err = gogit.PlainOpen(g.repositoryPath)
 		if err != nil {
-			return nil, fmt.Errorf("build change from patch: %w", err)
+			return fmt.Errorf("open repository: %w", err)
 		}
+	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan int)
+	results := make(chan indexedChange)
+	done := ctx.Done()
+
+	go func() {
+		defer close(jobs)
+		for i := range changes {
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, repository := range repositories {
+		wg.Add(1)
+		go func(repository *gogit.Repository) {
+			defer wg.Done()
+			for i := range jobs {
+				change, err := g.buildChange(ctx, repository, changes[i])
+				select {
+				case results <- indexedChange{index: i, change: change, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}(repository)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var built []indexedChange
+	for r := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if r.err != nil {
+			return r.err
+		}
 		// filter nil change because buildChangeFromPatch should return nil as result
-		if diffChange != nil {
-			diffChanges = append(diffChanges, diffChange)
+		if r.change == nil {
+			continue
+		}
+		if g.detectMoves {
+			built = append(built, r)
+			continue
 		}
+		if err := fn(r.index, r.change); err != nil {
+			return err
+		}
+	}
+	// the workers stop without results once the context is done.
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+	if g.detectMoves {
+		return streamMovedChanges(built, fn)
+	}
+	return nil
+}
 
-	return diffChanges, nil
+// buildChange builds the diff change from the git change, the trees of the change are read from the repository.
+func (g *gitClient) buildChange(ctx context.Context, repository *gogit.Repository, change *gogitobj.Change) (*Change, error) {
+	from, err := changeEntryOf(repository, change.From)
+	if err != nil {
+		return nil, fmt.Errorf("get tree of %s: %w", change.From.Name, err)
+	}
+	to, err := changeEntryOf(repository, change.To)
+	if err != nil {
+		return nil, fmt.Errorf("get tree of %s: %w", change.To.Name, err)
+	}
+
+	patch, err := (&gogitobj.Change{From: from, To: to}).PatchContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get patch: %w", err)
+	}
+	filePatches := patch.FilePatches()
+	if len(filePatches) < 1 {
+		return nil, errors.New("no patch found")
+	}
+
+	diffChange, err := g.buildChangeFromPatch(filePatches[0])
+	if err != nil {
+		return nil, fmt.Errorf("build change from patch: %w", err)
+	}
+	return diffChange, nil
+}
+
+// changeEntryOf returns the change entry whose tree is read from the repository.
+func changeEntryOf(repository *gogit.Repository, entry gogitobj.ChangeEntry) (gogitobj.ChangeEntry, error) {
+	if entry.Tree == nil {
+		return entry, nil
+	}
+	tree, err := repository.TreeObject(entry.Tree.Hash)
+	if err != nil {
+		return gogitobj.ChangeEntry{}, err
+	}
+	entry.Tree = tree
+	return entry, nil
+}
+
+func (g *gitClient) HeadCommit() (string, string, error) {
+	head, err := g.repository.Head()
+	if err != nil {
+		return "", "", fmt.Errorf("get HEAD %w", err)
+	}
+
+	var branch string
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	return head.Hash().String(), branch, nil
+}
+
+func (g *gitClient) CommitBefore(ctx context.Context, t time.Time) (string, error) {
+	head, err := g.repository.Head()
+	if err != nil {
+		return "", fmt.Errorf("get HEAD %w", err)
+	}
+
+	iter, err := g.repository.Log(&gogit.LogOptions{From: head.Hash(), Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return "", fmt.Errorf("get HEAD log %w", err)
+	}
+	defer iter.Close()
+
+	var hash string
+	err = iter.ForEach(func(c *gogitobj.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.Committer.When.Before(t) {
+			hash = c.Hash.String()
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("iterate HEAD log %w", err)
+	}
+	if hash == "" {
+		return "", fmt.Errorf("%w: %s", ErrNoCommitBefore, t.Format(time.RFC3339))
+	}
+	return hash, nil
+}
+
+func (g *gitClient) MergeBase(revision string) (string, error) {
+	head, err := g.repository.Head()
+	if err != nil {
+		return "", fmt.Errorf("get HEAD %w", err)
+	}
+	headCommit, err := g.repository.CommitObject(head.Hash())
+	if err != nil {
+		return "", fmt.Errorf("get HEAD commit %w", err)
+	}
+
+	hash, err := g.repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return "", fmt.Errorf("get %s %w", revision, err)
+	}
+	commit, err := g.repository.CommitObject(*hash)
+	if err != nil {
+		return "", fmt.Errorf("get %s commit %w", revision, err)
+	}
+
+	bases, err := headCommit.MergeBase(commit)
+	if err != nil {
+		return "", fmt.Errorf("merge base of HEAD and %s %w", revision, err)
+	}
+	if len(bases) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNoMergeBase, revision)
+	}
+	return bases[0].Hash.String(), nil
 }
 
 // diffChanges get the diff changes between compared branch and HEAD commit.
 // It equals to executing command `git diff {comparedBranch}...HEAD`.
 //
 // It uses package github.com/go-git/go-git to get such output.
-func (g *gitClient) diffChanges(comparedBranch string) (gogitobj.Changes, error) {
+func (g *gitClient) diffChanges(ctx context.Context, comparedBranch string) (gogitobj.Changes, error) {
+	comparedTree, headTree, err := g.trees(comparedBranch)
+	if err != nil {
+		return gogitobj.Changes{}, err
+	}
+	if g.detectMoves {
+		return gogitobj.DiffTreeWithOptions(ctx, comparedTree, headTree, gogitobj.DefaultDiffTreeOptions)
+	}
+	return gogitobj.DiffTreeContext(ctx, comparedTree, headTree)
+}
+
+// trees returns the tree of the revision and the tree of HEAD commit.
+func (g *gitClient) trees(revision string) (*gogitobj.Tree, *gogitobj.Tree, error) {
 	// get commit object of HEAD
 	head, err := g.repository.Head()
 	if err != nil {
-		return gogitobj.Changes{}, fmt.Errorf("get HEAD %w", err)
+		return nil, nil, fmt.Errorf("get HEAD %w", err)
 	}
 
 	headCommit, err := g.repository.CommitObject(head.Hash())
 	if err != nil {
-		return gogitobj.Changes{}, fmt.Errorf("get HEAD commit %w", err)
+		return nil, nil, fmt.Errorf("get HEAD commit %w", err)
 	}
 	headTree, err := headCommit.Tree()
 	if err != nil {
-		return gogitobj.Changes{}, fmt.Errorf("get HEAD tree object %w", err)
+		return nil, nil, fmt.Errorf("get HEAD tree object %w", err)
 	}
 
 	// get commit object of compared branch
-	comparedHash, err := g.repository.ResolveRevision(plumbing.Revision(comparedBranch))
+	comparedHash, err := g.repository.ResolveRevision(plumbing.Revision(revision))
 	if err != nil {
-		return gogitobj.Changes{}, fmt.Errorf("get %s %w", comparedBranch, err)
+		return nil, nil, fmt.Errorf("get %s %w", revision, err)
 	}
 
 	comparedCommit, err := g.repository.CommitObject(*comparedHash)
 	if err != nil {
-		return gogitobj.Changes{}, fmt.Errorf("get %s commit %w", comparedBranch, err)
+		return nil, nil, fmt.Errorf("get %s commit %w", revision, err)
 	}
 	comparedTree, err := comparedCommit.Tree()
 	if err != nil {
-		return gogitobj.Changes{}, fmt.Errorf("get %s tree object %w", comparedBranch, err)
+		return nil, nil, fmt.Errorf("get %s tree object %w", revision, err)
 	}
-
-	return gogitobj.DiffTree(comparedTree, headTree)
+	return comparedTree, headTree, nil
 }
 
 // buildChangeFromPatch builds the diff change from file patch.
@@ -135,7 +408,10 @@ func (g *gitClient) buildChangeFromPatch(filePatch diff.FilePatch) (*Change, err
 
 	// delete file
 	case to == nil:
-		// we don't care about delete files, omit it
+		// we don't care about delete files, omit it, unless the code may be moved from them.
+		if g.detectMoves && isGoFile(from) {
+			return buildChangeFromDeletedFile(from.Path(), filePatch.Chunks()), nil
+		}
 	}
 
 	return nil, nil
@@ -156,7 +432,9 @@ func (g *gitClient) buildChangeFromChunks(filename string, chunks []diff.Chunk)
 	// count the total lines of the file
 	// equals lines + added lines should be equal with total lines.
 	totalCount := 0
-	var sections []*Section
+	// count the lines of the file in the compared branch, which is equals lines + deleted lines.
+	oldCount := 0
+	var sections, deleted []*Section
 
 	for _, chunk := range chunks {
 
@@ -165,6 +443,7 @@ func (g *gitClient) buildChangeFromChunks(filename string, chunks []diff.Chunk)
 			scanner := bufio.NewScanner(bytes.NewBufferString(chunk.Content()))
 			for scanner.Scan() {
 				totalCount++
+				oldCount++
 			}
 
 		case diff.Add:
@@ -190,7 +469,22 @@ func (g *gitClient) buildChangeFromChunks(filename string, chunks []diff.Chunk)
 			})
 
 		case diff.Delete:
-			// we omit delete chunks
+			// the delete chunks don't count for diff coverage, they're kept to show the diff side by side.
+			startLine := oldCount + 1
+			scanner := bufio.NewScanner(bytes.NewBufferString(chunk.Content()))
+			var contents []string
+			for scanner.Scan() {
+				oldCount++
+				contents = append(contents, scanner.Text())
+			}
+
+			deleted = append(deleted, &Section{
+				StartLine: startLine,
+				EndLine:   oldCount,
+				Count:     len(contents),
+				Contents:  contents,
+				Operation: Delete,
+			})
 		}
 	}
 
@@ -198,6 +492,7 @@ func (g *gitClient) buildChangeFromChunks(filename string, chunks []diff.Chunk)
 		FileName: filename,
 		Sections: sections,
 		Mode:     ModifyMode,
+		Deleted:  deleted,
 	}, nil
 }
 
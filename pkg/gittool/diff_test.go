@@ -1,8 +1,12 @@
 package gittool
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -45,7 +49,7 @@ func TestDiffChanges(t *testing.T) {
 		defer clean()
 
 		client := &gitClient{repository: repo}
-		_, err := client.diffChanges(branch)
+		_, err := client.diffChanges(context.Background(), branch)
 		if err != nil {
 			t.Errorf("diff change: %s", err)
 		}
@@ -193,6 +197,20 @@ line6`
 		if section.Contents[1] != "line4" {
 			t.Errorf("first item should be 'line4', but get: %s", section.Contents[1])
 		}
+
+		if len(change.Deleted) != 1 {
+			t.Errorf("change should contain 1 deleted section, but get %d", len(change.Deleted))
+		}
+		deleted := change.Deleted[0]
+		if deleted.Operation != Delete {
+			t.Errorf("should be Delete(%d) operation, but get %d", Delete, deleted.Operation)
+		}
+		if deleted.StartLine != 3 || deleted.EndLine != 4 {
+			t.Errorf("deleted section should be lines 3-4 of the compared file, but get %d-%d", deleted.StartLine, deleted.EndLine)
+		}
+		if deleted.Count != 2 || deleted.Contents[0] != "line5" {
+			t.Errorf("deleted section should contain 'line5' and 'line6', but get: %v", deleted.Contents)
+		}
 	})
 }
 
@@ -355,7 +373,7 @@ func TestDiffChangesFromCommitted(t *testing.T) {
 		defer clean()
 
 		g := &gitClient{repositoryPath: path, repository: repo}
-		_, err := g.DiffChangesFromCommitted("foo")
+		_, err := g.DiffChangesFromCommitted(context.Background(), "foo")
 		if err == nil {
 			t.Error("should return error")
 		}
@@ -367,10 +385,169 @@ func TestDiffChangesFromCommitted(t *testing.T) {
 		defer clean()
 
 		g := &gitClient{repositoryPath: path, repository: repo}
-		_, err := g.DiffChangesFromCommitted("foo")
+		_, err := g.DiffChangesFromCommitted(context.Background(), "foo")
+		if err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+	})
+}
+
+func TestStreamChangesFromCommitted(t *testing.T) {
+	path, repo, clean := temporalRepository("foo")
+	defer clean()
+
+	// commit several go files on the new branch
+	worktree, err := repo.Worktree()
+	checkError(err)
+	for _, name := range []string{"a.go", "b.go", "c.go", "d_test.go"} {
+		err = os.WriteFile(filepath.Join(path, name), []byte("package foo\n"), 0644)
+		checkError(err)
+		_, err = worktree.Add(name)
+		checkError(err)
+	}
+	_, err = worktree.Commit("add go files", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "foo", Email: "foo@bar.org", When: time.Now()},
+	})
+	checkError(err)
+
+	g := &gitClient{repositoryPath: path, repository: repo}
+
+	t.Run("stream all the changes", func(t *testing.T) {
+		var streamed []string
+		err := g.StreamChangesFromCommitted(context.Background(), "master", func(c *Change) error {
+			streamed = append(streamed, c.FileName)
+			return nil
+		})
 		if err != nil {
 			t.Errorf("should not return error, but get: %s", err)
 		}
+
+		changes, err := g.DiffChangesFromCommitted(context.Background(), "master")
+		if err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+		var ordered []string
+		for _, c := range changes {
+			ordered = append(ordered, c.FileName)
+		}
+		if !reflect.DeepEqual(ordered, []string{"a.go", "b.go", "c.go"}) {
+			t.Errorf("expect changes [a.go b.go c.go], but get %v", ordered)
+		}
+
+		sort.Strings(streamed)
+		if !reflect.DeepEqual(streamed, ordered) {
+			t.Errorf("expect streamed changes %v, but get %v", ordered, streamed)
+		}
+	})
+
+	t.Run("stop when fn returns error", func(t *testing.T) {
+		stop := errors.New("stop")
+		var count int
+		err := g.StreamChangesFromCommitted(context.Background(), "master", func(c *Change) error {
+			count++
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("expect error %s, but get %v", stop, err)
+		}
+		if count != 1 {
+			t.Errorf("expect fn is called once, but get %d", count)
+		}
+	})
+
+	t.Run("stop when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := g.StreamChangesFromCommitted(ctx, "master", func(c *Change) error {
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expect error %s, but get %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestHeadCommit(t *testing.T) {
+	t.Run("HEAD on branch", func(t *testing.T) {
+		path, repo, clean := temporalRepository("foo")
+		defer clean()
+
+		head, err := repo.Head()
+		checkError(err)
+
+		g := &gitClient{repositoryPath: path, repository: repo}
+		hash, branch, err := g.HeadCommit()
+		if err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+		if hash != head.Hash().String() {
+			t.Errorf("expect hash %s, but get %s", head.Hash(), hash)
+		}
+		if branch != "foo" {
+			t.Errorf("expect branch foo, but get %s", branch)
+		}
+	})
+}
+
+func TestCommitBefore(t *testing.T) {
+	path, repo, clean := temporalRepository("")
+	defer clean()
+
+	head, err := repo.Head()
+	checkError(err)
+	g := &gitClient{repositoryPath: path, repository: repo}
+
+	t.Run("commit found", func(t *testing.T) {
+		hash, err := g.CommitBefore(context.Background(), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+		if hash != head.Hash().String() {
+			t.Errorf("expect hash %s, but get %s", head.Hash(), hash)
+		}
+	})
+
+	t.Run("no commit before", func(t *testing.T) {
+		_, err := g.CommitBefore(context.Background(), time.Now().Add(-time.Hour))
+		if !errors.Is(err, ErrNoCommitBefore) {
+			t.Errorf("expect error %s, but get %v", ErrNoCommitBefore, err)
+		}
+	})
+}
+
+func TestMergeBase(t *testing.T) {
+	path, repo, clean := temporalRepository("foo")
+	defer clean()
+
+	base, err := repo.Head()
+	checkError(err)
+
+	worktree, err := repo.Worktree()
+	checkError(err)
+	checkError(os.WriteFile(filepath.Join(path, "foo.go"), []byte("package foo\n"), 0644))
+	_, err = worktree.Add("foo.go")
+	checkError(err)
+	_, err = worktree.Commit("foo commit", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "foo", Email: "foo@bar.org", When: time.Now()},
+	})
+	checkError(err)
+
+	g := &gitClient{repositoryPath: path, repository: repo}
+
+	t.Run("merge base found", func(t *testing.T) {
+		hash, err := g.MergeBase("master")
+		if err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+		if hash != base.Hash().String() {
+			t.Errorf("expect hash %s, but get %s", base.Hash(), hash)
+		}
+	})
+
+	t.Run("revision not found", func(t *testing.T) {
+		if _, err := g.MergeBase("not-exist"); err == nil {
+			t.Errorf("should return error")
+		}
 	})
 }
 
@@ -0,0 +1,148 @@
+package gittool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// EnsureRevision makes sure that the commit of the revision exists in the repository.
+// The commit is usually missing when the repository is a shallow clone, such as the checkout in CI.
+// When remote is not empty, the branch of the revision is fetched from the remote, with depth 1 for a shallow clone,
+// as only the tree of the commit is needed for git diff. The remote is fetched with the credentials of SetFetchAuth,
+// and ErrFetchAuth is returned if they're missing or rejected.
+func (g *gitClient) EnsureRevision(ctx context.Context, revision string, remote string) error {
+	if g.hasRevision(revision) {
+		return nil
+	}
+
+	if remote == "" {
+		return g.revisionNotFound(revision, remote)
+	}
+
+	branch, local := branchOf(revision, remote)
+	if branch == "" || plumbing.IsHash(revision) {
+		return g.revisionNotFound(revision, remote)
+	}
+
+	dst := plumbing.NewRemoteReferenceName(remote, branch)
+	if local {
+		dst = plumbing.NewBranchReferenceName(branch)
+	}
+	refSpec := config.RefSpec(fmt.Sprintf("+%s:%s", plumbing.NewBranchReferenceName(branch), dst))
+
+	depth := 0
+	if shallow, err := g.IsShallow(); err == nil && shallow {
+		depth = 1
+	}
+
+	url, err := g.remoteURL(remote)
+	if err != nil {
+		return fmt.Errorf("fetch %s from %s: %w", branch, remote, err)
+	}
+	auth, err := g.auth.method(url)
+	if err != nil {
+		return fmt.Errorf("fetch %s from %s: %w", branch, remote, err)
+	}
+
+	options := &gogit.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Depth:      depth,
+		Auth:       auth,
+	}
+	g.auth.transportOptions(url, options)
+	err = g.repository.FetchContext(ctx, options)
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		if isAuthError(err) {
+			return fmt.Errorf(
+				"%w: fetch %s from %s: %w, provide the credentials of the remote, such as a token or a netrc file for https, "+
+					"or the ssh agent or a private key for ssh",
+				ErrFetchAuth, branch, remote, err,
+			)
+		}
+		return fmt.Errorf("fetch %s from %s: %w, %w", branch, remote, err, g.revisionNotFound(revision, remote))
+	}
+
+	if !g.hasRevision(revision) {
+		return g.revisionNotFound(revision, remote)
+	}
+	return nil
+}
+
+func (g *gitClient) IsShallow() (bool, error) {
+	shallow, err := g.repository.Storer.Shallow()
+	if err != nil {
+		return false, fmt.Errorf("read shallow commits: %w", err)
+	}
+	return len(shallow) != 0, nil
+}
+
+func (g *gitClient) SetFetchAuth(auth *FetchAuth) {
+	g.auth = auth
+}
+
+// remoteURL returns the first url of the remote whose credentials are set, it's empty without the credentials.
+func (g *gitClient) remoteURL(remote string) (string, error) {
+	if g.auth == nil {
+		return "", nil
+	}
+	r, err := g.repository.Remote(remote)
+	if err != nil {
+		return "", fmt.Errorf("remote %s: %w", remote, err)
+	}
+	urls := r.Config().URLs
+	if len(urls) == 0 {
+		return "", nil
+	}
+	return urls[0], nil
+}
+
+// hasRevision checks whether the revision can be resolved to a commit that exists in the repository.
+func (g *gitClient) hasRevision(revision string) bool {
+	hash, err := g.repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return false
+	}
+	_, err = g.repository.CommitObject(*hash)
+	return err == nil
+}
+
+// revisionNotFound returns the error that tells how to make the revision available.
+func (g *gitClient) revisionNotFound(revision string, remote string) error {
+	if remote == "" {
+		remote = "origin"
+	}
+	branch, _ := branchOf(revision, remote)
+	if branch == "" {
+		branch = revision
+	}
+	return fmt.Errorf(
+		"%w: %s, the repository may be a shallow clone, run `git fetch --depth=1 %s %s` before gocover, "+
+			"or checkout with full history, such as `fetch-depth: 0` for actions/checkout",
+		ErrRevisionNotFound, revision, remote, branch,
+	)
+}
+
+// branchOf returns the branch name of the revision on the remote, and whether the revision is a local branch.
+// Revisions such as HEAD~1 or origin/master^ are not branches, empty name is returned for them.
+func branchOf(revision string, remote string) (string, bool) {
+	if strings.ContainsAny(revision, "~^@:") {
+		return "", false
+	}
+
+	name := revision
+	for _, prefix := range []string{"refs/remotes/" + remote + "/", remote + "/"} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix), false
+		}
+	}
+	return strings.TrimPrefix(name, "refs/heads/"), true
+}
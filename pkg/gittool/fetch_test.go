@@ -0,0 +1,147 @@
+package gittool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestEnsureRevision(t *testing.T) {
+	originPath, origin, clean := temporalRepository("")
+	defer clean()
+
+	clonePath, err := os.MkdirTemp("", "gocover")
+	checkError(err)
+	defer os.RemoveAll(clonePath)
+	clone, err := gogit.PlainClone(clonePath, false, &gogit.CloneOptions{URL: originPath})
+	checkError(err)
+
+	// create a branch in origin after the clone, so it's missing in the clone
+	worktree, err := origin.Worktree()
+	checkError(err)
+	err = worktree.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("bar"), Create: true})
+	checkError(err)
+	err = os.WriteFile(filepath.Join(originPath, "bar.go"), []byte("package bar\n"), 0644)
+	checkError(err)
+	_, err = worktree.Add("bar.go")
+	checkError(err)
+	_, err = worktree.Commit("add bar", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "foo", Email: "foo@bar.org", When: time.Now()},
+	})
+	checkError(err)
+
+	g := &gitClient{repositoryPath: clonePath, repository: clone}
+
+	t.Run("revision exists", func(t *testing.T) {
+		if err := g.EnsureRevision(context.Background(), "origin/master", ""); err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+	})
+
+	t.Run("revision is missing and fetching is disabled", func(t *testing.T) {
+		err := g.EnsureRevision(context.Background(), "origin/bar", "")
+		if !errors.Is(err, ErrRevisionNotFound) {
+			t.Errorf("expect error %s, but get %v", ErrRevisionNotFound, err)
+		}
+	})
+
+	t.Run("revision is not a branch", func(t *testing.T) {
+		err := g.EnsureRevision(context.Background(), "origin/bar~1", "origin")
+		if !errors.Is(err, ErrRevisionNotFound) {
+			t.Errorf("expect error %s, but get %v", ErrRevisionNotFound, err)
+		}
+	})
+
+	t.Run("fetch the missing revision", func(t *testing.T) {
+		if err := g.EnsureRevision(context.Background(), "origin/bar", "origin"); err != nil {
+			t.Errorf("should not return error, but get: %s", err)
+		}
+		if !g.hasRevision("origin/bar") {
+			t.Error("origin/bar should be fetched")
+		}
+	})
+
+	t.Run("branch is missing in remote", func(t *testing.T) {
+		err := g.EnsureRevision(context.Background(), "origin/zoo", "origin")
+		if !errors.Is(err, ErrRevisionNotFound) {
+			t.Errorf("expect error %s, but get %v", ErrRevisionNotFound, err)
+		}
+	})
+
+	t.Run("credentials are rejected", func(t *testing.T) {
+		var username, password string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, _ = r.BasicAuth()
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+		_, err := clone.CreateRemote(&config.RemoteConfig{Name: "private", URLs: []string{server.URL + "/repo.git"}})
+		checkError(err)
+
+		g.SetFetchAuth(&FetchAuth{Token: "token"})
+		defer g.SetFetchAuth(nil)
+		err = g.EnsureRevision(context.Background(), "private/bar", "private")
+		if !errors.Is(err, ErrFetchAuth) {
+			t.Errorf("expect error %s, but get %v", ErrFetchAuth, err)
+		}
+		if username != DefaultTokenUsername || password != "token" {
+			t.Errorf("expect the token as basic auth, but get %q %q", username, password)
+		}
+	})
+}
+
+func TestBranchOf(t *testing.T) {
+	t.Run("branchOf", func(t *testing.T) {
+		testSuites := []struct {
+			revision string
+			branch   string
+			local    bool
+		}{
+			{revision: "origin/master", branch: "master"},
+			{revision: "refs/remotes/origin/feature/foo", branch: "feature/foo"},
+			{revision: "master", branch: "master", local: true},
+			{revision: "refs/heads/master", branch: "master", local: true},
+			{revision: "origin/master~1", branch: ""},
+			{revision: "HEAD^", branch: ""},
+		}
+
+		for _, testCase := range testSuites {
+			branch, local := branchOf(testCase.revision, "origin")
+			if branch != testCase.branch || local != testCase.local {
+				t.Errorf("for revision %s, expect (%s, %t), but get (%s, %t)",
+					testCase.revision, testCase.branch, testCase.local, branch, local)
+			}
+		}
+	})
+}
+
+func TestIsShallow(t *testing.T) {
+	path, repository, clean := temporalRepository("")
+	defer clean()
+
+	g := &gitClient{repositoryPath: path, repository: repository}
+	shallow, err := g.IsShallow()
+	checkError(err)
+	if shallow {
+		t.Error("repository should not be shallow")
+	}
+
+	head, err := repository.Head()
+	checkError(err)
+	checkError(repository.Storer.SetShallow([]plumbing.Hash{head.Hash()}))
+	shallow, err = g.IsShallow()
+	checkError(err)
+	if !shallow {
+		t.Error("repository should be shallow")
+	}
+}
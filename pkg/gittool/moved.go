@@ -0,0 +1,188 @@
+package gittool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	gogitobj "github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// minMovedLines and minMovedChars are the least code lines and alphanumeric characters of a moved block,
+// like the blocks of git diff --color-moved, so that the common lines such as `return nil` aren't regarded as moved.
+const (
+	minMovedLines = 3
+	minMovedChars = 20
+)
+
+func (g *gitClient) SetDetectMoves(detect bool) {
+	g.detectMoves = detect
+}
+
+// streamMovedChanges detects the moved code of the changes, then calls fn with the changes in the order of git diff.
+// The changes of the deleted files are only the sources of the moved code, so they're omitted.
+func streamMovedChanges(built []indexedChange, fn func(int, *Change) error) error {
+	sort.Slice(built, func(i, j int) bool {
+		return built[i].index < built[j].index
+	})
+	changes := make([]*Change, 0, len(built))
+	for _, c := range built {
+		changes = append(changes, c.change)
+	}
+	detectMovedCode(changes)
+
+	for _, c := range built {
+		if c.change.Mode == DeleteMode {
+			continue
+		}
+		if err := fn(c.index, c.change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildChangeFromDeletedFile builds the change of the deleted file, whose lines are all deleted.
+func buildChangeFromDeletedFile(filename string, chunks []diff.Chunk) *Change {
+	var contents []string
+	for _, chunk := range chunks {
+		scanner := bufio.NewScanner(bytes.NewBufferString(chunk.Content()))
+		for scanner.Scan() {
+			contents = append(contents, scanner.Text())
+		}
+	}
+
+	return &Change{
+		FileName: filename,
+		Mode:     DeleteMode,
+		Deleted: []*Section{{
+			StartLine: 1,
+			EndLine:   len(contents),
+			Count:     len(contents),
+			Contents:  contents,
+			Operation: Delete,
+		}},
+	}
+}
+
+// codeLine is a line of a section that isn't blank, the trailing spaces of its text are trimmed.
+type codeLine struct {
+	number int
+	text   string
+}
+
+// detectMovedCode sets Moved of the changes to the added lines that are also deleted in the diff,
+// the lines are compared in the blocks of at least minMovedLines code lines. The indentation counts,
+// so the code that is wrapped in a new block is still changed.
+func detectMovedCode(changes []*Change) {
+	deleted := make(map[string]bool)
+	for _, c := range changes {
+		for _, s := range c.Deleted {
+			lines := codeLines(s)
+			for i := 0; i+minMovedLines <= len(lines); i++ {
+				if key, ok := movedKey(lines[i : i+minMovedLines]); ok {
+					deleted[key] = true
+				}
+			}
+		}
+	}
+	if len(deleted) == 0 {
+		return
+	}
+
+	for _, c := range changes {
+		for _, s := range c.Sections {
+			lines := codeLines(s)
+			moved := make([]bool, len(lines))
+			for i := 0; i+minMovedLines <= len(lines); i++ {
+				if key, ok := movedKey(lines[i : i+minMovedLines]); ok && deleted[key] {
+					for j := i; j < i+minMovedLines; j++ {
+						moved[j] = true
+					}
+				}
+			}
+			c.Moved = append(c.Moved, movedSections(s, lines, moved)...)
+		}
+	}
+}
+
+// codeLines returns the lines of the section that aren't blank.
+func codeLines(s *Section) []codeLine {
+	var lines []codeLine
+	for i, text := range s.Contents {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, codeLine{number: s.StartLine + i, text: strings.TrimRightFunc(text, unicode.IsSpace)})
+	}
+	return lines
+}
+
+// movedKey returns the key of the block of the lines, it's false if the block has less than minMovedChars
+// alphanumeric characters.
+func movedKey(lines []codeLine) (string, bool) {
+	var b strings.Builder
+	chars := 0
+	for _, l := range lines {
+		for _, r := range l.text {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				chars++
+			}
+		}
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String(), chars >= minMovedChars
+}
+
+// movedSections groups the consecutive moved lines of the section into the sections,
+// the blank lines between them are kept in the sections.
+func movedSections(s *Section, lines []codeLine, moved []bool) []*Section {
+	var sections []*Section
+	for i := 0; i < len(lines); i++ {
+		if !moved[i] {
+			continue
+		}
+		j := i
+		for j+1 < len(lines) && moved[j+1] {
+			j++
+		}
+		start, end := lines[i].number, lines[j].number
+		sections = append(sections, &Section{
+			StartLine: start,
+			EndLine:   end,
+			Count:     end - start + 1,
+			Contents:  s.Contents[start-s.StartLine : end-s.StartLine+1],
+			Operation: Add,
+		})
+		i = j
+	}
+	return sections
+}
+
+func (g *gitClient) Renames(ctx context.Context, revision string) (map[string]string, error) {
+	revisionTree, headTree, err := g.trees(revision)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := gogitobj.DiffTreeWithOptions(ctx, revisionTree, headTree, gogitobj.DefaultDiffTreeOptions)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return nil, fmt.Errorf("detect renames: %w", err)
+	}
+
+	renames := make(map[string]string)
+	for _, c := range changes {
+		if c.From.Name != "" && c.To.Name != "" && c.From.Name != c.To.Name {
+			renames[c.From.Name] = c.To.Name
+		}
+	}
+	return renames, nil
+}
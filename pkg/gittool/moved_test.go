@@ -0,0 +1,179 @@
+package gittool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestDetectMovedCode(t *testing.T) {
+	deleted := []string{
+		"func Bar() int {",
+		"	result := compute(1, 2)",
+		"	return result",
+		"}",
+	}
+
+	t.Run("moved across files", func(t *testing.T) {
+		changes := []*Change{
+			{FileName: "a.go", Mode: DeleteMode, Deleted: []*Section{{StartLine: 3, EndLine: 6, Contents: deleted, Operation: Delete}}},
+			{FileName: "b.go", Mode: NewMode, Sections: []*Section{{StartLine: 1, EndLine: 8, Operation: Add, Contents: []string{
+				"package foo",
+				"",
+				"func Bar() int {",
+				"	result := compute(1, 2)",
+				"",
+				"	return result",
+				"}",
+				"func Baz() {}",
+			}}}},
+		}
+		detectMovedCode(changes)
+		if len(changes[0].Moved) != 0 {
+			t.Errorf("expect no moved code in the deleted file, but get %d sections", len(changes[0].Moved))
+		}
+		moved := changes[1].Moved
+		if len(moved) != 1 || moved[0].StartLine != 3 || moved[0].EndLine != 7 || moved[0].Count != 5 {
+			t.Fatalf("expect moved section [3, 7], but get %+v", moved)
+		}
+		if !reflect.DeepEqual(moved[0].Contents, changes[1].Sections[0].Contents[2:7]) {
+			t.Errorf("expect the contents of the moved lines, but get %v", moved[0].Contents)
+		}
+	})
+
+	t.Run("short or reindented blocks are not moved", func(t *testing.T) {
+		changes := []*Change{
+			{FileName: "a.go", Mode: ModifyMode, Deleted: []*Section{
+				{StartLine: 3, EndLine: 6, Contents: deleted, Operation: Delete},
+				{StartLine: 10, EndLine: 12, Contents: []string{"	}", "	return nil", "}"}, Operation: Delete},
+			}, Sections: []*Section{
+				{StartLine: 3, EndLine: 6, Operation: Add, Contents: []string{
+					"	func Bar() int {",
+					"		result := compute(1, 2)",
+					"		return result",
+					"	}",
+				}},
+				{StartLine: 20, EndLine: 22, Operation: Add, Contents: []string{"	}", "	return nil", "}"}},
+			}},
+		}
+		detectMovedCode(changes)
+		if len(changes[0].Moved) != 0 {
+			t.Errorf("expect no moved code, but get %+v", changes[0].Moved)
+		}
+	})
+}
+
+func TestDetectMoves(t *testing.T) {
+	path, repo, clean := temporalRepository("")
+	defer clean()
+	worktree, err := repo.Worktree()
+	checkError(err)
+
+	write := func(name, content string) {
+		err := os.WriteFile(filepath.Join(path, name), []byte(content), 0644)
+		checkError(err)
+		_, err = worktree.Add(name)
+		checkError(err)
+	}
+	commit := func(message string) {
+		_, err := worktree.Commit(message, &gogit.CommitOptions{
+			All:    true,
+			Author: &object.Signature{Name: "foo", Email: "foo@bar.org", When: time.Now()},
+		})
+		checkError(err)
+	}
+
+	bar := "func Bar(value int) int {\n\tdoubled := value * 2\n\treturn doubled + 1\n}\n"
+	baz := "func Baz(names []string) string {\n\tresult := strings.Join(names, \",\")\n\treturn result\n}\n"
+	write("a.go", "package foo\n\nfunc Foo() {}\n\n"+bar)
+	write("c.go", "package foo\n\n"+baz)
+	write("e.go", "package foo\n\nfunc Qux() {}\n")
+	commit("add go files")
+
+	err = worktree.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true})
+	checkError(err)
+	write("a.go", "package foo\n\nfunc Foo() {}\n")
+	write("b.go", "package foo\n\n"+bar)
+	_, err = worktree.Remove("c.go")
+	checkError(err)
+	write("d.go", "package foo\n\nfunc New() int {\n\treturn 1\n}\n\n"+baz)
+	_, err = worktree.Move("e.go", "f.go")
+	checkError(err)
+	commit("move the code")
+
+	g := &gitClient{repositoryPath: path, repository: repo}
+	g.SetDetectMoves(true)
+	changes, err := g.DiffChangesFromCommitted(context.Background(), "master")
+	if err != nil {
+		t.Fatalf("should not return error, but get: %s", err)
+	}
+
+	byName := make(map[string]*Change)
+	for _, c := range changes {
+		byName[c.FileName] = c
+	}
+	if _, ok := byName["c.go"]; ok {
+		t.Errorf("expect the deleted file is omitted")
+	}
+	if c := byName["f.go"]; c == nil || c.Mode != ModifyMode || len(c.Sections) != 0 {
+		t.Errorf("expect the renamed file without changed sections, but get %+v", c)
+	}
+	if c := byName["b.go"]; c == nil || len(c.Moved) != 1 || c.Moved[0].StartLine != 3 || c.Moved[0].EndLine != 6 {
+		t.Errorf("expect the lines [3, 6] of b.go are moved, but get %+v", c)
+	}
+	// the code of c.go is either moved to d.go or c.go is renamed to d.go, only the new function is changed.
+	if changed := changedCodeLines(byName["d.go"]); !reflect.DeepEqual(changed, []int{3, 4, 5}) {
+		t.Errorf("expect the lines [3, 5] of d.go are changed, but get %v", changed)
+	}
+
+	g.SetDetectMoves(false)
+	changes, err = g.DiffChangesFromCommitted(context.Background(), "master")
+	if err != nil {
+		t.Fatalf("should not return error, but get: %s", err)
+	}
+	for _, c := range changes {
+		if len(c.Moved) != 0 {
+			t.Errorf("expect no moved code without the detection, but get %+v in %s", c.Moved, c.FileName)
+		}
+		if c.FileName == "f.go" && c.Mode != NewMode {
+			t.Errorf("expect the renamed file is new without the detection, but get %v", c.Mode)
+		}
+	}
+	renames, err := g.Renames(context.Background(), "master")
+	if err != nil {
+		t.Fatalf("should not return error, but get: %s", err)
+	}
+	if renames["e.go"] != "f.go" {
+		t.Errorf("expect e.go is renamed to f.go, but get %v", renames)
+	}
+	if _, ok := renames["a.go"]; ok {
+		t.Errorf("expect the modified file is not renamed, but get %v", renames)
+	}
+}
+
+// changedCodeLines returns the lines of the sections of the change that are neither blank nor moved.
+func changedCodeLines(c *Change) []int {
+	moved := make(map[int]bool)
+	for _, s := range c.Moved {
+		for line := s.StartLine; line <= s.EndLine; line++ {
+			moved[line] = true
+		}
+	}
+	var lines []int
+	for _, s := range c.Sections {
+		for i, text := range s.Contents {
+			if line := s.StartLine + i; strings.TrimSpace(text) != "" && !moved[line] {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,92 @@
+package gittool
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// PathCase is how the file paths are compared.
+type PathCase string
+
+const (
+	// PathCaseAuto compares the paths case-insensitively on Windows and macOS,
+	// whose file systems are case-insensitive by default, and case-sensitively on the others.
+	PathCaseAuto        PathCase = "auto"
+	PathCaseSensitive   PathCase = "sensitive"
+	PathCaseInsensitive PathCase = "insensitive"
+)
+
+var ErrUnknownPathCase = errors.New("unknown path case")
+
+// caseInsensitive is whether the paths are compared case-insensitively, it's set by SetPathCase.
+var caseInsensitive = defaultCaseInsensitive(runtime.GOOS)
+
+func defaultCaseInsensitive(goos string) bool {
+	return goos == "windows" || goos == "darwin"
+}
+
+// SetPathCase sets how the paths are compared by SamePath and HasPathSuffix, it's called once before any comparison.
+func SetPathCase(c PathCase) error {
+	switch c {
+	case PathCaseAuto, "":
+		caseInsensitive = defaultCaseInsensitive(runtime.GOOS)
+	case PathCaseSensitive:
+		caseInsensitive = false
+	case PathCaseInsensitive:
+		caseInsensitive = true
+	default:
+		return fmt.Errorf("%w: %s, should be %s, %s or %s", ErrUnknownPathCase, c, PathCaseAuto, PathCaseSensitive, PathCaseInsensitive)
+	}
+	return nil
+}
+
+// RealPath returns the path with the symlinks resolved, so that the repository path, the files of git diff
+// and the package directories of go/build agree on the identity of a file when the repository or GOPATH
+// is reached through a symlink. The path is returned cleaned if it cannot be resolved, such as it doesn't exist.
+func RealPath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return resolved
+}
+
+// NormalizePath returns the path in the form that is compared, the backslashes are converted to slashes
+// as the cover profiles and the diffs generated on Windows may carry them, the leading "./" is removed,
+// the drive letter is lower cased, and the whole path is lower cased if the paths are case-insensitive.
+func NormalizePath(path string) string {
+	path = strings.ReplaceAll(path, `\`, "/")
+	for strings.HasPrefix(path, "./") {
+		path = path[len("./"):]
+	}
+	if caseInsensitive {
+		return strings.ToLower(path)
+	}
+	if len(path) >= 2 && path[1] == ':' && isLetter(path[0]) {
+		path = strings.ToLower(path[:1]) + path[1:]
+	}
+	return path
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// SamePath returns whether the two paths are the same file.
+func SamePath(a, b string) bool {
+	return NormalizePath(a) == NormalizePath(b)
+}
+
+// HasPathSuffix returns whether the path ends with the suffix at a path element boundary,
+// e.g. github.com/foo/bar/pkg/a.go ends with pkg/a.go but not with g/a.go.
+// The suffix is usually a file relative to the repository, and the path is prefixed with the module path.
+func HasPathSuffix(path, suffix string) bool {
+	path, suffix = NormalizePath(path), strings.TrimPrefix(NormalizePath(suffix), "/")
+	if suffix == "" {
+		return false
+	}
+	return path == suffix || strings.HasSuffix(path, "/"+suffix)
+}
@@ -0,0 +1,111 @@
+package gittool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRealPath(t *testing.T) {
+	t.Run("should resolve the symlink", func(t *testing.T) {
+		path, clean := temporalDir()
+		defer clean()
+
+		target := filepath.Join(path, "target")
+		checkError(os.Mkdir(target, 0755))
+		link := filepath.Join(path, "link")
+		checkError(os.Symlink(target, link))
+
+		expected, err := filepath.EvalSymlinks(target)
+		checkError(err)
+		if result := RealPath(filepath.Join(link, ".")); result != expected {
+			t.Errorf("should %s but return %s", expected, result)
+		}
+	})
+
+	t.Run("should return the cleaned path if it doesn't exist", func(t *testing.T) {
+		if result := RealPath("/not/exist/../path"); result != "/not/path" {
+			t.Errorf("should /not/path but return %s", result)
+		}
+	})
+}
+
+func TestNewGitClientWithSymlink(t *testing.T) {
+	path, _, clean := temporalRepository("")
+	defer clean()
+
+	link := path + "-link"
+	checkError(os.Symlink(path, link))
+	defer os.Remove(link)
+
+	client, err := NewGitClient(link)
+	if err != nil {
+		t.Fatalf("new git client: %s", err)
+	}
+	if result := client.(*gitClient).repositoryPath; result != RealPath(path) {
+		t.Errorf("should %s but return %s", RealPath(path), result)
+	}
+}
+
+func TestHasPathSuffix(t *testing.T) {
+	defer SetPathCase(PathCaseAuto)
+
+	for _, testCase := range []struct {
+		path            string
+		suffix          string
+		caseInsensitive bool
+		expected        bool
+	}{
+		{path: "github.com/foo/bar/pkg/a.go", suffix: "pkg/a.go", expected: true},
+		{path: "github.com/foo/bar/pkg/a.go", suffix: "./pkg/a.go", expected: true},
+		{path: "github.com/foo/bar/pkg/a.go", suffix: `pkg\a.go`, expected: true},
+		{path: "github.com/foo/bar/pkg/a.go", suffix: "/pkg/a.go", expected: true},
+		{path: "pkg/a.go", suffix: "pkg/a.go", expected: true},
+		{path: "github.com/foo/bar/pkg/xa.go", suffix: "a.go", expected: false},
+		{path: "github.com/foo/bar/pkg/a.go", suffix: "", expected: false},
+		{path: `C:\src\bar\pkg\a.go`, suffix: "c:/src/bar/pkg/a.go", expected: true},
+		{path: "github.com/foo/bar/Pkg/A.go", suffix: "pkg/a.go", expected: false},
+		{path: "github.com/foo/bar/Pkg/A.go", suffix: "pkg/a.go", caseInsensitive: true, expected: true},
+	} {
+		pathCase := PathCaseSensitive
+		if testCase.caseInsensitive {
+			pathCase = PathCaseInsensitive
+		}
+		checkError(SetPathCase(pathCase))
+
+		if result := HasPathSuffix(testCase.path, testCase.suffix); result != testCase.expected {
+			t.Errorf("%s has suffix %s in %s: should %t but return %t", testCase.path, testCase.suffix, pathCase, testCase.expected, result)
+		}
+	}
+}
+
+func TestSamePath(t *testing.T) {
+	defer SetPathCase(PathCaseAuto)
+
+	checkError(SetPathCase(PathCaseSensitive))
+	if !SamePath(`D:\a\b.go`, "d:/a/b.go") {
+		t.Error("should be the same path with different separators and drive letter case")
+	}
+	if SamePath("a/B.go", "a/b.go") {
+		t.Error("should not be the same path when case-sensitive")
+	}
+
+	checkError(SetPathCase(PathCaseInsensitive))
+	if !SamePath("a/B.go", "a/b.go") {
+		t.Error("should be the same path when case-insensitive")
+	}
+}
+
+func TestSetPathCase(t *testing.T) {
+	defer SetPathCase(PathCaseAuto)
+
+	if err := SetPathCase("upper"); !errors.Is(err, ErrUnknownPathCase) {
+		t.Errorf("should return ErrUnknownPathCase but return %v", err)
+	}
+	for goos, expected := range map[string]bool{"windows": true, "darwin": true, "linux": false} {
+		if result := defaultCaseInsensitive(goos); result != expected {
+			t.Errorf("%s should be case-insensitive %t but return %t", goos, expected, result)
+		}
+	}
+}
@@ -57,4 +57,10 @@ type Change struct {
 	// For ModifyMode it contains the each change sections made to compared branch
 	// For DeleteMode it's empty
 	Sections []*Section
+	// Deleted indicates the sections deleted from the compared branch, whose lines are numbered
+	// in the file of the compared branch. It's only populated for ModifyMode to show the diff side by side.
+	Deleted []*Section
+	// Moved indicates the parts of Sections that are moved from the code deleted in the diff,
+	// within the file or from another file. It's only populated when the moved code is detected.
+	Moved []*Section
 }
@@ -0,0 +1,193 @@
+package gocover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	outAggregateReport = "aggregate.json"
+)
+
+func NewAggregate(o *AggregateOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "aggregate")
+
+	if len(o.Results) == 0 {
+		return nil, ErrNoAggregateResult
+	}
+
+	algorithm, err := compression.Parse(o.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &aggregate{
+		results:         o.Results,
+		outputDir:       o.OutputDir,
+		compression:     algorithm,
+		reportGenerator: report.NewAggregateReportGenerator(o.OutputDir, o.ReportName, logger),
+		stdout:          stdout,
+		logger:          logger,
+	}, nil
+}
+
+var _ GoCover = (*aggregate)(nil)
+
+// aggregate implements the GoCover interface and rolls up the coverage results of several repositories.
+type aggregate struct {
+	// results are the coverage results with format [{team}=]{path},
+	// path is a history record file, or a history directory that the latest full coverage record is used.
+	results         []string
+	outputDir       string
+	compression     compression.Algorithm // compression of the json report
+	reportGenerator report.AggregateReportGenerator
+	stdout          io.Writer
+
+	logger logrus.FieldLogger
+}
+
+// teamRecord is the coverage record of a repository and the team that owns it.
+type teamRecord struct {
+	team   string
+	record *history.Record
+}
+
+func (a *aggregate) Run(ctx context.Context) error {
+	var records []*teamRecord
+	for _, v := range a.results {
+		team, path := splitLabel(v)
+		record, err := readHistoryRecord(ctx, path)
+		if err != nil {
+			return fmt.Errorf("read coverage result %s: %w", path, err)
+		}
+		records = append(records, &teamRecord{team: team, record: record})
+	}
+
+	statistics := aggregateRecords(records)
+
+	data, err := json.MarshalIndent(statistics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	reportFile, err := compression.WriteFile(filepath.Join(a.outputDir, outAggregateReport), data, 0644, a.compression)
+	if err != nil {
+		return fmt.Errorf("write aggregate report: %w", err)
+	}
+	a.logger.Infof("generate aggregate coverage report: %s", reportFile)
+
+	if err := a.reportGenerator.GenerateAggregateReport(statistics); err != nil {
+		return fmt.Errorf("generate report: %w", err)
+	}
+
+	writeAggregate(a.stdout, statistics)
+	return nil
+}
+
+// readHistoryRecord reads the history record from the file,
+// or reads the latest full coverage record if the path is a history directory.
+func readHistoryRecord(ctx context.Context, path string) (*history.Record, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		store, err := history.NewFileStore(path, compression.None)
+		if err != nil {
+			return nil, err
+		}
+		records, err := store.List(ctx, &history.Query{CoverageMode: string(FullCoverage), Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, ErrNoHistoryRecord
+		}
+		return records[0], nil
+	}
+
+	data, err := compression.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	record := &history.Record{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	return record, nil
+}
+
+// aggregateRecords rolls up the records by team and in total,
+// the coverage is weighted by the effective lines of each repository.
+func aggregateRecords(records []*teamRecord) *report.AggregateStatistics {
+	statistics := &report.AggregateStatistics{}
+	teams := make(map[string]*report.TeamStatistics)
+
+	for _, r := range records {
+		statistics.Repositories = append(statistics.Repositories, &report.RepositoryStatistics{
+			ModulePath:           r.record.ModulePath,
+			Team:                 r.team,
+			Commit:               r.record.Commit,
+			TotalEffectiveLines:  r.record.TotalEffectiveLines,
+			TotalCoveredLines:    r.record.TotalCoveredLines,
+			TotalCoveragePercent: r.record.CoveragePercent,
+		})
+		statistics.TotalEffectiveLines += r.record.TotalEffectiveLines
+		statistics.TotalCoveredLines += r.record.TotalCoveredLines
+
+		if r.team == "" {
+			continue
+		}
+		team, ok := teams[r.team]
+		if !ok {
+			team = &report.TeamStatistics{Team: r.team}
+			teams[r.team] = team
+			statistics.Teams = append(statistics.Teams, team)
+		}
+		team.Repositories++
+		team.TotalEffectiveLines += r.record.TotalEffectiveLines
+		team.TotalCoveredLines += r.record.TotalCoveredLines
+	}
+
+	for _, team := range statistics.Teams {
+		team.TotalCoveragePercent = calculateCoverage(int64(team.TotalCoveredLines), int64(team.TotalEffectiveLines))
+	}
+	sort.SliceStable(statistics.Teams, func(i, j int) bool {
+		return statistics.Teams[i].Team < statistics.Teams[j].Team
+	})
+	statistics.TotalCoveragePercent = calculateCoverage(int64(statistics.TotalCoveredLines), int64(statistics.TotalEffectiveLines))
+
+	return statistics
+}
+
+// writeAggregate outputs the summary of the coverage rollup.
+func writeAggregate(w io.Writer, statistics *report.AggregateStatistics) {
+	fmt.Fprintf(w, "total coverage of %d repositories: %.2f%% (%d/%d)\n",
+		len(statistics.Repositories), statistics.TotalCoveragePercent, statistics.TotalCoveredLines, statistics.TotalEffectiveLines)
+	for _, t := range statistics.Teams {
+		fmt.Fprintf(w, "team %s: %.2f%% (%d/%d) in %d repositories\n",
+			t.Team, t.TotalCoveragePercent, t.TotalCoveredLines, t.TotalEffectiveLines, t.Repositories)
+	}
+	for _, r := range statistics.Repositories {
+		fmt.Fprintf(w, "%s: %.2f%% (%d/%d)\n", r.ModulePath, r.TotalCoveragePercent, r.TotalCoveredLines, r.TotalEffectiveLines)
+	}
+}
@@ -0,0 +1,88 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateRecords(t *testing.T) {
+	t.Run("aggregateRecords", func(t *testing.T) {
+		statistics := aggregateRecords([]*teamRecord{
+			{team: "platform", record: &history.Record{ModulePath: "a", TotalEffectiveLines: 100, TotalCoveredLines: 90, CoveragePercent: 90}},
+			{team: "data", record: &history.Record{ModulePath: "b", TotalEffectiveLines: 300, TotalCoveredLines: 150, CoveragePercent: 50}},
+			{team: "platform", record: &history.Record{ModulePath: "c", TotalEffectiveLines: 100, TotalCoveredLines: 50, CoveragePercent: 50}},
+			{record: &history.Record{ModulePath: "d", TotalEffectiveLines: 500, TotalCoveredLines: 310, CoveragePercent: 62}},
+		})
+
+		assert.Equal(t, 1000, statistics.TotalEffectiveLines)
+		assert.Equal(t, 600, statistics.TotalCoveredLines)
+		assert.Equal(t, 60.0, statistics.TotalCoveragePercent)
+		assert.Len(t, statistics.Repositories, 4)
+		assert.Equal(t, "platform", statistics.Repositories[2].Team)
+		assert.Equal(t, []*report.TeamStatistics{
+			{Team: "data", Repositories: 1, TotalEffectiveLines: 300, TotalCoveredLines: 150, TotalCoveragePercent: 50},
+			{Team: "platform", Repositories: 2, TotalEffectiveLines: 200, TotalCoveredLines: 140, TotalCoveragePercent: 70},
+		}, statistics.Teams)
+	})
+}
+
+func TestAggregateRun(t *testing.T) {
+	t.Run("no results", func(t *testing.T) {
+		_, err := NewAggregate(&AggregateOption{})
+		assert.ErrorIs(t, err, ErrNoAggregateResult)
+	})
+
+	t.Run("aggregate record file and history directory", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := context.Background()
+
+		data, err := json.Marshal(&history.Record{ModulePath: "a", TotalEffectiveLines: 10, TotalCoveredLines: 5, CoveragePercent: 50})
+		assert.NoError(t, err)
+		recordFile := filepath.Join(dir, "a.json")
+		assert.NoError(t, os.WriteFile(recordFile, data, 0644))
+
+		historyDir := filepath.Join(dir, "history")
+		store, err := history.NewFileStore(historyDir, compression.None)
+		assert.NoError(t, err)
+		now := time.Now()
+		assert.NoError(t, store.Append(ctx, &history.Record{Timestamp: now, ModulePath: "b", CoverageMode: "full", TotalEffectiveLines: 10, CoveragePercent: 0}))
+		assert.NoError(t, store.Append(ctx, &history.Record{Timestamp: now.Add(time.Second), ModulePath: "b", CoverageMode: "full", TotalEffectiveLines: 10, TotalCoveredLines: 10, CoveragePercent: 100}))
+		assert.NoError(t, store.Append(ctx, &history.Record{Timestamp: now.Add(2 * time.Second), ModulePath: "b", CoverageMode: "diff", TotalEffectiveLines: 1}))
+
+		var buf bytes.Buffer
+		a, err := NewAggregate(&AggregateOption{
+			Results:    []string{"team-a=" + recordFile, historyDir},
+			OutputDir:  dir,
+			ReportName: "aggregate",
+			StdOut:     &buf,
+			Logger:     logrus.New(),
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, a.Run(ctx))
+
+		assert.Contains(t, buf.String(), "total coverage of 2 repositories: 75.00% (15/20)")
+		assert.Contains(t, buf.String(), "team team-a: 50.00% (5/10) in 1 repositories")
+		assert.Contains(t, buf.String(), "b: 100.00% (10/10)")
+
+		for _, name := range []string{outAggregateReport, "aggregate.html"} {
+			_, err := os.Stat(filepath.Join(dir, name))
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("empty history directory", func(t *testing.T) {
+		_, err := readHistoryRecord(context.Background(), t.TempDir())
+		assert.ErrorIs(t, err, ErrNoHistoryRecord)
+	})
+}
@@ -0,0 +1,110 @@
+package gocover
+
+import (
+	"sort"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// commitAttribution attributes the uncovered changed statements to the commits that introduce them,
+// so the commit of a multi-commit pull request that leaves the gap is found.
+type commitAttribution struct {
+	// blames are the authors of the lines of the changed files, by the file names relative to the repository.
+	blames  map[string][]*gittool.LineAuthor
+	commits map[string]*report.UncoveredCommit
+	files   map[string]*report.CommitFile // by the commit and the file name
+}
+
+// newCommitAttribution returns the attribution of the uncovered statements, it returns nil if it's disabled.
+func newCommitAttribution(enabled bool) *commitAttribution {
+	if !enabled {
+		return nil
+	}
+	return &commitAttribution{
+		blames:  make(map[string][]*gittool.LineAuthor),
+		commits: make(map[string]*report.UncoveredCommit),
+		files:   make(map[string]*report.CommitFile),
+	}
+}
+
+// addBlame adds the authors of the lines of the changed file.
+func (c *commitAttribution) addBlame(fileName string, authors []*gittool.LineAuthor) {
+	if c == nil {
+		return
+	}
+	c.blames[fileName] = authors
+}
+
+// add attributes the statement to the latest commit of its lines, which is the commit in the diff range
+// if any line of the statement is changed. fileName is prefixed with the module path,
+// the statements that start at the same line count once.
+func (c *commitAttribution) add(fileName string, st *parser.Statement) {
+	if c == nil {
+		return
+	}
+
+	var authors []*gittool.LineAuthor
+	for name, blame := range c.blames {
+		if parser.InFolder(fileName, name) {
+			authors = blame
+			break
+		}
+	}
+
+	var latest *gittool.LineAuthor
+	for line := st.StartLine; line <= st.EndLine && line <= len(authors); line++ {
+		if a := authors[line-1]; latest == nil || a.When.After(latest.When) {
+			latest = a
+		}
+	}
+	if latest == nil {
+		return
+	}
+
+	commit, ok := c.commits[latest.Commit]
+	if !ok {
+		commit = &report.UncoveredCommit{Commit: latest.Commit, Author: latest.Name}
+		c.commits[latest.Commit] = commit
+	}
+	file, ok := c.files[latest.Commit+"\x00"+fileName]
+	if !ok {
+		file = &report.CommitFile{FileName: fileName}
+		c.files[latest.Commit+"\x00"+fileName] = file
+		commit.Files = append(commit.Files, file)
+	}
+	for _, line := range file.Lines {
+		if line == st.StartLine {
+			return
+		}
+	}
+	file.Lines = append(file.Lines, st.StartLine)
+	commit.UncoveredLines++
+}
+
+// uncoveredCommits returns the commits of the uncovered statements, the most uncovered first.
+// The files of each commit are sorted by name, and their lines are sorted in order.
+func (c *commitAttribution) uncoveredCommits() []*report.UncoveredCommit {
+	if c == nil {
+		return nil
+	}
+
+	var commits []*report.UncoveredCommit
+	for _, commit := range c.commits {
+		sort.Slice(commit.Files, func(i, j int) bool {
+			return commit.Files[i].FileName < commit.Files[j].FileName
+		})
+		for _, f := range commit.Files {
+			sort.Ints(f.Lines)
+		}
+		commits = append(commits, commit)
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		if commits[i].UncoveredLines != commits[j].UncoveredLines {
+			return commits[i].UncoveredLines > commits[j].UncoveredLines
+		}
+		return commits[i].Commit < commits[j].Commit
+	})
+	return commits
+}
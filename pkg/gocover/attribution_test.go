@@ -0,0 +1,48 @@
+package gocover
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitAttribution(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		attribution := newCommitAttribution(false)
+		attribution.addBlame("pkg/foo.go", nil)
+		attribution.add("github.com/foo/pkg/foo.go", &parser.Statement{StartLine: 1, EndLine: 1})
+		assert.Nil(t, attribution.uncoveredCommits())
+	})
+
+	t.Run("attribute to the latest commit of the lines", func(t *testing.T) {
+		old := &gittool.LineAuthor{Name: "foo", Commit: "aaa", When: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		first := &gittool.LineAuthor{Name: "Jane Doe", Commit: "bbb", When: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+		second := &gittool.LineAuthor{Name: "Jane Doe", Commit: "ccc", When: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)}
+
+		attribution := newCommitAttribution(true)
+		attribution.addBlame("pkg/a.go", []*gittool.LineAuthor{old, first, old, second, first})
+		attribution.addBlame("pkg/b.go", []*gittool.LineAuthor{old, first})
+		attribution.add("github.com/foo/pkg/a.go", &parser.Statement{StartLine: 2, EndLine: 2})
+		// the statement spans an unchanged line and a line of the second commit.
+		attribution.add("github.com/foo/pkg/a.go", &parser.Statement{StartLine: 3, EndLine: 4})
+		attribution.add("github.com/foo/pkg/a.go", &parser.Statement{StartLine: 5, EndLine: 5})
+		attribution.add("github.com/foo/pkg/a.go", &parser.Statement{StartLine: 5, EndLine: 5})
+		attribution.add("github.com/foo/pkg/b.go", &parser.Statement{StartLine: 2, EndLine: 2})
+		// the file without blame is not attributed.
+		attribution.add("github.com/foo/pkg/c.go", &parser.Statement{StartLine: 1, EndLine: 1})
+
+		assert.Equal(t, []*report.UncoveredCommit{
+			{Commit: "bbb", Author: "Jane Doe", UncoveredLines: 3, Files: []*report.CommitFile{
+				{FileName: "github.com/foo/pkg/a.go", Lines: []int{2, 5}},
+				{FileName: "github.com/foo/pkg/b.go", Lines: []int{2}},
+			}},
+			{Commit: "ccc", Author: "Jane Doe", UncoveredLines: 1, Files: []*report.CommitFile{
+				{FileName: "github.com/foo/pkg/a.go", Lines: []int{3}},
+			}},
+		}, attribution.uncoveredCommits())
+	})
+}
@@ -0,0 +1,84 @@
+package gocover
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/gocover/pkg/bazel"
+	"github.com/sirupsen/logrus"
+)
+
+// NewBazelImporter creates a GoCover that imports the coverage data of bazel coverage into a go cover profile.
+func NewBazelImporter(o *BazelOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "bazel")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+
+	modulePath, err := parseGoModulePath(filepath.Join(repositoryAbsPath, o.ModuleDir))
+	if err != nil {
+		return nil, fmt.Errorf("parse go module path: %w", err)
+	}
+
+	return &bazelImporter{
+		reports: o.CoverageReports,
+		output:  o.Output,
+		translator: &bazel.Translator{
+			Workspace:  repositoryAbsPath,
+			ModuleDir:  o.ModuleDir,
+			ModulePath: modulePath,
+		},
+		logger: logger,
+	}, nil
+}
+
+var _ GoCover = (*bazelImporter)(nil)
+
+// bazelImporter implements the GoCover interface and writes the coverage data of bazel to a go cover profile,
+// which is analyzed by the other commands like the cover profile of go test.
+type bazelImporter struct {
+	reports    []string
+	output     string
+	translator *bazel.Translator
+
+	logger logrus.FieldLogger
+}
+
+func (b *bazelImporter) Run(ctx context.Context) error {
+	profiles, skipped, err := bazel.Import(b.reports, b.translator)
+	if err != nil {
+		return err
+	}
+	for _, file := range skipped {
+		b.logger.Debugf("skip the file out of module %s: %s", b.translator.ModulePath, file)
+	}
+	if len(profiles) == 0 {
+		return fmt.Errorf("no file of module %s in the coverage data, %d files are skipped", b.translator.ModulePath, len(skipped))
+	}
+
+	if dir := filepath.Dir(b.output); dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return fmt.Errorf("create output dir: %w", err)
+		}
+	}
+	f, err := os.Create(b.output)
+	if err != nil {
+		return fmt.Errorf("create cover profile: %w", err)
+	}
+	defer f.Close()
+
+	if err := bazel.WriteProfiles(f, profiles); err != nil {
+		return fmt.Errorf("write cover profile: %w", err)
+	}
+	b.logger.Infof("import %d files of module %s to %s, %d files out of the module are skipped",
+		len(profiles), b.translator.ModulePath, b.output, len(skipped))
+	return nil
+}
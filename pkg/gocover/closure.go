@@ -0,0 +1,73 @@
+package gocover
+
+import (
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// closureCoverage accounts the coverage of the function literals, such as goroutine bodies and handlers,
+// separately from their enclosing functions, or folds them into their enclosing functions.
+type closureCoverage struct {
+	fold      bool
+	functions []*report.FunctionCoverage
+	// declared are the declared functions keyed by the file and the function name,
+	// which the function literals are folded into.
+	declared map[string]*report.FunctionCoverage
+
+	closures  int
+	effective int
+	covered   int
+}
+
+// newClosureCoverage returns the accounting of the function literals,
+// they are folded into their enclosing functions if fold is true.
+func newClosureCoverage(fold bool) *closureCoverage {
+	return &closureCoverage{
+		fold:     fold,
+		declared: make(map[string]*report.FunctionCoverage),
+	}
+}
+
+// add adds the coverage of the function. The functions should be added in the order of the parser,
+// where a declared function comes before the function literals in it.
+func (c *closureCoverage) add(fun *parser.Function, f *report.FunctionCoverage) {
+	if !fun.Literal {
+		c.declared[f.FileName+"\x00"+fun.Name] = f
+		c.functions = append(c.functions, f)
+		return
+	}
+
+	c.closures++
+	c.effective += f.TotalEffectiveLines
+	c.covered += f.CoveredLines
+
+	enclosing, ok := c.declared[f.FileName+"\x00"+fun.Enclosing]
+	if !c.fold || !ok {
+		// the function literals at package level have no enclosing function to fold into.
+		c.functions = append(c.functions, f)
+		return
+	}
+	enclosing.Hits = mergeHitCounts(enclosing.Hits, enclosing.TotalEffectiveLines, f.Hits, f.TotalEffectiveLines)
+	enclosing.TotalEffectiveLines += f.TotalEffectiveLines
+	enclosing.CoveredLines += f.CoveredLines
+	enclosing.CoveragePercent = calculateCoverage(int64(enclosing.CoveredLines), int64(enclosing.TotalEffectiveLines))
+}
+
+// all returns the coverage of all the functions, the folded function literals are not included.
+func (c *closureCoverage) all() []*report.FunctionCoverage {
+	return c.functions
+}
+
+// statistics returns the coverage of all the function literals,
+// it returns nil if there is no function literal or they are folded.
+func (c *closureCoverage) statistics() *report.ClosureStatistics {
+	if c.fold || c.closures == 0 {
+		return nil
+	}
+	return &report.ClosureStatistics{
+		Closures:             c.closures,
+		TotalEffectiveLines:  c.effective,
+		TotalCoveredLines:    c.covered,
+		TotalCoveragePercent: calculateCoverage(int64(c.covered), int64(c.effective)),
+	}
+}
@@ -0,0 +1,62 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosureCoverage(t *testing.T) {
+	functions := []struct {
+		fun       *parser.Function
+		effective int
+		covered   int
+	}{
+		{fun: &parser.Function{Name: "Serve"}, effective: 10, covered: 10},
+		{fun: &parser.Function{Name: "Serve.func1", Literal: true, Enclosing: "Serve"}, effective: 6, covered: 0},
+		{fun: &parser.Function{Name: "Serve.func1.1", Literal: true, Enclosing: "Serve"}, effective: 4, covered: 2},
+		{fun: &parser.Function{Name: "glob..func1", Literal: true}, effective: 2, covered: 2},
+	}
+
+	t.Run("separate closures", func(t *testing.T) {
+		closures := newClosureCoverage(false)
+		for _, f := range functions {
+			closures.add(f.fun, newFunctionCoverage("foo.go", f.fun, f.effective, f.covered))
+		}
+
+		all := closures.all()
+		assert.Len(t, all, 4)
+		assert.Equal(t, 10, all[0].TotalEffectiveLines)
+		assert.Equal(t, 100.0, all[0].CoveragePercent)
+		assert.Equal(t, &report.ClosureStatistics{
+			Closures:             3,
+			TotalEffectiveLines:  12,
+			TotalCoveredLines:    4,
+			TotalCoveragePercent: calculateCoverage(4, 12),
+		}, closures.statistics())
+	})
+
+	t.Run("fold closures", func(t *testing.T) {
+		closures := newClosureCoverage(true)
+		for _, f := range functions {
+			closures.add(f.fun, newFunctionCoverage("foo.go", f.fun, f.effective, f.covered))
+		}
+
+		all := closures.all()
+		assert.Len(t, all, 2)
+		assert.Equal(t, "Serve", all[0].Function)
+		assert.Equal(t, 20, all[0].TotalEffectiveLines)
+		assert.Equal(t, 12, all[0].CoveredLines)
+		assert.Equal(t, 60.0, all[0].CoveragePercent)
+		assert.Equal(t, "glob..func1", all[1].Function)
+		assert.Nil(t, closures.statistics())
+	})
+
+	t.Run("no closures", func(t *testing.T) {
+		closures := newClosureCoverage(false)
+		closures.add(functions[0].fun, newFunctionCoverage("foo.go", functions[0].fun, 10, 10))
+		assert.Nil(t, closures.statistics())
+	})
+}
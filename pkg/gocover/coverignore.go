@@ -0,0 +1,126 @@
+package gocover
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/sirupsen/logrus"
+)
+
+// coverIgnoreFile is the file of the gitignore-style patterns of the paths that are excluded from coverage,
+// the patterns are relative to the directory of the file, and the files in the subdirectories take precedence.
+const coverIgnoreFile = ".gocoverignore"
+
+// coverIgnore matches the files against the patterns of the .gocoverignore files in the repository.
+type coverIgnore struct {
+	root    string
+	matcher gitignore.Matcher
+}
+
+// loadCoverIgnore reads the .gocoverignore files under the repository root, it returns nil if there are none.
+// The patterns of the parent directories come first, so that the patterns of the subdirectories override them,
+// including the negated patterns such as `!keep.go`.
+func loadCoverIgnore(root string) (*coverIgnore, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && d.Name() == coverIgnoreFile {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find %s files: %w", coverIgnoreFile, err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	domains := make(map[string][]string, len(files))
+	for _, f := range files {
+		domains[f] = splitPath(root, filepath.Dir(f))
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return len(domains[files[i]]) < len(domains[files[j]])
+	})
+
+	var patterns []gitignore.Pattern
+	for _, f := range files {
+		ps, err := readCoverIgnore(f, domains[f])
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, ps...)
+	}
+	return &coverIgnore{root: root, matcher: gitignore.NewMatcher(patterns)}, nil
+}
+
+// readCoverIgnore reads the patterns of the .gocoverignore file, the blank lines and the comments are skipped.
+func readCoverIgnore(file string, domain []string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	return patterns, nil
+}
+
+// match reports whether the file, which is the full path, is excluded by the .gocoverignore files.
+// The files out of the repository are never excluded.
+func (c *coverIgnore) match(file string) bool {
+	if c == nil {
+		return false
+	}
+	path := splitPath(c.root, file)
+	if len(path) == 0 || path[0] == ".." {
+		return false
+	}
+	return c.matcher.Match(path, false)
+}
+
+// splitPath returns the elements of the path relative to the root, it's empty for the root itself.
+func splitPath(root, path string) []string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// inExcludedFiles reports whether the file is excluded by the exclude patterns or by the .gocoverignore files,
+// file is the full path of the file and fileName is its name in the report, which is kept in the cache once excluded.
+func inExcludedFiles(cache excludeFileCache, excludesPattern []string, ignore *coverIgnore, file, fileName string, logger logrus.FieldLogger) bool {
+	if inExclueds(cache, excludesPattern, fileName, logger) {
+		return true
+	}
+	if ignore.match(file) {
+		cache[fileName] = true
+		logger.Debugf("exclude file by %s: %s", coverIgnoreFile, fileName)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package gocover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverIgnore(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	ignore, err := loadCoverIgnore(root)
+	assert.NoError(t, err)
+	assert.Nil(t, ignore)
+	assert.False(t, ignore.match(filepath.Join(root, "foo.go")))
+
+	write(".gocoverignore", "# generated code\n*.pb.go\n\n/tools/\n")
+	write("pkg/api/.gocoverignore", "!keep.pb.go\nmock_*.go\n")
+	write(".git/.gocoverignore", "*.go\n")
+
+	ignore, err = loadCoverIgnore(root)
+	assert.NoError(t, err)
+	for file, excluded := range map[string]bool{
+		"foo.go":                 false,
+		"foo.pb.go":              true,
+		"pkg/store/store.pb.go":  true,
+		"pkg/api/keep.pb.go":     false,
+		"pkg/api/api.pb.go":      true,
+		"pkg/api/mock_client.go": true,
+		"pkg/mock_client.go":     false,
+		"tools/gen.go":           true,
+		"pkg/tools/gen.go":       false,
+	} {
+		assert.Equal(t, excluded, ignore.match(filepath.Join(root, file)), file)
+	}
+	assert.False(t, ignore.match(filepath.Join(filepath.Dir(root), "foo.pb.go")))
+
+	t.Run("excluded files", func(t *testing.T) {
+		cache := make(excludeFileCache)
+		assert.True(t, inExcludedFiles(cache, nil, ignore, filepath.Join(root, "foo.pb.go"), "example.com/foo/foo.pb.go", logrus.New()))
+		assert.True(t, inExcludedFiles(cache, []string{"**/foo.go"}, ignore, filepath.Join(root, "foo.go"), "example.com/foo/foo.go", logrus.New()))
+		assert.False(t, inExcludedFiles(cache, nil, ignore, filepath.Join(root, "bar.go"), "example.com/foo/bar.go", logrus.New()))
+		assert.Equal(t, excludeFileCache{"example.com/foo/foo.pb.go": true, "example.com/foo/foo.go": true}, cache)
+	})
+}
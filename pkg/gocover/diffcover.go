@@ -2,20 +2,32 @@ package gocover
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"go/build"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/compression"
 	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/gate"
 	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/ownership"
 	"github.com/Azure/gocover/pkg/parser"
 	"github.com/Azure/gocover/pkg/report"
+	"github.com/Azure/gocover/pkg/webhook"
 	"github.com/sirupsen/logrus"
 )
 
 func NewDiffCover(o *DiffOption) (GoCover, error) {
+	return newDiffCover(o)
+}
+
+func newDiffCover(o *DiffOption) (*diffCover, error) {
 	var (
 		dbClient dbclient.DbClient
 		err      error
@@ -34,7 +46,7 @@ func NewDiffCover(o *DiffOption) (GoCover, error) {
 		}
 	}
 
-	repositoryAbsPath, err := filepath.Abs(o.RepositoryPath)
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
 	if err != nil {
 		return nil, fmt.Errorf("get absolute path of repo: %w", err)
 	}
@@ -44,22 +56,186 @@ func NewDiffCover(o *DiffOption) (GoCover, error) {
 		return nil, fmt.Errorf("parse go module path: %w", err)
 	}
 
+	moduleRoot, err := newModuleRoot(o.RepoRoot, o.ModuleDir)
+	if err != nil {
+		return nil, err
+	}
+
 	logger.Debugf("repository path: %s, module path: %s, output dir: %s, exclude patterns: %s",
 		repositoryAbsPath, modulePath, o.OutputDir, o.Excludes)
 
-	return &diffCover{
-		repositoryPath:   repositoryAbsPath,
-		comparedBranch:   o.CompareBranch,
-		moduleDir:        o.ModuleDir,
-		modulePath:       modulePath,
-		excludeFiles:     make(excludeFileCache),
-		excludePatterns:  o.Excludes,
-		coverageTree:     report.NewCoverageTree(modulePath),
-		coverFilenames:   o.CoverProfiles,
+	anonymizer, err := report.NewAnonymizer(o.Anonymize)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeFuncs, err := compileFunctionPatterns(o.ExcludeFunctions)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := loadCoverIgnore(repositoryAbsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	expression, err := parseGate(o.Gate)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := loadOwnership(o.Ownership)
+	if err != nil {
+		return nil, err
+	}
+
+	tests, err := loadTestResults(o.TestOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, err := parser.LoadOverlay(o.Overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	ignorePolicy, err := parseIgnorePolicy(o.IgnorePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	mixedModes, err := parser.ParseMixedModes(o.MixedCoverModes)
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := parseHistoryTime(o.Since, false)
+	if err != nil {
+		return nil, fmt.Errorf("since: %w", err)
+	}
+	until, err := parseHistoryTime(o.Until, true)
+	if err != nil {
+		return nil, fmt.Errorf("until: %w", err)
+	}
+
+	formats, err := parseReportFormats(o.ReportFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := compression.Parse(o.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	coverFilenames, labeled := parseCoverProfiles(o.CoverProfiles)
+
+	attestationKey, provenance, err := newProvenance(o.AttestationKey, o.ToolVersion, repositoryAbsPath, coverFilenames)
+	if err != nil {
+		return nil, err
+	}
+
+	tracking, err := newTrackingIssue(o.TrackingIssue, o.GitHubOption, o.CI, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	closedIssues, err := newIssueChecker(o.ClosedIssues, o.GitHubOption, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := newCommitStatus(o.CommitStatus, o.StatusContext, o.DetailsURL, o.GitHubOption, o.CI, repositoryAbsPath, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := webhook.NewClient(o.WebhookOption, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	reportGenerator := newReportGenerator(&reportOption{
+		formats:          formats,
+		templates:        o.Templates,
+		style:            o.Style,
+		outputDir:        o.OutputDir,
+		reportName:       o.ReportName,
+		treemap:          o.Treemap,
+		junit:            o.JUnit,
+		lines:            o.LinesReport,
+		directoryTree:    o.DirectoryTree,
+		annotatedDiff:    o.AnnotatedDiff,
+		teamcity:         o.TeamCity,
+		summaryLine:      o.SummaryLine,
+		azureDevOpsDir:   o.AzureDevOpsDir,
+		noStepSummary:    o.NoStepSummary,
 		coverageBaseline: o.CoverageBaseline,
-		dbClient:         dbClient,
-		reportGenerator:  report.NewReportGenerator(o.Style, o.OutputDir, o.ReportName, o.Logger),
-		logger:           logger,
+		modulePath:       modulePath,
+		moduleDir:        o.ModuleDir,
+		compression:      algorithm,
+		attestationKey:   attestationKey,
+		provenance:       provenance,
+	}, o.Logger)
+
+	return &diffCover{
+		repositoryPath:    repositoryAbsPath,
+		comparedBranch:    o.CompareBranch,
+		moduleDir:         o.ModuleDir,
+		moduleRoot:        moduleRoot,
+		modulePath:        modulePath,
+		excludeFiles:      make(excludeFileCache),
+		excludePatterns:   o.Excludes,
+		coverIgnore:       ignore,
+		excludeFuncs:      excludeFuncs,
+		excludeTags:       o.ExcludeBuildTags,
+		includePackages:   o.IncludePackages,
+		skipPackages:      o.SkipPackages,
+		coverageTree:      report.NewCoverageTree(modulePath),
+		coverFilenames:    coverFilenames,
+		labeledProfiles:   labeled,
+		coverageBaseline:  o.CoverageBaseline,
+		weakCoverage:      o.WeakCoverage,
+		detectMoves:       o.DetectMoves,
+		newCodeSince:      o.NewCodeSince,
+		stackBases:        o.StackBases,
+		authors:           o.Authors,
+		since:             since,
+		until:             until,
+		fetchRemote:       o.FetchRemote,
+		fetchAuth:         o.FetchAuth,
+		topUncovered:      o.TopUncovered,
+		topHot:            o.TopHot,
+		foldClosures:      o.FoldClosures,
+		linesReport:       o.LinesReport || hasReportFormat(formats, CoberturaReportFormat) || o.AzureDevOpsDir != "",
+		legacyOutput:      hasReportFormat(formats, LegacyReportFormat),
+		sideBySide:        o.SideBySide || o.AnnotatedDiff,
+		directoryTree:     o.DirectoryTree,
+		cacheDir:          o.CacheDir,
+		gate:              expression,
+		ownership:         owners,
+		tests:             tests,
+		overlay:           overlay,
+		skipUnresolved:    o.SkipUnresolved,
+		continueOnError:   o.ContinueOnError,
+		failOnFileError:   !o.IgnoreFileErrors,
+		progress:          o.Progress,
+		memoryLimit:       o.MemoryLimit,
+		ignorePolicy:      ignorePolicy,
+		mixedModes:        mixedModes,
+		compatibleMarkers: o.CompatibleMarkers,
+		closedIssues:      closedIssues,
+		metrics:           &coverageMetrics{modulePath: modulePath},
+		anonymizer:        anonymizer,
+		ci:                o.CI,
+		runLabels:         o.RunLabels,
+		commitStatus:      status,
+		webhooks:          webhooks,
+		trackingIssue:     tracking,
+		attribution:       newCommitAttribution(o.AttributeCommits),
+		dbClient:          dbClient,
+		reportGenerator:   reportGenerator,
+		logger:            logger,
 	}, nil
 
 }
@@ -68,16 +244,64 @@ var _ GoCover = (*diffCover)(nil)
 
 // diffCoverage implements the GoCover interface and generate the diff coverage statistics.
 type diffCover struct {
-	comparedBranch   string // git diff base branch
-	repositoryPath   string
-	excludePatterns  []string
-	ignoreProfiles   []*annotation.IgnoreProfile
-	excludeFiles     excludeFileCache
-	moduleDir        string
-	modulePath       string
-	coverFilenames   []string
-	coverageBaseline float64
-
+	comparedBranch    string // git diff base branch
+	newCodeSince      string // start of new code period, it overrides the comparedBranch when it's set
+	fetchRemote       string // remote to fetch the comparedBranch from when it's missing, disabled if it's empty
+	fetchAuth         *gittool.FetchAuth
+	cacheDir          string // directory of the conversion cache, cache is disabled if it's empty
+	repositoryPath    string
+	stackBases        []string
+	authors           []string
+	since             time.Time
+	until             time.Time
+	excludePatterns   []string
+	coverIgnore       *coverIgnore
+	excludeFuncs      []*regexp.Regexp
+	excludeTags       []string
+	includePackages   []string
+	skipPackages      []string
+	ignoreProfiles    []*annotation.IgnoreProfile
+	excludeFiles      excludeFileCache
+	moduleDir         string
+	moduleRoot        string
+	modulePath        string
+	coverFilenames    []string
+	labeledProfiles   *labeledProfiles
+	coverageBaseline  float64
+	weakCoverage      bool // report the changed statements that are reached only once
+	detectMoves       bool // the moved code doesn't count as changed
+	topUncovered      int  // number of the least covered functions to report
+	topHot            int  // number of the most frequently executed changed functions and statements to report
+	foldClosures      bool // fold the function literals into their enclosing functions
+	linesReport       bool // collect the state of each line of the changed functions for the per-line report
+	legacyOutput      bool // keep the log lines of the earlier releases, refer to LegacyReportFormat
+	sideBySide        bool // show the changed files side by side with the coverage in the html report
+	directoryTree     bool // aggregate the coverage of the changed files by directory
+	ci                *ci.Environment
+	runLabels         map[string]string
+	ownership         *ownership.Ownership
+	tests             *testResults
+	overlay           *parser.Overlay
+	skipUnresolved    bool
+	unresolvedFiles   []*parser.SkippedFile
+	continueOnError   bool
+	failOnFileError   bool // fail the run on the errors of the files
+	fileErrors        []*parser.FileError
+	progress          parser.ProgressFunc
+	memoryLimit       int
+	ignorePolicy      IgnorePolicy
+	mixedModes        parser.MixedModes
+	compatibleMarkers bool
+	closedIssues      *issueChecker
+	trackingIssue     *trackingIssue
+	functions         []*report.FunctionCoverage
+	attribution       *commitAttribution
+	commitStatus      *commitStatus    // sets the github commit status, it's nil if it's disabled
+	webhooks          *webhook.Client  // posts the json result to the webhooks, it's nil if there are no webhooks
+	gate              *gate.Expression // replaces the coverage baseline, it's nil if it's not set
+	metrics           *coverageMetrics // the metrics of the gate, which include full coverage in all coverage mode
+
+	anonymizer      *report.Anonymizer // hides the names in the report, it's nil if anonymization is disabled
 	reportGenerator report.ReportGenerator
 	coverageTree    report.CoverageTree
 	dbClient        dbclient.DbClient
@@ -87,10 +311,16 @@ type diffCover struct {
 
 func (diff *diffCover) Run(ctx context.Context) error {
 
-	statistics, err := diff.generateStatistics()
+	statistics, err := diff.generateStatistics(ctx)
 	if err != nil {
 		return fmt.Errorf("diff: %w", err)
 	}
+	statistics.CI = diff.ci
+	statistics.RunLabels = diff.runLabels
+	statistics.Teams = teamCoverage(statistics.CoverageProfile, diff.ownership, diff.modulePath)
+	diff.metrics.diff = newCoverageSnapshot(statistics)
+
+	diff.anonymizer.Anonymize(statistics)
 
 	if err := diff.reportGenerator.GenerateReport(statistics); err != nil {
 		return fmt.Errorf("generate report: %w", err)
@@ -100,14 +330,34 @@ func (diff *diffCover) Run(ctx context.Context) error {
 		return fmt.Errorf("%w", err)
 	}
 
-	if err := diff.pass(statistics); err != nil {
-		return fmt.Errorf("%w", err)
+	// the status is set even if the coverage requirement is not met, the exit code of the requirement is kept.
+	passErr := errors.Join(diff.pass(statistics), diff.closedIssues.check(ctx, diff.ignoreProfiles))
+	if err := diff.commitStatus.publish(ctx, statistics, passErr); err != nil {
+		return errors.Join(passErr, fmt.Errorf("publish commit status: %w", err))
+	}
+	if err := diff.webhooks.Publish(ctx, string(statistics.StatisticsType), report.NewJSONReport(statistics)); err != nil {
+		return errors.Join(passErr, fmt.Errorf("publish webhooks: %w", err))
+	}
+	if err := diff.trackingIssue.publish(ctx, diff.functions); err != nil {
+		return errors.Join(passErr, fmt.Errorf("publish tracking issue: %w", err))
+	}
+	if passErr != nil {
+		return fmt.Errorf("%w", passErr)
 	}
 
 	return nil
 }
 
+// pass checks the coverage requirement and the budgets of the teams.
 func (diff *diffCover) pass(statistics *report.Statistics) error {
+	return errors.Join(diff.requirement(statistics), checkTeamBudgets(statistics.Teams),
+		checkFileErrors(statistics.FileErrors, diff.failOnFileError))
+}
+
+func (diff *diffCover) requirement(statistics *report.Statistics) error {
+	if diff.gate != nil {
+		return evaluateGate(diff.gate, diff.metrics, diff.logger)
+	}
 	if statistics.TotalCoveragePercent < diff.coverageBaseline {
 		return WrapErrorWithCode(
 			fmt.Errorf("the coverage baseline pass rate is %.2f, currently is %.2f",
@@ -125,11 +375,11 @@ func (diff *diffCover) dump(ctx context.Context) error {
 	all := diff.coverageTree.All()
 
 	if diff.dbClient != nil {
-		err := storeCoverageData(ctx, diff.dbClient, all, DiffCoverage, diff.modulePath)
+		err := storeCoverageData(ctx, diff.dbClient, all, DiffCoverage, diff.modulePath, diff.ci, diff.runLabels)
 		if err != nil {
 			return fmt.Errorf("store coverage data: %w", err)
 		}
-		err = storeIgnoreProfileData(ctx, diff.dbClient, diff.ignoreProfiles, DiffCoverage, diff.modulePath, diff.repositoryPath, diff.moduleDir)
+		err = storeIgnoreProfileData(ctx, diff.dbClient, diff.ignoreProfiles, DiffCoverage, diff.modulePath, diff.repositoryPath, diff.moduleDir, diff.ci, diff.runLabels)
 		if err != nil {
 			return fmt.Errorf("store ignore profile data: %w", err)
 		}
@@ -139,25 +389,173 @@ func (diff *diffCover) dump(ctx context.Context) error {
 	return nil
 }
 
-func (diff *diffCover) getGitChanges() ([]*gittool.Change, error) {
+// parseGitChanges computes the git changes and converts the cover profiles of the changed files
+// while the changes are streamed from git diff. The changes are returned in the order of the file names.
+func (diff *diffCover) parseGitChanges(ctx context.Context) (parser.Packages, []*gittool.Change, error) {
 	gitClient, err := gittool.NewGitClient(diff.repositoryPath)
 	if err != nil {
-		return nil, fmt.Errorf("git repository: %w", err)
+		return nil, nil, fmt.Errorf("git repository: %w", err)
 	}
-	changes, err := gitClient.DiffChangesFromCommitted(diff.comparedBranch)
+	gitClient.SetFetchAuth(diff.fetchAuth)
+	gitClient.SetDetectMoves(diff.detectMoves)
+
+	if len(diff.stackBases) > 0 {
+		base, err := selectStackBase(ctx, gitClient, diff.stackBases, diff.fetchRemote, diff.logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		diff.comparedBranch = base
+	}
+
+	if diff.newCodeSince != "" {
+		comparedBranch := diff.newCodeSince
+		if since, ok := parseNewCodeSince(diff.newCodeSince); ok {
+			comparedBranch, err = gitClient.CommitBefore(ctx, since)
+			if err != nil {
+				return nil, nil, fmt.Errorf("new code period: %w", err)
+			}
+		}
+		diff.logger.Infof("check the code changed since %s, compare with %s", diff.newCodeSince, comparedBranch)
+		diff.comparedBranch = comparedBranch
+	}
+
+	if err := gitClient.EnsureRevision(ctx, diff.comparedBranch, diff.fetchRemote); err != nil {
+		return nil, nil, fmt.Errorf("compared branch: %w", err)
+	}
+
+	stream, err := diff.newParser(diff.coverFilenames, diff.logger).
+		WithCacheDir(diff.cacheDir).
+		WithExcludeFunctions(diff.excludeFuncs).
+		WithExcludeBuildTags(diff.excludeTags).
+		WithPackages(diff.includePackages, diff.skipPackages).
+		WithProgress(diff.progress).
+		Stream()
 	if err != nil {
-		return nil, fmt.Errorf("git diff: %w", err)
+		return nil, nil, err
+	}
+	defer stream.Close()
+
+	filterLines := len(diff.authors) > 0 || !diff.since.IsZero() || !diff.until.IsZero()
+	if len(diff.authors) > 0 {
+		diff.logger.Infof("check the changes authored by %s", strings.Join(diff.authors, ", "))
+	}
+	if !diff.since.IsZero() || !diff.until.IsZero() {
+		diff.logger.Infof("check the changes committed in the period [%s, %s)", formatPeriodTime(diff.since), formatPeriodTime(diff.until))
+	}
+
+	var changes []*gittool.Change
+	var parseErr error
+	err = gitClient.StreamChangesFromCommitted(ctx, diff.comparedBranch, func(change *gittool.Change) error {
+		if filterLines || diff.attribution != nil {
+			authors, err := gitClient.Blame(ctx, change.FileName)
+			if err != nil {
+				return err
+			}
+			diff.attribution.addBlame(change.FileName, authors)
+			if filterLines {
+				if change = gittool.FilterLines(change, authors, diff.keepLine); change == nil {
+					return nil
+				}
+			}
+		}
+		changes = append(changes, change)
+		parseErr = stream.Add(ctx, change)
+		return parseErr
+	})
+	if parseErr != nil {
+		return nil, nil, parseErr
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("git diff: %w", err)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].FileName < changes[j].FileName
+	})
+	packages, err := stream.Packages()
+	if err != nil {
+		return nil, nil, err
+	}
+	diff.unresolvedFiles = stream.UnresolvedFiles()
+	diff.fileErrors = stream.FileErrors()
+	return packages, changes, nil
+}
+
+// selectStackBase returns the first base ref that exists and has a common ancestor with HEAD, the missing refs
+// are fetched from the remote. So a stacked pull request is compared with its parent branch, and with the next
+// base once the parent branch is gone.
+func selectStackBase(ctx context.Context, gitClient gittool.GitClient, refs []string, remote string, logger logrus.FieldLogger) (string, error) {
+	for _, ref := range refs {
+		if err := gitClient.EnsureRevision(ctx, ref, remote); err != nil {
+			if errors.Is(err, gittool.ErrRevisionNotFound) {
+				logger.Infof("base ref %s is not found, try the next one", ref)
+				continue
+			}
+			return "", fmt.Errorf("base ref %s: %w", ref, err)
+		}
+		if _, err := gitClient.MergeBase(ref); err != nil {
+			if errors.Is(err, gittool.ErrNoMergeBase) {
+				logger.Infof("base ref %s has no common ancestor with HEAD, try the next one", ref)
+				continue
+			}
+			return "", fmt.Errorf("base ref %s: %w", ref, err)
+		}
+		logger.Infof("compare with base ref %s", ref)
+		return ref, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoStackBase, strings.Join(refs, ", "))
+}
+
+// keepLine reports whether the changed line is checked by its author and the date of its commit.
+func (diff *diffCover) keepLine(author *gittool.LineAuthor) bool {
+	if len(diff.authors) > 0 && !author.Matches(diff.authors) {
+		return false
 	}
-	return changes, nil
+	return author.InPeriod(diff.since, diff.until)
 }
 
-func (diff *diffCover) generateStatistics() (*report.Statistics, error) {
-	changes, err := diff.getGitChanges()
+// formatPeriodTime formats the time of a side of the period, which is open if the time is zero.
+func formatPeriodTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// newParser returns the parser of the cover profiles that resolves the files in the way of the options.
+func (diff *diffCover) newParser(coverProfiles []string, logger logrus.FieldLogger) *parser.Parser {
+	return parser.NewParser(coverProfiles, logger).
+		WithModuleRoot(diff.moduleRoot, diff.modulePath).
+		WithOverlay(diff.overlay).
+		WithSkipUnresolved(diff.skipUnresolved).
+		WithContinueOnError(diff.continueOnError).
+		WithMemoryLimit(diff.memoryLimit).
+		WithMixedModes(diff.mixedModes).
+		WithCompatibleMarkers(diff.compatibleMarkers)
+}
+
+// parseNewCodeSince parses the start of new code period, which is a date (in UTC) or a time in RFC3339 format.
+// It returns false if the value is neither of them, which should be used as a git ref.
+func parseNewCodeSince(v string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func (diff *diffCover) generateStatistics(ctx context.Context) (*report.Statistics, error) {
+	start := time.Now()
+	packages, changes, err := diff.parseGitChanges(ctx)
 	if err != nil {
 		return nil, err
 	}
+	parseDuration := time.Since(start)
+	applyIgnorePolicy(packages, diff.ignorePolicy)
 
-	packages, err := parser.NewParser(diff.coverFilenames, diff.logger).Parse(changes)
+	labels, err := newLabelCoverage(ctx, diff.labeledProfiles, changes, diff.newParser, diff.logger)
 	if err != nil {
 		return nil, err
 	}
@@ -165,32 +563,63 @@ func (diff *diffCover) generateStatistics() (*report.Statistics, error) {
 	statistics := &report.Statistics{
 		StatisticsType: report.DiffStatisticsType,
 		ComparedBranch: diff.comparedBranch,
+		IgnorePolicy:   string(diff.ignorePolicy),
+		Packages:       packages,
+		SkippedFiles:   unresolvedFiles(diff.unresolvedFiles),
+		FileErrors:     fileErrors(diff.fileErrors),
 	}
 	m := make(map[string]*report.CoverageProfile)
 	fileCache := make(fileContentsCache)
 	added := make(map[string]*report.CoverageProfile)
 	keep := make(map[string]string)
+	ranking := newFunctionRanking(diff.topUncovered)
+	hot := newHotPaths(diff.topHot)
+	closures := newClosureCoverage(diff.foldClosures)
+	// the states of the lines are collected for the side-by-side view as well.
+	lines := newLineCollector(diff.linesReport || diff.sideBySide)
 	for _, pkg := range packages {
 		diff.logger.Debugf("package: %s", pkg.Name)
 		diff.ignoreProfiles = append(diff.ignoreProfiles, pkg.IgnoreProfiles...)
 
-		p, err := build.Import(pkg.Name, ".", build.FindOnly)
+		root, err := packageRoot(diff.moduleRoot, pkg.Name)
 		if err != nil {
 			return nil, fmt.Errorf("build import %w", err)
 		}
 
+		weakCoverage := diff.weakCoverage && pkg.CoverMode != "set"
+		if diff.weakCoverage && !weakCoverage {
+			diff.logger.Warnf("package %s: weak coverage requires count or atomic cover mode", pkg.Name)
+		}
+
+		for _, f := range pkg.SkippedFiles {
+			statistics.SkippedFiles = append(statistics.SkippedFiles, &report.SkippedFile{
+				FileName: formatFilePath(root, f.File, diff.modulePath),
+				Reason:   f.Reason,
+			})
+		}
+
+		for _, f := range pkg.ExcludedFunctions {
+			fileName := formatFilePath(root, f.Function.File, diff.modulePath)
+			if inExcludedFiles(diff.excludeFiles, diff.excludePatterns, diff.coverIgnore, f.Function.File, fileName, diff.logger) {
+				continue
+			}
+			if excluded := newExcludedFunction(fileName, f, true); excluded != nil {
+				statistics.ExcludedFunctions = append(statistics.ExcludedFunctions, excluded)
+			}
+		}
+
 		for _, fun := range pkg.Functions {
 
 			// extract into single function
 			coverProfile, ok := m[fun.File]
 			if !ok {
 				coverProfile = &report.CoverageProfile{
-					FileName: formatFilePath(p.Root, fun.File, diff.modulePath),
+					FileName: formatFilePath(root, fun.File, diff.modulePath),
 				}
 				m[fun.File] = coverProfile
 			}
 
-			fileContents, err := findFileContents(fileCache, fun.File)
+			fileContents, err := findFileContents(fileCache, diff.overlay.Source(fun.File))
 			if err != nil {
 				return nil, fmt.Errorf("find file contents: %w", err)
 			}
@@ -205,15 +634,22 @@ func (diff *diffCover) generateStatistics() (*report.Statistics, error) {
 			}
 
 			var total, ignored, covered, coveredButIgnored int
+			var weakCovered, partial, moved []int
 			violated := false
 			changed := false
+			counter := labels.newCounter(coverProfile.FileName, fun.Name)
 			for _, st := range fun.Statements {
+				if st.State == parser.Moved {
+					moved = append(moved, st.StartLine)
+					continue
+				}
 				if st.State == parser.Original {
 					continue
 				}
 
 				changed = true
 				total += 1
+				labels.count(counter, fun.File, st)
 
 				if st.Mode == parser.Ignore && st.Reached > 0 {
 					coveredButIgnored++
@@ -225,6 +661,12 @@ func (diff *diffCover) generateStatistics() (*report.Statistics, error) {
 				}
 				if st.Reached > 0 {
 					covered++
+					if weakCoverage && st.Reached == 1 && st.Mode == parser.Keep {
+						weakCovered = append(weakCovered, st.StartLine)
+					}
+					if st.Partial && st.Mode == parser.Keep {
+						partial = append(partial, st.StartLine)
+					}
 				} else {
 					section.ViolationLines = append(section.ViolationLines, st.StartLine)
 					violated = true
@@ -232,30 +674,49 @@ func (diff *diffCover) generateStatistics() (*report.Statistics, error) {
 
 			}
 
-			if changed {
+			if !changed && len(moved) == 0 {
+				continue
+			}
+			if ok := inExcludedFiles(
+				diff.excludeFiles,
+				diff.excludePatterns,
+				diff.coverIgnore,
+				fun.File,
+				formatFilePath(root, fun.File, diff.modulePath),
+				diff.logger,
+			); ok {
+				continue
+			}
 
-				if ok := inExclueds(
-					diff.excludeFiles,
-					diff.excludePatterns,
-					formatFilePath(p.Root, fun.File, diff.modulePath),
-					diff.logger,
-				); ok {
-					continue
+			// the moved statements don't count for diff coverage, they're only reported.
+			coverProfile.MovedLines = append(coverProfile.MovedLines, moved...)
+			if changed {
+				labels.add(counter)
+				for _, st := range fun.Statements {
+					lines.add(coverProfile.FileName, st, isCountMode(pkg.CoverMode))
+					if st.State == parser.Changed && st.Mode == parser.Keep && st.Reached == 0 {
+						diff.attribution.add(coverProfile.FileName, st)
+					}
 				}
-
+				functionCoverage := newFunctionCoverage(coverProfile.FileName, fun, total-ignored, covered-coveredButIgnored)
+				functionCoverage.Hits = newHitCounts(pkg.CoverMode, fun.Statements, true)
+				hot.addStatements(coverProfile.FileName, fun, pkg.CoverMode, true)
+				closures.add(fun, functionCoverage)
 				coverProfile.TotalLines += total
 				coverProfile.CoveredLines += covered
 				coverProfile.TotalEffectiveLines += (total - ignored)
 				coverProfile.TotalIgnoredLines += ignored
 				coverProfile.CoveredButIgnoredLines += coveredButIgnored
+				coverProfile.WeakCoveredLines = append(coverProfile.WeakCoveredLines, weakCovered...)
+				coverProfile.PartialCoveredLines = append(coverProfile.PartialCoveredLines, partial...)
 				if violated {
 					coverProfile.ViolationSections = append(coverProfile.ViolationSections, section)
 				}
-				if _, ok := added[fun.File]; !ok {
-					statistics.CoverageProfile = append(statistics.CoverageProfile, coverProfile)
-					added[fun.File] = coverProfile
-					keep[fun.File] = p.Root
-				}
+			}
+			if _, ok := added[fun.File]; !ok {
+				statistics.CoverageProfile = append(statistics.CoverageProfile, coverProfile)
+				added[fun.File] = coverProfile
+				keep[fun.File] = root
 			}
 		}
 
@@ -271,8 +732,41 @@ func (diff *diffCover) generateStatistics() (*report.Statistics, error) {
 	}
 
 	diff.coverageTree.CollectCoverageData()
+	statistics.RunSummary = newRunSummary(packages, len(statistics.SkippedFiles), parseDuration)
+	if !diff.legacyOutput {
+		diff.logger.Infof("run summary: %s", statistics.RunSummary)
+	}
+
+	diff.functions = closures.all()
+	for _, f := range diff.functions {
+		ranking.add(f)
+		hot.addFunction(f)
+	}
 
 	reBuildStatistics(statistics, diff.excludeFiles)
+	if !diff.legacyOutput {
+		diff.logger.Infof("diff coverage: %.2f%%, raw coverage: %.2f%%, the ignore annotations contribute %+.2f%%",
+			statistics.TotalCoveragePercent, statistics.TotalCoverageWithoutIgnore, statistics.IgnoreContribution())
+	}
+	statistics.LabelStatistics = labels.statistics(statistics)
+	statistics.LabelGaps = labels.labelGaps()
+	statistics.ClosureStatistics = closures.statistics()
+	statistics.LeastCoveredFunctions = ranking.top()
+	statistics.HotFunctions, statistics.HotStatements = hot.top()
+	statistics.UncoveredCommits = diff.attribution.uncoveredCommits()
+	statistics.TestResults = diff.tests.statistics(diff.functions)
+	if diff.linesReport {
+		statistics.Lines = lines.lines()
+	}
+	if diff.sideBySide {
+		statistics.DiffFiles, err = diffFiles(changes, added, fileCache, diff.overlay, lines)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if diff.directoryTree {
+		statistics.DirectoryTree = report.NewDirectoryTree(statistics.CoverageProfile)
+	}
 
 	return statistics, nil
 }
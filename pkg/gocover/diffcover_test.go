@@ -0,0 +1,96 @@
+package gocover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNewCodeSince(t *testing.T) {
+	testSuites := []struct {
+		input  string
+		expect time.Time
+		ok     bool
+	}{
+		{input: "2024-03-01", expect: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), ok: true},
+		{input: "2024-03-01T08:30:00Z", expect: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC), ok: true},
+		{input: "origin/release-1.0", ok: false},
+		{input: "v1.2.3", ok: false},
+	}
+
+	for _, testCase := range testSuites {
+		actual, ok := parseNewCodeSince(testCase.input)
+		assert.Equal(t, testCase.ok, ok, testCase.input)
+		if ok {
+			assert.True(t, testCase.expect.Equal(actual), "for input %s, expect %s, but get %s", testCase.input, testCase.expect, actual)
+		}
+	}
+}
+
+func TestKeepLine(t *testing.T) {
+	jane := &gittool.LineAuthor{Name: "Jane Doe", Email: "jane@bar.org", When: time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)}
+	foo := &gittool.LineAuthor{Name: "foo", Email: "foo@bar.org", When: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)}
+
+	diff := &diffCover{authors: []string{"jane@bar.org"}}
+	assert.True(t, diff.keepLine(jane))
+	assert.False(t, diff.keepLine(foo))
+
+	diff = &diffCover{since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), until: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}
+	assert.True(t, diff.keepLine(jane))
+	assert.False(t, diff.keepLine(foo))
+
+	diff = &diffCover{authors: []string{"foo"}, since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	assert.False(t, diff.keepLine(jane))
+	assert.False(t, diff.keepLine(foo))
+}
+
+// stackGitClient resolves the refs of a stack, the missing refs are not found
+// and the unrelated refs have no common ancestor with HEAD.
+type stackGitClient struct {
+	gittool.GitClient
+	missing   map[string]bool
+	unrelated map[string]bool
+}
+
+func (c *stackGitClient) EnsureRevision(ctx context.Context, revision string, remote string) error {
+	if c.missing[revision] {
+		return gittool.ErrRevisionNotFound
+	}
+	return nil
+}
+
+func (c *stackGitClient) MergeBase(revision string) (string, error) {
+	if c.unrelated[revision] {
+		return "", gittool.ErrNoMergeBase
+	}
+	return "abc123", nil
+}
+
+func TestSelectStackBase(t *testing.T) {
+	gitClient := &stackGitClient{
+		missing:   map[string]bool{"origin/feature-a": true},
+		unrelated: map[string]bool{"origin/orphan": true},
+	}
+
+	t.Run("first base exists", func(t *testing.T) {
+		base, err := selectStackBase(context.Background(), gitClient, []string{"origin/feature-b", "origin/main"}, "", logrus.New())
+		assert.NoError(t, err)
+		assert.Equal(t, "origin/feature-b", base)
+	})
+
+	t.Run("skip the missing and unrelated bases", func(t *testing.T) {
+		base, err := selectStackBase(context.Background(), gitClient, []string{"origin/feature-a", "origin/orphan", "origin/main"}, "", logrus.New())
+		assert.NoError(t, err)
+		assert.Equal(t, "origin/main", base)
+	})
+
+	t.Run("none of the bases", func(t *testing.T) {
+		_, err := selectStackBase(context.Background(), gitClient, []string{"origin/feature-a", "origin/orphan"}, "", logrus.New())
+		assert.True(t, errors.Is(err, ErrNoStackBase), err)
+	})
+}
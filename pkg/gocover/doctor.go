@@ -0,0 +1,416 @@
+package gocover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/cover"
+)
+
+// DefaultDoctorSampleFiles is the default number of the files in the cover profiles whose packages are resolved by the doctor.
+const DefaultDoctorSampleFiles = 10
+
+// doctorStatus is the result of a check of the doctor.
+type doctorStatus string
+
+const (
+	doctorOK   doctorStatus = "ok"
+	doctorWarn doctorStatus = "warn"
+	doctorFail doctorStatus = "fail"
+	doctorSkip doctorStatus = "skip"
+)
+
+// doctorCheck is a check of the doctor and what to do when it doesn't pass.
+type doctorCheck struct {
+	name    string
+	status  doctorStatus
+	message string
+	fix     string
+}
+
+// NewDoctor creates a GoCover that checks the environment of the runs and prints the fixes of the problems.
+func NewDoctor(o *DoctorOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "doctor")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+
+	sampleFiles := o.SampleFiles
+	if sampleFiles <= 0 {
+		sampleFiles = DefaultDoctorSampleFiles
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &doctor{
+		repositoryPath: repositoryAbsPath,
+		moduleRoot:     filepath.Join(repositoryAbsPath, o.ModuleDir),
+		profiles:       o.CoverProfiles,
+		compareBranch:  o.CompareBranch,
+		fetchRemote:    o.FetchRemote,
+		fetchAuth:      o.FetchAuth,
+		sampleFiles:    sampleFiles,
+		dbOption:       o.DbOption,
+		githubOption:   o.GitHubOption,
+		stdout:         stdout,
+		logger:         logger,
+	}, nil
+}
+
+var _ GoCover = (*doctor)(nil)
+
+// doctor implements the GoCover interface and checks what the full and diff commands depend on:
+// the repository and the compared branch, the cover profiles and the packages of their files,
+// the db clients of the data collection and the GitHub token.
+type doctor struct {
+	repositoryPath string
+	moduleRoot     string
+	profiles       []string
+	compareBranch  string
+	fetchRemote    string
+	fetchAuth      *gittool.FetchAuth
+	sampleFiles    int
+	dbOption       *dbclient.DBOption
+	githubOption   *github.ClientOption
+	stdout         io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (d *doctor) Run(ctx context.Context) error {
+	var checks []*doctorCheck
+
+	gitClient, gitChecks := d.checkGit()
+	checks = append(checks, gitChecks...)
+	checks = append(checks, d.checkCompareBranch(ctx, gitClient))
+
+	files, profileCheck := d.checkProfiles()
+	checks = append(checks, profileCheck, d.checkPackages(files))
+	checks = append(checks, d.checkStores(ctx)...)
+	checks = append(checks, d.checkGitHubToken(ctx))
+
+	failed, skipped := 0, 0
+	for _, c := range checks {
+		fmt.Fprintf(d.stdout, "[%s] %s: %s\n", c.status, c.name, c.message)
+		if c.fix != "" && (c.status == doctorFail || c.status == doctorWarn) {
+			fmt.Fprintf(d.stdout, "       fix: %s\n", c.fix)
+		}
+		switch c.status {
+		case doctorFail:
+			failed++
+		case doctorSkip:
+			skipped++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrDoctorChecksFailed, failed, len(checks))
+	}
+	d.logger.Infof("%d checks passed, %d skipped", len(checks)-skipped, skipped)
+	return nil
+}
+
+// checkGit opens the repository, and checks that HEAD exists and the history is complete.
+// The git executable isn't needed by gocover, but the fixes of the other checks run it.
+func (d *doctor) checkGit() (gittool.GitClient, []*doctorCheck) {
+	var checks []*doctorCheck
+	if _, err := exec.LookPath("git"); err != nil {
+		checks = append(checks, &doctorCheck{
+			name:    "git executable",
+			status:  doctorWarn,
+			message: "git is not found in PATH, gocover reads the repository without it",
+			fix:     "install git to run the git commands of the fixes, such as git fetch",
+		})
+	}
+
+	gitClient, err := gittool.NewGitClient(d.repositoryPath)
+	if err != nil {
+		return nil, append(checks, &doctorCheck{
+			name:    "git repository",
+			status:  doctorFail,
+			message: fmt.Sprintf("open %s: %s", d.repositoryPath, err),
+			fix:     "set --repository-path to the root of the git repository, which contains the .git directory",
+		})
+	}
+	commit, branch, err := gitClient.HeadCommit()
+	if err != nil {
+		return nil, append(checks, &doctorCheck{
+			name:    "git repository",
+			status:  doctorFail,
+			message: err.Error(),
+			fix:     "commit the changes at least once, or check out a branch or a commit",
+		})
+	}
+
+	check := &doctorCheck{name: "git repository", status: doctorOK, message: fmt.Sprintf("HEAD is %s", shortHash(commit))}
+	if branch != "" {
+		check.message += " on " + branch
+	}
+	if shallow, err := gitClient.IsShallow(); err == nil && shallow {
+		check.status = doctorWarn
+		check.message += ", the repository is a shallow clone"
+		check.fix = "fetch the full history, such as fetch-depth: 0 of actions/checkout or GIT_DEPTH: 0 of GitLab CI, " +
+			"or keep --fetch-remote set so that the compared branch is fetched"
+	}
+	return gitClient, append(checks, check)
+}
+
+// checkCompareBranch makes sure that the compared branch exists, after fetching it from the remote, and has a common ancestor with HEAD.
+func (d *doctor) checkCompareBranch(ctx context.Context, gitClient gittool.GitClient) *doctorCheck {
+	check := &doctorCheck{name: "compare branch"}
+	switch {
+	case gitClient == nil:
+		check.status, check.message = doctorSkip, "the git repository can't be read"
+		return check
+	case d.compareBranch == "":
+		check.status, check.message = doctorSkip, "--compare-branch is not set"
+		return check
+	}
+
+	gitClient.SetFetchAuth(d.fetchAuth)
+	if err := gitClient.EnsureRevision(ctx, d.compareBranch, d.fetchRemote); err != nil {
+		check.status, check.message = doctorFail, err.Error()
+		switch {
+		case errors.Is(err, gittool.ErrFetchAuth):
+			check.fix = "set the token of the remote in GOCOVER_FETCH_TOKEN, or --fetch-netrc for https remotes, " +
+				"or --fetch-ssh-key or the ssh agent for ssh remotes"
+		case d.fetchRemote == "":
+			check.fix = fmt.Sprintf("run `git fetch origin` before gocover, or set --fetch-remote to fetch %s", d.compareBranch)
+		default:
+			check.fix = fmt.Sprintf("check that %s is the branch that the pull request targets, or set --compare-branch", d.compareBranch)
+		}
+		return check
+	}
+
+	base, err := gitClient.MergeBase(d.compareBranch)
+	if err != nil {
+		check.status, check.message = doctorFail, err.Error()
+		check.fix = "fetch the history of HEAD and the compared branch, such as `git fetch --unshallow`, " +
+			"the changes can't be found without their common ancestor"
+		return check
+	}
+	check.status = doctorOK
+	check.message = fmt.Sprintf("%s is reachable, the common ancestor with HEAD is %s", d.compareBranch, shortHash(base))
+	return check
+}
+
+// checkProfiles reads the cover profiles, and returns the files in them.
+func (d *doctor) checkProfiles() ([]string, *doctorCheck) {
+	check := &doctorCheck{name: "cover profiles"}
+	if len(d.profiles) == 0 {
+		check.status, check.message = doctorSkip, "--cover-profile is not set"
+		return nil, check
+	}
+
+	seen := make(map[string]bool)
+	var files, modes []string
+	for _, profile := range d.profiles {
+		profiles, err := cover.ParseProfiles(profile)
+		if err != nil {
+			check.status, check.message = doctorFail, fmt.Sprintf("read %s: %s", profile, err)
+			check.fix = fmt.Sprintf("run `go test -coverprofile=%s ./...` before gocover, "+
+				"and check the profile with `gocover validate --cover-profile %s`", profile, profile)
+			return nil, check
+		}
+		if len(profiles) == 0 {
+			check.status, check.message = doctorFail, fmt.Sprintf("%s has no files", profile)
+			check.fix = "check that the tests of the packages ran, such as `go test -coverprofile` of ./... rather than a single package"
+			return nil, check
+		}
+		modes = append(modes, profiles[0].Mode)
+		for _, p := range profiles {
+			if !seen[p.FileName] {
+				seen[p.FileName] = true
+				files = append(files, p.FileName)
+			}
+		}
+	}
+
+	check.status = doctorOK
+	check.message = fmt.Sprintf("%d files in %d profiles, mode %s", len(files), len(d.profiles), strings.Join(uniqueStrings(modes), ", "))
+	if len(uniqueStrings(modes)) > 1 {
+		check.status = doctorWarn
+		check.fix = "produce the profiles with the same -covermode, the hit counts are reduced to whether the blocks are reached when set is mixed with count or atomic"
+	}
+	return files, check
+}
+
+// checkPackages resolves the source files of the sampled files in the cover profiles,
+// the files of the module are resolved under the module directory and the others by importing their packages.
+func (d *doctor) checkPackages(files []string) *doctorCheck {
+	check := &doctorCheck{name: "packages"}
+	if len(files) == 0 {
+		check.status, check.message = doctorSkip, "there are no files in the cover profiles"
+		return check
+	}
+
+	modulePath, err := parseGoModulePath(d.moduleRoot)
+	if err != nil {
+		d.logger.WithError(err).Debug("resolve the files by importing the packages")
+	}
+	resolver := &validate{moduleRoot: d.moduleRoot, modulePath: modulePath}
+
+	sampled := sampleStrings(files, d.sampleFiles)
+	var unresolved []string
+	for _, file := range sampled {
+		if _, err := resolver.resolve(file); err != nil {
+			d.logger.WithError(err).Debugf("resolve %s", file)
+			unresolved = append(unresolved, file)
+		}
+	}
+
+	if len(unresolved) == 0 {
+		check.status = doctorOK
+		check.message = fmt.Sprintf("%d of %d sampled files are resolved", len(sampled), len(sampled))
+		return check
+	}
+	check.status = doctorFail
+	check.message = fmt.Sprintf("%d of %d sampled files are not resolved, such as %s", len(unresolved), len(sampled), unresolved[0])
+	switch {
+	case modulePath == "":
+		check.fix = "set --module-dir to the directory of go.mod relative to --repository-path"
+	case !strings.HasPrefix(unresolved[0], modulePath+"/"):
+		check.fix = fmt.Sprintf("the files are out of module %s, run gocover in the module of the profile with --module-dir, "+
+			"or run `go mod download` so their packages are imported", modulePath)
+	default:
+		check.fix = "run gocover on the commit that the cover profiles are produced from, the files are missing in the module"
+	}
+	return check
+}
+
+// checkStores checks that the data can be stored to each db client of the data collection.
+func (d *doctor) checkStores(ctx context.Context) []*doctorCheck {
+	if d.dbOption == nil || !d.dbOption.DataCollectionEnabled {
+		return []*doctorCheck{{name: "store", status: doctorSkip, message: "--data-collection-enabled is not set"}}
+	}
+	if len(d.dbOption.DbTypes) == 0 {
+		return []*doctorCheck{{
+			name:    "store",
+			status:  doctorFail,
+			message: "no db types are set",
+			fix:     "set --store-type, such as Kusto or File",
+		}}
+	}
+
+	var checks []*doctorCheck
+	for _, dbType := range d.dbOption.DbTypes {
+		check := &doctorCheck{name: fmt.Sprintf("store %s", dbType), status: doctorOK, message: "the data can be stored"}
+		if err := d.dbOption.Check(ctx, dbType); err != nil {
+			check.status, check.message = doctorFail, err.Error()
+			for _, optional := range d.dbOption.OptionalDbTypes {
+				if optional == dbType {
+					check.status = doctorWarn
+				}
+			}
+			check.fix = storeFix(dbType, err)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// storeFix returns what to do when the db client of the type fails the check.
+func storeFix(dbType dbclient.ClientType, err error) string {
+	switch {
+	case errors.Is(err, dbclient.ErrEnvRequired):
+		return "set the environment variables of --kusto-auth service-principal, or use another --kusto-auth such as managed-identity"
+	case errors.Is(err, dbclient.ErrFlagRequired):
+		return "set the flag, or its setting in the configuration file"
+	case errors.Is(err, dbclient.ErrTableNotFound):
+		return "create the table in the database, or set --coverage-event and --ignore-event to the existing tables"
+	case dbType == dbclient.File:
+		return "check that --store-dir is writable, such as the permissions of the mounted directory"
+	default:
+		return "check the network access to --endpoint, and that the identity of --kusto-auth can read and ingest into the database"
+	}
+}
+
+// checkGitHubToken checks that the token reads the repository and has the scope to set the commit statuses.
+func (d *doctor) checkGitHubToken(ctx context.Context) *doctorCheck {
+	check := &doctorCheck{name: "github token"}
+	if d.githubOption == nil || !d.githubOption.HasToken() {
+		check.status, check.message = doctorSkip, "GITHUB_TOKEN is not set"
+		return check
+	}
+
+	client, err := github.NewClient(d.githubOption, d.logger)
+	if err != nil {
+		check.status, check.message = doctorFail, err.Error()
+		check.fix = "set GITHUB_REPOSITORY in {owner}/{repo} format, it's set by GitHub Actions"
+		return check
+	}
+	scopes, err := client.TokenScopes(ctx)
+	if err != nil {
+		check.status, check.message = doctorFail, err.Error()
+		check.fix = fmt.Sprintf("renew the token, and make sure it can read %s, and GITHUB_API_URL or --github-api-url is the API of the server", d.githubOption.Repository)
+		return check
+	}
+
+	switch {
+	case !scopes.Listed:
+		check.status = doctorOK
+		check.message = "the token reads the repository, the permissions of a fine-grained token or GITHUB_TOKEN are not listed, " +
+			"it needs statuses: write for --commit-status and issues: write for --tracking-issue"
+	case !scopes.Has("repo", "repo:status"):
+		check.status = doctorFail
+		check.message = fmt.Sprintf("the scopes of the token are [%s], which can't set the commit statuses", strings.Join(scopes.Scopes, ", "))
+		check.fix = "grant the repo:status scope to the token, or the repo scope for --tracking-issue"
+	default:
+		check.status = doctorOK
+		check.message = fmt.Sprintf("the scopes of the token are [%s]", strings.Join(scopes.Scopes, ", "))
+	}
+	return check
+}
+
+// sampleStrings returns n of the sorted values spread evenly, or all of them if there are not more than n.
+func sampleStrings(values []string, n int) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	if len(sorted) <= n {
+		return sorted
+	}
+	sampled := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		sampled = append(sampled, sorted[i*len(sorted)/n])
+	}
+	return sampled
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
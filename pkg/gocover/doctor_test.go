@@ -0,0 +1,116 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/github"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctor(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/m\n",
+		"a.go":   "package m\n\nfunc A() {}\n",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	repository, err := gogit.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repository.Worktree()
+	assert.NoError(t, err)
+	assert.NoError(t, worktree.AddGlob("."))
+	_, err = worktree.Commit("init", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "foo", Email: "foo@bar.org", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	writeProfile := func(content string) string {
+		profile := filepath.Join(t.TempDir(), "coverage.out")
+		assert.NoError(t, os.WriteFile(profile, []byte(content), 0644))
+		return profile
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer status" {
+			w.Header().Set("X-OAuth-Scopes", "repo:status")
+		} else {
+			w.Header().Set("X-OAuth-Scopes", "read:org")
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	run := func(o *DoctorOption) (string, error) {
+		var stdout bytes.Buffer
+		o.RepositoryPath, o.ModuleDir, o.StdOut = dir, "./", &stdout
+		d, err := NewDoctor(o)
+		assert.NoError(t, err)
+		err = d.Run(context.Background())
+		return stdout.String(), err
+	}
+
+	t.Run("pass", func(t *testing.T) {
+		out, err := run(&DoctorOption{
+			CoverProfiles: []string{writeProfile("mode: set\nexample.com/m/a.go:3.12,3.13 0 1\n")},
+			CompareBranch: "master",
+			DbOption: &dbclient.DBOption{
+				DataCollectionEnabled: true,
+				DbTypes:               []dbclient.ClientType{dbclient.File},
+				FileOption:            dbclient.FileOption{Dir: t.TempDir()},
+			},
+			GitHubOption: &github.ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "status"},
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, out, "[ok] git repository: HEAD is ")
+		assert.Contains(t, out, "[ok] compare branch: master is reachable")
+		assert.Contains(t, out, "[ok] cover profiles: 1 files in 1 profiles, mode set\n")
+		assert.Contains(t, out, "[ok] packages: 1 of 1 sampled files are resolved\n")
+		assert.Contains(t, out, "[ok] store File: the data can be stored\n")
+		assert.Contains(t, out, "[ok] github token: the scopes of the token are [repo:status]\n")
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		t.Setenv("KUSTO_TENANT_ID", "")
+		out, err := run(&DoctorOption{
+			CoverProfiles: []string{writeProfile("mode: set\nexample.com/m/a.go:3.12,3.13 0 1\nexample.com/m/b.go:3.12,3.13 0 1\n")},
+			CompareBranch: "origin/main",
+			DbOption: &dbclient.DBOption{
+				DataCollectionEnabled: true,
+				DbTypes:               []dbclient.ClientType{dbclient.Kusto},
+				KustoOption:           dbclient.KustoOption{AuthMethod: dbclient.AuthServicePrincipal},
+			},
+			GitHubOption: &github.ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "org"},
+		})
+		assert.ErrorIs(t, err, ErrDoctorChecksFailed)
+		assert.Contains(t, out, "[fail] compare branch: ")
+		assert.Contains(t, out, "fix: run `git fetch origin` before gocover")
+		assert.Contains(t, out, "[fail] packages: 1 of 2 sampled files are not resolved, such as example.com/m/b.go\n")
+		assert.Contains(t, out, "[fail] store Kusto: KUSTO_TENANT_ID")
+		assert.Contains(t, out, "[fail] github token: the scopes of the token are [read:org]")
+		assert.Contains(t, out, "fix: grant the repo:status scope")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		out, err := run(&DoctorOption{})
+		assert.NoError(t, err)
+		assert.Contains(t, out, "[skip] cover profiles: --cover-profile is not set\n")
+		assert.Contains(t, out, "[skip] store: --data-collection-enabled is not set\n")
+		assert.Contains(t, out, "[skip] github token: GITHUB_TOKEN is not set\n")
+	})
+}
+
+func TestSampleStrings(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, sampleStrings([]string{"b", "a"}, 3))
+	assert.Equal(t, []string{"a", "c", "e"}, sampleStrings([]string{"f", "e", "d", "c", "b", "a"}, 3))
+}
@@ -4,6 +4,9 @@ const (
 	GeneralErrorExitCode        = 1  // bash general error exit code
 	UnitTestFailedErrorExitCode = 11 // unit test failed exit code
 	LowCoverageErrorExitCode    = 12 // pass rate is lower than the coverage baseline exit code
+	FlakyCoverageErrorExitCode  = 13 // coverage differs between repeated runs exit code
+	InvalidProfileErrorExitCode = 14 // cover profile has problems exit code
+	AnnotationErrorExitCode     = 15 // ignore annotations have problems exit code
 )
 
 // GoCoverError carries the detail error information for gocover error
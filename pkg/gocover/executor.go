@@ -18,6 +18,7 @@ import (
 
 const (
 	outCoverageProfile = "coverage.out"
+	outTestOutput      = "test.json"
 )
 
 type GoCoverTestExecutor interface {
@@ -25,7 +26,7 @@ type GoCoverTestExecutor interface {
 }
 
 func NewGoCoverTestExecutor(o *GoCoverTestOption) (GoCoverTestExecutor, error) {
-	repositoryAbsPath, err := filepath.Abs(o.RepositoryPath)
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
 	if err != nil {
 		return nil, fmt.Errorf("get absolute path of repo: %w", err)
 	}
@@ -101,34 +102,58 @@ func (t *goBuiltInTestExecutor) Run(ctx context.Context) error {
 	}
 	coverFile := filepath.Join(t.outputDir, outCoverageProfile)
 
-	goArgs := []string{"test", "./..."}
+	packages := t.option.Packages
+	if len(packages) == 0 {
+		packages = []string{DefaultTestPackages}
+	}
+	coverPkg := t.option.CoverPkg
+	if coverPkg == "" {
+		coverPkg = DefaultTestPackages
+	}
+
+	goArgs := []string{"test"}
+	goArgs = append(goArgs, packages...)
 	goArgs = append(goArgs, goFlags...)
+	if t.option.Overlay != "" {
+		goArgs = append(goArgs, "-overlay", t.option.Overlay)
+	}
 	goArgs = append(goArgs,
 		"-coverprofile", coverFile,
-		"-coverpkg=./...",
+		"-covermode", coverMode(t.option.CoverMode, goFlags),
+		"-coverpkg", coverPkg,
 		"-v")
 
-	cmd := exec.Command(t.executable, goArgs...)
+	cmd := exec.CommandContext(ctx, t.executable, goArgs...)
 	cmd.Dir = filepath.Join(t.repositoryPath, t.moduleDir)
 	cmd.Stdin = nil
 	cmd.Stdout = t.stdout
 	cmd.Stderr = t.stderr
 
+	// the events of go test -json are kept besides the cover profile, so the results of the tests are reported
+	if hasJSONFlag(goFlags) {
+		testOutput := filepath.Join(t.outputDir, outTestOutput)
+		f, err := os.Create(testOutput)
+		if err != nil {
+			return fmt.Errorf("create test output: %w", err)
+		}
+		defer f.Close()
+		cmd.Stdout = f
+		if t.stdout != nil {
+			cmd.Stdout = io.MultiWriter(t.stdout, f)
+		}
+		t.option.TestOutputs = append(t.option.TestOutputs, testOutput)
+	}
+
 	logger.Infof("run unit tests: '%s'", cmd.String())
 	if err := cmd.Run(); err != nil {
 		t.logger.WithError(err).Errorf(`run unit test '%s'`, cmd.String())
 		return WrapErrorWithCode(errors.New("unit test failed"), UnitTestFailedErrorExitCode, "")
 	}
 
-	gocover, err := buildGoCover(t.mode, t.option, []string{coverFile}, logger)
-	if err != nil {
-		return err
-	}
-
 	logger.Info("run unit test succeeded")
 	logger.Infof("cover profile: %s", coverFile)
 
-	if err := gocover.Run(ctx); err != nil {
+	if err := runGoCover(ctx, t.mode, t.option, []string{coverFile}, logger); err != nil {
 		err := fmt.Errorf("run gocover: %w", err)
 		logger.WithError(err).Error()
 		return err
@@ -136,6 +161,30 @@ func (t *goBuiltInTestExecutor) Run(ctx context.Context) error {
 	return nil
 }
 
+// hasJSONFlag reports whether go test writes the events in json with the flags.
+func hasJSONFlag(goFlags []string) bool {
+	for _, flag := range goFlags {
+		if flag == "-json" || flag == "-json=true" || flag == "--json" || flag == "--json=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// coverMode returns the go test -covermode value,
+// if mode is not specified, use "atomic" when race detector is enabled as go test requires, otherwise "set".
+func coverMode(mode string, goFlags []string) string {
+	if mode != "" {
+		return mode
+	}
+	for _, flag := range goFlags {
+		if flag == "-race" || flag == "-race=true" || flag == "--race" || flag == "--race=true" {
+			return "atomic"
+		}
+	}
+	return "set"
+}
+
 type ginkgoTestExecutor struct {
 	repositoryPath string
 	moduleDir      string
@@ -170,13 +219,8 @@ func (e *ginkgoTestExecutor) Run(ctx context.Context) error {
 		return fmt.Errorf("merge cover profiles: %w", err)
 	}
 
-	gocover, err := buildGoCover(e.mode, e.option, []string{mergedFile}, e.logger)
-	if err != nil {
-		return err
-	}
-
 	e.logger.Infof("cover profile: %s", mergedFile)
-	if err := gocover.Run(ctx); err != nil {
+	if err := runGoCover(ctx, e.mode, e.option, []string{mergedFile}, e.logger); err != nil {
 		err := fmt.Errorf("run gocover: %w", err)
 		e.logger.WithError(err).Error()
 		return err
@@ -228,7 +272,7 @@ func (executor *ginkgoTestExecutor) runTests(ctx context.Context) error {
 	buildString := fmt.Sprintf("%s %s", executor.executable, strings.Join(buildArgs, " "))
 
 	logger.Infof("executing cmd: %s", buildString)
-	buildCmd := exec.Command(executor.executable, buildArgs...)
+	buildCmd := exec.CommandContext(ctx, executor.executable, buildArgs...)
 	buildCmd.Dir = workingDir
 	buildCmd.Stdin = nil
 	buildCmd.Stdout = executor.stdout
@@ -249,7 +293,7 @@ func (executor *ginkgoTestExecutor) runTests(ctx context.Context) error {
 	runString := fmt.Sprintf("%s %s", executor.executable, strings.Join(ginkgoFlags, " "))
 
 	logger.Infof("executing cmd: %s", runString)
-	runCmd := exec.Command(executor.executable, ginkgoFlags...)
+	runCmd := exec.CommandContext(ctx, executor.executable, ginkgoFlags...)
 	runCmd.Dir = workingDir
 	runCmd.Stdin = nil
 	runCmd.Stdout = executor.stdout
@@ -309,6 +353,56 @@ func ginkgoCmd() string {
 	return "ginkgo"
 }
 
+// runGoCover runs the coverage calculation of the mode on the cover profiles.
+// In AllCoverage mode, full coverage and diff coverage are evaluated against their own baselines
+// and reported separately, the report names are suffixed with the coverage mode.
+// The gate expression is evaluated once with the metrics of both of them.
+func runGoCover(
+	ctx context.Context,
+	mode CoverageMode,
+	option *GoCoverTestOption,
+	coverProfiles []string,
+	logger logrus.FieldLogger,
+) error {
+	if mode != AllCoverage {
+		gocover, err := buildGoCover(mode, option, coverProfiles, logger)
+		if err != nil {
+			return err
+		}
+		return gocover.Run(ctx)
+	}
+
+	// the metrics of both runs are shared, the gate is evaluated by the diff coverage run at last.
+	var errs []error
+	metrics := &coverageMetrics{}
+	for _, m := range []CoverageMode{FullCoverage, DiffCoverage} {
+		o := *option
+		o.ReportName = fmt.Sprintf("%s-%s", option.ReportName, m)
+		if m == FullCoverage {
+			o.Gate = ""
+		}
+		if option.AzureDevOpsDir != "" {
+			o.AzureDevOpsDir = filepath.Join(option.AzureDevOpsDir, string(m))
+		}
+
+		gocover, err := buildGoCover(m, &o, coverProfiles, logger)
+		if err != nil {
+			return err
+		}
+		switch c := gocover.(type) {
+		case *fullCover:
+			metrics.modulePath = c.modulePath
+			c.metrics = metrics
+		case *diffCover:
+			c.metrics = metrics
+		}
+		if err := gocover.Run(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s coverage: %w", m, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func buildGoCover(
 	mode CoverageMode,
 	option *GoCoverTestOption,
@@ -318,33 +412,132 @@ func buildGoCover(
 	switch mode {
 	case FullCoverage:
 		return NewFullCover(&FullOption{
-			CoverProfiles:    coverProfiles,
-			RepositoryPath:   option.RepositoryPath,
-			ModuleDir:        option.ModuleDir,
-			CoverageBaseline: option.CoverageBaseline,
-			ReportFormat:     option.ReportFormat,
-			ReportName:       option.ReportName,
-			OutputDir:        option.OutputDir,
-			Excludes:         option.Excludes,
-			Style:            option.Style,
-			DbOption:         option.DbOption,
-			Logger:           logger,
+			CoverProfiles:     coverProfiles,
+			RepositoryPath:    option.RepositoryPath,
+			ModuleDir:         option.ModuleDir,
+			CoverageBaseline:  option.CoverageBaseline,
+			ReportFormats:     option.ReportFormats,
+			ReportName:        option.ReportName,
+			OutputDir:         option.OutputDir,
+			Excludes:          option.Excludes,
+			ExcludeFunctions:  option.ExcludeFunctions,
+			ExcludeBuildTags:  option.ExcludeBuildTags,
+			SkipPackages:      option.SkipPackages,
+			Style:             option.Style,
+			Templates:         option.Templates,
+			Treemap:           option.Treemap,
+			JUnit:             option.JUnit,
+			LinesReport:       option.LinesReport,
+			DirectoryTree:     option.DirectoryTree,
+			CacheDir:          option.CacheDir,
+			TeamCity:          option.TeamCity,
+			SummaryLine:       option.SummaryLine,
+			AzureDevOpsDir:    option.AzureDevOpsDir,
+			NoStepSummary:     option.NoStepSummary,
+			AttestationKey:    option.AttestationKey,
+			ToolVersion:       option.ToolVersion,
+			TopUncovered:      option.TopUncovered,
+			TopHot:            option.TopHot,
+			FoldClosures:      option.FoldClosures,
+			HistoryDir:        option.HistoryDir,
+			NeverCoveredRuns:  option.NeverCoveredRuns,
+			Compression:       option.Compression,
+			BaseRef:           option.BaseRef,
+			TrendRuns:         option.TrendRuns,
+			TrendPackages:     option.TrendPackages,
+			CoverageFloor:     option.CoverageFloor,
+			Gate:              option.Gate,
+			Ownership:         option.Ownership,
+			TestOutputs:       option.TestOutputs,
+			Overlay:           option.Overlay,
+			SkipUnresolved:    option.SkipUnresolved,
+			ContinueOnError:   option.ContinueOnError,
+			IgnoreFileErrors:  option.IgnoreFileErrors,
+			Progress:          option.Progress,
+			MemoryLimit:       option.MemoryLimit,
+			IgnorePolicy:      option.IgnorePolicy,
+			MixedCoverModes:   option.MixedCoverModes,
+			CompatibleMarkers: option.CompatibleMarkers,
+			ClosedIssues:      option.ClosedIssues,
+			Anonymize:         option.Anonymize,
+			CommitStatus:      option.CommitStatus,
+			StatusContext:     option.StatusContext,
+			DetailsURL:        option.DetailsURL,
+			GitHubOption:      option.GitHubOption,
+			WebhookOption:     option.WebhookOption,
+			CI:                option.CI,
+			RunLabels:         option.RunLabels,
+			DbOption:          option.DbOption,
+			Logger:            logger,
 		})
 	case DiffCoverage:
 		return NewDiffCover(&DiffOption{
-			CoverProfiles:    coverProfiles,
-			CompareBranch:    option.CompareBranch,
-			RepositoryPath:   option.RepositoryPath,
-			ModuleDir:        option.ModuleDir,
-			ModulePath:       option.ModuleDir,
-			CoverageBaseline: option.CoverageBaseline,
-			ReportFormat:     option.ReportFormat,
-			ReportName:       option.ReportName,
-			OutputDir:        option.OutputDir,
-			Excludes:         option.Excludes,
-			Style:            option.Style,
-			DbOption:         option.DbOption,
-			Logger:           logger,
+			CoverProfiles:     coverProfiles,
+			CompareBranch:     option.CompareBranch,
+			RepositoryPath:    option.RepositoryPath,
+			ModuleDir:         option.ModuleDir,
+			ModulePath:        option.ModuleDir,
+			CoverageBaseline:  option.CoverageBaseline,
+			ReportFormats:     option.ReportFormats,
+			ReportName:        option.ReportName,
+			OutputDir:         option.OutputDir,
+			Compression:       option.Compression,
+			Excludes:          option.Excludes,
+			ExcludeFunctions:  option.ExcludeFunctions,
+			ExcludeBuildTags:  option.ExcludeBuildTags,
+			SkipPackages:      option.SkipPackages,
+			Style:             option.Style,
+			WeakCoverage:      option.WeakCoverage,
+			DetectMoves:       option.DetectMoves,
+			NewCodeSince:      option.NewCodeSince,
+			StackBases:        option.StackBases,
+			Authors:           option.Authors,
+			Since:             option.Since,
+			Until:             option.Until,
+			AttributeCommits:  option.AttributeCommits,
+			FetchRemote:       option.FetchRemote,
+			FetchAuth:         option.FetchAuth,
+			Templates:         option.Templates,
+			Treemap:           option.Treemap,
+			JUnit:             option.JUnit,
+			LinesReport:       option.LinesReport,
+			DirectoryTree:     option.DirectoryTree,
+			SideBySide:        option.SideBySide,
+			AnnotatedDiff:     option.AnnotatedDiff,
+			CacheDir:          option.CacheDir,
+			TeamCity:          option.TeamCity,
+			SummaryLine:       option.SummaryLine,
+			AzureDevOpsDir:    option.AzureDevOpsDir,
+			NoStepSummary:     option.NoStepSummary,
+			AttestationKey:    option.AttestationKey,
+			ToolVersion:       option.ToolVersion,
+			TopUncovered:      option.TopUncovered,
+			TopHot:            option.TopHot,
+			FoldClosures:      option.FoldClosures,
+			Gate:              option.Gate,
+			Ownership:         option.Ownership,
+			TestOutputs:       option.TestOutputs,
+			Overlay:           option.Overlay,
+			SkipUnresolved:    option.SkipUnresolved,
+			ContinueOnError:   option.ContinueOnError,
+			IgnoreFileErrors:  option.IgnoreFileErrors,
+			Progress:          option.Progress,
+			MemoryLimit:       option.MemoryLimit,
+			IgnorePolicy:      option.IgnorePolicy,
+			MixedCoverModes:   option.MixedCoverModes,
+			CompatibleMarkers: option.CompatibleMarkers,
+			ClosedIssues:      option.ClosedIssues,
+			TrackingIssue:     option.TrackingIssue,
+			Anonymize:         option.Anonymize,
+			CommitStatus:      option.CommitStatus,
+			StatusContext:     option.StatusContext,
+			DetailsURL:        option.DetailsURL,
+			GitHubOption:      option.GitHubOption,
+			WebhookOption:     option.WebhookOption,
+			CI:                option.CI,
+			RunLabels:         option.RunLabels,
+			DbOption:          option.DbOption,
+			Logger:            logger,
 		})
 	default:
 		return nil, ErrUnknownCoverageMode
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 
+	"github.com/Azure/gocover/pkg/dbclient"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,6 +59,48 @@ func TestGoBuiltInTestExecutor_Run_Contains_Go_Flag(t *testing.T) {
 	executor.Run(context.Background())
 	logStr := logBuf.String()
 	assert.Contains(t, logStr, "test ./... -count=1")
+	assert.Contains(t, logStr, "-covermode set -coverpkg ./...")
+}
+
+func TestCoverMode(t *testing.T) {
+	testSuites := []struct {
+		mode    string
+		goFlags []string
+		expect  string
+	}{
+		{mode: "", goFlags: nil, expect: "set"},
+		{mode: "", goFlags: []string{"-count=1"}, expect: "set"},
+		{mode: "", goFlags: []string{"-race"}, expect: "atomic"},
+		{mode: "", goFlags: []string{"-count=1", "-race=true"}, expect: "atomic"},
+		{mode: "count", goFlags: nil, expect: "count"},
+		{mode: "atomic", goFlags: []string{"-race"}, expect: "atomic"},
+	}
+
+	for _, testCase := range testSuites {
+		actual := coverMode(testCase.mode, testCase.goFlags)
+		if actual != testCase.expect {
+			t.Errorf("for mode %q and flags %v, expect %s, but get %s", testCase.mode, testCase.goFlags, testCase.expect, actual)
+		}
+	}
+}
+
+func TestHasJSONFlag(t *testing.T) {
+	testSuites := []struct {
+		goFlags []string
+		expect  bool
+	}{
+		{goFlags: nil, expect: false},
+		{goFlags: []string{"-count=1", "-race"}, expect: false},
+		{goFlags: []string{"-json"}, expect: true},
+		{goFlags: []string{"-count=1", "--json=true"}, expect: true},
+		{goFlags: []string{"-json=false"}, expect: false},
+	}
+
+	for _, testCase := range testSuites {
+		if actual := hasJSONFlag(testCase.goFlags); actual != testCase.expect {
+			t.Errorf("for flags %v, expect %t, but get %t", testCase.goFlags, testCase.expect, actual)
+		}
+	}
 }
 
 func TestGoBuiltInTestExecutor_Run_CommandFails(t *testing.T) {
@@ -88,6 +132,37 @@ func TestGoBuiltInTestExecutor_Run_CommandFails(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRunGoCover(t *testing.T) {
+	t.Run("unknown coverage mode", func(t *testing.T) {
+		err := runGoCover(context.Background(), "foo", &GoCoverTestOption{}, nil, logrus.New())
+		assert.ErrorIs(t, err, ErrUnknownCoverageMode)
+	})
+
+	t.Run("full and diff coverage", func(t *testing.T) {
+		dir := t.TempDir()
+		coverProfile := filepath.Join(dir, "coverage.out")
+		assert.NoError(t, os.WriteFile(coverProfile, []byte("mode: set\n"), 0644))
+
+		option := &GoCoverTestOption{
+			RepositoryPath: "../..",
+			ModuleDir:      "",
+			CompareBranch:  "HEAD",
+			OutputDir:      dir,
+			ReportName:     "coverage",
+			CoverageFloor:  50,
+			DbOption:       &dbclient.DBOption{},
+		}
+		err := runGoCover(context.Background(), AllCoverage, option, []string{coverProfile}, logrus.New())
+		assert.NoError(t, err)
+
+		for _, name := range []string{"coverage-full.html", "coverage-diff.html"} {
+			_, err := os.Stat(filepath.Join(dir, name))
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, "coverage", option.ReportName)
+	})
+}
+
 // TestHelperProcess is not a real test. It's used as a helper process for exec.Command patching.
 func TestHelperProcess(t *testing.T) {
 	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
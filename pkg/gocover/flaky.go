@@ -0,0 +1,250 @@
+package gocover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	outFlakyReport = "flaky.json"
+)
+
+// FlakyCoverage represents the statements whose covered state differs between repeated runs.
+type FlakyCoverage struct {
+	// CoverProfiles are the cover profiles of the repeated runs.
+	CoverProfiles []string `json:"coverProfiles"`
+	// Statements are the statements that are covered in some runs but not in others.
+	Statements []*FlakyStatement `json:"statements"`
+}
+
+// FlakyStatement represents a statement whose covered state differs between runs.
+type FlakyStatement struct {
+	// FileName is the file that the statement belongs to.
+	FileName string `json:"fileName"`
+	// Function is the name of the function that the statement belongs to.
+	Function string `json:"function"`
+	// StartLine is the start line of the statement.
+	StartLine int `json:"startLine"`
+	// EndLine is the end line of the statement.
+	EndLine int `json:"endLine"`
+	// CoveredRuns are the indices of the runs that cover the statement.
+	CoveredRuns []int `json:"coveredRuns"`
+	// UncoveredRuns are the indices of the runs that don't cover the statement.
+	UncoveredRuns []int `json:"uncoveredRuns"`
+}
+
+func NewFlakyCoverage(o *FlakyOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "flaky")
+
+	if len(o.CoverProfiles) < 2 {
+		return nil, ErrNotEnoughCoverProfiles
+	}
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+
+	modulePath, err := parseGoModulePath(filepath.Join(repositoryAbsPath, o.ModuleDir))
+	if err != nil {
+		return nil, fmt.Errorf("parse go module path: %w", err)
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &flakyCoverage{
+		repositoryPath:  repositoryAbsPath,
+		modulePath:      modulePath,
+		comparedBranch:  o.CompareBranch,
+		coverFilenames:  o.CoverProfiles,
+		outputDir:       o.OutputDir,
+		excludeFiles:    make(excludeFileCache),
+		excludePatterns: o.Excludes,
+		failOnFlaky:     o.FailOnFlaky,
+		stdout:          stdout,
+		logger:          logger,
+	}, nil
+}
+
+var _ GoCover = (*flakyCoverage)(nil)
+
+// flakyCoverage implements the GoCover interface and finds the statements
+// whose covered state differs between the cover profiles of repeated runs.
+type flakyCoverage struct {
+	repositoryPath  string
+	modulePath      string
+	comparedBranch  string // when it's empty, all the statements are checked
+	coverFilenames  []string
+	outputDir       string
+	excludePatterns []string
+	excludeFiles    excludeFileCache
+	failOnFlaky     bool
+	stdout          io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (f *flakyCoverage) Run(ctx context.Context) error {
+	var changes []*gittool.Change
+	if f.comparedBranch != "" {
+		gitClient, err := gittool.NewGitClient(f.repositoryPath)
+		if err != nil {
+			return fmt.Errorf("git repository: %w", err)
+		}
+		changes, err = gitClient.DiffChangesFromCommitted(ctx, f.comparedBranch)
+		if err != nil {
+			return fmt.Errorf("git diff: %w", err)
+		}
+	}
+
+	tracker := newFlakyTracker(len(f.coverFilenames))
+	for i, coverFilename := range f.coverFilenames {
+		packages, err := parser.NewParser([]string{coverFilename}, f.logger).Parse(ctx, changes)
+		if err != nil {
+			return fmt.Errorf("parse cover profile %s: %w", coverFilename, err)
+		}
+
+		for _, pkg := range packages {
+			root, err := packageRoot("", pkg.Name)
+			if err != nil {
+				return fmt.Errorf("build import %w", err)
+			}
+
+			for _, fun := range pkg.Functions {
+				fileName := formatFilePath(root, fun.File, f.modulePath)
+				if inExclueds(f.excludeFiles, f.excludePatterns, fileName, f.logger) {
+					continue
+				}
+				for _, st := range fun.Statements {
+					if changes != nil && st.State != parser.Changed {
+						continue
+					}
+					tracker.record(i, fileName, fun.Name, st)
+				}
+			}
+		}
+	}
+
+	result := &FlakyCoverage{
+		CoverProfiles: f.coverFilenames,
+		Statements:    tracker.flaky(),
+	}
+
+	if f.outputDir != "" {
+		reportFile := filepath.Join(f.outputDir, outFlakyReport)
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("json marshal: %w", err)
+		}
+		if err := os.WriteFile(reportFile, data, 0644); err != nil {
+			return fmt.Errorf("write flaky coverage report: %w", err)
+		}
+		f.logger.Infof("generate flaky coverage report: %s", reportFile)
+	}
+
+	writeFlakyCoverage(f.stdout, result)
+
+	if f.failOnFlaky && len(result.Statements) != 0 {
+		return WrapErrorWithCode(
+			fmt.Errorf("found %d statements whose coverage differs between runs", len(result.Statements)),
+			FlakyCoverageErrorExitCode,
+			"",
+		)
+	}
+	return nil
+}
+
+// flakyTracker tracks the covered state of the statements in each run.
+type flakyTracker struct {
+	runs       int
+	statements map[string]*trackedStatement
+}
+
+type trackedStatement struct {
+	*FlakyStatement
+	covered []bool
+}
+
+func newFlakyTracker(runs int) *flakyTracker {
+	return &flakyTracker{
+		runs:       runs,
+		statements: make(map[string]*trackedStatement),
+	}
+}
+
+// record records the covered state of the statement in the run.
+func (t *flakyTracker) record(run int, fileName string, function string, st *parser.Statement) {
+	key := fmt.Sprintf("%s:%d:%d", fileName, st.Start, st.End)
+	s, ok := t.statements[key]
+	if !ok {
+		s = &trackedStatement{
+			FlakyStatement: &FlakyStatement{
+				FileName:  fileName,
+				Function:  function,
+				StartLine: st.StartLine,
+				EndLine:   st.EndLine,
+			},
+			covered: make([]bool, t.runs),
+		}
+		t.statements[key] = s
+	}
+	if st.Reached > 0 {
+		s.covered[run] = true
+	}
+}
+
+// flaky returns the statements that are covered in some runs but not in others, sorted by their positions.
+// A statement that not exists in a run is regarded as uncovered in that run.
+func (t *flakyTracker) flaky() []*FlakyStatement {
+	result := []*FlakyStatement{}
+	for _, s := range t.statements {
+		s.CoveredRuns, s.UncoveredRuns = []int{}, []int{}
+		for run, covered := range s.covered {
+			if covered {
+				s.CoveredRuns = append(s.CoveredRuns, run)
+			} else {
+				s.UncoveredRuns = append(s.UncoveredRuns, run)
+			}
+		}
+		if len(s.CoveredRuns) != 0 && len(s.UncoveredRuns) != 0 {
+			result = append(result, s.FlakyStatement)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		si, sj := result[i], result[j]
+		return si.FileName < sj.FileName || si.FileName == sj.FileName && si.StartLine < sj.StartLine
+	})
+	return result
+}
+
+// writeFlakyCoverage outputs the summary of the flaky coverage.
+func writeFlakyCoverage(w io.Writer, result *FlakyCoverage) {
+	if len(result.Statements) == 0 {
+		fmt.Fprintf(w, "no flaky coverage found in %d runs\n", len(result.CoverProfiles))
+		return
+	}
+
+	fmt.Fprintf(w, "found %d statements whose coverage differs between %d runs:\n", len(result.Statements), len(result.CoverProfiles))
+	for _, s := range result.Statements {
+		fmt.Fprintf(w, "%s:%d-%d %s covered in runs [%s], uncovered in runs [%s]\n",
+			s.FileName, s.StartLine, s.EndLine, s.Function, report.IntsJoin(s.CoveredRuns), report.IntsJoin(s.UncoveredRuns))
+	}
+}
@@ -0,0 +1,61 @@
+package gocover
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlakyTracker(t *testing.T) {
+	t.Run("find flaky statements", func(t *testing.T) {
+		tracker := newFlakyTracker(3)
+
+		for run, reached := range [][]int64{{1, 1, 0}, {1, 0, 0}, {1, 1, 0}} {
+			tracker.record(run, "foo.go", "foo", &parser.Statement{Start: 0, End: 10, StartLine: 1, EndLine: 1, Reached: reached[0]})
+			tracker.record(run, "foo.go", "foo", &parser.Statement{Start: 20, End: 30, StartLine: 3, EndLine: 3, Reached: reached[1]})
+			tracker.record(run, "foo.go", "foo", &parser.Statement{Start: 40, End: 50, StartLine: 5, EndLine: 5, Reached: reached[2]})
+		}
+		// statement only exists in the first run
+		tracker.record(0, "bar.go", "bar", &parser.Statement{Start: 0, End: 10, StartLine: 1, EndLine: 1, Reached: 1})
+
+		flaky := tracker.flaky()
+		assert.Len(t, flaky, 2)
+
+		assert.Equal(t, "bar.go", flaky[0].FileName)
+		assert.Equal(t, []int{0}, flaky[0].CoveredRuns)
+		assert.Equal(t, []int{1, 2}, flaky[0].UncoveredRuns)
+
+		assert.Equal(t, "foo.go", flaky[1].FileName)
+		assert.Equal(t, "foo", flaky[1].Function)
+		assert.Equal(t, 3, flaky[1].StartLine)
+		assert.Equal(t, []int{0, 2}, flaky[1].CoveredRuns)
+		assert.Equal(t, []int{1}, flaky[1].UncoveredRuns)
+
+		var buf bytes.Buffer
+		writeFlakyCoverage(&buf, &FlakyCoverage{CoverProfiles: []string{"a", "b", "c"}, Statements: flaky})
+		assert.Contains(t, buf.String(), "found 2 statements whose coverage differs between 3 runs")
+		assert.Contains(t, buf.String(), "foo.go:3-3 foo covered in runs [0,2], uncovered in runs [1]")
+	})
+
+	t.Run("no flaky statements", func(t *testing.T) {
+		tracker := newFlakyTracker(2)
+		tracker.record(0, "foo.go", "foo", &parser.Statement{Start: 0, End: 10, Reached: 1})
+		tracker.record(1, "foo.go", "foo", &parser.Statement{Start: 0, End: 10, Reached: 3})
+
+		flaky := tracker.flaky()
+		assert.Empty(t, flaky)
+
+		var buf bytes.Buffer
+		writeFlakyCoverage(&buf, &FlakyCoverage{CoverProfiles: []string{"a", "b"}, Statements: flaky})
+		assert.Contains(t, buf.String(), "no flaky coverage found in 2 runs")
+	})
+}
+
+func TestNewFlakyCoverage(t *testing.T) {
+	t.Run("not enough cover profiles", func(t *testing.T) {
+		_, err := NewFlakyCoverage(&FlakyOption{CoverProfiles: []string{"coverage.out"}})
+		assert.ErrorIs(t, err, ErrNotEnoughCoverProfiles)
+	})
+}
@@ -2,19 +2,32 @@ package gocover
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"go/build"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/compression"
 	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/gate"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/Azure/gocover/pkg/ownership"
 	"github.com/Azure/gocover/pkg/parser"
 	"github.com/Azure/gocover/pkg/report"
+	"github.com/Azure/gocover/pkg/webhook"
 	"github.com/sirupsen/logrus"
 )
 
 func NewFullCover(o *FullOption) (GoCover, error) {
+	return newFullCover(o)
+}
+
+func newFullCover(o *FullOption) (*fullCover, error) {
 	var (
 		dbClient dbclient.DbClient
 		err      error
@@ -33,7 +46,7 @@ func NewFullCover(o *FullOption) (GoCover, error) {
 		}
 	}
 
-	repositoryAbsPath, err := filepath.Abs(o.RepositoryPath)
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
 	if err != nil {
 		return nil, fmt.Errorf("get absolute path of repo: %w", err)
 	}
@@ -43,20 +56,165 @@ func NewFullCover(o *FullOption) (GoCover, error) {
 		return nil, fmt.Errorf("parse go module path: %w", err)
 	}
 
+	moduleRoot, err := newModuleRoot(o.RepoRoot, o.ModuleDir)
+	if err != nil {
+		return nil, err
+	}
+
 	logger.Debugf("repository path: %s, module path: %s, output dir: %s, exclude patterns: %s",
 		repositoryAbsPath, modulePath, o.OutputDir, o.Excludes)
 
+	anonymizer, err := report.NewAnonymizer(o.Anonymize)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeFuncs, err := compileFunctionPatterns(o.ExcludeFunctions)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := loadCoverIgnore(repositoryAbsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := compression.Parse(o.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	expression, err := parseGate(o.Gate)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := loadOwnership(o.Ownership)
+	if err != nil {
+		return nil, err
+	}
+
+	tests, err := loadTestResults(o.TestOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	overlay, err := parser.LoadOverlay(o.Overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	ignorePolicy, err := parseIgnorePolicy(o.IgnorePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	mixedModes, err := parser.ParseMixedModes(o.MixedCoverModes)
+	if err != nil {
+		return nil, err
+	}
+
+	formats, err := parseReportFormats(o.ReportFormats)
+	if err != nil {
+		return nil, err
+	}
+
+	coverFilenames, labeled := parseCoverProfiles(o.CoverProfiles)
+
+	attestationKey, provenance, err := newProvenance(o.AttestationKey, o.ToolVersion, repositoryAbsPath, coverFilenames)
+	if err != nil {
+		return nil, err
+	}
+
+	closedIssues, err := newIssueChecker(o.ClosedIssues, o.GitHubOption, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := newCommitStatus(o.CommitStatus, o.StatusContext, o.DetailsURL, o.GitHubOption, o.CI, repositoryAbsPath, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := webhook.NewClient(o.WebhookOption, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	reportGenerator := newReportGenerator(&reportOption{
+		formats:          formats,
+		templates:        o.Templates,
+		style:            o.Style,
+		outputDir:        o.OutputDir,
+		reportName:       o.ReportName,
+		treemap:          o.Treemap,
+		trend:            o.TrendRuns > 0,
+		junit:            o.JUnit,
+		lines:            o.LinesReport,
+		directoryTree:    o.DirectoryTree,
+		teamcity:         o.TeamCity,
+		summaryLine:      o.SummaryLine,
+		azureDevOpsDir:   o.AzureDevOpsDir,
+		noStepSummary:    o.NoStepSummary,
+		coverageBaseline: o.CoverageFloor,
+		modulePath:       modulePath,
+		moduleDir:        o.ModuleDir,
+		compression:      algorithm,
+		attestationKey:   attestationKey,
+		provenance:       provenance,
+	}, o.Logger)
+
 	return &fullCover{
-		coverFilenames:  o.CoverProfiles,
-		modulePath:      modulePath,
-		repositoryPath:  repositoryAbsPath,
-		excludeFiles:    make(excludeFileCache),
-		excludePatterns: o.Excludes,
-		moduleDir:       o.ModuleDir,
-		coverageTree:    report.NewCoverageTree(modulePath),
-		logger:          logger,
-		dbClient:        dbClient,
-		reportGenerator: report.NewReportGenerator(o.Style, o.OutputDir, o.ReportName, o.Logger),
+		coverFilenames:    coverFilenames,
+		labeledProfiles:   labeled,
+		modulePath:        modulePath,
+		repositoryPath:    repositoryAbsPath,
+		excludeFiles:      make(excludeFileCache),
+		excludePatterns:   o.Excludes,
+		coverIgnore:       ignore,
+		excludeFuncs:      excludeFuncs,
+		excludeTags:       o.ExcludeBuildTags,
+		includePackages:   o.IncludePackages,
+		skipPackages:      o.SkipPackages,
+		moduleDir:         o.ModuleDir,
+		moduleRoot:        moduleRoot,
+		coverageTree:      report.NewCoverageTree(modulePath),
+		topUncovered:      o.TopUncovered,
+		topHot:            o.TopHot,
+		foldClosures:      o.FoldClosures,
+		linesReport:       o.LinesReport || hasReportFormat(formats, CoberturaReportFormat) || o.AzureDevOpsDir != "",
+		legacyOutput:      hasReportFormat(formats, LegacyReportFormat),
+		directoryTree:     o.DirectoryTree,
+		cacheDir:          o.CacheDir,
+		compression:       algorithm,
+		historyDir:        o.HistoryDir,
+		neverCovered:      o.NeverCoveredRuns,
+		baseRef:           o.BaseRef,
+		trendRuns:         o.TrendRuns,
+		trendPackages:     o.TrendPackages,
+		coverageFloor:     o.CoverageFloor,
+		gate:              expression,
+		ownership:         owners,
+		tests:             tests,
+		overlay:           overlay,
+		skipUnresolved:    o.SkipUnresolved,
+		continueOnError:   o.ContinueOnError,
+		failOnFileError:   !o.IgnoreFileErrors,
+		progress:          o.Progress,
+		memoryLimit:       o.MemoryLimit,
+		ignorePolicy:      ignorePolicy,
+		mixedModes:        mixedModes,
+		compatibleMarkers: o.CompatibleMarkers,
+		closedIssues:      closedIssues,
+		metrics:           &coverageMetrics{modulePath: modulePath},
+		anonymizer:        anonymizer,
+		ci:                o.CI,
+		runLabels:         o.RunLabels,
+		commitStatus:      status,
+		webhooks:          webhooks,
+		logger:            logger,
+		dbClient:          dbClient,
+		reportGenerator:   reportGenerator,
 	}, nil
 
 }
@@ -65,26 +223,77 @@ var _ GoCover = (*fullCover)(nil)
 
 // diffCoverage implements the GoCover interface and generate the full coverage statistics.
 type fullCover struct {
-	coverFilenames  []string
-	moduleDir       string
-	modulePath      string
-	repositoryPath  string
-	excludePatterns []string
-	ignoreProfiles  []*annotation.IgnoreProfile
-	excludeFiles    excludeFileCache
-	coverageTree    report.CoverageTree
-	reportGenerator report.ReportGenerator
-	dbClient        dbclient.DbClient
+	coverFilenames    []string
+	labeledProfiles   *labeledProfiles
+	moduleDir         string
+	moduleRoot        string
+	modulePath        string
+	repositoryPath    string
+	excludePatterns   []string
+	coverIgnore       *coverIgnore
+	excludeFuncs      []*regexp.Regexp
+	excludeTags       []string
+	includePackages   []string
+	skipPackages      []string
+	ignoreProfiles    []*annotation.IgnoreProfile
+	excludeFiles      excludeFileCache
+	coverageTree      report.CoverageTree
+	topUncovered      int // number of the least covered functions to report
+	topHot            int // number of the most frequently executed functions and statements to report
+	functions         []*report.FunctionCoverage
+	historyDir        string // directory of the history store, history is disabled if it's empty
+	neverCovered      int    // number of the latest runs to check for never covered functions
+	baseRef           string // the ref whose merge base with HEAD is compared with, disabled if it's empty
+	trendRuns         int    // number of the latest runs to chart the coverage trend, disabled if it's zero
+	trendPackages     []string
+	foldClosures      bool   // fold the function literals into their enclosing functions
+	linesReport       bool   // collect the state of each line for the per-line report
+	legacyOutput      bool   // keep the log lines of the earlier releases, refer to LegacyReportFormat
+	directoryTree     bool   // aggregate the coverage by directory
+	cacheDir          string // directory of the conversion cache, cache is disabled if it's empty
+	coverageFloor     float64
+	ownership         *ownership.Ownership
+	tests             *testResults
+	overlay           *parser.Overlay
+	skipUnresolved    bool             // skip the files of the cover profiles that cannot be resolved
+	continueOnError   bool             // convert the rest of the files when a file fails
+	failOnFileError   bool             // fail the run on the errors of the files
+	gate              *gate.Expression // replaces the coverage floor, it's nil if it's not set
+	metrics           *coverageMetrics // the metrics of the gate, which are shared with diff coverage in all coverage mode
+	compression       compression.Algorithm
+	progress          parser.ProgressFunc
+	memoryLimit       int
+	ignorePolicy      IgnorePolicy
+	mixedModes        parser.MixedModes
+	compatibleMarkers bool
+	closedIssues      *issueChecker
+	ci                *ci.Environment
+	runLabels         map[string]string
+	commitStatus      *commitStatus      // sets the github commit status, it's nil if it's disabled
+	webhooks          *webhook.Client    // posts the json result to the webhooks, it's nil if there are no webhooks
+	anonymizer        *report.Anonymizer // hides the names in the report, it's nil if anonymization is disabled
+	reportGenerator   report.ReportGenerator
+	dbClient          dbclient.DbClient
 
 	logger logrus.FieldLogger
 }
 
 func (full *fullCover) Run(ctx context.Context) error {
 
-	statistics, err := full.generateStatistics()
+	statistics, err := full.generateStatistics(ctx)
 	if err != nil {
 		return fmt.Errorf("full: %w", err)
 	}
+	statistics.CI = full.ci
+	statistics.RunLabels = full.runLabels
+	statistics.Teams = teamCoverage(statistics.CoverageProfile, full.ownership, full.modulePath)
+	full.metrics.full = newCoverageSnapshot(statistics)
+
+	if err := full.history(ctx, statistics); err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+
+	full.anonymizer.Anonymize(statistics)
 
 	if err := full.reportGenerator.GenerateReport(statistics); err != nil {
 		return fmt.Errorf("generate report: %w", err)
@@ -94,18 +303,156 @@ func (full *fullCover) Run(ctx context.Context) error {
 		return fmt.Errorf("%w", err)
 	}
 
+	// the status is set even if the coverage requirement is not met, the exit code of the requirement is kept.
+	passErr := errors.Join(full.pass(statistics), full.closedIssues.check(ctx, full.ignoreProfiles))
+	if err := full.commitStatus.publish(ctx, statistics, passErr); err != nil {
+		return errors.Join(passErr, fmt.Errorf("publish commit status: %w", err))
+	}
+	if err := full.webhooks.Publish(ctx, string(statistics.StatisticsType), report.NewJSONReport(statistics)); err != nil {
+		return errors.Join(passErr, fmt.Errorf("publish webhooks: %w", err))
+	}
+	if passErr != nil {
+		return fmt.Errorf("%w", passErr)
+	}
+
+	return nil
+}
+
+// pass checks the coverage requirement and the budgets of the teams.
+func (full *fullCover) pass(statistics *report.Statistics) error {
+	return errors.Join(full.requirement(statistics), checkTeamBudgets(statistics.Teams),
+		checkFileErrors(statistics.FileErrors, full.failOnFileError))
+}
+
+func (full *fullCover) requirement(statistics *report.Statistics) error {
+	if full.gate != nil {
+		return evaluateGate(full.gate, full.metrics, full.logger)
+	}
+	if full.coverageFloor > 0 && statistics.TotalCoveragePercent < full.coverageFloor {
+		return WrapErrorWithCode(
+			fmt.Errorf("the full coverage floor is %.2f, currently is %.2f",
+				full.coverageFloor,
+				statistics.TotalCoveragePercent,
+			),
+			LowCoverageErrorExitCode,
+			"",
+		)
+	}
 	return nil
 }
 
+// history compares the result of current run with the stored result of the base commit,
+// appends it to the history store, charts the coverage trend of the latest runs, and checks the functions that
+// have no coverage in the latest runs.
+func (full *fullCover) history(ctx context.Context, statistics *report.Statistics) error {
+	if full.historyDir == "" {
+		return nil
+	}
+
+	store, err := history.NewFileStore(full.historyDir, full.compression)
+	if err != nil {
+		return fmt.Errorf("history store: %w", err)
+	}
+
+	if full.baseRef != "" {
+		if statistics.CoverageDelta, err = full.coverageDelta(ctx, store, statistics); err != nil {
+			return fmt.Errorf("coverage delta: %w", err)
+		}
+	}
+
+	record := newHistoryRecord(full.repositoryPath, full.modulePath, FullCoverage, statistics, full.functions, full.ci, full.logger)
+	if err := store.Append(ctx, record); err != nil {
+		return fmt.Errorf("append history record: %w", err)
+	}
+
+	if full.trendRuns > 0 {
+		if statistics.Trend, err = full.coverageTrend(ctx, store, record.Branch); err != nil {
+			return fmt.Errorf("coverage trend: %w", err)
+		}
+	}
+
+	if full.neverCovered <= 0 {
+		return nil
+	}
+
+	records, err := store.List(ctx, &history.Query{
+		ModulePath:   full.modulePath,
+		CoverageMode: string(FullCoverage),
+		Limit:        full.neverCovered,
+	})
+	if err != nil {
+		return fmt.Errorf("list history records: %w", err)
+	}
+	if len(records) < full.neverCovered {
+		full.logger.Infof("%d runs in history, never covered functions require %d runs", len(records), full.neverCovered)
+		return nil
+	}
+
+	statistics.NeverCoveredFunctions = neverCoveredFunctions(records)
+	statistics.NeverCoveredRuns = full.neverCovered
+	return nil
+}
+
+// coverageDelta finds the latest full coverage record of the merge base of HEAD and the base ref,
+// and compares current run with it. It returns nil if the base commit has no record in history.
+func (full *fullCover) coverageDelta(ctx context.Context, store history.Store, statistics *report.Statistics) (*report.CoverageDelta, error) {
+	gitClient, err := gittool.NewGitClient(full.repositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("git repository: %w", err)
+	}
+	baseCommit, err := gitClient.MergeBase(full.baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("base commit: %w", err)
+	}
+
+	records, err := store.List(ctx, &history.Query{
+		ModulePath:   full.modulePath,
+		CoverageMode: string(FullCoverage),
+		Commit:       baseCommit,
+		Limit:        1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list history records: %w", err)
+	}
+	if len(records) == 0 {
+		full.logger.Warnf("no full coverage record of base commit %s in history, skip the coverage delta", baseCommit)
+		return nil, nil
+	}
+	renames, err := gitClient.Renames(ctx, baseCommit)
+	if err != nil {
+		return nil, fmt.Errorf("renames since base commit: %w", err)
+	}
+
+	delta := coverageDelta(records[0], full.functions, statistics.TotalCoveragePercent, renamedFiles(renames, full.moduleDir, full.modulePath))
+	full.logger.Infof("coverage delta compared with %s: %+.2f%%, %d packages and %d files regressed",
+		baseCommit, delta.CoveragePercentDelta, len(delta.RegressedPackages), len(delta.RegressedFiles))
+	return delta, nil
+}
+
+// coverageTrend lists the latest full coverage records of the branch, which include current run.
+func (full *fullCover) coverageTrend(ctx context.Context, store history.Store, branch string) (*report.CoverageTrend, error) {
+	records, err := store.List(ctx, &history.Query{
+		ModulePath:   full.modulePath,
+		CoverageMode: string(FullCoverage),
+		Branch:       branch,
+		Limit:        full.trendRuns,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list history records: %w", err)
+	}
+	full.logger.Debugf("chart the coverage trend of %d runs", len(records))
+	return coverageTrend(records, full.trendPackages)
+}
+
 func (full *fullCover) dump(ctx context.Context) error {
 	all := full.coverageTree.All()
 
 	if full.dbClient != nil {
-		err := storeCoverageData(ctx, full.dbClient, all, FullCoverage, full.modulePath)
+		err := storeCoverageData(ctx, full.dbClient, all, FullCoverage, full.modulePath, full.ci, full.runLabels)
 		if err != nil {
 			return fmt.Errorf("store coverage data: %w", err)
 		}
-		err = storeIgnoreProfileData(ctx, full.dbClient, full.ignoreProfiles, FullCoverage, full.modulePath, full.repositoryPath, full.moduleDir)
+		err = storeIgnoreProfileData(ctx, full.dbClient, full.ignoreProfiles, FullCoverage, full.modulePath, full.repositoryPath, full.moduleDir, full.ci, full.runLabels)
 		if err != nil {
 			return fmt.Errorf("store ignore profile data: %w", err)
 		}
@@ -115,32 +462,85 @@ func (full *fullCover) dump(ctx context.Context) error {
 	return nil
 }
 
-func (full *fullCover) generateStatistics() (*report.Statistics, error) {
-	packages, err := parser.NewParser(full.coverFilenames, full.logger).Parse(nil)
+// newParser returns the parser of the cover profiles that resolves the files in the way of the options.
+func (full *fullCover) newParser(coverProfiles []string, logger logrus.FieldLogger) *parser.Parser {
+	return parser.NewParser(coverProfiles, logger).
+		WithModuleRoot(full.moduleRoot, full.modulePath).
+		WithOverlay(full.overlay).
+		WithSkipUnresolved(full.skipUnresolved).
+		WithContinueOnError(full.continueOnError).
+		WithMemoryLimit(full.memoryLimit).
+		WithMixedModes(full.mixedModes).
+		WithCompatibleMarkers(full.compatibleMarkers)
+}
+
+func (full *fullCover) generateStatistics(ctx context.Context) (*report.Statistics, error) {
+	p := full.newParser(full.coverFilenames, full.logger).
+		WithCacheDir(full.cacheDir).
+		WithExcludeFunctions(full.excludeFuncs).
+		WithExcludeBuildTags(full.excludeTags).
+		WithPackages(full.includePackages, full.skipPackages).
+		WithProgress(full.progress)
+	start := time.Now()
+	packages, err := p.Parse(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	parseDuration := time.Since(start)
+	applyIgnorePolicy(packages, full.ignorePolicy)
+
+	labels, err := newLabelCoverage(ctx, full.labeledProfiles, nil, full.newParser, full.logger)
 	if err != nil {
 		return nil, err
 	}
 
 	statistics := &report.Statistics{
 		StatisticsType: report.FullStatisticsType,
+		IgnorePolicy:   string(full.ignorePolicy),
+		Packages:       packages,
+		SkippedFiles:   unresolvedFiles(p.UnresolvedFiles()),
+		FileErrors:     fileErrors(p.FileErrors()),
 	}
 	m := make(map[string]*report.CoverageProfile)
 	fileCache := make(fileContentsCache)
+	ranking := newFunctionRanking(full.topUncovered)
+	hot := newHotPaths(full.topHot)
+	closures := newClosureCoverage(full.foldClosures)
+	lines := newLineCollector(full.linesReport)
 	for _, pkg := range packages {
 		full.logger.Debugf("package: %s", pkg.Name)
 		full.ignoreProfiles = append(full.ignoreProfiles, pkg.IgnoreProfiles...)
 
-		p, err := build.Import(pkg.Name, ".", build.FindOnly)
+		root, err := packageRoot(full.moduleRoot, pkg.Name)
 		if err != nil {
 			return nil, fmt.Errorf("build import %w", err)
 		}
 
+		for _, f := range pkg.SkippedFiles {
+			statistics.SkippedFiles = append(statistics.SkippedFiles, &report.SkippedFile{
+				FileName: formatFilePath(root, f.File, full.modulePath),
+				Reason:   f.Reason,
+			})
+		}
+
+		for _, f := range pkg.ExcludedFunctions {
+			fileName := formatFilePath(root, f.Function.File, full.modulePath)
+			if inExcludedFiles(full.excludeFiles, full.excludePatterns, full.coverIgnore, f.Function.File, fileName, full.logger) {
+				continue
+			}
+			if excluded := newExcludedFunction(fileName, f, false); excluded != nil {
+				statistics.ExcludedFunctions = append(statistics.ExcludedFunctions, excluded)
+			}
+		}
+
 		for _, fun := range pkg.Functions {
 
-			if ok := inExclueds(
+			if ok := inExcludedFiles(
 				full.excludeFiles,
 				full.excludePatterns,
-				formatFilePath(p.Root, fun.File, full.modulePath),
+				full.coverIgnore,
+				fun.File,
+				formatFilePath(root, fun.File, full.modulePath),
 				full.logger,
 			); ok {
 				continue
@@ -150,13 +550,13 @@ func (full *fullCover) generateStatistics() (*report.Statistics, error) {
 			coverProfile, ok := m[fun.File]
 			if !ok {
 				coverProfile = &report.CoverageProfile{
-					FileName: formatFilePath(p.Root, fun.File, full.modulePath),
+					FileName: formatFilePath(root, fun.File, full.modulePath),
 				}
 				m[fun.File] = coverProfile
 				statistics.CoverageProfile = append(statistics.CoverageProfile, coverProfile)
 			}
 
-			fileContents, err := findFileContents(fileCache, fun.File)
+			fileContents, err := findFileContents(fileCache, full.overlay.Source(fun.File))
 			if err != nil {
 				return nil, fmt.Errorf("find file contents: %w", err)
 			}
@@ -170,13 +570,17 @@ func (full *fullCover) generateStatistics() (*report.Statistics, error) {
 				section.Contents = append(section.Contents, fileContents[i-1])
 			}
 
-			node := full.coverageTree.FindOrCreate(strings.TrimPrefix(fun.File, p.Root))
+			node := full.coverageTree.FindOrCreate(strings.TrimPrefix(fun.File, root))
 
 			var total, ignored, covered, coveredButIgnored int
+			var partial []int
 			violated := false
+			counter := labels.newCounter(coverProfile.FileName, fun.Name)
 			for _, st := range fun.Statements {
 				total += 1
 				node.TotalLines += 1
+				labels.count(counter, fun.File, st)
+				lines.add(coverProfile.FileName, st, isCountMode(pkg.CoverMode))
 
 				if st.Mode == parser.Ignore && st.Reached > 0 {
 					coveredButIgnored++
@@ -190,6 +594,9 @@ func (full *fullCover) generateStatistics() (*report.Statistics, error) {
 				if st.Reached > 0 {
 					node.TotalCoveredLines += 1
 					covered++
+					if st.Partial && st.Mode == parser.Keep {
+						partial = append(partial, st.StartLine)
+					}
 				} else {
 					section.ViolationLines = append(section.ViolationLines, st.StartLine)
 					violated = true
@@ -198,6 +605,11 @@ func (full *fullCover) generateStatistics() (*report.Statistics, error) {
 			}
 
 			node.TotalEffectiveLines = node.TotalLines - node.TotalIgnoredLines
+			labels.add(counter)
+			functionCoverage := newFunctionCoverage(coverProfile.FileName, fun, total-ignored, covered-coveredButIgnored)
+			functionCoverage.Hits = newHitCounts(pkg.CoverMode, fun.Statements, false)
+			hot.addStatements(coverProfile.FileName, fun, pkg.CoverMode, false)
+			closures.add(fun, functionCoverage)
 
 			coverProfile.TotalLines += total
 			coverProfile.CoveredLines += covered
@@ -205,6 +617,7 @@ func (full *fullCover) generateStatistics() (*report.Statistics, error) {
 			coverProfile.TotalEffectiveLines += (total - ignored)
 			coverProfile.TotalIgnoredLines += ignored
 			coverProfile.TotalViolationLines = append(coverProfile.TotalViolationLines, section.ViolationLines...)
+			coverProfile.PartialCoveredLines = append(coverProfile.PartialCoveredLines, partial...)
 			if violated {
 				coverProfile.ViolationSections = append(coverProfile.ViolationSections, section)
 			}
@@ -213,8 +626,32 @@ func (full *fullCover) generateStatistics() (*report.Statistics, error) {
 	}
 
 	full.coverageTree.CollectCoverageData()
+	statistics.RunSummary = newRunSummary(packages, len(statistics.SkippedFiles), parseDuration)
+	if !full.legacyOutput {
+		full.logger.Infof("run summary: %s", statistics.RunSummary)
+	}
+
+	full.functions = closures.all()
+	for _, f := range full.functions {
+		ranking.add(f)
+		hot.addFunction(f)
+	}
 
 	reBuildStatistics(statistics, full.excludeFiles)
+	if !full.legacyOutput {
+		full.logger.Infof("full coverage: %.2f%%, raw coverage: %.2f%%, the ignore annotations contribute %+.2f%%",
+			statistics.TotalCoveragePercent, statistics.TotalCoverageWithoutIgnore, statistics.IgnoreContribution())
+	}
+	statistics.LabelStatistics = labels.statistics(statistics)
+	statistics.LabelGaps = labels.labelGaps()
+	statistics.ClosureStatistics = closures.statistics()
+	statistics.LeastCoveredFunctions = ranking.top()
+	statistics.HotFunctions, statistics.HotStatements = hot.top()
+	statistics.TestResults = full.tests.statistics(full.functions)
+	statistics.Lines = lines.lines()
+	if full.directoryTree {
+		statistics.DirectoryTree = report.NewDirectoryTree(statistics.CoverageProfile)
+	}
 
 	return statistics, nil
 }
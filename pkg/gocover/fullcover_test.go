@@ -0,0 +1,32 @@
+package gocover
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullCoverPass(t *testing.T) {
+	t.Run("coverage floor disabled", func(t *testing.T) {
+		full := &fullCover{}
+		assert.NoError(t, full.pass(&report.Statistics{TotalCoveragePercent: 10}))
+	})
+
+	t.Run("coverage higher than floor", func(t *testing.T) {
+		full := &fullCover{coverageFloor: 60}
+		assert.NoError(t, full.pass(&report.Statistics{TotalCoveragePercent: 60}))
+	})
+
+	t.Run("coverage lower than floor", func(t *testing.T) {
+		full := &fullCover{coverageFloor: 60}
+		err := full.pass(&report.Statistics{TotalCoveragePercent: 59.5})
+
+		var e *GoCoverError
+		if !errors.As(err, &e) {
+			t.Fatalf("expect GoCoverError, but get %v", err)
+		}
+		assert.Equal(t, LowCoverageErrorExitCode, e.ExitCode)
+	})
+}
@@ -3,26 +3,49 @@ package gocover
 import (
 	"bufio"
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
+	"go/build"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/compression"
 	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
 	"github.com/Azure/gocover/pkg/report"
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/mod/modfile"
 )
 
+// the formats of the coverage report.
 const (
-	DefaultReportFormat     = "html"
+	HTMLReportFormat      = "html"
+	JSONReportFormat      = "json"
+	MarkdownReportFormat  = "markdown"
+	CoberturaReportFormat = "cobertura"
+	// LegacyReportFormat keeps the output of the earlier releases for the pipelines that scrape it,
+	// the html report is generated and the log lines added since then are not printed.
+	LegacyReportFormat = "legacy"
+)
+
+const (
+	DefaultReportFormat     = HTMLReportFormat
 	DefaultCompareBranch    = "origin/master"
+	DefaultFetchRemote      = "origin"
 	DefaultCoverageBaseline = 80.0
+	DefaultTestPackages     = "./..."
+
+	// githubStepSummaryEnv is the environment variable of the job summary file in GitHub Actions.
+	githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
 )
 
 // excludeFileCache cache contains exclude file
@@ -48,6 +71,39 @@ func inExclueds(cache excludeFileCache, excludesPattern []string, fileName strin
 	return false
 }
 
+// compileFunctionPatterns compiles the regular expressions of the excluded function names.
+func compileFunctionPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("exclude function pattern %q: %w", p, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// newExcludedFunction returns the excluded function with the lines that don't count for coverage,
+// only the changed lines are counted if changedOnly is true. It returns nil if there is no such line.
+func newExcludedFunction(fileName string, f *parser.ExcludedFunction, changedOnly bool) *report.ExcludedFunction {
+	lines := 0
+	for _, st := range f.Function.Statements {
+		if !changedOnly || st.State == parser.Changed {
+			lines++
+		}
+	}
+	if changedOnly && lines == 0 {
+		return nil
+	}
+	return &report.ExcludedFunction{
+		FileName:   fileName,
+		Function:   f.Function.Name,
+		Pattern:    f.Pattern,
+		TotalLines: lines,
+	}
+}
+
 // calculateCoverage calculate coverage proportion
 func calculateCoverage(covered int64, effectived int64) float64 {
 	if effectived == 0 {
@@ -64,6 +120,9 @@ func reBuildStatistics(s *report.Statistics, cache excludeFileCache) {
 		s.TotalIgnoredLines += p.TotalIgnoredLines
 		s.TotalCoveredLines += p.CoveredLines
 		s.TotalCoveredButIgnoredLines += p.CoveredButIgnoredLines
+		s.TotalWeakCoveredLines += len(p.WeakCoveredLines)
+		s.TotalPartialCoveredLines += len(p.PartialCoveredLines)
+		s.TotalMovedLines += len(p.MovedLines)
 	}
 
 	s.TotalCoveragePercent = calculateCoverage(
@@ -80,6 +139,252 @@ func reBuildStatistics(s *report.Statistics, cache excludeFileCache) {
 	}
 }
 
+// reportOption contains the input to create the report generators.
+type reportOption struct {
+	// formats are the formats of the coverage report, it's html if it's empty.
+	formats []string
+	// templates are the files of the custom templates that the reports are generated from.
+	templates        []string
+	style            string
+	outputDir        string
+	reportName       string
+	treemap          bool
+	trend            bool
+	junit            bool
+	lines            bool
+	teamcity         bool
+	summaryLine      bool
+	azureDevOpsDir   string
+	directoryTree    bool
+	annotatedDiff    bool
+	noStepSummary    bool
+	coverageBaseline float64
+	// compression compresses the json report.
+	compression compression.Algorithm
+	// modulePath and moduleDir map the files of the reports to the files of the repository.
+	modulePath string
+	moduleDir  string
+	// attestationKey signs the attestation of the reports, the attestation is not generated when it's nil.
+	attestationKey ed25519.PrivateKey
+	provenance     *report.Provenance
+}
+
+// newReportGenerator creates the report generator of each format, all of them are generated from the same statistics,
+// and the custom template, treemap, trend chart, junit, per-line, azure devops, summary line, teamcity, directory tree or annotated diff report generator if it's enabled.
+// When it runs in GitHub Actions, the markdown report is written to the job summary unless it's disabled.
+func newReportGenerator(o *reportOption, logger logrus.FieldLogger) report.ReportGenerator {
+	formats := o.formats
+	if len(formats) == 0 {
+		formats = []string{DefaultReportFormat}
+	}
+
+	var generators []report.ReportGenerator
+	for _, format := range formats {
+		switch format {
+		case HTMLReportFormat:
+			generators = append(generators, report.NewReportGenerator(o.style, o.outputDir, o.reportName, logger))
+		case JSONReportFormat:
+			generators = append(generators, report.NewJSONReportGenerator(o.outputDir, o.reportName, o.compression, logger))
+		case MarkdownReportFormat:
+			generators = append(generators, report.NewMarkdownReportGenerator(o.outputDir, o.reportName, logger))
+		case CoberturaReportFormat:
+			generators = append(generators, report.NewCoberturaReportGenerator(o.outputDir, o.reportName, logger))
+		case LegacyReportFormat:
+			// the earlier releases generate the html report only, which logs the report file.
+			if !hasReportFormat(formats, HTMLReportFormat) {
+				generators = append(generators, report.NewReportGenerator(o.style, o.outputDir, o.reportName, logger))
+			}
+		}
+	}
+	if len(o.templates) != 0 {
+		generators = append(generators, report.NewCustomReportGenerator(o.outputDir, o.templates, logger))
+	}
+	if o.treemap {
+		generators = append(generators, report.NewTreemapReportGenerator(o.outputDir, o.reportName, logger))
+	}
+	if o.trend {
+		generators = append(generators, report.NewTrendReportGenerator(o.outputDir, o.reportName, logger))
+	}
+	if o.junit {
+		generators = append(generators, report.NewJUnitReportGenerator(o.outputDir, o.reportName, o.coverageBaseline, logger))
+	}
+	if o.lines {
+		generators = append(generators, report.NewLinesReportGenerator(o.outputDir, o.reportName, logger))
+	}
+	if o.teamcity {
+		generators = append(generators, report.NewTeamCityReportGenerator(os.Stdout, o.modulePath, o.moduleDir))
+	}
+	if o.azureDevOpsDir != "" {
+		generators = append(generators, report.NewAzureDevOpsReportGenerator(o.style, o.azureDevOpsDir, logger))
+	}
+	if o.summaryLine {
+		generators = append(generators, report.NewSummaryLineReportGenerator(os.Stdout))
+	}
+	if o.directoryTree {
+		generators = append(generators, report.NewDirectoryTreeReportGenerator(os.Stdout))
+	}
+	if o.annotatedDiff {
+		generators = append(generators, report.NewAnnotatedDiffReportGenerator(os.Stdout))
+	}
+	if summaryFile := os.Getenv(githubStepSummaryEnv); summaryFile != "" && !o.noStepSummary {
+		generators = append(generators, report.NewStepSummaryReportGenerator(summaryFile, logger))
+	}
+	// the attestation is generated at last, as the reports are the subjects of the attestation.
+	if o.attestationKey != nil {
+		generators = append(generators, report.NewAttestationReportGenerator(o.outputDir, o.reportName, o.provenance, o.attestationKey, logger))
+	}
+
+	if len(generators) == 1 {
+		return generators[0]
+	}
+	return report.NewReportGenerators(generators...)
+}
+
+// parseReportFormats validates the report formats and removes the duplicated ones, the order is kept.
+func parseReportFormats(formats []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, f := range formats {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch f {
+		case "":
+			continue
+		case HTMLReportFormat, JSONReportFormat, MarkdownReportFormat, CoberturaReportFormat, LegacyReportFormat:
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownReportFormat, f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// hasReportFormat checks whether the format is one of the report formats.
+func hasReportFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// newProvenance loads the key that signs the attestation of the reports, and collects the provenance of the run.
+// It returns nil key when the key file is empty, which means the attestation is not generated.
+func newProvenance(keyFile string, toolVersion string, repositoryPath string, coverProfiles []string) (ed25519.PrivateKey, *report.Provenance, error) {
+	if keyFile == "" {
+		return nil, nil, nil
+	}
+
+	key, err := report.LoadSigningKey(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provenance := &report.Provenance{
+		ToolVersion:   toolVersion,
+		CoverProfiles: coverProfiles,
+	}
+	if gitClient, err := gittool.NewGitClient(repositoryPath); err == nil {
+		if commit, _, err := gitClient.HeadCommit(); err == nil {
+			provenance.Commit = commit
+		}
+	}
+	return key, provenance, nil
+}
+
+// realAbsPath returns the absolute path with the symlinks resolved, the same as the file names of the parser.
+func realAbsPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return gittool.RealPath(abs), nil
+}
+
+// newModuleRoot returns the directory of the module under the repository root with the symlinks resolved,
+// the files are resolved in it by the module path. It returns empty if the repository root is not set.
+func newModuleRoot(repoRoot, moduleDir string) (string, error) {
+	if repoRoot == "" {
+		return "", nil
+	}
+	root, err := realAbsPath(repoRoot)
+	if err != nil {
+		return "", fmt.Errorf("get absolute path of repo root: %w", err)
+	}
+	return filepath.Join(root, moduleDir), nil
+}
+
+// packageRoot returns the root of the package with the symlinks resolved, which is trimmed from the file names of the parser.
+// It's the module root if it's set, otherwise the package is imported by go/build.
+func packageRoot(moduleRoot, importPath string) (string, error) {
+	if moduleRoot != "" {
+		return moduleRoot, nil
+	}
+	p, err := build.Import(importPath, ".", build.FindOnly)
+	if err != nil {
+		return "", err
+	}
+	return gittool.RealPath(p.Root), nil
+}
+
+// unresolvedFiles returns the skipped files of the files that cannot be resolved,
+// which are named in the cover profiles with the module path already.
+func unresolvedFiles(files []*parser.SkippedFile) []*report.SkippedFile {
+	var result []*report.SkippedFile
+	for _, f := range files {
+		result = append(result, &report.SkippedFile{FileName: f.File, Reason: f.Reason})
+	}
+	return result
+}
+
+// fileErrors returns the errors of the files that fail to convert for the report.
+func fileErrors(errs []*parser.FileError) []*report.FileError {
+	var result []*report.FileError
+	for _, e := range errs {
+		result = append(result, &report.FileError{FileName: e.File, Error: e.Err.Error()})
+	}
+	return result
+}
+
+// newRunSummary counts the files, functions and statements of the parsed packages for the run summary.
+func newRunSummary(packages parser.Packages, skippedFiles int, parseDuration time.Duration) *report.RunSummary {
+	summary := &report.RunSummary{SkippedFiles: skippedFiles, ParseDuration: parseDuration}
+	files := make(map[string]bool)
+	for _, pkg := range packages {
+		for _, fun := range pkg.Functions {
+			files[fun.File] = true
+			summary.Functions++
+			for _, st := range fun.Statements {
+				summary.Statements++
+				if st.Mode == parser.Ignore {
+					summary.IgnoredStatements++
+				}
+				if st.State == parser.Changed {
+					summary.ChangedStatements++
+				}
+			}
+		}
+	}
+	summary.Files = len(files)
+	return summary
+}
+
+// checkFileErrors returns the aggregated error of all the files that fail to convert,
+// it returns nil if there is none or the errors don't fail the run.
+func checkFileErrors(errs []*report.FileError, fail bool) error {
+	if len(errs) == 0 || !fail {
+		return nil
+	}
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, fmt.Sprintf("%s: %s", e.FileName, e.Error))
+	}
+	return fmt.Errorf("%d files fail to convert:\n%s", len(errs), strings.Join(messages, "\n"))
+}
+
 // formatFilePath format filename that strip root path and adds module path
 // fileNamePath is the absolute path of the file, modulePath is the module path of go module
 // for example:
@@ -96,7 +401,7 @@ func formatFilePath(rootRepoPath, fileNamePath, modulePath string) string {
 }
 
 // storeCoverageData send all coverage results to db store
-func storeCoverageData(ctx context.Context, dbClient dbclient.DbClient, all []*report.AllInformation, coverageMode CoverageMode, modulePath string) error {
+func storeCoverageData(ctx context.Context, dbClient dbclient.DbClient, all []*report.AllInformation, coverageMode CoverageMode, modulePath string, environment *ci.Environment, labels map[string]string) error {
 	now := time.Now().UTC()
 
 	var data []*dbclient.CoverageData
@@ -113,6 +418,8 @@ func storeCoverageData(ctx context.Context, dbClient dbclient.DbClient, all []*r
 			Coverage:               calculateCoverage(info.TotalCoveredLines, info.TotalLines),
 			CoverageWithIgnored:    calculateCoverage(info.TotalCoveredLines-info.TotalCoveredButIgnoreLines, info.TotalEffectiveLines),
 			CoverageMode:           string(coverageMode),
+			CI:                     environment,
+			Labels:                 labels,
 		}
 		data = append(data, d)
 	}
@@ -120,7 +427,7 @@ func storeCoverageData(ctx context.Context, dbClient dbclient.DbClient, all []*r
 	return dbClient.StoreCoverageDataFromFile(ctx, data)
 }
 
-func storeIgnoreProfileData(ctx context.Context, dbClient dbclient.DbClient, ignoreProfiles []*annotation.IgnoreProfile, coverageMode CoverageMode, modulePath string, repositoryPath string, moduleDir string) error {
+func storeIgnoreProfileData(ctx context.Context, dbClient dbclient.DbClient, ignoreProfiles []*annotation.IgnoreProfile, coverageMode CoverageMode, modulePath string, repositoryPath string, moduleDir string, environment *ci.Environment, labels map[string]string) error {
 	now := time.Now().UTC()
 
 	var data []*dbclient.IgnoreProfileData
@@ -135,27 +442,41 @@ func storeIgnoreProfileData(ctx context.Context, dbClient dbclient.DbClient, ign
 				IgnoreType:       string(profile.Type),
 				Comments:         profile.Comments,
 				Annotation:       profile.Annotation,
+				CI:               environment,
+				Labels:           labels,
 			}
 			data = append(data, d)
 
 			continue
 		}
 
-		for _, block := range profile.IgnoreBlocks {
+		addBlock := func(ignoreType annotation.IgnoreType, block *annotation.IgnoreBlock) {
 			d := &dbclient.IgnoreProfileData{
 				PreciseTimestamp: now,
 				FilePath:         formattedFilePath,
 				ModulePath:       modulePath,
-				IgnoreType:       string(profile.Type),
+				IgnoreType:       string(ignoreType),
 				LineNumber:       block.AnnotationLineNumber,
 				StartLine:        block.Lines[0],
 				EndLine:          block.Lines[len(block.Lines)-1],
 				Comments:         block.Comments,
 				Annotation:       block.Annotation,
 				Contents:         strings.Join(block.Contents, "\n"),
+				CI:               environment,
+				Labels:           labels,
 			}
 			data = append(data, d)
 		}
+		for _, block := range profile.IgnoreBlocks {
+			addBlock(profile.Type, block)
+		}
+		for _, block := range profile.IgnoreLines {
+			if block.AnnotationLineNumber == block.Lines[0] {
+				addBlock(annotation.LINE_IGNORE, block)
+			} else {
+				addBlock(annotation.NEXT_IGNORE, block)
+			}
+		}
 	}
 
 	return dbClient.StoreIgnoreProfileDataFromFile(ctx, data)
@@ -5,13 +5,19 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/gocover/pkg/ci"
 	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
 	"github.com/Azure/gocover/pkg/report"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCalculateCoverage(t *testing.T) {
@@ -75,6 +81,49 @@ func TestInExclueds(t *testing.T) {
 	})
 }
 
+func TestExcludedFunctions(t *testing.T) {
+	t.Run("compileFunctionPatterns", func(t *testing.T) {
+		patterns, err := compileFunctionPatterns([]string{`String$`, `^Must`})
+		if err != nil {
+			t.Errorf("should not return error: %s", err)
+		}
+		if len(patterns) != 2 || !patterns[1].MatchString("MustParse") {
+			t.Errorf("unexpected patterns %v", patterns)
+		}
+
+		if _, err := compileFunctionPatterns([]string{`(`}); err == nil {
+			t.Errorf("should return error for invalid pattern")
+		}
+	})
+
+	t.Run("newExcludedFunction", func(t *testing.T) {
+		f := &parser.ExcludedFunction{
+			Function: &parser.Function{
+				Name: "Foo.String",
+				Statements: []*parser.Statement{
+					{State: parser.Original},
+					{State: parser.Changed},
+				},
+			},
+			Pattern: `String$`,
+		}
+
+		full := newExcludedFunction("foo.go", f, false)
+		if full == nil || full.TotalLines != 2 || full.Function != "Foo.String" || full.Pattern != `String$` {
+			t.Errorf("unexpected excluded function %+v", full)
+		}
+		diff := newExcludedFunction("foo.go", f, true)
+		if diff == nil || diff.TotalLines != 1 {
+			t.Errorf("unexpected excluded function %+v", diff)
+		}
+
+		f.Function.Statements = f.Function.Statements[:1]
+		if excluded := newExcludedFunction("foo.go", f, true); excluded != nil {
+			t.Errorf("unchanged function should not be reported, but get %+v", excluded)
+		}
+	})
+}
+
 func TestFormatFilePath(t *testing.T) {
 	t.Run("formatFilePath", func(t *testing.T) {
 		testSuites := []struct {
@@ -97,12 +146,80 @@ func TestFormatFilePath(t *testing.T) {
 	})
 }
 
+func TestPackageRoot(t *testing.T) {
+	t.Run("module root", func(t *testing.T) {
+		repoRoot := t.TempDir()
+		moduleRoot, err := newModuleRoot(repoRoot, "./service")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(gittool.RealPath(repoRoot), "service"), moduleRoot)
+
+		root, err := packageRoot(moduleRoot, "github.com/foo/not/importable")
+		assert.NoError(t, err)
+		assert.Equal(t, moduleRoot, root)
+	})
+
+	t.Run("import the packages without module root", func(t *testing.T) {
+		moduleRoot, err := newModuleRoot("", "./")
+		assert.NoError(t, err)
+		assert.Empty(t, moduleRoot)
+
+		_, err = packageRoot(moduleRoot, "github.com/foo/not/importable")
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckFileErrors(t *testing.T) {
+	errs := []*report.FileError{
+		{FileName: "github.com/foo/bar/a.go", Error: "expected '}', found 'EOF'"},
+		{FileName: "github.com/foo/bar/b.go", Error: "invalid ignore annotation"},
+	}
+
+	err := checkFileErrors(errs, true)
+	assert.Error(t, err)
+	assert.Equal(t, "2 files fail to convert:\n"+
+		"github.com/foo/bar/a.go: expected '}', found 'EOF'\n"+
+		"github.com/foo/bar/b.go: invalid ignore annotation", err.Error())
+
+	assert.NoError(t, checkFileErrors(errs, false))
+	assert.NoError(t, checkFileErrors(nil, true))
+}
+
+func TestNewRunSummary(t *testing.T) {
+	packages := parser.Packages{
+		{
+			Name: "github.com/foo/bar",
+			Functions: []*parser.Function{
+				{File: "github.com/foo/bar/a.go", Statements: []*parser.Statement{
+					{State: parser.Changed, Mode: parser.Keep},
+					{State: parser.Original, Mode: parser.Ignore},
+				}},
+				{File: "github.com/foo/bar/a.go", Statements: []*parser.Statement{
+					{State: parser.Changed, Mode: parser.Ignore},
+				}},
+				{File: "github.com/foo/bar/b.go", Statements: []*parser.Statement{
+					{State: parser.Original, Mode: parser.Keep},
+				}},
+			},
+		},
+	}
+
+	assert.Equal(t, &report.RunSummary{
+		Files:             2,
+		Functions:         3,
+		Statements:        4,
+		IgnoredStatements: 2,
+		ChangedStatements: 2,
+		SkippedFiles:      1,
+		ParseDuration:     time.Second,
+	}, newRunSummary(packages, 1, time.Second))
+}
+
 func TestReBuildStatistics(t *testing.T) {
 	t.Run("reBuildStatistics", func(t *testing.T) {
 		s := &report.Statistics{
 			CoverageProfile: []*report.CoverageProfile{
-				{TotalLines: 50, CoveredLines: 30, TotalEffectiveLines: 40, TotalIgnoredLines: 10},
-				{TotalLines: 50, CoveredLines: 15, TotalEffectiveLines: 50, TotalIgnoredLines: 0},
+				{TotalLines: 50, CoveredLines: 30, TotalEffectiveLines: 40, TotalIgnoredLines: 10, MovedLines: []int{12}},
+				{TotalLines: 50, CoveredLines: 15, TotalEffectiveLines: 50, TotalIgnoredLines: 0, WeakCoveredLines: []int{3, 7}},
 			},
 		}
 		cache := excludeFileCache{"github.com/Azure/gocover/pkg/foo/foo.go": true}
@@ -133,6 +250,16 @@ func TestReBuildStatistics(t *testing.T) {
 			t.Errorf("expect ignored %d, but get %d", expectTotalIgnore, s.TotalIgnoredLines)
 		}
 
+		expectTotalWeakCovered := 2
+		if s.TotalWeakCoveredLines != expectTotalWeakCovered {
+			t.Errorf("expect weak covered %d, but get %d", expectTotalWeakCovered, s.TotalWeakCoveredLines)
+		}
+
+		expectTotalMoved := 1
+		if s.TotalMovedLines != expectTotalMoved {
+			t.Errorf("expect moved %d, but get %d", expectTotalMoved, s.TotalMovedLines)
+		}
+
 		if len(cache) != len(s.ExcludeFiles) {
 			t.Errorf("should have %d exclude file, but get %d", len(cache), len(s.ExcludeFiles))
 		}
@@ -207,8 +334,18 @@ func (client *mockDbClient) StoreIgnoreProfileDataFromFile(ctx context.Context,
 
 func TestStore(t *testing.T) {
 	t.Run("store successfully", func(t *testing.T) {
+		environment := &ci.Environment{Provider: ci.GitHubActions, Commit: "0123456789", PullRequest: "12"}
+		labels := map[string]string{"service": "api", "environment": "staging"}
 		client := &mockDbClient{
 			storeCoverageDataFromFileFn: func(ctx context.Context, data []*dbclient.CoverageData) error {
+				for _, d := range data {
+					if d.CI != environment {
+						t.Errorf("expect ci %v, but get %v", environment, d.CI)
+					}
+					if d.Labels["service"] != "api" || d.Labels["environment"] != "staging" {
+						t.Errorf("expect labels %v, but get %v", labels, d.Labels)
+					}
+				}
 				return nil
 			},
 		}
@@ -217,7 +354,7 @@ func TestStore(t *testing.T) {
 			{TotalLines: 120, TotalEffectiveLines: 100, TotalIgnoredLines: 20, TotalCoveredLines: 80},
 		}
 
-		err := storeCoverageData(context.Background(), client, all, FullCoverage, "")
+		err := storeCoverageData(context.Background(), client, all, FullCoverage, "", environment, labels)
 		if err != nil {
 			t.Errorf("should return nil, but get error: %s", err)
 		}
@@ -234,7 +371,7 @@ func TestStore(t *testing.T) {
 			{TotalLines: 120, TotalEffectiveLines: 100, TotalIgnoredLines: 20, TotalCoveredLines: 80},
 		}
 
-		err := storeCoverageData(context.Background(), client, all, FullCoverage, "")
+		err := storeCoverageData(context.Background(), client, all, FullCoverage, "", nil, nil)
 		if err == nil {
 			t.Errorf("should return error, but no error")
 		}
@@ -277,3 +414,121 @@ func TestParseGoModulePath(t *testing.T) {
 		}
 	})
 }
+
+func TestParseReportFormats(t *testing.T) {
+	t.Run("parseReportFormats", func(t *testing.T) {
+		formats, err := parseReportFormats([]string{"json", " HTML", "", "json", "cobertura", "Legacy"})
+		if err != nil {
+			t.Fatalf("should not error, but get %s", err)
+		}
+		if !reflect.DeepEqual(formats, []string{"json", "html", "cobertura", "legacy"}) {
+			t.Errorf("expect formats json, html, cobertura, legacy, but get %v", formats)
+		}
+
+		if _, err := parseReportFormats([]string{"html", "xml"}); !errors.Is(err, ErrUnknownReportFormat) {
+			t.Errorf("expect error %s, but get %v", ErrUnknownReportFormat, err)
+		}
+	})
+}
+
+func TestNewReportGenerator(t *testing.T) {
+	t.Run("generate enabled reports", func(t *testing.T) {
+		t.Setenv(githubStepSummaryEnv, "")
+		dir := t.TempDir()
+		g := newReportGenerator(&reportOption{
+			style:            "colorful",
+			outputDir:        dir,
+			reportName:       "coverage",
+			junit:            true,
+			coverageBaseline: 80,
+		}, logrus.New())
+
+		if err := g.GenerateReport(&report.Statistics{StatisticsType: report.FullStatisticsType}); err != nil {
+			t.Fatalf("should not error, but get %s", err)
+		}
+		for name, exist := range map[string]bool{
+			"coverage.html":         true,
+			"coverage-junit.xml":    true,
+			"coverage-treemap.html": false,
+		} {
+			if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != exist {
+				t.Errorf("report %s should exist: %t, but get %v", name, exist, err)
+			}
+		}
+	})
+
+	t.Run("generate each format", func(t *testing.T) {
+		t.Setenv(githubStepSummaryEnv, "")
+		dir := t.TempDir()
+		g := newReportGenerator(&reportOption{
+			formats:    []string{JSONReportFormat, CoberturaReportFormat, MarkdownReportFormat},
+			outputDir:  dir,
+			reportName: "coverage",
+		}, logrus.New())
+
+		if err := g.GenerateReport(&report.Statistics{StatisticsType: report.FullStatisticsType}); err != nil {
+			t.Fatalf("should not error, but get %s", err)
+		}
+		for name, exist := range map[string]bool{
+			"coverage.html":          false,
+			"coverage.json":          true,
+			"coverage.md":            true,
+			"coverage-cobertura.xml": true,
+		} {
+			if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != exist {
+				t.Errorf("report %s should exist: %t, but get %v", name, exist, err)
+			}
+		}
+	})
+
+	t.Run("generate legacy html report", func(t *testing.T) {
+		t.Setenv(githubStepSummaryEnv, "")
+		for _, formats := range [][]string{{LegacyReportFormat}, {LegacyReportFormat, HTMLReportFormat}} {
+			dir := t.TempDir()
+			g := newReportGenerator(&reportOption{formats: formats, outputDir: dir, reportName: "coverage"}, logrus.New())
+			if err := g.GenerateReport(&report.Statistics{StatisticsType: report.FullStatisticsType}); err != nil {
+				t.Fatalf("should not error, but get %s", err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil || len(entries) != 1 || entries[0].Name() != "coverage.html" {
+				t.Errorf("formats %v should generate coverage.html only, but get %v, %v", formats, entries, err)
+			}
+		}
+	})
+
+	t.Run("generate azure devops layout", func(t *testing.T) {
+		t.Setenv(githubStepSummaryEnv, "")
+		dir := t.TempDir()
+		azureDir := filepath.Join(dir, "azure")
+		g := newReportGenerator(&reportOption{outputDir: dir, reportName: "coverage", azureDevOpsDir: azureDir}, logrus.New())
+
+		if err := g.GenerateReport(&report.Statistics{StatisticsType: report.FullStatisticsType}); err != nil {
+			t.Fatalf("should not error, but get %s", err)
+		}
+		for _, name := range []string{
+			filepath.Join(dir, "coverage.html"),
+			filepath.Join(azureDir, "coverage-cobertura.xml"),
+			filepath.Join(azureDir, "html", "index.html"),
+		} {
+			if _, err := os.Stat(name); err != nil {
+				t.Errorf("report %s should exist, but get %v", name, err)
+			}
+		}
+	})
+
+	t.Run("write step summary in github actions", func(t *testing.T) {
+		dir := t.TempDir()
+		summaryFile := filepath.Join(dir, "summary.md")
+		t.Setenv(githubStepSummaryEnv, summaryFile)
+
+		for _, noStepSummary := range []bool{true, false} {
+			g := newReportGenerator(&reportOption{outputDir: dir, reportName: "coverage", noStepSummary: noStepSummary}, logrus.New())
+			if err := g.GenerateReport(&report.Statistics{StatisticsType: report.FullStatisticsType}); err != nil {
+				t.Fatalf("should not error, but get %s", err)
+			}
+			if _, err := os.Stat(summaryFile); (err == nil) == noStepSummary {
+				t.Errorf("step summary should be written: %t, but get %v", !noStepSummary, err)
+			}
+		}
+	})
+}
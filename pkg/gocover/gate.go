@@ -0,0 +1,138 @@
+package gocover
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/gate"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+// parseGate parses the gate expression, it returns nil if the expression is empty.
+func parseGate(expression string) (*gate.Expression, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+	return gate.Parse(expression)
+}
+
+// coverageSnapshot is the coverage of a run, which is taken before the names are anonymized.
+type coverageSnapshot struct {
+	percent     float64
+	directories map[string]*lineCount
+	teams       map[string]float64
+}
+
+func newCoverageSnapshot(statistics *report.Statistics) *coverageSnapshot {
+	s := &coverageSnapshot{
+		percent:     statistics.TotalCoveragePercent,
+		directories: make(map[string]*lineCount),
+		teams:       make(map[string]float64),
+	}
+	for _, t := range statistics.Teams {
+		s.teams[t.Team] = t.CoveragePercent
+	}
+	for _, p := range statistics.CoverageProfile {
+		addLineCount(s.directories, path.Dir(p.FileName), p.TotalEffectiveLines, p.CoveredLines-p.CoveredButIgnoredLines)
+	}
+	return s
+}
+
+// coverageMetrics provides the metrics of the gate expression:
+// diff and full are the total coverage percent of diff coverage and full coverage,
+// pkg("path") is the coverage percent of the package, the path is the import path or the path relative to the module,
+// and it ends with "/..." to include the sub packages, team("name") is the coverage percent of the files owned by the team
+// in the ownership file. The package and the team are from full coverage if it's evaluated, otherwise they're from diff coverage.
+type coverageMetrics struct {
+	modulePath string
+	diff       *coverageSnapshot
+	full       *coverageSnapshot
+}
+
+func (m *coverageMetrics) Metric(name string, arg string) (float64, error) {
+	switch name {
+	case "diff", "full":
+		if arg != "" {
+			return 0, fmt.Errorf("%s has no argument", name)
+		}
+		s := m.diff
+		if name == "full" {
+			s = m.full
+		}
+		if s == nil {
+			return 0, fmt.Errorf("%w: %s coverage is not evaluated", ErrMetricUnavailable, name)
+		}
+		return s.percent, nil
+	case "pkg", "team":
+		s := m.full
+		if s == nil {
+			s = m.diff
+		}
+		if s == nil {
+			return 0, fmt.Errorf("%w: no coverage is evaluated", ErrMetricUnavailable)
+		}
+		if name == "pkg" {
+			return m.packageCoverage(s, arg)
+		}
+		percent, ok := s.teams[arg]
+		if !ok {
+			return 0, fmt.Errorf("%w: team %s is not in the ownership file", ErrMetricUnavailable, arg)
+		}
+		return percent, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", gate.ErrUnknownMetric, name)
+	}
+}
+
+// packageCoverage returns the coverage percent of the package.
+func (m *coverageMetrics) packageCoverage(s *coverageSnapshot, pkg string) (float64, error) {
+	total, err := packageLines(s.directories, m.modulePath, pkg)
+	if err != nil {
+		return 0, err
+	}
+	return calculateCoverage(int64(total.covered), int64(total.effective)), nil
+}
+
+// packageLines sums up the lines of the directories that match the package, the package is the import path
+// or the path relative to the module, and it ends with "/..." to include the sub packages.
+func packageLines(directories map[string]*lineCount, modulePath string, pkg string) (*lineCount, error) {
+	recursive := pkg == "..." || strings.HasSuffix(pkg, "/...")
+	pkg = strings.TrimSuffix(strings.TrimSuffix(pkg, "..."), "/")
+	if !strings.HasPrefix(pkg+"/", modulePath+"/") {
+		pkg = strings.TrimSuffix(path.Join(modulePath, pkg), "/")
+	}
+
+	total := &lineCount{}
+	matched := false
+	for dir, c := range directories {
+		if dir != pkg && !(recursive && strings.HasPrefix(dir, pkg+"/")) {
+			continue
+		}
+		matched = true
+		total.effective += c.effective
+		total.covered += c.covered
+	}
+	if !matched {
+		return nil, fmt.Errorf("%w: %s", ErrMetricUnavailable, pkg)
+	}
+	return total, nil
+}
+
+// evaluateGate returns an error with the low coverage exit code if the gate expression is not met.
+func evaluateGate(expression *gate.Expression, metrics *coverageMetrics, logger logrus.FieldLogger) error {
+	result, err := expression.Evaluate(metrics)
+	if err != nil {
+		return fmt.Errorf("evaluate gate: %w", err)
+	}
+	if !result.Passed {
+		return WrapErrorWithCode(
+			fmt.Errorf("the coverage gate `%s` is not met: %s", expression, result),
+			LowCoverageErrorExitCode,
+			"",
+		)
+	}
+	logger.Infof("the coverage gate `%s` is met: %s", expression, result)
+	return nil
+}
@@ -0,0 +1,86 @@
+package gocover
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gate"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageMetrics(t *testing.T) {
+	full := newCoverageSnapshot(&report.Statistics{
+		TotalCoveragePercent: 60,
+		CoverageProfile: []*report.CoverageProfile{
+			{FileName: "github.com/Azure/gocover/pkg/api/api.go", TotalEffectiveLines: 10, CoveredLines: 9, CoveredButIgnoredLines: 1},
+			{FileName: "github.com/Azure/gocover/pkg/api/v1/v1.go", TotalEffectiveLines: 10, CoveredLines: 2},
+			{FileName: "github.com/Azure/gocover/pkg/apis/apis.go", TotalEffectiveLines: 10},
+		},
+		Teams: []*report.TeamCoverage{{Team: "api", CoveragePercent: 50}},
+	})
+
+	t.Run("full coverage", func(t *testing.T) {
+		m := &coverageMetrics{modulePath: "github.com/Azure/gocover", full: full}
+		for _, testCase := range []struct {
+			name     string
+			arg      string
+			expected float64
+		}{
+			{name: "full", expected: 60},
+			{name: "pkg", arg: "pkg/api", expected: 80},
+			{name: "pkg", arg: "github.com/Azure/gocover/pkg/api", expected: 80},
+			{name: "pkg", arg: "pkg/api/...", expected: 50},
+			{name: "pkg", arg: "...", expected: 100.0 / 3},
+			{name: "team", arg: "api", expected: 50},
+		} {
+			v, err := m.Metric(testCase.name, testCase.arg)
+			assert.NoError(t, err)
+			assert.InDelta(t, testCase.expected, v, 1e-9, "%s(%s)", testCase.name, testCase.arg)
+		}
+
+		_, err := m.Metric("diff", "")
+		assert.ErrorIs(t, err, ErrMetricUnavailable)
+		_, err = m.Metric("pkg", "pkg/other")
+		assert.ErrorIs(t, err, ErrMetricUnavailable)
+		_, err = m.Metric("team", "storage")
+		assert.ErrorIs(t, err, ErrMetricUnavailable)
+		_, err = m.Metric("file", "pkg/api/api.go")
+		assert.ErrorIs(t, err, gate.ErrUnknownMetric)
+	})
+
+	t.Run("package of diff coverage", func(t *testing.T) {
+		m := &coverageMetrics{modulePath: "github.com/Azure/gocover", diff: full}
+		v, err := m.Metric("pkg", "pkg/api")
+		assert.NoError(t, err)
+		assert.Equal(t, 80.0, v)
+	})
+}
+
+func TestEvaluateGate(t *testing.T) {
+	m := &coverageMetrics{
+		modulePath: "github.com/Azure/gocover",
+		diff:       &coverageSnapshot{percent: 85},
+		full:       &coverageSnapshot{percent: 65},
+	}
+
+	expression, err := parseGate("diff >= 80 && full >= 60")
+	assert.NoError(t, err)
+	assert.NoError(t, evaluateGate(expression, m, logrus.New()))
+
+	expression, err = parseGate("diff >= 80 && full >= 70")
+	assert.NoError(t, err)
+	err = evaluateGate(expression, m, logrus.New())
+	var e *GoCoverError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, LowCoverageErrorExitCode, e.ExitCode)
+	assert.Equal(t, "the coverage gate `diff >= 80 && full >= 70` is not met: diff = 85.00, full = 65.00", err.Error())
+
+	expression, err = parseGate(" ")
+	assert.NoError(t, err)
+	assert.Nil(t, expression)
+
+	_, err = parseGate("diff >=")
+	assert.ErrorIs(t, err, gate.ErrInvalidExpression)
+}
@@ -0,0 +1,277 @@
+package gocover
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+// newHistoryRecord builds the history record of the run from the statistics and the coverage of the functions.
+// The commit and branch are left empty if the git information of the repository is unavailable,
+// they are overridden by the information of the CI run if it's set.
+func newHistoryRecord(
+	repositoryPath string,
+	modulePath string,
+	coverageMode CoverageMode,
+	statistics *report.Statistics,
+	functions []*report.FunctionCoverage,
+	environment *ci.Environment,
+	logger logrus.FieldLogger,
+) *history.Record {
+	record := &history.Record{
+		Timestamp:           time.Now().UTC(),
+		ModulePath:          modulePath,
+		CoverageMode:        string(coverageMode),
+		TotalEffectiveLines: statistics.TotalEffectiveLines,
+		TotalCoveredLines:   statistics.TotalCoveredLines - statistics.TotalCoveredButIgnoredLines,
+		CoveragePercent:     statistics.TotalCoveragePercent,
+		Labels:              statistics.RunLabels,
+	}
+
+	if gitClient, err := gittool.NewGitClient(repositoryPath); err != nil {
+		logger.WithError(err).Warn("open git repository for history record")
+	} else if record.Commit, record.Branch, err = gitClient.HeadCommit(); err != nil {
+		logger.WithError(err).Warn("get HEAD commit for history record")
+	}
+
+	if environment != nil {
+		if environment.Commit != "" {
+			record.Commit = environment.Commit
+		}
+		if environment.Branch != "" {
+			record.Branch = environment.Branch
+		}
+		record.CIProvider = string(environment.Provider)
+		record.PullRequest = environment.PullRequest
+		record.BuildID = environment.BuildID
+	}
+
+	for _, f := range functions {
+		functionRecord := &history.FunctionRecord{
+			FileName:            f.FileName,
+			Function:            f.Function,
+			StartLine:           f.StartLine,
+			TotalEffectiveLines: f.TotalEffectiveLines,
+			CoveredLines:        f.CoveredLines,
+		}
+		if f.Hits != nil {
+			functionRecord.MinHits = f.Hits.Min
+			functionRecord.AvgHits = f.Hits.Avg
+			functionRecord.MaxHits = f.Hits.Max
+		}
+		record.Functions = append(record.Functions, functionRecord)
+	}
+	return record
+}
+
+// neverCoveredFunctions returns the functions that have no covered lines in all the records,
+// the functions that are missing from any of the records are not counted.
+// A function is identified by its file name and function name, the position is from the latest record.
+func neverCoveredFunctions(records []*history.Record) []*report.FunctionCoverage {
+	if len(records) == 0 {
+		return nil
+	}
+
+	type functionKey struct {
+		fileName string
+		function string
+	}
+
+	uncovered := make(map[functionKey]int)
+	for _, r := range records {
+		for _, f := range r.Functions {
+			if f.TotalEffectiveLines != 0 && f.CoveredLines == 0 {
+				uncovered[functionKey{fileName: f.FileName, function: f.Function}]++
+			}
+		}
+	}
+
+	var result []*report.FunctionCoverage
+	for _, f := range records[len(records)-1].Functions {
+		if uncovered[functionKey{fileName: f.FileName, function: f.Function}] != len(records) {
+			continue
+		}
+		result = append(result, &report.FunctionCoverage{
+			FileName:            f.FileName,
+			Function:            f.Function,
+			StartLine:           f.StartLine,
+			TotalEffectiveLines: f.TotalEffectiveLines,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].FileName != result[j].FileName {
+			return result[i].FileName < result[j].FileName
+		}
+		return result[i].StartLine < result[j].StartLine
+	})
+	return result
+}
+
+// coverageRegressionEpsilon is the least drop of the coverage percent that counts as a regression,
+// it ignores the drops that are invisible in the reports, which show two decimals.
+const coverageRegressionEpsilon = 0.005
+
+// lineCount is the effective lines and the covered lines of a package or a file.
+type lineCount struct {
+	effective int
+	covered   int
+}
+
+// coverageDelta compares the coverage of the functions with the base record by package and by file,
+// the packages and the files that exist in only one of them are not compared. The files of the base record
+// are compared by their new names if they're renamed, which are keyed by the old names, so a renamed package
+// is compared with its old self instead of being deleted and added.
+func coverageDelta(base *history.Record, functions []*report.FunctionCoverage, coveragePercent float64, renames map[string]string) *report.CoverageDelta {
+	baseFiles, basePackages := make(map[string]*lineCount), make(map[string]*lineCount)
+	renamedPackages := make(map[report.PackageRename]bool)
+	for _, f := range base.Functions {
+		fileName := f.FileName
+		if to, ok := renames[fileName]; ok {
+			fileName = to
+			if from, to := path.Dir(f.FileName), path.Dir(to); from != to {
+				renamedPackages[report.PackageRename{From: from, To: to}] = true
+			}
+		}
+		addLineCount(baseFiles, fileName, f.TotalEffectiveLines, f.CoveredLines)
+		addLineCount(basePackages, path.Dir(fileName), f.TotalEffectiveLines, f.CoveredLines)
+	}
+	files, packages := make(map[string]*lineCount), make(map[string]*lineCount)
+	for _, f := range functions {
+		addLineCount(files, f.FileName, f.TotalEffectiveLines, f.CoveredLines)
+		addLineCount(packages, path.Dir(f.FileName), f.TotalEffectiveLines, f.CoveredLines)
+	}
+
+	return &report.CoverageDelta{
+		BaseCommit:           base.Commit,
+		BaseCoveragePercent:  base.CoveragePercent,
+		CoveragePercentDelta: coveragePercent - base.CoveragePercent,
+		RegressedPackages:    regressions(basePackages, packages),
+		RegressedFiles:       regressions(baseFiles, files),
+		RenamedPackages:      sortedRenames(renamedPackages),
+	}
+}
+
+// sortedRenames returns the renamed packages in the order of their old names.
+func sortedRenames(renames map[report.PackageRename]bool) []*report.PackageRename {
+	var result []*report.PackageRename
+	for r := range renames {
+		r := r
+		result = append(result, &r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		return result[i].To < result[j].To
+	})
+	return result
+}
+
+// renamedFiles returns the report names of the renamed files of the module by the old names,
+// the renames are the paths relative to the repository, refer to gittool.GitClient.Renames.
+func renamedFiles(renames map[string]string, moduleDir, modulePath string) map[string]string {
+	prefix := path.Clean(filepath.ToSlash(moduleDir)) + "/"
+	if prefix == "./" {
+		prefix = ""
+	}
+	result := make(map[string]string)
+	for from, to := range renames {
+		if !strings.HasPrefix(from, prefix) || !strings.HasPrefix(to, prefix) {
+			continue
+		}
+		result[path.Join(modulePath, strings.TrimPrefix(from, prefix))] = path.Join(modulePath, strings.TrimPrefix(to, prefix))
+	}
+	return result
+}
+
+func addLineCount(counts map[string]*lineCount, name string, effective int, covered int) {
+	c, ok := counts[name]
+	if !ok {
+		c = &lineCount{}
+		counts[name] = c
+	}
+	c.effective += effective
+	c.covered += covered
+}
+
+// recordPackageLines sums up the lines of the package pattern from the functions of the record,
+// it returns ErrMetricUnavailable if the record has no file of the package.
+func recordPackageLines(r *history.Record, pkg string) (*lineCount, error) {
+	directories := make(map[string]*lineCount)
+	for _, f := range r.Functions {
+		addLineCount(directories, path.Dir(f.FileName), f.TotalEffectiveLines, f.CoveredLines)
+	}
+	return packageLines(directories, r.ModulePath, pkg)
+}
+
+// coverageTrend returns the coverage of the records over time, the total coverage is followed by the packages.
+// The records that have no file of a package are left out of the series of the package.
+func coverageTrend(records []*history.Record, packages []string) (*report.CoverageTrend, error) {
+	total := &report.TrendSeries{Name: "total"}
+	for _, r := range records {
+		total.Points = append(total.Points, &report.TrendPoint{
+			Timestamp:       r.Timestamp,
+			Commit:          r.Commit,
+			CoveragePercent: r.CoveragePercent,
+		})
+	}
+
+	trend := &report.CoverageTrend{Runs: len(records), Series: []*report.TrendSeries{total}}
+	for _, pkg := range packages {
+		series := &report.TrendSeries{Name: pkg}
+		for _, r := range records {
+			lines, err := recordPackageLines(r, pkg)
+			if errors.Is(err, ErrMetricUnavailable) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			series.Points = append(series.Points, &report.TrendPoint{
+				Timestamp:       r.Timestamp,
+				Commit:          r.Commit,
+				CoveragePercent: calculateCoverage(int64(lines.covered), int64(lines.effective)),
+			})
+		}
+		trend.Series = append(trend.Series, series)
+	}
+	return trend, nil
+}
+
+// regressions returns the changes whose coverage is lower than the base, the largest drop first.
+func regressions(base map[string]*lineCount, current map[string]*lineCount) []*report.CoverageChange {
+	var result []*report.CoverageChange
+	for name, c := range current {
+		b, ok := base[name]
+		if !ok {
+			continue
+		}
+		change := &report.CoverageChange{
+			Name:                name,
+			BaseCoveragePercent: calculateCoverage(int64(b.covered), int64(b.effective)),
+			CoveragePercent:     calculateCoverage(int64(c.covered), int64(c.effective)),
+		}
+		change.Delta = change.CoveragePercent - change.BaseCoveragePercent
+		if change.Delta <= -coverageRegressionEpsilon {
+			result = append(result, change)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Delta != result[j].Delta {
+			return result[i].Delta < result[j].Delta
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,190 @@
+package gocover
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHistoryRecord(t *testing.T) {
+	t.Run("newHistoryRecord", func(t *testing.T) {
+		record := newHistoryRecord(
+			t.TempDir(),
+			"github.com/Azure/gocover",
+			FullCoverage,
+			&report.Statistics{
+				TotalEffectiveLines:         100,
+				TotalCoveredLines:           60,
+				TotalCoveredButIgnoredLines: 10,
+				TotalCoveragePercent:        50,
+				RunLabels:                   map[string]string{"service": "api"},
+			},
+			[]*report.FunctionCoverage{
+				{FileName: "foo.go", Function: "foo", StartLine: 3, TotalEffectiveLines: 10, CoveredLines: 5},
+			},
+			nil,
+			logrus.New(),
+		)
+
+		assert.Equal(t, "github.com/Azure/gocover", record.ModulePath)
+		assert.Equal(t, map[string]string{"service": "api"}, record.Labels)
+		assert.Equal(t, "full", record.CoverageMode)
+		assert.Equal(t, "", record.Commit)
+		assert.Equal(t, 100, record.TotalEffectiveLines)
+		assert.Equal(t, 50, record.TotalCoveredLines)
+		assert.Equal(t, 50.0, record.CoveragePercent)
+		assert.Equal(t, []*history.FunctionRecord{
+			{FileName: "foo.go", Function: "foo", StartLine: 3, TotalEffectiveLines: 10, CoveredLines: 5},
+		}, record.Functions)
+	})
+
+	t.Run("newHistoryRecord in CI", func(t *testing.T) {
+		record := newHistoryRecord(
+			t.TempDir(),
+			"github.com/Azure/gocover",
+			FullCoverage,
+			&report.Statistics{},
+			nil,
+			&ci.Environment{Provider: ci.GitLab, Commit: "0123456789", Branch: "feature", PullRequest: "12", BuildID: "345"},
+			logrus.New(),
+		)
+
+		assert.Equal(t, "0123456789", record.Commit)
+		assert.Equal(t, "feature", record.Branch)
+		assert.Equal(t, "gitlab", record.CIProvider)
+		assert.Equal(t, "12", record.PullRequest)
+		assert.Equal(t, "345", record.BuildID)
+	})
+}
+
+func TestNeverCoveredFunctions(t *testing.T) {
+	t.Run("no records", func(t *testing.T) {
+		assert.Nil(t, neverCoveredFunctions(nil))
+	})
+
+	t.Run("neverCoveredFunctions", func(t *testing.T) {
+		records := []*history.Record{
+			{Functions: []*history.FunctionRecord{
+				{FileName: "foo.go", Function: "never", StartLine: 10, TotalEffectiveLines: 5},
+				{FileName: "foo.go", Function: "once", StartLine: 20, TotalEffectiveLines: 5, CoveredLines: 1},
+				{FileName: "bar.go", Function: "removed", StartLine: 1, TotalEffectiveLines: 5},
+			}},
+			{Functions: []*history.FunctionRecord{
+				{FileName: "foo.go", Function: "never", StartLine: 12, TotalEffectiveLines: 6},
+				{FileName: "foo.go", Function: "once", StartLine: 22, TotalEffectiveLines: 5},
+				{FileName: "foo.go", Function: "added", StartLine: 30, TotalEffectiveLines: 5},
+				{FileName: "bar.go", Function: "empty", StartLine: 1},
+				{FileName: "bar.go", Function: "alsoNever", StartLine: 5, TotalEffectiveLines: 2},
+			}},
+		}
+		records[0].Functions = append(records[0].Functions,
+			&history.FunctionRecord{FileName: "bar.go", Function: "alsoNever", StartLine: 5, TotalEffectiveLines: 2})
+
+		functions := neverCoveredFunctions(records)
+		assert.Equal(t, []*report.FunctionCoverage{
+			{FileName: "bar.go", Function: "alsoNever", StartLine: 5, TotalEffectiveLines: 2},
+			{FileName: "foo.go", Function: "never", StartLine: 12, TotalEffectiveLines: 6},
+		}, functions)
+	})
+}
+
+func TestCoverageTrend(t *testing.T) {
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []*history.Record{
+		{Timestamp: day, Commit: "0123456789", CoveragePercent: 50, ModulePath: "github.com/Azure/gocover", Functions: []*history.FunctionRecord{
+			{FileName: "github.com/Azure/gocover/pkg/foo/a.go", TotalEffectiveLines: 10, CoveredLines: 5},
+		}},
+		{Timestamp: day.AddDate(0, 0, 1), Commit: "1111111111", CoveragePercent: 60, ModulePath: "github.com/Azure/gocover", Functions: []*history.FunctionRecord{
+			{FileName: "github.com/Azure/gocover/pkg/foo/a.go", TotalEffectiveLines: 10, CoveredLines: 6},
+			{FileName: "github.com/Azure/gocover/pkg/foo/bar/b.go", TotalEffectiveLines: 10, CoveredLines: 8},
+		}},
+	}
+
+	trend, err := coverageTrend(records, []string{"pkg/foo/...", "github.com/Azure/gocover/pkg/foo/bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, &report.CoverageTrend{
+		Runs: 2,
+		Series: []*report.TrendSeries{
+			{Name: "total", Points: []*report.TrendPoint{
+				{Timestamp: day, Commit: "0123456789", CoveragePercent: 50},
+				{Timestamp: day.AddDate(0, 0, 1), Commit: "1111111111", CoveragePercent: 60},
+			}},
+			{Name: "pkg/foo/...", Points: []*report.TrendPoint{
+				{Timestamp: day, Commit: "0123456789", CoveragePercent: 50},
+				{Timestamp: day.AddDate(0, 0, 1), Commit: "1111111111", CoveragePercent: 70},
+			}},
+			// the package is missing from the first record.
+			{Name: "github.com/Azure/gocover/pkg/foo/bar", Points: []*report.TrendPoint{
+				{Timestamp: day.AddDate(0, 0, 1), Commit: "1111111111", CoveragePercent: 80},
+			}},
+		},
+	}, trend)
+}
+
+func TestCoverageDelta(t *testing.T) {
+	base := &history.Record{
+		Commit:          "0123456789",
+		CoveragePercent: 60,
+		Functions: []*history.FunctionRecord{
+			{FileName: "foo/a.go", Function: "a", TotalEffectiveLines: 10, CoveredLines: 8},
+			{FileName: "foo/b.go", Function: "b", TotalEffectiveLines: 10, CoveredLines: 4},
+			{FileName: "bar/c.go", Function: "c", TotalEffectiveLines: 10, CoveredLines: 6},
+			{FileName: "bar/deleted.go", Function: "d", TotalEffectiveLines: 10, CoveredLines: 0},
+		},
+	}
+	functions := []*report.FunctionCoverage{
+		{FileName: "foo/a.go", Function: "a", TotalEffectiveLines: 10, CoveredLines: 4},
+		{FileName: "foo/b.go", Function: "b", TotalEffectiveLines: 10, CoveredLines: 6},
+		{FileName: "bar/c.go", Function: "c", TotalEffectiveLines: 10, CoveredLines: 6},
+		{FileName: "baz/new.go", Function: "n", TotalEffectiveLines: 10, CoveredLines: 0},
+	}
+
+	delta := coverageDelta(base, functions, 42.5, nil)
+	assert.Equal(t, "0123456789", delta.BaseCommit)
+	assert.Equal(t, 60.0, delta.BaseCoveragePercent)
+	assert.Equal(t, -17.5, delta.CoveragePercentDelta)
+	assert.Equal(t, []*report.CoverageChange{
+		{Name: "foo", BaseCoveragePercent: 60, CoveragePercent: 50, Delta: -10},
+	}, delta.RegressedPackages)
+	assert.Equal(t, []*report.CoverageChange{
+		{Name: "foo/a.go", BaseCoveragePercent: 80, CoveragePercent: 40, Delta: -40},
+	}, delta.RegressedFiles)
+	assert.Empty(t, delta.RenamedPackages)
+
+	t.Run("renamed package", func(t *testing.T) {
+		functions := []*report.FunctionCoverage{
+			{FileName: "foo/a.go", Function: "a", TotalEffectiveLines: 10, CoveredLines: 8},
+			{FileName: "foo/b.go", Function: "b", TotalEffectiveLines: 10, CoveredLines: 4},
+			{FileName: "qux/c.go", Function: "c", TotalEffectiveLines: 10, CoveredLines: 3},
+		}
+		delta := coverageDelta(base, functions, 50, map[string]string{"bar/c.go": "qux/c.go"})
+		assert.Equal(t, []*report.CoverageChange{
+			{Name: "qux", BaseCoveragePercent: 60, CoveragePercent: 30, Delta: -30},
+		}, delta.RegressedPackages)
+		assert.Equal(t, []*report.CoverageChange{
+			{Name: "qux/c.go", BaseCoveragePercent: 60, CoveragePercent: 30, Delta: -30},
+		}, delta.RegressedFiles)
+		assert.Equal(t, []*report.PackageRename{{From: "bar", To: "qux"}}, delta.RenamedPackages)
+	})
+}
+
+func TestRenamedFiles(t *testing.T) {
+	renames := map[string]string{
+		"module/bar/c.go": "module/qux/c.go",
+		"module/d.go":     "other/d.go",
+		"tools/e.go":      "tools/f.go",
+	}
+	assert.Equal(t, map[string]string{
+		"example.com/foo/bar/c.go": "example.com/foo/qux/c.go",
+	}, renamedFiles(renames, "module", "example.com/foo"))
+	assert.Equal(t, map[string]string{
+		"example.com/foo/module/bar/c.go": "example.com/foo/module/qux/c.go",
+		"example.com/foo/module/d.go":     "example.com/foo/other/d.go",
+		"example.com/foo/tools/e.go":      "example.com/foo/tools/f.go",
+	}, renamedFiles(renames, "", "example.com/foo"))
+}
@@ -0,0 +1,242 @@
+package gocover
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TableHistoryFormat = "table"
+	JSONHistoryFormat  = "json"
+	CSVHistoryFormat   = "csv"
+)
+
+func NewHistoryQuery(o *HistoryOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "history")
+
+	format := strings.ToLower(o.Format)
+	switch format {
+	case "":
+		format = TableHistoryFormat
+	case TableHistoryFormat, JSONHistoryFormat, CSVHistoryFormat:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownReportFormat, o.Format)
+	}
+
+	since, err := parseHistoryTime(o.Since, false)
+	if err != nil {
+		return nil, fmt.Errorf("since: %w", err)
+	}
+	until, err := parseHistoryTime(o.Until, true)
+	if err != nil {
+		return nil, fmt.Errorf("until: %w", err)
+	}
+
+	// the store creates the directory that doesn't exist, which should be reported for a query.
+	if _, err := os.Stat(o.HistoryDir); err != nil {
+		return nil, fmt.Errorf("history dir: %w", err)
+	}
+	store, err := history.NewFileStore(o.HistoryDir, compression.None)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &historyQuery{
+		store: store,
+		query: &history.Query{
+			ModulePath:   o.ModulePath,
+			CoverageMode: o.CoverageMode,
+			Branch:       o.Branch,
+			Since:        since,
+			Until:        until,
+			Limit:        o.Limit,
+		},
+		pkg:    o.Package,
+		format: format,
+		output: o.Output,
+		stdout: stdout,
+		logger: logger,
+	}, nil
+}
+
+var _ GoCover = (*historyQuery)(nil)
+
+// historyQuery implements the GoCover interface and prints or exports the records in the history store.
+type historyQuery struct {
+	store  history.Store
+	query  *history.Query
+	pkg    string // reports the coverage of the package instead of the total coverage if it's set
+	format string
+	output string // file that the records are exported to, stdout is used if it's empty
+	stdout io.Writer
+
+	logger logrus.FieldLogger
+}
+
+// historyEntry is the coverage of a record in the output.
+type historyEntry struct {
+	Timestamp           time.Time `json:"timestamp"`
+	ModulePath          string    `json:"modulePath"`
+	Commit              string    `json:"commit"`
+	Branch              string    `json:"branch"`
+	CoverageMode        string    `json:"coverageMode"`
+	Package             string    `json:"package,omitempty"`
+	TotalEffectiveLines int       `json:"totalEffectiveLines"`
+	TotalCoveredLines   int       `json:"totalCoveredLines"`
+	CoveragePercent     float64   `json:"coveragePercent"`
+}
+
+func (h *historyQuery) Run(ctx context.Context) error {
+	records, err := h.store.List(ctx, h.query)
+	if err != nil {
+		return fmt.Errorf("list history records: %w", err)
+	}
+
+	entries, err := h.entries(records)
+	if err != nil {
+		return err
+	}
+
+	w := h.stdout
+	if h.output != "" {
+		f, err := os.Create(h.output)
+		if err != nil {
+			return fmt.Errorf("create history output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch h.format {
+	case JSONHistoryFormat:
+		err = writeHistoryJSON(w, entries)
+	case CSVHistoryFormat:
+		err = writeHistoryCSV(w, entries)
+	default:
+		err = writeHistoryTable(w, entries)
+	}
+	if err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	if h.output != "" {
+		h.logger.Infof("export %d history records: %s", len(entries), h.output)
+	}
+	return nil
+}
+
+// entries converts the records to the entries, the coverage of the package is summed up from the functions of the record,
+// the records that don't have the package are skipped.
+func (h *historyQuery) entries(records []*history.Record) ([]*historyEntry, error) {
+	entries := []*historyEntry{}
+	for _, r := range records {
+		entry := &historyEntry{
+			Timestamp:           r.Timestamp,
+			ModulePath:          r.ModulePath,
+			Commit:              r.Commit,
+			Branch:              r.Branch,
+			CoverageMode:        r.CoverageMode,
+			TotalEffectiveLines: r.TotalEffectiveLines,
+			TotalCoveredLines:   r.TotalCoveredLines,
+			CoveragePercent:     r.CoveragePercent,
+		}
+
+		if h.pkg != "" {
+			lines, err := recordPackageLines(r, h.pkg)
+			if errors.Is(err, ErrMetricUnavailable) {
+				h.logger.Debugf("skip the record of %s at %s: %s", r.Commit, r.Timestamp.Format(time.RFC3339), err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			entry.Package = h.pkg
+			entry.TotalEffectiveLines = lines.effective
+			entry.TotalCoveredLines = lines.covered
+			entry.CoveragePercent = calculateCoverage(int64(lines.covered), int64(lines.effective))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseHistoryTime parses the time in RFC3339 format or the date (in UTC), it returns zero time if the value is empty.
+// The date of the end of a range is the start of the next day, so that the range includes the whole day.
+func parseHistoryTime(v string, end bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q should be a date (2006-01-02) or a time in RFC3339 format", v)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func writeHistoryTable(w io.Writer, entries []*historyEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tMODULE\tBRANCH\tCOMMIT\tMODE\tCOVERAGE\tLINES")
+	for _, e := range entries {
+		commit := e.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.2f%%\t%d/%d\n",
+			e.Timestamp.Format(time.RFC3339), e.ModulePath, e.Branch, commit, e.CoverageMode,
+			e.CoveragePercent, e.TotalCoveredLines, e.TotalEffectiveLines)
+	}
+	return tw.Flush()
+}
+
+func writeHistoryJSON(w io.Writer, entries []*historyEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+func writeHistoryCSV(w io.Writer, entries []*historyEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "modulePath", "branch", "commit", "coverageMode", "package", "totalEffectiveLines", "totalCoveredLines", "coveragePercent"})
+	for _, e := range entries {
+		cw.Write([]string{
+			e.Timestamp.Format(time.RFC3339),
+			e.ModulePath,
+			e.Branch,
+			e.Commit,
+			e.CoverageMode,
+			e.Package,
+			strconv.Itoa(e.TotalEffectiveLines),
+			strconv.Itoa(e.TotalCoveredLines),
+			strconv.FormatFloat(e.CoveragePercent, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,116 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryQuery(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.NewFileStore(dir, compression.None)
+	assert.NoError(t, err)
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, r := range []*history.Record{
+		{Branch: "main", Commit: "0123456789abcdef", CoveragePercent: 60, TotalEffectiveLines: 10, TotalCoveredLines: 6},
+		{Branch: "dev", Commit: "1111111111111111", CoveragePercent: 50},
+		{Branch: "main", Commit: "2222222222222222", CoveragePercent: 70, TotalEffectiveLines: 10, TotalCoveredLines: 7},
+	} {
+		r.Timestamp = day.AddDate(0, 0, i)
+		r.ModulePath = "github.com/Azure/gocover"
+		r.CoverageMode = string(FullCoverage)
+		r.Functions = []*history.FunctionRecord{
+			{FileName: "github.com/Azure/gocover/pkg/x/x.go", TotalEffectiveLines: 4, CoveredLines: i + 1},
+			{FileName: "github.com/Azure/gocover/pkg/y/y.go", TotalEffectiveLines: 6, CoveredLines: 3},
+		}
+		assert.NoError(t, store.Append(context.Background(), r))
+	}
+
+	run := func(o *HistoryOption) (string, error) {
+		var buf bytes.Buffer
+		o.HistoryDir, o.StdOut = dir, &buf
+		q, err := NewHistoryQuery(o)
+		if err != nil {
+			return "", err
+		}
+		err = q.Run(context.Background())
+		return buf.String(), err
+	}
+
+	t.Run("table", func(t *testing.T) {
+		out, err := run(&HistoryOption{Branch: "main"})
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		assert.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "TIME"))
+		assert.Contains(t, lines[1], "01234567")
+		assert.Contains(t, lines[1], "60.00%")
+		assert.Contains(t, lines[2], "7/10")
+	})
+
+	t.Run("package in date range", func(t *testing.T) {
+		out, err := run(&HistoryOption{Format: JSONHistoryFormat, Package: "pkg/x", Since: "2024-05-02", Until: "2024-05-03"})
+		assert.NoError(t, err)
+		var entries []*historyEntry
+		assert.NoError(t, json.Unmarshal([]byte(out), &entries))
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "dev", entries[0].Branch)
+		assert.Equal(t, "pkg/x", entries[0].Package)
+		assert.Equal(t, 50.0, entries[0].CoveragePercent)
+		assert.Equal(t, 75.0, entries[1].CoveragePercent)
+	})
+
+	t.Run("export csv", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "history.csv")
+		_, err := run(&HistoryOption{Format: CSVHistoryFormat, Package: "...", Limit: 1, Output: output})
+		assert.NoError(t, err)
+		data, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		assert.Equal(t, "timestamp,modulePath,branch,commit,coverageMode,package,totalEffectiveLines,totalCoveredLines,coveragePercent\n"+
+			"2024-05-03T12:00:00Z,github.com/Azure/gocover,main,2222222222222222,full,...,10,6,60.00\n", string(data))
+	})
+
+	t.Run("package not found", func(t *testing.T) {
+		out, err := run(&HistoryOption{Format: JSONHistoryFormat, Package: "pkg/z"})
+		assert.NoError(t, err)
+		assert.Equal(t, "[]\n", out)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := run(&HistoryOption{Format: "xml"})
+		assert.True(t, errors.Is(err, ErrUnknownReportFormat))
+		_, err = run(&HistoryOption{Since: "yesterday"})
+		assert.Error(t, err)
+		_, err = NewHistoryQuery(&HistoryOption{HistoryDir: filepath.Join(dir, "missing")})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	since, err := parseHistoryTime("2024-05-01", false)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), since)
+
+	until, err := parseHistoryTime("2024-05-01", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), until)
+
+	exact, err := parseHistoryTime("2024-05-01T08:00:00Z", true)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), exact)
+
+	zero, err := parseHistoryTime("", true)
+	assert.NoError(t, err)
+	assert.True(t, zero.IsZero())
+}
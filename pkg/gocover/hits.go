@@ -0,0 +1,56 @@
+package gocover
+
+import (
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// isCountMode reports whether the cover mode records the hit counts of the statements.
+func isCountMode(coverMode string) bool {
+	return coverMode == "count" || coverMode == "atomic"
+}
+
+// newHitCounts returns the hit counts of the statements that count for coverage, only the changed
+// statements are counted if changedOnly is true. It returns nil if the cover mode doesn't record
+// the hit counts, or there is no such statement.
+func newHitCounts(coverMode string, statements []*parser.Statement, changedOnly bool) *report.HitCounts {
+	if !isCountMode(coverMode) {
+		return nil
+	}
+
+	var hits *report.HitCounts
+	var sum int64
+	n := 0
+	for _, st := range statements {
+		if st.Mode != parser.Keep || changedOnly && st.State != parser.Changed {
+			continue
+		}
+		if hits == nil {
+			hits = &report.HitCounts{Min: st.Reached, Max: st.Reached}
+		}
+		hits.Min = min(hits.Min, st.Reached)
+		hits.Max = max(hits.Max, st.Reached)
+		sum += st.Reached
+		n++
+	}
+	if hits != nil {
+		hits.Avg = float64(sum) / float64(n)
+	}
+	return hits
+}
+
+// mergeHitCounts merges the hit counts weighted by the effective lines n1 and n2, such as the function literals
+// that are folded into their enclosing functions.
+func mergeHitCounts(h1 *report.HitCounts, n1 int, h2 *report.HitCounts, n2 int) *report.HitCounts {
+	if h1 == nil || n1 == 0 {
+		return h2
+	}
+	if h2 == nil || n2 == 0 {
+		return h1
+	}
+	return &report.HitCounts{
+		Min: min(h1.Min, h2.Min),
+		Max: max(h1.Max, h2.Max),
+		Avg: (h1.Avg*float64(n1) + h2.Avg*float64(n2)) / float64(n1+n2),
+	}
+}
@@ -0,0 +1,43 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHitCounts(t *testing.T) {
+	statements := []*parser.Statement{
+		{Reached: 4, Mode: parser.Keep, State: parser.Original},
+		{Reached: 0, Mode: parser.Keep, State: parser.Changed},
+		{Reached: 9, Mode: parser.Ignore, State: parser.Changed},
+		{Reached: 2, Mode: parser.Keep, State: parser.Changed},
+	}
+
+	t.Run("set mode", func(t *testing.T) {
+		assert.Nil(t, newHitCounts("set", statements, false))
+	})
+
+	t.Run("full", func(t *testing.T) {
+		assert.Equal(t, &report.HitCounts{Min: 0, Max: 4, Avg: 2}, newHitCounts("count", statements, false))
+	})
+
+	t.Run("changed only", func(t *testing.T) {
+		assert.Equal(t, &report.HitCounts{Min: 0, Max: 2, Avg: 1}, newHitCounts("atomic", statements, true))
+	})
+
+	t.Run("no statement", func(t *testing.T) {
+		assert.Nil(t, newHitCounts("count", statements[2:3], false))
+	})
+}
+
+func TestMergeHitCounts(t *testing.T) {
+	h1 := &report.HitCounts{Min: 1, Max: 3, Avg: 2}
+	h2 := &report.HitCounts{Min: 0, Max: 8, Avg: 5}
+
+	assert.Equal(t, &report.HitCounts{Min: 0, Max: 8, Avg: 4}, mergeHitCounts(h1, 1, h2, 2))
+	assert.Equal(t, h2, mergeHitCounts(nil, 0, h2, 2))
+	assert.Equal(t, h1, mergeHitCounts(h1, 1, nil, 0))
+}
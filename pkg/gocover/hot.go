@@ -0,0 +1,81 @@
+package gocover
+
+import (
+	"sort"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// hotPaths ranks the functions and the statements by their hit counts, so the most frequently
+// executed code comes first. The hit counts are only recorded in count and atomic cover modes.
+type hotPaths struct {
+	n          int
+	functions  []*report.FunctionCoverage
+	statements []*report.HotStatement
+}
+
+// newHotPaths returns a ranking that keeps the top n functions and statements.
+// It returns nil if n is not positive, which disables the ranking.
+func newHotPaths(n int) *hotPaths {
+	if n <= 0 {
+		return nil
+	}
+	return &hotPaths{n: n}
+}
+
+// addStatements adds the reached statements of the function that count for coverage,
+// only the changed statements are added if changedOnly is true.
+func (h *hotPaths) addStatements(fileName string, fun *parser.Function, coverMode string, changedOnly bool) {
+	if h == nil || !isCountMode(coverMode) {
+		return
+	}
+	for _, st := range fun.Statements {
+		if st.Mode != parser.Keep || st.Reached == 0 || changedOnly && st.State != parser.Changed {
+			continue
+		}
+		h.statements = append(h.statements, &report.HotStatement{
+			FileName:  fileName,
+			Function:  fun.Name,
+			StartLine: st.StartLine,
+			EndLine:   st.EndLine,
+			Hits:      st.Reached,
+		})
+	}
+}
+
+// addFunction adds the function to the ranking, the functions without hit counts or never reached are not ranked.
+func (h *hotPaths) addFunction(f *report.FunctionCoverage) {
+	if h == nil || f.Hits == nil || f.Hits.Max == 0 {
+		return
+	}
+	h.functions = append(h.functions, f)
+}
+
+// top returns the top n functions, sorted by the max hit counts and then by the avg hit counts in descending order,
+// and the top n statements, sorted by the hit counts in descending order.
+func (h *hotPaths) top() ([]*report.FunctionCoverage, []*report.HotStatement) {
+	if h == nil {
+		return nil, nil
+	}
+
+	sort.SliceStable(h.functions, func(i, j int) bool {
+		hi, hj := h.functions[i].Hits, h.functions[j].Hits
+		if hi.Max != hj.Max {
+			return hi.Max > hj.Max
+		}
+		return hi.Avg > hj.Avg
+	})
+	sort.SliceStable(h.statements, func(i, j int) bool {
+		return h.statements[i].Hits > h.statements[j].Hits
+	})
+
+	functions, statements := h.functions, h.statements
+	if len(functions) > h.n {
+		functions = functions[:h.n]
+	}
+	if len(statements) > h.n {
+		statements = statements[:h.n]
+	}
+	return functions, statements
+}
@@ -0,0 +1,68 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotPaths(t *testing.T) {
+	fun := &parser.Function{
+		Name: "foo",
+		Statements: []*parser.Statement{
+			{StartLine: 1, EndLine: 1, Reached: 3, Mode: parser.Keep, State: parser.Original},
+			{StartLine: 2, EndLine: 3, Reached: 9, Mode: parser.Keep, State: parser.Changed},
+			{StartLine: 4, EndLine: 4, Reached: 20, Mode: parser.Ignore, State: parser.Changed},
+			{StartLine: 5, EndLine: 5, Reached: 0, Mode: parser.Keep, State: parser.Changed},
+			{StartLine: 6, EndLine: 6, Reached: 5, Mode: parser.Keep, State: parser.Changed},
+		},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		h := newHotPaths(0)
+		assert.Nil(t, h)
+		h.addStatements("foo.go", fun, "count", false)
+		h.addFunction(&report.FunctionCoverage{Hits: &report.HitCounts{Max: 1}})
+		functions, statements := h.top()
+		assert.Nil(t, functions)
+		assert.Nil(t, statements)
+	})
+
+	t.Run("set mode", func(t *testing.T) {
+		h := newHotPaths(3)
+		h.addStatements("foo.go", fun, "set", false)
+		h.addFunction(&report.FunctionCoverage{Function: "foo"})
+		functions, statements := h.top()
+		assert.Empty(t, functions)
+		assert.Empty(t, statements)
+	})
+
+	t.Run("rank by hit counts", func(t *testing.T) {
+		h := newHotPaths(2)
+		h.addStatements("foo.go", fun, "count", false)
+		h.addFunction(&report.FunctionCoverage{Function: "cold", Hits: &report.HitCounts{Max: 2, Avg: 1}})
+		h.addFunction(&report.FunctionCoverage{Function: "never", Hits: &report.HitCounts{}})
+		h.addFunction(&report.FunctionCoverage{Function: "warm", Hits: &report.HitCounts{Max: 9, Avg: 2}})
+		h.addFunction(&report.FunctionCoverage{Function: "hot", Hits: &report.HitCounts{Max: 9, Avg: 5}})
+
+		functions, statements := h.top()
+		assert.Len(t, functions, 2)
+		assert.Equal(t, "hot", functions[0].Function)
+		assert.Equal(t, "warm", functions[1].Function)
+		assert.Equal(t, []*report.HotStatement{
+			{FileName: "foo.go", Function: "foo", StartLine: 2, EndLine: 3, Hits: 9},
+			{FileName: "foo.go", Function: "foo", StartLine: 6, EndLine: 6, Hits: 5},
+		}, statements)
+	})
+
+	t.Run("changed only", func(t *testing.T) {
+		h := newHotPaths(5)
+		h.addStatements("foo.go", fun, "atomic", true)
+		_, statements := h.top()
+		assert.Len(t, statements, 2)
+		assert.Equal(t, 2, statements[0].StartLine)
+		assert.Equal(t, 6, statements[1].StartLine)
+	})
+}
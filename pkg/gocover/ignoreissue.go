@@ -0,0 +1,109 @@
+package gocover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/sirupsen/logrus"
+)
+
+// The ways that the closed issues of the ignore annotations are checked.
+const (
+	// WarnClosedIssues logs a warning for each closed issue.
+	WarnClosedIssues = "warn"
+	// FailClosedIssues fails the run if any issue is closed.
+	FailClosedIssues = "fail"
+)
+
+// issueChecker checks the issues that the ignore annotations are linked to by issue={owner}/{repo}#{number},
+// so that the annotations are cleaned up once their blockers are resolved.
+type issueChecker struct {
+	client *github.Client
+	fail   bool
+	logger logrus.FieldLogger
+}
+
+// newIssueChecker creates the checker of the closed issues, it returns nil if the check is disabled.
+func newIssueChecker(mode string, o *github.ClientOption, logger logrus.FieldLogger) (*issueChecker, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case WarnClosedIssues, FailClosedIssues:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownIssueCheck, mode)
+	}
+
+	if o == nil {
+		o = &github.ClientOption{}
+	}
+	client, err := github.NewClient(o, logger)
+	if err != nil {
+		return nil, fmt.Errorf("closed issues: %w", err)
+	}
+	return &issueChecker{client: client, fail: mode == FailClosedIssues, logger: logger}, nil
+}
+
+// check gets each issue of the ignore annotations once, and reports the annotations of the closed issues.
+func (c *issueChecker) check(ctx context.Context, profiles []*annotation.IgnoreProfile) error {
+	if c == nil {
+		return nil
+	}
+
+	annotations := make(map[annotation.Issue][]string)
+	add := func(issue *annotation.Issue, location string) {
+		if issue != nil {
+			annotations[*issue] = append(annotations[*issue], location)
+		}
+	}
+	for _, p := range profiles {
+		add(p.Issue, p.Filename)
+		for _, b := range p.IgnoreBlocks {
+			add(b.Issue, fmt.Sprintf("%s:%d", p.Filename, b.AnnotationLineNumber))
+		}
+		for _, b := range p.IgnoreLines {
+			add(b.Issue, fmt.Sprintf("%s:%d", p.Filename, b.AnnotationLineNumber))
+		}
+	}
+
+	issues := make([]annotation.Issue, 0, len(annotations))
+	for issue := range annotations {
+		issues = append(issues, issue)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].String() < issues[j].String()
+	})
+
+	var errs []error
+	for _, issue := range issues {
+		got, err := c.client.GetIssue(ctx, issue.Repository, issue.Number)
+		if err != nil {
+			if c.fail {
+				return fmt.Errorf("closed issues: %w", err)
+			}
+			c.logger.WithError(err).Warnf("cannot check the issue %s of the ignore annotations", issue.String())
+			continue
+		}
+		if got.State != github.IssueStateClosed {
+			continue
+		}
+
+		locations := annotations[issue]
+		sort.Strings(locations)
+		err = fmt.Errorf("issue %s is closed, clean up its ignore annotations: %s", issue.String(), strings.Join(locations, ", "))
+		if !c.fail {
+			c.logger.Warn(err)
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return WrapErrorWithCode(errors.Join(errs...), AnnotationErrorExitCode, "")
+}
@@ -0,0 +1,75 @@
+package gocover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestIssueChecker(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		state := "open"
+		if strings.HasSuffix(r.URL.Path, "/1") {
+			state = "closed"
+		}
+		fmt.Fprintf(w, `{"state": %q}`, state)
+	}))
+	defer server.Close()
+
+	o := &github.ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}
+	closed := &annotation.Issue{Repository: "foo/bar", Number: 1}
+	open := &annotation.Issue{Repository: "foo/bar", Number: 2}
+	profiles := []*annotation.IgnoreProfile{
+		{Type: annotation.FILE_IGNORE, Filename: "/src/a.go", Issue: closed},
+		{Type: annotation.BLOCK_IGNORE, Filename: "/src/b.go", IgnoreBlocks: map[cover.ProfileBlock]*annotation.IgnoreBlock{
+			{StartLine: 3}:  {AnnotationLineNumber: 4, Issue: closed},
+			{StartLine: 8}:  {AnnotationLineNumber: 9, Issue: open},
+			{StartLine: 12}: {AnnotationLineNumber: 13},
+		}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		checker, err := newIssueChecker("", o, logrus.New())
+		assert.NoError(t, err)
+		assert.Nil(t, checker)
+		assert.NoError(t, checker.check(context.Background(), profiles))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := newIssueChecker("error", o, logrus.New())
+		assert.ErrorIs(t, err, ErrUnknownIssueCheck)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		checker, err := newIssueChecker(WarnClosedIssues, o, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, checker.check(context.Background(), profiles))
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		for k := range requests {
+			delete(requests, k)
+		}
+		checker, err := newIssueChecker(FailClosedIssues, o, logrus.New())
+		assert.NoError(t, err)
+
+		err = checker.check(context.Background(), profiles)
+		var e *GoCoverError
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, AnnotationErrorExitCode, e.ExitCode)
+		assert.Equal(t, "issue foo/bar#1 is closed, clean up its ignore annotations: /src/a.go, /src/b.go:4", e.Err.Error())
+		assert.Equal(t, map[string]int{"/repos/foo/bar/issues/1": 1, "/repos/foo/bar/issues/2": 1}, requests)
+	})
+}
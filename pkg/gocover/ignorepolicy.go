@@ -0,0 +1,45 @@
+package gocover
+
+import (
+	"fmt"
+
+	"github.com/Azure/gocover/pkg/parser"
+)
+
+// IgnorePolicy decides how the statements ignored by the annotations count for coverage.
+type IgnorePolicy string
+
+const (
+	// ExcludeIgnored excludes the ignored statements from both the covered and the effective statements,
+	// so that they don't affect the coverage at all, it's the default policy.
+	ExcludeIgnored IgnorePolicy = "exclude"
+	// CountIgnored counts the ignored statements as the other statements by whether they're reached,
+	// the annotations are still recorded in the ignore profiles.
+	CountIgnored IgnorePolicy = "count"
+)
+
+// parseIgnorePolicy validates the ignore policy, it's ExcludeIgnored if it's empty.
+func parseIgnorePolicy(policy string) (IgnorePolicy, error) {
+	switch IgnorePolicy(policy) {
+	case "", ExcludeIgnored:
+		return ExcludeIgnored, nil
+	case CountIgnored:
+		return CountIgnored, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownIgnorePolicy, policy)
+	}
+}
+
+// applyIgnorePolicy keeps the ignored statements for coverage calculation when they're counted.
+func applyIgnorePolicy(packages parser.Packages, policy IgnorePolicy) {
+	if policy != CountIgnored {
+		return
+	}
+	for _, pkg := range packages {
+		for _, fun := range pkg.Functions {
+			for _, st := range fun.Statements {
+				st.Mode = parser.Keep
+			}
+		}
+	}
+}
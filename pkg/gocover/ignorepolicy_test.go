@@ -0,0 +1,53 @@
+package gocover
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIgnorePolicy(t *testing.T) {
+	for policy, expected := range map[string]IgnorePolicy{
+		"":        ExcludeIgnored,
+		"exclude": ExcludeIgnored,
+		"count":   CountIgnored,
+	} {
+		actual, err := parseIgnorePolicy(policy)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, policy)
+	}
+
+	_, err := parseIgnorePolicy("reached")
+	assert.True(t, errors.Is(err, ErrUnknownIgnorePolicy), err)
+}
+
+func TestApplyIgnorePolicy(t *testing.T) {
+	newPackages := func() parser.Packages {
+		return parser.Packages{{
+			Name: "github.com/foo/bar",
+			Functions: []*parser.Function{{
+				File: "github.com/foo/bar/a.go",
+				Statements: []*parser.Statement{
+					{Mode: parser.Keep, Reached: 1},
+					{Mode: parser.Ignore},
+				},
+			}},
+		}}
+	}
+
+	t.Run("exclude", func(t *testing.T) {
+		packages := newPackages()
+		applyIgnorePolicy(packages, ExcludeIgnored)
+		assert.Equal(t, parser.Ignore, packages[0].Functions[0].Statements[1].Mode)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		packages := newPackages()
+		applyIgnorePolicy(packages, CountIgnored)
+		for _, st := range packages[0].Functions[0].Statements {
+			assert.Equal(t, parser.Keep, st.Mode)
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package gocover
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	outTestImpactReport = "impact.json"
+)
+
+// TestImpact represents which tests cover the changed statements.
+type TestImpact struct {
+	// ComparedBranch is the branch that diff compared with.
+	ComparedBranch string `json:"comparedBranch"`
+	// Functions contains the functions that have changed statements.
+	Functions []*FunctionImpact `json:"functions"`
+}
+
+// FunctionImpact represents the tests that cover a function that has changes.
+type FunctionImpact struct {
+	// FileName is the file that the function belongs to.
+	FileName string `json:"fileName"`
+	// Name is the name of the function.
+	Name string `json:"name"`
+	// StartLine is the start line of the function.
+	StartLine int `json:"startLine"`
+	// EndLine is the end line of the function.
+	EndLine int `json:"endLine"`
+	// Tests are the tests that reach any statement of the function,
+	// they are the candidates to extend when changed statements are uncovered.
+	Tests []string `json:"tests"`
+	// Statements are the changed statements of the function.
+	Statements []*StatementImpact `json:"statements"`
+}
+
+// StatementImpact represents the tests that cover a changed statement.
+type StatementImpact struct {
+	// StartLine is the start line of the statement.
+	StartLine int `json:"startLine"`
+	// EndLine is the end line of the statement.
+	EndLine int `json:"endLine"`
+	// Tests are the tests that reach the statement.
+	Tests []string `json:"tests"`
+}
+
+// Covered reports whether the statement is covered by any test.
+func (s *StatementImpact) Covered() bool {
+	return len(s.Tests) != 0
+}
+
+func NewTestImpact(o *TestImpactOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "impact")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+
+	modulePath, err := parseGoModulePath(filepath.Join(repositoryAbsPath, o.ModuleDir))
+	if err != nil {
+		return nil, fmt.Errorf("parse go module path: %w", err)
+	}
+
+	testProfiles, err := parseTestProfiles(o.TestProfiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.OutputDir == "" {
+		dir, err := createGoCoverTempDirectory()
+		if err != nil {
+			return nil, fmt.Errorf("create gocover temp directory: %w", err)
+		}
+		o.OutputDir = dir
+	}
+
+	stdout, stderr := o.StdOut, o.StdErr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	return &testImpact{
+		repositoryPath:  repositoryAbsPath,
+		moduleDir:       o.ModuleDir,
+		modulePath:      modulePath,
+		comparedBranch:  o.CompareBranch,
+		outputDir:       o.OutputDir,
+		excludeFiles:    make(excludeFileCache),
+		excludePatterns: o.Excludes,
+		flags:           o.GoFlags,
+		testProfiles:    testProfiles,
+		executable:      goCmd(),
+		stdout:          stdout,
+		stderr:          stderr,
+		logger:          logger,
+	}, nil
+}
+
+var _ GoCover = (*testImpact)(nil)
+
+// testImpact implements the GoCover interface and maps the tests to the changed statements they cover.
+type testImpact struct {
+	repositoryPath  string
+	moduleDir       string
+	modulePath      string
+	comparedBranch  string
+	outputDir       string
+	excludePatterns []string
+	excludeFiles    excludeFileCache
+	flags           []string
+	testProfiles    map[string]string
+	executable      string
+	stdout          io.Writer
+	stderr          io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (t *testImpact) Run(ctx context.Context) error {
+	gitClient, err := gittool.NewGitClient(t.repositoryPath)
+	if err != nil {
+		return fmt.Errorf("git repository: %w", err)
+	}
+	changes, err := gitClient.DiffChangesFromCommitted(ctx, t.comparedBranch)
+	if err != nil {
+		return fmt.Errorf("git diff: %w", err)
+	}
+
+	profiles := t.testProfiles
+	if len(profiles) == 0 {
+		profiles, err = t.runTests(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	impact := &TestImpact{ComparedBranch: t.comparedBranch}
+	functions := make(map[string]*FunctionImpact)
+
+	var tests []string
+	for test := range profiles {
+		tests = append(tests, test)
+	}
+	sort.Strings(tests)
+
+	for _, test := range tests {
+		packages, err := parser.NewParser([]string{profiles[test]}, t.logger).Parse(ctx, changes)
+		if err != nil {
+			return fmt.Errorf("parse cover profile of %s: %w", test, err)
+		}
+		if err := t.collect(functions, test, packages); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range functions {
+		impact.Functions = append(impact.Functions, f)
+	}
+	sort.Slice(impact.Functions, func(i, j int) bool {
+		fi, fj := impact.Functions[i], impact.Functions[j]
+		return fi.FileName < fj.FileName || fi.FileName == fj.FileName && fi.StartLine < fj.StartLine
+	})
+
+	reportFile := filepath.Join(t.outputDir, outTestImpactReport)
+	data, err := json.MarshalIndent(impact, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	if err := os.WriteFile(reportFile, data, 0644); err != nil {
+		return fmt.Errorf("write test impact report: %w", err)
+	}
+	t.logger.Infof("generate test impact report: %s", reportFile)
+
+	writeTestImpact(t.stdout, impact)
+	return nil
+}
+
+// collect records the changed statements and functions that reached by the test.
+func (t *testImpact) collect(functions map[string]*FunctionImpact, test string, packages parser.Packages) error {
+	for _, pkg := range packages {
+		root, err := packageRoot("", pkg.Name)
+		if err != nil {
+			return fmt.Errorf("build import %w", err)
+		}
+
+		for _, fun := range pkg.Functions {
+			fileName := formatFilePath(root, fun.File, t.modulePath)
+			if inExclueds(t.excludeFiles, t.excludePatterns, fileName, t.logger) {
+				continue
+			}
+			collectFunctionImpact(functions, fileName, test, fun)
+		}
+	}
+	return nil
+}
+
+// collectFunctionImpact records the function and its changed statements that reached by the test.
+// Functions without changed statements are omitted.
+func collectFunctionImpact(functions map[string]*FunctionImpact, fileName string, test string, fun *parser.Function) {
+	key := fmt.Sprintf("%s:%d", fileName, fun.StartLine)
+
+	reached := false
+	var changed []*parser.Statement
+	for _, st := range fun.Statements {
+		if st.Reached > 0 {
+			reached = true
+		}
+		if st.State == parser.Changed {
+			changed = append(changed, st)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	f, ok := functions[key]
+	if !ok {
+		f = &FunctionImpact{
+			FileName:  fileName,
+			Name:      fun.Name,
+			StartLine: fun.StartLine,
+			EndLine:   fun.EndLine,
+			Tests:     []string{},
+		}
+		for _, st := range changed {
+			f.Statements = append(f.Statements, &StatementImpact{
+				StartLine: st.StartLine,
+				EndLine:   st.EndLine,
+				Tests:     []string{},
+			})
+		}
+		functions[key] = f
+	}
+
+	if reached {
+		f.Tests = append(f.Tests, test)
+	}
+	for i, st := range changed {
+		if st.Reached > 0 {
+			f.Statements[i].Tests = append(f.Statements[i].Tests, test)
+		}
+	}
+}
+
+// writeTestImpact outputs the summary of the test impact.
+func writeTestImpact(w io.Writer, impact *TestImpact) {
+	for _, f := range impact.Functions {
+		fmt.Fprintf(w, "%s:%d %s\n", f.FileName, f.StartLine, f.Name)
+		for _, st := range f.Statements {
+			if st.Covered() {
+				fmt.Fprintf(w, "  line %d-%d covered by: %s\n", st.StartLine, st.EndLine, strings.Join(st.Tests, ", "))
+			} else {
+				fmt.Fprintf(w, "  line %d-%d uncovered\n", st.StartLine, st.EndLine)
+			}
+		}
+		if len(f.Tests) == 0 {
+			fmt.Fprint(w, "  no test reaches this function\n")
+		} else {
+			fmt.Fprintf(w, "  tests to extend: %s\n", strings.Join(f.Tests, ", "))
+		}
+	}
+}
+
+// runTests runs each test of the module separately and returns the cover profile of each test.
+func (t *testImpact) runTests(ctx context.Context) (map[string]string, error) {
+	workingDir := filepath.Join(t.repositoryPath, t.moduleDir)
+
+	goFlags := []string{}
+	for _, flag := range t.flags {
+		if trimmed := strings.TrimSpace(flag); trimmed != "" {
+			goFlags = append(goFlags, trimmed)
+		}
+	}
+
+	listArgs := append([]string{"test", "-list", "."}, goFlags...)
+	listArgs = append(listArgs, "./...")
+
+	var buf bytes.Buffer
+	listCmd := exec.CommandContext(ctx, t.executable, listArgs...)
+	listCmd.Dir = workingDir
+	listCmd.Stdout = &buf
+	listCmd.Stderr = t.stderr
+	t.logger.Infof("list tests: '%s'", listCmd.String())
+	if err := listCmd.Run(); err != nil {
+		return nil, WrapErrorWithCode(fmt.Errorf("list tests: %w", err), UnitTestFailedErrorExitCode, "")
+	}
+
+	profiles := make(map[string]string)
+	for _, pt := range parseTestList(&buf) {
+		coverFile := filepath.Join(t.outputDir, fmt.Sprintf("impact-%d.out", len(profiles)))
+
+		runArgs := []string{"test", pt.pkg, "-run", fmt.Sprintf("^%s$", regexp.QuoteMeta(pt.test)), "-count=1"}
+		runArgs = append(runArgs, goFlags...)
+		runArgs = append(runArgs, "-coverprofile", coverFile, "-coverpkg=./...")
+
+		runCmd := exec.CommandContext(ctx, t.executable, runArgs...)
+		runCmd.Dir = workingDir
+		runCmd.Stdout = t.stdout
+		runCmd.Stderr = t.stderr
+		t.logger.Infof("run test: '%s'", runCmd.String())
+		if err := runCmd.Run(); err != nil {
+			// failed test still generates cover profile, keep going on
+			t.logger.WithError(err).Warnf("run test '%s'", runCmd.String())
+		}
+		if _, err := os.Stat(coverFile); err != nil {
+			t.logger.WithError(err).Warnf("no cover profile for %s", pt)
+			continue
+		}
+		profiles[pt.String()] = coverFile
+	}
+
+	return profiles, nil
+}
+
+// packageTest represents a test function of a package.
+type packageTest struct {
+	pkg  string
+	test string
+}
+
+func (pt packageTest) String() string {
+	return fmt.Sprintf("%s.%s", pt.pkg, pt.test)
+}
+
+// parseTestList parses the output of `go test -list . ./...`,
+// the tests are listed ahead of the package result line, for example:
+//
+//	TestFoo
+//	TestBar
+//	ok  	github.com/Azure/gocover/pkg/foo	0.010s
+//	?   	github.com/Azure/gocover/pkg/bar	[no test files]
+func parseTestList(r io.Reader) []packageTest {
+	var result []packageTest
+	var tests []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "ok" && len(fields) > 1:
+			for _, test := range tests {
+				result = append(result, packageTest{pkg: fields[1], test: test})
+			}
+			tests = nil
+		case fields[0] == "?" || fields[0] == "FAIL":
+			tests = nil
+		case len(fields) == 1 && isTestName(fields[0]):
+			tests = append(tests, fields[0])
+		}
+	}
+	return result
+}
+
+func isTestName(name string) bool {
+	return strings.HasPrefix(name, "Test") || strings.HasPrefix(name, "Example") || strings.HasPrefix(name, "Fuzz")
+}
+
+// parseTestProfiles parses the test profiles with format {test}={profile}.
+func parseTestProfiles(values []string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, v := range values {
+		tokens := strings.SplitN(v, "=", 2)
+		if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+			return nil, fmt.Errorf("%w: %s, format is {test}={profile}", ErrWrongTestProfileFormat, v)
+		}
+		result[tokens[0]] = tokens[1]
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package gocover
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTestList(t *testing.T) {
+	t.Run("parseTestList", func(t *testing.T) {
+		output := `TestFoo
+TestBar
+ok  	github.com/Azure/gocover/pkg/foo	0.010s
+?   	github.com/Azure/gocover/pkg/bar	[no test files]
+ExampleZoo
+FuzzZoo
+ok  	github.com/Azure/gocover/pkg/zoo	0.012s
+`
+		tests := parseTestList(strings.NewReader(output))
+		assert.Equal(t, []packageTest{
+			{pkg: "github.com/Azure/gocover/pkg/foo", test: "TestFoo"},
+			{pkg: "github.com/Azure/gocover/pkg/foo", test: "TestBar"},
+			{pkg: "github.com/Azure/gocover/pkg/zoo", test: "ExampleZoo"},
+			{pkg: "github.com/Azure/gocover/pkg/zoo", test: "FuzzZoo"},
+		}, tests)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/foo.TestFoo", tests[0].String())
+	})
+}
+
+func TestParseTestProfiles(t *testing.T) {
+	t.Run("valid test profiles", func(t *testing.T) {
+		profiles, err := parseTestProfiles([]string{"TestFoo=foo.out", "TestBar=/tmp/bar.out"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"TestFoo": "foo.out", "TestBar": "/tmp/bar.out"}, profiles)
+	})
+
+	t.Run("invalid test profiles", func(t *testing.T) {
+		for _, v := range []string{"TestFoo", "=foo.out", "TestFoo="} {
+			_, err := parseTestProfiles([]string{v})
+			if !errors.Is(err, ErrWrongTestProfileFormat) {
+				t.Errorf("for input %s, expect error %s, but get %v", v, ErrWrongTestProfileFormat, err)
+			}
+		}
+	})
+}
+
+func TestCollectFunctionImpact(t *testing.T) {
+	t.Run("collectFunctionImpact", func(t *testing.T) {
+		functions := make(map[string]*FunctionImpact)
+
+		newFunction := func(reached ...int64) *parser.Function {
+			return &parser.Function{
+				Name:      "foo",
+				StartLine: 10,
+				EndLine:   20,
+				Statements: []*parser.Statement{
+					{StartLine: 11, EndLine: 11, State: parser.Original, Reached: reached[0]},
+					{StartLine: 12, EndLine: 12, State: parser.Changed, Reached: reached[1]},
+					{StartLine: 13, EndLine: 13, State: parser.Changed, Reached: reached[2]},
+				},
+			}
+		}
+
+		collectFunctionImpact(functions, "foo.go", "TestA", newFunction(1, 1, 0))
+		collectFunctionImpact(functions, "foo.go", "TestB", newFunction(1, 0, 0))
+		collectFunctionImpact(functions, "foo.go", "TestC", newFunction(0, 0, 0))
+		collectFunctionImpact(functions, "bar.go", "TestA", &parser.Function{
+			Name:       "bar",
+			Statements: []*parser.Statement{{State: parser.Original, Reached: 1}},
+		})
+
+		assert.Len(t, functions, 1)
+		f := functions["foo.go:10"]
+		assert.Equal(t, []string{"TestA", "TestB"}, f.Tests)
+		assert.Len(t, f.Statements, 2)
+		assert.Equal(t, []string{"TestA"}, f.Statements[0].Tests)
+		assert.True(t, f.Statements[0].Covered())
+		assert.Empty(t, f.Statements[1].Tests)
+		assert.False(t, f.Statements[1].Covered())
+
+		var buf bytes.Buffer
+		writeTestImpact(&buf, &TestImpact{Functions: []*FunctionImpact{f}})
+		output := buf.String()
+		assert.Contains(t, output, "foo.go:10 foo")
+		assert.Contains(t, output, "line 12-12 covered by: TestA")
+		assert.Contains(t, output, "line 13-13 uncovered")
+		assert.Contains(t, output, "tests to extend: TestA, TestB")
+	})
+}
@@ -0,0 +1,455 @@
+package gocover
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/build/constraint"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultInitProfile and defaultInitTestCommand are used in the CI snippet when the repository has no coverage command.
+	defaultInitProfile     = "coverage.out"
+	defaultInitTestCommand = "go test -coverprofile=coverage.out ./..."
+)
+
+// initBuildTags are the build tags of the test harnesses that are usually excluded from coverage.
+var initBuildTags = map[string]bool{"integration": true, "e2e": true, "functional": true, "acceptance": true, "tools": true}
+
+// initCIFiles are the files of each CI system that are checked in order to detect the CI provider of the repository.
+var initCIFiles = []struct {
+	provider ci.Provider
+	patterns []string
+}{
+	{provider: ci.GitHubActions, patterns: []string{".github/workflows/*.yml", ".github/workflows/*.yaml"}},
+	{provider: ci.GitLab, patterns: []string{".gitlab-ci.yml"}},
+	{provider: ci.AzurePipelines, patterns: []string{"azure-pipelines.yml", ".azure-pipelines/*.yml", ".pipelines/*.yml"}},
+	{provider: ci.Bitbucket, patterns: []string{"bitbucket-pipelines.yml"}},
+	{provider: ci.Jenkins, patterns: []string{"Jenkinsfile"}},
+	{provider: ci.Buildkite, patterns: []string{".buildkite/*.yml", ".buildkite/*.yaml"}},
+}
+
+// initScriptFiles are the build scripts that are scanned for the coverage commands besides the CI files.
+var initScriptFiles = []string{"Makefile", "makefile", "GNUmakefile", "*.mk", "scripts/*.sh", "hack/*.sh", "Taskfile.yml", "magefile.go"}
+
+var (
+	coverProfileRegexp = regexp.MustCompile(`-coverprofile[= ]["']?([^\s"']+)`)
+	gocoverRegexp      = regexp.MustCompile(`\bgocover\s+(full|diff|test)\b`)
+)
+
+// NewInit creates a GoCover that inspects the repository and writes a starter configuration file and prints a CI snippet.
+func NewInit(o *InitOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "init")
+
+	provider := ci.Provider(strings.ToLower(o.CIProvider))
+	if provider != "" && !isInitProvider(provider) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCIProvider, o.CIProvider)
+	}
+
+	repositoryPath := o.RepositoryPath
+	if repositoryPath == "" {
+		repositoryPath = "."
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &initConfig{
+		repositoryPath: repositoryPath,
+		provider:       provider,
+		force:          o.Force,
+		stdout:         stdout,
+		logger:         logger,
+	}, nil
+}
+
+func isInitProvider(provider ci.Provider) bool {
+	for _, f := range initCIFiles {
+		if f.provider == provider {
+			return true
+		}
+	}
+	return false
+}
+
+var _ GoCover = (*initConfig)(nil)
+
+// initConfig implements the GoCover interface and scaffolds the configuration of a repository that adopts gocover.
+type initConfig struct {
+	repositoryPath string
+	provider       ci.Provider // the CI system of the snippet, it's detected if it's empty
+	force          bool        // overwrite the configuration file that exists
+	stdout         io.Writer
+
+	logger logrus.FieldLogger
+}
+
+// repositoryLayout is what the repository has that the configuration and the CI snippet are generated from.
+type repositoryLayout struct {
+	Modules     []*moduleLayout // the module at the root, or the closest to the root, is the first
+	Provider    ci.Provider     // empty if none of the CI files exists
+	Commands    []*coverageCommand
+	BuildTags   []string // the tags of the test harnesses in the build constraints
+	GocoverRuns []string // the files that run gocover already
+}
+
+// moduleLayout is a go module of the repository.
+type moduleLayout struct {
+	Path string
+	// Dir is the directory relative to the repository, "." for the root.
+	Dir string
+	// RepositoryPath is the path of the repository relative to Dir.
+	RepositoryPath string
+}
+
+// coverageCommand is a go test command that writes a cover profile in the build scripts or the CI files.
+type coverageCommand struct {
+	File    string
+	Line    int
+	Command string
+	Profile string
+}
+
+func (i *initConfig) Run(ctx context.Context) error {
+	layout, err := inspectRepository(ctx, i.repositoryPath)
+	if err != nil {
+		return fmt.Errorf("inspect repository: %w", err)
+	}
+	if len(layout.Modules) == 0 {
+		return fmt.Errorf("%w: no go.mod in %s", ErrModuleNotFound, i.repositoryPath)
+	}
+	if i.provider != "" {
+		layout.Provider = i.provider
+	}
+
+	configFile := filepath.Join(i.repositoryPath, config.DefaultFile)
+	if _, err := os.Stat(configFile); err == nil && !i.force {
+		return fmt.Errorf("%w: %s, use --force to overwrite it", ErrConfigExists, configFile)
+	}
+	var b bytes.Buffer
+	if err := configTemplate.Execute(&b, layout); err != nil {
+		return fmt.Errorf("generate configuration: %w", err)
+	}
+	if err := os.WriteFile(configFile, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write configuration: %w", err)
+	}
+
+	w := i.stdout
+	for _, m := range layout.Modules {
+		fmt.Fprintf(w, "module: %s (%s)\n", m.Path, m.Dir)
+	}
+	if layout.Provider != "" {
+		fmt.Fprintf(w, "ci provider: %s\n", layout.Provider)
+	}
+	for _, c := range layout.Commands {
+		fmt.Fprintf(w, "coverage command: %s:%d: %s\n", c.File, c.Line, c.Command)
+	}
+	for _, f := range layout.GocoverRuns {
+		fmt.Fprintf(w, "gocover runs in %s already, compare the snippet with it\n", f)
+	}
+	fmt.Fprintf(w, "wrote %s\n\n", configFile)
+
+	return ciSnippet(w, layout)
+}
+
+// inspectRepository finds the modules and the build tags of the test harnesses in the go files,
+// and the CI provider and the coverage commands in the CI files and the build scripts.
+func inspectRepository(ctx context.Context, repositoryPath string) (*repositoryLayout, error) {
+	layout := &repositoryLayout{}
+	tags := make(map[string]bool)
+	err := filepath.WalkDir(repositoryPath, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != repositoryPath && (skipLintDir(d.Name()) || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.Name() == "go.mod":
+			dir := filepath.Dir(name)
+			modulePath, err := parseGoModulePath(dir)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(repositoryPath, dir)
+			if err != nil {
+				return err
+			}
+			layout.Modules = append(layout.Modules, &moduleLayout{
+				Path:           modulePath,
+				Dir:            filepath.ToSlash(rel),
+				RepositoryPath: relativeRepositoryPath(filepath.ToSlash(rel)),
+			})
+		case strings.HasSuffix(name, ".go"):
+			for _, tag := range harnessBuildTags(name) {
+				tags[tag] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return strings.Count(dir, "/") + 1
+	}
+	sort.SliceStable(layout.Modules, func(i, j int) bool {
+		a, b := layout.Modules[i].Dir, layout.Modules[j].Dir
+		if depth(a) != depth(b) {
+			return depth(a) < depth(b)
+		}
+		return a < b
+	})
+	for tag := range tags {
+		layout.BuildTags = append(layout.BuildTags, tag)
+	}
+	sort.Strings(layout.BuildTags)
+
+	var files []string
+	for _, f := range initCIFiles {
+		for _, pattern := range f.patterns {
+			matches, _ := filepath.Glob(filepath.Join(repositoryPath, filepath.FromSlash(pattern)))
+			if len(matches) > 0 && layout.Provider == "" {
+				layout.Provider = f.provider
+			}
+			files = append(files, matches...)
+		}
+	}
+	for _, pattern := range initScriptFiles {
+		matches, _ := filepath.Glob(filepath.Join(repositoryPath, filepath.FromSlash(pattern)))
+		files = append(files, matches...)
+	}
+	for _, file := range files {
+		if err := scanCoverageCommands(layout, repositoryPath, file); err != nil {
+			return nil, err
+		}
+	}
+	return layout, nil
+}
+
+// relativeRepositoryPath returns the path from the module directory back to the repository, such as "../.." for "tools/lint".
+func relativeRepositoryPath(dir string) string {
+	if dir == "." {
+		return "."
+	}
+	return strings.TrimSuffix(strings.Repeat("../", strings.Count(dir, "/")+1), "/")
+}
+
+// harnessBuildTags returns the build tags of the test harnesses in the build constraint of the go file.
+func harnessBuildTags(fileName string) []string {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var tags []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if !constraint.IsGoBuild(line) {
+			continue
+		}
+		expr, err := constraint.Parse(line)
+		if err != nil {
+			continue
+		}
+		// the tags are collected whether they're required or negated, both mean a harness exists
+		expr.Eval(func(tag string) bool {
+			if initBuildTags[tag] {
+				tags = append(tags, tag)
+			}
+			return true
+		})
+	}
+	return tags
+}
+
+// scanCoverageCommands adds the go test commands with -coverprofile of the file, and whether gocover runs in it.
+func scanCoverageCommands(layout *repositoryLayout, repositoryPath string, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(repositoryPath, file)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+
+	runsGocover := false
+	for i, line := range strings.Split(string(data), "\n") {
+		if gocoverRegexp.MatchString(line) {
+			runsGocover = true
+		}
+		if !strings.Contains(line, "go test") {
+			continue
+		}
+		match := coverProfileRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		command := strings.TrimSpace(line)
+		if i := strings.Index(command, "go test"); i > 0 {
+			command = command[i:]
+		}
+		layout.Commands = append(layout.Commands, &coverageCommand{File: rel, Line: i + 1, Command: command, Profile: match[1]})
+	}
+	if runsGocover {
+		layout.GocoverRuns = append(layout.GocoverRuns, rel)
+	}
+	return nil
+}
+
+// TestCommand returns the coverage command of the snippet, the existing one is kept when there is a single module.
+func (l *repositoryLayout) TestCommand() string {
+	if len(l.Modules) == 1 && len(l.Commands) > 0 {
+		return l.Commands[0].Command
+	}
+	return defaultInitTestCommand
+}
+
+// Profile returns the cover profile that the coverage command writes.
+func (l *repositoryLayout) Profile() string {
+	if len(l.Modules) == 1 && len(l.Commands) > 0 {
+		return l.Commands[0].Profile
+	}
+	return defaultInitProfile
+}
+
+var configTemplate = template.Must(template.New("config").Funcs(template.FuncMap{"join": strings.Join}).Parse(
+	`# gocover configuration generated by gocover init, the flags override the values in it.
+# See "Configuration File" in the README of gocover for all the settings.
+{{- if gt (len .Modules) 1 }}
+#
+# The modules of the repository, run gocover in the directory of each module with --module-dir and --repository-path:
+{{- range .Modules }}
+#   {{ .Dir }}: {{ .Path }}
+{{- end }}
+{{- end }}
+
+# code that is excluded from coverage calculation
+exclude:
+  # regular expressions of the function names, methods are named T.N, such as 'String$'
+  functions: []
+  # build tags whose files are excluded, such as the test harnesses guarded by //go:build integration
+  buildTags: [{{ join .BuildTags ", " }}]
+
+# key/value labels of the runs, which are merged with --run-labels
+# runLabels:
+#   service: api
+
+# retry policy of the calls to the external services
+# retry:
+#   maxAttempts: 3
+#   initialBackoff: 1s
+`))
+
+// ciSnippets are the snippets of the CI systems, the others run the commands in a shell step.
+var ciSnippets = map[ci.Provider]string{
+	ci.GitHubActions: `# .github/workflows/coverage.yml
+name: coverage
+on: [pull_request]
+jobs:
+  coverage:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: {{ (index .Modules 0).Dir }}/go.mod
+      - run: go install github.com/Azure/gocover@latest
+{{- range .Modules }}
+      - name: coverage of {{ .Path }}
+        working-directory: {{ .Dir }}
+        run: |
+          {{ $.TestCommand }}
+          gocover diff --cover-profile {{ $.Profile }}{{ if ne .Dir "." }} --repository-path {{ .RepositoryPath }} --module-dir {{ .Dir }}{{ end }} --outputdir coverage
+{{- end }}
+`,
+	ci.GitLab: `# .gitlab-ci.yml
+coverage:
+  image: golang:latest
+  variables:
+    GIT_DEPTH: 0
+  rules:
+    - if: $CI_PIPELINE_SOURCE == "merge_request_event"
+  script:
+    - go install github.com/Azure/gocover@latest
+{{- range .Modules }}
+    - cd {{ .Dir }} && {{ $.TestCommand }} && gocover diff --cover-profile {{ $.Profile }}{{ if ne .Dir "." }} --repository-path {{ .RepositoryPath }} --module-dir {{ .Dir }}{{ end }} --summary-line --outputdir coverage; cd $CI_PROJECT_DIR
+{{- end }}
+  coverage: '/diff coverage: \d+\.\d+%/'
+`,
+	ci.AzurePipelines: `# azure-pipelines.yml
+steps:
+  - checkout: self
+    fetchDepth: 0
+  - script: go install github.com/Azure/gocover@latest
+{{- range .Modules }}
+  - script: |
+      {{ $.TestCommand }}
+      $(go env GOPATH)/bin/gocover diff --cover-profile {{ $.Profile }}{{ if ne .Dir "." }} --repository-path {{ .RepositoryPath }} --module-dir {{ .Dir }}{{ end }} --azure-devops-dir $(Build.ArtifactStagingDirectory)/coverage
+    workingDirectory: {{ .Dir }}
+    displayName: coverage of {{ .Path }}
+{{- end }}
+`,
+}
+
+const shellSnippet = `# run in the pull request builds{{ if .Provider }} of {{ .Provider }}{{ end }}, with the full history of the repository
+go install github.com/Azure/gocover@latest
+{{- range .Modules }}
+(cd {{ .Dir }} && {{ $.TestCommand }} && gocover diff --cover-profile {{ $.Profile }}{{ if ne .Dir "." }} --repository-path {{ .RepositoryPath }} --module-dir {{ .Dir }}{{ end }} --outputdir coverage)
+{{- end }}
+`
+
+// ciSnippet prints the steps that run the coverage and gocover in the CI system of the repository.
+func ciSnippet(w io.Writer, layout *repositoryLayout) error {
+	text, ok := ciSnippets[layout.Provider]
+	if !ok {
+		text = shellSnippet
+	}
+	t, err := template.New("ci").Parse(text)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(w, layout); err != nil {
+		return fmt.Errorf("generate ci snippet: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":                      "module github.com/example/app\n",
+		"main.go":                     "package main\n",
+		"e2e/e2e_test.go":             "//go:build e2e && !windows\n\npackage e2e\n",
+		"tools/go.mod":                "module github.com/example/app/tools\n",
+		"vendor/x/go.mod":             "module x\n",
+		"Makefile":                    "test:\n\tgo test -race -coverprofile=cover.out ./...\n",
+		".github/workflows/ci.yml":    "jobs:\n  test:\n    steps:\n      - run: gocover diff --cover-profile cover.out\n",
+		".github/workflows/lint.yaml": "jobs: {}\n",
+	} {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+
+	t.Run("inspect", func(t *testing.T) {
+		layout, err := inspectRepository(context.Background(), dir)
+		assert.NoError(t, err)
+		assert.Equal(t, []*moduleLayout{
+			{Path: "github.com/example/app", Dir: ".", RepositoryPath: "."},
+			{Path: "github.com/example/app/tools", Dir: "tools", RepositoryPath: ".."},
+		}, layout.Modules)
+		assert.Equal(t, ci.GitHubActions, layout.Provider)
+		assert.Equal(t, []string{"e2e"}, layout.BuildTags)
+		assert.Equal(t, []*coverageCommand{
+			{File: "Makefile", Line: 2, Command: "go test -race -coverprofile=cover.out ./...", Profile: "cover.out"},
+		}, layout.Commands)
+		assert.Equal(t, []string{".github/workflows/ci.yml"}, layout.GocoverRuns)
+		// the existing command is kept only when there is a single module
+		assert.Equal(t, defaultInitTestCommand, layout.TestCommand())
+	})
+
+	t.Run("init", func(t *testing.T) {
+		var stdout bytes.Buffer
+		g, err := NewInit(&InitOption{RepositoryPath: dir, CIProvider: "GitLab", StdOut: &stdout})
+		assert.NoError(t, err)
+		assert.NoError(t, g.Run(context.Background()))
+
+		c, err := config.Load(filepath.Join(dir, config.DefaultFile), true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e2e"}, c.Exclude.BuildTags)
+
+		assert.Contains(t, stdout.String(), "ci provider: gitlab\n")
+		assert.Contains(t, stdout.String(), "gocover runs in .github/workflows/ci.yml already")
+		assert.Contains(t, stdout.String(), "GIT_DEPTH: 0")
+		assert.Contains(t, stdout.String(), "cd tools && go test -coverprofile=coverage.out ./... && gocover diff --cover-profile coverage.out --repository-path .. --module-dir tools")
+	})
+
+	t.Run("configuration exists", func(t *testing.T) {
+		g, err := NewInit(&InitOption{RepositoryPath: dir, StdOut: &bytes.Buffer{}})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, g.Run(context.Background()), ErrConfigExists)
+
+		g, err = NewInit(&InitOption{RepositoryPath: dir, Force: true, StdOut: &bytes.Buffer{}})
+		assert.NoError(t, err)
+		assert.NoError(t, g.Run(context.Background()))
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := NewInit(&InitOption{RepositoryPath: dir, CIProvider: "travis"})
+		assert.ErrorIs(t, err, ErrUnknownCIProvider)
+	})
+
+	t.Run("no module", func(t *testing.T) {
+		g, err := NewInit(&InitOption{RepositoryPath: t.TempDir(), StdOut: &bytes.Buffer{}})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, g.Run(context.Background()), ErrModuleNotFound)
+	})
+}
+
+func TestRelativeRepositoryPath(t *testing.T) {
+	assert.Equal(t, ".", relativeRepositoryPath("."))
+	assert.Equal(t, "..", relativeRepositoryPath("tools"))
+	assert.Equal(t, "../..", relativeRepositoryPath("tools/lint"))
+}
@@ -0,0 +1,219 @@
+package gocover
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+// labeledProfiles represents the cover profiles grouped by their labels, such as unit, integration or e2e.
+type labeledProfiles struct {
+	// labels keeps the order of the labels as they are passed in.
+	labels   []string
+	profiles map[string][]string
+}
+
+// parseCoverProfiles parses the cover profile inputs, each input is either a path of the cover profile,
+// or has format {label}={profile} to label the cover profile.
+// It returns all the cover profile paths and the labeled cover profiles.
+func parseCoverProfiles(values []string) ([]string, *labeledProfiles) {
+	var all []string
+	labeled := &labeledProfiles{profiles: make(map[string][]string)}
+
+	for _, v := range values {
+		label, profile := splitLabel(v)
+		all = append(all, profile)
+		if label == "" {
+			continue
+		}
+		if _, ok := labeled.profiles[label]; !ok {
+			labeled.labels = append(labeled.labels, label)
+		}
+		labeled.profiles[label] = append(labeled.profiles[label], profile)
+	}
+
+	return all, labeled
+}
+
+// splitLabel splits the input with format {label}={profile}.
+// Label should not contain path separator, so that the path that contains "=" is kept as it is.
+func splitLabel(v string) (string, string) {
+	idx := strings.Index(v, "=")
+	if idx <= 0 || strings.ContainsAny(v[:idx], `/\`) {
+		return "", v
+	}
+	return v[:idx], v[idx+1:]
+}
+
+// statementKey identifies a statement in a file.
+type statementKey struct {
+	file  string
+	start int
+	end   int
+}
+
+// labelCoverage collects the coverage for each label over the statements that count for the overall coverage,
+// so the coverage for each label shares the same denominators with the overall coverage.
+// The statements that are covered by some labels but missed by the others are collected as the gaps of the labels,
+// such as the code covered only on linux when the cover profiles are labeled by the legs of a matrix build.
+type labelCoverage struct {
+	labels  []string
+	reached map[string]map[statementKey]int64
+
+	covered           map[string]int
+	coveredButIgnored map[string]int
+	missed            map[string]int
+	gaps              []*report.LabelGap
+}
+
+// newLabelCoverage parses the cover profiles of each label with the parsers of newParser, which resolve the files
+// in the same way as the unlabeled cover profiles. It returns nil if there is no labeled cover profile.
+func newLabelCoverage(ctx context.Context, labeled *labeledProfiles, changes []*gittool.Change, newParser func([]string, logrus.FieldLogger) *parser.Parser, logger logrus.FieldLogger) (*labelCoverage, error) {
+	if labeled == nil || len(labeled.labels) == 0 {
+		return nil, nil
+	}
+
+	lc := &labelCoverage{
+		labels:            labeled.labels,
+		reached:           make(map[string]map[statementKey]int64),
+		covered:           make(map[string]int),
+		coveredButIgnored: make(map[string]int),
+		missed:            make(map[string]int),
+	}
+
+	for _, label := range labeled.labels {
+		packages, err := newParser(labeled.profiles[label], logger.WithField("label", label)).Parse(ctx, changes)
+		if err != nil {
+			return nil, err
+		}
+
+		reached := make(map[statementKey]int64)
+		for _, pkg := range packages {
+			for _, fun := range pkg.Functions {
+				for _, st := range fun.Statements {
+					reached[statementKey{file: fun.File, start: st.Start, end: st.End}] += st.Reached
+				}
+			}
+		}
+		lc.reached[label] = reached
+	}
+
+	return lc, nil
+}
+
+// labelCounter counts the covered statements of each label for a portion of statements.
+type labelCounter struct {
+	fileName          string
+	function          string
+	covered           map[string]int
+	coveredButIgnored map[string]int
+	gaps              []*report.LabelGap
+}
+
+// newCounter returns a counter for counting a portion of statements, such as the statements of a function,
+// which is added to the label coverage when the portion counts for coverage. The file name and the function
+// are the location of the gaps in the reports.
+func (lc *labelCoverage) newCounter(fileName string, function string) *labelCounter {
+	return &labelCounter{
+		fileName:          fileName,
+		function:          function,
+		covered:           make(map[string]int),
+		coveredButIgnored: make(map[string]int),
+	}
+}
+
+// count counts the statement of the file for each label.
+func (lc *labelCoverage) count(counter *labelCounter, file string, st *parser.Statement) {
+	if lc == nil {
+		return
+	}
+	key := statementKey{file: file, start: st.Start, end: st.End}
+	var coveredBy, missedBy []string
+	for _, label := range lc.labels {
+		if lc.reached[label][key] > 0 {
+			counter.covered[label]++
+			if st.Mode == parser.Ignore {
+				counter.coveredButIgnored[label]++
+			}
+			coveredBy = append(coveredBy, label)
+		} else {
+			missedBy = append(missedBy, label)
+		}
+	}
+	if st.Mode != parser.Ignore && len(coveredBy) > 0 && len(missedBy) > 0 {
+		counter.gaps = append(counter.gaps, &report.LabelGap{
+			FileName:  counter.fileName,
+			Function:  counter.function,
+			StartLine: st.StartLine,
+			EndLine:   st.EndLine,
+			CoveredBy: coveredBy,
+			MissedBy:  missedBy,
+		})
+	}
+}
+
+// add adds the counter to the label coverage.
+func (lc *labelCoverage) add(counter *labelCounter) {
+	if lc == nil {
+		return
+	}
+	for label, v := range counter.covered {
+		lc.covered[label] += v
+	}
+	for label, v := range counter.coveredButIgnored {
+		lc.coveredButIgnored[label] += v
+	}
+	for _, gap := range counter.gaps {
+		for _, label := range gap.MissedBy {
+			lc.missed[label]++
+		}
+	}
+	lc.gaps = append(lc.gaps, counter.gaps...)
+}
+
+// statistics builds the coverage statistics of each label based on the overall statistics.
+func (lc *labelCoverage) statistics(s *report.Statistics) []*report.LabelStatistics {
+	if lc == nil {
+		return nil
+	}
+
+	var result []*report.LabelStatistics
+	for _, label := range lc.labels {
+		covered, coveredButIgnored := lc.covered[label], lc.coveredButIgnored[label]
+		result = append(result, &report.LabelStatistics{
+			Label:                       label,
+			TotalCoveredLines:           covered,
+			TotalCoveredButIgnoredLines: coveredButIgnored,
+			TotalCoveragePercent: calculateCoverage(
+				int64(covered-coveredButIgnored),
+				int64(s.TotalEffectiveLines),
+			),
+			TotalCoverageWithoutIgnore: calculateCoverage(
+				int64(covered),
+				int64(s.TotalLines),
+			),
+			MissedLines: lc.missed[label],
+		})
+	}
+	return result
+}
+
+// labelGaps returns the statements that are covered by some labels but missed by the others, sorted by location.
+func (lc *labelCoverage) labelGaps() []*report.LabelGap {
+	if lc == nil {
+		return nil
+	}
+
+	sort.SliceStable(lc.gaps, func(i, j int) bool {
+		if lc.gaps[i].FileName != lc.gaps[j].FileName {
+			return lc.gaps[i].FileName < lc.gaps[j].FileName
+		}
+		return lc.gaps[i].StartLine < lc.gaps[j].StartLine
+	})
+	return lc.gaps
+}
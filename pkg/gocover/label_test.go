@@ -0,0 +1,100 @@
+package gocover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCoverProfiles(t *testing.T) {
+	t.Run("parseCoverProfiles", func(t *testing.T) {
+		all, labeled := parseCoverProfiles([]string{
+			"coverage.out",
+			"unit=unit.out",
+			"integration=/tmp/integration.out",
+			"unit=unit2.out",
+			"/tmp/a=b/coverage.out",
+		})
+
+		assert.Equal(t, []string{"coverage.out", "unit.out", "/tmp/integration.out", "unit2.out", "/tmp/a=b/coverage.out"}, all)
+		assert.Equal(t, []string{"unit", "integration"}, labeled.labels)
+		assert.Equal(t, []string{"unit.out", "unit2.out"}, labeled.profiles["unit"])
+		assert.Equal(t, []string{"/tmp/integration.out"}, labeled.profiles["integration"])
+	})
+
+	t.Run("no labels", func(t *testing.T) {
+		all, labeled := parseCoverProfiles([]string{"coverage.out"})
+		assert.Equal(t, []string{"coverage.out"}, all)
+		assert.Empty(t, labeled.labels)
+
+		lc, err := newLabelCoverage(context.Background(), labeled, nil, parser.NewParser, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, lc)
+	})
+}
+
+func TestLabelCoverage(t *testing.T) {
+	t.Run("nil label coverage", func(t *testing.T) {
+		var lc *labelCoverage
+		counter := lc.newCounter("foo.go", "foo")
+		lc.count(counter, "foo.go", &parser.Statement{})
+		lc.add(counter)
+		assert.Nil(t, lc.statistics(&report.Statistics{}))
+		assert.Nil(t, lc.labelGaps())
+	})
+
+	t.Run("count statements for labels", func(t *testing.T) {
+		lc := &labelCoverage{
+			labels: []string{"unit", "e2e"},
+			reached: map[string]map[statementKey]int64{
+				"unit": {
+					{file: "foo.go", start: 0, end: 10}:  1,
+					{file: "foo.go", start: 20, end: 30}: 0,
+				},
+				"e2e": {
+					{file: "foo.go", start: 0, end: 10}:  2,
+					{file: "foo.go", start: 20, end: 30}: 1,
+					{file: "foo.go", start: 40, end: 50}: 1,
+				},
+			},
+			covered:           make(map[string]int),
+			coveredButIgnored: make(map[string]int),
+			missed:            make(map[string]int),
+		}
+
+		counter := lc.newCounter("foo.go", "foo")
+		lc.count(counter, "foo.go", &parser.Statement{Start: 0, End: 10})
+		lc.count(counter, "foo.go", &parser.Statement{Start: 20, End: 30})
+		lc.count(counter, "foo.go", &parser.Statement{Start: 40, End: 50, Mode: parser.Ignore})
+		lc.add(counter)
+
+		// the counter that is not added won't count
+		ignored := lc.newCounter("foo.go", "foo")
+		lc.count(ignored, "foo.go", &parser.Statement{Start: 0, End: 10})
+
+		statistics := lc.statistics(&report.Statistics{TotalLines: 4, TotalEffectiveLines: 3})
+		assert.Len(t, statistics, 2)
+
+		assert.Equal(t, "unit", statistics[0].Label)
+		assert.Equal(t, 1, statistics[0].TotalCoveredLines)
+		assert.Equal(t, 0, statistics[0].TotalCoveredButIgnoredLines)
+		assert.Equal(t, calculateCoverage(1, 3), statistics[0].TotalCoveragePercent)
+		assert.Equal(t, calculateCoverage(1, 4), statistics[0].TotalCoverageWithoutIgnore)
+
+		assert.Equal(t, "e2e", statistics[1].Label)
+		assert.Equal(t, 3, statistics[1].TotalCoveredLines)
+		assert.Equal(t, 1, statistics[1].TotalCoveredButIgnoredLines)
+		assert.Equal(t, calculateCoverage(2, 3), statistics[1].TotalCoveragePercent)
+		assert.Equal(t, calculateCoverage(3, 4), statistics[1].TotalCoverageWithoutIgnore)
+
+		// the statement covered by e2e only is a gap of unit, the ignored statement is not
+		assert.Equal(t, 1, statistics[0].MissedLines)
+		assert.Equal(t, 0, statistics[1].MissedLines)
+		assert.Equal(t, []*report.LabelGap{
+			{FileName: "foo.go", Function: "foo", CoveredBy: []string{"e2e"}, MissedBy: []string{"unit"}},
+		}, lc.labelGaps())
+	})
+}
@@ -0,0 +1,150 @@
+package gocover
+
+import (
+	"sort"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// lineState is the state of a line, the greater state takes precedence
+// when several statements share the line.
+type lineState int
+
+const (
+	lineIgnored lineState = iota + 1
+	lineCovered
+	linePartial
+	lineUncovered
+)
+
+// String returns the name of the state.
+func (s lineState) String() string {
+	switch s {
+	case lineIgnored:
+		return "ignored"
+	case lineCovered:
+		return "covered"
+	case linePartial:
+		return "partial"
+	case lineUncovered:
+		return "uncovered"
+	}
+	return ""
+}
+
+// fileLines tracks the state of each line in a file.
+type fileLines struct {
+	fileName string
+	states   map[int]lineState
+	changed  map[int]bool
+	hits     map[int]int64
+}
+
+// lineCollector collects the state of each line for the per-line report.
+type lineCollector struct {
+	files map[string]*fileLines
+}
+
+// newLineCollector returns a line collector.
+// It returns nil if it's not enabled, which disables the collection.
+func newLineCollector(enabled bool) *lineCollector {
+	if !enabled {
+		return nil
+	}
+	return &lineCollector{files: make(map[string]*fileLines)}
+}
+
+// add adds the lines of the statement in the file, the hit counts are collected if count is true,
+// which indicates the statement is from a count or atomic cover profile.
+func (c *lineCollector) add(fileName string, st *parser.Statement, count bool) {
+	if c == nil {
+		return
+	}
+
+	f, ok := c.files[fileName]
+	if !ok {
+		f = &fileLines{
+			fileName: fileName,
+			states:   make(map[int]lineState),
+			changed:  make(map[int]bool),
+			hits:     make(map[int]int64),
+		}
+		c.files[fileName] = f
+	}
+
+	state := lineCovered
+	if st.Mode == parser.Ignore {
+		state = lineIgnored
+	} else if st.Reached == 0 {
+		state = lineUncovered
+	} else if st.Partial {
+		state = linePartial
+	}
+
+	for line := st.StartLine; line <= st.EndLine; line++ {
+		if state > f.states[line] {
+			f.states[line] = state
+		}
+		if st.State == parser.Changed {
+			f.changed[line] = true
+		}
+		if count && st.Reached > f.hits[line] {
+			f.hits[line] = st.Reached
+		}
+	}
+}
+
+// states returns the state of each line of the file, it's nil if the file has no statement.
+func (c *lineCollector) states(fileName string) map[int]lineState {
+	if c == nil || c.files[fileName] == nil {
+		return nil
+	}
+	return c.files[fileName].states
+}
+
+// lines returns the lines of each file, sorted by file name and line number.
+func (c *lineCollector) lines() []*report.FileLines {
+	if c == nil {
+		return nil
+	}
+
+	result := []*report.FileLines{}
+	for _, f := range c.files {
+		lines := &report.FileLines{
+			FileName:  f.fileName,
+			Covered:   []int{},
+			Uncovered: []int{},
+			Ignored:   []int{},
+			Partial:   []int{},
+			Changed:   []int{},
+		}
+		for line, state := range f.states {
+			switch state {
+			case lineCovered:
+				lines.Covered = append(lines.Covered, line)
+			case lineUncovered:
+				lines.Uncovered = append(lines.Uncovered, line)
+			case lineIgnored:
+				lines.Ignored = append(lines.Ignored, line)
+			case linePartial:
+				lines.Partial = append(lines.Partial, line)
+			}
+		}
+		for line := range f.changed {
+			lines.Changed = append(lines.Changed, line)
+		}
+		if len(f.hits) != 0 {
+			lines.Hits = f.hits
+		}
+		for _, l := range [][]int{lines.Covered, lines.Uncovered, lines.Ignored, lines.Partial, lines.Changed} {
+			sort.Ints(l)
+		}
+		result = append(result, lines)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+	return result
+}
@@ -0,0 +1,49 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineCollector(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		c := newLineCollector(false)
+		c.add("foo.go", &parser.Statement{StartLine: 1, EndLine: 1}, false)
+		assert.Nil(t, c.lines())
+	})
+
+	t.Run("collect lines", func(t *testing.T) {
+		c := newLineCollector(true)
+		c.add("foo.go", &parser.Statement{StartLine: 3, EndLine: 4, Reached: 1, Mode: parser.Keep, State: parser.Original}, false)
+		// the uncovered statement takes precedence over the covered statement on line 4
+		c.add("foo.go", &parser.Statement{StartLine: 4, EndLine: 5, Reached: 0, Mode: parser.Keep, State: parser.Changed}, false)
+		c.add("foo.go", &parser.Statement{StartLine: 7, EndLine: 7, Reached: 0, Mode: parser.Ignore, State: parser.Original}, false)
+		// the covered statement takes precedence over the ignored statement on line 7
+		c.add("foo.go", &parser.Statement{StartLine: 7, EndLine: 7, Reached: 2, Mode: parser.Keep, State: parser.Original}, false)
+		c.add("foo.go", &parser.Statement{StartLine: 8, EndLine: 8, Reached: 0, Mode: parser.Ignore, State: parser.Changed}, false)
+		c.add("bar.go", &parser.Statement{StartLine: 1, EndLine: 1, Reached: 1, Mode: parser.Keep, State: parser.Original}, false)
+		// the partial statement takes precedence over the covered statement on line 2
+		c.add("bar.go", &parser.Statement{StartLine: 2, EndLine: 2, Reached: 1, Mode: parser.Keep, State: parser.Original}, false)
+		c.add("bar.go", &parser.Statement{StartLine: 2, EndLine: 4, Reached: 1, Partial: true, Mode: parser.Keep, State: parser.Original}, false)
+
+		assert.Equal(t, []*report.FileLines{
+			{FileName: "bar.go", Covered: []int{1}, Uncovered: []int{}, Ignored: []int{}, Partial: []int{2, 3, 4}, Changed: []int{}},
+			{FileName: "foo.go", Covered: []int{3, 7}, Uncovered: []int{4, 5}, Ignored: []int{8}, Partial: []int{}, Changed: []int{4, 5, 8}},
+		}, c.lines())
+	})
+
+	t.Run("hit counts", func(t *testing.T) {
+		c := newLineCollector(true)
+		c.add("foo.go", &parser.Statement{StartLine: 1, EndLine: 2, Reached: 3, Mode: parser.Keep, State: parser.Original}, true)
+		// the most hit count is kept on line 2
+		c.add("foo.go", &parser.Statement{StartLine: 2, EndLine: 2, Reached: 5, Mode: parser.Keep, State: parser.Original}, true)
+		c.add("foo.go", &parser.Statement{StartLine: 3, EndLine: 3, Reached: 0, Mode: parser.Keep, State: parser.Original}, true)
+
+		assert.Equal(t, []*report.FileLines{
+			{FileName: "foo.go", Covered: []int{1, 2}, Uncovered: []int{3}, Ignored: []int{}, Partial: []int{}, Changed: []int{}, Hits: map[int]int64{1: 3, 2: 5}},
+		}, c.lines())
+	})
+}
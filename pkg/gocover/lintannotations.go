@@ -0,0 +1,98 @@
+package gocover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/sirupsen/logrus"
+)
+
+// NewLintAnnotations creates a GoCover that checks the ignore annotations of the go files and prints their problems.
+func NewLintAnnotations(o *LintAnnotationsOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "lint-annotations")
+
+	paths := o.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &lintAnnotations{
+		paths:  paths,
+		stdout: stdout,
+		logger: logger,
+	}, nil
+}
+
+var _ GoCover = (*lintAnnotations)(nil)
+
+// lintAnnotations implements the GoCover interface and checks the ignore annotations before they skew the coverage.
+type lintAnnotations struct {
+	paths  []string
+	stdout io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (l *lintAnnotations) Run(ctx context.Context) error {
+	files, total := 0, 0
+	for _, path := range l.paths {
+		err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name != path && skipLintDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(name, ".go") {
+				return nil
+			}
+
+			problems, err := annotation.Lint(name, nil)
+			if err != nil {
+				return fmt.Errorf("lint %s: %w", name, err)
+			}
+			for _, p := range problems {
+				fmt.Fprintf(l.stdout, "%s:%s\n", name, p)
+			}
+			files++
+			total += len(problems)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	l.logger.Infof("%d problems in %d files", total, files)
+	if total > 0 {
+		return WrapErrorWithCode(fmt.Errorf("%d problems in the ignore annotations", total), AnnotationErrorExitCode, "")
+	}
+	return nil
+}
+
+// skipLintDir reports whether the directory is skipped like the go tool does,
+// which are vendor, testdata and the ones that start with "." or "_".
+func skipLintDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
@@ -0,0 +1,45 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	write("foo.go", "package foo\n\nfunc Foo() {\n\t//+gocover:ignore:block not tested\n\tprintln()\n}\n")
+	write("vendor/bar/bar.go", "package bar\n\n//+gocover:ignore:block\n")
+
+	t.Run("valid", func(t *testing.T) {
+		var buf bytes.Buffer
+		lint, err := NewLintAnnotations(&LintAnnotationsOption{Paths: []string{dir}, StdOut: &buf})
+		assert.NoError(t, err)
+		assert.NoError(t, lint.Run(context.Background()))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		file := write("pkg/baz/baz.go", "package baz\n\n//+gocover:ignore:skip unknown\n")
+		var buf bytes.Buffer
+		lint, err := NewLintAnnotations(&LintAnnotationsOption{Paths: []string{dir}, StdOut: &buf})
+		assert.NoError(t, err)
+
+		err = lint.Run(context.Background())
+		var e *GoCoverError
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, AnnotationErrorExitCode, e.ExitCode)
+		assert.Equal(t, file+":3: unknown directive: +gocover:ignore:skip, use +gocover:ignore:file, +gocover:ignore:block, +gocover:ignore:next or +gocover:ignore:line\n", buf.String())
+	})
+}
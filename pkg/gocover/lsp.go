@@ -0,0 +1,111 @@
+package gocover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/lsp"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+func NewLSP(o *LSPOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "lsp")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+
+	stdin, stdout := o.StdIn, o.StdOut
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &languageServer{
+		option: &DiffOption{
+			CoverProfiles:  o.CoverProfiles,
+			CompareBranch:  o.CompareBranch,
+			RepositoryPath: repositoryAbsPath,
+			ModuleDir:      o.ModuleDir,
+			Excludes:       o.Excludes,
+			NewCodeSince:   o.NewCodeSince,
+			FetchRemote:    o.FetchRemote,
+			DbOption:       &dbclient.DBOption{},
+			Logger:         logger,
+		},
+		moduleRoot: filepath.Join(repositoryAbsPath, o.ModuleDir),
+		stdin:      stdin,
+		stdout:     stdout,
+		logger:     logger,
+	}, nil
+}
+
+var _ GoCover = (*languageServer)(nil)
+
+// languageServer implements the GoCover interface and serves the uncovered changed lines as diagnostics.
+type languageServer struct {
+	option     *DiffOption
+	moduleRoot string
+	stdin      io.Reader
+	stdout     io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (l *languageServer) Run(ctx context.Context) error {
+	return lsp.NewServer(l.stdin, l.stdout, l.diagnostics, l.logger).Serve(ctx)
+}
+
+// diagnostics calculates the diff coverage from the cover profiles each time,
+// so that the diagnostics follow the latest cover profiles and git changes.
+func (l *languageServer) diagnostics(ctx context.Context) (map[string][]*lsp.Diagnostic, error) {
+	diff, err := newDiffCover(l.option)
+	if err != nil {
+		return nil, err
+	}
+
+	statistics, err := diff.generateStatistics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return coverageDiagnostics(statistics, l.moduleRoot, diff.modulePath), nil
+}
+
+// coverageDiagnostics converts the uncovered lines of each file to the diagnostics,
+// the file name is converted to the file URI under the module root.
+func coverageDiagnostics(statistics *report.Statistics, moduleRoot string, modulePath string) map[string][]*lsp.Diagnostic {
+	result := make(map[string][]*lsp.Diagnostic)
+	for _, p := range statistics.CoverageProfile {
+		fileName := filepath.Join(moduleRoot, filepath.FromSlash(strings.TrimPrefix(p.FileName, modulePath)))
+		uri := "file://" + filepath.ToSlash(fileName)
+
+		for _, section := range p.ViolationSections {
+			for _, line := range section.ViolationLines {
+				result[uri] = append(result[uri], &lsp.Diagnostic{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: line - 1},
+						End:   lsp.Position{Line: line},
+					},
+					Severity: lsp.SeverityWarning,
+					Source:   "gocover",
+					Message:  fmt.Sprintf("changed line is not covered by tests, compared with %s", statistics.ComparedBranch),
+				})
+			}
+		}
+	}
+	return result
+}
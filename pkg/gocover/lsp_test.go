@@ -0,0 +1,35 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/lsp"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageDiagnostics(t *testing.T) {
+	t.Run("coverageDiagnostics", func(t *testing.T) {
+		diagnostics := coverageDiagnostics(&report.Statistics{
+			ComparedBranch: "origin/main",
+			CoverageProfile: []*report.CoverageProfile{
+				{
+					FileName: "github.com/Azure/gocover/pkg/foo/foo.go",
+					ViolationSections: []*report.ViolationSection{
+						{ViolationLines: []int{3}},
+						{ViolationLines: []int{10, 11}},
+					},
+				},
+				{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go"},
+			},
+		}, "/home/user/gocover", "github.com/Azure/gocover")
+
+		assert.Len(t, diagnostics, 1)
+		foo := diagnostics["file:///home/user/gocover/pkg/foo/foo.go"]
+		assert.Len(t, foo, 3)
+		assert.Equal(t, lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 3}}, foo[0].Range)
+		assert.Equal(t, lsp.SeverityWarning, foo[0].Severity)
+		assert.Equal(t, "changed line is not covered by tests, compared with origin/main", foo[0].Message)
+		assert.Equal(t, 9, foo[1].Range.Start.Line)
+	})
+}
@@ -4,7 +4,12 @@ import (
 	"errors"
 	"io"
 
+	"github.com/Azure/gocover/pkg/ci"
 	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/webhook"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,13 +18,135 @@ type FullOption struct {
 	CoverProfiles  []string
 	RepositoryPath string
 	ModuleDir      string
+	// RepoRoot is the directory that the files of the cover profiles are resolved in by the module path in go.mod,
+	// so that the packages don't need to be importable by go/build. The packages are imported if it's empty.
+	RepoRoot string
+	// Overlay is the json file of the -overlay flag of go build, the files replaced in the instrumented build
+	// are read from their replacements when the statements are parsed.
+	Overlay string
+	// SkipUnresolved skips the files of the cover profiles that cannot be located, such as the deleted or generated files,
+	// and reports them as the skipped files instead of failing the run.
+	SkipUnresolved bool
+	// ContinueOnError converts the rest of the files when a file fails to convert, such as a parse failure,
+	// the errors of all the failed files are reported at the end.
+	ContinueOnError bool
+	// IgnoreFileErrors doesn't fail the run on the errors of the files when it continues on error.
+	IgnoreFileErrors bool
+	// Progress is called with the progress of converting the files of the cover profiles, it's disabled if it's nil.
+	Progress parser.ProgressFunc
+	// MemoryLimit is the statements of the converted files that the results of the rest of the files are spilled after,
+	// to a temporary directory until the conversion ends. They're read back for the statistics, so it doesn't bound
+	// the memory of the run. Zero disables the spill.
+	MemoryLimit int
+	// IgnorePolicy is how the ignored statements count for coverage, "exclude" or "count", default is "exclude".
+	IgnorePolicy string
+	// MixedCoverModes is how the cover profiles of the set mode are combined with the count or atomic profiles,
+	// "normalize" reduces all the counts to whether the blocks are reached, "reject" fails the run, default is "normalize".
+	MixedCoverModes string
+	// CompatibleMarkers honors the ignore markers of other coverage tools, such as `//coverage:ignore`, `// nocover`
+	// and `//nolint:gocover`, as the gocover ignore annotations.
+	CompatibleMarkers bool
+	// ClosedIssues checks the issues that the ignore annotations are linked to, "warn" or "fail" if any of them is closed,
+	// disabled if it's empty. The issues are read with GitHubOption.
+	ClosedIssues string
+	// HistoryDir is the directory that stores the result of each run, history is disabled if it's empty.
+	HistoryDir string
+	// NeverCoveredRuns reports the functions that have no coverage in the given number of latest runs in history.
+	NeverCoveredRuns int
+	// Compression is the algorithm to compress the history records, the json report and the File db records,
+	// "gzip" or "zstd", disabled if it's empty.
+	Compression string
+	// BaseRef compares the coverage with the result of the merge base of HEAD and the ref in history,
+	// and reports the packages and the files that regressed, disabled if it's empty.
+	BaseRef string
+	// TrendRuns charts the coverage of the given number of latest runs in history, disabled if it's zero.
+	TrendRuns int
+	// TrendPackages are the package patterns whose coverage is charted besides the total coverage.
+	TrendPackages []string
 
+	// CoverageBaseline is not read by full coverage, the run is gated by CoverageFloor.
 	CoverageBaseline float64
-	ReportFormat     string
+	ReportFormats    []string
 	ReportName       string
 	OutputDir        string
 	Excludes         []string
+	// ExcludeFunctions are the regular expressions of the function names that are excluded from coverage calculation.
+	ExcludeFunctions []string
+	// ExcludeBuildTags excludes the files that are built only with any of the build tags, such as integration.
+	ExcludeBuildTags []string
 	Style            string
+	// IncludePackages and SkipPackages are the import path patterns with "..." wildcards, such as github.com/foo/service-a/...,
+	// that scope the packages of the cover profiles, the packages out of the scope are not analyzed at all.
+	IncludePackages []string
+	SkipPackages    []string
+	// Templates are the go template files that the custom reports are generated from,
+	// the templates are executed with the statistics and the parsed packages.
+	Templates []string
+	// Treemap generates a treemap report besides the html report.
+	Treemap bool
+	// JUnit generates a JUnit XML report besides the html report, each package is a test case
+	// that fails if it's lower than CoverageFloor, so the packages fail by the same gate as the run.
+	JUnit bool
+	// LinesReport generates a json report of the state of each line for the editor plugins.
+	LinesReport bool
+	// DirectoryTree aggregates the coverage by directory in a collapsible tree of the html report,
+	// and prints the tree to the console.
+	DirectoryTree bool
+	// CacheDir is the directory that caches the conversion result of each file, cache is disabled if it's empty.
+	CacheDir string
+	// TeamCity writes TeamCity service messages of the coverage statistics and the uncovered lines to stdout.
+	TeamCity bool
+	// SummaryLine prints the total coverage in a stable line that the coverage regular expression of GitLab scrapes.
+	SummaryLine bool
+	// AzureDevOpsDir is the directory that the cobertura report and the html report are written to in the layout
+	// that the PublishCodeCoverageResults task of Azure Pipelines expects, disabled if it's empty.
+	AzureDevOpsDir string
+	// NoStepSummary disables writing the markdown report to the job summary when it runs in GitHub Actions.
+	NoStepSummary bool
+	// AttestationKey is the ed25519 private key file that signs the attestation of the reports,
+	// which carries the tool version, the digests of the cover profiles and the git commit.
+	AttestationKey string
+	// ToolVersion is the version of gocover in the attestation.
+	ToolVersion string
+	// TopUncovered reports the given number of functions that have the most uncovered lines.
+	TopUncovered int
+	// TopHot reports the given number of functions and statements that are executed most frequently,
+	// the hit counts are only recorded in count and atomic cover modes.
+	TopHot int
+	// FoldClosures folds the coverage of the function literals into their enclosing functions,
+	// instead of reporting them separately.
+	FoldClosures bool
+	// CoverageFloor returns an error code if full coverage is lower than it, disabled if it's zero.
+	CoverageFloor float64
+	// Gate is the expression of the coverage requirement, such as `full >= 70 && pkg("pkg/api") >= 90`,
+	// it replaces the coverage floor when it's set.
+	Gate string
+	// Ownership is the file that maps the teams to the paths they own and their coverage budgets,
+	// the coverage is reported by team and the run fails if any team is below its budget.
+	Ownership string
+	// TestOutputs are the files of the go test -json output, the results of the tests are reported by package
+	// and the failed and skipped tests are linked to the uncovered functions that they're named after.
+	TestOutputs []string
+	// Anonymize replaces the file paths and names in the reports, it's "hash" or "alias", disabled if it's empty.
+	Anonymize string
+	// CommitStatus sets the GitHub commit status of the commit with the coverage percent, the commit is
+	// the commit of the CI run or HEAD. The state is failure if the coverage requirement is not met.
+	CommitStatus bool
+	// StatusContext is the context of the commit status, default is gocover/full or gocover/diff.
+	StatusContext string
+	// DetailsURL is the target URL of the commit status, such as the URL of the uploaded html report.
+	DetailsURL string
+	// GitHubOption is used to call the GitHub API when CommitStatus is set.
+	GitHubOption *github.ClientOption
+	// WebhookOption posts the json result to the webhooks after the reports are generated,
+	// it's disabled if there are no webhooks.
+	WebhookOption *webhook.ClientOption
+
+	// CI is the information of the CI run, which is populated into the reports, the history records and the db records.
+	CI *ci.Environment
+	// RunLabels are the key/value labels of the run, such as the service, the environment or the test suite,
+	// which are populated into the json report, the history records and the db records like CI.
+	RunLabels map[string]string
 
 	DbOption *dbclient.DBOption
 
@@ -30,7 +157,7 @@ type FullOption struct {
 func NewFullOption() *FullOption {
 	return &FullOption{
 		CoverageBaseline: DefaultCoverageBaseline,
-		ReportFormat:     DefaultReportFormat,
+		ReportFormats:    []string{DefaultReportFormat},
 	}
 }
 
@@ -45,13 +172,142 @@ type DiffOption struct {
 	RepositoryPath string
 	ModuleDir      string
 	ModulePath     string
+	// RepoRoot resolves the files of the cover profiles without importing the packages, refer to FullOption.
+	RepoRoot string
+	// Overlay is the json file of the -overlay flag of go build, refer to FullOption.
+	Overlay string
+	// SkipUnresolved skips the files that cannot be located, refer to FullOption.
+	SkipUnresolved bool
+	// ContinueOnError and IgnoreFileErrors handle the errors of each file, refer to FullOption.
+	ContinueOnError  bool
+	IgnoreFileErrors bool
+	// Progress reports the progress of the conversion, refer to FullOption.
+	Progress parser.ProgressFunc
+	// MemoryLimit spills the conversion results over the statements, refer to FullOption.
+	MemoryLimit int
+	// IgnorePolicy is how the ignored statements count for coverage, refer to FullOption.
+	IgnorePolicy string
+	// MixedCoverModes is how the cover profiles of different modes are combined, refer to FullOption.
+	MixedCoverModes string
+	// CompatibleMarkers honors the ignore markers of other coverage tools, refer to FullOption.
+	CompatibleMarkers bool
+	// ClosedIssues checks the issues of the ignore annotations, refer to FullOption.
+	ClosedIssues string
 
 	CoverageBaseline float64
-	ReportFormat     string
+	ReportFormats    []string
 	ReportName       string
 	OutputDir        string
-	Excludes         []string
+	// Compression is the algorithm to compress the json report and the File db records, refer to FullOption.
+	Compression string
+	Excludes    []string
+	// ExcludeFunctions are the regular expressions of the function names that are excluded from coverage calculation.
+	ExcludeFunctions []string
+	// ExcludeBuildTags excludes the files that are built only with any of the build tags, such as integration.
+	ExcludeBuildTags []string
 	Style            string
+	// IncludePackages and SkipPackages scope the packages of the cover profiles, refer to FullOption.
+	IncludePackages []string
+	SkipPackages    []string
+	// Templates are the go template files that the custom reports are generated from,
+	// the templates are executed with the statistics and the parsed packages.
+	Templates []string
+	// Treemap generates a treemap report besides the html report.
+	Treemap bool
+	// JUnit generates a JUnit XML report besides the html report, each package in full coverage
+	// or each changed file in diff coverage is a test case that fails if it's lower than CoverageBaseline.
+	JUnit bool
+	// LinesReport generates a json report of the state of each line for the editor plugins.
+	LinesReport bool
+	// DirectoryTree aggregates the coverage by directory in a collapsible tree of the html report,
+	// and prints the tree to the console.
+	DirectoryTree bool
+	// SideBySide shows the changed files side by side in the html report of diff coverage,
+	// the added lines are colored by their coverage states.
+	SideBySide bool
+	// AnnotatedDiff writes the unified diff of the changes to stdout, the added lines are marked
+	// with their coverage states. The html report shows the changes side by side as well.
+	AnnotatedDiff bool
+	// CacheDir is the directory that caches the conversion result of each file, cache is disabled if it's empty.
+	CacheDir string
+	// TeamCity writes TeamCity service messages of the coverage statistics and the uncovered lines to stdout.
+	TeamCity bool
+	// SummaryLine prints the total coverage in a stable line that the coverage regular expression of GitLab scrapes.
+	SummaryLine bool
+	// AzureDevOpsDir is the directory that the cobertura report and the html report are written to in the layout
+	// that the PublishCodeCoverageResults task of Azure Pipelines expects, disabled if it's empty.
+	AzureDevOpsDir string
+	// NoStepSummary disables writing the markdown report to the job summary when it runs in GitHub Actions.
+	NoStepSummary bool
+	// AttestationKey is the ed25519 private key file that signs the attestation of the reports,
+	// which carries the tool version, the digests of the cover profiles and the git commit.
+	AttestationKey string
+	// ToolVersion is the version of gocover in the attestation.
+	ToolVersion string
+	// TopUncovered reports the given number of functions that have the most uncovered lines.
+	TopUncovered int
+	// TopHot reports the given number of functions and statements that are executed most frequently,
+	// the hit counts are only recorded in count and atomic cover modes.
+	TopHot int
+	// FoldClosures folds the coverage of the function literals into their enclosing functions,
+	// instead of reporting them separately.
+	FoldClosures bool
+	// WeakCoverage reports the changed statements that are reached only once in count or atomic mode.
+	WeakCoverage bool
+	// DetectMoves detects the renamed files and the code moved within or across files by git diff,
+	// the moved statements don't count for diff coverage and are reported as moved lines.
+	DetectMoves bool
+	// NewCodeSince is the start of new code period, which is a date, a time in RFC3339 format or a git ref.
+	// When it's set, the code changed since then is checked instead of the code changed compared to CompareBranch.
+	// All the files are compared with a single commit, which is the ref, or the latest commit in HEAD history
+	// that is committed before the date, rather than with a base of each file.
+	NewCodeSince string
+	// StackBases are the candidates of the compared branch in order, such as the parent branch of a stacked pull request
+	// and then main. The first one that exists, after fetching it from FetchRemote if it's missing, and has a common
+	// ancestor with HEAD is compared with instead of CompareBranch.
+	StackBases []string
+	// Authors are the names or the emails of the authors whose changes are checked, the authors of the changed lines
+	// are found by git blame. All the changes are checked if it's empty.
+	Authors []string
+	// Since and Until are the period of the commits whose changed lines are checked, which are dates (2006-01-02)
+	// or times in RFC3339 format, the date of Until is included. The commits of the lines are found by git blame,
+	// so the period doesn't depend on the topology of the branches. The period is open on the side that is empty.
+	Since string
+	Until string
+	// AttributeCommits finds the commits that introduce the uncovered changed statements by git blame,
+	// and reports them with their uncovered lines, so the commit of a multi-commit pull request that leaves the gap is shown.
+	AttributeCommits bool
+	// FetchRemote is the remote to fetch the compared branch from when it's missing in a shallow clone,
+	// fetching is disabled if it's empty.
+	FetchRemote string
+	// FetchAuth is the credentials to fetch from FetchRemote, such as a token, a netrc file or an ssh key,
+	// the https remotes are fetched anonymously and the ssh remotes use the ssh agent if it's nil.
+	FetchAuth *gittool.FetchAuth
+	// Gate is the expression of the coverage requirement, such as `diff >= 80 && pkg("pkg/api") >= 90`,
+	// it replaces the coverage baseline when it's set.
+	Gate string
+	// Ownership is the file of the teams and their coverage budgets, refer to FullOption.
+	Ownership string
+	// TestOutputs are the files of the go test -json output, refer to FullOption.
+	TestOutputs []string
+	// Anonymize replaces the file paths and names in the reports, refer to FullOption.
+	Anonymize string
+	// CommitStatus, StatusContext, DetailsURL and GitHubOption set the commit status, refer to FullOption.
+	CommitStatus  bool
+	StatusContext string
+	DetailsURL    string
+	GitHubOption  *github.ClientOption
+	// WebhookOption posts the json result to the webhooks, refer to FullOption.
+	WebhookOption *webhook.ClientOption
+	// TrackingIssue opens or updates a GitHub issue of the uncovered changed functions of the merged pull request,
+	// which is assigned to the author of the pull request. The pull request is the one of the CI run,
+	// or the one that the commit of the run is merged from.
+	TrackingIssue bool
+
+	// CI is the information of the CI run, refer to FullOption.
+	CI *ci.Environment
+	// RunLabels are the key/value labels of the run, refer to FullOption.
+	RunLabels map[string]string
 
 	DbOption *dbclient.DBOption
 
@@ -62,8 +318,9 @@ type DiffOption struct {
 func NewDiffOption() *DiffOption {
 	return &DiffOption{
 		CompareBranch:    DefaultCompareBranch,
+		FetchRemote:      DefaultFetchRemote,
 		CoverageBaseline: DefaultCoverageBaseline,
-		ReportFormat:     DefaultReportFormat,
+		ReportFormats:    []string{DefaultReportFormat},
 	}
 }
 
@@ -77,6 +334,8 @@ type ExecutorMode string
 const (
 	FullCoverage CoverageMode = "full"
 	DiffCoverage CoverageMode = "diff"
+	// AllCoverage evaluates and reports both full coverage and diff coverage.
+	AllCoverage CoverageMode = "all"
 
 	GoExecutor     ExecutorMode = "go"
 	GinkgoExecutor ExecutorMode = "ginkgo"
@@ -84,6 +343,19 @@ const (
 
 var ErrUnknownCoverageMode = errors.New("unknown coverage mode")
 var ErrUnknownExecutorMode = errors.New("unknown executor mode")
+var ErrWrongTestProfileFormat = errors.New("wrong test profile format")
+var ErrNotEnoughCoverProfiles = errors.New("at least two cover profiles are required")
+var ErrNoAggregateResult = errors.New("no coverage result to aggregate")
+var ErrNoHistoryRecord = errors.New("no full coverage record in history")
+var ErrFileNotCovered = errors.New("file is not found in the cover profiles")
+var ErrUnknownReportFormat = errors.New("unknown report format")
+var ErrMetricUnavailable = errors.New("metric is unavailable")
+var ErrUnknownIgnorePolicy = errors.New("unknown ignore policy")
+var ErrUnknownIssueCheck = errors.New("unknown check of the closed issues")
+var ErrNoStackBase = errors.New("none of the base refs can be compared with")
+var ErrConfigExists = errors.New("configuration file exists")
+var ErrUnknownCIProvider = errors.New("unknown ci provider")
+var ErrDoctorChecksFailed = errors.New("doctor checks failed")
 
 // GoCoverTestOption contains the input to the gocover govtest command.
 type GoCoverTestOption struct {
@@ -96,13 +368,141 @@ type GoCoverTestOption struct {
 	ExecutorMode   ExecutorMode
 	GinkgoFlags    []string
 	GoFlags        []string
+	// Packages are the packages that go test runs on, default is "./...".
+	Packages []string
+	// CoverMode is the value of go test -covermode,
+	// when it's empty, use "atomic" if -race is enabled, otherwise "set".
+	CoverMode string
+	// CoverPkg is the value of go test -coverpkg.
+	CoverPkg string
+	// Overlay is the json file of the -overlay flag that go test runs with, refer to FullOption.
+	Overlay string
+	// SkipUnresolved skips the files that cannot be located, refer to FullOption.
+	SkipUnresolved bool
+	// ContinueOnError and IgnoreFileErrors handle the errors of each file, refer to FullOption.
+	ContinueOnError  bool
+	IgnoreFileErrors bool
+	// Progress reports the progress of the conversion, refer to FullOption.
+	Progress parser.ProgressFunc
+	// MemoryLimit spills the conversion results over the statements, refer to FullOption.
+	MemoryLimit int
+	// IgnorePolicy is how the ignored statements count for coverage, refer to FullOption.
+	IgnorePolicy string
+	// MixedCoverModes is how the cover profiles of different modes are combined, refer to FullOption.
+	MixedCoverModes string
+	// CompatibleMarkers honors the ignore markers of other coverage tools, refer to FullOption.
+	CompatibleMarkers bool
+	// ClosedIssues checks the issues of the ignore annotations, refer to FullOption.
+	ClosedIssues string
+	// WeakCoverage reports the changed statements that are reached only once in diff coverage mode.
+	WeakCoverage bool
+	// DetectMoves detects the moved code in diff coverage mode, refer to DiffOption.
+	DetectMoves bool
+	// NewCodeSince is the start of new code period in diff coverage mode, refer to DiffOption.
+	NewCodeSince string
+	// StackBases are the candidates of the compared branch in diff coverage mode, refer to DiffOption.
+	StackBases []string
+	// Authors are the authors whose changes are checked in diff coverage mode, refer to DiffOption.
+	Authors []string
+	// Since and Until are the period of the commits whose changes are checked in diff coverage mode, refer to DiffOption.
+	Since string
+	Until string
+	// AttributeCommits reports the commits of the uncovered changed statements in diff coverage mode, refer to DiffOption.
+	AttributeCommits bool
+	// FetchRemote and FetchAuth are used in diff coverage mode, refer to DiffOption.
+	FetchRemote string
+	FetchAuth   *gittool.FetchAuth
+	// Compression is used in both coverage modes, refer to FullOption.
+	Compression string
+	// HistoryDir, NeverCoveredRuns, BaseRef, TrendRuns and TrendPackages are used in full coverage mode, refer to FullOption.
+	HistoryDir       string
+	NeverCoveredRuns int
+	BaseRef          string
+	TrendRuns        int
+	TrendPackages    []string
 
 	CoverageBaseline float64
-	ReportFormat     string
+	ReportFormats    []string
 	ReportName       string
 	OutputDir        string
 	Excludes         []string
+	// ExcludeFunctions are the regular expressions of the function names that are excluded from coverage calculation.
+	ExcludeFunctions []string
+	// ExcludeBuildTags excludes the files that are built only with any of the build tags, such as integration.
+	ExcludeBuildTags []string
 	Style            string
+	// SkipPackages are the packages of the cover profiles that are not analyzed, refer to FullOption.
+	// The packages that go test runs on and the coverpkg scope the cover profiles in the first place.
+	SkipPackages []string
+	// Templates are the go template files that the custom reports are generated from,
+	// the templates are executed with the statistics and the parsed packages.
+	Templates []string
+	// Treemap generates a treemap report besides the html report.
+	Treemap bool
+	// JUnit generates a JUnit XML report besides the html report, each package in full coverage
+	// or each changed file in diff coverage is a test case that fails if it's lower than CoverageBaseline.
+	JUnit bool
+	// LinesReport generates a json report of the state of each line for the editor plugins.
+	LinesReport bool
+	// DirectoryTree aggregates the coverage by directory in a collapsible tree of the html report,
+	// and prints the tree to the console.
+	DirectoryTree bool
+	// SideBySide shows the changed files side by side in the html report of diff coverage,
+	// the added lines are colored by their coverage states.
+	SideBySide bool
+	// AnnotatedDiff writes the unified diff of the changes to stdout, the added lines are marked
+	// with their coverage states. The html report shows the changes side by side as well.
+	AnnotatedDiff bool
+	// CacheDir is the directory that caches the conversion result of each file, cache is disabled if it's empty.
+	CacheDir string
+	// TeamCity writes TeamCity service messages of the coverage statistics and the uncovered lines to stdout.
+	TeamCity bool
+	// SummaryLine prints the total coverage in a stable line that the coverage regular expression of GitLab scrapes.
+	SummaryLine bool
+	// AzureDevOpsDir is the directory that the cobertura report and the html report are written to in the layout
+	// that the PublishCodeCoverageResults task of Azure Pipelines expects, disabled if it's empty.
+	AzureDevOpsDir string
+	// NoStepSummary disables writing the markdown report to the job summary when it runs in GitHub Actions.
+	NoStepSummary bool
+	// AttestationKey is the ed25519 private key file that signs the attestation of the reports,
+	// which carries the tool version, the digests of the cover profiles and the git commit.
+	AttestationKey string
+	// ToolVersion is the version of gocover in the attestation.
+	ToolVersion string
+	// TopUncovered reports the given number of functions that have the most uncovered lines.
+	TopUncovered int
+	// TopHot reports the given number of functions and statements that are executed most frequently,
+	// the hit counts are only recorded in count and atomic cover modes.
+	TopHot int
+	// FoldClosures folds the coverage of the function literals into their enclosing functions,
+	// instead of reporting them separately.
+	FoldClosures bool
+	// CoverageFloor is the full coverage requirement, refer to FullOption.
+	CoverageFloor float64
+	// Gate is the expression of the coverage requirement, it's evaluated once with both diff coverage and
+	// full coverage in all coverage mode.
+	Gate string
+	// Ownership is the file of the teams and their coverage budgets, refer to FullOption.
+	Ownership string
+	// TestOutputs are the files of the go test -json output, refer to FullOption.
+	// The output of the tests is added when they run with the -json flag.
+	TestOutputs []string
+	// Anonymize replaces the file paths and names in the reports, refer to FullOption.
+	Anonymize string
+	// CommitStatus, StatusContext, DetailsURL and GitHubOption set the commit status, refer to FullOption.
+	CommitStatus  bool
+	StatusContext string
+	DetailsURL    string
+	GitHubOption  *github.ClientOption
+	// WebhookOption posts the json result to the webhooks, refer to FullOption.
+	WebhookOption *webhook.ClientOption
+	// TrackingIssue opens or updates the issue of the uncovered changed functions, refer to DiffOption.
+	TrackingIssue bool
+
+	// CI is the information of the CI run, refer to FullOption.
+	CI *ci.Environment
+	// RunLabels are the key/value labels of the run, refer to FullOption.
+	RunLabels map[string]string
 
 	DbOption *dbclient.DBOption
 
@@ -114,8 +514,296 @@ type GoCoverTestOption struct {
 // NewGoCoverTestOption returns a Options with default values.
 func NewGoCoverTestOption() *GoCoverTestOption {
 	return &GoCoverTestOption{
+		Packages:         []string{DefaultTestPackages},
+		CoverPkg:         DefaultTestPackages,
 		CompareBranch:    DefaultCompareBranch,
+		FetchRemote:      DefaultFetchRemote,
 		CoverageBaseline: DefaultCoverageBaseline,
-		ReportFormat:     DefaultReportFormat,
+		ReportFormats:    []string{DefaultReportFormat},
+	}
+}
+
+// TestImpactOption contains the input to the gocover impact command.
+type TestImpactOption struct {
+	RepositoryPath string
+	ModuleDir      string
+	CompareBranch  string
+	OutputDir      string
+	Excludes       []string
+	GoFlags        []string
+
+	// TestProfiles contains the cover profiles that already generated for each test,
+	// with format {test}={profile}. When it's set, the tests won't be executed.
+	TestProfiles []string
+
+	StdOut io.Writer
+	StdErr io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewTestImpactOption returns a TestImpactOption with default values.
+func NewTestImpactOption() *TestImpactOption {
+	return &TestImpactOption{
+		CompareBranch: DefaultCompareBranch,
+	}
+}
+
+// FlakyOption contains the input to the gocover flaky command.
+type FlakyOption struct {
+	// CoverProfiles are the cover profiles generated by repeated runs of the same commit.
+	CoverProfiles  []string
+	RepositoryPath string
+	ModuleDir      string
+	// CompareBranch is the branch to compare, when it's set, only the changed statements are checked.
+	CompareBranch string
+	OutputDir     string
+	Excludes      []string
+	// FailOnFlaky returns an error code when any flaky statement is found.
+	FailOnFlaky bool
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewFlakyOption returns a FlakyOption with default values.
+func NewFlakyOption() *FlakyOption {
+	return &FlakyOption{}
+}
+
+// LSPOption contains the input to the gocover lsp command.
+type LSPOption struct {
+	CoverProfiles  []string
+	CompareBranch  string
+	RepositoryPath string
+	ModuleDir      string
+	Excludes       []string
+	// NewCodeSince is the start of new code period, refer to DiffOption.
+	NewCodeSince string
+	// FetchRemote is the remote to fetch the compared branch from, refer to DiffOption.
+	FetchRemote string
+
+	StdIn  io.Reader
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewLSPOption returns a LSPOption with default values.
+func NewLSPOption() *LSPOption {
+	return &LSPOption{
+		CompareBranch: DefaultCompareBranch,
+		FetchRemote:   DefaultFetchRemote,
+	}
+}
+
+// ShowOption contains the input to the gocover show command.
+type ShowOption struct {
+	// File is the source file to show.
+	File           string
+	CoverProfiles  []string
+	RepositoryPath string
+	ModuleDir      string
+	// Changed shows only the changed hunks of the file compared with CompareBranch.
+	Changed       bool
+	CompareBranch string
+	// NewCodeSince is the start of new code period, refer to DiffOption.
+	NewCodeSince string
+	// FetchRemote is the remote to fetch the compared branch from, refer to DiffOption.
+	FetchRemote string
+	// NoColor prints the markers of the coverage states without the ANSI colors.
+	NoColor bool
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewShowOption returns a ShowOption with default values.
+func NewShowOption() *ShowOption {
+	return &ShowOption{
+		CompareBranch: DefaultCompareBranch,
+		FetchRemote:   DefaultFetchRemote,
+	}
+}
+
+// AggregateOption contains the input to the gocover aggregate command.
+type AggregateOption struct {
+	// Results are the coverage results of the repositories, format is [{team}=]{path},
+	// path is a history record file or a history directory.
+	Results    []string
+	OutputDir  string
+	ReportName string
+	// Compression is the algorithm to compress the json report, "gzip" or "zstd", disabled if it's empty.
+	Compression string
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewAggregateOption returns a AggregateOption with default values.
+func NewAggregateOption() *AggregateOption {
+	return &AggregateOption{
+		ReportName: "aggregate",
+	}
+}
+
+// HistoryOption contains the input to the gocover history command.
+type HistoryOption struct {
+	// HistoryDir is the directory of the history records.
+	HistoryDir string
+	// ModulePath, Branch and CoverageMode match the records, they match all if they're empty.
+	ModulePath   string
+	Branch       string
+	CoverageMode string
+	// Since and Until are the range of the records, which are dates (2006-01-02) or times in RFC3339 format,
+	// the date of Until is included.
+	Since string
+	Until string
+	// Package reports the coverage of the package instead of the total coverage, the package is the import path
+	// or the path relative to the module, and it ends with "/..." to include the sub packages.
+	Package string
+	// Limit returns the latest records up to limit, returns all if it's zero.
+	Limit int
+	// Format is the output format, "table", "json" or "csv".
+	Format string
+	// Output is the file that the records are exported to, they're printed to StdOut if it's empty.
+	Output string
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+func NewHistoryOption() *HistoryOption {
+	return &HistoryOption{
+		Format: TableHistoryFormat,
+	}
+}
+
+// InitOption contains the input to the gocover init command.
+type InitOption struct {
+	// RepositoryPath is the repository that is inspected, the configuration file is written to its root.
+	RepositoryPath string
+	// CIProvider is the CI system of the snippet, it's detected from the CI files of the repository if it's empty.
+	CIProvider string
+	// Force overwrites the configuration file that exists.
+	Force bool
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// DoctorOption contains the input to the gocover doctor command.
+type DoctorOption struct {
+	RepositoryPath string
+	ModuleDir      string
+	CoverProfiles  []string
+	// CompareBranch is the branch that is checked to be reachable, it's fetched from FetchRemote with FetchAuth if it's missing.
+	CompareBranch string
+	FetchRemote   string
+	FetchAuth     *gittool.FetchAuth
+	// SampleFiles is the number of the files in the cover profiles whose packages are resolved.
+	SampleFiles int
+	// DbOption is the db clients that are checked when the data collection is enabled.
+	DbOption *dbclient.DBOption
+	// GitHubOption is the GitHub token whose scopes are checked, it's skipped if the token is empty.
+	GitHubOption *github.ClientOption
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewDoctorOption returns a DoctorOption with default values.
+func NewDoctorOption() *DoctorOption {
+	return &DoctorOption{
+		CompareBranch: DefaultCompareBranch,
+		FetchRemote:   DefaultFetchRemote,
+		SampleFiles:   DefaultDoctorSampleFiles,
+	}
+}
+
+// DefaultServeAddress is the default address that the gocover serve command listens on.
+const DefaultServeAddress = ":9184"
+
+// ServeOption contains the input to the gocover serve command.
+type ServeOption struct {
+	// HistoryDir is the directory of the history records, which is read on each scrape.
+	HistoryDir string
+	// Address is the TCP address that the server listens on, such as ":9184".
+	Address string
+	// ModulePath and Branch match the records, they match all if they're empty.
+	ModulePath string
+	Branch     string
+
+	Logger logrus.FieldLogger
+}
+
+// NewServeOption returns a ServeOption with default values.
+func NewServeOption() *ServeOption {
+	return &ServeOption{
+		Address: DefaultServeAddress,
+	}
+}
+
+// BazelOption contains the input to the gocover bazel command.
+type BazelOption struct {
+	// CoverageReports are the coverage data files of bazel coverage, in lcov or go format.
+	CoverageReports []string
+	// RepositoryPath is the bazel workspace, ModuleDir is the directory of the go module in it.
+	RepositoryPath string
+	ModuleDir      string
+	// Output is the go cover profile that the coverage is written to.
+	Output string
+
+	Logger logrus.FieldLogger
+}
+
+// NewBazelOption returns a BazelOption with default values.
+func NewBazelOption() *BazelOption {
+	return &BazelOption{
+		Output: outCoverageProfile,
+	}
+}
+
+// LintAnnotationsOption contains the input to the gocover lint-annotations command.
+type LintAnnotationsOption struct {
+	// Paths are the go files or the directories that are scanned recursively, default is the current directory.
+	Paths []string
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// ValidateOption contains the input to the gocover validate command.
+type ValidateOption struct {
+	CoverProfiles  []string
+	RepositoryPath string
+	ModuleDir      string
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// ScaffoldOption contains the input to the gocover scaffold command.
+type ScaffoldOption struct {
+	CoverProfiles  []string
+	RepositoryPath string
+	ModuleDir      string
+	// Changed scaffolds only the uncovered functions that are changed compared with CompareBranch.
+	Changed       bool
+	CompareBranch string
+	// NewCodeSince is the start of new code period, refer to DiffOption.
+	NewCodeSince string
+	// FetchRemote is the remote to fetch the compared branch from, refer to DiffOption.
+	FetchRemote string
+	// Print writes the skeletons to StdOut instead of the _test.go files next to the sources.
+	Print bool
+
+	StdOut io.Writer
+	Logger logrus.FieldLogger
+}
+
+// NewScaffoldOption returns a ScaffoldOption with default values.
+func NewScaffoldOption() *ScaffoldOption {
+	return &ScaffoldOption{
+		CompareBranch: DefaultCompareBranch,
+		FetchRemote:   DefaultFetchRemote,
 	}
 }
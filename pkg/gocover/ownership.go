@@ -0,0 +1,68 @@
+package gocover
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/ownership"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// loadOwnership loads the ownership file, it returns nil if the file is not set.
+func loadOwnership(file string) (*ownership.Ownership, error) {
+	if file == "" {
+		return nil, nil
+	}
+	return ownership.Load(file)
+}
+
+// teamCoverage sums up the coverage of the profiles by the teams that own them, in the order of the ownership file.
+// The teams that own none of the profiles are reported without lines, it returns nil if the ownership is not set.
+func teamCoverage(profiles []*report.CoverageProfile, owners *ownership.Ownership, modulePath string) []*report.TeamCoverage {
+	if owners == nil {
+		return nil
+	}
+
+	var teams []*report.TeamCoverage
+	byName := make(map[string]*report.TeamCoverage)
+	for _, team := range owners.Teams {
+		t := &report.TeamCoverage{Team: team.Name, Paths: team.Paths, Budget: team.Budget}
+		teams = append(teams, t)
+		byName[team.Name] = t
+	}
+
+	for _, p := range profiles {
+		owner := owners.Owner(strings.TrimPrefix(p.FileName, modulePath+"/"))
+		if owner == nil {
+			continue
+		}
+		t := byName[owner.Name]
+		t.Files++
+		t.TotalEffectiveLines += p.TotalEffectiveLines
+		t.TotalCoveredLines += p.CoveredLines - p.CoveredButIgnoredLines
+	}
+
+	for _, t := range teams {
+		t.CoveragePercent = calculateCoverage(int64(t.TotalCoveredLines), int64(t.TotalEffectiveLines))
+		t.OverBudget = t.Budget > 0 && t.CoveragePercent < t.Budget
+	}
+	return teams
+}
+
+// checkTeamBudgets returns an error with the low coverage exit code if any team is over its budget,
+// the error names only the offending teams and their paths.
+func checkTeamBudgets(teams []*report.TeamCoverage) error {
+	var errs []error
+	for _, t := range teams {
+		if !t.OverBudget {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("the coverage budget of team %s is %.2f, currently is %.2f, paths: %s",
+			t.Team, t.Budget, t.CoveragePercent, strings.Join(t.Paths, ", ")))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return WrapErrorWithCode(errors.Join(errs...), LowCoverageErrorExitCode, "")
+}
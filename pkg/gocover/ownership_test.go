@@ -0,0 +1,41 @@
+package gocover
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/ownership"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamCoverage(t *testing.T) {
+	owners := &ownership.Ownership{Teams: []*ownership.Team{
+		{Name: "api", Paths: []string{"pkg/api/**"}, Budget: 80},
+		{Name: "store", Paths: []string{"pkg/store/**"}, Budget: 50},
+		{Name: "docs", Paths: []string{"docs/**"}},
+	}}
+	teams := teamCoverage([]*report.CoverageProfile{
+		{FileName: "github.com/Azure/gocover/pkg/api/api.go", TotalEffectiveLines: 10, CoveredLines: 9, CoveredButIgnoredLines: 1},
+		{FileName: "github.com/Azure/gocover/pkg/api/v1/v1.go", TotalEffectiveLines: 10, CoveredLines: 2},
+		{FileName: "github.com/Azure/gocover/pkg/store/store.go", TotalEffectiveLines: 10, CoveredLines: 6},
+		{FileName: "github.com/Azure/gocover/main.go", TotalEffectiveLines: 10},
+	}, owners, "github.com/Azure/gocover")
+
+	assert.Equal(t, []*report.TeamCoverage{
+		{Team: "api", Paths: []string{"pkg/api/**"}, Files: 2, TotalEffectiveLines: 20, TotalCoveredLines: 10, CoveragePercent: 50, Budget: 80, OverBudget: true},
+		{Team: "store", Paths: []string{"pkg/store/**"}, Files: 1, TotalEffectiveLines: 10, TotalCoveredLines: 6, CoveragePercent: 60, Budget: 50},
+		{Team: "docs", Paths: []string{"docs/**"}, CoveragePercent: 100},
+	}, teams)
+	assert.Nil(t, teamCoverage(nil, nil, "github.com/Azure/gocover"))
+
+	t.Run("check budgets", func(t *testing.T) {
+		err := checkTeamBudgets(teams)
+		var e *GoCoverError
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, LowCoverageErrorExitCode, e.ExitCode)
+		assert.Equal(t, "the coverage budget of team api is 80.00, currently is 50.00, paths: pkg/api/**", err.Error())
+
+		assert.NoError(t, checkTeamBudgets(teams[1:]))
+	})
+}
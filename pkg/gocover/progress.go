@@ -0,0 +1,52 @@
+package gocover
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/gocover/pkg/parser"
+)
+
+const (
+	progressBarWidth    = 30
+	progressBarInterval = 100 * time.Millisecond
+)
+
+// NewProgressBar returns the progress function that draws the progress of the conversion as a bar in a line of w,
+// which is usually the terminal. The line is redrawn at most every 100ms, and ends when the conversion ends.
+func NewProgressBar(w io.Writer) parser.ProgressFunc {
+	var last time.Time
+	return func(p parser.Progress) {
+		end := p.Done == p.Total
+		if !end && time.Since(last) < progressBarInterval {
+			return
+		}
+		last = time.Now()
+
+		fmt.Fprintf(w, "\r%s\x1b[K", formatProgress(p))
+		if end {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// formatProgress formats the progress, such as `[=====>    ]  50% 120/240 files, ETA 1m2s, github.com/foo/bar`.
+// Only the number of the converted files is shown when the total is unknown.
+func formatProgress(p parser.Progress) string {
+	if p.Total == 0 {
+		return fmt.Sprintf("%d files, %s", p.Done, p.Package)
+	}
+
+	filled := progressBarWidth * p.Done / p.Total
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	line := fmt.Sprintf("[%s] %3d%% %d/%d files", bar, 100*p.Done/p.Total, p.Done, p.Total)
+	if p.ETA > 0 {
+		line += ", ETA " + p.ETA.Round(time.Second).String()
+	}
+	return line + ", " + p.Package
+}
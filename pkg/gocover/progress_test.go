@@ -0,0 +1,50 @@
+package gocover
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatProgress(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		progress parser.Progress
+		expected string
+	}{
+		"start": {
+			progress: parser.Progress{Done: 0, Total: 10, Package: "github.com/foo/bar"},
+			expected: "[>                             ]   0% 0/10 files, github.com/foo/bar",
+		},
+		"half with eta": {
+			progress: parser.Progress{Done: 5, Total: 10, Package: "github.com/foo/bar", ETA: 62400 * time.Millisecond},
+			expected: "[===============>              ]  50% 5/10 files, ETA 1m2s, github.com/foo/bar",
+		},
+		"end": {
+			progress: parser.Progress{Done: 10, Total: 10, Package: "github.com/foo/bar"},
+			expected: "[==============================] 100% 10/10 files, github.com/foo/bar",
+		},
+		"unknown total": {
+			progress: parser.Progress{Done: 3, Package: "github.com/foo/bar"},
+			expected: "3 files, github.com/foo/bar",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, formatProgress(testCase.progress))
+		})
+	}
+}
+
+func TestNewProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewProgressBar(&buf)
+	bar(parser.Progress{Done: 1, Total: 3, Package: "a"})
+	// redrawn within the interval is skipped, but the end is always drawn.
+	bar(parser.Progress{Done: 2, Total: 3, Package: "b"})
+	bar(parser.Progress{Done: 3, Total: 3, Package: "c"})
+
+	assert.Equal(t, "\r[==========>                   ]  33% 1/3 files, a\x1b[K"+
+		"\r[==============================] 100% 3/3 files, c\x1b[K\n", buf.String())
+}
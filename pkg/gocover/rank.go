@@ -0,0 +1,67 @@
+package gocover
+
+import (
+	"sort"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// newFunctionCoverage returns the coverage of the function
+// with its effective statements and covered statements that count for coverage.
+func newFunctionCoverage(fileName string, fun *parser.Function, effective, covered int) *report.FunctionCoverage {
+	return &report.FunctionCoverage{
+		FileName:            fileName,
+		Function:            fun.Name,
+		StartLine:           fun.StartLine,
+		TotalEffectiveLines: effective,
+		CoveredLines:        covered,
+		CoveragePercent:     calculateCoverage(int64(covered), int64(effective)),
+	}
+}
+
+// functionRanking ranks the functions by their uncovered statements,
+// so the large functions with low coverage come first.
+type functionRanking struct {
+	n         int
+	functions []*report.FunctionCoverage
+}
+
+// newFunctionRanking returns a ranking that keeps the top n functions.
+// It returns nil if n is not positive, which disables the ranking.
+func newFunctionRanking(n int) *functionRanking {
+	if n <= 0 {
+		return nil
+	}
+	return &functionRanking{n: n}
+}
+
+// add adds the function to the ranking, the fully covered functions are not ranked.
+func (r *functionRanking) add(f *report.FunctionCoverage) {
+	if r == nil || f.CoveredLines >= f.TotalEffectiveLines {
+		return
+	}
+	r.functions = append(r.functions, f)
+}
+
+// top returns the top n functions, sorted by the uncovered statements in descending order,
+// and then by the coverage percent in ascending order.
+func (r *functionRanking) top() []*report.FunctionCoverage {
+	if r == nil {
+		return nil
+	}
+
+	sort.SliceStable(r.functions, func(i, j int) bool {
+		fi, fj := r.functions[i], r.functions[j]
+		ui, uj := fi.TotalEffectiveLines-fi.CoveredLines, fj.TotalEffectiveLines-fj.CoveredLines
+		if ui != uj {
+			return ui > uj
+		}
+		return fi.CoveragePercent < fj.CoveragePercent
+	})
+
+	if len(r.functions) > r.n {
+		return r.functions[:r.n]
+	}
+	return r.functions
+}
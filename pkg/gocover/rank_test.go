@@ -0,0 +1,36 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionRanking(t *testing.T) {
+	t.Run("disabled ranking", func(t *testing.T) {
+		ranking := newFunctionRanking(0)
+		assert.Nil(t, ranking)
+		ranking.add(newFunctionCoverage("foo.go", &parser.Function{Name: "foo"}, 10, 0))
+		assert.Nil(t, ranking.top())
+	})
+
+	t.Run("rank by uncovered lines", func(t *testing.T) {
+		ranking := newFunctionRanking(3)
+		ranking.add(newFunctionCoverage("foo.go", &parser.Function{Name: "covered", StartLine: 1}, 10, 10))
+		ranking.add(newFunctionCoverage("foo.go", &parser.Function{Name: "small", StartLine: 20}, 2, 0))
+		ranking.add(newFunctionCoverage("foo.go", &parser.Function{Name: "large", StartLine: 30}, 40, 20))
+		ranking.add(newFunctionCoverage("bar.go", &parser.Function{Name: "medium", StartLine: 5}, 10, 0))
+		ranking.add(newFunctionCoverage("bar.go", &parser.Function{Name: "half", StartLine: 15}, 20, 10))
+
+		top := ranking.top()
+		assert.Len(t, top, 3)
+		assert.Equal(t, "large", top[0].Function)
+		assert.Equal(t, 50.0, top[0].CoveragePercent)
+		assert.Equal(t, "medium", top[1].Function)
+		assert.Equal(t, "bar.go", top[1].FileName)
+		assert.Equal(t, 5, top[1].StartLine)
+		assert.Equal(t, 0.0, top[1].CoveragePercent)
+		assert.Equal(t, "half", top[2].Function)
+	})
+}
@@ -0,0 +1,485 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+// scaffoldSuffix is the suffix of the test skeleton files, which replaces the ".go" of the source files.
+const scaffoldSuffix = "_scaffold_test.go"
+
+// versionRegexp matches the major version element of an import path, such as v2 of github.com/foo/bar/v2.
+var versionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+func NewScaffold(o *ScaffoldOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "scaffold")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+	moduleRoot := filepath.Join(repositoryAbsPath, o.ModuleDir)
+	modulePath, err := parseGoModulePath(moduleRoot)
+	if err != nil {
+		return nil, fmt.Errorf("parse go module path: %w", err)
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &scaffold{
+		option:         o,
+		repositoryPath: repositoryAbsPath,
+		moduleRoot:     moduleRoot,
+		modulePath:     modulePath,
+		stdout:         stdout,
+		logger:         logger,
+	}, nil
+}
+
+var _ GoCover = (*scaffold)(nil)
+
+// scaffold implements the GoCover interface and generates the table test skeletons of the uncovered functions,
+// so closing a coverage gap starts from filling in the test cases rather than from an empty file.
+type scaffold struct {
+	option         *ScaffoldOption
+	repositoryPath string
+	moduleRoot     string // directory of the module, the files of the functions are resolved in it
+	modulePath     string
+	stdout         io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (s *scaffold) Run(ctx context.Context) error {
+	functions, err := s.uncoveredFunctions(ctx)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string][]*report.FunctionCoverage)
+	for _, f := range functions {
+		rel := strings.TrimPrefix(strings.TrimPrefix(f.FileName, s.modulePath), "/")
+		name := filepath.Join(s.moduleRoot, filepath.FromSlash(rel))
+		files[name] = append(files[name], f)
+	}
+	return s.scaffold(files)
+}
+
+// uncoveredFunctions returns the functions that are not fully covered in full coverage,
+// or in diff coverage if only the changed functions are scaffolded.
+// The function literals are folded into their enclosing functions, which are the ones that tests call.
+func (s *scaffold) uncoveredFunctions(ctx context.Context) ([]*report.FunctionCoverage, error) {
+	var functions []*report.FunctionCoverage
+	if s.option.Changed {
+		diff, err := newDiffCover(&DiffOption{
+			CoverProfiles:  s.option.CoverProfiles,
+			CompareBranch:  s.option.CompareBranch,
+			RepositoryPath: s.repositoryPath,
+			ModuleDir:      s.option.ModuleDir,
+			NewCodeSince:   s.option.NewCodeSince,
+			FetchRemote:    s.option.FetchRemote,
+			FoldClosures:   true,
+			DbOption:       &dbclient.DBOption{},
+			Logger:         s.logger,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := diff.generateStatistics(ctx); err != nil {
+			return nil, err
+		}
+		functions = diff.functions
+	} else {
+		full, err := newFullCover(&FullOption{
+			CoverProfiles:  s.option.CoverProfiles,
+			RepositoryPath: s.repositoryPath,
+			ModuleDir:      s.option.ModuleDir,
+			FoldClosures:   true,
+			DbOption:       &dbclient.DBOption{},
+			Logger:         s.logger,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := full.generateStatistics(ctx); err != nil {
+			return nil, err
+		}
+		functions = full.functions
+	}
+
+	var uncovered []*report.FunctionCoverage
+	for _, f := range functions {
+		if f.CoveredLines < f.TotalEffectiveLines {
+			uncovered = append(uncovered, f)
+		}
+	}
+	return uncovered, nil
+}
+
+// scaffold writes the skeletons of the uncovered functions of each file, the skeleton files that exist are kept.
+func (s *scaffold) scaffold(files map[string][]*report.FunctionCoverage) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	written, tests := 0, 0
+	for _, name := range names {
+		lines := make(map[int]bool)
+		for _, f := range files[name] {
+			lines[f.StartLine] = true
+		}
+
+		src, n, err := scaffoldFile(name, lines, s.logger)
+		if err != nil {
+			return fmt.Errorf("scaffold %s: %w", name, err)
+		}
+		if n == 0 {
+			continue
+		}
+
+		output := strings.TrimSuffix(name, ".go") + scaffoldSuffix
+		if s.option.Print {
+			fmt.Fprintf(s.stdout, "// %s\n%s\n", output, src)
+		} else {
+			if _, err := os.Stat(output); err == nil {
+				s.logger.Warnf("%s exists, remove it to scaffold %s again", output, name)
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			if err := os.WriteFile(output, src, 0644); err != nil {
+				return fmt.Errorf("write %s: %w", output, err)
+			}
+			s.logger.Infof("%d test skeletons are written to %s", n, output)
+		}
+		written++
+		tests += n
+	}
+
+	s.logger.Infof("%d test skeletons in %d files", tests, written)
+	return nil
+}
+
+// scaffoldFile generates the table test skeletons of the functions that start at the lines of the file.
+// The functions that cannot be called by a test without more context are skipped, which are the generic functions,
+// init, main and the ones whose test name is declared in the test files of the package or the skeletons already.
+// It returns the formatted source of the test file and the number of the skeletons in it.
+func scaffoldFile(name string, lines map[int]bool, logger logrus.FieldLogger) ([]byte, int, error) {
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, name, nil, goparser.SkipObjectResolution)
+	if err != nil {
+		return nil, 0, err
+	}
+	declared, err := declaredTests(filepath.Dir(name))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		b       bytes.Buffer
+		n       int
+		compare bool
+		used    = make(map[string]bool)
+	)
+	for _, decl := range f.Decls {
+		fun, ok := decl.(*ast.FuncDecl)
+		if !ok || fun.Body == nil || !lines[fset.Position(fun.Pos()).Line] {
+			continue
+		}
+		if fun.Recv == nil && (fun.Name.Name == "init" || fun.Name.Name == "main") {
+			continue
+		}
+		if isGeneric(fun) {
+			logger.Warnf("%s:%d: generic function %s is not scaffolded", name, fset.Position(fun.Pos()).Line, fun.Name.Name)
+			continue
+		}
+
+		test := scaffoldTestName(fun)
+		if declared[test] {
+			logger.Infof("%s is declared in the tests of %s already", test, filepath.Dir(name))
+			continue
+		}
+
+		declared[test] = true
+		if writeTableTest(&b, test, fun) {
+			compare = true
+		}
+		collectPackages(fun.Type, used)
+		n++
+	}
+	if n == 0 {
+		return nil, 0, nil
+	}
+
+	imports := []string{strconv.Quote("testing")}
+	if compare {
+		imports = []string{strconv.Quote("reflect"), strconv.Quote("testing")}
+	}
+	// the imports of the signatures are grouped after the standard library ones.
+	var signatures []string
+	for _, spec := range f.Imports {
+		alias, name := importName(spec)
+		if !used[name] {
+			continue
+		}
+		if alias != "" {
+			signatures = append(signatures, alias+" "+spec.Path.Value)
+		} else {
+			signatures = append(signatures, spec.Path.Value)
+		}
+	}
+	if len(signatures) > 0 {
+		imports = append(imports, "")
+		imports = append(imports, signatures...)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "package %s\n\nimport (\n%s\n)\n", f.Name.Name, strings.Join(imports, "\n"))
+	out.Write(b.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, 0, fmt.Errorf("format the test skeletons: %w", err)
+	}
+	return src, n, nil
+}
+
+// declaredTests returns the names of the functions that are declared in the test files of the directory.
+func declaredTests(dir string) (map[string]bool, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, match := range matches {
+		f, err := goparser.ParseFile(fset, match, nil, goparser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if fun, ok := decl.(*ast.FuncDecl); ok && fun.Recv == nil {
+				declared[fun.Name.Name] = true
+			}
+		}
+	}
+	return declared, nil
+}
+
+// isGeneric reports whether the function or its receiver has type parameters,
+// the skeleton cannot instantiate them.
+func isGeneric(fun *ast.FuncDecl) bool {
+	if fun.Type.TypeParams != nil {
+		return true
+	}
+	if fun.Recv == nil {
+		return false
+	}
+	switch x := unparen(fun.Recv.List[0].Type).(type) {
+	case *ast.StarExpr:
+		switch unparen(x.X).(type) {
+		case *ast.IndexExpr, *ast.IndexListExpr:
+			return true
+		}
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return true
+	}
+	return false
+}
+
+func unparen(x ast.Expr) ast.Expr {
+	if p, ok := x.(*ast.ParenExpr); ok {
+		return unparen(p.X)
+	}
+	return x
+}
+
+// scaffoldTestName returns the name of the test of the function, Test{N} for a function and Test{T}_{N} for a method.
+func scaffoldTestName(fun *ast.FuncDecl) string {
+	name := fun.Name.Name
+	if fun.Recv != nil {
+		name = exprTypeName(fun.Recv.List[0].Type) + "_" + name
+	}
+	return "Test" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+func exprTypeName(x ast.Expr) string {
+	switch y := x.(type) {
+	case *ast.StarExpr:
+		return exprTypeName(y.X)
+	case *ast.ParenExpr:
+		return exprTypeName(y.X)
+	case *ast.Ident:
+		return y.Name
+	default:
+		return ""
+	}
+}
+
+// scaffoldField is a field of the test case struct.
+type scaffoldField struct {
+	name string
+	typ  string
+}
+
+// writeTableTest writes the table test of the function, the test cases are left to fill in.
+// The parameters are the fields of the test case, the results are compared with the want fields,
+// and the last error result is checked by wantErr. It returns whether reflect is used to compare the results.
+func writeTableTest(w io.Writer, test string, fun *ast.FuncDecl) bool {
+	reserved := map[string]bool{"name": true, "receiver": true, "wantErr": true, "testCase": true, "t": true, "err": true}
+
+	var (
+		fields []scaffoldField
+		args   []string
+	)
+	call := fun.Name.Name
+	if fun.Recv != nil {
+		fields = append(fields, scaffoldField{name: "receiver", typ: types.ExprString(fun.Recv.List[0].Type)})
+		call = "testCase.receiver." + call
+	}
+	i := 0
+	for _, param := range fun.Type.Params.List {
+		typ := param.Type
+		variadic := false
+		if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+			typ = &ast.ArrayType{Elt: ellipsis.Elt}
+			variadic = true
+		}
+
+		names := param.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+		for _, ident := range names {
+			name := ident.Name
+			if name == "_" {
+				name = fmt.Sprintf("arg%d", i)
+			} else if reserved[name] || strings.HasPrefix(name, "want") || strings.HasPrefix(name, "got") {
+				name += "Arg"
+			}
+			i++
+
+			fields = append(fields, scaffoldField{name: name, typ: types.ExprString(typ)})
+			arg := "testCase." + name
+			if variadic {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+
+	var results []string
+	if fun.Type.Results != nil {
+		for _, result := range fun.Type.Results.List {
+			n := len(result.Names)
+			if n == 0 {
+				n = 1
+			}
+			for j := 0; j < n; j++ {
+				results = append(results, types.ExprString(result.Type))
+			}
+		}
+	}
+	returnsErr := len(results) > 0 && results[len(results)-1] == "error"
+	if returnsErr {
+		results = results[:len(results)-1]
+	}
+
+	var got, want []string
+	for j, typ := range results {
+		suffix := ""
+		if j > 0 {
+			suffix = strconv.Itoa(j)
+		}
+		fields = append(fields, scaffoldField{name: "want" + suffix, typ: typ})
+		got = append(got, "got"+suffix)
+		want = append(want, "want"+suffix)
+	}
+	if returnsErr {
+		fields = append(fields, scaffoldField{name: "wantErr", typ: "bool"})
+		got = append(got, "err")
+	}
+
+	fmt.Fprintf(w, "\nfunc %s(t *testing.T) {\n", test)
+	fmt.Fprintf(w, "for _, testCase := range []struct {\nname string\n")
+	for _, field := range fields {
+		fmt.Fprintf(w, "%s %s\n", field.name, field.typ)
+	}
+	fmt.Fprintf(w, "}{\n// TODO: add the test cases.\n} {\n")
+	fmt.Fprintf(w, "t.Run(testCase.name, func(t *testing.T) {\n")
+
+	invocation := fmt.Sprintf("%s(%s)", call, strings.Join(args, ", "))
+	if len(got) > 0 {
+		fmt.Fprintf(w, "%s := %s\n", strings.Join(got, ", "), invocation)
+	} else {
+		fmt.Fprintf(w, "%s\n", invocation)
+	}
+	if returnsErr {
+		fmt.Fprintf(w, "if (err != nil) != testCase.wantErr {\nt.Fatalf(\"%s() error = %%v, wantErr %%v\", err, testCase.wantErr)\n}\n", fun.Name.Name)
+	}
+	for j := range want {
+		fmt.Fprintf(w, "if !reflect.DeepEqual(%[1]s, testCase.%[2]s) {\nt.Errorf(\"%[3]s() %[1]s = %%v, %[2]s %%v\", %[1]s, testCase.%[2]s)\n}\n",
+			got[j], want[j], fun.Name.Name)
+	}
+	fmt.Fprintf(w, "})\n}\n}\n")
+	return len(want) > 0
+}
+
+// collectPackages collects the names of the packages that are referred to in the node, such as foo of foo.Bar.
+func collectPackages(node ast.Node, used map[string]bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+// importName returns the alias of the import and the name that the package is referred to by.
+// The name of an import without alias is guessed from its path, such as bar of github.com/foo/bar/v2 or gopkg.in/bar.v3.
+func importName(spec *ast.ImportSpec) (alias, name string) {
+	if spec.Name != nil {
+		return spec.Name.Name, spec.Name.Name
+	}
+	p, _ := strconv.Unquote(spec.Path.Value)
+	name = path.Base(p)
+	if versionRegexp.MatchString(name) && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "go-"), "-go")
+	return "", name
+}
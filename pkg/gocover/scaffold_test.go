@@ -0,0 +1,153 @@
+package gocover
+
+import (
+	"bytes"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffold(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "foo.go")
+	assert.NoError(t, os.WriteFile(source, []byte(`package foo
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+type server struct{ name string }
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func (s *server) Serve(ctx context.Context, w io.Writer, names ...string) (int, error) {
+	return io.WriteString(w, strings.Join(names, s.name))
+}
+
+func Map[K comparable](m map[K]int) {}
+
+func run(_ int, name string) error {
+	return nil
+}
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo_test.go"), []byte(`package foo
+
+import "testing"
+
+func TestRun(t *testing.T) {}
+`), 0644))
+
+	files := map[string][]*report.FunctionCoverage{
+		source: {{StartLine: 11}, {StartLine: 15}, {StartLine: 19}, {StartLine: 21}},
+	}
+	expected := `package foo
+
+import (
+	"reflect"
+	"testing"
+
+	"context"
+	"io"
+)
+
+func TestAdd(t *testing.T) {
+	for _, testCase := range []struct {
+		name string
+		a    int
+		b    int
+		want int
+	}{
+		// TODO: add the test cases.
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := Add(testCase.a, testCase.b)
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Add() got = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		receiver *server
+		ctx      context.Context
+		w        io.Writer
+		names    []string
+		want     int
+		wantErr  bool
+	}{
+		// TODO: add the test cases.
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := testCase.receiver.Serve(testCase.ctx, testCase.w, testCase.names...)
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Serve() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+			if !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("Serve() got = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+`
+
+	t.Run("print", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := &scaffold{option: &ScaffoldOption{Print: true}, stdout: &buf, logger: logrus.New()}
+		assert.NoError(t, s.scaffold(files))
+		assert.Equal(t, "// "+filepath.Join(dir, "foo_scaffold_test.go")+"\n"+expected+"\n", buf.String())
+	})
+
+	t.Run("write", func(t *testing.T) {
+		s := &scaffold{option: &ScaffoldOption{}, logger: logrus.New()}
+		assert.NoError(t, s.scaffold(files))
+		data, err := os.ReadFile(filepath.Join(dir, "foo_scaffold_test.go"))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+
+		// the existing skeletons are kept.
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo_scaffold_test.go"), []byte("package foo\n"), 0644))
+		assert.NoError(t, s.scaffold(files))
+		data, err = os.ReadFile(filepath.Join(dir, "foo_scaffold_test.go"))
+		assert.NoError(t, err)
+		assert.Equal(t, "package foo\n", string(data))
+	})
+
+	t.Run("unnamed and reserved parameters", func(t *testing.T) {
+		assert.NoError(t, os.Remove(filepath.Join(dir, "foo_test.go")))
+		src, n, err := scaffoldFile(source, map[int]bool{21: true}, logrus.New())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Contains(t, string(src), "\t\targ0    int\n\t\tnameArg string\n\t\twantErr bool\n")
+		assert.Contains(t, string(src), "err := run(testCase.arg0, testCase.nameArg)")
+		assert.NotContains(t, string(src), "reflect")
+	})
+}
+
+func TestImportName(t *testing.T) {
+	for path, expected := range map[string]string{
+		`"net/http"`:                   "http",
+		`"github.com/foo/bar/v2"`:      "bar",
+		`"gopkg.in/yaml.v3"`:           "yaml",
+		`"github.com/foo/go-bar"`:      "bar",
+		`"github.com/sirupsen/logrus"`: "logrus",
+	} {
+		_, name := importName(&ast.ImportSpec{Path: &ast.BasicLit{Value: path}})
+		assert.Equal(t, expected, name, path)
+	}
+
+	alias, name := importName(&ast.ImportSpec{Name: ast.NewIdent("foo"), Path: &ast.BasicLit{Value: `"github.com/bar"`}})
+	assert.Equal(t, "foo", alias)
+	assert.Equal(t, "foo", name)
+}
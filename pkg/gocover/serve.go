@@ -0,0 +1,267 @@
+package gocover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// openMetricsContentType is the content type of the OpenMetrics text format.
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	// serveShutdownTimeout is the time that the in-flight scrapes are waited for on shutdown.
+	serveShutdownTimeout = 5 * time.Second
+)
+
+// NewServe creates the server that exposes the latest coverage of the history records as OpenMetrics.
+func NewServe(o *ServeOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "serve")
+
+	address := o.Address
+	if address == "" {
+		address = DefaultServeAddress
+	}
+
+	// the store creates the directory that doesn't exist, which should be reported for a server.
+	if _, err := os.Stat(o.HistoryDir); err != nil {
+		return nil, fmt.Errorf("history dir: %w", err)
+	}
+	store, err := history.NewFileStore(o.HistoryDir, compression.None)
+	if err != nil {
+		return nil, err
+	}
+
+	return &coverageServer{
+		store: store,
+		query: &history.Query{
+			ModulePath: o.ModulePath,
+			Branch:     o.Branch,
+		},
+		address: address,
+		logger:  logger,
+	}, nil
+}
+
+var _ GoCover = (*coverageServer)(nil)
+
+// coverageServer implements the GoCover interface and serves the latest coverage of the history records
+// at /metrics, the records are read on each scrape so that the new runs are picked up without restart.
+type coverageServer struct {
+	store   history.Store
+	query   *history.Query
+	address string
+
+	logger logrus.FieldLogger
+}
+
+// Run serves until the context is cancelled, the server is shut down gracefully then.
+func (s *coverageServer) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.address, err)
+	}
+	return s.serve(ctx, listener)
+}
+
+func (s *coverageServer) serve(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.Serve(listener)
+	}()
+	s.logger.Infof("serve metrics at http://%s/metrics", listener.Addr())
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("serve metrics: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown server: %w", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve metrics: %w", err)
+	}
+	return nil
+}
+
+func (s *coverageServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	records, err := s.store.List(r.Context(), s.query)
+	if err != nil {
+		s.logger.WithError(err).Error("list history records")
+		http.Error(w, "list history records", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", openMetricsContentType)
+	if err := writeOpenMetrics(w, latestRecords(records)); err != nil {
+		s.logger.WithError(err).Warn("write metrics")
+	}
+}
+
+// latestRecords returns the latest record of each module, coverage mode and run labels,
+// which are the series of the metrics. The records are in the ascending order of time.
+func latestRecords(records []*history.Record) []*history.Record {
+	latest := make(map[string]int)
+	var result []*history.Record
+	for _, r := range records {
+		key := strings.Join(append([]string{r.ModulePath, r.CoverageMode}, sortedLabels(r.Labels)...), "\x00")
+		if i, ok := latest[key]; ok {
+			result[i] = r
+			continue
+		}
+		latest[key] = len(result)
+		result = append(result, r)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].ModulePath != result[j].ModulePath {
+			return result[i].ModulePath < result[j].ModulePath
+		}
+		return result[i].CoverageMode < result[j].CoverageMode
+	})
+	return result
+}
+
+// sortedLabels returns the run labels as key=value pairs sorted by key.
+func sortedLabels(labels map[string]string) []string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// metricFamily is a gauge of the OpenMetrics exposition, the samples share the name of the family.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []string
+}
+
+func (f *metricFamily) add(labels string, value float64) {
+	f.samples = append(f.samples, fmt.Sprintf("%s{%s} %s", f.name, labels, strconv.FormatFloat(value, 'f', -1, 64)))
+}
+
+// writeOpenMetrics writes the coverage of the records in the OpenMetrics text format,
+// the total coverage of each record is followed by the coverage of its packages.
+func writeOpenMetrics(w io.Writer, records []*history.Record) error {
+	var (
+		coverage         = &metricFamily{name: "gocover_coverage_percent", help: "Coverage percent of the latest run."}
+		effective        = &metricFamily{name: "gocover_effective_lines", help: "Effective lines of the latest run."}
+		covered          = &metricFamily{name: "gocover_covered_lines", help: "Covered lines of the latest run."}
+		timestamp        = &metricFamily{name: "gocover_last_run_timestamp_seconds", help: "Time of the latest run."}
+		packageCoverage  = &metricFamily{name: "gocover_package_coverage_percent", help: "Coverage percent of the package in the latest run."}
+		packageEffective = &metricFamily{name: "gocover_package_effective_lines", help: "Effective lines of the package in the latest run."}
+		packageCovered   = &metricFamily{name: "gocover_package_covered_lines", help: "Covered lines of the package in the latest run."}
+	)
+
+	for _, r := range records {
+		labels := recordMetricLabels(r)
+		coverage.add(labels, r.CoveragePercent)
+		effective.add(labels, float64(r.TotalEffectiveLines))
+		covered.add(labels, float64(r.TotalCoveredLines))
+		timestamp.add(labels, float64(r.Timestamp.Unix()))
+
+		packages := make(map[string]*lineCount)
+		for _, f := range r.Functions {
+			addLineCount(packages, path.Dir(f.FileName), f.TotalEffectiveLines, f.CoveredLines)
+		}
+		names := make([]string, 0, len(packages))
+		for name := range packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c := packages[name]
+			packageLabels := labels + `,package="` + escapeLabelValue(name) + `"`
+			packageCoverage.add(packageLabels, calculateCoverage(int64(c.covered), int64(c.effective)))
+			packageEffective.add(packageLabels, float64(c.effective))
+			packageCovered.add(packageLabels, float64(c.covered))
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range []*metricFamily{coverage, effective, covered, timestamp, packageCoverage, packageEffective, packageCovered} {
+		fmt.Fprintf(&b, "# TYPE %s gauge\n# HELP %s %s\n", f.name, f.name, f.help)
+		for _, sample := range f.samples {
+			b.WriteString(sample)
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// recordMetricLabels returns the labels of the series of the record, which are the module, the coverage mode
+// and the run labels. The names of the run labels are sanitized, the ones that clash with the others are dropped.
+func recordMetricLabels(r *history.Record) string {
+	labels := []string{
+		`module="` + escapeLabelValue(r.ModulePath) + `"`,
+		`mode="` + escapeLabelValue(r.CoverageMode) + `"`,
+	}
+	seen := map[string]bool{"module": true, "mode": true, "package": true}
+	for _, pair := range sortedLabels(r.Labels) {
+		key, value, _ := strings.Cut(pair, "=")
+		name := labelName(key)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		labels = append(labels, name+`="`+escapeLabelValue(value)+`"`)
+	}
+	return strings.Join(labels, ",")
+}
+
+// labelName converts the key to a valid label name, the invalid characters are replaced by underscores.
+func labelName(key string) string {
+	var b strings.Builder
+	for i, c := range key {
+		switch {
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// escapeLabelValue escapes the backslashes, double quotes and line feeds of the label value.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
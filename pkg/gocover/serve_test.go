@@ -0,0 +1,97 @@
+package gocover
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/Azure/gocover/pkg/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.NewFileStore(dir, compression.None)
+	assert.NoError(t, err)
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, r := range []*history.Record{
+		{Branch: "main", CoverageMode: string(FullCoverage), CoveragePercent: 60, TotalEffectiveLines: 10, TotalCoveredLines: 6},
+		{Branch: "main", CoverageMode: string(FullCoverage), CoveragePercent: 70, TotalEffectiveLines: 10, TotalCoveredLines: 7},
+		{Branch: "main", CoverageMode: string(FullCoverage), CoveragePercent: 40, Labels: map[string]string{"test-suite": `e2e "nightly"`, "mode": "x"}},
+		{Branch: "dev", CoverageMode: string(FullCoverage), CoveragePercent: 10},
+	} {
+		r.Timestamp = day.AddDate(0, 0, i)
+		r.ModulePath = "github.com/Azure/gocover"
+		r.Functions = []*history.FunctionRecord{
+			{FileName: "github.com/Azure/gocover/pkg/x/x.go", TotalEffectiveLines: 4, CoveredLines: i + 1},
+			{FileName: "github.com/Azure/gocover/pkg/y/y.go", TotalEffectiveLines: 6, CoveredLines: 3},
+		}
+		assert.NoError(t, store.Append(context.Background(), r))
+	}
+
+	t.Run("metrics", func(t *testing.T) {
+		server, err := NewServe(&ServeOption{HistoryDir: dir, Branch: "main"})
+		assert.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		server.(*coverageServer).handleMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, openMetricsContentType, recorder.Header().Get("Content-Type"))
+
+		body := recorder.Body.String()
+		assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+		assert.Contains(t, body, "# TYPE gocover_coverage_percent gauge\n")
+		assert.Contains(t, body, `gocover_coverage_percent{module="github.com/Azure/gocover",mode="full"} 70`+"\n")
+		assert.NotContains(t, body, "} 60\n")
+		assert.NotContains(t, body, "} 1714824000\n")
+		assert.Contains(t, body, `gocover_coverage_percent{module="github.com/Azure/gocover",mode="full",test_suite="e2e \"nightly\""} 40`+"\n")
+		assert.Contains(t, body, `gocover_package_coverage_percent{module="github.com/Azure/gocover",mode="full",package="github.com/Azure/gocover/pkg/x"} 50`+"\n")
+		assert.Contains(t, body, `gocover_package_covered_lines{module="github.com/Azure/gocover",mode="full",package="github.com/Azure/gocover/pkg/y"} 3`+"\n")
+		assert.Contains(t, body, `gocover_last_run_timestamp_seconds{module="github.com/Azure/gocover",mode="full"} 1714651200`+"\n")
+	})
+
+	t.Run("missing history dir", func(t *testing.T) {
+		_, err := NewServe(&ServeOption{HistoryDir: dir + "/missing"})
+		assert.Error(t, err)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		server, err := NewServe(&ServeOption{HistoryDir: dir})
+		assert.NoError(t, err)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- server.(*coverageServer).serve(ctx, listener)
+		}()
+
+		resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Contains(t, string(body), `mode="full"} 10`)
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
+
+func TestLabelName(t *testing.T) {
+	assert.Equal(t, "service", labelName("service"))
+	assert.Equal(t, "test_suite", labelName("test-suite"))
+	assert.Equal(t, "_1st", labelName("1st"))
+	assert.Equal(t, "_", labelName(""))
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
@@ -0,0 +1,203 @@
+package gocover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/dbclient"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+const ansiReset = "\033[0m"
+
+// ansiColors are the ANSI colors of the lines by their coverage states.
+var ansiColors = map[string]string{
+	"covered":   "\033[32m",
+	"uncovered": "\033[31m",
+	"partial":   "\033[33m",
+	"ignored":   "\033[90m",
+}
+
+func NewShow(o *ShowOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "show")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+	file, err := realAbsPath(o.File)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of file: %w", err)
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &show{
+		option:         o,
+		repositoryPath: repositoryAbsPath,
+		file:           file,
+		stdout:         stdout,
+		logger:         logger,
+	}, nil
+}
+
+var _ GoCover = (*show)(nil)
+
+// show implements the GoCover interface and prints the file with the coverage state of each line to the terminal.
+type show struct {
+	option         *ShowOption
+	repositoryPath string
+	file           string // absolute path of the file to show
+	stdout         io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (s *show) Run(ctx context.Context) error {
+	rel, err := filepath.Rel(filepath.Join(s.repositoryPath, s.option.ModuleDir), s.file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s is not in the module: %w", s.option.File, ErrFileNotCovered)
+	}
+	// the file names in the statistics are prefixed with the module path.
+	suffix := filepath.ToSlash(rel)
+
+	if s.option.Changed {
+		return s.changedHunks(ctx, suffix)
+	}
+	return s.wholeFile(ctx, suffix)
+}
+
+// wholeFile prints all the lines of the file with their states in full coverage.
+func (s *show) wholeFile(ctx context.Context, suffix string) error {
+	full, err := newFullCover(&FullOption{
+		CoverProfiles:  s.option.CoverProfiles,
+		RepositoryPath: s.repositoryPath,
+		ModuleDir:      s.option.ModuleDir,
+		LinesReport:    true,
+		DbOption:       &dbclient.DBOption{},
+		Logger:         s.logger,
+	})
+	if err != nil {
+		return err
+	}
+	statistics, err := full.generateStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lines *report.FileLines
+	for _, l := range statistics.Lines {
+		if gittool.HasPathSuffix(l.FileName, suffix) {
+			lines = l
+			break
+		}
+	}
+	if lines == nil {
+		return fmt.Errorf("%s: %w", s.option.File, ErrFileNotCovered)
+	}
+
+	contents, err := findFileContents(make(fileContentsCache), s.file)
+	if err != nil {
+		return fmt.Errorf("find file contents: %w", err)
+	}
+
+	states := make(map[int]string)
+	for state, numbers := range map[string][]int{
+		lineCovered.String():   lines.Covered,
+		lineUncovered.String(): lines.Uncovered,
+		lineIgnored.String():   lines.Ignored,
+		linePartial.String():   lines.Partial,
+	} {
+		for _, line := range numbers {
+			states[line] = state
+		}
+	}
+
+	s.writeSummary(statistics, lines.FileName)
+	for i, text := range contents {
+		s.writeLine(i+1, text, states[i+1])
+	}
+	return nil
+}
+
+// changedHunks prints the lines around the changes of the file with the states of the added lines in diff coverage.
+func (s *show) changedHunks(ctx context.Context, suffix string) error {
+	diff, err := newDiffCover(&DiffOption{
+		CoverProfiles:  s.option.CoverProfiles,
+		CompareBranch:  s.option.CompareBranch,
+		RepositoryPath: s.repositoryPath,
+		ModuleDir:      s.option.ModuleDir,
+		NewCodeSince:   s.option.NewCodeSince,
+		FetchRemote:    s.option.FetchRemote,
+		SideBySide:     true,
+		DbOption:       &dbclient.DBOption{},
+		Logger:         s.logger,
+	})
+	if err != nil {
+		return err
+	}
+	statistics, err := diff.generateStatistics(ctx)
+	if err != nil {
+		return err
+	}
+
+	var file *report.DiffFile
+	for _, f := range statistics.DiffFiles {
+		if gittool.HasPathSuffix(f.FileName, suffix) {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return fmt.Errorf("%s has no changed statement compared with %s: %w", s.option.File, statistics.ComparedBranch, ErrFileNotCovered)
+	}
+
+	s.writeSummary(statistics, file.FileName)
+	for _, row := range file.Rows {
+		if row.Skipped {
+			fmt.Fprintln(s.stdout, "    ⋯")
+			continue
+		}
+		// the deleted lines are not in the file anymore.
+		if row.NewLine == 0 {
+			continue
+		}
+		s.writeLine(row.NewLine, row.New, row.State)
+	}
+	return nil
+}
+
+// writeSummary prints the coverage of the file.
+func (s *show) writeSummary(statistics *report.Statistics, fileName string) {
+	for _, p := range statistics.CoverageProfile {
+		if p.FileName != fileName {
+			continue
+		}
+		covered := p.CoveredLines - p.CoveredButIgnoredLines
+		fmt.Fprintf(s.stdout, "%s: %.2f%% (%d/%d lines) in %s coverage\n", fileName,
+			calculateCoverage(int64(covered), int64(p.TotalEffectiveLines)), covered, p.TotalEffectiveLines, statistics.StatisticsType)
+	}
+}
+
+// writeLine prints the line with its number and the marker of its state, the line is colored by the state unless the color is disabled.
+func (s *show) writeLine(line int, text string, state string) {
+	color := ansiColors[state]
+	if color == "" || s.option.NoColor {
+		fmt.Fprintf(s.stdout, "%5d %s %s\n", line, report.LineStateMarker(state), text)
+		return
+	}
+	fmt.Fprintf(s.stdout, "%s%5d %s %s%s\n", color, line, report.LineStateMarker(state), text, ansiReset)
+}
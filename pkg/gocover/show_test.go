@@ -0,0 +1,44 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShow(t *testing.T) {
+	t.Run("file not in module", func(t *testing.T) {
+		s, err := NewShow(&ShowOption{File: "/tmp/foo.go", RepositoryPath: t.TempDir(), ModuleDir: "./"})
+		assert.NoError(t, err)
+		assert.ErrorIs(t, s.Run(context.Background()), ErrFileNotCovered)
+	})
+
+	t.Run("write lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := &show{option: &ShowOption{}, stdout: &buf}
+		s.writeSummary(&report.Statistics{
+			StatisticsType: report.FullStatisticsType,
+			CoverageProfile: []*report.CoverageProfile{
+				{FileName: "github.com/foo/foo.go", TotalEffectiveLines: 4, CoveredLines: 3, CoveredButIgnoredLines: 1},
+			},
+		}, "github.com/foo/foo.go")
+		s.writeLine(1, "package foo", "")
+		s.writeLine(3, "return nil", "covered")
+		s.writeLine(12, "panic(err)", "uncovered")
+
+		assert.Equal(t, "github.com/foo/foo.go: 50.00% (2/4 lines) in full coverage\n"+
+			"    1   package foo\n"+
+			"\033[32m    3 ✓ return nil\033[0m\n"+
+			"\033[31m   12 ✗ panic(err)\033[0m\n", buf.String())
+	})
+
+	t.Run("no color", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := &show{option: &ShowOption{NoColor: true}, stdout: &buf}
+		s.writeLine(7, "if ok {", "partial")
+		assert.Equal(t, "    7 ◐ if ok {\n", buf.String())
+	})
+}
@@ -0,0 +1,123 @@
+package gocover
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// diffContextLines is the number of the unchanged lines that are shown around the changes.
+const diffContextLines = 3
+
+// newDiffFile aligns the lines of the compared branch and the HEAD side by side, the deleted lines are paired
+// with the added lines that replace them, and the added lines are marked with their coverage states.
+// contents are the lines of the file in the HEAD.
+func newDiffFile(fileName string, change *gittool.Change, contents []string, states map[int]lineState) *report.DiffFile {
+	added := make(map[int]bool)
+	for _, s := range change.Sections {
+		for line := s.StartLine; line <= s.EndLine; line++ {
+			added[line] = true
+		}
+	}
+	deleted := make(map[int]*gittool.Section)
+	for _, s := range change.Deleted {
+		deleted[s.StartLine] = s
+	}
+
+	var rows, pending []*report.DiffRow
+	old := 1
+	// takeDeleted takes the deleted sections at the current line of the compared branch.
+	takeDeleted := func() {
+		for s, ok := deleted[old]; ok; s, ok = deleted[old] {
+			for i, text := range s.Contents {
+				pending = append(pending, &report.DiffRow{OldLine: s.StartLine + i, Old: text, Deleted: true})
+			}
+			old = s.EndLine + 1
+		}
+	}
+	for line := 1; line <= len(contents); line++ {
+		takeDeleted()
+		if added[line] {
+			row := &report.DiffRow{}
+			if len(pending) > 0 {
+				row, pending = pending[0], pending[1:]
+			}
+			row.NewLine, row.New, row.Added, row.State = line, contents[line-1], true, states[line].String()
+			rows = append(rows, row)
+			continue
+		}
+
+		rows = append(rows, pending...)
+		pending = nil
+		rows = append(rows, &report.DiffRow{OldLine: old, Old: contents[line-1], NewLine: line, New: contents[line-1]})
+		old++
+	}
+	takeDeleted()
+	rows = append(rows, pending...)
+
+	return &report.DiffFile{
+		FileName: fileName,
+		Path:     change.FileName,
+		Rows:     skipUnchanged(rows, diffContextLines),
+	}
+}
+
+// skipUnchanged replaces the unchanged lines that are not within n lines of the changes with a skipped row.
+func skipUnchanged(rows []*report.DiffRow, n int) []*report.DiffRow {
+	keep := make([]bool, len(rows))
+	for i, row := range rows {
+		if !row.Added && !row.Deleted {
+			continue
+		}
+		for j := max(0, i-n); j <= min(len(rows)-1, i+n); j++ {
+			keep[j] = true
+		}
+	}
+
+	var result []*report.DiffRow
+	for i, row := range rows {
+		if keep[i] {
+			result = append(result, row)
+		} else if len(result) == 0 || !result[len(result)-1].Skipped {
+			result = append(result, &report.DiffRow{Skipped: true})
+		}
+	}
+	return result
+}
+
+// diffFiles returns the side-by-side diff of the changed files that count for diff coverage,
+// files maps the absolute paths of the files to their coverage profiles, their contents are read through the overlay.
+// The diff files are sorted by file name.
+func diffFiles(changes []*gittool.Change, files map[string]*report.CoverageProfile, fileCache fileContentsCache, overlay *parser.Overlay, lines *lineCollector) ([]*report.DiffFile, error) {
+	var result []*report.DiffFile
+	for file, p := range files {
+		change := findFileChange(p.FileName, changes)
+		if change == nil {
+			continue
+		}
+		contents, err := findFileContents(fileCache, overlay.Source(file))
+		if err != nil {
+			return nil, fmt.Errorf("find file contents: %w", err)
+		}
+		result = append(result, newDiffFile(p.FileName, change, contents, lines.states(p.FileName)))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+	return result, nil
+}
+
+// findFileChange finds the change of the file, fileName is prefixed with the module path,
+// and the file name of the change is relative to the repository.
+func findFileChange(fileName string, changes []*gittool.Change) *gittool.Change {
+	for _, change := range changes {
+		if parser.InFolder(fileName, change.FileName) {
+			return change
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package gocover
+
+import (
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDiffFile(t *testing.T) {
+	t.Run("pair the deleted lines with the added lines", func(t *testing.T) {
+		// compared branch: a, b, old1, old2, c
+		// HEAD:            a, b, new1, c, new2
+		change := &gittool.Change{
+			FileName: "foo.go",
+			Mode:     gittool.ModifyMode,
+			Sections: []*gittool.Section{
+				{StartLine: 3, EndLine: 3, Contents: []string{"new1"}, Operation: gittool.Add},
+				{StartLine: 5, EndLine: 5, Contents: []string{"new2"}, Operation: gittool.Add},
+			},
+			Deleted: []*gittool.Section{
+				{StartLine: 3, EndLine: 4, Contents: []string{"old1", "old2"}, Operation: gittool.Delete},
+			},
+		}
+		states := map[int]lineState{3: lineCovered, 5: lineUncovered}
+
+		f := newDiffFile("github.com/foo/foo.go", change, []string{"a", "b", "new1", "c", "new2"}, states)
+		assert.Equal(t, "github.com/foo/foo.go", f.FileName)
+		assert.Equal(t, "foo.go", f.Path)
+		assert.Equal(t, []*report.DiffRow{
+			{OldLine: 1, Old: "a", NewLine: 1, New: "a"},
+			{OldLine: 2, Old: "b", NewLine: 2, New: "b"},
+			{OldLine: 3, Old: "old1", Deleted: true, NewLine: 3, New: "new1", Added: true, State: "covered"},
+			{OldLine: 4, Old: "old2", Deleted: true},
+			{OldLine: 5, Old: "c", NewLine: 4, New: "c"},
+			{NewLine: 5, New: "new2", Added: true, State: "uncovered"},
+		}, f.Rows)
+	})
+
+	t.Run("deleted lines at the end", func(t *testing.T) {
+		change := &gittool.Change{
+			Deleted: []*gittool.Section{{StartLine: 2, EndLine: 2, Contents: []string{"b"}, Operation: gittool.Delete}},
+		}
+		f := newDiffFile("foo.go", change, []string{"a"}, nil)
+		assert.Equal(t, []*report.DiffRow{
+			{OldLine: 1, Old: "a", NewLine: 1, New: "a"},
+			{OldLine: 2, Old: "b", Deleted: true},
+		}, f.Rows)
+	})
+}
+
+func TestSkipUnchanged(t *testing.T) {
+	var rows []*report.DiffRow
+	for i := 1; i <= 20; i++ {
+		rows = append(rows, &report.DiffRow{OldLine: i, NewLine: i, Added: i == 10})
+	}
+
+	result := skipUnchanged(rows, 3)
+	assert.Len(t, result, 9)
+	assert.True(t, result[0].Skipped)
+	assert.Equal(t, 7, result[1].NewLine)
+	assert.Equal(t, 13, result[7].NewLine)
+	assert.True(t, result[8].Skipped)
+	assert.Equal(t, []*report.DiffRow{{Skipped: true}}, skipUnchanged(rows[:5], 3))
+}
@@ -0,0 +1,107 @@
+package gocover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+// commitStatusContextPrefix prefixes the statistics type to the default context of the commit status.
+const commitStatusContextPrefix = "gocover/"
+
+// commitStatus sets the GitHub commit status with the coverage of the run,
+// for the repositories that use the commit statuses instead of the check runs.
+type commitStatus struct {
+	client     *github.Client
+	commit     string
+	context    string // gocover/{statistics type} if it's empty
+	detailsURL string
+}
+
+// newCommitStatus creates the commit status of the commit of the CI run, or HEAD when it doesn't run in CI,
+// it returns nil if the commit status is disabled.
+func newCommitStatus(
+	enabled bool,
+	statusContext string,
+	detailsURL string,
+	o *github.ClientOption,
+	environment *ci.Environment,
+	repositoryPath string,
+	logger logrus.FieldLogger,
+) (*commitStatus, error) {
+	if !enabled {
+		return nil, nil
+	}
+	if o == nil {
+		o = &github.ClientOption{}
+	}
+	client, err := github.NewClient(o, logger)
+	if err != nil {
+		return nil, fmt.Errorf("commit status: %w", err)
+	}
+
+	var commit string
+	if environment != nil {
+		commit = environment.Commit
+	}
+	if commit == "" {
+		gitClient, err := gittool.NewGitClient(repositoryPath)
+		if err != nil {
+			return nil, fmt.Errorf("commit status: %w", err)
+		}
+		if commit, _, err = gitClient.HeadCommit(); err != nil {
+			return nil, fmt.Errorf("commit status: %w", err)
+		}
+	}
+
+	return &commitStatus{
+		client:     client,
+		commit:     commit,
+		context:    statusContext,
+		detailsURL: detailsURL,
+	}, nil
+}
+
+// publish sets the status by the result of the coverage requirement, passErr is the error of the requirement check.
+// The status is failure if the coverage is low, and error if the requirement cannot be checked.
+func (s *commitStatus) publish(ctx context.Context, statistics *report.Statistics, passErr error) error {
+	if s == nil {
+		return nil
+	}
+
+	state := github.StateSuccess
+	if passErr != nil {
+		state = github.StateError
+		var e *GoCoverError
+		if errors.As(passErr, &e) && e.ExitCode == LowCoverageErrorExitCode {
+			state = github.StateFailure
+		}
+	}
+
+	statusContext := s.context
+	if statusContext == "" {
+		statusContext = commitStatusContextPrefix + string(statistics.StatisticsType)
+	}
+
+	return s.client.CreateStatus(ctx, s.commit, &github.Status{
+		State:       state,
+		TargetURL:   s.detailsURL,
+		Description: commitStatusDescription(statistics),
+		Context:     statusContext,
+	})
+}
+
+// commitStatusDescription describes the coverage, such as `83.20% of 125 changed lines covered`.
+func commitStatusDescription(statistics *report.Statistics) string {
+	lines := "lines"
+	if statistics.StatisticsType == report.DiffStatisticsType {
+		lines = "changed lines"
+	}
+	return fmt.Sprintf("%.2f%% of %d %s covered", statistics.TotalCoveragePercent, statistics.TotalEffectiveLines, lines)
+}
@@ -0,0 +1,75 @@
+package gocover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitStatus(t *testing.T) {
+	var (
+		path   string
+		status github.Status
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	o := &github.ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}
+
+	t.Run("disabled", func(t *testing.T) {
+		s, err := newCommitStatus(false, "", "", nil, nil, "", logrus.New())
+		assert.NoError(t, err)
+		assert.Nil(t, s)
+		assert.NoError(t, s.publish(context.Background(), &report.Statistics{}, nil))
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		_, err := newCommitStatus(true, "", "", &github.ClientOption{Repository: "owner/repo"}, nil, "", logrus.New())
+		assert.ErrorIs(t, err, github.ErrNoToken)
+	})
+
+	s, err := newCommitStatus(true, "", "https://example.com/coverage.html", o, &ci.Environment{Commit: "abc123"}, "", logrus.New())
+	assert.NoError(t, err)
+	statistics := &report.Statistics{StatisticsType: report.DiffStatisticsType, TotalEffectiveLines: 125, TotalCoveragePercent: 83.2}
+
+	for _, testCase := range []struct {
+		name     string
+		passErr  error
+		expected github.State
+	}{
+		{name: "success", expected: github.StateSuccess},
+		{name: "low coverage", passErr: WrapErrorWithCode(errors.New("low"), LowCoverageErrorExitCode, ""), expected: github.StateFailure},
+		{name: "error", passErr: errors.New("evaluate gate"), expected: github.StateError},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.NoError(t, s.publish(context.Background(), statistics, testCase.passErr))
+			assert.Equal(t, "/repos/owner/repo/statuses/abc123", path)
+			assert.Equal(t, github.Status{
+				State:       testCase.expected,
+				TargetURL:   "https://example.com/coverage.html",
+				Description: "83.20% of 125 changed lines covered",
+				Context:     "gocover/diff",
+			}, status)
+		})
+	}
+
+	t.Run("context", func(t *testing.T) {
+		s, err := newCommitStatus(true, "coverage", "", o, &ci.Environment{Commit: "abc123"}, "", logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, s.publish(context.Background(), &report.Statistics{StatisticsType: report.FullStatisticsType, TotalEffectiveLines: 10, TotalCoveragePercent: 50}, nil))
+		assert.Equal(t, "coverage", status.Context)
+		assert.Equal(t, "50.00% of 10 lines covered", status.Description)
+	})
+}
@@ -0,0 +1,234 @@
+package gocover
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/report"
+)
+
+// testEvent is an event of the go test -json output, refer to `go doc test2json`.
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// testResults collects the results of the tests from the go test -json outputs,
+// which are linked to the coverage of the functions that the failed and skipped tests are named after.
+type testResults struct {
+	packages map[string]*report.TestPackage
+	tests    map[string]*report.TestCase // by the package and the top-level test
+	outputs  map[string][]string         // the output lines of the top-level tests and their subtests
+}
+
+// loadTestResults reads the go test -json outputs, it returns nil if none of the files is set.
+func loadTestResults(files []string) (*testResults, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	results := &testResults{
+		packages: make(map[string]*report.TestPackage),
+		tests:    make(map[string]*report.TestCase),
+		outputs:  make(map[string][]string),
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("open test output: %w", err)
+		}
+		err = results.read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read test output %s: %w", file, err)
+		}
+	}
+	return results, nil
+}
+
+// read reads the events of the go test -json output, the lines that are not events are skipped,
+// such as the build errors written to the same stream.
+func (r *testResults) read(reader io.Reader) error {
+	br := bufio.NewReader(reader)
+	for {
+		line, err := br.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); bytes.HasPrefix(trimmed, []byte("{")) {
+			var event testEvent
+			if err := json.Unmarshal(trimmed, &event); err != nil {
+				return err
+			}
+			r.add(&event)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *testResults) add(event *testEvent) {
+	if event.Package == "" {
+		return
+	}
+	pkg, ok := r.packages[event.Package]
+	if !ok {
+		pkg = &report.TestPackage{Package: event.Package}
+		r.packages[event.Package] = pkg
+	}
+
+	if event.Test == "" {
+		switch event.Action {
+		case "pass", "fail", "skip":
+			// a package that fails in any run fails
+			if pkg.Result != "fail" {
+				pkg.Result = event.Action
+			}
+			pkg.Elapsed += event.Elapsed
+		}
+		return
+	}
+
+	top, _, subtest := strings.Cut(event.Test, "/")
+	key := event.Package + " " + top
+	if event.Action == "output" {
+		if message := testMessage(event.Output); message != "" {
+			r.outputs[key] = append(r.outputs[key], message)
+		}
+		return
+	}
+	if subtest {
+		return
+	}
+
+	switch event.Action {
+	case "pass":
+		pkg.Passed++
+	case "fail":
+		pkg.Failed++
+	case "skip":
+		pkg.Skipped++
+	default:
+		return
+	}
+	if event.Action == "pass" {
+		return
+	}
+	// a test that fails in any run, such as with -count, is reported as failed
+	if test, ok := r.tests[key]; !ok || test.Result != "fail" {
+		r.tests[key] = &report.TestCase{Package: event.Package, Test: top, Result: event.Action}
+	}
+}
+
+// testMessage returns the output line without the framing lines of go test, such as "=== RUN" and "--- FAIL".
+func testMessage(output string) string {
+	message := strings.TrimSpace(output)
+	for _, prefix := range []string{"=== ", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(message, prefix) {
+			return ""
+		}
+	}
+	return message
+}
+
+// statistics returns the results of the tests linked to the coverage of the functions, it returns nil without results.
+func (r *testResults) statistics(functions []*report.FunctionCoverage) *report.TestResults {
+	if r == nil {
+		return nil
+	}
+
+	byPackage := make(map[string][]*report.FunctionCoverage)
+	for _, f := range functions {
+		dir := path.Dir(f.FileName)
+		byPackage[dir] = append(byPackage[dir], f)
+	}
+
+	results := &report.TestResults{Packages: []*report.TestPackage{}, Tests: []*report.TestCase{}}
+	for _, pkg := range r.packages {
+		var effective, covered int
+		for _, f := range byPackage[pkg.Package] {
+			effective += f.TotalEffectiveLines
+			covered += f.CoveredLines
+		}
+		if pkg.Functions = len(byPackage[pkg.Package]); pkg.Functions > 0 {
+			pkg.CoveragePercent = calculateCoverage(int64(covered), int64(effective))
+		}
+		results.Packages = append(results.Packages, pkg)
+		results.Passed += pkg.Passed
+		results.Failed += pkg.Failed
+		results.Skipped += pkg.Skipped
+	}
+	sort.Slice(results.Packages, func(i, j int) bool {
+		return results.Packages[i].Package < results.Packages[j].Package
+	})
+
+	for key, test := range r.tests {
+		if outputs := r.outputs[key]; len(outputs) > 0 {
+			test.Message = outputs[0]
+		}
+		for _, f := range byPackage[test.Package] {
+			if f.CoveragePercent < 100 && testedBy(test.Test, f.Function) {
+				test.Gaps = append(test.Gaps, &report.TestGap{
+					FileName:        f.FileName,
+					Function:        f.Function,
+					StartLine:       f.StartLine,
+					CoveragePercent: f.CoveragePercent,
+				})
+			}
+		}
+		results.Tests = append(results.Tests, test)
+	}
+	sort.Slice(results.Tests, func(i, j int) bool {
+		a, b := results.Tests[i], results.Tests[j]
+		if a.Result != b.Result {
+			return a.Result == "fail"
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Test < b.Test
+	})
+	return results
+}
+
+// testedBy reports whether the function is the one that the test is named after, which is the function Foo
+// or a method of the type Foo for TestFoo, and the method Foo.Bar for TestFoo_Bar, compared case-insensitively.
+func testedBy(test string, function string) bool {
+	target := strings.TrimPrefix(test, "Test")
+	if target == "" || target == test {
+		return false
+	}
+
+	// drop the type parameters, such as "Map[K, V]" and "List[T].Push"
+	var name strings.Builder
+	depth := 0
+	for _, c := range function {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			name.WriteRune(c)
+		}
+	}
+	function = name.String()
+
+	if strings.EqualFold(function, target) || strings.EqualFold(function, strings.Replace(target, "_", ".", 1)) {
+		return true
+	}
+	receiver, _, isMethod := strings.Cut(function, ".")
+	return isMethod && strings.EqualFold(receiver, target)
+}
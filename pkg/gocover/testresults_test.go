@@ -0,0 +1,96 @@
+package gocover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOutput = `# example.com/foo/pkg/broken
+{"Action":"run","Package":"example.com/foo/pkg/bar","Test":"TestParse"}
+{"Action":"output","Package":"example.com/foo/pkg/bar","Test":"TestParse","Output":"=== RUN   TestParse\n"}
+{"Action":"pass","Package":"example.com/foo/pkg/bar","Test":"TestParse","Elapsed":0.01}
+{"Action":"run","Package":"example.com/foo/pkg/bar","Test":"TestParser_Close"}
+{"Action":"run","Package":"example.com/foo/pkg/bar","Test":"TestParser_Close/twice"}
+{"Action":"output","Package":"example.com/foo/pkg/bar","Test":"TestParser_Close/twice","Output":"    bar_test.go:12: close twice: unexpected error\n"}
+{"Action":"output","Package":"example.com/foo/pkg/bar","Test":"TestParser_Close/twice","Output":"    --- FAIL: TestParser_Close/twice (0.00s)\n"}
+{"Action":"fail","Package":"example.com/foo/pkg/bar","Test":"TestParser_Close/twice","Elapsed":0}
+{"Action":"fail","Package":"example.com/foo/pkg/bar","Test":"TestParser_Close","Elapsed":0}
+{"Action":"skip","Package":"example.com/foo/pkg/bar","Test":"TestIntegration","Elapsed":0}
+{"Action":"output","Package":"example.com/foo/pkg/bar","Test":"TestIntegration","Output":"    bar_test.go:20: skipping in short mode\n"}
+{"Action":"fail","Package":"example.com/foo/pkg/bar","Elapsed":0.5}
+{"Action":"skip","Package":"example.com/foo/pkg/zoo","Elapsed":0}
+`
+
+func TestTestResults(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.json")
+	assert.NoError(t, os.WriteFile(file, []byte(testOutput), 0644))
+
+	t.Run("no test output", func(t *testing.T) {
+		results, err := loadTestResults(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, results.statistics(nil))
+	})
+
+	t.Run("malformed test output", func(t *testing.T) {
+		malformed := filepath.Join(t.TempDir(), "test.json")
+		assert.NoError(t, os.WriteFile(malformed, []byte(`{"Action":`), 0644))
+		_, err := loadTestResults([]string{malformed})
+		assert.Error(t, err)
+	})
+
+	t.Run("link the tests to the coverage", func(t *testing.T) {
+		results, err := loadTestResults([]string{file})
+		assert.NoError(t, err)
+
+		statistics := results.statistics([]*report.FunctionCoverage{
+			{FileName: "example.com/foo/pkg/bar/bar.go", Function: "Parse", StartLine: 3, TotalEffectiveLines: 4, CoveredLines: 4, CoveragePercent: 100},
+			{FileName: "example.com/foo/pkg/bar/bar.go", Function: "Parser.Close", StartLine: 10, TotalEffectiveLines: 4, CoveredLines: 1, CoveragePercent: 25},
+			{FileName: "example.com/foo/pkg/bar/bar.go", Function: "Parser.Open", StartLine: 20, TotalEffectiveLines: 2, CoveredLines: 0, CoveragePercent: 0},
+		})
+		assert.Equal(t, 1, statistics.Passed)
+		assert.Equal(t, 1, statistics.Failed)
+		assert.Equal(t, 1, statistics.Skipped)
+
+		assert.Len(t, statistics.Packages, 2)
+		assert.Equal(t, &report.TestPackage{
+			Package: "example.com/foo/pkg/bar", Result: "fail", Passed: 1, Failed: 1, Skipped: 1, Elapsed: 0.5, Functions: 3, CoveragePercent: 50,
+		}, statistics.Packages[0])
+		assert.Equal(t, &report.TestPackage{Package: "example.com/foo/pkg/zoo", Result: "skip"}, statistics.Packages[1])
+
+		assert.Equal(t, []*report.TestCase{
+			{
+				Package: "example.com/foo/pkg/bar", Test: "TestParser_Close", Result: "fail", Message: "bar_test.go:12: close twice: unexpected error",
+				Gaps: []*report.TestGap{{FileName: "example.com/foo/pkg/bar/bar.go", Function: "Parser.Close", StartLine: 10, CoveragePercent: 25}},
+			},
+			{Package: "example.com/foo/pkg/bar", Test: "TestIntegration", Result: "skip", Message: "bar_test.go:20: skipping in short mode"},
+		}, statistics.Tests)
+	})
+}
+
+func TestTestedBy(t *testing.T) {
+	testSuites := []struct {
+		test     string
+		function string
+		expect   bool
+	}{
+		{test: "TestFoo", function: "Foo", expect: true},
+		{test: "TestFoo", function: "foo", expect: true},
+		{test: "TestFoo", function: "Foo.Bar", expect: true},
+		{test: "TestFoo_Bar", function: "Foo.Bar", expect: true},
+		{test: "TestFoo_Bar", function: "Foo_Bar", expect: true},
+		{test: "TestMap", function: "Map[K, V]", expect: true},
+		{test: "TestList_Push", function: "List[T].Push", expect: true},
+		{test: "TestFoo", function: "FooBar", expect: false},
+		{test: "TestFoo_Bar", function: "Foo.Baz", expect: false},
+		{test: "Test", function: "Foo", expect: false},
+		{test: "ExampleFoo", function: "Foo", expect: false},
+	}
+
+	for _, testCase := range testSuites {
+		assert.Equal(t, testCase.expect, testedBy(testCase.test, testCase.function), "%s %s", testCase.test, testCase.function)
+	}
+}
@@ -0,0 +1,164 @@
+package gocover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+)
+
+// trackingIssueLabel labels the tracking issues, which are found by the label and the title of the pull request.
+const trackingIssueLabel = "gocover-uncovered"
+
+// trackingIssue opens or updates a GitHub issue of the uncovered changed functions of a merged pull request,
+// assigned to the author of the pull request, so that the gaps merged under pressure are followed up.
+type trackingIssue struct {
+	client      *github.Client
+	pullRequest string // number of the pull request, the pull request of the commit is used if it's empty
+	commit      string
+	logger      logrus.FieldLogger
+}
+
+// newTrackingIssue creates the tracking issue of the pull request of the CI run, it returns nil if it's disabled.
+func newTrackingIssue(enabled bool, o *github.ClientOption, environment *ci.Environment, logger logrus.FieldLogger) (*trackingIssue, error) {
+	if !enabled {
+		return nil, nil
+	}
+	if o == nil {
+		o = &github.ClientOption{}
+	}
+	client, err := github.NewClient(o, logger)
+	if err != nil {
+		return nil, fmt.Errorf("tracking issue: %w", err)
+	}
+
+	t := &trackingIssue{client: client, logger: logger}
+	if environment != nil {
+		t.pullRequest = environment.PullRequest
+		t.commit = environment.Commit
+	}
+	return t, nil
+}
+
+// publish lists the functions that are not fully covered in the tracking issue, the issue is closed
+// when all of them are covered, and it's not opened if there is none.
+func (t *trackingIssue) publish(ctx context.Context, functions []*report.FunctionCoverage) error {
+	if t == nil {
+		return nil
+	}
+
+	pull, err := t.findPullRequest(ctx)
+	if err != nil {
+		return err
+	}
+	if pull == nil {
+		t.logger.Warnf("no merged pull request is found for the commit %s, the tracking issue is not published", t.commit)
+		return nil
+	}
+
+	var uncovered []*report.FunctionCoverage
+	for _, f := range functions {
+		if f.CoveredLines < f.TotalEffectiveLines {
+			uncovered = append(uncovered, f)
+		}
+	}
+	sort.SliceStable(uncovered, func(i, j int) bool {
+		return uncovered[i].TotalEffectiveLines-uncovered[i].CoveredLines > uncovered[j].TotalEffectiveLines-uncovered[j].CoveredLines
+	})
+
+	title := fmt.Sprintf("Uncovered new code of #%d", pull.Number)
+	issues, err := t.client.ListOpenIssues(ctx, trackingIssueLabel)
+	if err != nil {
+		return err
+	}
+	var existing *github.Issue
+	for _, issue := range issues {
+		if issue.Title == title {
+			existing = issue
+			break
+		}
+	}
+
+	if len(uncovered) == 0 {
+		if existing == nil {
+			return nil
+		}
+		_, err := t.client.UpdateIssue(ctx, existing.Number, &github.IssueRequest{
+			Body:  fmt.Sprintf("All the changed functions of #%d are covered.", pull.Number),
+			State: github.IssueStateClosed,
+		})
+		return err
+	}
+
+	request := &github.IssueRequest{
+		Title:  title,
+		Body:   trackingIssueBody(pull, uncovered),
+		Labels: []string{trackingIssueLabel},
+	}
+	if pull.User != nil && pull.User.Login != "" {
+		request.Assignees = []string{pull.User.Login}
+	}
+	if existing != nil {
+		_, err = t.client.UpdateIssue(ctx, existing.Number, request)
+	} else {
+		_, err = t.client.CreateIssue(ctx, request)
+	}
+	return err
+}
+
+// findPullRequest gets the pull request of the CI run, or the pull request that the commit is merged from,
+// it returns nil if there is none or the pull request is not merged yet.
+func (t *trackingIssue) findPullRequest(ctx context.Context) (*github.PullRequest, error) {
+	if t.pullRequest != "" {
+		number, err := strconv.Atoi(t.pullRequest)
+		if err != nil {
+			return nil, fmt.Errorf("tracking issue: pull request %q is not a number", t.pullRequest)
+		}
+		pull, err := t.client.GetPullRequest(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		if !pull.Merged() {
+			t.logger.Infof("pull request #%d is not merged", pull.Number)
+			return nil, nil
+		}
+		return pull, nil
+	}
+	if t.commit == "" {
+		return nil, nil
+	}
+
+	// the open pull requests that contain the commit are listed as well, only the merged one is tracked.
+	pulls, err := t.client.PullRequestsOfCommit(ctx, t.commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, pull := range pulls {
+		if pull.Merged() {
+			return pull, nil
+		}
+	}
+	return nil, nil
+}
+
+// trackingIssueBody lists the uncovered functions in a markdown table, the most uncovered first.
+func trackingIssueBody(pull *github.PullRequest, uncovered []*report.FunctionCoverage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The changed functions of #%d", pull.Number)
+	if pull.User != nil && pull.User.Login != "" {
+		fmt.Fprintf(&b, " by @%s", pull.User.Login)
+	}
+	fmt.Fprintf(&b, " are not fully covered by tests, %d functions are found by gocover diff coverage.\n\n", len(uncovered))
+	b.WriteString("| Function | Location | Uncovered Lines | Coverage (%) |\n")
+	b.WriteString("| --- | --- | ---: | ---: |\n")
+	for _, f := range uncovered {
+		fmt.Fprintf(&b, "| %s | %s:%d | %d | %.2f |\n", f.Function, f.FileName, f.StartLine, f.TotalEffectiveLines-f.CoveredLines, f.CoveragePercent)
+	}
+	return b.String()
+}
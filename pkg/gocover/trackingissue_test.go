@@ -0,0 +1,127 @@
+package gocover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/github"
+	"github.com/Azure/gocover/pkg/report"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackingIssue(t *testing.T) {
+	var (
+		existing string
+		requests []string
+		request  github.IssueRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/repos/owner/repo/commits/abc123/pulls":
+			fmt.Fprint(w, `[{"number": 15, "user": {"login": "hubot"}}, {"number": 12, "user": {"login": "octocat"}, "merged_at": "2024-05-01T10:00:00Z"}]`)
+		case r.URL.Path == "/repos/owner/repo/pulls/12":
+			fmt.Fprint(w, `{"number": 12, "user": {"login": "octocat"}, "merged_at": "2024-05-01T10:00:00Z"}`)
+		case r.URL.Path == "/repos/owner/repo/pulls/15":
+			fmt.Fprint(w, `{"number": 15, "user": {"login": "hubot"}}`)
+		case r.Method == http.MethodGet:
+			fmt.Fprintf(w, `[%s]`, existing)
+		default:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+			}
+			fmt.Fprint(w, `{"number": 3}`)
+		}
+	}))
+	defer server.Close()
+
+	o := &github.ClientOption{APIURL: server.URL, Repository: "owner/repo", Token: "token"}
+	functions := []*report.FunctionCoverage{
+		{FileName: "a.go", Function: "Covered", StartLine: 3, CoveredLines: 2, TotalEffectiveLines: 2, CoveragePercent: 100},
+		{FileName: "a.go", Function: "Partial", StartLine: 8, CoveredLines: 1, TotalEffectiveLines: 2, CoveragePercent: 50},
+		{FileName: "b.go", Function: "Uncovered", StartLine: 5, CoveredLines: 0, TotalEffectiveLines: 4, CoveragePercent: 0},
+	}
+	reset := func(issue string) {
+		existing, requests, request = issue, nil, github.IssueRequest{}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		tracking, err := newTrackingIssue(false, o, &ci.Environment{Commit: "abc123"}, logrus.New())
+		assert.NoError(t, err)
+		assert.Nil(t, tracking)
+		assert.NoError(t, tracking.publish(context.Background(), functions))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		reset("")
+		tracking, err := newTrackingIssue(true, o, &ci.Environment{Commit: "abc123"}, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, tracking.publish(context.Background(), functions))
+		assert.Equal(t, []string{
+			"GET /repos/owner/repo/commits/abc123/pulls",
+			"GET /repos/owner/repo/issues",
+			"POST /repos/owner/repo/issues",
+		}, requests)
+		assert.Equal(t, "Uncovered new code of #12", request.Title)
+		assert.Equal(t, []string{trackingIssueLabel}, request.Labels)
+		assert.Equal(t, []string{"octocat"}, request.Assignees)
+		assert.Equal(t, "The changed functions of #12 by @octocat are not fully covered by tests, 2 functions are found by gocover diff coverage.\n\n"+
+			"| Function | Location | Uncovered Lines | Coverage (%) |\n"+
+			"| --- | --- | ---: | ---: |\n"+
+			"| Uncovered | b.go:5 | 4 | 0.00 |\n"+
+			"| Partial | a.go:8 | 1 | 50.00 |\n", request.Body)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		reset(`{"number": 3, "title": "Uncovered new code of #12", "state": "open"}`)
+		tracking, err := newTrackingIssue(true, o, &ci.Environment{PullRequest: "12"}, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, tracking.publish(context.Background(), functions))
+		assert.Equal(t, []string{
+			"GET /repos/owner/repo/pulls/12",
+			"GET /repos/owner/repo/issues",
+			"PATCH /repos/owner/repo/issues/3",
+		}, requests)
+		assert.Equal(t, "Uncovered new code of #12", request.Title)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		reset(`{"number": 3, "title": "Uncovered new code of #12", "state": "open"}`)
+		tracking, err := newTrackingIssue(true, o, &ci.Environment{PullRequest: "12"}, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, tracking.publish(context.Background(), functions[:1]))
+		assert.Equal(t, "PATCH /repos/owner/repo/issues/3", requests[len(requests)-1])
+		assert.Equal(t, github.IssueStateClosed, request.State)
+	})
+
+	t.Run("all covered without issue", func(t *testing.T) {
+		reset("")
+		tracking, err := newTrackingIssue(true, o, &ci.Environment{PullRequest: "12"}, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, tracking.publish(context.Background(), functions[:1]))
+		assert.Equal(t, []string{"GET /repos/owner/repo/pulls/12", "GET /repos/owner/repo/issues"}, requests)
+	})
+
+	t.Run("not merged", func(t *testing.T) {
+		reset("")
+		tracking, err := newTrackingIssue(true, o, &ci.Environment{PullRequest: "15"}, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, tracking.publish(context.Background(), functions))
+		assert.Equal(t, []string{"GET /repos/owner/repo/pulls/15"}, requests)
+	})
+
+	t.Run("no pull request", func(t *testing.T) {
+		reset("")
+		tracking, err := newTrackingIssue(true, o, &ci.Environment{}, logrus.New())
+		assert.NoError(t, err)
+		assert.NoError(t, tracking.publish(context.Background(), functions))
+		assert.Empty(t, requests)
+	})
+}
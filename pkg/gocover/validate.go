@@ -0,0 +1,121 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/build"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// NewValidate creates a GoCover that checks the cover profiles and prints their problems.
+func NewValidate(o *ValidateOption) (GoCover, error) {
+	logger := o.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+	logger = logger.WithField("source", "validate")
+
+	repositoryAbsPath, err := realAbsPath(o.RepositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of repo: %w", err)
+	}
+	moduleRoot := filepath.Join(repositoryAbsPath, o.ModuleDir)
+	// the profile can be validated out of a module, whose files are resolved by importing the packages.
+	modulePath, err := parseGoModulePath(moduleRoot)
+	if err != nil {
+		logger.WithError(err).Debug("resolve the files by importing the packages")
+	}
+
+	stdout := o.StdOut
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &validate{
+		profiles:   o.CoverProfiles,
+		moduleRoot: moduleRoot,
+		modulePath: modulePath,
+		stdout:     stdout,
+		logger:     logger,
+	}, nil
+}
+
+var _ GoCover = (*validate)(nil)
+
+// validate implements the GoCover interface and checks the cover profiles before they're analyzed.
+type validate struct {
+	profiles   []string
+	moduleRoot string
+	modulePath string
+	stdout     io.Writer
+
+	logger logrus.FieldLogger
+}
+
+func (v *validate) Run(ctx context.Context) error {
+	total := 0
+	for _, profile := range v.profiles {
+		problems, err := v.validateProfile(profile)
+		if err != nil {
+			return err
+		}
+		for _, p := range problems {
+			fmt.Fprintf(v.stdout, "%s:%s\n", profile, p)
+		}
+		v.logger.Infof("%d problems in %s", len(problems), profile)
+		total += len(problems)
+	}
+
+	if total > 0 {
+		return WrapErrorWithCode(fmt.Errorf("%d problems in the cover profiles", total), InvalidProfileErrorExitCode, "")
+	}
+	return nil
+}
+
+func (v *validate) validateProfile(profile string) ([]*parser.ProfileProblem, error) {
+	f, err := os.Open(profile)
+	if err != nil {
+		return nil, fmt.Errorf("open cover profile: %w", err)
+	}
+	defer f.Close()
+
+	problems, err := parser.ValidateProfile(f, v.resolve)
+	if err != nil {
+		return nil, fmt.Errorf("read cover profile %s: %w", profile, err)
+	}
+	return problems, nil
+}
+
+// resolve returns the number of lines of the file in the profile, the files of the module are resolved under the module root,
+// and the others are resolved by importing their packages.
+func (v *validate) resolve(file string) (int, error) {
+	var name string
+	if v.modulePath != "" && strings.HasPrefix(file, v.modulePath+"/") {
+		name = filepath.Join(v.moduleRoot, filepath.FromSlash(strings.TrimPrefix(file, v.modulePath+"/")))
+	} else {
+		dir, base := path.Split(file)
+		pkg, err := build.Import(strings.TrimSuffix(dir, "/"), ".", build.FindOnly)
+		if err != nil {
+			return 0, err
+		}
+		name = filepath.Join(pkg.Dir, base)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines, nil
+}
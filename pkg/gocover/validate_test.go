@@ -0,0 +1,56 @@
+package gocover
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/foo/bar\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo.go"), []byte("package bar\n\nfunc Foo() int {\n\treturn 1\n}\n"), 0644))
+
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		var buf bytes.Buffer
+		v, err := NewValidate(&ValidateOption{
+			CoverProfiles:  []string{write("valid.out", "mode: set\ngithub.com/foo/bar/foo.go:3.16,5.2 1 1\n")},
+			RepositoryPath: dir,
+			ModuleDir:      "./",
+			StdOut:         &buf,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, v.Run(context.Background()))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		var buf bytes.Buffer
+		profile := write("invalid.out", "mode: set\ngithub.com/foo/bar/foo.go:3.16,9.2 1 1\ngithub.com/foo/bar/bar.go:1.1,1.2 1 0\n")
+		v, err := NewValidate(&ValidateOption{
+			CoverProfiles:  []string{profile},
+			RepositoryPath: dir,
+			ModuleDir:      "./",
+			StdOut:         &buf,
+		})
+		assert.NoError(t, err)
+
+		err = v.Run(context.Background())
+		var e *GoCoverError
+		assert.True(t, errors.As(err, &e))
+		assert.Equal(t, InvalidProfileErrorExitCode, e.ExitCode)
+		assert.Contains(t, buf.String(), profile+":2: unknown file: block ends at line 9 beyond the end of github.com/foo/bar/foo.go, which has 5 lines\n")
+		assert.Contains(t, buf.String(), profile+":3: unknown file: github.com/foo/bar/bar.go: ")
+	})
+}
@@ -0,0 +1,3 @@
+// Package history stores the coverage result of each run,
+// so that the results can be compared across runs.
+package history
@@ -0,0 +1,128 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/gocover/pkg/compression"
+)
+
+const (
+	recordFileExtension = ".json"
+)
+
+// Store represents the backend that stores the history records.
+type Store interface {
+	// Append stores the record.
+	Append(ctx context.Context, record *Record) error
+	// List returns the records that match the query, sorted by timestamp in ascending order.
+	List(ctx context.Context, query *Query) ([]*Record, error)
+}
+
+// NewFileStore creates a store that keeps each record as a json file in the directory,
+// the record is compressed with the algorithm when it's appended. The records are decompressed on read
+// whichever algorithm they're compressed with, so the algorithm can be changed for an existing directory.
+func NewFileStore(dir string, algorithm compression.Algorithm) (Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+	return &fileStore{dir: dir, algorithm: algorithm}, nil
+}
+
+// fileStore implements Store on local file system.
+type fileStore struct {
+	dir       string
+	algorithm compression.Algorithm
+}
+
+var _ Store = (*fileStore)(nil)
+
+func (s *fileStore) Append(ctx context.Context, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	data, err = compression.Compress(s.algorithm, data)
+	if err != nil {
+		return fmt.Errorf("compress history record: %w", err)
+	}
+
+	f, err := os.CreateTemp(s.dir, recordFilePattern(record)+s.algorithm.Extension())
+	if err != nil {
+		return fmt.Errorf("create history record: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write history record: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close history record: %w", err)
+	}
+	return nil
+}
+
+func (s *fileStore) List(ctx context.Context, query *Query) ([]*Record, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read history directory: %w", err)
+	}
+
+	var records []*Record
+	for _, entry := range entries {
+		if entry.IsDir() || !isRecordFile(entry.Name()) {
+			continue
+		}
+
+		data, err := compression.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read history record: %w", err)
+		}
+		record := &Record{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return nil, fmt.Errorf("json unmarshal %s: %w", entry.Name(), err)
+		}
+
+		if query == nil || query.Match(record) {
+			records = append(records, record)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	if query != nil && query.Limit > 0 && len(records) > query.Limit {
+		records = records[len(records)-query.Limit:]
+	}
+	return records, nil
+}
+
+// recordFilePattern returns the file name pattern of the record, which sorts by time.
+// The random string that replaces "*" keeps the file name unique for each run.
+func recordFilePattern(record *Record) string {
+	commit := record.Commit
+	if len(commit) > 8 {
+		commit = commit[:8]
+	}
+	name := fmt.Sprintf("%d", record.Timestamp.UnixNano())
+	if commit != "" {
+		name = fmt.Sprintf("%s-%s", name, commit)
+	}
+	return name + "-*" + recordFileExtension
+}
+
+// isRecordFile checks whether the file is a record file, which may be compressed.
+func isRecordFile(name string) bool {
+	for _, a := range []compression.Algorithm{compression.None, compression.Gzip, compression.Zstd} {
+		if strings.HasSuffix(name, recordFileExtension+a.Extension()) {
+			return true
+		}
+	}
+	return false
+}
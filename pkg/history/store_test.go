@@ -0,0 +1,126 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("append and list", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileStore(filepath.Join(dir, "history"), compression.None)
+		assert.NoError(t, err)
+
+		ctx := context.Background()
+		now := time.Now().UTC()
+		records := []*Record{
+			{Timestamp: now.Add(2 * time.Hour), ModulePath: "foo", CoverageMode: "full", Commit: "0123456789abcdef", CoveragePercent: 30},
+			{Timestamp: now, ModulePath: "foo", CoverageMode: "full", CoveragePercent: 10},
+			{Timestamp: now.Add(time.Hour), ModulePath: "foo", CoverageMode: "full", CoveragePercent: 20},
+			{Timestamp: now.Add(time.Hour), ModulePath: "foo", CoverageMode: "diff", CoveragePercent: 90},
+			{Timestamp: now.Add(time.Hour), ModulePath: "bar", CoverageMode: "full", CoveragePercent: 50},
+		}
+		for _, r := range records {
+			assert.NoError(t, store.Append(ctx, r))
+		}
+
+		all, err := store.List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, all, 5)
+
+		full, err := store.List(ctx, &Query{ModulePath: "foo", CoverageMode: "full"})
+		assert.NoError(t, err)
+		assert.Len(t, full, 3)
+		assert.Equal(t, 10.0, full[0].CoveragePercent)
+		assert.Equal(t, 20.0, full[1].CoveragePercent)
+		assert.Equal(t, 30.0, full[2].CoveragePercent)
+		assert.Equal(t, "0123456789abcdef", full[2].Commit)
+
+		commit, err := store.List(ctx, &Query{ModulePath: "foo", Commit: "0123456789abcdef"})
+		assert.NoError(t, err)
+		assert.Len(t, commit, 1)
+		assert.Equal(t, 30.0, commit[0].CoveragePercent)
+
+		latest, err := store.List(ctx, &Query{ModulePath: "foo", CoverageMode: "full", Limit: 2})
+		assert.NoError(t, err)
+		assert.Len(t, latest, 2)
+		assert.Equal(t, 20.0, latest[0].CoveragePercent)
+		assert.Equal(t, 30.0, latest[1].CoveragePercent)
+	})
+
+	t.Run("compressed records", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := context.Background()
+		now := time.Now().UTC()
+
+		// the algorithm changes for the existing directory
+		for i, a := range []compression.Algorithm{compression.None, compression.Gzip, compression.Zstd} {
+			store, err := NewFileStore(dir, a)
+			assert.NoError(t, err)
+			assert.NoError(t, store.Append(ctx, &Record{Timestamp: now.Add(time.Duration(i) * time.Hour), CoveragePercent: float64(i)}))
+		}
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, filepath.Ext(e.Name()))
+		}
+		assert.ElementsMatch(t, []string{".json", ".gz", ".zst"}, names)
+
+		store, err := NewFileStore(dir, compression.None)
+		assert.NoError(t, err)
+		records, err := store.List(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, records, 3)
+		assert.Equal(t, 2.0, records[2].CoveragePercent)
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewFileStore(dir, compression.None)
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0644))
+		_, err = store.List(context.Background(), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestRecordFilePattern(t *testing.T) {
+	t.Run("recordFilePattern", func(t *testing.T) {
+		ts := time.Unix(0, 100)
+		assert.Equal(t, "100-01234567-*.json", recordFilePattern(&Record{Timestamp: ts, Commit: "0123456789"}))
+		assert.Equal(t, "100-*.json", recordFilePattern(&Record{Timestamp: ts}))
+	})
+}
+
+func TestQueryMatch(t *testing.T) {
+	now := time.Now().UTC()
+	record := &Record{Timestamp: now, ModulePath: "foo", CoverageMode: "full", Commit: "abc", Branch: "main"}
+
+	for _, testCase := range []struct {
+		name     string
+		query    *Query
+		expected bool
+	}{
+		{name: "empty", query: &Query{}, expected: true},
+		{name: "branch", query: &Query{Branch: "main"}, expected: true},
+		{name: "other branch", query: &Query{Branch: "dev"}, expected: false},
+		{name: "since", query: &Query{Since: now}, expected: true},
+		{name: "since later", query: &Query{Since: now.Add(time.Second)}, expected: false},
+		{name: "until later", query: &Query{Until: now.Add(time.Second)}, expected: true},
+		{name: "until", query: &Query{Until: now}, expected: false},
+		{name: "date range", query: &Query{ModulePath: "foo", Since: now.Add(-time.Hour), Until: now.Add(time.Hour)}, expected: true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, testCase.query.Match(record))
+		})
+	}
+}
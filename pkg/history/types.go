@@ -0,0 +1,93 @@
+package history
+
+import "time"
+
+// Record represents the coverage result of a run.
+type Record struct {
+	// Timestamp is the time that the run finished.
+	Timestamp time.Time `json:"timestamp"`
+	// ModulePath is the module path declared in go.mod.
+	ModulePath string `json:"modulePath"`
+	// Commit is the hash of the HEAD commit of the run.
+	Commit string `json:"commit"`
+	// Branch is the branch of the run, it's empty when HEAD is detached.
+	Branch string `json:"branch"`
+	// CIProvider is the CI system of the run, it's empty when the run is not in CI.
+	CIProvider string `json:"ciProvider,omitempty"`
+	// PullRequest is the number of the pull request of the run in CI.
+	PullRequest string `json:"pullRequest,omitempty"`
+	// BuildID is the id of the run in CI.
+	BuildID string `json:"buildId,omitempty"`
+	// Labels are the key/value labels of the run, such as the service, the environment or the test suite.
+	Labels map[string]string `json:"labels,omitempty"`
+	// CoverageMode is the coverage mode of the run, full or diff.
+	CoverageMode string `json:"coverageMode"`
+	// TotalEffectiveLines indicates effective lines of the run.
+	TotalEffectiveLines int `json:"totalEffectiveLines"`
+	// TotalCoveredLines indicates covered lines of the run that count for coverage.
+	TotalCoveredLines int `json:"totalCoveredLines"`
+	// CoveragePercent represents the coverage percent (with ignorance) of the run.
+	CoveragePercent float64 `json:"coveragePercent"`
+	// Functions represents the coverage of each function.
+	Functions []*FunctionRecord `json:"functions,omitempty"`
+}
+
+// FunctionRecord represents the coverage of a function in a run.
+type FunctionRecord struct {
+	// FileName indicates which file the function belongs to.
+	FileName string `json:"fileName"`
+	// Function is the name of the function.
+	Function string `json:"function"`
+	// StartLine is the start line of the function.
+	StartLine int `json:"startLine"`
+	// TotalEffectiveLines indicates effective lines of the function.
+	TotalEffectiveLines int `json:"totalEffectiveLines"`
+	// CoveredLines indicates covered lines of the function that count for coverage.
+	CoveredLines int `json:"coveredLines"`
+	// MinHits, AvgHits and MaxHits are the hit counts of the effective lines, they're only
+	// recorded in count and atomic cover modes.
+	MinHits int64   `json:"minHits,omitempty"`
+	AvgHits float64 `json:"avgHits,omitempty"`
+	MaxHits int64   `json:"maxHits,omitempty"`
+}
+
+// Query represents the conditions to find the records.
+type Query struct {
+	// ModulePath matches the records of the module, matches all if it's empty.
+	ModulePath string
+	// CoverageMode matches the records of the coverage mode, matches all if it's empty.
+	CoverageMode string
+	// Commit matches the records of the commit, matches all if it's empty.
+	Commit string
+	// Branch matches the records of the branch, matches all if it's empty.
+	Branch string
+	// Since matches the records at or after it, matches all if it's zero.
+	Since time.Time
+	// Until matches the records before it, matches all if it's zero.
+	Until time.Time
+	// Limit returns the latest records up to limit, returns all if it's zero.
+	Limit int
+}
+
+// Match returns whether the record matches the query.
+func (q *Query) Match(r *Record) bool {
+	if q.ModulePath != "" && q.ModulePath != r.ModulePath {
+		return false
+	}
+	if q.CoverageMode != "" && q.CoverageMode != r.CoverageMode {
+		return false
+	}
+	if q.Commit != "" && q.Commit != r.Commit {
+		return false
+	}
+	if q.Branch != "" && q.Branch != r.Branch {
+		return false
+	}
+	if !q.Since.IsZero() && r.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Timestamp.Before(q.Until) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+// Package httpclient creates the http client of the calls to the external services, such as GitHub, the webhooks and kusto,
+// so that they go through the proxy and trust the CA of the corporate networks that intercept TLS.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+var (
+	ErrNoCertificates    = errors.New("no certificates in the CA bundle")
+	ErrClientCertificate = errors.New("client certificate and client key should be set together")
+	ErrInvalidProxy      = errors.New("invalid proxy url")
+)
+
+// Option is how the http client connects to the external services. The zero value is the default of the go http client,
+// which goes through the proxy of HTTPS_PROXY and HTTP_PROXY except the hosts of NO_PROXY.
+type Option struct {
+	// Proxy is the url of the proxy, such as http://proxy.example.com:8080, which replaces HTTPS_PROXY and HTTP_PROXY.
+	// The hosts of NO_PROXY still don't go through the proxy.
+	Proxy string
+	// CABundle is the PEM file of the CA certificates that the servers are verified with in addition to the system roots.
+	CABundle string
+	// ClientCert and ClientKey are the PEM files of the certificate and the private key of mutual TLS.
+	ClientCert string
+	ClientKey  string
+}
+
+// IsZero reports whether the option is the default of the go http client.
+func (o *Option) IsZero() bool {
+	return o == nil || *o == Option{}
+}
+
+// Validate checks that the proxy is an absolute url and the client certificate comes with its key.
+func (o *Option) Validate() error {
+	if o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidProxy, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%w: %q should be in {scheme}://{host}:{port} format", ErrInvalidProxy, o.Proxy)
+		}
+	}
+	if (o.ClientCert == "") != (o.ClientKey == "") {
+		return ErrClientCertificate
+	}
+	return nil
+}
+
+// New creates the http client of the option, it's http.DefaultClient if the option is zero.
+func New(o *Option) (*http.Client, error) {
+	if o.IsZero() {
+		return http.DefaultClient, nil
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.Proxy != "" {
+		proxy := httpproxy.FromEnvironment()
+		proxy.HTTPProxy, proxy.HTTPSProxy = o.Proxy, o.Proxy
+		proxyFunc := proxy.ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	tlsConfig, err := o.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// CABundleData reads the PEM certificates of the CA bundle, it's nil if the CA bundle is not set.
+func (o *Option) CABundleData() ([]byte, error) {
+	if o.IsZero() || o.CABundle == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(o.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	return data, nil
+}
+
+// tlsConfig returns the TLS config of the CA bundle and the client certificate, it's nil if neither is set.
+func (o *Option) tlsConfig() (*tls.Config, error) {
+	if o.CABundle == "" && o.ClientCert == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.CABundle != "" {
+		data, err := o.CABundleData()
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%w: %s", ErrNoCertificates, o.CABundle)
+		}
+		config.RootCAs = pool
+	}
+	if o.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(o.ClientCert, o.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, (&Option{}).Validate())
+	assert.NoError(t, (&Option{Proxy: "http://proxy.example.com:8080", ClientCert: "cert.pem", ClientKey: "key.pem"}).Validate())
+	assert.ErrorIs(t, (&Option{Proxy: "proxy.example.com:8080"}).Validate(), ErrInvalidProxy)
+	assert.ErrorIs(t, (&Option{ClientCert: "cert.pem"}).Validate(), ErrClientCertificate)
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("default", func(t *testing.T) {
+		client, err := New(&Option{})
+		assert.NoError(t, err)
+		assert.Equal(t, http.DefaultClient, client)
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var requested string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL.String()
+		}))
+		defer proxy.Close()
+		t.Setenv("NO_PROXY", "direct.invalid")
+
+		client, err := New(&Option{Proxy: proxy.URL})
+		assert.NoError(t, err)
+		resp, err := client.Get("http://gocover.invalid/status")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		assert.Equal(t, "http://gocover.invalid/status", requested)
+
+		_, err = client.Get("http://direct.invalid/status")
+		assert.Error(t, err)
+	})
+
+	t.Run("ca bundle", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		_, err := http.Get(server.URL)
+		assert.Error(t, err)
+
+		bundle := filepath.Join(dir, "ca.pem")
+		writePEM(t, bundle, "CERTIFICATE", server.Certificate().Raw)
+		client, err := New(&Option{CABundle: bundle})
+		assert.NoError(t, err)
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		empty := filepath.Join(dir, "empty.pem")
+		assert.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0644))
+		_, err = New(&Option{CABundle: empty})
+		assert.ErrorIs(t, err, ErrNoCertificates)
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		var subject string
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject = r.TLS.PeerCertificates[0].Subject.CommonName
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+		defer server.Close()
+
+		bundle := filepath.Join(dir, "server.pem")
+		writePEM(t, bundle, "CERTIFICATE", server.Certificate().Raw)
+		cert, key := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+		writeClientCertificate(t, cert, key)
+
+		client, err := New(&Option{CABundle: bundle, ClientCert: cert, ClientKey: key})
+		assert.NoError(t, err)
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		assert.Equal(t, "gocover", subject)
+
+		_, err = New(&Option{ClientCert: cert, ClientKey: bundle})
+		assert.Error(t, err)
+	})
+}
+
+func writePEM(t *testing.T, file, blockType string, data []byte) {
+	assert.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600))
+}
+
+// writeClientCertificate writes a self-signed client certificate and its private key.
+func writeClientCertificate(t *testing.T, cert, key string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gocover"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	assert.NoError(t, err)
+	writePEM(t, cert, "CERTIFICATE", der)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+	writePEM(t, key, "PRIVATE KEY", keyDER)
+}
@@ -0,0 +1,4 @@
+// Package lsp implements a minimal language server that publishes coverage diagnostics,
+// so that editors show the coverage gaps inline while developing.
+// See https://microsoft.github.io/language-server-protocol/specification for the protocol.
+package lsp
@@ -0,0 +1,177 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxContentLength caps the content of a message, the messages of the client are small notifications.
+const maxContentLength = 16 << 20
+
+var ErrInvalidContentLength = errors.New("invalid content length")
+
+// DiagnosticsFunc returns the diagnostics of each text document, keyed by the document URI.
+type DiagnosticsFunc func(ctx context.Context) (map[string][]*Diagnostic, error)
+
+// Server is a language server that publishes the diagnostics when the client is initialized
+// and each time a text document is opened or saved, so the diagnostics follow the latest cover profile.
+type Server struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	diagnostics DiagnosticsFunc
+	// published are the documents that have diagnostics published, they are cleared when the gaps are fixed.
+	published map[string]bool
+
+	logger logrus.FieldLogger
+}
+
+// NewServer creates a language server that communicates over the reader and the writer, such as stdin and stdout.
+func NewServer(reader io.Reader, writer io.Writer, diagnostics DiagnosticsFunc, logger logrus.FieldLogger) *Server {
+	return &Server{
+		reader:      bufio.NewReader(reader),
+		writer:      writer,
+		diagnostics: diagnostics,
+		published:   make(map[string]bool),
+		logger:      logger,
+	}
+}
+
+// Serve handles the messages until the client sends exit notification or closes the connection.
+func (s *Server) Serve(ctx context.Context) error {
+	for {
+		msg, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.logger.Debugf("receive %s", msg.Method)
+		switch msg.Method {
+		case "initialize":
+			err = s.reply(msg, &initializeResult{
+				Capabilities: serverCapabilities{
+					TextDocumentSync: textDocumentSyncOptions{OpenClose: true, Save: true},
+				},
+				ServerInfo: serverInfo{Name: "gocover"},
+			})
+		case "initialized", "textDocument/didOpen", "textDocument/didSave":
+			err = s.publish(ctx)
+		case "shutdown":
+			err = s.reply(msg, nil)
+		case "exit":
+			return nil
+		default:
+			// requests must be responded, the notifications that are not supported are ignored
+			if msg.ID != nil {
+				err = s.write(&message{
+					JSONRPC: jsonrpcVersion,
+					ID:      msg.ID,
+					Error:   &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)},
+				})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// publish publishes the diagnostics of all the documents, it doesn't stop the server if the diagnostics fail,
+// for example, the cover profile is being regenerated.
+func (s *Server) publish(ctx context.Context) error {
+	diagnostics, err := s.diagnostics(ctx)
+	if err != nil {
+		s.logger.Warnf("get diagnostics: %s", err)
+		return nil
+	}
+
+	var uris []string
+	for uri := range diagnostics {
+		uris = append(uris, uri)
+	}
+	for uri := range s.published {
+		if _, ok := diagnostics[uri]; !ok {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+
+	published := make(map[string]bool)
+	for _, uri := range uris {
+		d := diagnostics[uri]
+		if d == nil {
+			d = []*Diagnostic{}
+		}
+		if err := s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: uri, Diagnostics: d}); err != nil {
+			return err
+		}
+		if len(d) != 0 {
+			published[uri] = true
+		}
+	}
+	s.published = published
+	return nil
+}
+
+func (s *Server) reply(request *message, result any) error {
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return s.write(&message{JSONRPC: jsonrpcVersion, ID: request.ID, Result: result})
+}
+
+func (s *Server) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	return s.write(&message{JSONRPC: jsonrpcVersion, Method: method, Params: data})
+}
+
+// read reads a message, which has a header part with Content-Length and a content part in JSON.
+func (s *Server) read() (*message, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidContentLength, err)
+	}
+	if length < 0 || length > maxContentLength {
+		return nil, fmt.Errorf("%w: %d should be between 0 and %d", ErrInvalidContentLength, length, maxContentLength)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, content); err != nil {
+		return nil, fmt.Errorf("read content: %w", err)
+	}
+
+	msg := &message{}
+	if err := json.Unmarshal(content, msg); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	return msg, nil
+}
+
+func (s *Server) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func encode(messages ...string) io.Reader {
+	var buf bytes.Buffer
+	for _, m := range messages {
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	return &buf
+}
+
+func decode(t *testing.T, r io.Reader) []*message {
+	var result []*message
+	s := NewServer(r, io.Discard, nil, logrus.New())
+	for {
+		msg, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return result
+		}
+		assert.NoError(t, err)
+		result = append(result, msg)
+	}
+}
+
+func TestServe(t *testing.T) {
+	t.Run("publish diagnostics", func(t *testing.T) {
+		const uri = "file:///foo/foo.go"
+		calls := 0
+		diagnostics := func(ctx context.Context) (map[string][]*Diagnostic, error) {
+			calls++
+			if calls > 1 {
+				// the gaps are fixed
+				return map[string][]*Diagnostic{}, nil
+			}
+			return map[string][]*Diagnostic{uri: {{
+				Range:    Range{Start: Position{Line: 2}, End: Position{Line: 3}},
+				Severity: SeverityWarning,
+				Source:   "gocover",
+				Message:  "line is not covered by tests",
+			}}}, nil
+		}
+
+		var out bytes.Buffer
+		s := NewServer(encode(
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+			`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+			`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/didSave","params":{"textDocument":{"uri":"file:///foo/foo.go"}}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+			`{"jsonrpc":"2.0","method":"exit"}`,
+		), &out, diagnostics, logrus.New())
+		assert.NoError(t, s.Serve(context.Background()))
+
+		assert.Contains(t, out.String(), `"textDocumentSync":{"openClose":true,"change":0,"save":true}`)
+
+		messages := decode(t, &out)
+		assert.Len(t, messages, 5)
+
+		assert.Equal(t, "1", string(*messages[0].ID))
+
+		assert.Equal(t, "textDocument/publishDiagnostics", messages[1].Method)
+		var params publishDiagnosticsParams
+		assert.NoError(t, json.Unmarshal(messages[1].Params, &params))
+		assert.Equal(t, uri, params.URI)
+		assert.Len(t, params.Diagnostics, 1)
+		assert.Equal(t, 2, params.Diagnostics[0].Range.Start.Line)
+
+		assert.Equal(t, "2", string(*messages[2].ID))
+		assert.NotNil(t, messages[2].Error)
+
+		// the diagnostics of fixed document are cleared
+		assert.NoError(t, json.Unmarshal(messages[3].Params, &params))
+		assert.Equal(t, uri, params.URI)
+		assert.Empty(t, params.Diagnostics)
+
+		assert.Equal(t, "3", string(*messages[4].ID))
+	})
+
+	t.Run("connection closed", func(t *testing.T) {
+		s := NewServer(strings.NewReader(""), io.Discard, nil, logrus.New())
+		assert.NoError(t, s.Serve(context.Background()))
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		s := NewServer(bufio.NewReader(strings.NewReader("Content-Length: abc\r\n\r\n")), io.Discard, nil, logrus.New())
+		assert.ErrorIs(t, s.Serve(context.Background()), ErrInvalidContentLength)
+	})
+
+	t.Run("content length out of range", func(t *testing.T) {
+		for _, length := range []string{"-1", "17179869184"} {
+			s := NewServer(bufio.NewReader(strings.NewReader("Content-Length: "+length+"\r\n\r\n")), io.Discard, nil, logrus.New())
+			assert.ErrorIs(t, s.Serve(context.Background()), ErrInvalidContentLength, length)
+		}
+	})
+
+	t.Run("diagnostics failure", func(t *testing.T) {
+		var out bytes.Buffer
+		s := NewServer(encode(`{"jsonrpc":"2.0","method":"initialized"}`), &out, func(ctx context.Context) (map[string][]*Diagnostic, error) {
+			return nil, errors.New("cover profile not found")
+		}, logrus.New())
+		assert.NoError(t, s.Serve(context.Background()))
+		assert.Empty(t, out.String())
+	})
+}
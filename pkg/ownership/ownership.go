@@ -0,0 +1,101 @@
+// Package ownership loads the ownership file, which maps the teams to the paths they own and their coverage budgets,
+// so that the coverage is reported by team and the gate fails only the team whose coverage is below its budget.
+//
+// The ownership file is in yaml:
+//
+//	teams:
+//	  - name: api
+//	    paths: ["pkg/api/**", "cmd/server/**"]
+//	    budget: 80
+//	  - name: storage
+//	    paths: ["pkg/store/**"]
+//
+// The paths are the glob patterns of the files relative to the module, "**" matches any directories.
+// A file is owned by the first team that has a matching path, the files without an owner are not reported by team.
+package ownership
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidOwnership = errors.New("invalid ownership file")
+
+// Ownership is the content of the ownership file.
+type Ownership struct {
+	Teams []*Team `yaml:"teams"`
+}
+
+// Team is a team and the paths it owns.
+type Team struct {
+	Name string `yaml:"name"`
+	// Paths are the glob patterns of the files relative to the module.
+	Paths []string `yaml:"paths"`
+	// Budget is the least coverage percent of the files of the team, it's not checked if it's zero.
+	Budget float64 `yaml:"budget"`
+}
+
+// Load reads and validates the ownership file.
+func Load(path string) (*Ownership, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read ownership file: %w", err)
+	}
+
+	o := &Ownership{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(o); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse ownership file %s: %w", path, err)
+	}
+	if err := o.validate(); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrInvalidOwnership, path, err)
+	}
+	return o, nil
+}
+
+func (o *Ownership) validate() error {
+	names := make(map[string]bool)
+	for i, team := range o.Teams {
+		if team.Name == "" {
+			return fmt.Errorf("team %d has no name", i+1)
+		}
+		if names[team.Name] {
+			return fmt.Errorf("team %s is defined more than once", team.Name)
+		}
+		names[team.Name] = true
+
+		if len(team.Paths) == 0 {
+			return fmt.Errorf("team %s has no paths", team.Name)
+		}
+		for _, p := range team.Paths {
+			if !doublestar.ValidatePattern(p) {
+				return fmt.Errorf("team %s has invalid path pattern %q", team.Name, p)
+			}
+		}
+		if team.Budget < 0 || team.Budget > 100 {
+			return fmt.Errorf("team %s has budget %v out of range [0, 100]", team.Name, team.Budget)
+		}
+	}
+	return nil
+}
+
+// Owner returns the first team that has a path matching the file, which is relative to the module.
+// It returns nil if no team owns the file.
+func (o *Ownership) Owner(file string) *Team {
+	for _, team := range o.Teams {
+		for _, p := range team.Paths {
+			// the patterns are validated when they are loaded.
+			if match, _ := doublestar.Match(p, file); match {
+				return team
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package ownership
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("teams", func(t *testing.T) {
+		o, err := Load(write("owners.yaml", `teams:
+  - name: api
+    paths: ["pkg/api/**", "cmd/server/**"]
+    budget: 80
+  - name: storage
+    paths: ["pkg/store/**"]
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, &Ownership{Teams: []*Team{
+			{Name: "api", Paths: []string{"pkg/api/**", "cmd/server/**"}, Budget: 80},
+			{Name: "storage", Paths: []string{"pkg/store/**"}},
+		}}, o)
+	})
+
+	t.Run("file not exist", func(t *testing.T) {
+		_, err := Load(filepath.Join(dir, "missing.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := Load(write("unknown.yaml", "teams:\n  - name: api\n    owners: [alice]\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"no name":        "teams:\n  - paths: [pkg/**]\n",
+			"duplicate name": "teams:\n  - name: api\n    paths: [pkg/a/**]\n  - name: api\n    paths: [pkg/b/**]\n",
+			"no paths":       "teams:\n  - name: api\n",
+			"bad pattern":    "teams:\n  - name: api\n    paths: ['pkg/[a']\n",
+			"bad budget":     "teams:\n  - name: api\n    paths: [pkg/**]\n    budget: 120\n",
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Load(write("invalid.yaml", content))
+				assert.True(t, errors.Is(err, ErrInvalidOwnership), err)
+			})
+		}
+	})
+}
+
+func TestOwner(t *testing.T) {
+	o := &Ownership{Teams: []*Team{
+		{Name: "api", Paths: []string{"pkg/api/**", "cmd/server/*.go"}},
+		{Name: "core", Paths: []string{"pkg/**"}},
+	}}
+
+	assert.Equal(t, "api", o.Owner("pkg/api/v1/handler.go").Name)
+	assert.Equal(t, "api", o.Owner("cmd/server/main.go").Name)
+	// the first matching team owns the file.
+	assert.Equal(t, "core", o.Owner("pkg/store/store.go").Name)
+	assert.Nil(t, o.Owner("cmd/cli/main.go"))
+	assert.Nil(t, o.Owner("main.go"))
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// maxConstraintTags limits the tags of a build constraint that are enumerated to check whether
+// the file requires the excluded tags, the constraints with more tags are not excluded.
+const maxConstraintTags = 16
+
+// WithExcludeBuildTags excludes the files that are built only when any of the tags is set,
+// such as the test harness guarded by `//go:build integration`. The excluded files are
+// reported in SkippedFiles of the packages with the tags as the reason.
+func (parser *Parser) WithExcludeBuildTags(tags []string) *Parser {
+	if len(tags) == 0 {
+		parser.excludeBuildTags = nil
+		return parser
+	}
+	parser.excludeBuildTags = make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		parser.excludeBuildTags[tag] = true
+	}
+	return parser
+}
+
+// excludedBuildTags returns the excluded tags that the file requires, it returns nil if the file
+// is built without them, or it has no build constraint.
+func (parser *Parser) excludedBuildTags(file string) []string {
+	if len(parser.excludeBuildTags) == 0 {
+		return nil
+	}
+	expr := buildConstraint(file)
+	if expr == nil {
+		return nil
+	}
+
+	var excluded, others []string
+	for _, tag := range constraintTags(expr) {
+		if parser.excludeBuildTags[tag] {
+			excluded = append(excluded, tag)
+		} else {
+			others = append(others, tag)
+		}
+	}
+	if len(excluded) == 0 || len(others) > maxConstraintTags {
+		return nil
+	}
+
+	// the file requires the excluded tags if no combination of the other tags satisfies the constraint.
+	for i := 0; i < 1<<len(others); i++ {
+		set := make(map[string]bool, len(others))
+		for j, tag := range others {
+			set[tag] = i&(1<<j) != 0
+		}
+		if expr.Eval(func(tag string) bool { return set[tag] }) {
+			return nil
+		}
+	}
+	return excluded
+}
+
+// buildConstraint returns the //go:build constraint of the file, it returns nil
+// if the file has no such constraint or it cannot be parsed.
+func buildConstraint(file string) constraint.Expr {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				return nil
+			}
+			return expr
+		}
+	}
+	return nil
+}
+
+// constraintTags returns the sorted tags in the build constraint.
+func constraintTags(expr constraint.Expr) []string {
+	seen := make(map[string]bool)
+	var walk func(constraint.Expr)
+	walk = func(e constraint.Expr) {
+		switch e := e.(type) {
+		case *constraint.TagExpr:
+			seen[e.Tag] = true
+		case *constraint.NotExpr:
+			walk(e.X)
+		case *constraint.AndExpr:
+			walk(e.X)
+			walk(e.Y)
+		case *constraint.OrExpr:
+			walk(e.X)
+			walk(e.Y)
+		}
+	}
+	walk(expr)
+
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// buildTagReason is the reason of the skipped file that is excluded by the build tags.
+func buildTagReason(tags []string) string {
+	return "excluded by build tag " + strings.Join(tags, ", ")
+}
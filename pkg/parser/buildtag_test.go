@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludedBuildTags(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, header string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(header+"package foo\n"), 0644))
+		return path
+	}
+
+	parser := NewParser(nil, logrus.New()).WithExcludeBuildTags([]string{"integration", "tools"})
+
+	testSuites := []struct {
+		name   string
+		header string
+		expect []string
+	}{
+		{name: "no constraint", header: "", expect: nil},
+		{name: "excluded tag", header: "//go:build integration\n\n", expect: []string{"integration"}},
+		{name: "excluded tag with others", header: "// Copyright\n\n//go:build linux && (integration || tools)\n\n", expect: []string{"integration", "tools"}},
+		{name: "negated tag", header: "//go:build !integration\n\n", expect: nil},
+		{name: "optional tag", header: "//go:build integration || linux\n\n", expect: nil},
+		{name: "other tags", header: "//go:build !windows\n\n", expect: nil},
+		{name: "not a constraint", header: "// go:build integration\n\n", expect: nil},
+	}
+	for i, testCase := range testSuites {
+		t.Run(testCase.name, func(t *testing.T) {
+			file := write(string(rune('a'+i))+".go", testCase.header)
+			assert.Equal(t, testCase.expect, parser.excludedBuildTags(file))
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		file := write("disabled.go", "//go:build integration\n\n")
+		assert.Nil(t, NewParser(nil, logrus.New()).excludedBuildTags(file))
+	})
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/gocover/pkg/annotation"
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/tools/cover"
+)
+
+// cacheVersion is part of the cache key, bump it when the conversion or the cached data changes,
+// so that the stale cache entries are not used.
+const cacheVersion = "v8"
+
+// fileResult is the conversion result of a file.
+type fileResult struct {
+	Functions     []*Function
+	IgnoreProfile *annotation.IgnoreProfile
+	// SkippedFile is set when the file cannot be used for coverage calculation.
+	SkippedFile *SkippedFile
+}
+
+// fileCache caches the conversion results of the files in a directory, the key is the hash of the source file,
+// the profile blocks and the change of the file, so a file is converted again only when any of them changes.
+type fileCache struct {
+	dir    string
+	hits   int
+	misses int
+
+	logger logrus.FieldLogger
+}
+
+// newFileCache returns a cache in the directory.
+// It returns nil if dir is empty, which disables the cache.
+func newFileCache(dir string, logger logrus.FieldLogger) *fileCache {
+	if dir == "" {
+		return nil
+	}
+	return &fileCache{dir: dir, logger: logger}
+}
+
+// run returns the cache of the same directory for a single conversion, which counts its own hits and misses.
+func (c *fileCache) run() *fileCache {
+	if c == nil {
+		return nil
+	}
+	return &fileCache{dir: c.dir, logger: c.logger}
+}
+
+// packages returns the package cache in the same directory for a single conversion.
+func (c *fileCache) packages() *packageCache {
+	if c == nil {
+		return nil
+	}
+	return newPackageCache(c.dir, c.logger)
+}
+
+// key returns the cache key of the file, whose content is read from source.
+// compatibleMarkers is part of the key as it changes the ignore annotations of the file.
+func (c *fileCache) key(file, source string, p *cover.Profile, change *gittool.Change, compatibleMarkers bool) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%x\n%s\n%t\n", cacheVersion, file, sha256.Sum256(content), p.Mode, compatibleMarkers)
+	for _, b := range p.Blocks {
+		fmt.Fprintf(h, "%d.%d,%d.%d %d %d\n", b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count)
+	}
+	if change != nil {
+		for _, s := range change.Sections {
+			fmt.Fprintf(h, "%d,%d\n", s.StartLine, s.EndLine)
+			for _, line := range s.Contents {
+				fmt.Fprintf(h, "%s\n", line)
+			}
+		}
+		for _, s := range change.Moved {
+			fmt.Fprintf(h, "moved %d,%d\n", s.StartLine, s.EndLine)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// get returns the cached result of the key, a broken cache entry is regarded as a miss.
+func (c *fileCache) get(key string) (*fileResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err == nil {
+		result := &fileResult{}
+		if err = gob.NewDecoder(bytes.NewReader(data)).Decode(result); err == nil {
+			c.hits++
+			return result, true
+		}
+		c.logger.WithError(err).Debugf("broken cache entry %s", key)
+	}
+	c.misses++
+	return nil, false
+}
+
+// put stores the result of the key, the cache entry is written to a temporary file
+// then renamed, so that the runs in parallel don't read a partial entry.
+func (c *fileCache) put(key string, result *fileResult) {
+	if c == nil {
+		return
+	}
+
+	if err := c.write(key, result); err != nil {
+		c.logger.WithError(err).Warnf("write cache entry %s", key)
+	}
+}
+
+func (c *fileCache) write(key string, result *fileResult) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(result); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+func (c *fileCache) path(key string) string {
+	return filepath.Join(c.dir, key+".gob")
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "foo.go")
+	assert.NoError(t, os.WriteFile(file, []byte(`package foo
+
+func foo() int {
+	return 1
+}
+`), 0644))
+	profile := &cover.Profile{
+		FileName: "github.com/Azure/gocover/foo/foo.go",
+		Mode:     "set",
+		Blocks:   []cover.ProfileBlock{{StartLine: 3, StartCol: 16, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newFileCache("", logrus.New())
+		key, err := c.key(file, file, profile, nil, false)
+		assert.NoError(t, err)
+		assert.Empty(t, key)
+		c.put(key, &fileResult{})
+		_, ok := c.get(key)
+		assert.False(t, ok)
+	})
+
+	t.Run("key", func(t *testing.T) {
+		c := newFileCache(filepath.Join(dir, "cache"), logrus.New())
+		key, err := c.key(file, file, profile, nil, false)
+		assert.NoError(t, err)
+
+		again, err := c.key(file, file, profile, nil, false)
+		assert.NoError(t, err)
+		assert.Equal(t, key, again)
+
+		covered := &cover.Profile{FileName: profile.FileName, Mode: profile.Mode, Blocks: []cover.ProfileBlock{profile.Blocks[0]}}
+		covered.Blocks[0].Count = 0
+		other, err := c.key(file, file, covered, nil, false)
+		assert.NoError(t, err)
+		assert.NotEqual(t, key, other)
+
+		other, err = c.key(file, file, profile, &gittool.Change{Sections: []*gittool.Section{{StartLine: 4, EndLine: 4, Contents: []string{"	return 1"}}}}, false)
+		assert.NoError(t, err)
+		assert.NotEqual(t, key, other)
+
+		other, err = c.key(file, file, profile, nil, true)
+		assert.NoError(t, err)
+		assert.NotEqual(t, key, other)
+
+		_, err = c.key(filepath.Join(dir, "nonexist.go"), filepath.Join(dir, "nonexist.go"), profile, nil, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("get and put", func(t *testing.T) {
+		c := newFileCache(filepath.Join(dir, "cache"), logrus.New())
+		parser := NewParser(nil, logrus.New())
+
+		result, err := parser.convertFile(file, file, profile, nil)
+		assert.NoError(t, err)
+
+		key, err := c.key(file, file, profile, nil, false)
+		assert.NoError(t, err)
+		_, ok := c.get(key)
+		assert.False(t, ok)
+
+		c.put(key, result)
+		cached, ok := c.get(key)
+		assert.True(t, ok)
+		assert.Equal(t, result, cached)
+		assert.Equal(t, int64(1), cached.Functions[0].Statements[0].Reached)
+		assert.Equal(t, 1, c.hits)
+		assert.Equal(t, 1, c.misses)
+
+		// broken cache entry is a miss
+		assert.NoError(t, os.WriteFile(c.path(key), []byte("broken"), 0644))
+		_, ok = c.get(key)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// ErrMixedCoverModes is the error of the cover profiles whose modes cannot be combined, such as set with count,
+// when the mixed modes are rejected.
+var ErrMixedCoverModes = errors.New("cover profiles of mixed modes")
+
+// ErrUnknownMixedModes is the error of the policy of the mixed modes that is neither normalize nor reject.
+var ErrUnknownMixedModes = errors.New("unknown policy of mixed cover modes")
+
+// MixedModes decides how the cover profiles of the set mode are combined with the profiles of the count or atomic modes.
+// The counts of the count and atomic modes are the same kind of numbers, so these modes are always combined as count.
+type MixedModes string
+
+const (
+	// NormalizeMixedModes reduces the counts of all the profiles to whether the blocks are reached, which is the set mode,
+	// when some profiles are of the set mode, it's the default policy.
+	NormalizeMixedModes MixedModes = "normalize"
+	// RejectMixedModes fails the parse with ErrMixedCoverModes when some profiles are of the set mode and the others are not.
+	RejectMixedModes MixedModes = "reject"
+)
+
+// ParseMixedModes validates the policy of the mixed modes, it's NormalizeMixedModes if it's empty.
+func ParseMixedModes(policy string) (MixedModes, error) {
+	switch MixedModes(policy) {
+	case "", NormalizeMixedModes:
+		return NormalizeMixedModes, nil
+	case RejectMixedModes:
+		return RejectMixedModes, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownMixedModes, policy)
+	}
+}
+
+// WithMixedModes sets how the cover profiles of different modes are combined, refer to MixedModes.
+func (parser *Parser) WithMixedModes(policy MixedModes) *Parser {
+	parser.mixedModes = policy
+	return parser
+}
+
+// combineModes makes the modes of the profiles the same before they're merged, files are the cover profile files
+// that have the profiles of each mode. Otherwise, the counts of set and count would be merged as the same numbers,
+// such as a block run 5 times in one profile and once in another is counted as reached 5 times.
+func (parser *Parser) combineModes(profiles []*cover.Profile, files map[string][]string) error {
+	if len(files) < 2 {
+		return nil
+	}
+	if len(files["set"]) == 0 {
+		setMode(profiles, "count", false)
+		parser.logger.Infof("combine the cover profiles of count and atomic modes as count")
+		return nil
+	}
+
+	modes := make([]string, 0, len(files))
+	for mode := range files {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	described := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		described = append(described, fmt.Sprintf("%s in %s", mode, strings.Join(files[mode], ", ")))
+	}
+	if parser.mixedModes == RejectMixedModes {
+		return fmt.Errorf("%w: %s", ErrMixedCoverModes, strings.Join(described, "; "))
+	}
+	setMode(profiles, "set", true)
+	parser.logger.Warnf("normalize the cover profiles of mixed modes to set, the hit counts are not kept: %s", strings.Join(described, "; "))
+	return nil
+}
+
+// setMode sets the mode of the profiles, the counts are reduced to 0 or 1 if reached is set.
+func setMode(profiles []*cover.Profile, mode string, reached bool) {
+	for _, p := range profiles {
+		p.Mode = mode
+		if !reached {
+			continue
+		}
+		for i := range p.Blocks {
+			if p.Blocks[i].Count > 0 {
+				p.Blocks[i].Count = 1
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestMixedModes(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		return file
+	}
+	set := writeProfile("set.out", "mode: set\nexample.com/m/foo.go:3.16,5.2 1 1\nexample.com/m/foo.go:7.16,9.2 1 0\n")
+	count := writeProfile("count.out", "mode: count\nexample.com/m/foo.go:3.16,5.2 1 5\nexample.com/m/foo.go:7.16,9.2 1 0\n")
+	atomic := writeProfile("atomic.out", "mode: atomic\nexample.com/m/foo.go:3.16,5.2 1 2\nexample.com/m/foo.go:7.16,9.2 1 3\n")
+
+	read := func(policy MixedModes, files ...string) ([]*cover.Profile, error) {
+		return NewParser(files, logrus.New()).WithMixedModes(policy).readCoverProfiles()
+	}
+
+	t.Run("same mode", func(t *testing.T) {
+		profiles, err := read(RejectMixedModes, count, count)
+		assert.NoError(t, err)
+		assert.Equal(t, "count", profiles[0].Mode)
+		assert.Equal(t, 5, profiles[0].Blocks[0].Count)
+	})
+
+	t.Run("count and atomic", func(t *testing.T) {
+		profiles, err := read(RejectMixedModes, count, atomic)
+		assert.NoError(t, err)
+		assert.Equal(t, "count", profiles[0].Mode)
+		assert.Equal(t, 5, profiles[0].Blocks[0].Count)
+		assert.Equal(t, 3, profiles[0].Blocks[1].Count)
+	})
+
+	t.Run("normalize", func(t *testing.T) {
+		profiles, err := read("", set, count, atomic)
+		assert.NoError(t, err)
+		assert.Equal(t, "set", profiles[0].Mode)
+		assert.Equal(t, 1, profiles[0].Blocks[0].Count)
+		assert.Equal(t, 1, profiles[0].Blocks[1].Count)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		_, err := read(RejectMixedModes, set, count, atomic)
+		assert.ErrorIs(t, err, ErrMixedCoverModes)
+		assert.ErrorContains(t, err, "count in "+count+"; set in "+set)
+	})
+}
+
+func TestParseMixedModes(t *testing.T) {
+	policy, err := ParseMixedModes("")
+	assert.NoError(t, err)
+	assert.Equal(t, NormalizeMixedModes, policy)
+
+	policy, err = ParseMixedModes("reject")
+	assert.NoError(t, err)
+	assert.Equal(t, RejectMixedModes, policy)
+
+	_, err = ParseMixedModes("sum")
+	assert.ErrorIs(t, err, ErrUnknownMixedModes)
+}
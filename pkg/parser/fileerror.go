@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/cover"
+)
+
+// FileError is the error of converting a file of the cover profiles, such as a parse failure or an invalid annotation.
+type FileError struct {
+	// File is the file named in the cover profiles.
+	File string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.File, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// WithContinueOnError converts the rest of the files when a file fails instead of aborting on the first error,
+// the errors of the failed files are returned by FileErrors and the files are left out of the result.
+func (parser *Parser) WithContinueOnError(continueOnError bool) *Parser {
+	parser.continueOnError = continueOnError
+	return parser
+}
+
+// FileErrors returns the errors of the files that fail to convert when the parser continues on error,
+// in the order of the cover profiles.
+func (parser *Parser) FileErrors() []*FileError {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+	return parser.fileErrors
+}
+
+// tolerate returns nil if the file of the profile is skipped as unresolved, or its error is collected
+// when the parser continues on error, otherwise it returns the error.
+func (parser *Parser) tolerate(p *cover.Profile, err error) error {
+	if err := parser.skip(p, err); err == nil || !parser.continueOnError {
+		return err
+	}
+	parser.logger.WithError(err).Warnf("continue on the error of file %s", p.FileName)
+	parser.fileErrors = append(parser.fileErrors, &FileError{File: p.FileName, Err: err})
+	return nil
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithContinueOnError(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "pkg", "foo"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "foo", "foo.go"), []byte(`package foo
+
+func Foo() int {
+	return 1
+}
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "foo", "broken.go"), []byte("package foo\n\nfunc Broken() {\n"), 0644))
+
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/bar/pkg/foo/broken.go:3.16,5.2 1 1\n"+
+		"example.com/bar/pkg/foo/deleted.go:3.16,5.2 1 1\n"+
+		"example.com/bar/pkg/foo/foo.go:3.16,5.2 1 1\n"), 0644))
+
+	newParser := func(continueOnError bool) *Parser {
+		return NewParser([]string{profile}, logrus.New()).
+			WithModuleRoot(root, "example.com/bar").
+			WithSkipUnresolved(true).
+			WithContinueOnError(continueOnError)
+	}
+
+	t.Run("abort on the first error", func(t *testing.T) {
+		_, err := newParser(false).Parse(context.Background(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		parser := newParser(true)
+		packages, err := parser.Parse(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, packages, 1)
+		assert.Len(t, packages[0].Functions, 1)
+		assert.Equal(t, "Foo", packages[0].Functions[0].Name)
+
+		// the unresolved file is skipped rather than failed.
+		assert.Len(t, parser.UnresolvedFiles(), 1)
+		assert.Len(t, parser.FileErrors(), 1)
+		fileErr := parser.FileErrors()[0]
+		assert.Equal(t, "example.com/bar/pkg/foo/broken.go", fileErr.File)
+		assert.Contains(t, fileErr.Error(), "example.com/bar/pkg/foo/broken.go: ")
+		assert.False(t, errors.Is(fileErr, ErrUnresolvedFile))
+	})
+
+	t.Run("continue on error in stream", func(t *testing.T) {
+		stream, err := newParser(true).Stream()
+		assert.NoError(t, err)
+		assert.NoError(t, stream.Add(context.Background(), &gittool.Change{FileName: "pkg/foo/broken.go"}))
+		assert.NoError(t, stream.Add(context.Background(), &gittool.Change{FileName: "pkg/foo/foo.go"}))
+		packages, err := stream.Packages()
+		assert.NoError(t, err)
+		assert.Len(t, packages, 1)
+		assert.Len(t, stream.FileErrors(), 1)
+	})
+}
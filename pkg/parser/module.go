@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// WithModuleRoot resolves the files of the cover profiles by the module path declared in go.mod instead of go/build,
+// a file named {modulePath}/{dir}/{file} is read from {moduleRoot}/{dir}/{file}. So the packages don't need to be
+// importable, such as in the CI images without the go toolchain or the module cache. Empty moduleRoot imports the packages.
+//
+// The packages of the other modules are resolved through the module graph of go.mod under moduleRoot like the go command,
+// that's the vendor directory if the build uses it, or the local directory of a replace directive.
+func (parser *Parser) WithModuleRoot(moduleRoot string, modulePath string) *Parser {
+	parser.moduleRoot = moduleRoot
+	parser.modulePath = modulePath
+	parser.modules = nil
+	if moduleRoot != "" {
+		modules, err := loadModuleGraph(moduleRoot)
+		if err != nil {
+			parser.logger.WithError(err).Warn("resolve the packages of the main module only")
+		}
+		parser.modules = modules
+	}
+	return parser
+}
+
+// moduleGraph is the part of the module graph that the packages out of the main module are resolved by,
+// which is read from go.mod and vendor/modules.txt without the go command.
+type moduleGraph struct {
+	// replaces are the modules replaced by the local directories, the longest path first.
+	replaces []*moduleReplace
+	// vendorDir is the vendor directory if the build uses it, it's empty otherwise.
+	vendorDir string
+	// vendored are the import paths of the packages in vendorDir.
+	vendored map[string]bool
+}
+
+// moduleReplace is a replace directive whose new path is a local directory.
+type moduleReplace struct {
+	path string
+	dir  string
+}
+
+// loadModuleGraph reads go.mod in moduleRoot and the vendored packages if the build uses the vendor directory.
+func loadModuleGraph(moduleRoot string) (*moduleGraph, error) {
+	goMod := filepath.Join(moduleRoot, "go.mod")
+	data, err := os.ReadFile(goMod)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.Parse(goMod, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := &moduleGraph{}
+	for _, r := range f.Replace {
+		// the replacement by a module version is in the module cache, which isn't resolved without importing.
+		if !modfile.IsDirectoryPath(r.New.Path) {
+			continue
+		}
+		dir := filepath.FromSlash(r.New.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(moduleRoot, dir)
+		}
+		modules.replaces = append(modules.replaces, &moduleReplace{path: r.Old.Path, dir: dir})
+	}
+	sort.SliceStable(modules.replaces, func(i, j int) bool {
+		return len(modules.replaces[i].path) > len(modules.replaces[j].path)
+	})
+
+	goVersion := ""
+	if f.Go != nil {
+		goVersion = f.Go.Version
+	}
+	vendorDir := filepath.Join(moduleRoot, "vendor")
+	if !useVendor(goVersion, os.Getenv("GOFLAGS"), vendorDir) {
+		return modules, nil
+	}
+	vendored, err := vendoredPackages(filepath.Join(vendorDir, "modules.txt"))
+	if err != nil {
+		return nil, err
+	}
+	modules.vendorDir, modules.vendored = vendorDir, vendored
+	return modules, nil
+}
+
+// useVendor tells whether the go command builds with the vendor directory: -mod=vendor in GOFLAGS uses it,
+// and the other -mod flags don't. Otherwise it's used if it exists and the go version of go.mod is 1.14 or later,
+// go.mod without the go version is taken as go 1.16.
+func useVendor(goVersion string, goFlags string, vendorDir string) bool {
+	for _, flag := range strings.Fields(goFlags) {
+		if mod, ok := strings.CutPrefix(strings.TrimLeft(flag, "-"), "mod="); ok {
+			return mod == "vendor"
+		}
+	}
+	if _, err := os.Stat(filepath.Join(vendorDir, "modules.txt")); err != nil {
+		return false
+	}
+	if goVersion == "" {
+		goVersion = "1.16"
+	}
+	return semver.Compare("v"+goVersion, "v1.14") >= 0
+}
+
+// vendoredPackages reads the import paths of the packages from vendor/modules.txt,
+// which are listed after the line of their module, that starts with #.
+func vendoredPackages(modulesTxt string) (map[string]bool, error) {
+	data, err := os.ReadFile(modulesTxt)
+	if err != nil {
+		return nil, err
+	}
+	packages := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		packages[line] = true
+	}
+	return packages, scanner.Err()
+}
+
+// resolve returns the directory of the package out of the main module and the root of its module,
+// the vendored package is preferred as the build does.
+func (m *moduleGraph) resolve(importPath string) (string, string, bool) {
+	if m == nil {
+		return "", "", false
+	}
+	if m.vendored[importPath] {
+		return filepath.Join(m.vendorDir, filepath.FromSlash(importPath)), filepath.Dir(m.vendorDir), true
+	}
+	for _, r := range m.replaces {
+		if rel, ok := inModule(importPath, r.path); ok {
+			return filepath.Join(r.dir, filepath.FromSlash(rel)), r.dir, true
+		}
+	}
+	return "", "", false
+}
+
+// inModule returns the path of the package relative to the module if it's in the module.
+func inModule(importPath string, modulePath string) (string, bool) {
+	if importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(importPath, modulePath), true
+}
+
+// importPackage finds the directory of the package, it's resolved under the module root if it's set,
+// otherwise the imported package is kept in the package cache.
+func (parser *Parser) importPackage(importPath string) (*build.Package, error) {
+	if parser.moduleRoot == "" {
+		if pkg, ok := parser.packageCache.get(importPath); ok {
+			return pkg, nil
+		}
+		pkg, err := build.Import(importPath, ".", build.FindOnly)
+		if err != nil {
+			return nil, err
+		}
+		parser.packageCache.put(importPath, pkg)
+		return pkg, nil
+	}
+
+	rel, ok := inModule(importPath, parser.modulePath)
+	if !ok {
+		dir, root, ok := parser.modules.resolve(importPath)
+		if !ok {
+			return nil, fmt.Errorf("package %s is not in module %s under %s", importPath, parser.modulePath, parser.moduleRoot)
+		}
+		return &build.Package{ImportPath: importPath, Dir: dir, Root: root}, nil
+	}
+	return &build.Package{
+		ImportPath: importPath,
+		Dir:        filepath.Join(parser.moduleRoot, filepath.FromSlash(rel)),
+		Root:       parser.moduleRoot,
+	}, nil
+}
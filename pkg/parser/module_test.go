@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithModuleRoot(t *testing.T) {
+	// the package directories are resolved like the repository path.
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "pkg", "foo"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "foo", "foo.go"), []byte(`package foo
+
+func Foo() int {
+	return 1
+}
+`), 0644))
+
+	t.Run("resolve the files by the module path", func(t *testing.T) {
+		profile := filepath.Join(t.TempDir(), "cover.out")
+		assert.NoError(t, os.WriteFile(profile, []byte("mode: set\nexample.com/bar/pkg/foo/foo.go:3.16,5.2 1 1\n"), 0644))
+
+		packages, err := NewParser([]string{profile}, logrus.New()).WithModuleRoot(root, "example.com/bar").Parse(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, packages, 1)
+		assert.Equal(t, "example.com/bar/pkg/foo", packages[0].Name)
+		assert.Len(t, packages[0].Functions, 1)
+		assert.Equal(t, filepath.Join(root, "pkg", "foo", "foo.go"), packages[0].Functions[0].File)
+	})
+
+	t.Run("package out of the module", func(t *testing.T) {
+		parser := NewParser(nil, logrus.New()).WithModuleRoot(root, "example.com/bar")
+		_, err := parser.importPackage("example.com/barbaz/pkg")
+		assert.Error(t, err)
+
+		pkg, err := parser.importPackage("example.com/bar")
+		assert.NoError(t, err)
+		assert.Equal(t, root, pkg.Dir)
+	})
+
+	t.Run("replace directives and vendored modules", func(t *testing.T) {
+		module := gittool.RealPath(t.TempDir())
+		for name, content := range map[string]string{
+			"app/go.mod":                       "module example.com/app\n\ngo 1.22\n\nrequire (\n\texample.com/lib v1.0.0\n\texample.com/ext v1.0.0\n)\n\nreplace example.com/lib => ../lib\n\nreplace example.com/ext => example.com/fork v1.0.0\n",
+			"lib/sub/sub.go":                   "package sub\n",
+			"app/vendor/modules.txt":           "# example.com/ext v1.0.0 => example.com/fork v1.0.0\n## explicit\nexample.com/ext/pkg\n",
+			"app/vendor/example.com/ext/pkg/a": "",
+		} {
+			file := filepath.Join(module, filepath.FromSlash(name))
+			assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+			assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+		}
+		app := filepath.Join(module, "app")
+
+		t.Setenv("GOFLAGS", "")
+		parser := NewParser(nil, logrus.New()).WithModuleRoot(app, "example.com/app")
+		pkg, err := parser.importPackage("example.com/lib/sub")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(module, "lib", "sub"), pkg.Dir)
+		assert.Equal(t, filepath.Join(module, "lib"), pkg.Root)
+
+		pkg, err = parser.importPackage("example.com/ext/pkg")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(app, "vendor", "example.com", "ext", "pkg"), pkg.Dir)
+
+		_, err = parser.importPackage("example.com/ext/other")
+		assert.Error(t, err)
+
+		t.Setenv("GOFLAGS", "-mod=mod")
+		parser = NewParser(nil, logrus.New()).WithModuleRoot(app, "example.com/app")
+		_, err = parser.importPackage("example.com/ext/pkg")
+		assert.Error(t, err)
+	})
+}
+
+func TestUseVendor(t *testing.T) {
+	dir := t.TempDir()
+	assert.False(t, useVendor("1.22", "", dir))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "modules.txt"), nil, 0644))
+	assert.True(t, useVendor("1.22", "", dir))
+	assert.True(t, useVendor("", "", dir))
+	assert.False(t, useVendor("1.13", "", dir))
+	assert.True(t, useVendor("1.13", "-mod=vendor", dir))
+	assert.False(t, useVendor("1.22", "-race -mod=readonly", dir))
+}
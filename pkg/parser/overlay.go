@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/gocover/pkg/gittool"
+)
+
+// Overlay maps the files of the packages to the files that replace them in the build,
+// it's loaded from the same json file as the -overlay flag of go build:
+//
+//	{"Replace": {"/src/foo/gen.go": "/tmp/build/gen.go"}}
+//
+// The generated or rewritten files that are instrumented in the build are read from their replacements,
+// so that the extents of the statements are those of the cover profiles. The files are still reported by their own names.
+type Overlay struct {
+	Replace map[string]string
+}
+
+// LoadOverlay loads the overlay file, the relative paths in it are relative to the current directory like go build.
+// It returns nil if the file is not set.
+func LoadOverlay(path string) (*Overlay, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read overlay file: %w", err)
+	}
+	o := &Overlay{}
+	if err := json.Unmarshal(data, o); err != nil {
+		return nil, fmt.Errorf("parse overlay file %s: %w", path, err)
+	}
+
+	replace := make(map[string]string, len(o.Replace))
+	for from, to := range o.Replace {
+		// the deleted files have empty replacements, they cannot be in the cover profiles.
+		if to == "" {
+			continue
+		}
+		from, err := overlayPath(from)
+		if err != nil {
+			return nil, err
+		}
+		if to, err = overlayPath(to); err != nil {
+			return nil, err
+		}
+		replace[from] = to
+	}
+	o.Replace = replace
+	return o, nil
+}
+
+// overlayPath returns the absolute path with the symlinks of the directory resolved, the same as the file names of the parser.
+// The file itself may not exist, such as a generated file that exists only in the overlay.
+func overlayPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("get absolute path of overlay file %s: %w", path, err)
+	}
+	dir, file := filepath.Split(abs)
+	return filepath.Join(gittool.RealPath(dir), file), nil
+}
+
+// Source returns the file that the content of the file is read from, it's the file itself if it's not replaced.
+func (o *Overlay) Source(file string) string {
+	if o == nil {
+		return file
+	}
+	if source, ok := o.Replace[file]; ok {
+		return source
+	}
+	return file
+}
+
+// WithOverlay reads the files of the cover profiles from their replacements in the overlay, nil overlay reads the files themselves.
+func (parser *Parser) WithOverlay(o *Overlay) *Parser {
+	parser.overlay = o
+	return parser
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadOverlay(t *testing.T) {
+	dir := gittool.RealPath(t.TempDir())
+
+	t.Run("not set", func(t *testing.T) {
+		o, err := LoadOverlay("")
+		assert.NoError(t, err)
+		assert.Nil(t, o)
+		assert.Equal(t, "foo.go", o.Source("foo.go"))
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		file := filepath.Join(dir, "overlay.json")
+		assert.NoError(t, os.WriteFile(file, []byte(`{"Replace": {
+			"`+filepath.ToSlash(filepath.Join(dir, "gen.go"))+`": "`+filepath.ToSlash(filepath.Join(dir, "build", "gen.go"))+`",
+			"`+filepath.ToSlash(filepath.Join(dir, "deleted.go"))+`": ""
+		}}`), 0644))
+
+		o, err := LoadOverlay(file)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{filepath.Join(dir, "gen.go"): filepath.Join(dir, "build", "gen.go")}, o.Replace)
+		assert.Equal(t, filepath.Join(dir, "build", "gen.go"), o.Source(filepath.Join(dir, "gen.go")))
+		assert.Equal(t, filepath.Join(dir, "foo.go"), o.Source(filepath.Join(dir, "foo.go")))
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		_, err := LoadOverlay(filepath.Join(dir, "missing.json"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		file := filepath.Join(dir, "invalid.json")
+		assert.NoError(t, os.WriteFile(file, []byte(`{"Replace": [`), 0644))
+		_, err = LoadOverlay(file)
+		assert.Error(t, err)
+	})
+}
+
+func TestWithOverlay(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "gen.go"), []byte("package gen\n"), 0644))
+	// the replacement is the file built, whose statements are in the cover profile.
+	replacement := filepath.Join(root, "build", "gen.go")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(replacement), 0755))
+	assert.NoError(t, os.WriteFile(replacement, []byte(`package gen
+
+func Gen() int {
+	return 1
+}
+`), 0644))
+
+	profile := filepath.Join(root, "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\nexample.com/gen/gen.go:3.16,5.2 1 1\n"), 0644))
+
+	packages, err := NewParser([]string{profile}, logrus.New()).
+		WithModuleRoot(root, "example.com/gen").
+		WithOverlay(&Overlay{Replace: map[string]string{filepath.Join(root, "gen.go"): replacement}}).
+		Parse(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+	assert.Len(t, packages[0].Functions, 1)
+	assert.Equal(t, "Gen", packages[0].Functions[0].Name)
+	// the file is reported by its own name.
+	assert.Equal(t, filepath.Join(root, "gen.go"), packages[0].Functions[0].File)
+	assert.Len(t, packages[0].Functions[0].Statements, 1)
+	assert.EqualValues(t, 1, packages[0].Functions[0].Statements[0].Reached)
+}
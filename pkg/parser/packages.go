@@ -19,6 +19,35 @@ type Package struct {
 
 	// IgnoreProfiles is a list of ignore profiles that within this package.
 	IgnoreProfiles []*annotation.IgnoreProfile
+
+	// SkippedFiles is a list of files that cannot be used for coverage calculation.
+	SkippedFiles []*SkippedFile
+
+	// ExcludedFunctions is a list of functions that are excluded from coverage calculation by name patterns.
+	ExcludedFunctions []*ExcludedFunction
+
+	// CoverMode is the mode of the cover profiles, one of set, count and atomic.
+	CoverMode string
+}
+
+// SkippedFile represents a file that is excluded from coverage calculation
+// because its cover profile cannot be mapped to the source, for example, cgo files.
+type SkippedFile struct {
+	// File is the full path to the file.
+	File string
+
+	// Reason tells why the file is skipped.
+	Reason string
+}
+
+// ExcludedFunction represents a function that is excluded from coverage calculation
+// because its name matches an exclude pattern, such as generated getters.
+type ExcludedFunction struct {
+	// Function is the excluded function with its statements.
+	Function *Function
+
+	// Pattern is the pattern that the function, or its enclosing function, matches.
+	Pattern string
 }
 
 type Function struct {
@@ -41,6 +70,14 @@ type Function struct {
 	// EndLine is the end line number of the function.
 	EndLine int
 
+	// Literal indicates the function is a function literal, which is named
+	// after its enclosing function, e.g. "Handler.func1".
+	Literal bool
+
+	// Enclosing is the name of the outermost declared function that the function literal is in,
+	// it is empty for the declared functions and the function literals at package level.
+	Enclosing string
+
 	// statements registered with this function.
 	Statements []*Statement
 }
@@ -61,6 +98,10 @@ type Statement struct {
 	// Reached is the number of times the statement was reached.
 	Reached int64
 
+	// Partial indicates the statement spans several blocks that are not all reached or all unreached,
+	// outside the branches and the function literals nested in it, it's resolved by its first block.
+	Partial bool
+
 	// State indicates whether current statement is changed or not.
 	State State
 
@@ -71,6 +112,7 @@ type Statement struct {
 // State represents statement's state.
 // "Original" means it hasn't changed compared with compare branch (master or main)
 // "Changed" means it has been changed compared with compare branch (master or main)
+// "Moved" means it has been moved from the code deleted elsewhere in the diff, which doesn't count as changed
 type State string
 
 // Mode represents statement's mode.
@@ -81,6 +123,7 @@ type Mode string
 const (
 	Original State = "Original"
 	Changed  State = "Changed"
+	Moved    State = "Moved"
 
 	Keep   Mode = "Keep"
 	Ignore Mode = "Ignore"
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"golang.org/x/tools/cover"
+)
+
+// WithWorkers sets the number of the goroutines that parse the source files of the cover profiles,
+// it's runtime.GOMAXPROCS(0) if n is not positive. The results are the same whatever the number is.
+func (parser *Parser) WithWorkers(n int) *Parser {
+	parser.workers = n
+	return parser
+}
+
+// conversion is the conversion of the file of a cover profile. It's prepared sequentially, then the source file
+// is parsed by a worker unless the result is known already, and done is closed once result or err is set.
+type conversion struct {
+	profile *cover.Profile
+	change  *gittool.Change
+	file    string
+	source  string
+	// key is the key of the conversion cache, it's empty if the cache is disabled.
+	key string
+	pkg *Package
+
+	result *fileResult
+	err    error
+	done   chan struct{}
+	// window is the slot of the conversion in the window of convertFiles, it's nil if the file is not parsed by a worker.
+	window chan struct{}
+}
+
+// pending reports whether the source file is left to parse.
+func (c *conversion) pending() bool {
+	return c.result == nil && c.err == nil
+}
+
+func (c *conversion) finish(result *fileResult) *conversion {
+	c.result = result
+	close(c.done)
+	return c
+}
+
+func (c *conversion) fail(err error) *conversion {
+	c.err = err
+	close(c.done)
+	return c
+}
+
+// release frees the slot of the conversion in the window once it's consumed, and drops the result
+// that is kept or spilled already, so that the slice of the conversions doesn't keep the results alive.
+func (c *conversion) release() {
+	c.result = nil
+	if c.window != nil {
+		<-c.window
+		c.window = nil
+	}
+}
+
+// convert parses the source file, and puts the result to the conversion cache.
+// It reads the configuration of the parser only, so the workers call it concurrently.
+func (c *conversion) convert(parser *Parser) {
+	result, err := parser.convertFile(c.file, c.source, c.profile, c.change)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	parser.cache.put(c.key, result)
+	c.finish(result)
+}
+
+// convertFiles parses the source files of the pending conversions in the worker pool, and returns the function
+// that waits for the workers to exit. The workers stop taking the conversions once the context is done,
+// so the conversions that are not taken are never done.
+//
+// At most twice the workers of the conversions are parsed ahead of the consumer, which releases each conversion
+// once its result is kept, so the results waiting to be consumed are bounded whatever the number of the files is.
+func (parser *Parser) convertFiles(ctx context.Context, conversions []*conversion) func() {
+	workers := parser.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	window := make(chan struct{}, 2*workers)
+
+	var pending []*conversion
+	for _, c := range conversions {
+		if c.pending() {
+			c.window = window
+			pending = append(pending, c)
+		}
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	jobs := make(chan *conversion)
+	go func() {
+		defer close(jobs)
+		for _, c := range pending {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				c.convert(parser)
+			}
+		}()
+	}
+	return wg.Wait
+}
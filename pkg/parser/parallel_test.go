@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWorkers(t *testing.T) {
+	t.Run("workers convert the same packages in the same order", func(t *testing.T) {
+		expected, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).WithWorkers(1).Parse(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, packageFunctions(expected))
+
+		for _, workers := range []int{0, 4, 64} {
+			actual, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).WithWorkers(workers).Parse(context.Background(), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual, "workers %d", workers)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).WithWorkers(4).Parse(ctx, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestConvertFilesWindow(t *testing.T) {
+	parser := &Parser{logger: logrus.New(), workers: 1}
+	missing := filepath.Join(t.TempDir(), "missing.go")
+	conversions := make([]*conversion, 5)
+	for i := range conversions {
+		conversions[i] = &conversion{file: missing, source: missing, done: make(chan struct{})}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := parser.convertFiles(ctx, conversions)
+	defer func() {
+		cancel()
+		wait()
+	}()
+
+	done := func() int {
+		n := 0
+		for _, c := range conversions {
+			select {
+			case <-c.done:
+				n++
+			default:
+			}
+		}
+		return n
+	}
+	// the conversions beyond the window wait for the consumer.
+	assert.Eventually(t, func() bool { return done() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 2, done())
+
+	conversions[0].release()
+	assert.Eventually(t, func() bool { return done() == 3 }, time.Second, time.Millisecond)
+	assert.Nil(t, conversions[0].window)
+}
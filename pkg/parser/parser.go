@@ -1,14 +1,19 @@
 package parser
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"go/ast"
 	"go/build"
 	"go/parser"
 	"go/token"
+	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/Azure/gocover/pkg/annotation"
 	"github.com/Azure/gocover/pkg/gittool"
@@ -32,87 +37,332 @@ func NewParser(
 }
 
 // Parser wrapper for parsing
+//
+// The parser is built with the With methods, then each Parse or Stream converts the cover profiles with its own state,
+// so a parser can be used by the goroutines concurrently once it's built, such as analyzing multiple repositories
+// in parallel. The With methods must not be called while the parser is in use.
 type Parser struct {
 	packages          map[string]*Package
 	packagesCache     packagesCache
 	coverProfileFiles []string
 	coverProfiles     []*cover.Profile
+	// cache caches the conversion results of the files, it's nil if the cache is disabled.
+	cache *fileCache
+	// packageCache keeps the imported packages in the directory of cache across the runs, it's nil if the cache is disabled.
+	packageCache *packageCache
+	// excludeFunctions are the name patterns of the functions that are excluded from the result.
+	excludeFunctions []*regexp.Regexp
+	// excludeBuildTags are the build tags whose files are excluded from the result.
+	excludeBuildTags map[string]bool
+	// includePackages and skipPackages are the import path patterns of the packages that are converted.
+	includePackages []*regexp.Regexp
+	skipPackages    []*regexp.Regexp
+	// moduleRoot is the directory that the files of modulePath are resolved in, the packages are imported if it's empty.
+	moduleRoot string
+	modulePath string
+	// modules resolves the packages out of the main module under moduleRoot, it's nil if only the main module is resolved.
+	modules *moduleGraph
+	// overlay replaces the files that are read, such as the generated files in the build, it's nil if not set.
+	overlay *Overlay
+	// skipUnresolved skips the files that cannot be resolved, which are kept in unresolvedFiles.
+	skipUnresolved  bool
+	unresolvedFiles []*SkippedFile
+	// continueOnError converts the rest of the files when a file fails, the errors are kept in fileErrors.
+	continueOnError bool
+	fileErrors      []*FileError
+	// progress is called with the progress of the conversion, it's nil if the progress isn't reported.
+	progress ProgressFunc
+	// memoryLimit caps the statements kept in memory, the results beyond it are spilled to spill.
+	memoryLimit int
+	spill       *spillStore
+	// mixedModes is how the cover profiles of different modes are combined, they're normalized if it's empty.
+	mixedModes MixedModes
+	// compatibleMarkers honors the ignore markers of other coverage tools as the ignore annotations.
+	compatibleMarkers bool
+	// workers is the number of the goroutines that parse the source files, it's runtime.GOMAXPROCS(0) if it's not positive.
+	workers int
+	// mu guards unresolvedFiles and fileErrors, which are kept from the last Parse.
+	mu sync.Mutex
 
 	logger logrus.FieldLogger
 }
 
+// WithCacheDir enables the conversion cache in the directory, so that the files are converted again
+// only when the source, the profile blocks or the change of the file differs. The imported packages are kept
+// in the directory as well until go.mod changes. Empty dir disables the cache.
+func (parser *Parser) WithCacheDir(dir string) *Parser {
+	parser.cache = newFileCache(dir, parser.logger)
+	return parser
+}
+
+// WithExcludeFunctions excludes the functions whose names match any of the patterns from the result,
+// the function literals are excluded along with their enclosing functions. The excluded functions
+// are kept in ExcludedFunctions of the packages, so that they can be reported.
+func (parser *Parser) WithExcludeFunctions(patterns []*regexp.Regexp) *Parser {
+	parser.excludeFunctions = patterns
+	return parser
+}
+
+// WithCompatibleMarkers honors the ignore markers of other coverage tools, such as `//coverage:ignore`,
+// as the gocover ignore annotations, refer to annotation.CompatibleMarkers.
+func (parser *Parser) WithCompatibleMarkers(compatible bool) *Parser {
+	parser.compatibleMarkers = compatible
+	return parser
+}
+
 // Parse parses cover profiles into statements, and modify their state based on git changes.
-func (parser *Parser) Parse(changes []*gittool.Change) (Packages, error) {
+// The skipped and failed files of the last Parse are returned by UnresolvedFiles and FileErrors.
+// It stops with the error of the context once the context is done.
+func (parser *Parser) Parse(ctx context.Context, changes []*gittool.Change) (Packages, error) {
+	run := parser.run()
+	packages, err := run.parse(ctx, changes)
+
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+	parser.unresolvedFiles = run.unresolvedFiles
+	parser.fileErrors = run.fileErrors
+	return packages, err
+}
+
+// run returns a copy of the parser with the configuration only, which keeps the state of a single conversion.
+func (parser *Parser) run() *Parser {
+	return &Parser{
+		packages:          make(map[string]*Package),
+		packagesCache:     make(packagesCache),
+		coverProfileFiles: parser.coverProfileFiles,
+		coverProfiles:     make([]*cover.Profile, 0),
+		cache:             parser.cache.run(),
+		packageCache:      parser.cache.packages(),
+		excludeFunctions:  parser.excludeFunctions,
+		excludeBuildTags:  parser.excludeBuildTags,
+		includePackages:   parser.includePackages,
+		skipPackages:      parser.skipPackages,
+		moduleRoot:        parser.moduleRoot,
+		modulePath:        parser.modulePath,
+		modules:           parser.modules,
+		overlay:           parser.overlay,
+		skipUnresolved:    parser.skipUnresolved,
+		continueOnError:   parser.continueOnError,
+		progress:          parser.progress,
+		memoryLimit:       parser.memoryLimit,
+		spill:             newSpillStore(parser.memoryLimit),
+		mixedModes:        parser.mixedModes,
+		compatibleMarkers: parser.compatibleMarkers,
+		workers:           parser.workers,
+		logger:            parser.logger,
+	}
+}
+
+func (parser *Parser) parse(ctx context.Context, changes []*gittool.Change) (Packages, error) {
+	defer parser.spill.close()
 	if err := parser.filterCoverProfiles(changes); err != nil {
 		parser.logger.WithError(err).Error("filter cover profiles")
 		return nil, err
 	}
-	if err := parser.buildPackageCache(); err != nil {
+	if err := parser.buildPackageCache(ctx); err != nil {
 		parser.logger.WithError(err).Error("build package cache")
 		return nil, err
 	}
+	parser.packageCache.save()
 
-	var result Packages
-
+	conversions := make([]*conversion, 0, len(parser.coverProfiles))
 	for _, p := range parser.coverProfiles {
-		if err := parser.convertProfile(p, findChange(p, changes)); err != nil {
-			parser.logger.WithError(err).Error("covert cover profile")
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
+		conversions = append(conversions, parser.prepareConversion(p, findChange(p, changes)))
+	}
+
+	// the files are parsed by the workers, and the results are kept in the order of the cover profiles
+	// as soon as each of them is done, so the packages don't depend on which worker finishes first.
+	ctx, cancel := context.WithCancel(ctx)
+	wait := parser.convertFiles(ctx, conversions)
+	defer func() {
+		cancel()
+		wait()
+	}()
+
+	progress := newProgressTracker(parser.progress, len(parser.coverProfiles))
+	for _, c := range conversions {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if c.err != nil {
+			if err := parser.tolerate(c.profile, c.err); err != nil {
+				parser.logger.WithError(err).Error("covert cover profile")
+				return nil, err
+			}
+		} else {
+			parser.keepResult(c.pkg, c.result)
+		}
+		c.release()
+		progress.add(c.profile)
 	}
+	progress.finish()
 
+	if err := parser.applySpilled(); err != nil {
+		parser.logger.WithError(err).Error("apply spilled results")
+		return nil, err
+	}
+	return parser.result(), nil
+}
+
+// result returns the converted packages.
+func (parser *Parser) result() Packages {
+	if parser.cache != nil {
+		parser.logger.Infof("conversion cache: %d hits, %d misses", parser.cache.hits, parser.cache.misses)
+	}
+
+	var result Packages
 	for _, pkg := range parser.packages {
 		result.AddPackage(pkg)
 	}
-
-	return result, nil
+	return result
 }
 
 // filterCoverProfiles filters cover profiles based on git changes.
 // If changes is nil, all cover profiles will be kept.
 // If changes is not nil, only cover profiles that are changed will be kept.
 func (parser *Parser) filterCoverProfiles(changes []*gittool.Change) error {
+	all, err := parser.readCoverProfiles()
+	if err != nil {
+		return err
+	}
 
+	for _, p := range all {
+		if changes == nil || findChange(p, changes) != nil {
+			parser.coverProfiles = append(parser.coverProfiles, p)
+		}
+	}
+
+	return nil
+}
+
+// readCoverProfiles reads the cover profile files, combines their modes, merges the profiles of the same file,
+// and drops the profiles whose packages are out of the scope.
+func (parser *Parser) readCoverProfiles() ([]*cover.Profile, error) {
+	var all []*cover.Profile
+	files := make(map[string][]string)
 	for _, coverProfile := range parser.coverProfileFiles {
 		profiles, err := cover.ParseProfiles(coverProfile)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if len(profiles) > 0 {
+			files[profiles[0].Mode] = append(files[profiles[0].Mode], coverProfile)
 		}
+		all = append(all, profiles...)
+	}
+	if err := parser.combineModes(all, files); err != nil {
+		return nil, err
+	}
+	return parser.scopeCoverProfiles(mergeProfiles(all)), nil
+}
+
+// mergeProfiles merges the profiles that belong to the same file into a single profile.
+// Profiles generated with `-coverpkg` contain blocks for packages outside the tested one,
+// so the same file shows up in several cover profiles. Without merging, the file is converted
+// more than once and its functions are attributed to the package repeatedly.
+//
+// Blocks at the same location are deduplicated by keeping the max count. The input profiles often overlap,
+// such as the profiles of `go test -coverpkg` for each package that instrument the same packages,
+// or a merged profile passed with the profiles it's merged from, and summing the counts of the
+// same block would count the same execution repeatedly in Reached. The counts of the same block
+// within one profile are summed by cover.ParseProfiles, the same as `go tool cover` does.
+// The order of the first occurrence of each file is kept.
+func mergeProfiles(profiles []*cover.Profile) []*cover.Profile {
+	var result []*cover.Profile
+	merged := make(map[string]*cover.Profile)
 
-		if changes == nil {
-			parser.coverProfiles = append(parser.coverProfiles, profiles...)
+	for _, p := range profiles {
+		m, ok := merged[p.FileName]
+		if !ok {
+			m = &cover.Profile{
+				FileName: p.FileName,
+				Mode:     p.Mode,
+				Blocks:   append([]cover.ProfileBlock{}, p.Blocks...),
+			}
+			merged[p.FileName] = m
+			result = append(result, m)
 			continue
 		}
+		m.Blocks = append(m.Blocks, p.Blocks...)
+	}
+
+	for _, m := range result {
+		sort.Sort(blocksByStart(m.Blocks))
 
-		for _, p := range profiles {
-			if findChange(p, changes) != nil {
-				parser.coverProfiles = append(parser.coverProfiles, p)
+		j := 0
+		for i, b := range m.Blocks {
+			if i > 0 && sameLocation(m.Blocks[j-1], b) {
+				if b.Count > m.Blocks[j-1].Count {
+					m.Blocks[j-1].Count = b.Count
+				}
+				continue
 			}
+			m.Blocks[j] = b
+			j++
 		}
+		m.Blocks = m.Blocks[:j]
 	}
 
-	return nil
+	return result
 }
 
-// buildPackageCache builds a cache of packages for all cover profiles.
-func (parser *Parser) buildPackageCache() error {
+func sameLocation(a, b cover.ProfileBlock) bool {
+	return a.StartLine == b.StartLine && a.StartCol == b.StartCol &&
+		a.EndLine == b.EndLine && a.EndCol == b.EndCol
+}
+
+type blocksByStart []cover.ProfileBlock
+
+func (b blocksByStart) Len() int      { return len(b) }
+func (b blocksByStart) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b blocksByStart) Less(i, j int) bool {
+	bi, bj := b[i], b[j]
+	return bi.StartLine < bj.StartLine || bi.StartLine == bj.StartLine && bi.StartCol < bj.StartCol
+}
 
+// buildPackageCache builds a cache of packages for all cover profiles,
+// the profiles whose packages cannot be resolved are dropped if they're skipped.
+func (parser *Parser) buildPackageCache(ctx context.Context) error {
+	var resolved []*cover.Profile
 	for _, profile := range parser.coverProfiles {
-		dir, _ := filepath.Split(profile.FileName)
-		if dir != "" {
-			dir = strings.TrimSuffix(dir, "/")
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		_, ok := parser.packagesCache[dir]
-		if !ok {
-			pkg, err := build.Import(dir, ".", build.FindOnly)
-			if err != nil {
+		if err := parser.cachePackage(profile); err != nil {
+			if err := parser.tolerate(profile, err); err != nil {
 				return err
 			}
-			parser.packagesCache[dir] = pkg
-			parser.packages[pkg.ImportPath] = &Package{Name: pkg.ImportPath}
+			continue
 		}
+		resolved = append(resolved, profile)
 	}
+	parser.coverProfiles = resolved
+
+	return nil
+}
 
+// cachePackage caches the package that the cover profile belongs to.
+func (parser *Parser) cachePackage(profile *cover.Profile) error {
+	dir, _ := filepath.Split(profile.FileName)
+	if dir != "" {
+		dir = strings.TrimSuffix(dir, "/")
+	}
+	_, ok := parser.packagesCache[dir]
+	if !ok {
+		pkg, err := parser.importPackage(dir)
+		if err != nil {
+			return fmt.Errorf("%w %s: %w", ErrUnresolvedFile, profile.FileName, err)
+		}
+		// the file names are built from the package directory, which is resolved like the repository path.
+		pkg.Dir, pkg.Root = gittool.RealPath(pkg.Dir), gittool.RealPath(pkg.Root)
+		parser.packagesCache[dir] = pkg
+		parser.packages[pkg.ImportPath] = &Package{Name: pkg.ImportPath}
+	}
 	return nil
 }
 
@@ -122,43 +372,159 @@ type statement struct {
 	*StmtExtent
 }
 
-func (parser *Parser) convertProfile(p *cover.Profile, change *gittool.Change) error {
+// convertResult returns the package of the profile and the conversion result of the file,
+// the result is taken from the conversion cache if it's there.
+func (parser *Parser) convertResult(p *cover.Profile, change *gittool.Change) (*Package, *fileResult, error) {
+	c := parser.prepareConversion(p, change)
+	if c.pending() {
+		c.convert(parser)
+	}
+	return c.pkg, c.result, c.err
+}
+
+// prepareConversion resolves the file of the profile and its package, and takes the result from the conversion cache
+// or the build tags if it's there. The file is left to convert when neither result nor err of the conversion is set.
+// It updates the packages and the cache counters of the parser, so it's called sequentially.
+func (parser *Parser) prepareConversion(p *cover.Profile, change *gittool.Change) *conversion {
+	c := &conversion{profile: p, change: change, done: make(chan struct{})}
+
 	file, pkgpath, err := findFile(parser.packagesCache, p.FileName)
 	if err != nil {
 		parser.logger.WithError(err).Error("find file")
-		return err
+		return c.fail(err)
 	}
 	parser.logger.Debugf("[file=%s, pkgPath=%s]", file, pkgpath)
+	source := parser.overlay.Source(file)
+	if source != file {
+		parser.logger.Debugf("read file %s from overlay %s", file, source)
+	}
+	if _, err := os.Stat(source); err != nil {
+		return c.fail(fmt.Errorf("%w %s: %w", ErrUnresolvedFile, p.FileName, err))
+	}
+	c.file, c.source = file, source
 
 	pkg := parser.packages[pkgpath]
 	if pkg == nil {
 		pkg = &Package{Name: pkgpath}
 		parser.packages[pkgpath] = pkg
 	}
+	pkg.CoverMode = p.Mode
+	c.pkg = pkg
 
-	ignoreProfile, err := annotation.ParseIgnoreProfiles(file, p)
+	if tags := parser.excludedBuildTags(source); tags != nil {
+		parser.logger.Debugf("exclude file %s by build tags %v", file, tags)
+		return c.finish(&fileResult{SkippedFile: &SkippedFile{File: file, Reason: buildTagReason(tags)}})
+	}
+
+	c.key, err = parser.cache.key(file, source, p, change, parser.compatibleMarkers)
 	if err != nil {
-		parser.logger.WithError(err).Error("parse ignore profile")
-		return err
+		parser.logger.WithError(err).Error("cache key")
+		return c.fail(err)
+	}
+	if result, ok := parser.cache.get(c.key); ok {
+		parser.logger.Debugf("conversion cache hit on [%s]", file)
+		return c.finish(result)
 	}
-	if ignoreProfile != nil {
+	return c
+}
+
+// applyResult adds the conversion result of a file to its package.
+// The functions are excluded here rather than in the conversion, so the cached results don't depend on the patterns.
+func (parser *Parser) applyResult(pkg *Package, result *fileResult) {
+	if ignoreProfile := result.IgnoreProfile; ignoreProfile != nil {
 		if ignoreProfile.Type == annotation.FILE_IGNORE {
 			pkg.IgnoreProfiles = append(pkg.IgnoreProfiles, ignoreProfile)
 		} else {
-			if len(ignoreProfile.IgnoreBlocks) != 0 {
+			if len(ignoreProfile.IgnoreBlocks) != 0 || len(ignoreProfile.IgnoreLines) != 0 {
 				pkg.IgnoreProfiles = append(pkg.IgnoreProfiles, ignoreProfile)
 			}
 		}
 	}
+	if result.SkippedFile != nil {
+		pkg.SkippedFiles = append(pkg.SkippedFiles, result.SkippedFile)
+		return
+	}
+	if len(parser.excludeFunctions) == 0 {
+		pkg.Functions = append(pkg.Functions, result.Functions...)
+		return
+	}
+
+	// excluded maps the excluded declared functions of the file to the patterns they match.
+	excluded := make(map[string]string)
+	for _, f := range result.Functions {
+		pattern, ok := "", false
+		if f.Literal && f.Enclosing != "" {
+			pattern, ok = excluded[f.Enclosing]
+		}
+		if !ok {
+			pattern, ok = matchFunction(parser.excludeFunctions, f.Name)
+		}
+		if !ok {
+			pkg.Functions = append(pkg.Functions, f)
+			continue
+		}
+		if !f.Literal {
+			excluded[f.Name] = pattern
+		}
+		parser.logger.Debugf("exclude function %s in %s: %s", f.Name, f.File, pattern)
+		pkg.ExcludedFunctions = append(pkg.ExcludedFunctions, &ExcludedFunction{Function: f, Pattern: pattern})
+	}
+}
+
+// matchFunction returns the first pattern that the function name matches.
+func matchFunction(patterns []*regexp.Regexp, name string) (string, bool) {
+	for _, p := range patterns {
+		if p.MatchString(name) {
+			return p.String(), true
+		}
+	}
+	return "", false
+}
+
+// convertFile converts the profile of the file into functions and statements,
+// and sets the statements' Mode and State based on the ignore annotations and the change.
+// The content of the file is read from source, which is the file itself unless it's replaced by the overlay.
+func (parser *Parser) convertFile(file, source string, p *cover.Profile, change *gittool.Change) (*fileResult, error) {
+	result := &fileResult{}
+
+	cgo, err := isCgoFile(source)
+	if err != nil {
+		parser.logger.WithError(err).Error("check cgo file")
+		return nil, err
+	}
+	if cgo {
+		if reason := checkCgoProfile(source, p); reason != "" {
+			parser.logger.Warnf("skip cgo file %s: %s", file, reason)
+			result.SkippedFile = &SkippedFile{File: file, Reason: reason}
+			return result, nil
+		}
+	}
+
+	parseIgnoreProfiles := annotation.ParseIgnoreProfiles
+	if parser.compatibleMarkers {
+		parseIgnoreProfiles = annotation.ParseCompatibleIgnoreProfiles
+	}
+	ignoreProfile, err := parseIgnoreProfiles(source, p)
+	if err != nil {
+		parser.logger.WithError(err).Error("parse ignore profile")
+		return nil, err
+	}
+	ignoreProfile.Filename = file
+	result.IgnoreProfile = ignoreProfile
 
 	// Find function and statement extents; create corresponding
 	// Functions and Statements, and keep a separate
 	// slice of Statements so we can match them with profile
 	// blocks.
-	extents, err := findFuncs(file)
+	extents, err := findFuncs(source)
 	if err != nil {
+		if cgo {
+			parser.logger.WithError(err).Warnf("skip cgo file %s", file)
+			result.SkippedFile = &SkippedFile{File: file, Reason: fmt.Sprintf("cgo file cannot be parsed: %s", err)}
+			return result, nil
+		}
 		parser.logger.WithError(err).Error("find Functions")
-		return err
+		return nil, err
 	}
 	var stmts []*statement
 	for _, fe := range extents {
@@ -169,6 +535,8 @@ func (parser *Parser) convertProfile(p *cover.Profile, change *gittool.Change) e
 			End:       fe.endOffset,
 			StartLine: fe.startLine,
 			EndLine:   fe.endLine,
+			Literal:   fe.literal,
+			Enclosing: fe.enclosing,
 		}
 		for _, se := range fe.stmts {
 			s := &statement{
@@ -185,24 +553,45 @@ func (parser *Parser) convertProfile(p *cover.Profile, change *gittool.Change) e
 			f.Statements = append(f.Statements, s.Statement)
 			stmts = append(stmts, s)
 		}
-		pkg.Functions = append(pkg.Functions, f)
+		result.Functions = append(result.Functions, f)
 	}
 	// For each profile block in the file, find the statement(s) it
 	// covers and increment the Reached field(s).
+	// The statement is resolved by the first block, and the later blocks that cover the rest of its own span,
+	// outside the blocks nested in it, mark it Partial if their reached state differs.
 	blocks := p.Blocks
 	for _, s := range stmts {
 
+		matched := false
 		for i, b := range blocks {
 			if b.StartLine > s.endLine || (b.StartLine == s.endLine && b.StartCol >= s.endCol) {
 				// Past the end of the statement
-				blocks = blocks[i:]
+				if !matched {
+					blocks = blocks[i:]
+				}
 				break
 			}
 			if b.EndLine < s.startLine || (b.EndLine == s.startLine && b.EndCol <= s.startCol) {
 				// Before the beginning of the statement
 				continue
 			}
+			if s.inBody(b) {
+				// the block belongs to the statements nested in the statement
+				continue
+			}
 
+			// the statement is resolved once its own span is covered to the end.
+			resolved := !positionBefore(b.EndLine, b.EndCol, s.endLine, s.endCol)
+			if matched {
+				if (b.Count > 0) != (s.Reached > 0) {
+					s.Partial = true
+				}
+				if resolved {
+					break
+				}
+				continue
+			}
+			matched = true
 			s.Reached += int64(b.Count)
 
 			if ignoreProfile != nil {
@@ -214,16 +603,21 @@ func (parser *Parser) convertProfile(p *cover.Profile, change *gittool.Change) e
 					if _, ok := ignoreProfile.IgnoreBlocks[b]; ok {
 						s.Mode = Ignore
 						parser.logger.Debugf("hit block ignore on [%s], ignore statement at line %d", file, s.startLine)
+					} else if _, ok := ignoreProfile.IgnoreLines[s.startLine]; ok {
+						// only the statements that start at the line, not the blocks nested in them
+						s.Mode = Ignore
+						parser.logger.Debugf("hit line ignore on [%s], ignore statement at line %d", file, s.startLine)
 					}
 				}
 			}
-
-			break
+			if resolved {
+				break
+			}
 		}
 	}
 
 	parser.setStatementsState(change, stmts)
-	return nil
+	return result, nil
 }
 
 // findFile finds the location of the named file in GOROOT, GOPATH etc.
@@ -234,12 +628,50 @@ func findFile(packages packagesCache, file string) (filename, pkgpath string, er
 	}
 	pkg, ok := packages[dir]
 	if !ok {
-		return "", "", fmt.Errorf("no package found for %s", file)
+		return "", "", fmt.Errorf("%w: no package found for %s", ErrUnresolvedFile, file)
 	}
 
 	return filepath.Join(pkg.Dir, file), pkg.ImportPath, nil
 }
 
+// isCgoFile reports whether the file imports the pseudo package "C".
+func isCgoFile(name string) (bool, error) {
+	fset := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fset, name, nil, parser.ImportsOnly)
+	if err != nil {
+		// leave the syntax error to the following parsing
+		return false, nil
+	}
+	for _, imp := range parsedFile.Imports {
+		if imp.Path != nil && imp.Path.Value == `"C"` {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkCgoProfile checks whether the profile blocks of a cgo file can be mapped to its source.
+// Depending on the go version, the cover profile of a cgo file may be generated from the cgo
+// processed file instead of the original one, so the blocks don't match the source lines.
+// It returns the reason why the file cannot be mapped, or empty string if it's fine.
+func checkCgoProfile(name string, profile *cover.Profile) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Sprintf("cgo file cannot be read: %s", err)
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	for _, b := range profile.Blocks {
+		if b.StartLine > lines || b.EndLine > lines {
+			return fmt.Sprintf("cgo file profile block %d.%d,%d.%d is out of the source range (%d lines)",
+				b.StartLine, b.StartCol, b.EndLine, b.EndCol, lines)
+		}
+	}
+	return ""
+}
+
 // findFuncs parses the file and returns a slice of FuncExtent descriptors.
 func findFuncs(name string) ([]*FuncExtent, error) {
 	fset := token.NewFileSet()
@@ -266,24 +698,126 @@ type FuncExtent struct {
 	extent
 	name  string
 	stmts []*StmtExtent
+
+	// literal indicates the function is a function literal.
+	literal bool
+	// enclosing is the name of the declared function that the function literal is in.
+	enclosing string
 }
 
 // StmtExtent describes a statements's extent in the source by file and position.
-type StmtExtent extent
+type StmtExtent struct {
+	startOffset int
+	startLine   int
+	startCol    int
+	endOffset   int
+	endLine     int
+	endCol      int
+
+	// bodies are the extents of the blocks nested in the statement, such as the branches of an if statement
+	// or the body of a function literal, whose profile blocks belong to the statements in them.
+	bodies []extent
+}
+
+// inBody reports whether the profile block is in any of the blocks nested in the statement.
+func (se *StmtExtent) inBody(b cover.ProfileBlock) bool {
+	for _, body := range se.bodies {
+		if !positionBefore(b.StartLine, b.StartCol, body.startLine, body.startCol) &&
+			!positionBefore(body.endLine, body.endCol, b.EndLine, b.EndCol) {
+			return true
+		}
+	}
+	return false
+}
+
+// positionBefore reports whether the position of line1 and col1 is before the one of line2 and col2.
+func positionBefore(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || (line1 == line2 && col1 < col2)
+}
+
+// nestedBodies returns the extents of the outermost blocks nested in the statement, the else branch of an if
+// statement is a whole from the else keyword, as the else-if conditions are in the blocks of their own.
+func nestedBodies(fset *token.FileSet, s ast.Stmt) []extent {
+	var bodies []extent
+	add := func(pos, end token.Pos) {
+		start, stop := fset.Position(pos), fset.Position(end)
+		bodies = append(bodies, extent{
+			startOffset: start.Offset,
+			startLine:   start.Line,
+			startCol:    start.Column,
+			endOffset:   stop.Offset,
+			endLine:     stop.Line,
+			endCol:      stop.Column,
+		})
+	}
+	ast.Inspect(s, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			add(n.Lbrace, n.End())
+			return false
+		case *ast.IfStmt:
+			if n.Else != nil {
+				const backupToElse = token.Pos(len("else "))
+				add(n.Else.Pos()-backupToElse, n.Else.End())
+			}
+		}
+		return true
+	})
+	return bodies
+}
 
 // FuncVisitor implements the visitor that builds the function position list for a file.
+// A nested visitor is created for each function, which tracks the enclosing function
+// to name the function literals in it.
 type FuncVisitor struct {
 	fset  *token.FileSet
 	funcs []*FuncExtent
+
+	// root is the visitor of the file that collects the functions, nil for the root itself.
+	root *FuncVisitor
+	// name is the name of the enclosing function, empty at package level.
+	name string
+	// literal indicates the enclosing function is a function literal.
+	literal bool
+	// closures counts the function literals directly in the enclosing function.
+	closures int
+	// declared is the name of the outermost declared function, empty at package level.
+	declared string
+}
+
+// closureName names the next function literal in the enclosing function the same way as the runtime does,
+// e.g. "Handler.func1" in function Handler, "Handler.func1.1" in function literal "Handler.func1",
+// and "glob..func1" at package level.
+func (v *FuncVisitor) closureName() string {
+	v.closures++
+	switch {
+	case v.name == "":
+		return fmt.Sprintf("glob..func%d", v.closures)
+	case v.literal:
+		return fmt.Sprintf("%s.%d", v.name, v.closures)
+	default:
+		return fmt.Sprintf("%s.func%d", v.name, v.closures)
+	}
 }
 
 func functionName(f *ast.FuncDecl) string {
 	name := f.Name.Name
 	if f.Recv == nil {
+		// Generic function name is appended with its type parameters, e.g. "Map[K, V]".
+		if f.Type.TypeParams != nil {
+			var params []string
+			for _, field := range f.Type.TypeParams.List {
+				for _, n := range field.Names {
+					params = append(params, n.Name)
+				}
+			}
+			name = fmt.Sprintf("%s[%s]", name, strings.Join(params, ", "))
+		}
 		return name
 	} else {
 		// Function name is prepended with "T." if there is a receiver, where
 		// T is the type of the receiver, dereferenced if it is a pointer.
+		// For generic receiver, T contains the type parameters, e.g. "T[K, V]".
 		return exprName(f.Recv.List[0].Type) + "." + name
 	}
 }
@@ -292,8 +826,16 @@ func exprName(x ast.Expr) string {
 	switch y := x.(type) {
 	case *ast.StarExpr:
 		return exprName(y.X)
+	case *ast.ParenExpr:
+		return exprName(y.X)
 	case *ast.IndexExpr:
 		return fmt.Sprintf("%s[%s]", exprName(y.X), exprName(y.Index))
+	case *ast.IndexListExpr:
+		var indices []string
+		for _, index := range y.Indices {
+			indices = append(indices, exprName(index))
+		}
+		return fmt.Sprintf("%s[%s]", exprName(y.X), strings.Join(indices, ", "))
 	case *ast.Ident:
 		return y.Name
 	default:
@@ -305,19 +847,24 @@ func exprName(x ast.Expr) string {
 func (v *FuncVisitor) Visit(node ast.Node) ast.Visitor {
 	var body *ast.BlockStmt
 	var name string
+	declared := v.declared
 	switch n := node.(type) {
 	case *ast.FuncLit:
 		body = n.Body
+		name = v.closureName()
 	case *ast.FuncDecl:
 		body = n.Body
 		name = functionName(n)
+		declared = name
 	}
 	if body != nil {
+		root := v.root
+		if root == nil {
+			root = v
+		}
+		_, literal := node.(*ast.FuncLit)
 		start := v.fset.Position(node.Pos())
 		end := v.fset.Position(node.End())
-		if name == "" {
-			name = fmt.Sprintf("@%d:%d", start.Line, start.Column)
-		}
 		fe := &FuncExtent{
 			name: name,
 			extent: extent{
@@ -329,9 +876,15 @@ func (v *FuncVisitor) Visit(node ast.Node) ast.Visitor {
 				endCol:      end.Column,
 			},
 		}
-		v.funcs = append(v.funcs, fe)
+		if literal {
+			fe.literal = true
+			fe.enclosing = v.declared
+		}
+		root.funcs = append(root.funcs, fe)
 		sv := StmtVisitor{fset: v.fset, function: fe}
 		sv.VisitStmt(body)
+
+		return &FuncVisitor{fset: v.fset, root: root, name: name, literal: literal, declared: declared}
 	}
 	return v
 }
@@ -416,6 +969,7 @@ func (v *StmtVisitor) VisitStmt(s ast.Stmt) {
 				endOffset:   end.Offset,
 				endLine:     end.Line,
 				endCol:      end.Column,
+				bodies:      nestedBodies(v.fset, s),
 			}
 			v.function.stmts = append(v.function.stmts, se)
 		}
@@ -425,7 +979,8 @@ func (v *StmtVisitor) VisitStmt(s ast.Stmt) {
 
 // setStatementsState sets statements' State field according to the file change.
 // If change is nil, means no change is made, it usually runs in full coverage mode
-// If change is not nil, loop over each changed lines and find its statement and set the statement to Changed
+// If change is not nil, loop over each changed lines and find its statement and set the statement to Changed,
+// or to Moved if the line is moved code, a statement with any changed line that isn't moved is Changed.
 func (parser *Parser) setStatementsState(change *gittool.Change, statements []*statement) {
 	if change == nil {
 		return
@@ -436,12 +991,24 @@ func (parser *Parser) setStatementsState(change *gittool.Change, statements []*s
 
 	sort.Sort(statementByStart(statements))
 
+	moved := make(map[int]bool)
+	for _, s := range change.Moved {
+		for lineNum := s.StartLine; lineNum <= s.EndLine; lineNum++ {
+			moved[lineNum] = true
+		}
+	}
+
 	parser.logger.Debugf("processing changed file: %s", change.FileName)
 	for _, s := range change.Sections {
 		for lineNum := s.StartLine; lineNum <= s.EndLine; lineNum++ {
-			if isCodeLine(s.Contents[lineNum-s.StartLine]) {
-				parser.setStatementsStateByLineNumber(lineNum, statements)
+			if !isCodeLine(s.Contents[lineNum-s.StartLine]) {
+				continue
+			}
+			state := Changed
+			if moved[lineNum] {
+				state = Moved
 			}
+			parser.setStatementsStateByLineNumber(lineNum, statements, state)
 		}
 	}
 }
@@ -454,13 +1021,14 @@ func isCodeLine(line string) bool {
 // setStatementsStateByLineNumber sets statements' State field based on code line number.
 // It sort statements by startline first, then try to find first statement
 // that line number is greater than or equals the startline of the statement.
+// The Changed statement is kept Changed when the state is Moved.
 // There are two edge cases:
 //  1. When line number is less than all the statements, `Search` function will return 0,
 //     but there is no suitable statement, should return immediately.
 //  2. Otherwise, `Search` function will return first statement that its startline is greater than changed line number,
 //     then statement of that position minus one is the statement we want,
 //     but still need to check whether the changed line is among the statement scope.
-func (parser *Parser) setStatementsStateByLineNumber(changedlineNumber int, statements []*statement) {
+func (parser *Parser) setStatementsStateByLineNumber(changedlineNumber int, statements []*statement, state State) {
 	idx := sort.Search(len(statements), func(i int) bool {
 		return statements[i].startLine > changedlineNumber
 	})
@@ -473,7 +1041,9 @@ func (parser *Parser) setStatementsStateByLineNumber(changedlineNumber int, stat
 	stmt := statements[idx]
 
 	if stmt != nil && lineNumberInStatement(changedlineNumber, stmt) {
-		stmt.State = Changed
+		if state == Changed || stmt.State != Changed {
+			stmt.State = state
+		}
 		parser.logger.Debugf(
 			"for changed line number %d, set statement [%d:%d] to %s",
 			changedlineNumber, statements[idx].startLine, statements[idx].endLine, statements[idx].State,
@@ -504,7 +1074,8 @@ func findChange(profile *cover.Profile, changes []*gittool.Change) *gittool.Chan
 	return nil
 }
 
-// InFolder check whether specified filepath is a part of parent path.
+// InFolder check whether specified filepath is a part of parent path,
+// the paths are compared by gittool.HasPathSuffix so that the separators and the case of Windows are tolerated.
 func InFolder(parentDir, filepath string) bool {
-	return strings.HasSuffix(parentDir, filepath)
+	return gittool.HasPathSuffix(parentDir, filepath)
 }
@@ -1,6 +1,11 @@
 package parser
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
 	"testing"
 
 	"github.com/Azure/gocover/pkg/gittool"
@@ -35,7 +40,7 @@ func TestParser(t *testing.T) {
 			logger:            logrus.New(),
 		}
 
-		parser.buildPackageCache()
+		parser.buildPackageCache(context.Background())
 		for _, pkg := range allPackages {
 			if _, ok := parser.packagesCache[pkg]; !ok {
 				t.Errorf("package %s is not in packagesCache", pkg)
@@ -105,6 +110,46 @@ func TestParser(t *testing.T) {
 		})
 	})
 
+	t.Run("parse concurrently", func(t *testing.T) {
+		changes := []*gittool.Change{
+			{FileName: "pkg/parser/parser.go", Mode: gittool.NewMode},
+			{FileName: "pkg/gocover/executor.go", Mode: gittool.NewMode},
+		}
+		parser := NewParser([]string{"testdata/cover.out"}, logrus.New())
+
+		expected, err := parser.Parse(context.Background(), changes)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, packageFunctions(expected))
+
+		results := make([]Packages, 4)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				packages, err := parser.Parse(context.Background(), changes)
+				assert.NoError(t, err)
+				results[i] = packages
+			}(i)
+		}
+		wg.Wait()
+
+		// the same parser converts the same packages in each call.
+		for _, packages := range results {
+			assert.Equal(t, packageFunctions(expected), packageFunctions(packages))
+		}
+	})
+
+	t.Run("cancel parse", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).Parse(ctx, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		stream, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).Stream()
+		assert.NoError(t, err)
+		assert.ErrorIs(t, stream.Add(ctx, &gittool.Change{FileName: "pkg/parser/parser.go"}), context.Canceled)
+	})
 }
 
 func TestSetStatementsState(t *testing.T) {
@@ -307,4 +352,462 @@ func TestSetStatementsState(t *testing.T) {
 		}
 
 	})
+
+	t.Run("moved lines", func(t *testing.T) {
+		parser := &Parser{logger: logrus.New()}
+		change := &gittool.Change{
+			Sections: []*gittool.Section{{StartLine: 3, EndLine: 8, Contents: []string{
+				"func Bar() int {",
+				"	x := compute()",
+				"	if x > 0 {",
+				"		return x",
+				"	}",
+				"	return 0",
+			}}},
+			Moved: []*gittool.Section{{StartLine: 3, EndLine: 6}},
+		}
+		moved := &statement{Statement: &Statement{State: Original}, StmtExtent: &StmtExtent{startLine: 4, endLine: 4}}
+		mixed := &statement{Statement: &Statement{State: Original}, StmtExtent: &StmtExtent{startLine: 5, endLine: 7}}
+		changed := &statement{Statement: &Statement{State: Original}, StmtExtent: &StmtExtent{startLine: 8, endLine: 8}}
+		parser.setStatementsState(change, []*statement{moved, mixed, changed})
+
+		assert.Equal(t, Moved, moved.State)
+		assert.Equal(t, Changed, mixed.State)
+		assert.Equal(t, Changed, changed.State)
+	})
+}
+
+func TestMergeProfiles(t *testing.T) {
+	t.Run("merge profiles of the same file", func(t *testing.T) {
+		profiles := []*cover.Profile{
+			{
+				FileName: "github.com/Azure/gocover/pkg/foo/foo.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, StartCol: 10, EndLine: 3, EndCol: 2, NumStmt: 1, Count: 0},
+					{StartLine: 5, StartCol: 10, EndLine: 7, EndCol: 2, NumStmt: 1, Count: 1},
+				},
+			},
+			{
+				FileName: "github.com/Azure/gocover/pkg/bar/bar.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, StartCol: 10, EndLine: 3, EndCol: 2, NumStmt: 1, Count: 0},
+				},
+			},
+			{
+				FileName: "github.com/Azure/gocover/pkg/foo/foo.go",
+				Mode:     "set",
+				Blocks: []cover.ProfileBlock{
+					{StartLine: 1, StartCol: 10, EndLine: 3, EndCol: 2, NumStmt: 1, Count: 1},
+					{StartLine: 5, StartCol: 10, EndLine: 7, EndCol: 2, NumStmt: 1, Count: 0},
+				},
+			},
+		}
+
+		merged := mergeProfiles(profiles)
+		assert.Len(t, merged, 2)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/foo/foo.go", merged[0].FileName)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/bar/bar.go", merged[1].FileName)
+
+		assert.Len(t, merged[0].Blocks, 2)
+		for _, b := range merged[0].Blocks {
+			assert.Equal(t, 1, b.Count)
+		}
+	})
+
+	t.Run("deduplicate counts for count mode", func(t *testing.T) {
+		profiles := []*cover.Profile{
+			{
+				FileName: "foo.go",
+				Mode:     "count",
+				Blocks:   []cover.ProfileBlock{{StartLine: 1, StartCol: 10, EndLine: 3, EndCol: 2, NumStmt: 1, Count: 2}},
+			},
+			{
+				FileName: "foo.go",
+				Mode:     "count",
+				Blocks:   []cover.ProfileBlock{{StartLine: 1, StartCol: 10, EndLine: 3, EndCol: 2, NumStmt: 1, Count: 3}},
+			},
+		}
+
+		merged := mergeProfiles(profiles)
+		assert.Len(t, merged, 1)
+		assert.Len(t, merged[0].Blocks, 1)
+		assert.Equal(t, 3, merged[0].Blocks[0].Count)
+	})
+
+	t.Run("overlapping profiles are not double counted", func(t *testing.T) {
+		dir := t.TempDir()
+		profile := "mode: count\ngithub.com/Azure/gocover/pkg/foo/foo.go:1.10,3.2 1 1\ngithub.com/Azure/gocover/pkg/foo/foo.go:5.10,7.2 1 0\n"
+		other := "mode: count\ngithub.com/Azure/gocover/pkg/foo/foo.go:1.10,3.2 1 1\ngithub.com/Azure/gocover/pkg/foo/foo.go:5.10,7.2 1 2\n"
+		for name, content := range map[string]string{"a.out": profile, "b.out": other} {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		}
+
+		parser := &Parser{coverProfileFiles: []string{filepath.Join(dir, "a.out"), filepath.Join(dir, "b.out")}}
+		merged, err := parser.readCoverProfiles()
+		assert.NoError(t, err)
+		assert.Len(t, merged, 1)
+		assert.Equal(t, []int{1, 2}, []int{merged[0].Blocks[0].Count, merged[0].Blocks[1].Count})
+	})
+
+	t.Run("duplicated cover profiles are parsed once", func(t *testing.T) {
+		parser := &Parser{
+			coverProfileFiles: []string{"testdata/cover.out", "testdata/cover.out"},
+			packages:          make(map[string]*Package),
+			packagesCache:     make(packagesCache),
+			logger:            logrus.New(),
+		}
+
+		profiles, err := cover.ParseProfiles("testdata/cover.out")
+		assert.NoError(t, err)
+
+		err = parser.filterCoverProfiles(nil)
+		assert.NoError(t, err)
+		assert.Len(t, parser.coverProfiles, len(profiles))
+	})
+}
+
+func TestPartialStatement(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "foo.go")
+	assert.NoError(t, os.WriteFile(file, []byte(`package foo
+
+func foo(x bool) int {
+	if x {
+		return 1
+	}
+	f := func() int {
+		return 2
+	}
+	return 0 +
+		f()
+}
+`), 0644))
+	profile := &cover.Profile{
+		FileName: "github.com/Azure/gocover/foo/foo.go",
+		Mode:     "set",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, StartCol: 22, EndLine: 4, EndCol: 7, NumStmt: 1, Count: 1},
+			{StartLine: 4, StartCol: 7, EndLine: 6, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 7, StartCol: 2, EndLine: 7, EndCol: 18, NumStmt: 1, Count: 1},
+			{StartLine: 7, StartCol: 18, EndLine: 9, EndCol: 3, NumStmt: 1, Count: 0},
+			{StartLine: 10, StartCol: 2, EndLine: 10, EndCol: 11, NumStmt: 1, Count: 1},
+			// the statement is split in two blocks of its own span, such as a block ends in it.
+			{StartLine: 10, StartCol: 11, EndLine: 11, EndCol: 6, NumStmt: 1, Count: 0},
+		},
+	}
+
+	result, err := NewParser(nil, logrus.New()).convertFile(file, file, profile, nil)
+	assert.NoError(t, err)
+
+	statements := result.Functions[0].Statements
+	assert.Len(t, statements, 4)
+	// the if statement is reached, its untaken branch belongs to the statement in it
+	assert.Equal(t, int64(1), statements[0].Reached)
+	assert.False(t, statements[0].Partial)
+	assert.Equal(t, int64(0), statements[1].Reached)
+	assert.False(t, statements[1].Partial)
+	// the body of the function literal belongs to the function literal
+	assert.Equal(t, int64(1), statements[2].Reached)
+	assert.False(t, statements[2].Partial)
+	assert.Equal(t, int64(1), statements[3].Reached)
+	assert.True(t, statements[3].Partial)
+
+	literal := result.Functions[1].Statements
+	assert.Len(t, literal, 1)
+	assert.Equal(t, int64(0), literal[0].Reached)
+	assert.False(t, literal[0].Partial)
+}
+
+func TestExcludeFunctions(t *testing.T) {
+	functions := []*Function{
+		{Name: "Foo.String"},
+		{Name: "Foo.String.func1", Literal: true, Enclosing: "Foo.String"},
+		{Name: "MustParse"},
+		{Name: "Parse"},
+		{Name: "Parse.func1", Literal: true, Enclosing: "Parse"},
+		{Name: "glob..func1", Literal: true},
+	}
+
+	t.Run("no patterns", func(t *testing.T) {
+		pkg := &Package{}
+		NewParser(nil, logrus.New()).applyResult(pkg, &fileResult{Functions: functions})
+		assert.Len(t, pkg.Functions, 6)
+		assert.Empty(t, pkg.ExcludedFunctions)
+	})
+
+	t.Run("exclude by name patterns", func(t *testing.T) {
+		pkg := &Package{}
+		patterns := []*regexp.Regexp{regexp.MustCompile(`String$`), regexp.MustCompile(`^Must`)}
+		NewParser(nil, logrus.New()).WithExcludeFunctions(patterns).applyResult(pkg, &fileResult{Functions: functions})
+
+		var names []string
+		for _, f := range pkg.Functions {
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, []string{"Parse", "Parse.func1", "glob..func1"}, names)
+
+		var excluded []string
+		for _, f := range pkg.ExcludedFunctions {
+			excluded = append(excluded, f.Function.Name+":"+f.Pattern)
+		}
+		assert.Equal(t, []string{"Foo.String:String$", "Foo.String.func1:String$", "MustParse:^Must"}, excluded)
+	})
+}
+
+func TestCgoFile(t *testing.T) {
+	dir := t.TempDir()
+	cgoFile := filepath.Join(dir, "cgo.go")
+	err := os.WriteFile(cgoFile, []byte(`package foo
+
+// #include <stdlib.h>
+import "C"
+
+func foo() {
+	C.free(nil)
+}
+`), 0644)
+	assert.NoError(t, err)
+
+	goFile := filepath.Join(dir, "foo.go")
+	err = os.WriteFile(goFile, []byte(`package foo
+
+import "fmt"
+
+func bar() {
+	fmt.Println()
+}
+`), 0644)
+	assert.NoError(t, err)
+
+	t.Run("isCgoFile", func(t *testing.T) {
+		cgo, err := isCgoFile(cgoFile)
+		assert.NoError(t, err)
+		assert.True(t, cgo)
+
+		cgo, err = isCgoFile(goFile)
+		assert.NoError(t, err)
+		assert.False(t, cgo)
+	})
+
+	t.Run("checkCgoProfile", func(t *testing.T) {
+		reason := checkCgoProfile(cgoFile, &cover.Profile{
+			Blocks: []cover.ProfileBlock{{StartLine: 6, StartCol: 12, EndLine: 8, EndCol: 2, NumStmt: 1}},
+		})
+		assert.Empty(t, reason)
+
+		reason = checkCgoProfile(cgoFile, &cover.Profile{
+			Blocks: []cover.ProfileBlock{{StartLine: 20, StartCol: 12, EndLine: 28, EndCol: 2, NumStmt: 1}},
+		})
+		assert.NotEmpty(t, reason)
+
+		reason = checkCgoProfile(filepath.Join(dir, "nonexist.go"), &cover.Profile{})
+		assert.NotEmpty(t, reason)
+	})
+}
+
+func TestFindFuncs(t *testing.T) {
+	t.Run("function names", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "foo.go")
+		err := os.WriteFile(filename, []byte(`package foo
+
+type Foo struct{}
+
+func (f *Foo) Bar() {}
+
+type List[T any] struct{}
+
+func (l *List[T]) Len() int { return 0 }
+
+type Map[K comparable, V any] struct{}
+
+func (m Map[K, V]) Get(k K) V {
+	var v V
+	return v
+}
+
+func Keys[K comparable, V any](m map[K]V) []K { return nil }
+
+func Values[M ~map[K]V, K comparable, V any](m M) []V { return nil }
+
+func foo() {
+	_ = func() {}
+	go func() {
+		defer func() {}()
+	}()
+}
+
+func (f *Foo) Handler() {
+	f.Bar(func() {})
+}
+
+var handler = func() {}
+`), 0644)
+		assert.NoError(t, err)
+
+		funcs, err := findFuncs(filename)
+		assert.NoError(t, err)
+
+		var names []string
+		for _, f := range funcs {
+			names = append(names, f.name)
+		}
+		assert.Equal(t, []string{
+			"Foo.Bar",
+			"List[T].Len",
+			"Map[K, V].Get",
+			"Keys[K, V]",
+			"Values[M, K, V]",
+			"foo",
+			"foo.func1",
+			"foo.func2",
+			"foo.func2.1",
+			"Foo.Handler",
+			"Foo.Handler.func1",
+			"glob..func1",
+		}, names)
+
+		var enclosings []string
+		for _, f := range funcs {
+			if f.literal {
+				enclosings = append(enclosings, f.enclosing)
+			}
+		}
+		assert.Equal(t, []string{"foo", "foo", "foo", "Foo.Handler", ""}, enclosings)
+	})
+}
+
+func TestNextLineIgnore(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "foo.go"), []byte(`package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		//+gocover:ignore:next cannot be reached
+		panic("unreachable")
+	}
+	return x
+}
+`), 0644))
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/foo/foo.go:3.21,4.11 1 1\n"+
+		"example.com/foo/foo.go:4.11,7.3 1 0\n"+
+		"example.com/foo/foo.go:8.2,8.10 1 1\n"), 0644))
+
+	packages, err := NewParser([]string{profile}, logrus.New()).WithModuleRoot(root, "example.com/foo").Parse(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+	assert.Len(t, packages[0].IgnoreProfiles, 1)
+
+	var ignored []int
+	for _, s := range packages[0].Functions[0].Statements {
+		if s.Mode == Ignore {
+			ignored = append(ignored, s.StartLine)
+		}
+	}
+	assert.Equal(t, []int{6}, ignored)
+}
+
+func TestLineIgnore(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "foo.go"), []byte(`package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		panic("unreachable")
+	}
+	return x //+gocover:ignore:line trivial
+}
+`), 0644))
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/foo/foo.go:3.21,4.11 1 1\n"+
+		"example.com/foo/foo.go:4.11,6.3 1 0\n"+
+		"example.com/foo/foo.go:7.2,7.10 1 1\n"), 0644))
+
+	packages, err := NewParser([]string{profile}, logrus.New()).WithModuleRoot(root, "example.com/foo").Parse(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+	assert.Len(t, packages[0].IgnoreProfiles, 1)
+
+	var ignored []int
+	for _, s := range packages[0].Functions[0].Statements {
+		if s.Mode == Ignore {
+			ignored = append(ignored, s.StartLine)
+		}
+	}
+	assert.Equal(t, []int{7}, ignored)
+}
+
+func TestLineIgnoreAlias(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "foo.go"), []byte(`package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		panic("unreachable")
+	}
+	return x //gocover:ignore trivial
+}
+`), 0644))
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/foo/foo.go:3.21,4.11 1 1\n"+
+		"example.com/foo/foo.go:4.11,6.3 1 0\n"+
+		"example.com/foo/foo.go:7.2,7.10 1 1\n"), 0644))
+
+	packages, err := NewParser([]string{profile}, logrus.New()).WithModuleRoot(root, "example.com/foo").Parse(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+	if assert.Len(t, packages[0].IgnoreProfiles, 1) {
+		assert.Equal(t, "	return x //gocover:ignore trivial", packages[0].IgnoreProfiles[0].IgnoreLines[7].Annotation)
+	}
+
+	var ignored []int
+	for _, s := range packages[0].Functions[0].Statements {
+		if s.Mode == Ignore {
+			ignored = append(ignored, s.StartLine)
+		}
+	}
+	assert.Equal(t, []int{7}, ignored)
+}
+
+func TestCompatibleMarkers(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "foo.go"), []byte(`package foo
+
+func Foo(x int) int {
+	if x > 0 {
+		// nocover
+		panic("unreachable")
+	}
+	return x //coverage:ignore
+}
+`), 0644))
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/foo/foo.go:3.21,4.11 1 1\n"+
+		"example.com/foo/foo.go:4.11,7.3 1 0\n"+
+		"example.com/foo/foo.go:8.2,8.10 1 1\n"), 0644))
+
+	ignored := func(compatible bool) []int {
+		packages, err := NewParser([]string{profile}, logrus.New()).
+			WithModuleRoot(root, "example.com/foo").
+			WithCompatibleMarkers(compatible).
+			Parse(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, packages, 1)
+
+		var lines []int
+		for _, s := range packages[0].Functions[0].Statements {
+			if s.Mode == Ignore {
+				lines = append(lines, s.StartLine)
+			}
+		}
+		return lines
+	}
+	assert.Empty(t, ignored(false))
+	assert.Equal(t, []int{6, 8}, ignored(true))
 }
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// packageCacheVersion is part of the key of the package cache, bump it when the cached entries change.
+const packageCacheVersion = "v1"
+
+// packageCache keeps the directories of the imported packages in the cache dir across the runs, as importing a package
+// runs go list in module mode and the packages are resolved identically on every run of the same module.
+// The entries are kept in a file named by the hash of go.mod and the build environment, so a change of the dependencies
+// or the toolchain starts a new file. An entry whose directory no longer exists is imported again.
+type packageCache struct {
+	// file is the file of the entries, the cache is disabled if it's empty.
+	file    string
+	entries map[string]*packageEntry
+	dirty   bool
+	hits    int
+	misses  int
+
+	logger logrus.FieldLogger
+}
+
+// packageEntry is the imported package of an import path.
+type packageEntry struct {
+	ImportPath string `json:"importPath"`
+	Dir        string `json:"dir"`
+	Root       string `json:"root"`
+}
+
+// newPackageCache loads the package cache of the working directory in the cache dir.
+// It returns nil if dir is empty or the key can't be computed, which disables the cache.
+func newPackageCache(dir string, logger logrus.FieldLogger) *packageCache {
+	if dir == "" {
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		logger.WithError(err).Warn("disable package cache")
+		return nil
+	}
+	key, err := packageCacheKey(wd)
+	if err != nil {
+		logger.WithError(err).Warn("disable package cache")
+		return nil
+	}
+
+	c := &packageCache{
+		file:    filepath.Join(dir, "packages-"+key+".json"),
+		entries: make(map[string]*packageEntry),
+		logger:  logger,
+	}
+	data, err := os.ReadFile(c.file)
+	if err == nil {
+		if err := json.Unmarshal(data, &c.entries); err != nil {
+			logger.WithError(err).Debugf("broken package cache %s", c.file)
+			c.entries = make(map[string]*packageEntry)
+		}
+	}
+	return c
+}
+
+// packageCacheKey returns the hash of what the packages are resolved with in the working directory: the go.mod
+// of the module, whose directory is found upward, the vendor directory, and the build environment.
+func packageCacheKey(wd string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", packageCacheVersion, wd)
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n", build.Default.GOROOT, build.Default.GOPATH, build.Default.GOOS, build.Default.GOARCH)
+	for _, env := range []string{"GOFLAGS", "GO111MODULE", "GOWORK"} {
+		fmt.Fprintf(h, "%s=%s\n", env, os.Getenv(env))
+	}
+
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			fmt.Fprintf(h, "%s\n%x\n", dir, sha256.Sum256(data))
+			if vendor, err := os.ReadFile(filepath.Join(dir, "vendor", "modules.txt")); err == nil {
+				fmt.Fprintf(h, "%x\n", sha256.Sum256(vendor))
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if filepath.Dir(dir) == dir {
+			// the packages are resolved in GOPATH mode without go.mod
+			break
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// get returns the package of the import path, the entry whose directory doesn't exist is a miss.
+func (c *packageCache) get(importPath string) (*build.Package, bool) {
+	if c == nil {
+		return nil, false
+	}
+	e, ok := c.entries[importPath]
+	if ok {
+		if _, err := os.Stat(e.Dir); err == nil {
+			c.hits++
+			return &build.Package{ImportPath: e.ImportPath, Dir: e.Dir, Root: e.Root}, true
+		}
+		delete(c.entries, importPath)
+		c.dirty = true
+	}
+	c.misses++
+	return nil, false
+}
+
+// put keeps the package of the import path.
+func (c *packageCache) put(importPath string, pkg *build.Package) {
+	if c == nil {
+		return
+	}
+	c.entries[importPath] = &packageEntry{ImportPath: pkg.ImportPath, Dir: pkg.Dir, Root: pkg.Root}
+	c.dirty = true
+}
+
+// save writes the entries if they're changed, the file is written to a temporary file then renamed,
+// so that the runs in parallel don't read a partial file.
+func (c *packageCache) save() {
+	if c == nil || !c.dirty {
+		return
+	}
+	c.logger.Debugf("package cache: %d hits, %d misses", c.hits, c.misses)
+	if err := c.write(); err != nil {
+		c.logger.WithError(err).Warnf("write package cache %s", c.file)
+		return
+	}
+	c.dirty = false
+}
+
+func (c *packageCache) write() error {
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.file), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(c.file), filepath.Base(c.file)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.file)
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageCache(t *testing.T) {
+	module := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/m\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(module, "foo"), 0755))
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(filepath.Join(module, "foo")))
+	defer os.Chdir(wd)
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	pkg := &build.Package{ImportPath: "example.com/m/foo", Dir: filepath.Join(module, "foo"), Root: module}
+
+	t.Run("disabled", func(t *testing.T) {
+		c := newPackageCache("", logrus.New())
+		assert.Nil(t, c)
+		c.put(pkg.ImportPath, pkg)
+		_, ok := c.get(pkg.ImportPath)
+		assert.False(t, ok)
+		c.save()
+	})
+
+	t.Run("across runs", func(t *testing.T) {
+		c := newPackageCache(dir, logrus.New())
+		_, ok := c.get(pkg.ImportPath)
+		assert.False(t, ok)
+		c.put(pkg.ImportPath, pkg)
+		c.save()
+
+		c = newPackageCache(dir, logrus.New())
+		got, ok := c.get(pkg.ImportPath)
+		assert.True(t, ok)
+		assert.Equal(t, pkg, got)
+		assert.Equal(t, 1, c.hits)
+	})
+
+	t.Run("go.mod changes", func(t *testing.T) {
+		before, err := packageCacheKey(filepath.Join(module, "foo"))
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/m\n\ngo 1.22\n"), 0644))
+		after, err := packageCacheKey(filepath.Join(module, "foo"))
+		assert.NoError(t, err)
+		assert.NotEqual(t, before, after)
+
+		c := newPackageCache(dir, logrus.New())
+		_, ok := c.get(pkg.ImportPath)
+		assert.False(t, ok)
+	})
+
+	t.Run("directory removed", func(t *testing.T) {
+		c := newPackageCache(dir, logrus.New())
+		removed := &build.Package{ImportPath: "example.com/m/bar", Dir: filepath.Join(module, "bar"), Root: module}
+		c.put(removed.ImportPath, removed)
+		c.save()
+
+		c = newPackageCache(dir, logrus.New())
+		_, ok := c.get(removed.ImportPath)
+		assert.False(t, ok)
+		assert.Equal(t, 1, c.misses)
+	})
+}
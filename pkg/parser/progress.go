@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"path"
+	"time"
+
+	"golang.org/x/tools/cover"
+)
+
+// Progress is the progress of converting the files of the cover profiles.
+type Progress struct {
+	// Done is the number of the files that are converted, including the skipped and failed ones.
+	Done int
+	// Total is the number of the files to convert, it's zero when it's unknown until the end,
+	// such as the changed files that are streamed.
+	Total int
+	// Package is the import path of the package of the last converted file.
+	Package string
+	// ETA is the estimated time to convert the rest of the files, it's zero when the total is unknown.
+	ETA time.Duration
+}
+
+// ProgressFunc is called with the progress after each file is converted, and once more when the conversion ends.
+type ProgressFunc func(Progress)
+
+// WithProgress calls fn with the progress of the conversion, so that a long run on a large repository can be tracked.
+// fn is called by the goroutine of Parse or Stream, it's called concurrently if the parser is used concurrently.
+func (parser *Parser) WithProgress(fn ProgressFunc) *Parser {
+	parser.progress = fn
+	return parser
+}
+
+// progressTracker tracks the files converted in a run, it's nil if the progress isn't reported.
+type progressTracker struct {
+	fn      ProgressFunc
+	total   int
+	done    int
+	pkg     string
+	start   time.Time
+	elapsed func(start time.Time) time.Duration
+}
+
+func newProgressTracker(fn ProgressFunc, total int) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	return &progressTracker{fn: fn, total: total, start: time.Now(), elapsed: time.Since}
+}
+
+// add reports the progress after the file of the profile is converted.
+func (t *progressTracker) add(p *cover.Profile) {
+	if t == nil {
+		return
+	}
+	t.done++
+	t.pkg = path.Dir(p.FileName)
+	t.fn(t.progress())
+}
+
+// finish reports the end of the conversion, whose total is the files converted if it's unknown.
+func (t *progressTracker) finish() {
+	if t == nil {
+		return
+	}
+	if t.total == 0 {
+		t.total = t.done
+	}
+	t.fn(t.progress())
+}
+
+func (t *progressTracker) progress() Progress {
+	p := Progress{Done: t.done, Total: t.total, Package: t.pkg}
+	if t.total > t.done && t.done > 0 {
+		p.ETA = t.elapsed(t.start) / time.Duration(t.done) * time.Duration(t.total-t.done)
+	}
+	return p
+}
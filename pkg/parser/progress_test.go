@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/cover"
+)
+
+func TestWithProgress(t *testing.T) {
+	changes := []*gittool.Change{
+		{FileName: "pkg/parser/parser.go", Mode: gittool.NewMode},
+		{FileName: "pkg/gocover/executor.go", Mode: gittool.NewMode},
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		var progress []Progress
+		_, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).
+			WithProgress(func(p Progress) { progress = append(progress, p) }).
+			Parse(context.Background(), changes)
+		assert.NoError(t, err)
+
+		// each file, then the end.
+		assert.Len(t, progress, 3)
+		assert.Equal(t, 1, progress[0].Done)
+		assert.Equal(t, 2, progress[0].Total)
+		assert.Equal(t, Progress{Done: 2, Total: 2, Package: progress[1].Package}, progress[2])
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var progress []Progress
+		stream, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).
+			WithProgress(func(p Progress) { progress = append(progress, p) }).
+			Stream()
+		assert.NoError(t, err)
+		for _, change := range changes {
+			assert.NoError(t, stream.Add(context.Background(), change))
+		}
+		_, err = stream.Packages()
+		assert.NoError(t, err)
+
+		// the total is unknown until the end.
+		assert.Len(t, progress, 3)
+		assert.Equal(t, 0, progress[1].Total)
+		assert.Equal(t, 2, progress[2].Done)
+		assert.Equal(t, 2, progress[2].Total)
+	})
+
+	t.Run("eta", func(t *testing.T) {
+		var last Progress
+		tracker := newProgressTracker(func(p Progress) { last = p }, 4)
+		tracker.elapsed = func(time.Time) time.Duration { return 10 * time.Second }
+
+		tracker.add(&cover.Profile{FileName: "github.com/foo/bar/a.go"})
+		assert.Equal(t, Progress{Done: 1, Total: 4, Package: "github.com/foo/bar", ETA: 30 * time.Second}, last)
+		tracker.add(&cover.Profile{FileName: "github.com/foo/baz/b.go"})
+		assert.Equal(t, Progress{Done: 2, Total: 4, Package: "github.com/foo/baz", ETA: 10 * time.Second}, last)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracker := newProgressTracker(nil, 1)
+		assert.Nil(t, tracker)
+		tracker.add(&cover.Profile{FileName: "github.com/foo/bar/a.go"})
+		tracker.finish()
+	})
+}
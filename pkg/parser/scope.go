@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// WithPackages scopes the packages of the cover profiles that are converted, a package is converted
+// when it matches any of the include patterns, or the include patterns are empty, and it matches none of the skip patterns.
+// The patterns are import paths with "..." wildcards like the go command, such as github.com/foo/service-a/...,
+// so that the packages of the other services in the same cover profile are not read at all.
+func (parser *Parser) WithPackages(include []string, skip []string) *Parser {
+	parser.includePackages = compilePackagePatterns(include)
+	parser.skipPackages = compilePackagePatterns(skip)
+	return parser
+}
+
+// inScope returns whether the package of the cover profile is converted.
+func (parser *Parser) inScope(p *cover.Profile) bool {
+	pkg := path.Dir(p.FileName)
+	if len(parser.includePackages) != 0 && !matchAnyPackage(parser.includePackages, pkg) {
+		return false
+	}
+	return !matchAnyPackage(parser.skipPackages, pkg)
+}
+
+// scopeCoverProfiles returns the cover profiles whose packages are in the scope.
+func (parser *Parser) scopeCoverProfiles(profiles []*cover.Profile) []*cover.Profile {
+	if len(parser.includePackages) == 0 && len(parser.skipPackages) == 0 {
+		return profiles
+	}
+
+	var result []*cover.Profile
+	for _, p := range profiles {
+		if parser.inScope(p) {
+			result = append(result, p)
+			continue
+		}
+		parser.logger.Debugf("skip the cover profile out of the package scope: %s", p.FileName)
+	}
+	parser.logger.Infof("%d of %d files in the cover profiles are in the package scope", len(result), len(profiles))
+	return result
+}
+
+// compilePackagePatterns compiles the import path patterns, "..." matches any string including the empty one,
+// and the pattern that ends with "/..." matches the package without the suffix too, e.g. net/... matches net and net/http.
+// This is the same as the package patterns of the go command.
+func compilePackagePatterns(patterns []string) []*regexp.Regexp {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		re := regexp.QuoteMeta(strings.TrimSuffix(pattern, "/"))
+		re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+		if strings.HasSuffix(re, `/.*`) {
+			re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+		}
+		result = append(result, regexp.MustCompile(`^`+re+`$`))
+	}
+	return result
+}
+
+func matchAnyPackage(patterns []*regexp.Regexp, pkg string) bool {
+	for _, re := range patterns {
+		if re.MatchString(pkg) {
+			return true
+		}
+	}
+	return false
+}
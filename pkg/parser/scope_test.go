@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePackagePatterns(t *testing.T) {
+	for _, testCase := range []struct {
+		pattern  string
+		matched  []string
+		excluded []string
+	}{
+		{
+			pattern:  "github.com/foo/service-a/...",
+			matched:  []string{"github.com/foo/service-a", "github.com/foo/service-a/api", "github.com/foo/service-a/api/v1"},
+			excluded: []string{"github.com/foo/service-ab", "github.com/foo/service-b/api"},
+		},
+		{
+			pattern:  "github.com/foo/.../mock",
+			matched:  []string{"github.com/foo/service-a/mock", "github.com/foo/x/y/mock"},
+			excluded: []string{"github.com/foo/service-a/mocks", "github.com/foo/mock"},
+		},
+		{
+			pattern:  "github.com/foo/service-a",
+			matched:  []string{"github.com/foo/service-a"},
+			excluded: []string{"github.com/foo/service-a/api"},
+		},
+		{
+			pattern: "...",
+			matched: []string{"github.com/foo", "main"},
+		},
+	} {
+		t.Run(testCase.pattern, func(t *testing.T) {
+			patterns := compilePackagePatterns([]string{testCase.pattern})
+			for _, pkg := range testCase.matched {
+				assert.True(t, matchAnyPackage(patterns, pkg), pkg)
+			}
+			for _, pkg := range testCase.excluded {
+				assert.False(t, matchAnyPackage(patterns, pkg), pkg)
+			}
+		})
+	}
+}
+
+func TestWithPackages(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(file, []byte("mode: set\n"+
+		"github.com/foo/service-a/api/api.go:1.10,3.2 1 1\n"+
+		"github.com/foo/service-a/mock/mock.go:1.10,3.2 1 0\n"+
+		"github.com/foo/service-b/b.go:1.10,3.2 1 1\n"), 0644))
+
+	for name, testCase := range map[string]struct {
+		include  []string
+		skip     []string
+		expected []string
+	}{
+		"all": {
+			expected: []string{"github.com/foo/service-a/api/api.go", "github.com/foo/service-a/mock/mock.go", "github.com/foo/service-b/b.go"},
+		},
+		"include": {
+			include:  []string{"github.com/foo/service-a/..."},
+			expected: []string{"github.com/foo/service-a/api/api.go", "github.com/foo/service-a/mock/mock.go"},
+		},
+		"include and skip": {
+			include:  []string{"github.com/foo/service-a/..."},
+			skip:     []string{"github.com/foo/.../mock"},
+			expected: []string{"github.com/foo/service-a/api/api.go"},
+		},
+		"skip": {
+			skip:     []string{"github.com/foo/service-a/..."},
+			expected: []string{"github.com/foo/service-b/b.go"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parser := NewParser([]string{file}, logrus.New()).WithPackages(testCase.include, testCase.skip)
+			profiles, err := parser.readCoverProfiles()
+			assert.NoError(t, err)
+
+			var files []string
+			for _, p := range profiles {
+				files = append(files, p.FileName)
+			}
+			assert.Equal(t, testCase.expected, files)
+		})
+	}
+}
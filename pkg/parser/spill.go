@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WithMemoryLimit spills the results of the files converted after the given number of statements to a temporary
+// directory, and they're read back after the cover profiles and the package information are released, so that the
+// converted results don't pile up alongside them. It doesn't bound the memory of a run, all the spilled results are
+// back in memory when the packages are returned for the statistics. Zero disables the spill.
+func (parser *Parser) WithMemoryLimit(statements int) *Parser {
+	parser.memoryLimit = statements
+	return parser
+}
+
+// spillStore keeps the conversion results of a run in a temporary directory once the statements in memory
+// reach the limit, it's nil if the limit is disabled.
+type spillStore struct {
+	limit      int
+	statements int
+	// spilling is set once the limit is reached, all the later results are spilled so that they are
+	// applied in the order of the cover profiles.
+	spilling bool
+	dir      string
+	entries  []*spilledResult
+}
+
+// spilledResult is a conversion result in the spill store.
+type spilledResult struct {
+	pkg  *Package
+	path string
+}
+
+func newSpillStore(limit int) *spillStore {
+	if limit <= 0 {
+		return nil
+	}
+	return &spillStore{limit: limit}
+}
+
+// keep returns the index of the spilled result, or -1 if the result is kept in memory.
+// The result is kept in memory if it cannot be spilled.
+func (s *spillStore) keep(pkg *Package, result *fileResult) (int, error) {
+	if s == nil {
+		return -1, nil
+	}
+
+	if !s.spilling {
+		for _, f := range result.Functions {
+			s.statements += len(f.Statements)
+		}
+		if s.statements <= s.limit {
+			return -1, nil
+		}
+		s.spilling = true
+	}
+
+	if s.dir == "" {
+		dir, err := os.MkdirTemp("", "gocover-spill-")
+		if err != nil {
+			return -1, fmt.Errorf("create spill directory: %w", err)
+		}
+		s.dir = dir
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%d.gob", len(s.entries)))
+	f, err := os.Create(path)
+	if err != nil {
+		return -1, fmt.Errorf("create spill file: %w", err)
+	}
+	defer f.Close()
+	if err := gob.NewEncoder(f).Encode(result); err != nil {
+		return -1, fmt.Errorf("spill result of package %s: %w", pkg.Name, err)
+	}
+
+	s.entries = append(s.entries, &spilledResult{pkg: pkg, path: path})
+	return len(s.entries) - 1, nil
+}
+
+// load reads back the spilled result of the index.
+func (s *spillStore) load(i int) (*Package, *fileResult, error) {
+	entry := s.entries[i]
+	f, err := os.Open(entry.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open spill file: %w", err)
+	}
+	defer f.Close()
+
+	result := &fileResult{}
+	if err := gob.NewDecoder(f).Decode(result); err != nil {
+		return nil, nil, fmt.Errorf("read spilled result of package %s: %w", entry.pkg.Name, err)
+	}
+	// the result is read only once.
+	os.Remove(entry.path)
+	return entry.pkg, result, nil
+}
+
+// close removes the spill directory.
+func (s *spillStore) close() {
+	if s == nil || s.dir == "" {
+		return
+	}
+	os.RemoveAll(s.dir)
+}
+
+// keepResult applies the result to the package, or spills it if the statements in memory reach the limit.
+func (parser *Parser) keepResult(pkg *Package, result *fileResult) {
+	i, err := parser.spill.keep(pkg, result)
+	if err != nil {
+		parser.logger.WithError(err).Warn("keep the result in memory")
+	}
+	if i < 0 {
+		parser.applyResult(pkg, result)
+	}
+}
+
+// applySpilled applies the spilled results in the order they're spilled, after the cover profiles
+// and the package information that are no longer needed are released.
+func (parser *Parser) applySpilled() error {
+	if parser.spill == nil || len(parser.spill.entries) == 0 {
+		return nil
+	}
+
+	parser.logger.Infof("read back %d results spilled over the limit of %d statements", len(parser.spill.entries), parser.spill.limit)
+	parser.coverProfiles = nil
+	parser.packagesCache = nil
+	for i := range parser.spill.entries {
+		pkg, result, err := parser.spill.load(i)
+		if err != nil {
+			return err
+		}
+		parser.applyResult(pkg, result)
+	}
+	return nil
+}
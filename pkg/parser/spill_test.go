@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMemoryLimit(t *testing.T) {
+	changes := []*gittool.Change{
+		{FileName: "pkg/parser/parser.go", Mode: gittool.NewMode},
+		{FileName: "pkg/gocover/executor.go", Mode: gittool.NewMode},
+	}
+	expected, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).Parse(context.Background(), changes)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, packageFunctions(expected))
+
+	t.Run("parse", func(t *testing.T) {
+		// the results after the first statement are spilled.
+		actual, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).WithMemoryLimit(1).Parse(context.Background(), changes)
+		assert.NoError(t, err)
+		assert.Equal(t, packageFunctions(expected), packageFunctions(actual))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		stream, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).WithMemoryLimit(1).Stream()
+		assert.NoError(t, err)
+		for _, change := range changes {
+			assert.NoError(t, stream.Add(context.Background(), change))
+		}
+		dir := stream.parser.spill.dir
+		assert.NotEmpty(t, dir)
+
+		actual, err := stream.Packages()
+		assert.NoError(t, err)
+		assert.Equal(t, packageFunctions(expected), packageFunctions(actual))
+		// the spill directory is removed at the end.
+		_, err = os.Stat(dir)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s := newSpillStore(0)
+		assert.Nil(t, s)
+		i, err := s.keep(&Package{}, &fileResult{})
+		assert.NoError(t, err)
+		assert.Equal(t, -1, i)
+		s.close()
+	})
+}
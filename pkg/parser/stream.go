@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"context"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"golang.org/x/tools/cover"
+)
+
+// Stream converts the cover profiles of the changed files as the changes are added,
+// so that the conversion runs while the rest of the changes are still computed by git diff.
+//
+// The results are applied in the order of the cover profiles, so the packages are the same
+// as what Parse returns for the same changes, whichever order the changes are added in.
+type Stream struct {
+	parser   *Parser
+	profiles []*cover.Profile
+	results  []*streamResult
+	// progress reports the files converted, the total is unknown until Packages as only the changed files are converted.
+	progress *progressTracker
+}
+
+// streamResult is the conversion result of a cover profile.
+type streamResult struct {
+	pkg    *Package
+	result *fileResult
+	// spilled is the index of the result in the spill store, it's -1 if the result is in memory.
+	spilled int
+}
+
+// Stream reads the cover profiles and returns the stream that converts them as the changes are added.
+// The stream has its own state of the conversion like Parse, so its skipped and failed files are returned by the stream.
+func (parser *Parser) Stream() (*Stream, error) {
+	run := parser.run()
+	profiles, err := run.readCoverProfiles()
+	if err != nil {
+		run.logger.WithError(err).Error("read cover profiles")
+		return nil, err
+	}
+	return &Stream{
+		parser:   run,
+		profiles: profiles,
+		results:  make([]*streamResult, len(profiles)),
+		progress: newProgressTracker(run.progress, 0),
+	}, nil
+}
+
+// Add converts the cover profiles that the change belongs to.
+// A cover profile is converted only once, with the first added change that it belongs to.
+// It stops with the error of the context once the context is done.
+func (s *Stream) Add(ctx context.Context, change *gittool.Change) error {
+	parser := s.parser
+	for i, p := range s.profiles {
+		if s.results[i] != nil || !InFolder(p.FileName, change.FileName) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := parser.cachePackage(p); err != nil {
+			if err := parser.tolerate(p, err); err != nil {
+				parser.logger.WithError(err).Error("build package cache")
+				return err
+			}
+			// the skipped profile is done without result.
+			s.results[i] = &streamResult{spilled: -1}
+			continue
+		}
+		pkg, result, err := parser.convertResult(p, change)
+		if err != nil {
+			if err := parser.tolerate(p, err); err != nil {
+				parser.logger.WithError(err).Error("covert cover profile")
+				return err
+			}
+			s.results[i] = &streamResult{spilled: -1}
+			s.progress.add(p)
+			continue
+		}
+		spilled, err := parser.spill.keep(pkg, result)
+		if err != nil {
+			parser.logger.WithError(err).Warn("keep the result in memory")
+		}
+		if spilled >= 0 {
+			result = nil
+		}
+		s.results[i] = &streamResult{pkg: pkg, result: result, spilled: spilled}
+		s.progress.add(p)
+	}
+	return nil
+}
+
+// Close removes the results spilled over the memory limit, it's called by Packages,
+// and it should be called if the stream is abandoned before Packages.
+func (s *Stream) Close() {
+	s.parser.spill.close()
+}
+
+// UnresolvedFiles returns the files that are skipped because they cannot be resolved, refer to Parser.UnresolvedFiles.
+func (s *Stream) UnresolvedFiles() []*SkippedFile {
+	return s.parser.UnresolvedFiles()
+}
+
+// FileErrors returns the errors of the files that fail to convert, refer to Parser.FileErrors.
+func (s *Stream) FileErrors() []*FileError {
+	return s.parser.FileErrors()
+}
+
+// Packages returns the packages converted from the cover profiles of the added changes,
+// it should be called once after all the changes are added. The spilled results are all read back into the packages.
+func (s *Stream) Packages() (Packages, error) {
+	s.progress.finish()
+	defer s.Close()
+	s.parser.packageCache.save()
+
+	// the spilled results are read back after the cover profiles are released.
+	s.profiles = nil
+	s.parser.packagesCache = nil
+	for _, r := range s.results {
+		if r == nil || r.pkg == nil {
+			continue
+		}
+		if r.spilled < 0 {
+			s.parser.applyResult(r.pkg, r.result)
+			continue
+		}
+		pkg, result, err := s.parser.spill.load(r.spilled)
+		if err != nil {
+			s.parser.logger.WithError(err).Error("apply spilled results")
+			return nil, err
+		}
+		s.parser.applyResult(pkg, result)
+	}
+	return s.parser.result(), nil
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	t.Run("stream converts the same packages as parse", func(t *testing.T) {
+		changes := []*gittool.Change{
+			{FileName: "pkg/parser/parser.go", Mode: gittool.NewMode},
+			{FileName: "pkg/gocover/executor.go", Mode: gittool.NewMode},
+		}
+
+		expected, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).Parse(context.Background(), changes)
+		assert.NoError(t, err)
+
+		stream, err := NewParser([]string{"testdata/cover.out"}, logrus.New()).Stream()
+		assert.NoError(t, err)
+		// add the changes in the reverse order
+		for i := len(changes) - 1; i >= 0; i-- {
+			assert.NoError(t, stream.Add(context.Background(), changes[i]))
+		}
+		actual, err := stream.Packages()
+		assert.NoError(t, err)
+
+		assert.NotEmpty(t, packageFunctions(expected))
+		assert.Equal(t, packageFunctions(expected), packageFunctions(actual))
+	})
+
+	t.Run("read cover profile fail", func(t *testing.T) {
+		_, err := NewParser([]string{"testdata/not-exist.out"}, logrus.New()).Stream()
+		assert.Error(t, err)
+	})
+}
+
+// packageFunctions returns the names of the functions of each package.
+func packageFunctions(packages Packages) map[string][]string {
+	result := make(map[string][]string)
+	for _, pkg := range packages {
+		var names []string
+		for _, f := range pkg.Functions {
+			names = append(names, f.File+":"+f.Name)
+		}
+		result[pkg.Name] = names
+	}
+	return result
+}
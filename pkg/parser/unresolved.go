@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"errors"
+
+	"golang.org/x/tools/cover"
+)
+
+// ErrUnresolvedFile is the error of the file in the cover profiles that cannot be found,
+// such as a file deleted after the tests or a file in the build cache.
+var ErrUnresolvedFile = errors.New("cannot resolve file")
+
+// WithSkipUnresolved skips the files of the cover profiles that cannot be resolved instead of failing the parse,
+// the skipped files are returned by UnresolvedFiles.
+func (parser *Parser) WithSkipUnresolved(skip bool) *Parser {
+	parser.skipUnresolved = skip
+	return parser
+}
+
+// UnresolvedFiles returns the files that are skipped because they cannot be resolved,
+// the files are named as they are in the cover profiles.
+func (parser *Parser) UnresolvedFiles() []*SkippedFile {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+	return parser.unresolvedFiles
+}
+
+// skip records the file of the profile as unresolved if the error is ErrUnresolvedFile and the files are skipped,
+// otherwise it returns the error.
+func (parser *Parser) skip(p *cover.Profile, err error) error {
+	if !parser.skipUnresolved || !errors.Is(err, ErrUnresolvedFile) {
+		return err
+	}
+	parser.logger.WithError(err).Warnf("skip the file that cannot be resolved: %s", p.FileName)
+	parser.unresolvedFiles = append(parser.unresolvedFiles, &SkippedFile{File: p.FileName, Reason: err.Error()})
+	return nil
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/gittool"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSkipUnresolved(t *testing.T) {
+	root := gittool.RealPath(t.TempDir())
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "pkg", "foo"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "foo", "foo.go"), []byte(`package foo
+
+func Foo() int {
+	return 1
+}
+`), 0644))
+
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"+
+		"example.com/bar/pkg/foo/deleted.go:3.16,5.2 1 1\n"+
+		"example.com/bar/pkg/foo/foo.go:3.16,5.2 1 1\n"+
+		"example.com/baz/pkg/gone/gone.go:3.16,5.2 1 0\n"), 0644))
+
+	newParser := func(skip bool) *Parser {
+		return NewParser([]string{profile}, logrus.New()).WithModuleRoot(root, "example.com/bar").WithSkipUnresolved(skip)
+	}
+
+	t.Run("fail on the unresolved file", func(t *testing.T) {
+		_, err := newParser(false).Parse(context.Background(), nil)
+		assert.True(t, errors.Is(err, ErrUnresolvedFile), err)
+	})
+
+	t.Run("skip the unresolved files", func(t *testing.T) {
+		parser := newParser(true)
+		packages, err := parser.Parse(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Len(t, packages, 1)
+		assert.Equal(t, "example.com/bar/pkg/foo", packages[0].Name)
+		assert.Len(t, packages[0].Functions, 1)
+
+		var files []string
+		for _, f := range parser.UnresolvedFiles() {
+			assert.NotEmpty(t, f.Reason)
+			files = append(files, f.File)
+		}
+		assert.ElementsMatch(t, []string{"example.com/bar/pkg/foo/deleted.go", "example.com/baz/pkg/gone/gone.go"}, files)
+	})
+
+	t.Run("skip the unresolved files in stream", func(t *testing.T) {
+		stream, err := newParser(true).Stream()
+		assert.NoError(t, err)
+		assert.NoError(t, stream.Add(context.Background(), &gittool.Change{FileName: "pkg/foo/deleted.go"}))
+		assert.NoError(t, stream.Add(context.Background(), &gittool.Change{FileName: "pkg/foo/foo.go"}))
+
+		packages, err := stream.Packages()
+		assert.NoError(t, err)
+		assert.Len(t, packages, 1)
+		assert.Len(t, stream.UnresolvedFiles(), 1)
+		assert.Equal(t, "example.com/bar/pkg/foo/deleted.go", stream.UnresolvedFiles()[0].File)
+	})
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// The kinds of the problems of the cover profiles.
+const (
+	MalformedProblem   = "malformed"
+	ModeProblem        = "mode"
+	OverlapProblem     = "overlap"
+	UnknownFileProblem = "unknown file"
+)
+
+// ProfileProblem is a problem found in a line of the cover profile.
+type ProfileProblem struct {
+	Line    int
+	Kind    string
+	Message string
+}
+
+func (p *ProfileProblem) String() string {
+	return fmt.Sprintf("%d: %s: %s", p.Line, p.Kind, p.Message)
+}
+
+// FileResolver returns the number of lines of the file in the cover profile,
+// it returns an error if the file cannot be found.
+type FileResolver func(file string) (lines int, err error)
+
+var (
+	modeLineRe    = regexp.MustCompile(`^mode: (.*)$`)
+	profileLineRe = regexp.MustCompile(`^(.+):([0-9]+)\.([0-9]+),([0-9]+)\.([0-9]+) ([0-9]+) ([0-9]+)$`)
+)
+
+// profileBlock is a block of the cover profile with the line of the cover profile it's in.
+type profileBlock struct {
+	line                                 int
+	startLine, startCol, endLine, endCol int
+	numStmt                              int
+}
+
+func (b *profileBlock) samePosition(o *profileBlock) bool {
+	return b.startLine == o.startLine && b.startCol == o.startCol && b.endLine == o.endLine && b.endCol == o.endCol
+}
+
+// endsAfter returns whether the block ends after the other block starts.
+func (b *profileBlock) endsAfter(line, col int) bool {
+	return b.endLine > line || b.endLine == line && b.endCol > col
+}
+
+// ValidateProfile checks the cover profile for the problems that fail or skew the coverage calculation,
+// such as the malformed lines, the mode lines that differ, the blocks that overlap, and the files that cannot be resolved.
+// The problems are sorted by line, the files are not resolved if resolve is nil.
+func ValidateProfile(r io.Reader, resolve FileResolver) ([]*ProfileProblem, error) {
+	var problems []*ProfileProblem
+	report := func(line int, kind, format string, args ...interface{}) {
+		problems = append(problems, &ProfileProblem{Line: line, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	mode, modeLine := "", 0
+	var files []string
+	blocks := make(map[string][]*profileBlock)
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if m := modeLineRe.FindStringSubmatch(line); m != nil {
+			switch {
+			case m[1] != "set" && m[1] != "count" && m[1] != "atomic":
+				report(n, ModeProblem, "unknown mode %q, should be set, count or atomic", m[1])
+			case modeLine == 0 && n != 1:
+				report(n, MalformedProblem, "mode line should be the first line")
+			case modeLine != 0 && m[1] != mode:
+				report(n, ModeProblem, "mode %s differs from mode %s at line %d, the profiles of different modes cannot be merged", m[1], mode, modeLine)
+			case modeLine != 0:
+				report(n, MalformedProblem, "repeated mode line, the mode lines of the concatenated profiles should be removed")
+			}
+			if modeLine == 0 {
+				mode, modeLine = m[1], n
+			}
+			continue
+		}
+		if n == 1 {
+			report(n, MalformedProblem, "missing mode line, the first line should be mode: set, count or atomic")
+		}
+
+		m := profileLineRe.FindStringSubmatch(line)
+		if m == nil {
+			report(n, MalformedProblem, "%q doesn't match {file}:{line}.{column},{line}.{column} {statements} {count}", line)
+			continue
+		}
+		b := &profileBlock{line: n}
+		// the numbers are matched by the regular expression, they overflow only if they're too large.
+		var err error
+		for i, v := range []*int{&b.startLine, &b.startCol, &b.endLine, &b.endCol, &b.numStmt} {
+			if *v, err = strconv.Atoi(m[i+2]); err != nil {
+				break
+			}
+		}
+		count, countErr := strconv.ParseInt(m[7], 10, 64)
+		if err != nil || countErr != nil {
+			report(n, MalformedProblem, "number out of range in %q", line)
+			continue
+		}
+		if b.startLine == 0 || b.endLine == 0 || b.startLine > b.endLine || b.startLine == b.endLine && b.startCol > b.endCol {
+			report(n, MalformedProblem, "block %d.%d,%d.%d ends before it starts", b.startLine, b.startCol, b.endLine, b.endCol)
+			continue
+		}
+		if mode == "set" && count > 1 {
+			report(n, ModeProblem, "count %d in set mode should be 0 or 1", count)
+		}
+
+		file := m[1]
+		if _, ok := blocks[file]; !ok {
+			files = append(files, file)
+		}
+		blocks[file] = append(blocks[file], b)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		problems = append(problems, validateBlocks(file, blocks[file], resolve)...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// validateBlocks checks the blocks of the file overlap and the file exists, the blocks at the same position are merged
+// by counts, which is valid as long as they have the same number of statements.
+func validateBlocks(file string, blocks []*profileBlock, resolve FileResolver) []*ProfileProblem {
+	var problems []*ProfileProblem
+
+	if resolve != nil {
+		lines, err := resolve(file)
+		if err != nil {
+			problems = append(problems, &ProfileProblem{Line: blocks[0].line, Kind: UnknownFileProblem, Message: fmt.Sprintf("%s: %s", file, err)})
+		} else {
+			for _, b := range blocks {
+				if b.endLine > lines {
+					problems = append(problems, &ProfileProblem{Line: b.line, Kind: UnknownFileProblem,
+						Message: fmt.Sprintf("block ends at line %d beyond the end of %s, which has %d lines", b.endLine, file, lines)})
+				}
+			}
+		}
+	}
+
+	sorted := make([]*profileBlock, len(blocks))
+	copy(sorted, blocks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		bi, bj := sorted[i], sorted[j]
+		return bi.startLine < bj.startLine || bi.startLine == bj.startLine && bi.startCol < bj.startCol
+	})
+	var last *profileBlock // the block that ends last among the blocks before
+	for _, b := range sorted {
+		switch {
+		case last == nil:
+		case b.samePosition(last):
+			if b.numStmt != last.numStmt {
+				problems = append(problems, &ProfileProblem{Line: b.line, Kind: OverlapProblem,
+					Message: fmt.Sprintf("%d statements differ from %d statements of the same block at line %d", b.numStmt, last.numStmt, last.line)})
+			}
+			continue
+		case last.endsAfter(b.startLine, b.startCol):
+			problems = append(problems, &ProfileProblem{Line: b.line, Kind: OverlapProblem,
+				Message: fmt.Sprintf("block %d.%d,%d.%d overlaps block %d.%d,%d.%d at line %d",
+					b.startLine, b.startCol, b.endLine, b.endCol, last.startLine, last.startCol, last.endLine, last.endCol, last.line)})
+		}
+		if last == nil || b.endsAfter(last.endLine, last.endCol) {
+			last = b
+		}
+	}
+	return problems
+}
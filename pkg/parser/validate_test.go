@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProfile(t *testing.T) {
+	resolve := func(file string) (int, error) {
+		if file == "github.com/foo/bar/missing.go" {
+			return 0, errors.New("no such file")
+		}
+		return 20, nil
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		problems, err := ValidateProfile(strings.NewReader(`mode: count
+github.com/foo/bar/a.go:3.10,5.2 2 1
+github.com/foo/bar/a.go:5.2,7.3 1 0
+github.com/foo/bar/a.go:3.10,5.2 2 4
+github.com/foo/bar/b.go:1.1,1.20 1 0
+`), resolve)
+		assert.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	for name, testCase := range map[string]struct {
+		profile  string
+		expected []*ProfileProblem
+	}{
+		"missing mode line": {
+			profile:  "github.com/foo/bar/a.go:3.10,5.2 2 1\n",
+			expected: []*ProfileProblem{{Line: 1, Kind: MalformedProblem}},
+		},
+		"unknown mode": {
+			profile:  "mode: sum\n",
+			expected: []*ProfileProblem{{Line: 1, Kind: ModeProblem}},
+		},
+		"malformed lines": {
+			profile: "mode: set\ngithub.com/foo/bar/a.go:3.10,5.2 2\n\ngithub.com/foo/bar/a.go:5.2,3.10 1 1\n",
+			expected: []*ProfileProblem{
+				{Line: 2, Kind: MalformedProblem},
+				{Line: 3, Kind: MalformedProblem},
+				{Line: 4, Kind: MalformedProblem},
+			},
+		},
+		"mode mismatch": {
+			profile: "mode: set\ngithub.com/foo/bar/a.go:3.10,5.2 2 3\nmode: atomic\nmode: set\n",
+			expected: []*ProfileProblem{
+				{Line: 2, Kind: ModeProblem},
+				{Line: 3, Kind: ModeProblem},
+				{Line: 4, Kind: MalformedProblem},
+			},
+		},
+		"overlap": {
+			profile: "mode: set\ngithub.com/foo/bar/a.go:3.10,9.2 2 1\ngithub.com/foo/bar/a.go:4.1,4.20 1 1\ngithub.com/foo/bar/a.go:3.10,9.2 1 1\n",
+			expected: []*ProfileProblem{
+				{Line: 3, Kind: OverlapProblem},
+				{Line: 4, Kind: OverlapProblem},
+			},
+		},
+		"unknown file": {
+			profile: "mode: set\ngithub.com/foo/bar/missing.go:3.10,9.2 2 1\ngithub.com/foo/bar/a.go:18.1,21.2 1 1\n",
+			expected: []*ProfileProblem{
+				{Line: 2, Kind: UnknownFileProblem},
+				{Line: 3, Kind: UnknownFileProblem},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			problems, err := ValidateProfile(strings.NewReader(testCase.profile), resolve)
+			assert.NoError(t, err)
+			for _, p := range problems {
+				assert.NotEmpty(t, p.Message)
+				p.Message = ""
+			}
+			assert.Equal(t, testCase.expected, problems)
+		})
+	}
+
+	t.Run("without resolving files", func(t *testing.T) {
+		problems, err := ValidateProfile(strings.NewReader("mode: set\ngithub.com/foo/bar/missing.go:3.10,9.2 2 1\n"), nil)
+		assert.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+}
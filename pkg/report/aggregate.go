@@ -0,0 +1,104 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AggregateStatistics represents the coverage rollup of several repositories.
+type AggregateStatistics struct {
+	// TotalEffectiveLines indicates effective lines of all the repositories.
+	TotalEffectiveLines int `json:"totalEffectiveLines"`
+	// TotalCoveredLines indicates covered lines of all the repositories that count for coverage.
+	TotalCoveredLines int `json:"totalCoveredLines"`
+	// TotalCoveragePercent represents the coverage percent (with ignorance) of all the repositories.
+	TotalCoveragePercent float64 `json:"totalCoveragePercent"`
+	// Teams represents the coverage of each team, it's empty if no repository is assigned to a team.
+	Teams []*TeamStatistics `json:"teams,omitempty"`
+	// Repositories represents the coverage of each repository.
+	Repositories []*RepositoryStatistics `json:"repositories"`
+}
+
+// TeamStatistics represents the coverage of the repositories owned by a team.
+type TeamStatistics struct {
+	// Team is the name of the team.
+	Team string `json:"team"`
+	// Repositories indicates how many repositories the team owns.
+	Repositories int `json:"repositories"`
+	// TotalEffectiveLines indicates effective lines of the repositories of the team.
+	TotalEffectiveLines int `json:"totalEffectiveLines"`
+	// TotalCoveredLines indicates covered lines of the repositories of the team.
+	TotalCoveredLines int `json:"totalCoveredLines"`
+	// TotalCoveragePercent represents the coverage percent (with ignorance) of the repositories of the team.
+	TotalCoveragePercent float64 `json:"totalCoveragePercent"`
+}
+
+// RepositoryStatistics represents the coverage of a repository.
+type RepositoryStatistics struct {
+	// ModulePath is the module path of the repository.
+	ModulePath string `json:"modulePath"`
+	// Team is the team that owns the repository.
+	Team string `json:"team,omitempty"`
+	// Commit is the commit that the coverage is calculated on.
+	Commit string `json:"commit,omitempty"`
+	// TotalEffectiveLines indicates effective lines of the repository.
+	TotalEffectiveLines int `json:"totalEffectiveLines"`
+	// TotalCoveredLines indicates covered lines of the repository.
+	TotalCoveredLines int `json:"totalCoveredLines"`
+	// TotalCoveragePercent represents the coverage percent (with ignorance) of the repository.
+	TotalCoveragePercent float64 `json:"totalCoveragePercent"`
+}
+
+// AggregateReportGenerator represents the feature that generate coverage rollup report.
+type AggregateReportGenerator interface {
+	GenerateAggregateReport(statistics *AggregateStatistics) error
+}
+
+// htmlAggregateReportGenerator implements a html style rollup report generator.
+type htmlAggregateReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ AggregateReportGenerator = (*htmlAggregateReportGenerator)(nil)
+
+// NewAggregateReportGenerator creates a html report generator to generate coverage rollup report.
+func NewAggregateReportGenerator(outputPath string, reportName string, logger logrus.FieldLogger) AggregateReportGenerator {
+	return &htmlAggregateReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		logger:     logger,
+	}
+}
+
+// GenerateAggregateReport generates the html rollup report.
+func (g *htmlAggregateReportGenerator) GenerateAggregateReport(statistics *AggregateStatistics) error {
+	reportFile := filepath.Join(g.outputPath, finalName(g.reportName))
+	f, err := os.Create(reportFile)
+	if err != nil {
+		return fmt.Errorf("create report file: %w", err)
+	}
+	defer f.Close()
+
+	if err := htmlAggregateReportTemplate.Execute(f, statistics); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+
+	g.logger.Infof("generate html aggregate coverage report: %s", reportFile)
+	return nil
+}
+
+// htmlAggregateReportTemplate is the render engine for html rollup report.
+var htmlAggregateReportTemplate = template.Must(
+	template.New("htmlAggregateReportTemplate").
+		Funcs(template.FuncMap{"HeatmapColor": heatmapColor}).
+		Parse(htmlAggregateReport),
+)
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// lineStateMarkers are the markers of the lines by their coverage states.
+var lineStateMarkers = map[string]string{
+	"covered":   "✓",
+	"uncovered": "✗",
+	"partial":   "◐",
+	"ignored":   "○",
+}
+
+// LineStateMarker returns the marker of the coverage state of a line, it's a space if the line has no statement.
+func LineStateMarker(state string) string {
+	if marker, ok := lineStateMarkers[state]; ok {
+		return marker
+	}
+	return " "
+}
+
+// annotatedDiffReportGenerator writes the unified diff of the changed files, each line has a marker
+// after the diff operation: the coverage state of the added line, or a space for the other lines.
+type annotatedDiffReportGenerator struct {
+	writer io.Writer
+}
+
+var _ ReportGenerator = (*annotatedDiffReportGenerator)(nil)
+
+// NewAnnotatedDiffReportGenerator creates a generator that writes the annotated unified diff to the writer.
+func NewAnnotatedDiffReportGenerator(writer io.Writer) ReportGenerator {
+	return &annotatedDiffReportGenerator{writer: writer}
+}
+
+// GenerateReport writes the diff of each changed file, the unchanged lines that are skipped separate the hunks.
+func (g *annotatedDiffReportGenerator) GenerateReport(statistics *Statistics) error {
+	for _, f := range statistics.DiffFiles {
+		if err := g.writeFile(f); err != nil {
+			return fmt.Errorf("write annotated diff: %w", err)
+		}
+	}
+	return nil
+}
+
+func (g *annotatedDiffReportGenerator) writeFile(f *DiffFile) error {
+	path := f.Path
+	if path == "" {
+		path = f.FileName
+	}
+	if _, err := fmt.Fprintf(g.writer, "--- a/%s\n+++ b/%s\n", path, path); err != nil {
+		return err
+	}
+
+	oldLine, newLine := 0, 0
+	for _, hunk := range diffHunks(f.Rows) {
+		oldStart, oldCount, newStart, newCount := oldLine, 0, newLine, 0
+		for _, row := range hunk {
+			if row.OldLine > 0 {
+				if oldCount == 0 {
+					oldStart = row.OldLine
+				}
+				oldCount++
+				oldLine = row.OldLine
+			}
+			if row.NewLine > 0 {
+				if newCount == 0 {
+					newStart = row.NewLine
+				}
+				newCount++
+				newLine = row.NewLine
+			}
+		}
+		if _, err := fmt.Fprintf(g.writer, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount); err != nil {
+			return err
+		}
+
+		// the deleted lines of the hunk are written before the added lines that replace them.
+		var added []*DiffRow
+		for _, row := range hunk {
+			if !row.Deleted && !row.Added {
+				if err := g.writeAdded(added); err != nil {
+					return err
+				}
+				added = nil
+				if _, err := fmt.Fprintf(g.writer, "  %s\n", row.New); err != nil {
+					return err
+				}
+				continue
+			}
+			if row.Deleted {
+				if _, err := fmt.Fprintf(g.writer, "- %s\n", row.Old); err != nil {
+					return err
+				}
+			}
+			if row.Added {
+				added = append(added, row)
+			}
+		}
+		if err := g.writeAdded(added); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *annotatedDiffReportGenerator) writeAdded(rows []*DiffRow) error {
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(g.writer, "+%s%s\n", LineStateMarker(row.State), row.New); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffHunks splits the rows into hunks by the skipped rows.
+func diffHunks(rows []*DiffRow) [][]*DiffRow {
+	var hunks [][]*DiffRow
+	var hunk []*DiffRow
+	for _, row := range rows {
+		if row.Skipped {
+			if len(hunk) > 0 {
+				hunks = append(hunks, hunk)
+			}
+			hunk = nil
+			continue
+		}
+		hunk = append(hunk, row)
+	}
+	if len(hunk) > 0 {
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
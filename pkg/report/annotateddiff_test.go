@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotatedDiffReportGenerator(t *testing.T) {
+	t.Run("annotate the added lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		g := NewAnnotatedDiffReportGenerator(&buf)
+		err := g.GenerateReport(&Statistics{DiffFiles: []*DiffFile{{
+			FileName: "github.com/foo/pkg/foo.go",
+			Path:     "pkg/foo.go",
+			Rows: []*DiffRow{
+				{Skipped: true},
+				{OldLine: 10, Old: "a", NewLine: 10, New: "a"},
+				{OldLine: 11, Old: "old", Deleted: true, NewLine: 11, New: "covered()", Added: true, State: "covered"},
+				{NewLine: 12, New: "uncovered()", Added: true, State: "uncovered"},
+				{NewLine: 13, New: "// comment", Added: true},
+				{OldLine: 12, Old: "b", NewLine: 14, New: "b"},
+				{Skipped: true},
+				{OldLine: 30, Old: "c", NewLine: 32, New: "c"},
+				{NewLine: 33, New: "ignored()", Added: true, State: "ignored"},
+				{OldLine: 31, Old: "gone", Deleted: true},
+			},
+		}}})
+		assert.NoError(t, err)
+		assert.Equal(t, "--- a/pkg/foo.go\n+++ b/pkg/foo.go\n"+
+			"@@ -10,3 +10,5 @@\n"+
+			"  a\n"+
+			"- old\n"+
+			"+✓covered()\n"+
+			"+✗uncovered()\n"+
+			"+ // comment\n"+
+			"  b\n"+
+			"@@ -30,2 +32,2 @@\n"+
+			"  c\n"+
+			"- gone\n"+
+			"+○ignored()\n", buf.String())
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		var buf bytes.Buffer
+		g := NewAnnotatedDiffReportGenerator(&buf)
+		err := g.GenerateReport(&Statistics{DiffFiles: []*DiffFile{{
+			FileName: "foo.go",
+			Rows:     []*DiffRow{{NewLine: 1, New: "package foo", Added: true}},
+		}}})
+		assert.NoError(t, err)
+		assert.Equal(t, "--- a/foo.go\n+++ b/foo.go\n@@ -0,0 +1,1 @@\n+ package foo\n", buf.String())
+	})
+}
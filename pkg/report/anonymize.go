@@ -0,0 +1,190 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// AnonymizeHash replaces each path segment and function name with its hash.
+	AnonymizeHash = "hash"
+	// AnonymizeAlias replaces each path segment and function name with a sequential alias.
+	AnonymizeAlias = "alias"
+
+	// hashLength is the length of the hex hash that replaces a name.
+	hashLength = 8
+)
+
+var ErrUnknownAnonymizeMode = errors.New(`supported anonymize modes are "hash" and "alias", unknown anonymize mode`)
+
+// Anonymizer hides the file paths, package names and function names in statistics,
+// so that the report can be shared without revealing the code structure.
+// The same name is always replaced with the same value, so the directory structure is kept.
+type Anonymizer struct {
+	mode    string
+	aliases map[string]string
+	counts  map[string]int
+}
+
+// NewAnonymizer creates an anonymizer of the mode, it returns nil if mode is empty, which disables anonymization.
+func NewAnonymizer(mode string) (*Anonymizer, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case AnonymizeHash, AnonymizeAlias:
+		return &Anonymizer{
+			mode:    mode,
+			aliases: make(map[string]string),
+			counts:  make(map[string]int),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAnonymizeMode, mode)
+	}
+}
+
+// Anonymize replaces the names in the statistics, and removes the source code of the violation sections.
+func (a *Anonymizer) Anonymize(s *Statistics) {
+	if a == nil {
+		return
+	}
+
+	for _, p := range s.CoverageProfile {
+		p.FileName = a.Path(p.FileName)
+		p.ViolationSections = nil
+		p.CodeSnippet = nil
+	}
+	for _, f := range s.SkippedFiles {
+		f.FileName = a.Path(f.FileName)
+	}
+	for _, f := range s.FileErrors {
+		f.FileName = a.Path(f.FileName)
+	}
+	for _, f := range s.ExcludedFunctions {
+		f.FileName = a.Path(f.FileName)
+		f.Function = a.name("func", f.Function)
+	}
+	for _, f := range s.Lines {
+		f.FileName = a.Path(f.FileName)
+	}
+	// the side-by-side diff contains the source code, which is dropped like the violation sections.
+	s.DiffFiles = nil
+	s.Packages = nil
+	// the path patterns of the teams reveal the code structure, the team names are kept.
+	for _, t := range s.Teams {
+		t.Paths = nil
+	}
+	// the directory tree is rebuilt from the anonymized file names.
+	if s.DirectoryTree != nil {
+		s.DirectoryTree = NewDirectoryTree(s.CoverageProfile)
+	}
+	for i, f := range s.ExcludeFiles {
+		s.ExcludeFiles[i] = a.Path(f)
+	}
+	for _, st := range s.HotStatements {
+		st.FileName = a.Path(st.FileName)
+		st.Function = a.name("func", st.Function)
+	}
+	if s.CoverageDelta != nil {
+		for _, c := range s.CoverageDelta.RegressedPackages {
+			c.Name = a.Path(c.Name)
+		}
+		for _, c := range s.CoverageDelta.RegressedFiles {
+			c.Name = a.Path(c.Name)
+		}
+		for _, r := range s.CoverageDelta.RenamedPackages {
+			r.From, r.To = a.Path(r.From), a.Path(r.To)
+		}
+	}
+	if s.Trend != nil {
+		// the first series is the total coverage, the others are named by the package patterns.
+		for _, series := range s.Trend.Series[1:] {
+			series.Name = a.pattern(series.Name)
+		}
+	}
+	for _, functions := range [][]*FunctionCoverage{s.LeastCoveredFunctions, s.HotFunctions, s.NeverCoveredFunctions} {
+		for _, f := range functions {
+			f.FileName = a.Path(f.FileName)
+			f.Function = a.name("func", f.Function)
+		}
+	}
+	for _, g := range s.LabelGaps {
+		g.FileName = a.Path(g.FileName)
+		g.Function = a.name("func", g.Function)
+	}
+	for _, c := range s.UncoveredCommits {
+		c.Author = a.name("author", c.Author)
+		for _, f := range c.Files {
+			f.FileName = a.Path(f.FileName)
+		}
+	}
+	if s.TestResults != nil {
+		for _, p := range s.TestResults.Packages {
+			p.Package = a.Path(p.Package)
+		}
+		// the output of the tests may contain the source code and the values, which are dropped.
+		for _, t := range s.TestResults.Tests {
+			t.Package = a.Path(t.Package)
+			t.Test = a.name("test", t.Test)
+			t.Message = ""
+			for _, g := range t.Gaps {
+				g.FileName = a.Path(g.FileName)
+				g.Function = a.name("func", g.Function)
+			}
+		}
+	}
+}
+
+// Path replaces each segment of the slash separated path, the extension of the file is kept.
+func (a *Anonymizer) Path(p string) string {
+	if a == nil {
+		return p
+	}
+
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		if i == len(segments)-1 {
+			ext := path.Ext(segment)
+			segments[i] = a.name("file", strings.TrimSuffix(segment, ext)) + ext
+			continue
+		}
+		segments[i] = a.name("dir", segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// pattern replaces the package pattern like Path, the "..." wildcard is kept.
+func (a *Anonymizer) pattern(p string) string {
+	if p == "..." {
+		return p
+	}
+	if strings.HasSuffix(p, "/...") {
+		return a.Path(strings.TrimSuffix(p, "/...")) + "/..."
+	}
+	return a.Path(p)
+}
+
+// name returns the replacement of the name, kind is the prefix of the alias.
+func (a *Anonymizer) name(kind string, name string) string {
+	key := kind + ":" + name
+	if v, ok := a.aliases[key]; ok {
+		return v
+	}
+
+	var v string
+	if a.mode == AnonymizeHash {
+		sum := sha256.Sum256([]byte(name))
+		v = hex.EncodeToString(sum[:])[:hashLength]
+	} else {
+		a.counts[kind]++
+		v = fmt.Sprintf("%s%d", kind, a.counts[kind])
+	}
+	a.aliases[key] = v
+	return v
+}
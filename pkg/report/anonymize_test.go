@@ -0,0 +1,98 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAnonymizer(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		a, err := NewAnonymizer("")
+		assert.NoError(t, err)
+		assert.Nil(t, a)
+		assert.Equal(t, "foo/bar.go", a.Path("foo/bar.go"))
+		a.Anonymize(&Statistics{})
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := NewAnonymizer("foo")
+		assert.ErrorIs(t, err, ErrUnknownAnonymizeMode)
+	})
+}
+
+func TestAnonymizer(t *testing.T) {
+	t.Run("alias", func(t *testing.T) {
+		a, err := NewAnonymizer(AnonymizeAlias)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "dir1/dir2/file1.go", a.Path("github.com/foo/bar.go"))
+		assert.Equal(t, "dir1/dir2/file2.go", a.Path("github.com/foo/zoo.go"))
+		assert.Equal(t, "dir1/dir3/file1.go", a.Path("github.com/bar/bar.go"))
+		assert.Equal(t, "dir1/dir2/file1.go", a.Path("github.com/foo/bar.go"))
+	})
+
+	t.Run("hash", func(t *testing.T) {
+		a, err := NewAnonymizer(AnonymizeHash)
+		assert.NoError(t, err)
+
+		p := a.Path("github.com/foo/bar.go")
+		assert.Regexp(t, `^[0-9a-f]{8}/[0-9a-f]{8}/[0-9a-f]{8}\.go$`, p)
+		assert.Equal(t, p, a.Path("github.com/foo/bar.go"))
+		assert.NotContains(t, p, "foo")
+	})
+
+	t.Run("anonymize statistics", func(t *testing.T) {
+		a, err := NewAnonymizer(AnonymizeAlias)
+		assert.NoError(t, err)
+
+		s := &Statistics{
+			CoverageProfile: []*CoverageProfile{{
+				FileName:          "github.com/foo/bar.go",
+				ViolationSections: []*ViolationSection{{Contents: []string{"func secret() {}"}}},
+			}},
+			SkippedFiles:          []*SkippedFile{{FileName: "github.com/foo/cgo.go"}},
+			ExcludedFunctions:     []*ExcludedFunction{{FileName: "github.com/foo/bar.go", Function: "secret", Pattern: "^secret$"}},
+			ExcludeFiles:          []string{"github.com/foo/mock.go"},
+			LeastCoveredFunctions: []*FunctionCoverage{{FileName: "github.com/foo/bar.go", Function: "secret"}},
+			NeverCoveredFunctions: []*FunctionCoverage{{FileName: "github.com/foo/bar.go", Function: "secret"}},
+			HotStatements:         []*HotStatement{{FileName: "github.com/foo/bar.go", Function: "secret"}},
+			DirectoryTree:         &DirectoryNode{Name: "github.com/foo"},
+			DiffFiles:             []*DiffFile{{FileName: "github.com/foo/bar.go"}},
+			Teams:                 []*TeamCoverage{{Team: "api", Paths: []string{"pkg/api/**"}}},
+		}
+		a.Anonymize(s)
+
+		assert.Equal(t, "dir1/dir2/file1.go", s.CoverageProfile[0].FileName)
+		assert.Nil(t, s.CoverageProfile[0].ViolationSections)
+		assert.Equal(t, "dir1/dir2/file2.go", s.SkippedFiles[0].FileName)
+		assert.Equal(t, "dir1/dir2/file1.go", s.ExcludedFunctions[0].FileName)
+		assert.Equal(t, "func1", s.ExcludedFunctions[0].Function)
+		assert.Equal(t, []string{"dir1/dir2/file3.go"}, s.ExcludeFiles)
+		assert.Equal(t, "func1", s.LeastCoveredFunctions[0].Function)
+		assert.Equal(t, "func1", s.NeverCoveredFunctions[0].Function)
+		assert.Equal(t, "dir1/dir2/file1.go", s.NeverCoveredFunctions[0].FileName)
+		assert.Equal(t, "func1", s.HotStatements[0].Function)
+		assert.Equal(t, "dir1/dir2", s.DirectoryTree.Name)
+		assert.Nil(t, s.DiffFiles)
+		assert.Equal(t, "api", s.Teams[0].Team)
+		assert.Nil(t, s.Teams[0].Paths)
+		assert.Equal(t, "dir1/dir2/file1.go", s.HotStatements[0].FileName)
+	})
+
+	t.Run("anonymize trend", func(t *testing.T) {
+		a, err := NewAnonymizer(AnonymizeAlias)
+		assert.NoError(t, err)
+
+		s := &Statistics{Trend: &CoverageTrend{Series: []*TrendSeries{
+			{Name: "total"}, {Name: "pkg/api/..."}, {Name: "pkg/parser"}, {Name: "..."},
+		}}}
+		a.Anonymize(s)
+
+		var names []string
+		for _, series := range s.Trend.Series {
+			names = append(names, series.Name)
+		}
+		assert.Equal(t, []string{"total", "dir1/file1/...", "dir1/file2", "..."}, names)
+	})
+}
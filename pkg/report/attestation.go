@@ -0,0 +1,287 @@
+package report
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	inTotoStatementType     = "https://in-toto.io/Statement/v1"
+	attestationPredicate    = "https://github.com/Azure/gocover/attestation/v1"
+	attestationPayloadType  = "application/vnd.in-toto+json"
+	attestationToolName     = "gocover"
+	attestationFileNameTail = "-attestation.json"
+)
+
+var (
+	ErrInvalidSigningKey    = errors.New("invalid signing key, it should be an ed25519 private key in PKCS #8 PEM format")
+	ErrSignatureNotVerified = errors.New("attestation signature is not verified")
+)
+
+// Provenance is the information of the run that produces the reports.
+type Provenance struct {
+	// ToolVersion is the version of gocover.
+	ToolVersion string
+	// Commit is the git commit that the coverage is collected on.
+	Commit string
+	// CoverProfiles are the cover profiles that the coverage is calculated from.
+	CoverProfiles []string
+}
+
+// attestationReportGenerator signs an in-toto statement about the reports in the output directory,
+// and writes it in a DSSE envelope, so that the consumers of the reports can verify that
+// the reports are produced by gocover from the cover profiles and are not modified.
+type attestationReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	provenance *Provenance
+	key        ed25519.PrivateKey
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*attestationReportGenerator)(nil)
+
+// NewAttestationReportGenerator creates the generator of the signed attestation,
+// it should run after the other report generators, as the reports are the subjects of the attestation.
+func NewAttestationReportGenerator(outputPath string, reportName string, provenance *Provenance, key ed25519.PrivateKey, logger logrus.FieldLogger) ReportGenerator {
+	return &attestationReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		provenance: provenance,
+		key:        key,
+		logger:     logger,
+	}
+}
+
+// Envelope is the DSSE envelope of the signed statement.
+// See https://github.com/secure-systems-lab/dsse for more information.
+type Envelope struct {
+	PayloadType string       `json:"payloadType"`
+	Payload     string       `json:"payload"`
+	Signatures  []*Signature `json:"signatures"`
+}
+
+// Signature is the signature of the envelope.
+type Signature struct {
+	// KeyID is the hex sha256 digest of the public key.
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Statement is the in-toto statement, whose subjects are the reports.
+type Statement struct {
+	Type          string                `json:"_type"`
+	Subject       []*AttestationSubject `json:"subject"`
+	PredicateType string                `json:"predicateType"`
+	Predicate     *AttestationPredicate `json:"predicate"`
+}
+
+// AttestationSubject is a file and its digests.
+type AttestationSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// AttestationPredicate is the provenance of the reports.
+type AttestationPredicate struct {
+	Tool            *AttestationTool      `json:"tool"`
+	Commit          string                `json:"commit,omitempty"`
+	CoverProfiles   []*AttestationSubject `json:"coverProfiles"`
+	StatisticsType  StatisticsType        `json:"statisticsType"`
+	ComparedBranch  string                `json:"comparedBranch,omitempty"`
+	CoveragePercent float64               `json:"coveragePercent"`
+}
+
+// AttestationTool identifies the tool that produces the reports.
+type AttestationTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// GenerateReport signs the statement about the reports and writes the envelope to {report-name}-attestation.json.
+func (g *attestationReportGenerator) GenerateReport(statistics *Statistics) error {
+	subjects, err := g.reports()
+	if err != nil {
+		return err
+	}
+
+	var profiles []*AttestationSubject
+	for _, profile := range g.provenance.CoverProfiles {
+		s, err := digestFile(profile, profile)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, s)
+	}
+
+	commit := g.provenance.Commit
+	if commit == "" && statistics.CI != nil {
+		commit = statistics.CI.Commit
+	}
+
+	statement := &Statement{
+		Type:          inTotoStatementType,
+		Subject:       subjects,
+		PredicateType: attestationPredicate,
+		Predicate: &AttestationPredicate{
+			Tool:            &AttestationTool{Name: attestationToolName, Version: g.provenance.ToolVersion},
+			Commit:          commit,
+			CoverProfiles:   profiles,
+			StatisticsType:  statistics.StatisticsType,
+			ComparedBranch:  statistics.ComparedBranch,
+			CoveragePercent: statistics.TotalCoveragePercent,
+		},
+	}
+
+	envelope, err := signStatement(statement, g.key)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	reportFile := filepath.Join(g.outputPath, attestationName(g.reportName))
+	if err := os.WriteFile(reportFile, data, 0644); err != nil {
+		return fmt.Errorf("write attestation: %w", err)
+	}
+	g.logger.Infof("generate attestation: %s", reportFile)
+	return nil
+}
+
+// reports returns the digests of the reports, which are the files in the output directory named after the report name.
+func (g *attestationReportGenerator) reports() ([]*AttestationSubject, error) {
+	entries, err := os.ReadDir(g.outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read output dir: %w", err)
+	}
+
+	subjects := []*AttestationSubject{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == attestationName(g.reportName) {
+			continue
+		}
+		if !strings.HasPrefix(name, g.reportName+".") && !strings.HasPrefix(name, g.reportName+"-") {
+			continue
+		}
+		s, err := digestFile(filepath.Join(g.outputPath, name), name)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, s)
+	}
+	sort.Slice(subjects, func(i, j int) bool { return subjects[i].Name < subjects[j].Name })
+	return subjects, nil
+}
+
+func attestationName(reportName string) string {
+	return reportName + attestationFileNameTail
+}
+
+func digestFile(path string, name string) (*AttestationSubject, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return &AttestationSubject{Name: name, Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])}}, nil
+}
+
+// signStatement signs the statement with the pre-authentication encoding of DSSE.
+func signStatement(statement *Statement, key ed25519.PrivateKey) (*Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+	sig := ed25519.Sign(key, preAuthEncoding(attestationPayloadType, payload))
+	return &Envelope{
+		PayloadType: attestationPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []*Signature{
+			{KeyID: keyID(key.Public().(ed25519.PublicKey)), Sig: base64.StdEncoding.EncodeToString(sig)},
+		},
+	}, nil
+}
+
+// VerifyAttestation verifies the signature of the envelope with the public key, and returns the statement.
+func VerifyAttestation(data []byte, publicKey ed25519.PublicKey) (*Statement, error) {
+	envelope := &Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	verified := false
+	for _, s := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(publicKey, preAuthEncoding(envelope.PayloadType, payload), sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrSignatureNotVerified
+	}
+
+	statement := &Statement{}
+	if err := json.Unmarshal(payload, statement); err != nil {
+		return nil, fmt.Errorf("json unmarshal statement: %w", err)
+	}
+	return statement, nil
+}
+
+// preAuthEncoding returns the message that is signed, see https://github.com/secure-systems-lab/dsse/blob/master/protocol.md.
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+func keyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadSigningKey loads the ed25519 private key in PKCS #8 PEM format, which is generated by
+// `openssl genpkey -algorithm ed25519 -out key.pem`.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidSigningKey
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSigningKey, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidSigningKey
+	}
+	return edKey, nil
+}
@@ -0,0 +1,89 @@
+package report
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttestationReportGenerator(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	profile := filepath.Join(t.TempDir(), "coverage.out")
+	assert.NoError(t, os.WriteFile(profile, []byte("mode: set\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "coverage.html"), []byte("<html></html>"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "coverage-junit.xml"), []byte("<testsuites/>"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.html"), []byte("<html></html>"), 0644))
+
+	g := NewAttestationReportGenerator(dir, "coverage", &Provenance{
+		ToolVersion:   "v1.0.0",
+		CoverProfiles: []string{profile},
+	}, privateKey, logrus.New())
+	err = g.GenerateReport(&Statistics{
+		StatisticsType:       DiffStatisticsType,
+		ComparedBranch:       "origin/master",
+		TotalCoveragePercent: 80,
+		CI:                   &ci.Environment{Commit: "abc123"},
+	})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "coverage-attestation.json"))
+	assert.NoError(t, err)
+
+	statement, err := VerifyAttestation(data, publicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, inTotoStatementType, statement.Type)
+	assert.Equal(t, attestationPredicate, statement.PredicateType)
+
+	sum := sha256.Sum256([]byte("<html></html>"))
+	assert.Equal(t, []*AttestationSubject{
+		{Name: "coverage-junit.xml", Digest: statement.Subject[0].Digest},
+		{Name: "coverage.html", Digest: map[string]string{"sha256": hex.EncodeToString(sum[:])}},
+	}, statement.Subject)
+
+	assert.Equal(t, &AttestationTool{Name: "gocover", Version: "v1.0.0"}, statement.Predicate.Tool)
+	assert.Equal(t, "abc123", statement.Predicate.Commit)
+	assert.Equal(t, profile, statement.Predicate.CoverProfiles[0].Name)
+	assert.Equal(t, DiffStatisticsType, statement.Predicate.StatisticsType)
+	assert.Equal(t, "origin/master", statement.Predicate.ComparedBranch)
+	assert.Equal(t, float64(80), statement.Predicate.CoveragePercent)
+
+	t.Run("tampered", func(t *testing.T) {
+		otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		_, err = VerifyAttestation(data, otherKey)
+		assert.ErrorIs(t, err, ErrSignatureNotVerified)
+	})
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	dir := t.TempDir()
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+	path := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	key, err := LoadSigningKey(path)
+	assert.NoError(t, err)
+	assert.Equal(t, privateKey, key)
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	assert.NoError(t, os.WriteFile(invalid, []byte("not a key"), 0600))
+	_, err = LoadSigningKey(invalid)
+	assert.ErrorIs(t, err, ErrInvalidSigningKey)
+}
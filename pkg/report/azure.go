@@ -0,0 +1,62 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// azureDevOpsReportName is the name of the cobertura report in the azure devops layout.
+	azureDevOpsReportName = "coverage"
+	// azureDevOpsHTMLDir is the directory of the html report in the azure devops layout.
+	azureDevOpsHTMLDir = "html"
+	// azureDevOpsHTMLName is the name of the html report, the code coverage tab opens index.html of the report directory.
+	azureDevOpsHTMLName = "index"
+)
+
+// azureDevOpsReportGenerator writes the reports in the layout that the PublishCodeCoverageResults task of
+// Azure Pipelines expects, the summary file is {dir}/coverage-cobertura.xml and the report directory is {dir}/html,
+// whose entry is index.html. See https://learn.microsoft.com/azure/devops/pipelines/tasks/reference/publish-code-coverage-results-v1.
+type azureDevOpsReportGenerator struct {
+	// outputPath is the directory of the layout, it's created if it doesn't exist.
+	outputPath string
+	generators ReportGenerator
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*azureDevOpsReportGenerator)(nil)
+
+// NewAzureDevOpsReportGenerator creates the generator of the cobertura report and the html report in the Azure DevOps layout,
+// it requires the state of each line in the statistics.
+func NewAzureDevOpsReportGenerator(codeStyle string, outputPath string, logger logrus.FieldLogger) ReportGenerator {
+	return &azureDevOpsReportGenerator{
+		outputPath: outputPath,
+		generators: NewReportGenerators(
+			NewCoberturaReportGenerator(outputPath, azureDevOpsReportName, logger),
+			NewReportGenerator(codeStyle, filepath.Join(outputPath, azureDevOpsHTMLDir), azureDevOpsHTMLName, logger),
+		),
+		logger: logger,
+	}
+}
+
+// GenerateReport creates the directories of the layout and writes the reports into them.
+func (g *azureDevOpsReportGenerator) GenerateReport(statistics *Statistics) error {
+	if err := os.MkdirAll(filepath.Join(g.outputPath, azureDevOpsHTMLDir), 0755); err != nil {
+		return fmt.Errorf("create azure devops report dir: %w", err)
+	}
+	if err := g.generators.GenerateReport(statistics); err != nil {
+		return err
+	}
+	g.logger.Infof("generate azure devops coverage reports, summary file: %s, report directory: %s",
+		azureDevOpsSummaryFile(g.outputPath), filepath.Join(g.outputPath, azureDevOpsHTMLDir))
+	return nil
+}
+
+// azureDevOpsSummaryFile returns the cobertura report in the Azure DevOps layout of the directory.
+func azureDevOpsSummaryFile(outputPath string) string {
+	return filepath.Join(outputPath, coberturaName(azureDevOpsReportName))
+}
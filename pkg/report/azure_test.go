@@ -0,0 +1,29 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAzureDevOpsReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "coverage")
+
+	g := NewAzureDevOpsReportGenerator("colorful", dir, logrus.New())
+	err := g.GenerateReport(&Statistics{
+		StatisticsType: FullStatisticsType,
+		Lines: []*FileLines{
+			{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", Covered: []int{3}, Uncovered: []int{4}},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dir, "coverage-cobertura.xml"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "html", "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "coverage-cobertura.xml"), azureDevOpsSummaryFile(dir))
+}
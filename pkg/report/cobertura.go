@@ -0,0 +1,196 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// coberturaDocType is the document type of the cobertura report.
+const coberturaDocType = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">` + "\n"
+
+// coberturaReportGenerator generates a cobertura XML report from the state of each line, so that the CI systems
+// that visualize cobertura results, such as GitLab and Azure Pipelines, can show the coverage.
+// Each package is a cobertura package and each file is a class, only the changed lines are reported in diff coverage.
+type coberturaReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*coberturaReportGenerator)(nil)
+
+// NewCoberturaReportGenerator creates a cobertura XML report generator, it requires the state of each line in the statistics.
+func NewCoberturaReportGenerator(outputPath string, reportName string, logger logrus.FieldLogger) ReportGenerator {
+	return &coberturaReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		logger:     logger,
+	}
+}
+
+// CoberturaCoverage is the root element of the cobertura XML report.
+type CoberturaCoverage struct {
+	XMLName         xml.Name            `xml:"coverage"`
+	LineRate        float64             `xml:"line-rate,attr"`
+	BranchRate      float64             `xml:"branch-rate,attr"`
+	LinesCovered    int                 `xml:"lines-covered,attr"`
+	LinesValid      int                 `xml:"lines-valid,attr"`
+	BranchesCovered int                 `xml:"branches-covered,attr"`
+	BranchesValid   int                 `xml:"branches-valid,attr"`
+	Complexity      float64             `xml:"complexity,attr"`
+	Version         string              `xml:"version,attr"`
+	Timestamp       int64               `xml:"timestamp,attr"`
+	Sources         []string            `xml:"sources>source"`
+	Packages        []*CoberturaPackage `xml:"packages>package"`
+}
+
+// CoberturaPackage represents the coverage of a package.
+type CoberturaPackage struct {
+	Name       string            `xml:"name,attr"`
+	LineRate   float64           `xml:"line-rate,attr"`
+	BranchRate float64           `xml:"branch-rate,attr"`
+	Complexity float64           `xml:"complexity,attr"`
+	Classes    []*CoberturaClass `xml:"classes>class"`
+}
+
+// CoberturaClass represents the coverage of a file.
+type CoberturaClass struct {
+	Name       string           `xml:"name,attr"`
+	FileName   string           `xml:"filename,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Methods    struct{}         `xml:"methods"`
+	Lines      []*CoberturaLine `xml:"lines>line"`
+}
+
+// CoberturaLine is the hit count of a line, the line is covered if its hit count is positive.
+type CoberturaLine struct {
+	Number int   `xml:"number,attr"`
+	Hits   int64 `xml:"hits,attr"`
+}
+
+// GenerateReport generates the cobertura XML report of the statistics.
+func (g *coberturaReportGenerator) GenerateReport(statistics *Statistics) error {
+	coverage := coberturaCoverage(statistics)
+	coverage.Timestamp = time.Now().UnixMilli()
+	data, err := xml.MarshalIndent(coverage, "", "  ")
+	if err != nil {
+		return fmt.Errorf("xml marshal: %w", err)
+	}
+
+	reportFile := filepath.Join(g.outputPath, coberturaName(g.reportName))
+	if err := os.WriteFile(reportFile, append([]byte(xml.Header+coberturaDocType), data...), 0644); err != nil {
+		return fmt.Errorf("write cobertura report: %w", err)
+	}
+
+	g.logger.Infof("generate cobertura coverage report: %s", reportFile)
+	return nil
+}
+
+func coberturaName(reportName string) string {
+	return fmt.Sprintf("%s-cobertura.xml", reportName)
+}
+
+// coberturaCoverage converts the state of each line into the cobertura coverage, the ignored lines are not reported.
+// The hit count of a line is the recorded one in count and atomic cover modes, otherwise it's 1 for the covered lines.
+func coberturaCoverage(statistics *Statistics) *CoberturaCoverage {
+	coverage := &CoberturaCoverage{Sources: []string{"."}}
+
+	packages := make(map[string]*CoberturaPackage)
+	for _, f := range statistics.Lines {
+		class := coberturaClass(f, statistics.StatisticsType == DiffStatisticsType)
+		if len(class.Lines) == 0 {
+			continue
+		}
+
+		name := path.Dir(f.FileName)
+		pkg, ok := packages[name]
+		if !ok {
+			pkg = &CoberturaPackage{Name: name}
+			packages[name] = pkg
+			coverage.Packages = append(coverage.Packages, pkg)
+		}
+		pkg.Classes = append(pkg.Classes, class)
+	}
+
+	for _, pkg := range coverage.Packages {
+		covered, valid := 0, 0
+		for _, class := range pkg.Classes {
+			covered += coveredCoberturaLines(class.Lines)
+			valid += len(class.Lines)
+		}
+		pkg.LineRate = lineRate(covered, valid)
+		coverage.LinesCovered += covered
+		coverage.LinesValid += valid
+	}
+	sort.Slice(coverage.Packages, func(i, j int) bool {
+		return coverage.Packages[i].Name < coverage.Packages[j].Name
+	})
+	coverage.LineRate = lineRate(coverage.LinesCovered, coverage.LinesValid)
+	return coverage
+}
+
+// coberturaClass converts the lines of a file, only the changed lines are converted if changedOnly is true.
+func coberturaClass(f *FileLines, changedOnly bool) *CoberturaClass {
+	changed := make(map[int]bool, len(f.Changed))
+	for _, line := range f.Changed {
+		changed[line] = true
+	}
+
+	var lines []*CoberturaLine
+	add := func(numbers []int, covered bool) {
+		for _, n := range numbers {
+			if changedOnly && !changed[n] {
+				continue
+			}
+			l := &CoberturaLine{Number: n}
+			if covered {
+				l.Hits = 1
+				if hits, ok := f.Hits[n]; ok {
+					l.Hits = hits
+				}
+			}
+			lines = append(lines, l)
+		}
+	}
+	add(f.Covered, true)
+	add(f.Partial, true)
+	add(f.Uncovered, false)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Number < lines[j].Number })
+
+	return &CoberturaClass{
+		Name:     path.Base(f.FileName),
+		FileName: f.FileName,
+		LineRate: lineRate(coveredCoberturaLines(lines), len(lines)),
+		Lines:    lines,
+	}
+}
+
+func coveredCoberturaLines(lines []*CoberturaLine) int {
+	covered := 0
+	for _, l := range lines {
+		if l.Hits > 0 {
+			covered++
+		}
+	}
+	return covered
+}
+
+// lineRate returns the proportion of the covered lines, it's 1 if there is no line.
+func lineRate(covered int, valid int) float64 {
+	if valid == 0 {
+		return 1
+	}
+	return float64(covered) / float64(valid)
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCoberturaReport(t *testing.T) {
+	path, clean := temporalDir()
+	defer clean()
+
+	g := NewCoberturaReportGenerator(path, "coverage", logrus.New())
+	err := g.GenerateReport(&Statistics{
+		StatisticsType: FullStatisticsType,
+		Lines: []*FileLines{
+			{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", Covered: []int{3}, Uncovered: []int{4}, Partial: []int{5}, Ignored: []int{6}},
+			{FileName: "github.com/Azure/gocover/pkg/bar/bar.go", Covered: []int{3}, Hits: map[int]int64{3: 7}},
+		},
+	})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(path, coberturaName("coverage")))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), xml.Header+coberturaDocType))
+
+	coverage := &CoberturaCoverage{}
+	assert.NoError(t, xml.Unmarshal(data, coverage))
+	assert.Equal(t, 3, coverage.LinesCovered)
+	assert.Equal(t, 4, coverage.LinesValid)
+	assert.Equal(t, 0.75, coverage.LineRate)
+	assert.Len(t, coverage.Packages, 2)
+
+	bar := coverage.Packages[0]
+	assert.Equal(t, "github.com/Azure/gocover/pkg/bar", bar.Name)
+	assert.Equal(t, []*CoberturaLine{{Number: 3, Hits: 7}}, bar.Classes[0].Lines)
+
+	foo := coverage.Packages[1]
+	assert.Equal(t, "github.com/Azure/gocover/pkg/foo", foo.Name)
+	assert.Equal(t, "foo.go", foo.Classes[0].Name)
+	assert.Equal(t, "github.com/Azure/gocover/pkg/foo/foo.go", foo.Classes[0].FileName)
+	assert.InDelta(t, 2.0/3, foo.LineRate, 1e-9)
+	assert.Equal(t, []*CoberturaLine{{Number: 3, Hits: 1}, {Number: 4, Hits: 0}, {Number: 5, Hits: 1}}, foo.Classes[0].Lines)
+}
+
+func TestCoberturaCoverage(t *testing.T) {
+	t.Run("only changed lines in diff coverage", func(t *testing.T) {
+		coverage := coberturaCoverage(&Statistics{
+			StatisticsType: DiffStatisticsType,
+			Lines: []*FileLines{
+				{FileName: "foo/foo.go", Covered: []int{3}, Uncovered: []int{4, 5}, Changed: []int{4}},
+				{FileName: "foo/bar.go", Covered: []int{3}},
+			},
+		})
+		assert.Equal(t, 0, coverage.LinesCovered)
+		assert.Equal(t, 1, coverage.LinesValid)
+		assert.Equal(t, float64(0), coverage.LineRate)
+		assert.Len(t, coverage.Packages, 1)
+		assert.Len(t, coverage.Packages[0].Classes, 1)
+	})
+
+	t.Run("no lines", func(t *testing.T) {
+		coverage := coberturaCoverage(&Statistics{StatisticsType: FullStatisticsType})
+		assert.Equal(t, float64(1), coverage.LineRate)
+		assert.Empty(t, coverage.Packages)
+	})
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+const customTemplateExtension = ".tmpl"
+
+// customTemplateFuncs are the functions of the built-in reports that the custom templates can use.
+var customTemplateFuncs = map[string]interface{}{
+	"IntsJoin":             IntsJoin,
+	"NormalizeLines":       normalizeLines,
+	"PercentCovered":       percentCovered,
+	"IsFullCoverageReport": isFullCoverageReport,
+	"IsDiffCoverageReport": isDiffCoverageReport,
+	"Heatmap":              heatmap,
+	"CISummary":            ciSummary,
+	"HasHits":              hasHits,
+	"HitsSummary":          hitsSummary,
+	"UncoveredLines":       uncoveredLines,
+}
+
+// customReportGenerator generates the reports from the user-supplied go templates, so that the teams
+// can generate the layouts of their own, such as an internal wiki page or a pull request comment.
+// The templates are executed with the statistics, whose Packages are the parsed cover profiles.
+type customReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// templates are the paths of the template files
+	templates []string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*customReportGenerator)(nil)
+
+// NewCustomReportGenerator creates the generator of the reports from the template files.
+// The report of a template is written to the output directory and named after the template without the .tmpl extension.
+func NewCustomReportGenerator(outputPath string, templates []string, logger logrus.FieldLogger) ReportGenerator {
+	return &customReportGenerator{
+		outputPath: outputPath,
+		templates:  templates,
+		logger:     logger,
+	}
+}
+
+// GenerateReport executes each template with the statistics.
+func (g *customReportGenerator) GenerateReport(statistics *Statistics) error {
+	for _, t := range g.templates {
+		if err := g.generate(t, statistics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *customReportGenerator) generate(templateFile string, statistics *Statistics) error {
+	data, err := os.ReadFile(templateFile)
+	if err != nil {
+		return fmt.Errorf("read template: %w", err)
+	}
+
+	name := customReportName(templateFile)
+	var buf bytes.Buffer
+	if err := executeCustomTemplate(&buf, name, string(data), statistics); err != nil {
+		return fmt.Errorf("template %s: %w", templateFile, err)
+	}
+
+	reportFile := filepath.Join(g.outputPath, name)
+	if err := os.WriteFile(reportFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write custom report: %w", err)
+	}
+	g.logger.Infof("generate custom coverage report: %s", reportFile)
+	return nil
+}
+
+// executeCustomTemplate parses the template as html/template if the report is an html file,
+// so that the contents are escaped, otherwise it's parsed as text/template.
+func executeCustomTemplate(w io.Writer, name string, text string, statistics *Statistics) error {
+	if filepath.Ext(name) == ".html" {
+		t, err := htmltemplate.New(name).Funcs(customTemplateFuncs).Parse(text)
+		if err != nil {
+			return fmt.Errorf("parse: %w", err)
+		}
+		return t.Execute(w, statistics)
+	}
+
+	t, err := texttemplate.New(name).Funcs(customTemplateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+	return t.Execute(w, statistics)
+}
+
+// customReportName returns the file name of the report of the template, such as wiki.md for wiki.md.tmpl.
+func customReportName(templateFile string) string {
+	return strings.TrimSuffix(filepath.Base(templateFile), customTemplateExtension)
+}
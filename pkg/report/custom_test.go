@@ -0,0 +1,51 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/parser"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCustomReport(t *testing.T) {
+	statistics := &Statistics{
+		StatisticsType:       FullStatisticsType,
+		TotalCoveragePercent: 50,
+		CoverageProfile: []*CoverageProfile{
+			{FileName: "foo/foo.go", ViolationSections: []*ViolationSection{{ViolationLines: []int{3, 4}}}},
+		},
+		Packages: parser.Packages{{Name: "foo", Functions: []*parser.Function{{Name: "Foo<T>"}}}},
+	}
+
+	t.Run("text and html templates", func(t *testing.T) {
+		dir := t.TempDir()
+		text := filepath.Join(dir, "wiki.md.tmpl")
+		assert.NoError(t, os.WriteFile(text, []byte(`{{ printf "%.1f" .TotalCoveragePercent }}{{ range .CoverageProfile }} {{ .FileName }}:{{ IntsJoin (UncoveredLines .) }}{{ end }}{{ range .Packages }}{{ range .Functions }} {{ .Name }}{{ end }}{{ end }}`), 0644))
+		html := filepath.Join(dir, "page.html.tmpl")
+		assert.NoError(t, os.WriteFile(html, []byte(`{{ range .Packages }}{{ range .Functions }}<b>{{ .Name }}</b>{{ end }}{{ end }}`), 0644))
+
+		output := t.TempDir()
+		g := NewCustomReportGenerator(output, []string{text, html}, logrus.New())
+		assert.NoError(t, g.GenerateReport(statistics))
+
+		data, err := os.ReadFile(filepath.Join(output, "wiki.md"))
+		assert.NoError(t, err)
+		assert.Equal(t, "50.0 foo/foo.go:3,4 Foo<T>", string(data))
+
+		data, err = os.ReadFile(filepath.Join(output, "page.html"))
+		assert.NoError(t, err)
+		assert.Equal(t, "<b>Foo&lt;T&gt;</b>", string(data))
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		dir := t.TempDir()
+		invalid := filepath.Join(dir, "invalid.tmpl")
+		assert.NoError(t, os.WriteFile(invalid, []byte(`{{ .Unknown `), 0644))
+
+		g := NewCustomReportGenerator(t.TempDir(), []string{invalid}, logrus.New())
+		assert.Error(t, g.GenerateReport(statistics))
+	})
+}
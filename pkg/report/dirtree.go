@@ -0,0 +1,125 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DirectoryNode represents the coverage of a directory and its sub directories,
+// the directories that have only one sub directory and no source file are joined into one node,
+// such as the module path github.com/Azure/gocover.
+type DirectoryNode struct {
+	// Name is the name of the directory relative to its parent.
+	Name string
+	// Path is the slash separated path of the directory.
+	Path string
+	// Files is the number of the source files in the directory and its sub directories.
+	Files int
+	// TotalEffectiveLines indicates effective lines of the directory and its sub directories.
+	TotalEffectiveLines int
+	// CoveredLines indicates covered lines of the directory and its sub directories, exclude the lines covered but ignored.
+	CoveredLines int
+	// CoveragePercent is the coverage percent (with ignorance) of the directory.
+	CoveragePercent float64
+	// Children are the sub directories, sorted by name.
+	Children []*DirectoryNode
+
+	files int                       // number of the source files directly in the directory
+	nodes map[string]*DirectoryNode // sub directories by name
+}
+
+// NewDirectoryTree aggregates the coverage profiles hierarchically by directory, it returns the root of the tree.
+func NewDirectoryTree(profiles []*CoverageProfile) *DirectoryNode {
+	root := &DirectoryNode{nodes: make(map[string]*DirectoryNode)}
+	for _, p := range profiles {
+		node := root
+		node.add(p)
+		segments := strings.Split(p.FileName, seperator)
+		for _, name := range segments[:len(segments)-1] {
+			if name == "" {
+				continue
+			}
+			child, ok := node.nodes[name]
+			if !ok {
+				child = &DirectoryNode{Name: name, Path: strings.TrimPrefix(node.Path+seperator+name, seperator), nodes: make(map[string]*DirectoryNode)}
+				node.nodes[name] = child
+			}
+			node = child
+			node.add(p)
+		}
+		node.files++
+	}
+
+	root.build()
+	// the root is replaced by the top directory when all the files are in it.
+	for root.files == 0 && len(root.Children) == 1 {
+		root = root.Children[0]
+	}
+	return root
+}
+
+// add adds the coverage of the file to the directory.
+func (n *DirectoryNode) add(p *CoverageProfile) {
+	n.Files++
+	n.TotalEffectiveLines += p.TotalEffectiveLines
+	n.CoveredLines += p.CoveredLines - p.CoveredButIgnoredLines
+}
+
+// build sorts the sub directories, joins the directories that have only one sub directory and no source file,
+// and calculates the coverage percent.
+func (n *DirectoryNode) build() {
+	n.CoveragePercent = percentCovered(n.TotalEffectiveLines, n.CoveredLines, 0)
+	for _, child := range n.nodes {
+		for child.files == 0 && len(child.nodes) == 1 {
+			for _, grandChild := range child.nodes {
+				grandChild.Name = child.Name + seperator + grandChild.Name
+				child = grandChild
+			}
+		}
+		child.build()
+		n.Children = append(n.Children, child)
+	}
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+}
+
+// directoryTreeReportGenerator prints the directory tree of the coverage with indentation, such as to the console.
+type directoryTreeReportGenerator struct {
+	writer io.Writer
+}
+
+var _ ReportGenerator = (*directoryTreeReportGenerator)(nil)
+
+// NewDirectoryTreeReportGenerator creates a generator that prints the directory tree of the coverage to the writer.
+func NewDirectoryTreeReportGenerator(writer io.Writer) ReportGenerator {
+	return &directoryTreeReportGenerator{writer: writer}
+}
+
+// GenerateReport prints a directory per line, indented by its depth in the tree.
+func (g *directoryTreeReportGenerator) GenerateReport(statistics *Statistics) error {
+	if statistics.DirectoryTree == nil {
+		return nil
+	}
+
+	var print func(n *DirectoryNode, depth int) error
+	print = func(n *DirectoryNode, depth int) error {
+		name := n.Name
+		if name == "" {
+			name = "."
+		}
+		if _, err := fmt.Fprintf(g.writer, "%s%s/ %.2f%% (%d/%d lines, %d files)\n",
+			strings.Repeat("  ", depth), name, n.CoveragePercent, n.CoveredLines, n.TotalEffectiveLines, n.Files); err != nil {
+			return fmt.Errorf("write directory tree: %w", err)
+		}
+		for _, child := range n.Children {
+			if err := print(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return print(statistics.DirectoryTree, 0)
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDirectoryTree(t *testing.T) {
+	t.Run("aggregate hierarchically", func(t *testing.T) {
+		root := NewDirectoryTree([]*CoverageProfile{
+			{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 10, CoveredLines: 5},
+			{FileName: "github.com/Azure/gocover/pkg/foo/zoo/zoo.go", TotalEffectiveLines: 10, CoveredLines: 10, CoveredButIgnoredLines: 2},
+			{FileName: "github.com/Azure/gocover/pkg/bar/internal/bar.go", TotalEffectiveLines: 20, CoveredLines: 2},
+		})
+
+		assert.Equal(t, "github.com/Azure/gocover/pkg", root.Name)
+		assert.Equal(t, "github.com/Azure/gocover/pkg", root.Path)
+		assert.Equal(t, 3, root.Files)
+		assert.Equal(t, 40, root.TotalEffectiveLines)
+		assert.Equal(t, 15, root.CoveredLines)
+		assert.Equal(t, 37.5, root.CoveragePercent)
+
+		assert.Len(t, root.Children, 2)
+		bar := root.Children[0]
+		assert.Equal(t, "bar/internal", bar.Name)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/bar/internal", bar.Path)
+		assert.Empty(t, bar.Children)
+
+		foo := root.Children[1]
+		assert.Equal(t, "foo", foo.Name)
+		assert.Equal(t, 2, foo.Files)
+		assert.Equal(t, 65.0, foo.CoveragePercent)
+		assert.Len(t, foo.Children, 1)
+		assert.Equal(t, "zoo", foo.Children[0].Name)
+		assert.Equal(t, 80.0, foo.Children[0].CoveragePercent)
+	})
+
+	t.Run("files in the root", func(t *testing.T) {
+		root := NewDirectoryTree([]*CoverageProfile{
+			{FileName: "main.go", TotalEffectiveLines: 4, CoveredLines: 4},
+			{FileName: "pkg/foo.go", TotalEffectiveLines: 4},
+		})
+		assert.Equal(t, "", root.Name)
+		assert.Equal(t, 2, root.Files)
+		assert.Len(t, root.Children, 1)
+		assert.Equal(t, "pkg", root.Children[0].Name)
+	})
+}
+
+func TestDirectoryTreeReportGenerator(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewDirectoryTreeReportGenerator(&buf)
+	assert.NoError(t, g.GenerateReport(&Statistics{}))
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, g.GenerateReport(&Statistics{DirectoryTree: NewDirectoryTree([]*CoverageProfile{
+		{FileName: "github.com/foo/pkg/a/a.go", TotalEffectiveLines: 4, CoveredLines: 1},
+		{FileName: "github.com/foo/pkg/b/b.go", TotalEffectiveLines: 4, CoveredLines: 4},
+	})}))
+	assert.Equal(t, "github.com/foo/pkg/ 62.50% (5/8 lines, 2 files)\n"+
+		"  a/ 25.00% (1/4 lines, 1 files)\n"+
+		"  b/ 100.00% (4/4 lines, 1 files)\n", buf.String())
+}
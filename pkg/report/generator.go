@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Azure/gocover/pkg/ci"
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/alecthomas/chroma/v2/lexers"
@@ -21,6 +22,26 @@ type ReportGenerator interface {
 	GenerateReport(statistics *Statistics) error
 }
 
+// reportGenerators generates the reports with each of the generators in order.
+type reportGenerators []ReportGenerator
+
+var _ ReportGenerator = (reportGenerators)(nil)
+
+// NewReportGenerators combines the generators into one report generator.
+func NewReportGenerators(generators ...ReportGenerator) ReportGenerator {
+	return reportGenerators(generators)
+}
+
+// GenerateReport generates the reports, it stops at the first generator that fails.
+func (generators reportGenerators) GenerateReport(statistics *Statistics) error {
+	for _, g := range generators {
+		if err := g.GenerateReport(statistics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // htmlReportGenerator implements a html style report generator.
 type htmlReportGenerator struct {
 	// lexer for parsing go code
@@ -151,16 +172,21 @@ func finalName(reportName string) string {
 // htmlCoverageReportTemplate is the render engine for html coverage report.
 var htmlCoverageReportTemplate = template.Must(
 	template.New("htmlReportTemplate").
-		Funcs(template.FuncMap{"IntsJoin": intsJoin}).
+		Funcs(template.FuncMap{"IntsJoin": IntsJoin}).
 		Funcs(template.FuncMap{"NormalizeLines": normalizeLines}).
 		Funcs(template.FuncMap{"PercentCovered": percentCovered}).
 		Funcs(template.FuncMap{"IsFullCoverageReport": isFullCoverageReport}).
 		Funcs(template.FuncMap{"IsDiffCoverageReport": isDiffCoverageReport}).
+		Funcs(template.FuncMap{"Heatmap": heatmap}).
+		Funcs(template.FuncMap{"CISummary": ciSummary}).
+		Funcs(template.FuncMap{"HasHits": hasHits}).
+		Funcs(template.FuncMap{"HitsSummary": hitsSummary}).
+		Funcs(template.FuncMap{"TrendSVG": trendSVG}).
 		Parse(htmlCoverageReport),
 )
 
-// intsJoin returns string that a int slice join with ,
-func intsJoin(inputs []int) string {
+// IntsJoin returns string that a int slice join with ,
+func IntsJoin(inputs []int) string {
 	var s []string
 	for _, i := range inputs {
 		s = append(s, fmt.Sprintf("%d", i))
@@ -189,6 +215,57 @@ func percentCovered(total, covered, coveredButIgnored int) float64 {
 	return percent
 }
 
+// uncoveredLines returns the lines that miss the coverage in the violation sections of the profile.
+func uncoveredLines(p *CoverageProfile) []int {
+	var lines []int
+	for _, section := range p.ViolationSections {
+		lines = append(lines, section.ViolationLines...)
+	}
+	return lines
+}
+
+// hasHits checks whether any of the functions has the hit counts, which are only collected in count and atomic cover modes.
+func hasHits(functions []*FunctionCoverage) bool {
+	for _, f := range functions {
+		if f.Hits != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hitsSummary returns the min, avg and max hit counts, it's "-" if there is no hit count.
+func hitsSummary(hits *HitCounts) string {
+	if hits == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d / %.1f / %d", hits.Min, hits.Avg, hits.Max)
+}
+
+// ciSummary returns the information of the CI run in a line, it's empty if it doesn't run in CI.
+func ciSummary(e *ci.Environment) string {
+	if e == nil {
+		return ""
+	}
+	var s []string
+	if e.Provider != "" {
+		s = append(s, string(e.Provider))
+	}
+	if e.Branch != "" {
+		s = append(s, fmt.Sprintf("branch %s", e.Branch))
+	}
+	if e.Commit != "" {
+		s = append(s, fmt.Sprintf("commit %s", e.Commit))
+	}
+	if e.PullRequest != "" {
+		s = append(s, fmt.Sprintf("pull request #%s", e.PullRequest))
+	}
+	if e.BuildID != "" {
+		s = append(s, fmt.Sprintf("build %s", e.BuildID))
+	}
+	return strings.Join(s, ", ")
+}
+
 func isFullCoverageReport(statisticsType StatisticsType) bool {
 	return statisticsType == FullStatisticsType
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Azure/gocover/pkg/ci"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/sirupsen/logrus"
@@ -55,14 +56,27 @@ func TestGenerateReport(t *testing.T) {
 		defer clean()
 
 		statistics := &Statistics{
-			StatisticsType:       DiffStatisticsType,
-			ComparedBranch:       "origin/master",
-			TotalLines:           8,
-			TotalEffectiveLines:  6,
-			TotalIgnoredLines:    2,
-			TotalViolationLines:  2,
-			TotalCoveragePercent: 70,
-			ExcludeFiles:         []string{"exclude.txt"},
+			StatisticsType:           DiffStatisticsType,
+			ComparedBranch:           "origin/master",
+			TotalLines:               8,
+			TotalEffectiveLines:      6,
+			TotalIgnoredLines:        2,
+			TotalViolationLines:      2,
+			TotalCoveragePercent:     70,
+			TotalWeakCoveredLines:    2,
+			TotalPartialCoveredLines: 1,
+			ExcludeFiles:             []string{"exclude.txt"},
+			SkippedFiles:             []*SkippedFile{{FileName: "cgo.go", Reason: "cgo file cannot be parsed"}},
+			LabelStatistics:          []*LabelStatistics{{Label: "integration", TotalCoveredLines: 4, TotalCoveragePercent: 50}},
+			LeastCoveredFunctions:    []*FunctionCoverage{{FileName: "bar.txt", Function: "barFunc", StartLine: 3, TotalEffectiveLines: 10, CoveredLines: 8, CoveragePercent: 80}},
+			NeverCoveredFunctions:    []*FunctionCoverage{{FileName: "bar.txt", Function: "deadFunc", StartLine: 9, TotalEffectiveLines: 2}},
+			NeverCoveredRuns:         5,
+			DirectoryTree:            &DirectoryNode{Name: "github.com/foo", Children: []*DirectoryNode{{Name: "bar", CoveragePercent: 75}}},
+			HotStatements:            []*HotStatement{{FileName: "foo.txt", Function: "fooFunc", StartLine: 12, EndLine: 13, Hits: 42}},
+			DiffFiles: []*DiffFile{{FileName: "zoo.txt", Rows: []*DiffRow{
+				{Skipped: true},
+				{OldLine: 2, Old: "old", Deleted: true, NewLine: 3, New: "new", Added: true, State: "uncovered"},
+			}}},
 			CoverageProfile: []*CoverageProfile{
 				{
 					FileName:            "foo.txt",
@@ -70,6 +84,14 @@ func TestGenerateReport(t *testing.T) {
 					TotalEffectiveLines: 20,
 					TotalIgnoredLines:   0,
 					CoveredLines:        20,
+					WeakCoveredLines:    []int{12, 15},
+				},
+				{
+					FileName:            "zoo.txt",
+					TotalLines:          4,
+					TotalEffectiveLines: 4,
+					CoveredLines:        4,
+					PartialCoveredLines: []int{3},
 				},
 				{
 					FileName:            "bar.txt",
@@ -128,7 +150,49 @@ func TestGenerateReport(t *testing.T) {
 		if !strings.Contains(string(data), "Exclude Files") {
 			t.Error("report should contain 'Exclude Files' header")
 		}
-		for _, v := range []string{"foo", "bar", "zoo", "text1", "text2", "text3", "foo.txt", "bar.txt"} {
+		if !strings.Contains(reportString, "Coverage by Directory") || !strings.Contains(reportString, "<b>bar/</b> 75.00%") {
+			t.Error("report should contain the directory tree")
+		}
+		if !strings.Contains(reportString, `<td class="deleted">old</td>`) || !strings.Contains(reportString, `<td class="uncovered">new</td>`) {
+			t.Error("report should contain the side-by-side diff")
+		}
+		if !strings.Contains(reportString, "Hot Paths") || !strings.Contains(reportString, "foo.txt:12-13") {
+			t.Error("report should contain the hot statements")
+		}
+		if !strings.Contains(reportString, "Partially Covered Lines") || !strings.Contains(reportString, "zoo.txt: 3") {
+			t.Error("report should contain the partially covered lines")
+		}
+		if !strings.Contains(string(data), "Skipped Files") {
+			t.Error("report should contain 'Skipped Files' header")
+		}
+		if !strings.Contains(string(data), "Coverage by Label") {
+			t.Error("report should contain 'Coverage by Label' header")
+		}
+		if !strings.Contains(string(data), "Least Covered Functions") {
+			t.Error("report should contain 'Least Covered Functions' header")
+		}
+		if !strings.Contains(string(data), "bar.txt:3") {
+			t.Error("report should contain the location of the least covered function")
+		}
+		if !strings.Contains(string(data), "Never Covered Functions") {
+			t.Error("report should contain 'Never Covered Functions' header")
+		}
+		if !strings.Contains(string(data), "no coverage in the last 5 runs") {
+			t.Error("report should contain the number of runs checked for never covered functions")
+		}
+		if !strings.Contains(string(data), "Coverage Heatmap") {
+			t.Error("report should contain 'Coverage Heatmap' header")
+		}
+		if !strings.Contains(string(data), "Weakly Covered Lines") {
+			t.Error("report should contain 'Weakly Covered Lines' header")
+		}
+		if !strings.Contains(string(data), "foo.txt: 12,15") {
+			t.Error("report should contain weakly covered lines of foo.txt")
+		}
+		if !strings.Contains(string(data), "integration") {
+			t.Error("report should contain label 'integration'")
+		}
+		for _, v := range []string{"foo", "bar", "zoo", "text1", "text2", "text3", "foo.txt", "bar.txt", "cgo.go", "cgo file cannot be parsed"} {
 			if !strings.Contains(reportString, v) {
 				t.Errorf("report should contain %s", v)
 			}
@@ -283,7 +347,7 @@ func TestProcessCodeSnippets(t *testing.T) {
 }
 
 func TestIntsJoin(t *testing.T) {
-	t.Run("intsJoin", func(t *testing.T) {
+	t.Run("IntsJoin", func(t *testing.T) {
 		testsuites := []struct {
 			expected string
 			input    []int
@@ -294,7 +358,7 @@ func TestIntsJoin(t *testing.T) {
 		}
 
 		for _, testcase := range testsuites {
-			actual := intsJoin(testcase.input)
+			actual := IntsJoin(testcase.input)
 			if testcase.expected != actual {
 				t.Errorf("expected %s, but get %s", testcase.expected, actual)
 			}
@@ -384,6 +448,30 @@ func TestIsFullCoverageReport(t *testing.T) {
 	})
 }
 
+func TestCISummary(t *testing.T) {
+	t.Run("ciSummary", func(t *testing.T) {
+		testSuites := []struct {
+			input  *ci.Environment
+			expect string
+		}{
+			{input: nil, expect: ""},
+			{input: &ci.Environment{Provider: ci.GitHubActions}, expect: "github-actions"},
+			{
+				input:  &ci.Environment{Provider: ci.GitLab, Branch: "feature", Commit: "0123456789", PullRequest: "12", BuildID: "345"},
+				expect: "gitlab, branch feature, commit 0123456789, pull request #12, build 345",
+			},
+			{input: &ci.Environment{BuildID: "345"}, expect: "build 345"},
+		}
+
+		for _, testcase := range testSuites {
+			actual := ciSummary(testcase.input)
+			if actual != testcase.expect {
+				t.Errorf("expect %s, but get %s", testcase.expect, actual)
+			}
+		}
+	})
+}
+
 func TestIsDiffCoverageReport(t *testing.T) {
 	t.Run("isDiffCoverageReport", func(t *testing.T) {
 		testSuites := []struct {
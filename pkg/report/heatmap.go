@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// HeatmapCell represents the coverage of a directory in the heatmap.
+type HeatmapCell struct {
+	// Directory is the directory that the source files belong to.
+	Directory string
+	// Files is the number of the source files in the directory.
+	Files int
+	// TotalEffectiveLines indicates effective lines of the directory.
+	TotalEffectiveLines int
+	// CoveredLines indicates covered lines of the directory, exclude the lines covered but ignored.
+	CoveredLines int
+	// CoveragePercent is the coverage percent (with ignorance) of the directory.
+	CoveragePercent float64
+	// Color is the background color of the cell, which goes from red to green as the coverage increases.
+	Color string
+}
+
+// heatmap aggregates the coverage profiles by directory, the cells are sorted by directory.
+func heatmap(profiles []*CoverageProfile) []*HeatmapCell {
+	cells := make(map[string]*HeatmapCell)
+	for _, p := range profiles {
+		dir := path.Dir(p.FileName)
+		cell, ok := cells[dir]
+		if !ok {
+			cell = &HeatmapCell{Directory: dir}
+			cells[dir] = cell
+		}
+		cell.Files++
+		cell.TotalEffectiveLines += p.TotalEffectiveLines
+		cell.CoveredLines += p.CoveredLines - p.CoveredButIgnoredLines
+	}
+
+	var result []*HeatmapCell
+	for _, cell := range cells {
+		cell.CoveragePercent = percentCovered(cell.TotalEffectiveLines, cell.CoveredLines, 0)
+		cell.Color = heatmapColor(cell.CoveragePercent)
+		result = append(result, cell)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Directory < result[j].Directory
+	})
+	return result
+}
+
+var (
+	heatmapLow    = [3]int{0xf8, 0x69, 0x6b} // red
+	heatmapMiddle = [3]int{0xff, 0xeb, 0x84} // yellow
+	heatmapHigh   = [3]int{0x63, 0xbe, 0x7b} // green
+)
+
+// heatmapColor returns the hex color of the coverage percent on a red-yellow-green color scale.
+func heatmapColor(percent float64) string {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	from, to, ratio := heatmapLow, heatmapMiddle, percent/50
+	if percent > 50 {
+		from, to, ratio = heatmapMiddle, heatmapHigh, (percent-50)/50
+	}
+
+	var c [3]int
+	for i := range c {
+		c[i] = from[i] + int(float64(to[i]-from[i])*ratio)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmap(t *testing.T) {
+	t.Run("aggregate by directory", func(t *testing.T) {
+		cells := heatmap([]*CoverageProfile{
+			{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 10, CoveredLines: 5},
+			{FileName: "github.com/Azure/gocover/pkg/bar/bar.go", TotalEffectiveLines: 20, CoveredLines: 20, CoveredButIgnoredLines: 2},
+			{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", TotalEffectiveLines: 10, CoveredLines: 1},
+		})
+
+		assert.Len(t, cells, 2)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/bar", cells[0].Directory)
+		assert.Equal(t, 1, cells[0].Files)
+		assert.Equal(t, 18, cells[0].CoveredLines)
+		assert.Equal(t, 90.0, cells[0].CoveragePercent)
+
+		assert.Equal(t, "github.com/Azure/gocover/pkg/foo", cells[1].Directory)
+		assert.Equal(t, 2, cells[1].Files)
+		assert.Equal(t, 20, cells[1].TotalEffectiveLines)
+		assert.Equal(t, 6, cells[1].CoveredLines)
+		assert.Equal(t, 30.0, cells[1].CoveragePercent)
+	})
+}
+
+func TestHeatmapColor(t *testing.T) {
+	t.Run("heatmapColor", func(t *testing.T) {
+		assert.Equal(t, "#f8696b", heatmapColor(0))
+		assert.Equal(t, "#ffeb84", heatmapColor(50))
+		assert.Equal(t, "#63be7b", heatmapColor(100))
+		assert.Equal(t, "#f8696b", heatmapColor(-1))
+		assert.Equal(t, "#63be7b", heatmapColor(120))
+	})
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/sirupsen/logrus"
+)
+
+// jsonReportGenerator generates a json report of the coverage statistics for the tools that consume the result,
+// the code snippets of the html report are not included.
+type jsonReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// algorithm compresses the report, the extension of the algorithm is appended to the report name
+	algorithm compression.Algorithm
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*jsonReportGenerator)(nil)
+
+// NewJSONReportGenerator creates a json report generator, the report is compressed with the algorithm.
+func NewJSONReportGenerator(outputPath string, reportName string, algorithm compression.Algorithm, logger logrus.FieldLogger) ReportGenerator {
+	return &jsonReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		algorithm:  algorithm,
+		logger:     logger,
+	}
+}
+
+// JSONReport is the contents of the json report.
+type JSONReport struct {
+	StatisticsType      StatisticsType      `json:"statisticsType"`
+	ComparedBranch      string              `json:"comparedBranch,omitempty"`
+	CI                  *ci.Environment     `json:"ci,omitempty"`
+	TotalEffectiveLines int                 `json:"totalEffectiveLines"`
+	TotalCoveredLines   int                 `json:"totalCoveredLines"`
+	TotalIgnoredLines   int                 `json:"totalIgnoredLines"`
+	CoveragePercent     float64             `json:"coveragePercent"`
+	RawCoveragePercent  float64             `json:"rawCoveragePercent"`
+	IgnorePolicy        string              `json:"ignorePolicy,omitempty"`
+	Files               []*JSONFileCoverage `json:"files"`
+	RunSummary          *JSONRunSummary     `json:"runSummary,omitempty"`
+	Labels              map[string]string   `json:"labels,omitempty"`
+	UncoveredCommits    []*UncoveredCommit  `json:"uncoveredCommits,omitempty"`
+	TestResults         *TestResults        `json:"testResults,omitempty"`
+}
+
+// JSONRunSummary is the aggregate statistics of the run in the json report.
+type JSONRunSummary struct {
+	Files                int     `json:"files"`
+	Functions            int     `json:"functions"`
+	Statements           int     `json:"statements"`
+	IgnoredStatements    int     `json:"ignoredStatements"`
+	ChangedStatements    int     `json:"changedStatements"`
+	SkippedFiles         int     `json:"skippedFiles"`
+	ParseDurationSeconds float64 `json:"parseDurationSeconds"`
+}
+
+// JSONFileCoverage is the coverage of a file in the json report.
+type JSONFileCoverage struct {
+	FileName        string  `json:"fileName"`
+	EffectiveLines  int     `json:"effectiveLines"`
+	CoveredLines    int     `json:"coveredLines"`
+	IgnoredLines    int     `json:"ignoredLines"`
+	CoveragePercent float64 `json:"coveragePercent"`
+	UncoveredLines  []int   `json:"uncoveredLines"`
+}
+
+// GenerateReport generates the json report of the statistics.
+func (g *jsonReportGenerator) GenerateReport(statistics *Statistics) error {
+	data, err := json.MarshalIndent(NewJSONReport(statistics), "", "  ")
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	reportFile, err := compression.WriteFile(filepath.Join(g.outputPath, jsonName(g.reportName)), data, 0644, g.algorithm)
+	if err != nil {
+		return fmt.Errorf("write json report: %w", err)
+	}
+
+	g.logger.Infof("generate json coverage report: %s", reportFile)
+	return nil
+}
+
+func jsonName(reportName string) string {
+	return fmt.Sprintf("%s.json", reportName)
+}
+
+// NewJSONReport converts the statistics into the json report, the covered lines are the ones that count for coverage.
+func NewJSONReport(statistics *Statistics) *JSONReport {
+	r := &JSONReport{
+		StatisticsType:      statistics.StatisticsType,
+		ComparedBranch:      statistics.ComparedBranch,
+		CI:                  statistics.CI,
+		TotalEffectiveLines: statistics.TotalEffectiveLines,
+		TotalCoveredLines:   statistics.TotalCoveredLines - statistics.TotalCoveredButIgnoredLines,
+		TotalIgnoredLines:   statistics.TotalIgnoredLines,
+		CoveragePercent:     statistics.TotalCoveragePercent,
+		RawCoveragePercent:  statistics.TotalCoverageWithoutIgnore,
+		IgnorePolicy:        statistics.IgnorePolicy,
+		Labels:              statistics.RunLabels,
+		Files:               []*JSONFileCoverage{},
+		UncoveredCommits:    statistics.UncoveredCommits,
+		TestResults:         statistics.TestResults,
+	}
+	for _, p := range statistics.CoverageProfile {
+		uncovered := uncoveredLines(p)
+		if uncovered == nil {
+			uncovered = []int{}
+		}
+		r.Files = append(r.Files, &JSONFileCoverage{
+			FileName:        p.FileName,
+			EffectiveLines:  p.TotalEffectiveLines,
+			CoveredLines:    p.CoveredLines - p.CoveredButIgnoredLines,
+			IgnoredLines:    p.TotalIgnoredLines,
+			CoveragePercent: percentCovered(p.TotalEffectiveLines, p.CoveredLines, p.CoveredButIgnoredLines),
+			UncoveredLines:  uncovered,
+		})
+	}
+	if s := statistics.RunSummary; s != nil {
+		r.RunSummary = &JSONRunSummary{
+			Files:                s.Files,
+			Functions:            s.Functions,
+			Statements:           s.Statements,
+			IgnoredStatements:    s.IgnoredStatements,
+			ChangedStatements:    s.ChangedStatements,
+			SkippedFiles:         s.SkippedFiles,
+			ParseDurationSeconds: s.ParseDuration.Seconds(),
+		}
+	}
+	return r
+}
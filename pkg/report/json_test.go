@@ -0,0 +1,80 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/gocover/pkg/compression"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateJSONReport(t *testing.T) {
+	path, clean := temporalDir()
+	defer clean()
+
+	g := NewJSONReportGenerator(path, "coverage", compression.None, logrus.New())
+	err := g.GenerateReport(&Statistics{
+		StatisticsType:              DiffStatisticsType,
+		ComparedBranch:              "origin/main",
+		TotalEffectiveLines:         4,
+		TotalCoveredLines:           3,
+		TotalCoveredButIgnoredLines: 1,
+		TotalIgnoredLines:           1,
+		TotalCoveragePercent:        50,
+		TotalCoverageWithoutIgnore:  60,
+		CoverageProfile: []*CoverageProfile{
+			{
+				FileName:               "github.com/Azure/gocover/pkg/foo/foo.go",
+				TotalEffectiveLines:    4,
+				CoveredLines:           3,
+				CoveredButIgnoredLines: 1,
+				TotalIgnoredLines:      1,
+				ViolationSections:      []*ViolationSection{{ViolationLines: []int{5, 7}}},
+			},
+			{FileName: "github.com/Azure/gocover/pkg/foo/bar.go", TotalEffectiveLines: 1, CoveredLines: 1},
+		},
+		RunSummary: &RunSummary{Files: 2, Functions: 3, Statements: 5, IgnoredStatements: 1, ChangedStatements: 5, ParseDuration: 1500 * time.Millisecond},
+		RunLabels:  map[string]string{"service": "api", "suite": "unit"},
+	})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(path, jsonName("coverage")))
+	assert.NoError(t, err)
+	r := &JSONReport{}
+	assert.NoError(t, json.Unmarshal(data, r))
+	assert.Equal(t, &JSONReport{
+		StatisticsType:      DiffStatisticsType,
+		ComparedBranch:      "origin/main",
+		TotalEffectiveLines: 4,
+		TotalCoveredLines:   2,
+		TotalIgnoredLines:   1,
+		CoveragePercent:     50,
+		RawCoveragePercent:  60,
+		Files: []*JSONFileCoverage{
+			{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", EffectiveLines: 4, CoveredLines: 2, IgnoredLines: 1, CoveragePercent: 50, UncoveredLines: []int{5, 7}},
+			{FileName: "github.com/Azure/gocover/pkg/foo/bar.go", EffectiveLines: 1, CoveredLines: 1, CoveragePercent: 100, UncoveredLines: []int{}},
+		},
+		RunSummary: &JSONRunSummary{Files: 2, Functions: 3, Statements: 5, IgnoredStatements: 1, ChangedStatements: 5, ParseDurationSeconds: 1.5},
+		Labels:     map[string]string{"service": "api", "suite": "unit"},
+	}, r)
+
+	t.Run("compressed", func(t *testing.T) {
+		g := NewJSONReportGenerator(path, "compressed", compression.Zstd, logrus.New())
+		assert.NoError(t, g.GenerateReport(&Statistics{StatisticsType: FullStatisticsType, TotalCoveragePercent: 75}))
+
+		data, err := compression.ReadFile(filepath.Join(path, jsonName("compressed")+compression.Zstd.Extension()))
+		assert.NoError(t, err)
+		r := &JSONReport{}
+		assert.NoError(t, json.Unmarshal(data, r))
+		assert.Equal(t, 75.0, r.CoveragePercent)
+	})
+}
+
+func TestRunSummaryString(t *testing.T) {
+	summary := &RunSummary{Files: 12, Functions: 80, Statements: 640, IgnoredStatements: 20, SkippedFiles: 1, ParseDuration: 1234567 * time.Microsecond}
+	assert.Equal(t, "12 files, 80 functions, 640 statements (20 ignored, 0 changed), 1 skipped files, parsed in 1.235s", summary.String())
+}
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// junitReportGenerator generates a JUnit XML report, so that the CI systems that visualize JUnit results
+// can show the coverage failures. For full coverage, each package is a test case,
+// for diff coverage, each changed file is a test case, the test case fails if its coverage is lower than the baseline.
+type junitReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// coverageBaseline is the threshold of each test case
+	coverageBaseline float64
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*junitReportGenerator)(nil)
+
+// NewJUnitReportGenerator creates a JUnit XML report generator.
+func NewJUnitReportGenerator(outputPath string, reportName string, coverageBaseline float64, logger logrus.FieldLogger) ReportGenerator {
+	return &junitReportGenerator{
+		outputPath:       outputPath,
+		reportName:       reportName,
+		coverageBaseline: coverageBaseline,
+		logger:           logger,
+	}
+}
+
+// JUnitTestSuites is the root element of the JUnit XML report.
+type JUnitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Suites   []*JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite contains the test cases of a coverage statistics.
+type JUnitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Properties []*JUnitProperty `xml:"properties>property,omitempty"`
+	TestCases  []*JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitProperty is a property of the test suite.
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitTestCase represents the coverage of a package or a file.
+type JUnitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure indicates the coverage of the test case is lower than the baseline.
+type JUnitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// GenerateReport generates the JUnit XML report of the statistics.
+func (g *junitReportGenerator) GenerateReport(statistics *Statistics) error {
+	suites := junitTestSuites(statistics, g.coverageBaseline)
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("xml marshal: %w", err)
+	}
+
+	reportFile := filepath.Join(g.outputPath, junitName(g.reportName))
+	if err := os.WriteFile(reportFile, append([]byte(xml.Header), data...), 0644); err != nil {
+		return fmt.Errorf("write junit report: %w", err)
+	}
+
+	g.logger.Infof("generate junit coverage report: %s", reportFile)
+	return nil
+}
+
+func junitName(reportName string) string {
+	return fmt.Sprintf("%s-junit.xml", reportName)
+}
+
+// junitTestSuites converts the statistics into a test suite.
+func junitTestSuites(statistics *Statistics, coverageBaseline float64) *JUnitTestSuites {
+	suite := &JUnitTestSuite{
+		Name: fmt.Sprintf("gocover %s coverage", statistics.StatisticsType),
+		Properties: []*JUnitProperty{
+			{Name: "coverageBaseline", Value: fmt.Sprintf("%.2f", coverageBaseline)},
+			{Name: "coveragePercent", Value: fmt.Sprintf("%.2f", statistics.TotalCoveragePercent)},
+		},
+	}
+	if statistics.ComparedBranch != "" {
+		suite.Properties = append(suite.Properties, &JUnitProperty{Name: "comparedBranch", Value: statistics.ComparedBranch})
+	}
+	if e := statistics.CI; e != nil {
+		for _, p := range []*JUnitProperty{
+			{Name: "ci.provider", Value: string(e.Provider)},
+			{Name: "ci.branch", Value: e.Branch},
+			{Name: "ci.commit", Value: e.Commit},
+			{Name: "ci.pullRequest", Value: e.PullRequest},
+			{Name: "ci.buildId", Value: e.BuildID},
+		} {
+			if p.Value != "" {
+				suite.Properties = append(suite.Properties, p)
+			}
+		}
+	}
+
+	if statistics.StatisticsType == DiffStatisticsType {
+		for _, p := range statistics.CoverageProfile {
+			percent := percentCovered(p.TotalEffectiveLines, p.CoveredLines, p.CoveredButIgnoredLines)
+			testCase := &JUnitTestCase{ClassName: path.Dir(p.FileName), Name: path.Base(p.FileName)}
+			if percent < coverageBaseline {
+				testCase.Failure = &JUnitFailure{
+					Message: fmt.Sprintf("coverage of changed lines is %.2f%%, lower than %.2f%%", percent, coverageBaseline),
+					Type:    "LowCoverage",
+					Contents: fmt.Sprintf("%s: %d of %d changed lines are covered, uncovered lines: %s",
+						p.FileName, p.CoveredLines-p.CoveredButIgnoredLines, p.TotalEffectiveLines, IntsJoin(uncoveredLines(p))),
+				}
+			}
+			suite.addTestCase(testCase)
+		}
+	} else {
+		for _, cell := range heatmap(statistics.CoverageProfile) {
+			testCase := &JUnitTestCase{ClassName: cell.Directory, Name: "coverage"}
+			if cell.CoveragePercent < coverageBaseline {
+				testCase.Failure = &JUnitFailure{
+					Message: fmt.Sprintf("coverage is %.2f%%, lower than %.2f%%", cell.CoveragePercent, coverageBaseline),
+					Type:    "LowCoverage",
+					Contents: fmt.Sprintf("%s: %d of %d lines in %d files are covered",
+						cell.Directory, cell.CoveredLines, cell.TotalEffectiveLines, cell.Files),
+				}
+			}
+			suite.addTestCase(testCase)
+		}
+	}
+
+	return &JUnitTestSuites{
+		Name:     "gocover",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []*JUnitTestSuite{suite},
+	}
+}
+
+func (s *JUnitTestSuite) addTestCase(testCase *JUnitTestCase) {
+	s.Tests++
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+	s.TestCases = append(s.TestCases, testCase)
+}
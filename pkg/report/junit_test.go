@@ -0,0 +1,79 @@
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitTestSuites(t *testing.T) {
+	t.Run("full coverage", func(t *testing.T) {
+		suites := junitTestSuites(&Statistics{
+			StatisticsType:       FullStatisticsType,
+			TotalCoveragePercent: 60,
+			CoverageProfile: []*CoverageProfile{
+				{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 30, CoveredLines: 30},
+				{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", TotalEffectiveLines: 10},
+				{FileName: "github.com/Azure/gocover/pkg/bar/bar.go", TotalEffectiveLines: 60, CoveredLines: 30},
+			},
+		}, 70)
+
+		assert.Equal(t, 2, suites.Tests)
+		assert.Equal(t, 1, suites.Failures)
+		testCases := suites.Suites[0].TestCases
+		assert.Equal(t, "github.com/Azure/gocover/pkg/bar", testCases[0].ClassName)
+		assert.Equal(t, "coverage is 50.00%, lower than 70.00%", testCases[0].Failure.Message)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/foo", testCases[1].ClassName)
+		assert.Nil(t, testCases[1].Failure)
+	})
+
+	t.Run("diff coverage", func(t *testing.T) {
+		suites := junitTestSuites(&Statistics{
+			StatisticsType: DiffStatisticsType,
+			ComparedBranch: "origin/main",
+			CoverageProfile: []*CoverageProfile{
+				{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 4, CoveredLines: 1, ViolationSections: []*ViolationSection{
+					{ViolationLines: []int{3}}, {ViolationLines: []int{5, 6}},
+				}},
+				{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", TotalEffectiveLines: 2, CoveredLines: 2},
+			},
+		}, 80)
+
+		assert.Equal(t, 2, suites.Tests)
+		assert.Equal(t, 1, suites.Failures)
+		testCases := suites.Suites[0].TestCases
+		assert.Equal(t, "foo.go", testCases[0].Name)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/foo/foo.go: 1 of 4 changed lines are covered, uncovered lines: 3,5,6", testCases[0].Failure.Contents)
+		assert.Nil(t, testCases[1].Failure)
+		assert.Contains(t, suites.Suites[0].Properties, &JUnitProperty{Name: "comparedBranch", Value: "origin/main"})
+	})
+}
+
+func TestGenerateJUnitReport(t *testing.T) {
+	t.Run("generate junit report", func(t *testing.T) {
+		path, clean := temporalDir()
+		defer clean()
+
+		g := NewJUnitReportGenerator(path, "coverage", 80, logrus.New())
+		err := g.GenerateReport(&Statistics{
+			StatisticsType: FullStatisticsType,
+			CoverageProfile: []*CoverageProfile{
+				{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 30, CoveredLines: 20},
+			},
+		})
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(path, junitName("coverage")))
+		assert.NoError(t, err)
+
+		var suites JUnitTestSuites
+		assert.NoError(t, xml.Unmarshal(data, &suites))
+		assert.Equal(t, 1, suites.Failures)
+		assert.Equal(t, "gocover full coverage", suites.Suites[0].Name)
+		assert.Equal(t, "LowCoverage", suites.Suites[0].TestCases[0].Failure.Type)
+	})
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/sirupsen/logrus"
+)
+
+// linesReportGenerator generates a compact json report of the state of each line,
+// so that the editor plugins show the coverage in the gutters without deriving the lines from statements.
+type linesReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*linesReportGenerator)(nil)
+
+// NewLinesReportGenerator creates a per-line json report generator.
+func NewLinesReportGenerator(outputPath string, reportName string, logger logrus.FieldLogger) ReportGenerator {
+	return &linesReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		logger:     logger,
+	}
+}
+
+// LinesReport is the contents of the per-line json report.
+type LinesReport struct {
+	StatisticsType StatisticsType  `json:"statisticsType"`
+	ComparedBranch string          `json:"comparedBranch,omitempty"`
+	CI             *ci.Environment `json:"ci,omitempty"`
+	Files          []*FileLines    `json:"files"`
+}
+
+// GenerateReport generates the per-line json report of the statistics.
+func (g *linesReportGenerator) GenerateReport(statistics *Statistics) error {
+	files := statistics.Lines
+	if files == nil {
+		files = []*FileLines{}
+	}
+	data, err := json.Marshal(&LinesReport{
+		StatisticsType: statistics.StatisticsType,
+		ComparedBranch: statistics.ComparedBranch,
+		CI:             statistics.CI,
+		Files:          files,
+	})
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	reportFile := filepath.Join(g.outputPath, linesName(g.reportName))
+	if err := os.WriteFile(reportFile, data, 0644); err != nil {
+		return fmt.Errorf("write lines report: %w", err)
+	}
+
+	g.logger.Infof("generate per-line coverage report: %s", reportFile)
+	return nil
+}
+
+func linesName(reportName string) string {
+	return fmt.Sprintf("%s-lines.json", reportName)
+}
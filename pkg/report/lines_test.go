@@ -0,0 +1,48 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateLinesReport(t *testing.T) {
+	t.Run("generate lines report", func(t *testing.T) {
+		path, clean := temporalDir()
+		defer clean()
+
+		g := NewLinesReportGenerator(path, "coverage", logrus.New())
+		err := g.GenerateReport(&Statistics{
+			StatisticsType: DiffStatisticsType,
+			ComparedBranch: "origin/main",
+			Lines: []*FileLines{{
+				FileName:  "github.com/Azure/gocover/pkg/foo/foo.go",
+				Covered:   []int{3},
+				Uncovered: []int{4, 5},
+				Ignored:   []int{},
+				Partial:   []int{3},
+				Changed:   []int{4},
+			}},
+		})
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(path, linesName("coverage")))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"statisticsType":"diff","comparedBranch":"origin/main","files":[{"fileName":"github.com/Azure/gocover/pkg/foo/foo.go","covered":[3],"uncovered":[4,5],"ignored":[],"partial":[3],"changed":[4]}]}`, string(data))
+	})
+
+	t.Run("no lines", func(t *testing.T) {
+		path, clean := temporalDir()
+		defer clean()
+
+		g := NewLinesReportGenerator(path, "coverage", logrus.New())
+		assert.NoError(t, g.GenerateReport(&Statistics{StatisticsType: FullStatisticsType}))
+
+		data, err := os.ReadFile(filepath.Join(path, linesName("coverage")))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"statisticsType":"full","files":[]}`, string(data))
+	})
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stepSummaryReportGenerator appends the markdown report to the job summary of GitHub Actions,
+// so that the coverage summary is rendered on the summary page of the run.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary.
+type stepSummaryReportGenerator struct {
+	// summaryFile is the file that GITHUB_STEP_SUMMARY points to
+	summaryFile string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*stepSummaryReportGenerator)(nil)
+
+// NewStepSummaryReportGenerator creates a generator that appends the markdown report to the summary file.
+func NewStepSummaryReportGenerator(summaryFile string, logger logrus.FieldLogger) ReportGenerator {
+	return &stepSummaryReportGenerator{
+		summaryFile: summaryFile,
+		logger:      logger,
+	}
+}
+
+// GenerateReport appends the markdown report of the statistics to the summary file.
+// The step summary could contain the reports of other steps, so the file is not truncated.
+func (g *stepSummaryReportGenerator) GenerateReport(statistics *Statistics) error {
+	f, err := os.OpenFile(g.summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open step summary file: %w", err)
+	}
+
+	if err := writeMarkdownReport(f, statistics); err != nil {
+		f.Close()
+		return fmt.Errorf("write step summary: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close step summary file: %w", err)
+	}
+
+	g.logger.Infof("write markdown coverage report to step summary: %s", g.summaryFile)
+	return nil
+}
+
+// markdownReportGenerator writes the markdown report to a file in the output directory,
+// such as for the comment of a pull request.
+type markdownReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*markdownReportGenerator)(nil)
+
+// NewMarkdownReportGenerator creates a markdown report generator.
+func NewMarkdownReportGenerator(outputPath string, reportName string, logger logrus.FieldLogger) ReportGenerator {
+	return &markdownReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		logger:     logger,
+	}
+}
+
+// GenerateReport writes the markdown report of the statistics to {report-name}.md.
+func (g *markdownReportGenerator) GenerateReport(statistics *Statistics) error {
+	reportFile := filepath.Join(g.outputPath, markdownName(g.reportName))
+	f, err := os.Create(reportFile)
+	if err != nil {
+		return fmt.Errorf("create markdown report: %w", err)
+	}
+	defer f.Close()
+
+	if err := writeMarkdownReport(f, statistics); err != nil {
+		return fmt.Errorf("write markdown report: %w", err)
+	}
+
+	g.logger.Infof("generate markdown coverage report: %s", reportFile)
+	return nil
+}
+
+func markdownName(reportName string) string {
+	return fmt.Sprintf("%s.md", reportName)
+}
+
+// writeMarkdownReport writes the markdown report of the statistics.
+func writeMarkdownReport(w io.Writer, statistics *Statistics) error {
+	return markdownCoverageReportTemplate.Execute(w, statistics)
+}
+
+// markdownCoverageReportTemplate is the render engine for markdown coverage report.
+var markdownCoverageReportTemplate = template.Must(
+	template.New("markdownReportTemplate").
+		Funcs(template.FuncMap{"PercentCovered": percentCovered}).
+		Funcs(template.FuncMap{"IsFullCoverageReport": isFullCoverageReport}).
+		Funcs(template.FuncMap{"Heatmap": heatmap}).
+		Funcs(template.FuncMap{"CISummary": ciSummary}).
+		Funcs(template.FuncMap{"HasHits": hasHits}).
+		Funcs(template.FuncMap{"HitsSummary": hitsSummary}).
+		Funcs(template.FuncMap{"UncoveredLines": func(p *CoverageProfile) string { return IntsJoin(uncoveredLines(p)) }}).
+		Parse(markdownCoverageReport),
+)
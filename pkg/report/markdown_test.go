@@ -0,0 +1,221 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMarkdownReport(t *testing.T) {
+	t.Run("full coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType:       FullStatisticsType,
+			TotalEffectiveLines:  40,
+			TotalCoveredLines:    30,
+			TotalCoveragePercent: 75,
+			IgnorePolicy:         "count",
+			CoverageProfile: []*CoverageProfile{
+				{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 30, CoveredLines: 30},
+				{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", TotalEffectiveLines: 10},
+			},
+			LabelStatistics:   []*LabelStatistics{{Label: "unit", TotalCoveredLines: 20, TotalCoveragePercent: 50}},
+			ClosureStatistics: &ClosureStatistics{Closures: 2, TotalEffectiveLines: 8, TotalCoveredLines: 2, TotalCoveragePercent: 25},
+			LeastCoveredFunctions: []*FunctionCoverage{
+				{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", Function: "Zoo", StartLine: 3, TotalEffectiveLines: 10},
+			},
+			ExcludedFunctions: []*ExcludedFunction{
+				{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", Function: "Zoo.String", Pattern: "String$", TotalLines: 2},
+			},
+		})
+		assert.NoError(t, err)
+
+		report := buf.String()
+		assert.True(t, strings.HasPrefix(report, "## Full Coverage Report\n"))
+		assert.Contains(t, report, "| **75.00** | 0.00 | 40 | 30 | 0 |")
+		assert.Contains(t, report, "Ignore policy: `count`, the ignored statements count for coverage as the other statements.")
+		assert.Contains(t, report, "| unit | 50.00 | 20 |")
+		assert.Contains(t, report, "| 2 | 8 | 2 | 25.00 |")
+		assert.Contains(t, report, "| github.com/Azure/gocover/pkg/foo | 2 | 40 | 30 | 75.00 |")
+		assert.Contains(t, report, "| Zoo | github.com/Azure/gocover/pkg/foo/zoo.go:3 | 10 | 0 | 0.00 |")
+		assert.Contains(t, report, "| Zoo.String | github.com/Azure/gocover/pkg/foo/zoo.go | 2 | `String$` |")
+		assert.NotContains(t, report, "Hits (min / avg / max)")
+	})
+
+	t.Run("raw coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType:             FullStatisticsType,
+			TotalLines:                 50,
+			TotalEffectiveLines:        40,
+			TotalCoveredLines:          30,
+			TotalIgnoredLines:          10,
+			TotalCoveragePercent:       75,
+			TotalCoverageWithoutIgnore: 60,
+		})
+		assert.NoError(t, err)
+
+		report := buf.String()
+		assert.Contains(t, report, "| **75.00** | 60.00 | 40 | 30 | 10 |")
+		assert.Contains(t, report, "the annotations contribute +15.00%.")
+	})
+
+	t.Run("diff coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType: DiffStatisticsType,
+			ComparedBranch: "origin/main",
+			CoverageProfile: []*CoverageProfile{{
+				FileName:            "github.com/Azure/gocover/pkg/foo/foo.go",
+				TotalEffectiveLines: 4,
+				CoveredLines:        2,
+				ViolationSections:   []*ViolationSection{{ViolationLines: []int{3, 5}}},
+			}},
+		})
+		assert.NoError(t, err)
+
+		report := buf.String()
+		assert.Contains(t, report, "Compared with `origin/main`.")
+		assert.Contains(t, report, "| github.com/Azure/gocover/pkg/foo/foo.go | 4 | 2 | 50.00 | 3,5 |")
+		assert.NotContains(t, report, "Least Covered Functions")
+		assert.NotContains(t, report, "Function Literals")
+	})
+
+	t.Run("hit counts", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType: FullStatisticsType,
+			LeastCoveredFunctions: []*FunctionCoverage{
+				{FileName: "foo.go", Function: "Foo", StartLine: 3, TotalEffectiveLines: 4, CoveredLines: 2, CoveragePercent: 50, Hits: &HitCounts{Min: 0, Avg: 2.5, Max: 7}},
+				{FileName: "foo.go", Function: "Bar", StartLine: 9, TotalEffectiveLines: 2},
+			},
+		})
+		assert.NoError(t, err)
+
+		report := buf.String()
+		assert.Contains(t, report, "| Coverage (%) | Hits (min / avg / max) |")
+		assert.Contains(t, report, "| Foo | foo.go:3 | 4 | 2 | 50.00 | 0 / 2.5 / 7 |")
+		assert.Contains(t, report, "| Bar | foo.go:9 | 2 | 0 | 0.00 | - |")
+	})
+
+	t.Run("hot paths", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType: FullStatisticsType,
+			HotFunctions: []*FunctionCoverage{
+				{FileName: "foo.go", Function: "Foo", StartLine: 3, TotalEffectiveLines: 4, CoveredLines: 4, CoveragePercent: 100, Hits: &HitCounts{Min: 1, Avg: 20.5, Max: 60}},
+			},
+			HotStatements: []*HotStatement{{FileName: "foo.go", Function: "Foo", StartLine: 5, EndLine: 6, Hits: 60}},
+		})
+		assert.NoError(t, err)
+
+		report := buf.String()
+		assert.Contains(t, report, "| Foo | foo.go:3 | 4 | 100.00 | 1 / 20.5 / 60 |")
+		assert.Contains(t, report, "| foo.go:5-6 | Foo | 60 |")
+	})
+
+	t.Run("coverage delta", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType: FullStatisticsType,
+			CoverageDelta: &CoverageDelta{
+				BaseCommit:           "0123456789",
+				BaseCoveragePercent:  60,
+				CoveragePercentDelta: -2.5,
+				RegressedFiles:       []*CoverageChange{{Name: "foo/a.go", BaseCoveragePercent: 80, CoveragePercent: 40, Delta: -40}},
+				RenamedPackages:      []*PackageRename{{From: "bar", To: "baz"}},
+			},
+		})
+		assert.NoError(t, err)
+
+		report := buf.String()
+		assert.Contains(t, report, "Compared with `0123456789`, the coverage changes from 60.00% by **-2.50%**.")
+		assert.Contains(t, report, "| foo/a.go | 80.00 | 40.00 | -40.00 |")
+		assert.Contains(t, report, "| baz | bar |")
+		assert.NotContains(t, report, "Regressed Package")
+	})
+
+	t.Run("uncovered commits", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType: DiffStatisticsType,
+			UncoveredCommits: []*UncoveredCommit{
+				{Commit: "0123456789abcdef", Author: "Jane Doe", UncoveredLines: 3, Files: []*CommitFile{
+					{FileName: "foo/a.go", Lines: []int{3, 5}},
+					{FileName: "foo/b.go", Lines: []int{7}},
+				}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "| `01234567` | Jane Doe | 3 | foo/a.go: 3, 5<br>foo/b.go: 7 |")
+	})
+
+	t.Run("label gaps", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType:  FullStatisticsType,
+			LabelStatistics: []*LabelStatistics{{Label: "linux"}, {Label: "darwin"}, {Label: "windows", MissedLines: 1}},
+			LabelGaps: []*LabelGap{
+				{FileName: "foo/a.go", Function: "Open", StartLine: 12, EndLine: 12, CoveredBy: []string{"linux", "darwin"}, MissedBy: []string{"windows"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "### Covered by Some Labels")
+		assert.Contains(t, buf.String(), "| foo/a.go:12 | Open | linux, darwin | windows |")
+	})
+
+	t.Run("test results", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := writeMarkdownReport(&buf, &Statistics{
+			StatisticsType: FullStatisticsType,
+			TestResults: &TestResults{
+				Passed: 3, Failed: 1,
+				Packages: []*TestPackage{
+					{Package: "foo", Result: "fail", Passed: 3, Failed: 1, Functions: 2, CoveragePercent: 62.5},
+					{Package: "zoo", Result: "skip"},
+				},
+				Tests: []*TestCase{
+					{Package: "foo", Test: "TestBar", Result: "fail", Gaps: []*TestGap{{FileName: "foo/bar.go", Function: "Bar", StartLine: 3, CoveragePercent: 25}}},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		report := buf.String()
+		assert.Contains(t, report, "3 passed, 1 failed and 0 skipped tests in 2 packages.")
+		assert.Contains(t, report, "| foo | fail | 3 | 1 | 0 | 62.50 |")
+		assert.Contains(t, report, "| zoo | skip | 0 | 0 | 0 | - |")
+		assert.Contains(t, report, "| TestBar | foo | fail | Bar (25.00%) |")
+	})
+}
+
+func TestGenerateMarkdownReport(t *testing.T) {
+	path, clean := temporalDir()
+	defer clean()
+
+	g := NewMarkdownReportGenerator(path, "coverage", logrus.New())
+	assert.NoError(t, g.GenerateReport(&Statistics{StatisticsType: FullStatisticsType, TotalCoveragePercent: 50}))
+
+	data, err := os.ReadFile(filepath.Join(path, markdownName("coverage")))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "## Full Coverage Report")
+	assert.Contains(t, string(data), "| **50.00** |")
+}
+
+func TestGenerateStepSummaryReport(t *testing.T) {
+	t.Run("append to step summary", func(t *testing.T) {
+		summaryFile := filepath.Join(t.TempDir(), "summary.md")
+		assert.NoError(t, os.WriteFile(summaryFile, []byte("# Build\n"), 0644))
+
+		g := NewStepSummaryReportGenerator(summaryFile, logrus.New())
+		assert.NoError(t, g.GenerateReport(&Statistics{StatisticsType: FullStatisticsType}))
+
+		data, err := os.ReadFile(summaryFile)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "# Build\n## Full Coverage Report\n"))
+	})
+}
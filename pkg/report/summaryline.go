@@ -0,0 +1,32 @@
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// summaryLineReportGenerator prints the total coverage in a stable line, such as `total coverage: 83.2% of statements`,
+// so that the coverage regular expression of GitLab scrapes it from the job log for the merge request widgets and badges.
+// The line of diff coverage starts with `diff coverage:` instead, so that both of them can be scraped separately.
+type summaryLineReportGenerator struct {
+	writer io.Writer
+}
+
+var _ ReportGenerator = (*summaryLineReportGenerator)(nil)
+
+// NewSummaryLineReportGenerator creates a generator that writes the summary line to the writer.
+func NewSummaryLineReportGenerator(writer io.Writer) ReportGenerator {
+	return &summaryLineReportGenerator{writer: writer}
+}
+
+// GenerateReport writes the summary line, the format should not change as the pipelines depend on it.
+func (g *summaryLineReportGenerator) GenerateReport(statistics *Statistics) error {
+	prefix := "total"
+	if statistics.StatisticsType == DiffStatisticsType {
+		prefix = "diff"
+	}
+	if _, err := fmt.Fprintf(g.writer, "%s coverage: %.1f%% of statements\n", prefix, statistics.TotalCoveragePercent); err != nil {
+		return fmt.Errorf("write summary line: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package report
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSummaryLineReport(t *testing.T) {
+	// gitlabRegex is the coverage regular expression in the README.
+	gitlabRegex := regexp.MustCompile(`^total coverage: (\d+\.\d+)% of statements$`)
+
+	t.Run("full coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := NewSummaryLineReportGenerator(&buf).GenerateReport(&Statistics{
+			StatisticsType:       FullStatisticsType,
+			TotalCoveragePercent: 83.24,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "total coverage: 83.2% of statements\n", buf.String())
+		assert.Equal(t, []string{"total coverage: 83.2% of statements", "83.2"}, gitlabRegex.FindStringSubmatch(buf.String()[:buf.Len()-1]))
+	})
+
+	t.Run("diff coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := NewSummaryLineReportGenerator(&buf).GenerateReport(&Statistics{
+			StatisticsType:       DiffStatisticsType,
+			TotalCoveragePercent: 100,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "diff coverage: 100.0% of statements\n", buf.String())
+	})
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// teamcityInspectionType is the inspection type of the uncovered lines.
+	teamcityInspectionType = "gocover.uncovered"
+)
+
+// teamcityReportGenerator writes TeamCity service messages, so that TeamCity charts the coverage statistics
+// and shows the uncovered lines as inspections without extra plugins.
+// See https://www.jetbrains.com/help/teamcity/service-messages.html for more information.
+type teamcityReportGenerator struct {
+	writer     io.Writer
+	modulePath string
+	moduleDir  string
+}
+
+var _ ReportGenerator = (*teamcityReportGenerator)(nil)
+
+// NewTeamCityReportGenerator creates a generator that writes TeamCity service messages to the writer.
+// The files of the inspections are relative to the repository root, which are the files of the module path
+// in the module directory of the repository, so that TeamCity maps them to the files of the checkout.
+func NewTeamCityReportGenerator(writer io.Writer, modulePath, moduleDir string) ReportGenerator {
+	return &teamcityReportGenerator{writer: writer, modulePath: modulePath, moduleDir: filepath.ToSlash(moduleDir)}
+}
+
+// GenerateReport writes the build statistic values of the coverage and an inspection for each uncovered line.
+// Full coverage uses the statistic keys that TeamCity recognizes as code coverage,
+// diff coverage uses the keys with prefix "DiffCoverage".
+func (g *teamcityReportGenerator) GenerateReport(statistics *Statistics) error {
+	prefix := "CodeCoverage"
+	if statistics.StatisticsType == DiffStatisticsType {
+		prefix = "DiffCoverage"
+	}
+
+	values := []struct {
+		key   string
+		value string
+	}{
+		{key: prefix + "L", value: fmt.Sprintf("%.2f", statistics.TotalCoveragePercent)},
+		{key: prefix + "AbsLCovered", value: fmt.Sprintf("%d", statistics.TotalCoveredLines-statistics.TotalCoveredButIgnoredLines)},
+		{key: prefix + "AbsLTotal", value: fmt.Sprintf("%d", statistics.TotalEffectiveLines)},
+	}
+	for _, v := range values {
+		if err := g.write("buildStatisticValue", "key", v.key, "value", v.value); err != nil {
+			return err
+		}
+	}
+
+	if err := g.write("inspectionType",
+		"id", teamcityInspectionType,
+		"name", "uncovered line",
+		"category", "Coverage",
+		"description", fmt.Sprintf("line is not covered by tests in %s coverage", statistics.StatisticsType),
+	); err != nil {
+		return err
+	}
+	for _, p := range statistics.CoverageProfile {
+		for _, line := range uncoveredLines(p) {
+			if err := g.write("inspection",
+				"typeId", teamcityInspectionType,
+				"message", "line is not covered by tests",
+				"file", g.repositoryFile(p.FileName),
+				"line", fmt.Sprintf("%d", line),
+				"SEVERITY", "WARNING",
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// repositoryFile returns the path of the file relative to the repository root,
+// the file is returned as it is if it's not in the module.
+func (g *teamcityReportGenerator) repositoryFile(fileName string) string {
+	if g.modulePath == "" || !strings.HasPrefix(fileName, g.modulePath+"/") {
+		return fileName
+	}
+	return path.Join(g.moduleDir, strings.TrimPrefix(fileName, g.modulePath+"/"))
+}
+
+// write writes the service message with the attributes, which are pairs of name and value.
+func (g *teamcityReportGenerator) write(message string, attributes ...string) error {
+	var b strings.Builder
+	b.WriteString("##teamcity[")
+	b.WriteString(message)
+	for i := 0; i+1 < len(attributes); i += 2 {
+		fmt.Fprintf(&b, " %s='%s'", attributes[i], teamcityEscape(attributes[i+1]))
+	}
+	b.WriteString("]\n")
+
+	if _, err := io.WriteString(g.writer, b.String()); err != nil {
+		return fmt.Errorf("write teamcity service message: %w", err)
+	}
+	return nil
+}
+
+var teamcityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+)
+
+// teamcityEscape escapes the value of the service message attribute.
+func teamcityEscape(v string) string {
+	return teamcityEscaper.Replace(v)
+}
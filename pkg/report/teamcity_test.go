@@ -0,0 +1,56 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamCityEscape(t *testing.T) {
+	t.Run("teamcityEscape", func(t *testing.T) {
+		assert.Equal(t, "a||b|'c|n|r|[d|]", teamcityEscape("a|b'c\n\r[d]"))
+	})
+}
+
+func TestGenerateTeamCityReport(t *testing.T) {
+	t.Run("repositoryFile", func(t *testing.T) {
+		g := NewTeamCityReportGenerator(nil, "example.com/foo", "services/foo").(*teamcityReportGenerator)
+		assert.Equal(t, "services/foo/pkg/a.go", g.repositoryFile("example.com/foo/pkg/a.go"))
+		assert.Equal(t, "example.com/foobar/a.go", g.repositoryFile("example.com/foobar/a.go"))
+	})
+
+	t.Run("full coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := NewTeamCityReportGenerator(&buf, "github.com/Azure/gocover", "").GenerateReport(&Statistics{
+			StatisticsType:              FullStatisticsType,
+			TotalEffectiveLines:         10,
+			TotalCoveredLines:           8,
+			TotalCoveredButIgnoredLines: 1,
+			TotalCoveragePercent:        70,
+			CoverageProfile: []*CoverageProfile{{
+				FileName:          "github.com/Azure/gocover/pkg/foo/foo.go",
+				ViolationSections: []*ViolationSection{{ViolationLines: []int{3, 4}}},
+			}},
+		})
+		assert.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, []string{
+			"##teamcity[buildStatisticValue key='CodeCoverageL' value='70.00']",
+			"##teamcity[buildStatisticValue key='CodeCoverageAbsLCovered' value='7']",
+			"##teamcity[buildStatisticValue key='CodeCoverageAbsLTotal' value='10']",
+			"##teamcity[inspectionType id='gocover.uncovered' name='uncovered line' category='Coverage' description='line is not covered by tests in full coverage']",
+			"##teamcity[inspection typeId='gocover.uncovered' message='line is not covered by tests' file='pkg/foo/foo.go' line='3' SEVERITY='WARNING']",
+			"##teamcity[inspection typeId='gocover.uncovered' message='line is not covered by tests' file='pkg/foo/foo.go' line='4' SEVERITY='WARNING']",
+		}, lines)
+	})
+
+	t.Run("diff coverage", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := NewTeamCityReportGenerator(&buf, "github.com/Azure/gocover", "").GenerateReport(&Statistics{StatisticsType: DiffStatisticsType})
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "##teamcity[buildStatisticValue key='DiffCoverageL' value='0.00']")
+	})
+}
@@ -30,6 +30,69 @@ var htmlCoverageReport = "" +
             border-bottom: 1px solid #bdbdbd;
         }
 
+        .heatmap {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 4px;
+        }
+
+        .heatmap-cell {
+            width: 12em;
+            padding: 0.5em;
+            border: 1px solid #bdbdbd;
+            font-size: small;
+            word-break: break-all;
+        }
+
+        .directory-tree details details {
+            margin-left: 2em;
+        }
+
+        .side-by-side {
+            border-collapse: collapse;
+            font-family: monospace;
+            white-space: pre;
+            width: 100%;
+        }
+
+        .side-by-side td {
+            padding: 0 0.5em;
+        }
+
+        .side-by-side .line-number {
+            color: #757575;
+            text-align: right;
+        }
+
+        .side-by-side .deleted {
+            background-color: #ffebe9;
+        }
+
+        .side-by-side .added {
+            background-color: #e6ffec;
+        }
+
+        .side-by-side .covered {
+            background-color: #aceebb;
+        }
+
+        .side-by-side .uncovered {
+            background-color: #ffc1c0;
+        }
+
+        .side-by-side .partial {
+            background-color: #fff5b1;
+        }
+
+        .side-by-side .ignored {
+            background-color: #eaeef2;
+        }
+
+        .side-by-side .skipped {
+            color: #757575;
+            background-color: #f6f8fa;
+        }
+
         a {
             text-decoration: none;
         }
@@ -52,6 +115,10 @@ var htmlCoverageReport = "" +
         <p>Diff: {{ .ComparedBranch }}...HEAD</p>
     {{ end }}
 
+    {{ with CISummary .CI }}
+        <p>CI: {{ . }}</p>
+    {{ end }}
+
     {{ if .CoverageProfile }}
         <ul>
             <li>
@@ -72,14 +139,147 @@ var htmlCoverageReport = "" +
             <li>
                 <b>Coverage (with ignorance)</b>: {{ .TotalCoveragePercent }}%
             </li>
+            <li>
+                <b>Ignore contribution</b>: {{ printf "%+.2f" .IgnoreContribution }}%
+            </li>
         </ul>
 
         <p>
             <b>Coverage </b> = Covered / Total <br />
             <b>Coverage (with ignorance) </b> = (Covered - CoveredButIngored) / Effective <br />
             <b>Total</b> = Effective + Ignored
+            {{ with .IgnorePolicy }}<br />
+            <b>Ignore policy</b>: {{ . }}{{ if eq . "count" }}, the ignored statements count for coverage as the other statements{{ end }}{{ end }}
         </p>
 
+        {{ if .LabelStatistics }}
+        <h3>Coverage by Label</h3>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Label</th>
+                    <th>Coverage (with ignorance) (%)</th>
+                    <th>Coverage (%)</th>
+                    <th>Covered Lines</th>
+                    <th>Covered But Ignored Lines</th>
+                    <th>Missed Lines</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .LabelStatistics }}
+                <tr>
+                    <td>{{ .Label }}</td>
+                    <td>{{ printf "%.2f" .TotalCoveragePercent }}</td>
+                    <td>{{ printf "%.2f" .TotalCoverageWithoutIgnore }}</td>
+                    <td>{{ .TotalCoveredLines }}</td>
+                    <td>{{ .TotalCoveredButIgnoredLines }}</td>
+                    <td>{{ .MissedLines }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        <br />
+        {{ end }}
+
+        {{ if .LabelGaps }}
+        <h3>Covered by Some Labels</h3>
+        <p>The statements covered by some labels but missed by the others, such as the code covered only on one platform of a matrix build.</p>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Location</th>
+                    <th>Function</th>
+                    <th>Covered By</th>
+                    <th>Missed By</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .LabelGaps }}
+                <tr>
+                    <td>{{ .FileName }}:{{ .StartLine }}</td>
+                    <td>{{ .Function }}</td>
+                    <td>{{ range $i, $l := .CoveredBy }}{{ if $i }}, {{ end }}{{ $l }}{{ end }}</td>
+                    <td>{{ range $i, $l := .MissedBy }}{{ if $i }}, {{ end }}{{ $l }}{{ end }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        <br />
+        {{ end }}
+
+        {{ if .Teams }}
+        <h3>Coverage by Team</h3>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Team</th>
+                    <th>Paths</th>
+                    <th>Files</th>
+                    <th>Effective Lines</th>
+                    <th>Covered Lines</th>
+                    <th>Coverage (%)</th>
+                    <th>Budget (%)</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .Teams }}
+                <tr>
+                    <td>{{ .Team }}</td>
+                    <td>{{ range .Paths }}<code>{{ . }}</code> {{ end }}</td>
+                    <td>{{ .Files }}</td>
+                    <td>{{ .TotalEffectiveLines }}</td>
+                    <td>{{ .TotalCoveredLines }}</td>
+                    <td>{{ printf "%.2f" .CoveragePercent }}</td>
+                    <td>{{ if .Budget }}{{ printf "%.2f" .Budget }}{{ if .OverBudget }} <b>failed</b>{{ end }}{{ else }}-{{ end }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        <br />
+        {{ end }}
+
+        {{ with .ClosureStatistics }}
+        <h3>Function Literals</h3>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Function Literals</th>
+                    <th>Effective Lines</th>
+                    <th>Covered Lines</th>
+                    <th>Coverage (%)</th>
+                </tr>
+            </thead>
+            <tbody>
+                <tr>
+                    <td>{{ .Closures }}</td>
+                    <td>{{ .TotalEffectiveLines }}</td>
+                    <td>{{ .TotalCoveredLines }}</td>
+                    <td>{{ printf "%.2f" .TotalCoveragePercent }}</td>
+                </tr>
+            </tbody>
+        </table>
+        <br />
+        {{ end }}
+
+        <h3>Coverage Heatmap</h3>
+        <div class="heatmap">
+            {{ range Heatmap .CoverageProfile }}
+            <div class="heatmap-cell" style="background-color: {{ .Color }}" title="{{ .Files }} files, {{ .CoveredLines }}/{{ .TotalEffectiveLines }} lines covered">
+                <b>{{ .Directory }}</b><br />
+                {{ printf "%.2f" .CoveragePercent }}%
+            </div>
+            {{ end }}
+        </div>
+        <br />
+
+        {{ if .DirectoryTree }}
+        <h3>Coverage by Directory</h3>
+        <div class="directory-tree">
+            {{ template "directory" .DirectoryTree }}
+        </div>
+        <br />
+        {{ end }}
+
         <table border="1">
             <thead>
                 <tr>
@@ -132,6 +332,349 @@ var htmlCoverageReport = "" +
         <p>No lines with coverage information in this diff.</p>
     {{ end }}
 
+    {{ if .LeastCoveredFunctions }}
+        <h3>Least Covered Functions</h3>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Function</th>
+                    <th>Location</th>
+                    <th>Effective Lines</th>
+                    <th>Covered Lines</th>
+                    <th>Coverage (%)</th>
+                    {{ if HasHits .LeastCoveredFunctions }}<th>Hits (min / avg / max)</th>{{ end }}
+                </tr>
+            </thead>
+            <tbody>
+                {{ $hits := HasHits .LeastCoveredFunctions }}
+                {{ range .LeastCoveredFunctions }}
+                <tr>
+                    <td>{{ .Function }}</td>
+                    <td>{{ .FileName }}:{{ .StartLine }}</td>
+                    <td>{{ .TotalEffectiveLines }}</td>
+                    <td>{{ .CoveredLines }}</td>
+                    <td>{{ printf "%.2f" .CoveragePercent }}</td>
+                    {{ if $hits }}<td>{{ HitsSummary .Hits }}</td>{{ end }}
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+    {{ end }}
+
+    {{ if or .HotFunctions .HotStatements }}
+        <h3>Hot Paths</h3>
+        <p>The functions and statements that are executed most frequently by the tests.</p>
+        {{ if .HotFunctions }}
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Function</th>
+                    <th>Location</th>
+                    <th>Effective Lines</th>
+                    <th>Coverage (%)</th>
+                    <th>Hits (min / avg / max)</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .HotFunctions }}
+                <tr>
+                    <td>{{ .Function }}</td>
+                    <td>{{ .FileName }}:{{ .StartLine }}</td>
+                    <td>{{ .TotalEffectiveLines }}</td>
+                    <td>{{ printf "%.2f" .CoveragePercent }}</td>
+                    <td>{{ HitsSummary .Hits }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ end }}
+        {{ if .HotStatements }}
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Statement</th>
+                    <th>Function</th>
+                    <th>Hits</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .HotStatements }}
+                <tr>
+                    <td>{{ .FileName }}:{{ .StartLine }}-{{ .EndLine }}</td>
+                    <td>{{ .Function }}</td>
+                    <td>{{ .Hits }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ end }}
+    {{ end }}
+
+    {{ with .CoverageDelta }}
+        <h3>Coverage Delta</h3>
+        <p>Compared with the stored result of <code>{{ .BaseCommit }}</code>, the coverage changes from {{ printf "%.2f" .BaseCoveragePercent }}% by {{ printf "%+.2f" .CoveragePercentDelta }}%.</p>
+        {{ if .RegressedPackages }}
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Regressed Package</th>
+                    <th>Base Coverage (%)</th>
+                    <th>Coverage (%)</th>
+                    <th>Delta (%)</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .RegressedPackages }}
+                <tr>
+                    <td>{{ .Name }}</td>
+                    <td>{{ printf "%.2f" .BaseCoveragePercent }}</td>
+                    <td>{{ printf "%.2f" .CoveragePercent }}</td>
+                    <td>{{ printf "%+.2f" .Delta }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ end }}
+        {{ if .RegressedFiles }}
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Regressed File</th>
+                    <th>Base Coverage (%)</th>
+                    <th>Coverage (%)</th>
+                    <th>Delta (%)</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .RegressedFiles }}
+                <tr>
+                    <td>{{ .Name }}</td>
+                    <td>{{ printf "%.2f" .BaseCoveragePercent }}</td>
+                    <td>{{ printf "%.2f" .CoveragePercent }}</td>
+                    <td>{{ printf "%+.2f" .Delta }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ end }}
+        {{ if .RenamedPackages }}
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Renamed Package</th>
+                    <th>Base Package</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .RenamedPackages }}
+                <tr>
+                    <td>{{ .To }}</td>
+                    <td>{{ .From }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ end }}
+    {{ end }}
+
+    {{ with .Trend }}
+        <h3>Coverage Trend</h3>
+        <p>The coverage of the last {{ .Runs }} runs in history.</p>
+        {{ TrendSVG . }}
+    {{ end }}
+
+    {{ if .NeverCoveredFunctions }}
+        <h3>Never Covered Functions</h3>
+        <p>These functions have no coverage in the last {{ .NeverCoveredRuns }} runs, they are likely dead or dangerously untested code.</p>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Function</th>
+                    <th>Location</th>
+                    <th>Effective Lines</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .NeverCoveredFunctions }}
+                <tr>
+                    <td>{{ .Function }}</td>
+                    <td>{{ .FileName }}:{{ .StartLine }}</td>
+                    <td>{{ .TotalEffectiveLines }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+    {{ end }}
+
+    {{ if .UncoveredCommits }}
+        <h3>Uncovered Lines by Commit</h3>
+        <p>The commits that introduce the uncovered changed lines, found by git blame.</p>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Commit</th>
+                    <th>Author</th>
+                    <th>Uncovered Lines</th>
+                    <th>Files</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .UncoveredCommits }}
+                <tr>
+                    <td title="{{ .Commit }}">{{ .ShortCommit }}</td>
+                    <td>{{ .Author }}</td>
+                    <td>{{ .UncoveredLines }}</td>
+                    <td>{{ range $i, $f := .Files }}{{ if $i }}<br>{{ end }}{{ $f.FileName }}: {{ range $j, $line := $f.Lines }}{{ if $j }}, {{ end }}{{ $line }}{{ end }}{{ end }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+    {{ end }}
+
+    {{ with .TestResults }}
+        <h3>Test Results</h3>
+        <p>{{ .Passed }} passed, {{ .Failed }} failed and {{ .Skipped }} skipped tests in {{ len .Packages }} packages, read from the go test -json output.</p>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Package</th>
+                    <th>Result</th>
+                    <th>Passed</th>
+                    <th>Failed</th>
+                    <th>Skipped</th>
+                    <th>Elapsed (s)</th>
+                    <th>Coverage (%)</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .Packages }}
+                <tr>
+                    <td>{{ .Package }}</td>
+                    <td>{{ .Result }}</td>
+                    <td>{{ .Passed }}</td>
+                    <td>{{ .Failed }}</td>
+                    <td>{{ .Skipped }}</td>
+                    <td>{{ printf "%.2f" .Elapsed }}</td>
+                    <td>{{ if .Functions }}{{ printf "%.2f" .CoveragePercent }}{{ else }}-{{ end }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ if .Tests }}
+        <p>The failed and skipped tests with the uncovered functions that they're named after.</p>
+        <table border="1">
+            <thead>
+                <tr>
+                    <th>Test</th>
+                    <th>Package</th>
+                    <th>Result</th>
+                    <th>Message</th>
+                    <th>Uncovered Functions</th>
+                </tr>
+            </thead>
+            <tbody>
+                {{ range .Tests }}
+                <tr>
+                    <td>{{ .Test }}</td>
+                    <td>{{ .Package }}</td>
+                    <td>{{ .Result }}</td>
+                    <td>{{ .Message }}</td>
+                    <td>{{ range $i, $g := .Gaps }}{{ if $i }}<br>{{ end }}{{ $g.Function }} ({{ $g.FileName }}:{{ $g.StartLine }}) {{ printf "%.2f" $g.CoveragePercent }}%{{ end }}</td>
+                </tr>
+                {{ end }}
+            </tbody>
+        </table>
+        {{ end }}
+    {{ end }}
+
+    {{ if .TotalWeakCoveredLines }}
+        <h3>Weakly Covered Lines</h3>
+        <p>{{ NormalizeLines .TotalWeakCoveredLines }} reached only once, they may be touched only incidentally by a broad test.</p>
+        <ul>
+        {{ range .CoverageProfile }}
+            {{ if .WeakCoveredLines }}
+            <li>{{ .FileName }}: {{ IntsJoin .WeakCoveredLines }}</li>
+            {{ end }}
+        {{ end }}
+        </ul>
+    {{ end }}
+
+    {{ if .TotalPartialCoveredLines }}
+        <h3>Partially Covered Lines</h3>
+        <p>{{ NormalizeLines .TotalPartialCoveredLines }} reached, but some of their own blocks are not, the nested branches are not counted.</p>
+        <ul>
+        {{ range .CoverageProfile }}
+            {{ if .PartialCoveredLines }}
+            <li>{{ .FileName }}: {{ IntsJoin .PartialCoveredLines }}</li>
+            {{ end }}
+        {{ end }}
+        </ul>
+    {{ end }}
+
+    {{ if .TotalMovedLines }}
+        <h3>Moved Lines</h3>
+        <p>{{ NormalizeLines .TotalMovedLines }} moved from the code deleted in the diff, they don't count for diff coverage.</p>
+        <ul>
+        {{ range .CoverageProfile }}
+            {{ if .MovedLines }}
+            <li>{{ .FileName }}: {{ IntsJoin .MovedLines }}</li>
+            {{ end }}
+        {{ end }}
+        </ul>
+    {{ end }}
+
+    {{ if .SkippedFiles }}
+        <h3>Skipped Files</h3>
+        <ul>
+        {{ range .SkippedFiles }}
+            <li>{{ .FileName }}: {{ .Reason }}</li>
+        {{ end }}
+        </ul>
+    {{ end }}
+
+    {{ if .FileErrors }}
+        <h3>File Errors</h3>
+        <p>The files below failed to convert and are left out of the coverage.</p>
+        <ul>
+        {{ range .FileErrors }}
+            <li>{{ .FileName }}: {{ .Error }}</li>
+        {{ end }}
+        </ul>
+    {{ end }}
+
+    {{ if .ExcludedFunctions }}
+        <h3>Excluded Functions</h3>
+        <ul>
+        {{ range .ExcludedFunctions }}
+            <li>{{ .FileName }}: {{ .Function }}, {{ .TotalLines }} lines excluded by {{ .Pattern }}</li>
+        {{ end }}
+        </ul>
+    {{ end }}
+
+    {{ if .DiffFiles }}
+        <h3>Changes</h3>
+        <p>The changed files side by side, the added lines are colored by their coverage states: covered, uncovered, partially covered or ignored.</p>
+        {{ range .DiffFiles }}
+        <details open>
+            <summary class="src-name">{{ .FileName }}</summary>
+            <table class="side-by-side">
+                {{ range .Rows }}
+                {{ if .Skipped }}
+                <tr class="skipped"><td colspan="4">...</td></tr>
+                {{ else }}
+                <tr>
+                    <td class="line-number">{{ if .OldLine }}{{ .OldLine }}{{ end }}</td>
+                    <td{{ if .Deleted }} class="deleted"{{ end }}>{{ .Old }}</td>
+                    <td class="line-number">{{ if .NewLine }}{{ .NewLine }}{{ end }}</td>
+                    <td{{ if .Added }} class="{{ or .State "added" }}"{{ end }}>{{ .New }}</td>
+                </tr>
+                {{ end }}
+                {{ end }}
+            </table>
+        </details>
+        {{ end }}
+    {{ end }}
+
     {{ if .ExcludeFiles }}
         <h3>Exclude Files</h3>
         <ul>
@@ -144,4 +687,286 @@ var htmlCoverageReport = "" +
 </body>
 
 </html>
+{{ define "directory" }}
+<details open>
+    <summary><b>{{ .Name }}/</b> {{ printf "%.2f" .CoveragePercent }}% ({{ .CoveredLines }}/{{ .TotalEffectiveLines }} lines, {{ .Files }} files)</summary>
+    {{ range .Children }}{{ template "directory" . }}{{ end }}
+</details>
+{{ end }}
 `
+
+// htmlTreemapReport is the templates contents for html treemap report.
+var htmlTreemapReport = "" +
+	`<!DOCTYPE html>
+<html lang="en">
+
+<head>
+    <meta charset="utf-8">
+    <title>
+    {{ if IsFullCoverageReport .StatisticsType }}
+        Full Coverage Treemap
+    {{ end }}
+
+    {{ if IsDiffCoverageReport .StatisticsType }}
+        Diff Coverage Treemap
+    {{ end }}
+    </title>
+    <style type="text/css">
+        .directory {
+            fill: none;
+            stroke: #424242;
+            stroke-width: 2;
+        }
+
+        .file {
+            stroke: #ffffff;
+            stroke-width: 1;
+        }
+
+        text {
+            font-family: sans-serif;
+            font-size: 11px;
+            pointer-events: none;
+        }
+    </style>
+</head>
+
+<body>
+    {{ if IsFullCoverageReport .StatisticsType }}
+        <h1>Full Coverage Treemap</h1>
+    {{ end }}
+
+    {{ if IsDiffCoverageReport .StatisticsType }}
+        <h1>Diff Coverage Treemap</h1>
+        <p>Diff: {{ .ComparedBranch }}...HEAD</p>
+    {{ end }}
+
+    {{ if .Files }}
+        <p>
+            <b>Coverage (with ignorance)</b>: {{ .CoveragePercent }}% <br />
+            Size of the rectangle is the effective lines, color of the rectangle goes from red to green as the coverage increases.
+        </p>
+
+        <svg xmlns="http://www.w3.org/2000/svg" width="{{ .Width }}" height="{{ .Height }}">
+            {{ range .Files }}
+            <g>
+                <title>{{ .Name }}: {{ printf "%.2f" .CoveragePercent }}% of {{ .TotalEffectiveLines }} lines</title>
+                <rect class="file" x="{{ printf "%.2f" .X }}" y="{{ printf "%.2f" .Y }}" width="{{ printf "%.2f" .Width }}" height="{{ printf "%.2f" .Height }}" fill="{{ .Color }}" />
+                {{ if .ShowLabel }}
+                <text x="{{ printf "%.2f" .X }}" y="{{ printf "%.2f" .Y }}" dx="3" dy="12">{{ .Name }}</text>
+                {{ end }}
+            </g>
+            {{ end }}
+            {{ range .Directories }}
+            <g>
+                <title>{{ .Name }}: {{ printf "%.2f" .CoveragePercent }}% of {{ .TotalEffectiveLines }} lines</title>
+                <rect class="directory" x="{{ printf "%.2f" .X }}" y="{{ printf "%.2f" .Y }}" width="{{ printf "%.2f" .Width }}" height="{{ printf "%.2f" .Height }}" />
+            </g>
+            {{ end }}
+        </svg>
+    {{ else }}
+        <p>No lines with coverage information.</p>
+    {{ end }}
+
+</body>
+
+</html>
+`
+
+// htmlAggregateReport is the templates contents for html coverage rollup report.
+var htmlAggregateReport = "" +
+	`<!DOCTYPE html>
+<html lang="en">
+
+<head>
+    <meta charset="utf-8">
+    <title>Aggregate Coverage</title>
+</head>
+
+<body>
+    <h1>Aggregate Coverage</h1>
+
+    <ul>
+        <li>
+            <b>Repositories</b>: {{ len .Repositories }}
+        </li>
+        <li>
+            <b>Effective</b>: {{ .TotalEffectiveLines }}
+        </li>
+        <li>
+            <b>Covered</b>: {{ .TotalCoveredLines }}
+        </li>
+        <li>
+            <b>Coverage (with ignorance)</b>: {{ printf "%.2f" .TotalCoveragePercent }}%
+        </li>
+    </ul>
+
+    {{ if .Teams }}
+    <h3>Coverage by Team</h3>
+    <table border="1">
+        <thead>
+            <tr>
+                <th>Team</th>
+                <th>Repositories</th>
+                <th>Coverage (with ignorance) (%)</th>
+                <th>Covered Lines</th>
+                <th>Effective Lines</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Teams }}
+            <tr>
+                <td>{{ .Team }}</td>
+                <td>{{ .Repositories }}</td>
+                <td style="background-color: {{ HeatmapColor .TotalCoveragePercent }}">{{ printf "%.2f" .TotalCoveragePercent }}</td>
+                <td>{{ .TotalCoveredLines }}</td>
+                <td>{{ .TotalEffectiveLines }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+
+    <h3>Coverage by Repository</h3>
+    <table border="1">
+        <thead>
+            <tr>
+                <th>Module</th>
+                <th>Team</th>
+                <th>Commit</th>
+                <th>Coverage (with ignorance) (%)</th>
+                <th>Covered Lines</th>
+                <th>Effective Lines</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Repositories }}
+            <tr>
+                <td>{{ .ModulePath }}</td>
+                <td>{{ .Team }}</td>
+                <td>{{ .Commit }}</td>
+                <td style="background-color: {{ HeatmapColor .TotalCoveragePercent }}">{{ printf "%.2f" .TotalCoveragePercent }}</td>
+                <td>{{ .TotalCoveredLines }}</td>
+                <td>{{ .TotalEffectiveLines }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+
+</body>
+
+</html>
+`
+
+// markdownCoverageReport is the templates contents for markdown coverage report.
+var markdownCoverageReport = "" +
+	`{{ if IsFullCoverageReport .StatisticsType }}## Full Coverage Report
+{{ else }}## Diff Coverage Report
+
+Compared with ` + "`{{ .ComparedBranch }}`" + `.
+{{ end }}{{ with CISummary .CI }}
+CI: {{ . }}.
+{{ end }}
+| Coverage (%) | Raw Coverage (%) | Effective Lines | Covered Lines | Ignored Lines |
+| ---: | ---: | ---: | ---: | ---: |
+| **{{ printf "%.2f" .TotalCoveragePercent }}** | {{ printf "%.2f" .TotalCoverageWithoutIgnore }} | {{ .TotalEffectiveLines }} | {{ .TotalCoveredLines }} | {{ .TotalIgnoredLines }} |
+
+The coverage is calculated after the ignore annotations, the raw coverage counts the ignored lines as well, the annotations contribute {{ printf "%+.2f" .IgnoreContribution }}%.
+{{ with .IgnorePolicy }}
+Ignore policy: ` + "`{{ . }}`" + `{{ if eq . "count" }}, the ignored statements count for coverage as the other statements{{ end }}.
+{{ end }}{{ if .LabelStatistics }}
+### Coverage by Label
+
+| Label | Coverage (%) | Covered Lines |
+| --- | ---: | ---: |
+{{ range .LabelStatistics }}| {{ .Label }} | {{ printf "%.2f" .TotalCoveragePercent }} | {{ .TotalCoveredLines }} |
+{{ end }}{{ end }}{{ if .LabelGaps }}
+### Covered by Some Labels
+
+The statements covered by some labels but missed by the others.
+
+| Location | Function | Covered By | Missed By |
+| --- | --- | --- | --- |
+{{ range .LabelGaps }}| {{ .FileName }}:{{ .StartLine }} | {{ .Function }} | {{ range $i, $l := .CoveredBy }}{{ if $i }}, {{ end }}{{ $l }}{{ end }} | {{ range $i, $l := .MissedBy }}{{ if $i }}, {{ end }}{{ $l }}{{ end }} |
+{{ end }}{{ end }}{{ if .Teams }}
+### Coverage by Team
+
+| Team | Files | Effective Lines | Covered Lines | Coverage (%) | Budget (%) |
+| --- | ---: | ---: | ---: | ---: | ---: |
+{{ range .Teams }}| {{ .Team }} | {{ .Files }} | {{ .TotalEffectiveLines }} | {{ .TotalCoveredLines }} | {{ printf "%.2f" .CoveragePercent }} | {{ if .Budget }}{{ printf "%.2f" .Budget }}{{ if .OverBudget }} (failed){{ end }}{{ else }}-{{ end }} |
+{{ end }}{{ end }}{{ with .ClosureStatistics }}
+### Function Literals
+
+| Function Literals | Effective Lines | Covered Lines | Coverage (%) |
+| ---: | ---: | ---: | ---: |
+| {{ .Closures }} | {{ .TotalEffectiveLines }} | {{ .TotalCoveredLines }} | {{ printf "%.2f" .TotalCoveragePercent }} |
+{{ end }}
+{{ if IsFullCoverageReport .StatisticsType }}### Packages
+
+| Package | Files | Effective Lines | Covered Lines | Coverage (%) |
+| --- | ---: | ---: | ---: | ---: |
+{{ range Heatmap .CoverageProfile }}| {{ .Directory }} | {{ .Files }} | {{ .TotalEffectiveLines }} | {{ .CoveredLines }} | {{ printf "%.2f" .CoveragePercent }} |
+{{ end }}{{ else }}### Changed Files
+
+| File | Effective Lines | Covered Lines | Coverage (%) | Uncovered Lines |
+| --- | ---: | ---: | ---: | --- |
+{{ range .CoverageProfile }}| {{ .FileName }} | {{ .TotalEffectiveLines }} | {{ .CoveredLines }} | {{ printf "%.2f" (PercentCovered .TotalEffectiveLines .CoveredLines .CoveredButIgnoredLines) }} | {{ UncoveredLines . }} |
+{{ end }}{{ end }}{{ with .CoverageDelta }}
+### Coverage Delta
+
+Compared with ` + "`{{ .BaseCommit }}`" + `, the coverage changes from {{ printf "%.2f" .BaseCoveragePercent }}% by **{{ printf "%+.2f" .CoveragePercentDelta }}%**.
+{{ if .RegressedPackages }}
+| Regressed Package | Base Coverage (%) | Coverage (%) | Delta (%) |
+| --- | ---: | ---: | ---: |
+{{ range .RegressedPackages }}| {{ .Name }} | {{ printf "%.2f" .BaseCoveragePercent }} | {{ printf "%.2f" .CoveragePercent }} | {{ printf "%+.2f" .Delta }} |
+{{ end }}{{ end }}{{ if .RegressedFiles }}
+| Regressed File | Base Coverage (%) | Coverage (%) | Delta (%) |
+| --- | ---: | ---: | ---: |
+{{ range .RegressedFiles }}| {{ .Name }} | {{ printf "%.2f" .BaseCoveragePercent }} | {{ printf "%.2f" .CoveragePercent }} | {{ printf "%+.2f" .Delta }} |
+{{ end }}{{ end }}{{ if .RenamedPackages }}
+| Renamed Package | Base Package |
+| --- | --- |
+{{ range .RenamedPackages }}| {{ .To }} | {{ .From }} |
+{{ end }}{{ end }}{{ end }}{{ if .LeastCoveredFunctions }}
+### Least Covered Functions
+
+{{ $hits := HasHits .LeastCoveredFunctions }}| Function | Location | Effective Lines | Covered Lines | Coverage (%) |{{ if $hits }} Hits (min / avg / max) |{{ end }}
+| --- | --- | ---: | ---: | ---: |{{ if $hits }} ---: |{{ end }}
+{{ range .LeastCoveredFunctions }}| {{ .Function }} | {{ .FileName }}:{{ .StartLine }} | {{ .TotalEffectiveLines }} | {{ .CoveredLines }} | {{ printf "%.2f" .CoveragePercent }} |{{ if $hits }} {{ HitsSummary .Hits }} |{{ end }}
+{{ end }}{{ end }}{{ if .UncoveredCommits }}
+### Uncovered Lines by Commit
+
+| Commit | Author | Uncovered Lines | Files |
+| --- | --- | ---: | --- |
+{{ range .UncoveredCommits }}| ` + "`{{ .ShortCommit }}`" + ` | {{ .Author }} | {{ .UncoveredLines }} | {{ range $i, $f := .Files }}{{ if $i }}<br>{{ end }}{{ $f.FileName }}: {{ range $j, $line := $f.Lines }}{{ if $j }}, {{ end }}{{ $line }}{{ end }}{{ end }} |
+{{ end }}{{ end }}{{ with .TestResults }}
+### Test Results
+
+{{ .Passed }} passed, {{ .Failed }} failed and {{ .Skipped }} skipped tests in {{ len .Packages }} packages.
+
+| Package | Result | Passed | Failed | Skipped | Coverage (%) |
+| --- | --- | ---: | ---: | ---: | ---: |
+{{ range .Packages }}| {{ .Package }} | {{ .Result }} | {{ .Passed }} | {{ .Failed }} | {{ .Skipped }} | {{ if .Functions }}{{ printf "%.2f" .CoveragePercent }}{{ else }}-{{ end }} |
+{{ end }}{{ if .Tests }}
+| Test | Package | Result | Uncovered Functions |
+| --- | --- | --- | --- |
+{{ range .Tests }}| {{ .Test }} | {{ .Package }} | {{ .Result }} | {{ range $i, $g := .Gaps }}{{ if $i }}<br>{{ end }}{{ $g.Function }} ({{ printf "%.2f" $g.CoveragePercent }}%){{ end }} |
+{{ end }}{{ end }}{{ end }}{{ if .HotFunctions }}
+### Hot Functions
+
+| Function | Location | Effective Lines | Coverage (%) | Hits (min / avg / max) |
+| --- | --- | ---: | ---: | ---: |
+{{ range .HotFunctions }}| {{ .Function }} | {{ .FileName }}:{{ .StartLine }} | {{ .TotalEffectiveLines }} | {{ printf "%.2f" .CoveragePercent }} | {{ HitsSummary .Hits }} |
+{{ end }}{{ end }}{{ if .HotStatements }}
+### Hot Statements
+
+| Statement | Function | Hits |
+| --- | --- | ---: |
+{{ range .HotStatements }}| {{ .FileName }}:{{ .StartLine }}-{{ .EndLine }} | {{ .Function }} | {{ .Hits }} |
+{{ end }}{{ end }}{{ if .ExcludedFunctions }}
+### Excluded Functions
+
+| Function | File | Excluded Lines | Pattern |
+| --- | --- | ---: | --- |
+{{ range .ExcludedFunctions }}| {{ .Function }} | {{ .FileName }} | {{ .TotalLines }} | ` + "`{{ .Pattern }}`" + ` |
+{{ end }}{{ end }}`
@@ -0,0 +1,283 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	treemapWidth  = 1200
+	treemapHeight = 800
+	// treemapPadding is the space between a directory rectangle and its files.
+	treemapPadding = 2
+	// treemapLabelWidth is the minimum width of a rectangle to show its label.
+	treemapLabelWidth = 60
+	// treemapLabelHeight is the minimum height of a rectangle to show its label.
+	treemapLabelHeight = 14
+)
+
+// treemapReportGenerator generates a standalone html report that contains a svg treemap,
+// the size of the rectangle is the statement count and the color is the coverage percent.
+type treemapReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*treemapReportGenerator)(nil)
+
+// NewTreemapReportGenerator creates a treemap report generator.
+func NewTreemapReportGenerator(outputPath string, reportName string, logger logrus.FieldLogger) ReportGenerator {
+	return &treemapReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		logger:     logger,
+	}
+}
+
+// TreemapRect represents a rectangle in the treemap.
+type TreemapRect struct {
+	// Name is the directory or file name of the rectangle.
+	Name string
+	// X, Y, Width and Height indicates the position and the size of the rectangle.
+	X, Y, Width, Height float64
+	// TotalEffectiveLines indicates effective lines of the rectangle.
+	TotalEffectiveLines int
+	// CoveragePercent is the coverage percent (with ignorance) of the rectangle.
+	CoveragePercent float64
+	// Color is the fill color of the rectangle.
+	Color string
+	// ShowLabel indicates whether the rectangle is large enough to show its label.
+	ShowLabel bool
+}
+
+// treemap contains the inputs of the treemap template.
+type treemap struct {
+	StatisticsType  StatisticsType
+	ComparedBranch  string
+	Width           int
+	Height          int
+	Directories     []*TreemapRect
+	Files           []*TreemapRect
+	CoveragePercent float64
+}
+
+// GenerateReport generates the treemap report of the statistics.
+func (g *treemapReportGenerator) GenerateReport(statistics *Statistics) error {
+	reportFile := filepath.Join(g.outputPath, treemapName(g.reportName))
+	f, err := os.Create(reportFile)
+	if err != nil {
+		return fmt.Errorf("create treemap report file: %w", err)
+	}
+	defer f.Close()
+
+	directories, files := layoutTreemap(statistics.CoverageProfile, treemapWidth, treemapHeight)
+	err = htmlTreemapReportTemplate.Execute(f, &treemap{
+		StatisticsType:  statistics.StatisticsType,
+		ComparedBranch:  statistics.ComparedBranch,
+		Width:           treemapWidth,
+		Height:          treemapHeight,
+		Directories:     directories,
+		Files:           files,
+		CoveragePercent: statistics.TotalCoveragePercent,
+	})
+	if err != nil {
+		return fmt.Errorf("write treemap report: %w", err)
+	}
+
+	g.logger.Infof("generate treemap coverage report: %s", reportFile)
+	return nil
+}
+
+func treemapName(reportName string) string {
+	return fmt.Sprintf("%s-treemap.html", reportName)
+}
+
+// layoutTreemap lays out the directories in the area, then lays out the files in each directory.
+// The profiles without effective lines are not shown.
+func layoutTreemap(profiles []*CoverageProfile, width, height float64) ([]*TreemapRect, []*TreemapRect) {
+	filesByDir := make(map[string][]*CoverageProfile)
+	for _, p := range profiles {
+		if p.TotalEffectiveLines == 0 {
+			continue
+		}
+		dir := path.Dir(p.FileName)
+		filesByDir[dir] = append(filesByDir[dir], p)
+	}
+
+	var cells []*HeatmapCell
+	for _, cell := range heatmap(profiles) {
+		if cell.TotalEffectiveLines != 0 {
+			cells = append(cells, cell)
+		}
+	}
+	sort.SliceStable(cells, func(i, j int) bool {
+		return cells[i].TotalEffectiveLines > cells[j].TotalEffectiveLines
+	})
+
+	var sizes []float64
+	for _, cell := range cells {
+		sizes = append(sizes, float64(cell.TotalEffectiveLines))
+	}
+
+	var directories, files []*TreemapRect
+	for i, area := range squarify(sizes, rect{w: width, h: height}) {
+		cell := cells[i]
+		directories = append(directories, newTreemapRect(
+			cell.Directory, area, cell.TotalEffectiveLines, cell.CoveragePercent,
+		))
+
+		dirFiles := filesByDir[cell.Directory]
+		sort.SliceStable(dirFiles, func(i, j int) bool {
+			return dirFiles[i].TotalEffectiveLines > dirFiles[j].TotalEffectiveLines
+		})
+		var fileSizes []float64
+		for _, p := range dirFiles {
+			fileSizes = append(fileSizes, float64(p.TotalEffectiveLines))
+		}
+		for j, fileArea := range squarify(fileSizes, area.shrink(treemapPadding)) {
+			p := dirFiles[j]
+			files = append(files, newTreemapRect(
+				path.Base(p.FileName), fileArea, p.TotalEffectiveLines,
+				percentCovered(p.TotalEffectiveLines, p.CoveredLines, p.CoveredButIgnoredLines),
+			))
+		}
+	}
+
+	return directories, files
+}
+
+func newTreemapRect(name string, r rect, lines int, percent float64) *TreemapRect {
+	return &TreemapRect{
+		Name:                name,
+		X:                   r.x,
+		Y:                   r.y,
+		Width:               r.w,
+		Height:              r.h,
+		TotalEffectiveLines: lines,
+		CoveragePercent:     percent,
+		Color:               heatmapColor(percent),
+		ShowLabel:           r.w >= treemapLabelWidth && r.h >= treemapLabelHeight,
+	}
+}
+
+// rect represents an area in the treemap.
+type rect struct {
+	x, y, w, h float64
+}
+
+// shrink returns the area inside the rectangle with the padding.
+func (r rect) shrink(padding float64) rect {
+	if r.w <= 2*padding || r.h <= 2*padding {
+		return r
+	}
+	return rect{x: r.x + padding, y: r.y + padding, w: r.w - 2*padding, h: r.h - 2*padding}
+}
+
+// squarify lays out the sizes in the area with the squarified treemap algorithm,
+// which keeps the aspect ratio of the rectangles close to 1. The sizes should be sorted in descending order.
+// See https://www.win.tue.nl/~vanwijk/stm.pdf for more information.
+func squarify(sizes []float64, area rect) []rect {
+	var total float64
+	for _, s := range sizes {
+		total += s
+	}
+	if total == 0 {
+		return make([]rect, len(sizes))
+	}
+
+	// scale the sizes to fill the area
+	areas := make([]float64, len(sizes))
+	for i, s := range sizes {
+		areas[i] = s * area.w * area.h / total
+	}
+
+	var result []rect
+	for len(areas) != 0 {
+		side := area.w
+		if area.h < side {
+			side = area.h
+		}
+
+		n := 1
+		for n < len(areas) && worstRatio(areas[:n+1], side) <= worstRatio(areas[:n], side) {
+			n++
+		}
+
+		var rects []rect
+		rects, area = layoutRow(areas[:n], area)
+		result = append(result, rects...)
+		areas = areas[n:]
+	}
+	return result
+}
+
+// worstRatio returns the max aspect ratio of the rectangles when the row is laid out along the side.
+func worstRatio(row []float64, side float64) float64 {
+	var sum, max, min float64
+	min = row[0]
+	for _, a := range row {
+		sum += a
+		if a > max {
+			max = a
+		}
+		if a < min {
+			min = a
+		}
+	}
+	if sum == 0 || min == 0 {
+		return 0
+	}
+	s2, side2 := sum*sum, side*side
+	ratio := side2 * max / s2
+	if r := s2 / (side2 * min); r > ratio {
+		ratio = r
+	}
+	return ratio
+}
+
+// layoutRow lays out the row along the shorter side of the area, returns the rectangles and the remaining area.
+func layoutRow(row []float64, area rect) ([]rect, rect) {
+	var sum float64
+	for _, a := range row {
+		sum += a
+	}
+
+	var rects []rect
+	if area.w >= area.h {
+		width := sum / area.h
+		y := area.y
+		for _, a := range row {
+			h := a / width
+			rects = append(rects, rect{x: area.x, y: y, w: width, h: h})
+			y += h
+		}
+		return rects, rect{x: area.x + width, y: area.y, w: area.w - width, h: area.h}
+	}
+
+	height := sum / area.w
+	x := area.x
+	for _, a := range row {
+		w := a / height
+		rects = append(rects, rect{x: x, y: area.y, w: w, h: height})
+		x += w
+	}
+	return rects, rect{x: area.x, y: area.y + height, w: area.w, h: area.h - height}
+}
+
+// htmlTreemapReportTemplate is the render engine for html treemap report.
+var htmlTreemapReportTemplate = template.Must(
+	template.New("htmlTreemapReportTemplate").
+		Funcs(template.FuncMap{"IsFullCoverageReport": isFullCoverageReport}).
+		Funcs(template.FuncMap{"IsDiffCoverageReport": isDiffCoverageReport}).
+		Parse(htmlTreemapReport),
+)
@@ -0,0 +1,100 @@
+package report
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSquarify(t *testing.T) {
+	t.Run("fill the area", func(t *testing.T) {
+		area := rect{w: 600, h: 400}
+		sizes := []float64{6, 6, 4, 3, 2, 2, 1}
+		rects := squarify(sizes, area)
+		assert.Len(t, rects, len(sizes))
+
+		var total float64
+		for i, r := range rects {
+			total += r.w * r.h
+			assert.InDelta(t, sizes[i]*area.w*area.h/24, r.w*r.h, 1e-6)
+			assert.True(t, r.x >= 0 && r.y >= 0 && r.x+r.w <= area.w+1e-6 && r.y+r.h <= area.h+1e-6)
+		}
+		assert.InDelta(t, area.w*area.h, total, 1e-6)
+	})
+
+	t.Run("zero sizes", func(t *testing.T) {
+		rects := squarify([]float64{0, 0}, rect{w: 100, h: 100})
+		assert.Equal(t, []rect{{}, {}}, rects)
+	})
+}
+
+func TestLayoutTreemap(t *testing.T) {
+	t.Run("layoutTreemap", func(t *testing.T) {
+		directories, files := layoutTreemap([]*CoverageProfile{
+			{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 30, CoveredLines: 30},
+			{FileName: "github.com/Azure/gocover/pkg/foo/zoo.go", TotalEffectiveLines: 10},
+			{FileName: "github.com/Azure/gocover/pkg/bar/bar.go", TotalEffectiveLines: 60, CoveredLines: 30},
+			{FileName: "github.com/Azure/gocover/pkg/bar/empty.go"},
+		}, 100, 100)
+
+		assert.Len(t, directories, 2)
+		assert.Equal(t, "github.com/Azure/gocover/pkg/bar", directories[0].Name)
+		assert.InDelta(t, 6000, directories[0].Width*directories[0].Height, 1e-6)
+		assert.Equal(t, heatmapColor(50), directories[0].Color)
+
+		assert.Len(t, files, 3)
+		assert.Equal(t, "bar.go", files[0].Name)
+		assert.Equal(t, "foo.go", files[1].Name)
+		assert.Equal(t, 100.0, files[1].CoveragePercent)
+		assert.Equal(t, "zoo.go", files[2].Name)
+		assert.Equal(t, 0.0, files[2].CoveragePercent)
+		assert.False(t, math.IsNaN(files[2].Width))
+	})
+}
+
+func TestGenerateTreemapReport(t *testing.T) {
+	t.Run("generate treemap report", func(t *testing.T) {
+		path, clean := temporalDir()
+		defer clean()
+
+		g := NewTreemapReportGenerator(path, "coverage", logrus.New())
+		err := g.GenerateReport(&Statistics{
+			StatisticsType:       FullStatisticsType,
+			TotalCoveragePercent: 75,
+			CoverageProfile: []*CoverageProfile{
+				{FileName: "github.com/Azure/gocover/pkg/foo/foo.go", TotalEffectiveLines: 30, CoveredLines: 20},
+			},
+		})
+		assert.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(path, treemapName("coverage")))
+		assert.NoError(t, err)
+		report := string(data)
+		assert.True(t, strings.Contains(report, "Full Coverage Treemap"))
+		assert.True(t, strings.Contains(report, "<svg"))
+		assert.True(t, strings.Contains(report, "github.com/Azure/gocover/pkg/foo: 66.67% of 30 lines"))
+		assert.True(t, strings.Contains(report, "foo.go"))
+	})
+
+	t.Run("generate with multiple generators", func(t *testing.T) {
+		path, clean := temporalDir()
+		defer clean()
+
+		g := NewReportGenerators(
+			NewReportGenerator("colorful", path, "coverage", logrus.New()),
+			NewTreemapReportGenerator(path, "coverage", logrus.New()),
+		)
+		err := g.GenerateReport(&Statistics{StatisticsType: DiffStatisticsType})
+		assert.NoError(t, err)
+
+		for _, name := range []string{finalName("coverage"), treemapName("coverage")} {
+			_, err := os.Stat(filepath.Join(path, name))
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,409 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	trendWidth  = 800
+	trendHeight = 320
+	// trendMarginLeft, trendMarginTop and trendMarginBottom are the space of the axis labels around the plot area.
+	trendMarginLeft   = 50
+	trendMarginTop    = 20
+	trendMarginBottom = 40
+	// trendLegendWidth is the space of the legend on the right of the plot area.
+	trendLegendWidth = 200
+	// trendLegendHeight is the height of each series in the legend.
+	trendLegendHeight = 18
+	// trendYTicks is the number of intervals on the coverage axis.
+	trendYTicks = 5
+)
+
+// trendColors are the colors of the series, they're reused when there are more series.
+var trendColors = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// CoverageTrend represents the coverage of the latest runs in history.
+type CoverageTrend struct {
+	// Runs is the number of the latest runs in history that the trend is made of.
+	Runs int
+	// Series are the coverage over time, the first one is the total coverage and the others are the packages.
+	Series []*TrendSeries
+}
+
+// TrendSeries represents the coverage of the total or a package over time.
+type TrendSeries struct {
+	// Name is the package pattern, or "total" for the total coverage.
+	Name string
+	// Points are the coverage of each run, from the oldest to the latest.
+	Points []*TrendPoint
+}
+
+// TrendPoint represents the coverage of a run in history.
+type TrendPoint struct {
+	Timestamp       time.Time
+	Commit          string
+	CoveragePercent float64
+}
+
+// trendReportGenerator generates a svg and a png line chart of the coverage trend,
+// the svg can be embedded in the README and the png can be uploaded where svg is not supported.
+type trendReportGenerator struct {
+	// outputPath report path
+	outputPath string
+	// reportName report name
+	reportName string
+	// logger
+	logger logrus.FieldLogger
+}
+
+var _ ReportGenerator = (*trendReportGenerator)(nil)
+
+// NewTrendReportGenerator creates a trend chart generator, it requires the coverage trend in the statistics.
+func NewTrendReportGenerator(outputPath string, reportName string, logger logrus.FieldLogger) ReportGenerator {
+	return &trendReportGenerator{
+		outputPath: outputPath,
+		reportName: reportName,
+		logger:     logger,
+	}
+}
+
+// GenerateReport writes the trend chart of the statistics in svg and png, nothing is written if there is no trend.
+func (g *trendReportGenerator) GenerateReport(statistics *Statistics) error {
+	if statistics.Trend == nil {
+		g.logger.Debugf("no coverage trend, skip the trend chart")
+		return nil
+	}
+
+	chart := layoutTrend(statistics.Trend, trendWidth, trendHeight)
+
+	svgFile := filepath.Join(g.outputPath, trendName(g.reportName, "svg"))
+	svg, err := renderTrendSVG(chart)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(svgFile, []byte(svg), 0644); err != nil {
+		return fmt.Errorf("write trend chart: %w", err)
+	}
+
+	pngFile := filepath.Join(g.outputPath, trendName(g.reportName, "png"))
+	f, err := os.Create(pngFile)
+	if err != nil {
+		return fmt.Errorf("create trend chart file: %w", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, drawTrendPNG(chart)); err != nil {
+		return fmt.Errorf("write trend chart: %w", err)
+	}
+
+	g.logger.Infof("generate coverage trend chart: %s, %s", svgFile, pngFile)
+	return nil
+}
+
+func trendName(reportName string, ext string) string {
+	return fmt.Sprintf("%s-trend.%s", reportName, ext)
+}
+
+// trendChart contains the inputs of the trend chart template, the positions are in pixels.
+type trendChart struct {
+	Width, Height int
+	// Left, Top, Right and Bottom are the edges of the plot area.
+	Left, Top, Right, Bottom float64
+	// LegendX is the left edge of the legend.
+	LegendX float64
+	YTicks  []*trendTick
+	XTicks  []*trendTick
+	Lines   []*trendLine
+}
+
+// trendTick is a label on an axis.
+type trendTick struct {
+	Position float64
+	Label    string
+}
+
+// trendLine is a series in the chart.
+type trendLine struct {
+	Name  string
+	Color string
+	// Latest is the coverage of the latest run.
+	Latest float64
+	// LegendY is the position of the series in the legend.
+	LegendY float64
+	Points  []*trendLinePoint
+}
+
+// trendLinePoint is a run of the series in the chart.
+type trendLinePoint struct {
+	X, Y  float64
+	Title string
+}
+
+// PolylinePoints returns the points attribute of the svg polyline.
+func (l *trendLine) PolylinePoints() string {
+	var points []string
+	for _, p := range l.Points {
+		points = append(points, fmt.Sprintf("%.2f,%.2f", p.X, p.Y))
+	}
+	return strings.Join(points, " ")
+}
+
+// layoutTrend places the runs by their time on the x axis and their coverage on the y axis,
+// the range of the y axis is fitted to the coverage in steps of 10 percent so that small changes are visible.
+func layoutTrend(trend *CoverageTrend, width, height int) *trendChart {
+	chart := &trendChart{
+		Width:  width,
+		Height: height,
+		Left:   trendMarginLeft,
+		Top:    trendMarginTop,
+		Right:  float64(width - trendLegendWidth),
+		Bottom: float64(height - trendMarginBottom),
+	}
+	chart.LegendX = chart.Right + 16
+
+	var (
+		first, last time.Time
+		low, high   = 100.0, 0.0
+	)
+	for _, s := range trend.Series {
+		for _, p := range s.Points {
+			if first.IsZero() || p.Timestamp.Before(first) {
+				first = p.Timestamp
+			}
+			if p.Timestamp.After(last) {
+				last = p.Timestamp
+			}
+			low = math.Min(low, p.CoveragePercent)
+			high = math.Max(high, p.CoveragePercent)
+		}
+	}
+	if low > high {
+		low, high = 0, 100
+	}
+	low = math.Max(0, math.Floor(low/10)*10)
+	high = math.Min(100, math.Ceil(high/10)*10)
+	if high-low < 10 {
+		if high < 100 {
+			high = low + 10
+		} else {
+			low = high - 10
+		}
+	}
+
+	x := func(t time.Time) float64 {
+		span := last.Sub(first)
+		if span <= 0 {
+			return (chart.Left + chart.Right) / 2
+		}
+		return chart.Left + float64(t.Sub(first))/float64(span)*(chart.Right-chart.Left)
+	}
+	y := func(percent float64) float64 {
+		return chart.Bottom - (percent-low)/(high-low)*(chart.Bottom-chart.Top)
+	}
+
+	for i := 0; i <= trendYTicks; i++ {
+		percent := low + (high-low)*float64(i)/trendYTicks
+		chart.YTicks = append(chart.YTicks, &trendTick{
+			Position: y(percent),
+			Label:    strconv.FormatFloat(percent, 'f', -1, 64) + "%",
+		})
+	}
+	if !first.IsZero() {
+		chart.XTicks = append(chart.XTicks, &trendTick{Position: x(first), Label: first.Format(time.DateOnly)})
+		if last.Format(time.DateOnly) != first.Format(time.DateOnly) {
+			chart.XTicks = append(chart.XTicks, &trendTick{Position: x(last), Label: last.Format(time.DateOnly)})
+		}
+	}
+
+	for i, s := range trend.Series {
+		line := &trendLine{
+			Name:    s.Name,
+			Color:   trendColors[i%len(trendColors)],
+			LegendY: chart.Top + float64(i)*trendLegendHeight,
+		}
+		for _, p := range s.Points {
+			line.Points = append(line.Points, &trendLinePoint{
+				X:     x(p.Timestamp),
+				Y:     y(p.CoveragePercent),
+				Title: fmt.Sprintf("%s %s: %.2f%%", p.Timestamp.Format(time.RFC3339), shortCommit(p.Commit), p.CoveragePercent),
+			})
+			line.Latest = p.CoveragePercent
+		}
+		chart.Lines = append(chart.Lines, line)
+	}
+	return chart
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+// trendSVG renders the trend chart that is embedded in the html report.
+func trendSVG(trend *CoverageTrend) (template.HTML, error) {
+	svg, err := renderTrendSVG(layoutTrend(trend, trendWidth, trendHeight))
+	return template.HTML(svg), err
+}
+
+func renderTrendSVG(chart *trendChart) (string, error) {
+	var buf bytes.Buffer
+	if err := trendSVGTemplate.Execute(&buf, chart); err != nil {
+		return "", fmt.Errorf("render trend chart: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// trendSVGTemplate is the render engine for the svg trend chart, the styles are inline so that it's standalone.
+var trendSVGTemplate = template.Must(template.New("trendSVGTemplate").Parse(trendChartSVG))
+
+var trendChartSVG = "" +
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{ .Width }}" height="{{ .Height }}" viewBox="0 0 {{ .Width }} {{ .Height }}" font-family="sans-serif" font-size="11">
+    <rect width="{{ .Width }}" height="{{ .Height }}" fill="#ffffff" />
+    {{ range .YTicks }}
+    <line x1="{{ $.Left }}" y1="{{ printf "%.2f" .Position }}" x2="{{ $.Right }}" y2="{{ printf "%.2f" .Position }}" stroke="#e0e0e0" />
+    <text x="{{ $.Left }}" y="{{ printf "%.2f" .Position }}" dx="-6" dy="4" text-anchor="end">{{ .Label }}</text>
+    {{ end }}
+    {{ range .XTicks }}
+    <text x="{{ printf "%.2f" .Position }}" y="{{ $.Bottom }}" dy="18" text-anchor="middle">{{ .Label }}</text>
+    {{ end }}
+    <polyline points="{{ .Left }},{{ .Top }} {{ .Left }},{{ .Bottom }} {{ .Right }},{{ .Bottom }}" fill="none" stroke="#424242" />
+    {{ range .Lines }}
+    <g>
+        <polyline points="{{ .PolylinePoints }}" fill="none" stroke="{{ .Color }}" stroke-width="2" />
+        {{ $color := .Color }}
+        {{ range .Points }}
+        <circle cx="{{ printf "%.2f" .X }}" cy="{{ printf "%.2f" .Y }}" r="3" fill="{{ $color }}"><title>{{ .Title }}</title></circle>
+        {{ end }}
+        <rect x="{{ $.LegendX }}" y="{{ printf "%.2f" .LegendY }}" width="12" height="12" fill="{{ .Color }}" />
+        <text x="{{ $.LegendX }}" y="{{ printf "%.2f" .LegendY }}" dx="18" dy="10">{{ .Name }} {{ printf "%.2f" .Latest }}%</text>
+    </g>
+    {{ end }}
+</svg>
+`
+
+// drawTrendPNG draws the trend chart in the same layout as the svg. As there is no font in the standard library,
+// only the digits and the symbols of the axis labels and the latest coverage of the legend are drawn in a tiny bitmap font,
+// the series are told apart by their colors, which are in the same order as the svg.
+func drawTrendPNG(chart *trendChart) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, chart.Width, chart.Height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	grid, axis, text := parseHexColor("#e0e0e0"), parseHexColor("#424242"), parseHexColor("#000000")
+	for _, tick := range chart.YTicks {
+		drawLine(img, chart.Left, tick.Position, chart.Right, tick.Position, 1, grid)
+		drawText(img, tick.Label, chart.Left-6, tick.Position-5, true, text)
+	}
+	for _, tick := range chart.XTicks {
+		drawText(img, tick.Label, tick.Position+textWidth(tick.Label)/2, chart.Bottom+10, true, text)
+	}
+	drawLine(img, chart.Left, chart.Top, chart.Left, chart.Bottom, 1, axis)
+	drawLine(img, chart.Left, chart.Bottom, chart.Right, chart.Bottom, 1, axis)
+
+	for _, line := range chart.Lines {
+		c := parseHexColor(line.Color)
+		for i, p := range line.Points {
+			if i > 0 {
+				prev := line.Points[i-1]
+				drawLine(img, prev.X, prev.Y, p.X, p.Y, 2, c)
+			}
+			fillRect(img, int(p.X)-2, int(p.Y)-2, 5, 5, c)
+		}
+		fillRect(img, int(chart.LegendX), int(line.LegendY), 12, 12, c)
+		drawText(img, strconv.FormatFloat(line.Latest, 'f', 2, 64)+"%", chart.LegendX+18, line.LegendY+1, false, text)
+	}
+	return img
+}
+
+// drawLine draws the line from (x1, y1) to (x2, y2) with the width in pixels.
+func drawLine(img *image.RGBA, x1, y1, x2, y2 float64, width int, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1)))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		fillRect(img, int(x1+(x2-x1)*t), int(y1+(y2-y1)*t), width, width, c)
+	}
+}
+
+func fillRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			img.SetRGBA(i, j, c)
+		}
+	}
+}
+
+const (
+	// glyphScale is the size of a pixel of the glyphs.
+	glyphScale = 2
+	// glyphAdvance is the width of a glyph and the space after it.
+	glyphAdvance = 4 * glyphScale
+)
+
+// glyphs are the 3x5 bitmaps of the characters of the labels, each row is 3 bits from left to right.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'-': {0, 0, 7, 0, 0},
+	'.': {0, 0, 0, 0, 2},
+	'%': {5, 1, 2, 4, 5},
+}
+
+func textWidth(s string) float64 {
+	return float64(len(s) * glyphAdvance)
+}
+
+// drawText draws the text from (x, y), or ends the text at x if it's right aligned.
+// The characters that have no glyph are left blank.
+func drawText(img *image.RGBA, s string, x, y float64, rightAligned bool, c color.RGBA) {
+	if rightAligned {
+		x -= textWidth(s)
+	}
+	for i, r := range s {
+		glyph, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>col) != 0 {
+					fillRect(img, int(x)+i*glyphAdvance+col*glyphScale, int(y)+row*glyphScale, glyphScale, glyphScale, c)
+				}
+			}
+		}
+	}
+}
+
+// parseHexColor parses the color in #rrggbb format.
+func parseHexColor(s string) color.RGBA {
+	v, _ := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
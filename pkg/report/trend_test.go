@@ -0,0 +1,118 @@
+package report
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCoverageTrend() *CoverageTrend {
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &CoverageTrend{
+		Runs: 3,
+		Series: []*TrendSeries{
+			{Name: "total", Points: []*TrendPoint{
+				{Timestamp: day, Commit: "0123456789abcdef", CoveragePercent: 72},
+				{Timestamp: day.AddDate(0, 0, 1), Commit: "1111111111111111", CoveragePercent: 75},
+				{Timestamp: day.AddDate(0, 0, 4), Commit: "2222222222222222", CoveragePercent: 78.5},
+			}},
+			{Name: "pkg/api/...", Points: []*TrendPoint{
+				{Timestamp: day.AddDate(0, 0, 1), Commit: "1111111111111111", CoveragePercent: 81},
+				{Timestamp: day.AddDate(0, 0, 4), Commit: "2222222222222222", CoveragePercent: 84},
+			}},
+		},
+	}
+}
+
+func TestLayoutTrend(t *testing.T) {
+	t.Run("fit the runs", func(t *testing.T) {
+		chart := layoutTrend(testCoverageTrend(), trendWidth, trendHeight)
+
+		// the coverage ranges from 72% to 84%, which are fitted in the 70% to 90% axis.
+		assert.Len(t, chart.YTicks, trendYTicks+1)
+		assert.Equal(t, "70%", chart.YTicks[0].Label)
+		assert.Equal(t, "90%", chart.YTicks[trendYTicks].Label)
+		assert.Equal(t, chart.Bottom, chart.YTicks[0].Position)
+		assert.Equal(t, chart.Top, chart.YTicks[trendYTicks].Position)
+
+		assert.Len(t, chart.XTicks, 2)
+		assert.Equal(t, "2024-05-01", chart.XTicks[0].Label)
+		assert.Equal(t, "2024-05-05", chart.XTicks[1].Label)
+
+		assert.Len(t, chart.Lines, 2)
+		total, api := chart.Lines[0], chart.Lines[1]
+		assert.Equal(t, chart.Left, total.Points[0].X)
+		assert.Equal(t, chart.Right, total.Points[2].X)
+		assert.InDelta(t, chart.Left+(chart.Right-chart.Left)/4, total.Points[1].X, 1e-6)
+		assert.Equal(t, api.Points[0].X, total.Points[1].X)
+		assert.Equal(t, 78.5, total.Latest)
+		assert.Equal(t, 84.0, api.Latest)
+		assert.NotEqual(t, total.Color, api.Color)
+		assert.Equal(t, "2024-05-05T12:00:00Z 22222222: 78.50%", total.Points[2].Title)
+	})
+
+	t.Run("single run", func(t *testing.T) {
+		chart := layoutTrend(&CoverageTrend{Runs: 1, Series: []*TrendSeries{
+			{Name: "total", Points: []*TrendPoint{{Timestamp: time.Now(), CoveragePercent: 100}}},
+		}}, trendWidth, trendHeight)
+		assert.Equal(t, "90%", chart.YTicks[0].Label)
+		assert.Equal(t, "100%", chart.YTicks[trendYTicks].Label)
+		assert.Len(t, chart.XTicks, 1)
+		assert.Equal(t, (chart.Left+chart.Right)/2, chart.Lines[0].Points[0].X)
+		assert.Equal(t, chart.Top, chart.Lines[0].Points[0].Y)
+	})
+}
+
+func TestGenerateTrendReport(t *testing.T) {
+	dir := t.TempDir()
+	g := NewTrendReportGenerator(dir, "coverage", logrus.New())
+
+	t.Run("no trend", func(t *testing.T) {
+		assert.NoError(t, g.GenerateReport(&Statistics{}))
+		_, err := os.Stat(filepath.Join(dir, "coverage-trend.svg"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("svg and png", func(t *testing.T) {
+		assert.NoError(t, g.GenerateReport(&Statistics{Trend: testCoverageTrend()}))
+
+		svg, err := os.ReadFile(filepath.Join(dir, "coverage-trend.svg"))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(svg), "<svg"))
+		assert.Contains(t, string(svg), "pkg/api/... 84.00%")
+		assert.Equal(t, 2, strings.Count(string(svg), `stroke-width="2"`))
+
+		f, err := os.Open(filepath.Join(dir, "coverage-trend.png"))
+		assert.NoError(t, err)
+		defer f.Close()
+		img, err := png.Decode(f)
+		assert.NoError(t, err)
+		assert.Equal(t, trendWidth, img.Bounds().Dx())
+		assert.Equal(t, trendHeight, img.Bounds().Dy())
+
+		// the last point of the total coverage is drawn in the color of the series.
+		chart := layoutTrend(testCoverageTrend(), trendWidth, trendHeight)
+		p := chart.Lines[0].Points[2]
+		r, g, b, _ := img.At(int(p.X), int(p.Y)).RGBA()
+		c := parseHexColor(chart.Lines[0].Color)
+		assert.Equal(t, []uint32{uint32(c.R), uint32(c.G), uint32(c.B)}, []uint32{r >> 8, g >> 8, b >> 8})
+	})
+}
+
+func TestTrendInHTMLReport(t *testing.T) {
+	dir := t.TempDir()
+	g := NewReportGenerator("colorful", dir, "coverage", logrus.New())
+	assert.NoError(t, g.GenerateReport(&Statistics{StatisticsType: FullStatisticsType, Trend: testCoverageTrend()}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "coverage.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "Coverage Trend")
+	assert.Contains(t, string(data), "The coverage of the last 3 runs in history.")
+	assert.Contains(t, string(data), "<svg")
+}
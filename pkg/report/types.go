@@ -1,7 +1,12 @@
 package report
 
 import (
+	"fmt"
 	"html/template"
+	"time"
+
+	"github.com/Azure/gocover/pkg/ci"
+	"github.com/Azure/gocover/pkg/parser"
 )
 
 type StatisticsType string
@@ -28,16 +33,417 @@ type Statistics struct {
 	TotalCoveredButIgnoredLines int
 	// TotalViolationLines represents all the lines that miss test coverage.
 	TotalViolationLines int
+	// TotalWeakCoveredLines represents the lines that are reached only once.
+	TotalWeakCoveredLines int
+	// TotalPartialCoveredLines represents the lines that are reached but not all of their blocks are.
+	TotalPartialCoveredLines int
+	// TotalMovedLines represents the lines that are moved from the code deleted in the diff, which don't count for coverage.
+	TotalMovedLines int
 	// TotalCoveragePercent represents the coverage percent for current diff.
 	TotalCoveragePercent float64
 	// TotalCoverageWithoutIgnore represents the coverage percent for current diff without ignorance
 	TotalCoverageWithoutIgnore float64
+	// IgnorePolicy indicates how the ignored statements count for coverage, "exclude" or "count".
+	// The ignored lines are zero when they're counted, as all the statements count for coverage.
+	IgnorePolicy string
 	// CoverageProfile represents the coverage profile for a specific file.
 	CoverageProfile []*CoverageProfile
 	// StatisticsType indicates which type the Statistics is.
 	StatisticsType StatisticsType
 	// exclude files that won't take participate to coverage calculation.
 	ExcludeFiles []string
+	// SkippedFiles represents the files that cannot be used for coverage calculation.
+	SkippedFiles []*SkippedFile
+	// FileErrors represents the files that fail to convert when the conversion continues on error.
+	FileErrors []*FileError
+	// ExcludedFunctions represents the functions that are excluded from coverage calculation by name patterns.
+	ExcludedFunctions []*ExcludedFunction
+	// DirectoryTree represents the coverage aggregated hierarchically by directory, it's nil unless the directory tree is enabled.
+	DirectoryTree *DirectoryNode
+	// LabelStatistics represents the coverage of each labeled cover profiles, such as unit, integration or e2e.
+	LabelStatistics []*LabelStatistics
+	// LabelGaps represents the statements that are covered by some labels but missed by the others when the cover profiles are labeled.
+	LabelGaps []*LabelGap
+	// Teams represents the coverage of the files owned by each team in the ownership file.
+	Teams []*TeamCoverage
+	// ClosureStatistics represents the coverage of the function literals, it's nil when there is no
+	// function literal or the function literals are folded into their enclosing functions.
+	ClosureStatistics *ClosureStatistics
+	// LeastCoveredFunctions represents the functions that have the most uncovered lines.
+	LeastCoveredFunctions []*FunctionCoverage
+	// HotFunctions represents the functions that are executed most frequently, it's only collected in count and atomic cover modes.
+	HotFunctions []*FunctionCoverage
+	// HotStatements represents the statements that are executed most frequently, it's only collected in count and atomic cover modes.
+	HotStatements []*HotStatement
+	// NeverCoveredFunctions represents the functions that have no coverage in the last NeverCoveredRuns runs.
+	NeverCoveredFunctions []*FunctionCoverage
+	// NeverCoveredRuns indicates how many runs are checked for NeverCoveredFunctions.
+	NeverCoveredRuns int
+	// CoverageDelta represents the coverage change compared with the stored result of the base commit,
+	// it's nil unless the base comparison is enabled.
+	CoverageDelta *CoverageDelta
+	// Trend represents the coverage of the latest runs in history, it's nil unless the trend is enabled.
+	Trend *CoverageTrend
+	// Lines represents the state of each line, it's only collected when the per-line report is enabled.
+	Lines []*FileLines
+	// DiffFiles represents the changed files shown side by side in diff coverage, it's only collected when the side-by-side view is enabled.
+	DiffFiles []*DiffFile
+	// Packages are the parsed cover profiles with the state of each statement, they're exposed to the custom templates.
+	Packages parser.Packages
+	// CI is the information of the CI run, it's nil when it doesn't run in CI.
+	CI *ci.Environment
+	// RunLabels are the key/value labels attached to the run, such as the service, the environment or the test suite,
+	// so the results are sliced downstream.
+	RunLabels map[string]string
+	// RunSummary represents the aggregate statistics of the run, such as how many files and statements are analyzed.
+	RunSummary *RunSummary
+	// UncoveredCommits represents the commits that introduce the uncovered changed statements, the most uncovered first.
+	// It's only collected when the commit attribution is enabled in diff coverage.
+	UncoveredCommits []*UncoveredCommit
+	// TestResults represents the results of the tests read from the go test -json output, it's nil without the output.
+	TestResults *TestResults
+}
+
+// IgnoreContribution returns how much the ignore annotations contribute to the coverage percent,
+// which is the difference between the coverage with ignorance and the raw coverage.
+func (s *Statistics) IgnoreContribution() float64 {
+	return s.TotalCoveragePercent - s.TotalCoverageWithoutIgnore
+}
+
+// RunSummary represents the aggregate statistics of a run for the observability of the pipelines,
+// they're counted from all the parsed statements before any file is excluded.
+type RunSummary struct {
+	// Files indicates the number of the files that are analyzed.
+	Files int
+	// Functions indicates the number of the functions that are analyzed.
+	Functions int
+	// Statements indicates the number of the statements that are analyzed.
+	Statements int
+	// IgnoredStatements indicates the statements that don't count for coverage.
+	IgnoredStatements int
+	// ChangedStatements indicates the statements that are changed compared with the compared branch, it's zero for full coverage.
+	ChangedStatements int
+	// SkippedFiles indicates the number of the files that are skipped.
+	SkippedFiles int
+	// ParseDuration is how long it takes to parse the cover profiles, including the git diff for diff coverage.
+	ParseDuration time.Duration
+}
+
+// String returns the summary in a line, such as `12 files, 80 functions, 640 statements (20 ignored, 0 changed), 1 skipped files, parsed in 1.2s`.
+func (s *RunSummary) String() string {
+	return fmt.Sprintf("%d files, %d functions, %d statements (%d ignored, %d changed), %d skipped files, parsed in %s",
+		s.Files, s.Functions, s.Statements, s.IgnoredStatements, s.ChangedStatements, s.SkippedFiles, s.ParseDuration.Round(time.Millisecond))
+}
+
+// UncoveredCommit represents the uncovered changed statements that a commit introduces,
+// the commits of the statements are found by git blame of their lines.
+type UncoveredCommit struct {
+	// Commit is the hash of the commit.
+	Commit string `json:"commit"`
+	// Author is the name of the author of the commit.
+	Author string `json:"author"`
+	// UncoveredLines indicates the number of the uncovered statements that the commit introduces.
+	UncoveredLines int `json:"uncoveredLines"`
+	// Files are the files of the uncovered statements with their start lines.
+	Files []*CommitFile `json:"files"`
+}
+
+// ShortCommit returns the abbreviated hash of the commit.
+func (c *UncoveredCommit) ShortCommit() string {
+	if len(c.Commit) > 8 {
+		return c.Commit[:8]
+	}
+	return c.Commit
+}
+
+// CommitFile represents the lines of a file in a commit.
+type CommitFile struct {
+	// FileName indicates which file the lines belong to.
+	FileName string `json:"fileName"`
+	// Lines are the start lines of the statements.
+	Lines []int `json:"lines"`
+}
+
+// TestResults represents the results of the tests of a run, which are read from the go test -json output
+// and linked to the coverage of the packages that they test.
+type TestResults struct {
+	// Passed, Failed and Skipped indicate the number of the top-level tests of each result.
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	// Packages are the test packages that ran, sorted by name.
+	Packages []*TestPackage `json:"packages"`
+	// Tests are the tests that failed or were skipped, the failed ones first.
+	Tests []*TestCase `json:"tests"`
+}
+
+// TestPackage represents the results of the tests of a package.
+type TestPackage struct {
+	// Package is the import path of the package.
+	Package string `json:"package"`
+	// Result is the result of the package, "pass", "fail" or "skip" if it has no test files.
+	Result string `json:"result"`
+	// Passed, Failed and Skipped indicate the number of the top-level tests of each result.
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	// Elapsed is the seconds that the tests of the package took.
+	Elapsed float64 `json:"elapsed"`
+	// Functions indicates the number of the functions of the package in the report, it's zero if the package has no coverage.
+	Functions int `json:"functions"`
+	// CoveragePercent represents the coverage percent of the functions of the package in the report.
+	CoveragePercent float64 `json:"coveragePercent"`
+}
+
+// TestCase represents a top-level test that failed or was skipped, with the coverage gaps that it may leave.
+type TestCase struct {
+	// Package is the import path of the package of the test.
+	Package string `json:"package"`
+	// Test is the name of the test.
+	Test string `json:"test"`
+	// Result is "fail" or "skip".
+	Result string `json:"result"`
+	// Message is the first line of the output of the test or its subtests, such as the failure or the reason to skip.
+	Message string `json:"message,omitempty"`
+	// Gaps are the functions of the package that the test is named after and are not fully covered,
+	// such as the function Foo or the methods of the type Foo for TestFoo, and the method Foo.Bar for TestFoo_Bar.
+	Gaps []*TestGap `json:"gaps,omitempty"`
+}
+
+// TestGap represents a function that is not fully covered.
+type TestGap struct {
+	// FileName indicates which file the function belongs to.
+	FileName string `json:"fileName"`
+	// Function is the name of the function.
+	Function string `json:"function"`
+	// StartLine is the start line of the function.
+	StartLine int `json:"startLine"`
+	// CoveragePercent represents the coverage percent of the function.
+	CoveragePercent float64 `json:"coveragePercent"`
+}
+
+// FileLines represents the state of each line in a file, a line number appears in at most one
+// of Covered, Uncovered and Ignored. When the statements of a line have different states,
+// the line is uncovered if any statement that counts for coverage is uncovered.
+type FileLines struct {
+	// FileName indicates which file the lines belong to.
+	FileName string `json:"fileName"`
+	// Covered are the lines that covered by tests.
+	Covered []int `json:"covered"`
+	// Uncovered are the lines that miss test coverage.
+	Uncovered []int `json:"uncovered"`
+	// Ignored are the lines that don't count for coverage.
+	Ignored []int `json:"ignored"`
+	// Partial are the lines of the statements that are covered but not all of their blocks are.
+	Partial []int `json:"partial"`
+	// Hits maps the lines to the most hit count of their statements, it's only collected in count and atomic cover modes.
+	Hits map[int]int64 `json:"hits,omitempty"`
+	// Changed are the lines that changed compared with the compared branch, it's empty for full coverage.
+	Changed []int `json:"changed"`
+}
+
+// FunctionCoverage represents the coverage of a function.
+type FunctionCoverage struct {
+	// FileName indicates which file the function belongs to.
+	FileName string
+	// Function is the name of the function.
+	Function string
+	// StartLine is the start line of the function.
+	StartLine int
+	// TotalEffectiveLines indicates effective lines of the function.
+	TotalEffectiveLines int
+	// CoveredLines indicates covered lines of the function that count for coverage.
+	CoveredLines int
+	// CoveragePercent represents the coverage percent of the function.
+	CoveragePercent float64
+	// Hits represents the hit counts of the effective lines, it's nil in set cover mode.
+	Hits *HitCounts
+}
+
+// CoverageDelta represents the coverage change of the run compared with the stored result of the base commit.
+type CoverageDelta struct {
+	// BaseCommit is the hash of the commit that the run is compared with.
+	BaseCommit string
+	// BaseCoveragePercent is the coverage percent of the base commit.
+	BaseCoveragePercent float64
+	// CoveragePercentDelta is the change of the coverage percent, it's negative if the coverage regressed.
+	CoveragePercentDelta float64
+	// RegressedPackages are the packages whose coverage is lower than the base commit, the largest drop first.
+	RegressedPackages []*CoverageChange
+	// RegressedFiles are the files whose coverage is lower than the base commit, the largest drop first.
+	RegressedFiles []*CoverageChange
+	// RenamedPackages are the packages whose files are renamed since the base commit, which are compared by their new names.
+	RenamedPackages []*PackageRename
+}
+
+// PackageRename represents a package whose files are moved to another package.
+type PackageRename struct {
+	// From is the package path of the base commit.
+	From string
+	// To is the package path of the run.
+	To string
+}
+
+// CoverageChange represents the coverage change of a package or a file.
+type CoverageChange struct {
+	// Name is the package path or the file name.
+	Name string
+	// BaseCoveragePercent is the coverage percent of the base commit.
+	BaseCoveragePercent float64
+	// CoveragePercent is the coverage percent of the run.
+	CoveragePercent float64
+	// Delta is the change of the coverage percent.
+	Delta float64
+}
+
+// DiffFile represents the git diff of a changed file side by side,
+// the lines that are not close to the changes are skipped.
+type DiffFile struct {
+	// FileName indicates which file the diff belongs to.
+	FileName string
+	// Path is the path of the file relative to the repository, as it's in the git diff.
+	Path string
+	// Rows are the lines of the compared branch on the left and the lines of the HEAD on the right.
+	Rows []*DiffRow
+}
+
+// DiffRow represents a row of the side-by-side diff, a line number is zero if there is no line on that side.
+type DiffRow struct {
+	// OldLine is the line number of the compared branch.
+	OldLine int
+	// Old is the contents of the line of the compared branch.
+	Old string
+	// NewLine is the line number of the HEAD.
+	NewLine int
+	// New is the contents of the line of the HEAD.
+	New string
+	// Deleted indicates the line of the compared branch is deleted.
+	Deleted bool
+	// Added indicates the line of the HEAD is added.
+	Added bool
+	// State is the coverage state of the added line, one of covered, uncovered, ignored and partial,
+	// it's empty if the line has no statement.
+	State string
+	// Skipped indicates the row represents the unchanged lines that are skipped.
+	Skipped bool
+}
+
+// HotStatement represents a statement and how many times it's executed.
+type HotStatement struct {
+	// FileName indicates which file the statement belongs to.
+	FileName string
+	// Function is the name of the function that the statement belongs to.
+	Function string
+	// StartLine is the start line of the statement.
+	StartLine int
+	// EndLine is the end line of the statement.
+	EndLine int
+	// Hits is the hit count of the statement.
+	Hits int64
+}
+
+// HitCounts represents the hit counts of the statements, which are recorded in count and atomic cover modes.
+type HitCounts struct {
+	// Min is the least hit count of the statements.
+	Min int64
+	// Max is the most hit count of the statements.
+	Max int64
+	// Avg is the average hit count of the statements.
+	Avg float64
+}
+
+// LabelStatistics represents the coverage contributed by the cover profiles with the same label.
+// It shares the total and effective lines with the overall Statistics.
+type LabelStatistics struct {
+	// Label is the label of the cover profiles.
+	Label string
+	// TotalCoveredLines indicates total covered lines by the labeled cover profiles.
+	TotalCoveredLines int
+	// TotalCoveredButIgnoredLines indicates the lines that covered by the labeled cover profiles but ignored.
+	TotalCoveredButIgnoredLines int
+	// TotalCoveragePercent represents the coverage percent of the labeled cover profiles.
+	TotalCoveragePercent float64
+	// TotalCoverageWithoutIgnore represents the coverage percent of the labeled cover profiles without ignorance.
+	TotalCoverageWithoutIgnore float64
+	// MissedLines indicates the lines that count for coverage and are covered by the other labels but not by this label,
+	// such as the code covered only on linux when the cover profiles are labeled by the legs of a matrix build.
+	MissedLines int
+}
+
+// LabelGap represents a statement that is covered by some labels but missed by the others.
+type LabelGap struct {
+	// FileName indicates which file the statement belongs to.
+	FileName string
+	// Function is the name of the function that the statement belongs to.
+	Function string
+	// StartLine and EndLine are the lines of the statement.
+	StartLine int
+	EndLine   int
+	// CoveredBy and MissedBy are the labels whose cover profiles cover the statement and miss the statement.
+	CoveredBy []string
+	MissedBy  []string
+}
+
+// TeamCoverage represents the coverage of the files owned by a team, and the budget of the team.
+type TeamCoverage struct {
+	// Team is the name of the team.
+	Team string
+	// Paths are the glob patterns of the files that the team owns.
+	Paths []string
+	// Files indicates the number of the files that the team owns.
+	Files int
+	// TotalEffectiveLines indicates effective lines of the files.
+	TotalEffectiveLines int
+	// TotalCoveredLines indicates covered lines of the files that count for coverage.
+	TotalCoveredLines int
+	// CoveragePercent represents the coverage percent (with ignorance) of the files.
+	CoveragePercent float64
+	// Budget is the least coverage percent of the team, it's not checked if it's zero.
+	Budget float64
+	// OverBudget indicates the coverage is less than the budget.
+	OverBudget bool
+}
+
+// ClosureStatistics represents the coverage of the function literals, such as goroutine bodies and handlers,
+// which is accounted separately from their enclosing functions.
+type ClosureStatistics struct {
+	// Closures indicates the number of the function literals.
+	Closures int
+	// TotalEffectiveLines indicates effective lines of the function literals.
+	TotalEffectiveLines int
+	// TotalCoveredLines indicates covered lines of the function literals that count for coverage.
+	TotalCoveredLines int
+	// TotalCoveragePercent represents the coverage percent of the function literals.
+	TotalCoveragePercent float64
+}
+
+// ExcludedFunction represents a function that is excluded from coverage calculation by a name pattern.
+type ExcludedFunction struct {
+	// FileName indicates which file the function belongs to.
+	FileName string
+	// Function is the name of the function.
+	Function string
+	// Pattern is the name pattern that excludes the function.
+	Pattern string
+	// TotalLines indicates the lines of the function that don't count for coverage,
+	// only the changed lines count for diff coverage.
+	TotalLines int
+}
+
+// SkippedFile represents a file that is skipped at coverage calculation and the reason of it.
+type SkippedFile struct {
+	// FileName indicates which file is skipped.
+	FileName string
+	// Reason indicates why the file is skipped.
+	Reason string
+}
+
+// FileError represents a file that fails to convert and the error of it.
+type FileError struct {
+	// FileName indicates which file fails.
+	FileName string
+	// Error is the message of the error.
+	Error string
 }
 
 // CoverageProfile represents the test coverage information for a file.
@@ -58,6 +464,13 @@ type CoverageProfile struct {
 	TotalViolationLines []int
 	// ViolationSections indicates the violation sections that miss full coverage.
 	ViolationSections []*ViolationSection
+	// WeakCoveredLines indicates the start lines of the statements that are reached only once.
+	WeakCoveredLines []int
+	// PartialCoveredLines indicates the start lines of the statements that are reached but not all of their blocks are,
+	// the blocks nested in the statements, such as the branches of an if statement, are not counted.
+	PartialCoveredLines []int
+	// MovedLines indicates the start lines of the statements that are moved from the code deleted in the diff.
+	MovedLines []int
 	// CodeSnippet represents the output of the ViolationSections, it's calculated from ViolationSections.
 	CodeSnippet []template.HTML
 }
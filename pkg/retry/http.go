@@ -0,0 +1,19 @@
+package retry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// UnexpectedStatus wraps the sentinel error with the status and the head of the body of the unexpected response,
+// the client errors other than rate limiting are permanent, the server errors are retried.
+func UnexpectedStatus(sentinel error, resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("%w: %s: %s", sentinel, resp.Status, bytes.TrimSpace(message))
+	if resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
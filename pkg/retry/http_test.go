@@ -0,0 +1,31 @@
+package retry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnexpectedStatus(t *testing.T) {
+	errSentinel := errors.New("unexpected status code")
+	response := func(code int, body string) *http.Response {
+		return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	err := UnexpectedStatus(errSentinel, response(http.StatusNotFound, " not found\n"))
+	assert.ErrorIs(t, err, errSentinel)
+	assert.EqualError(t, err, "unexpected status code: Not Found: not found")
+	assert.False(t, Retryable(err))
+
+	err = UnexpectedStatus(errSentinel, response(http.StatusTooManyRequests, ""))
+	assert.ErrorIs(t, err, errSentinel)
+	assert.True(t, Retryable(err))
+
+	err = UnexpectedStatus(errSentinel, response(http.StatusBadGateway, strings.Repeat("x", 2048)))
+	assert.True(t, Retryable(err))
+	assert.Len(t, err.Error(), len("unexpected status code: Bad Gateway: ")+1024)
+}
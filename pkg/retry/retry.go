@@ -0,0 +1,149 @@
+// Package retry retries the calls to the external services, such as the db clients,
+// with exponential backoff and jitter, so that a throttled or transient failure doesn't fail the build.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidPolicy = errors.New("invalid retry policy")
+
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultMultiplier     = 2
+	DefaultJitter         = 0.2
+)
+
+// Policy decides how many times and how long to wait before a failed call is retried.
+type Policy struct {
+	// MaxAttempts is the max number of calls including the first one, 1 disables retry.
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait time between retries.
+	MaxBackoff time.Duration
+	// Multiplier multiplies the wait time after each retry.
+	Multiplier float64
+	// Jitter randomizes the wait time by the fraction of it, from 0 to 1,
+	// so that the clients throttled at the same time don't retry at the same time.
+	Jitter float64
+	// Retryable classifies the error of the call, the call is retried only when it returns true.
+	// Retryable is used when it's nil.
+	Retryable func(err error) bool
+}
+
+// DefaultPolicy returns the policy that retries twice, waiting about 1s and 2s.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultMultiplier,
+		Jitter:         DefaultJitter,
+	}
+}
+
+// Validate checks the values of the policy.
+func (p *Policy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("%w: max attempts %d should be at least 1", ErrInvalidPolicy, p.MaxAttempts)
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("%w: backoff should not be negative", ErrInvalidPolicy)
+	}
+	if p.Multiplier < 1 {
+		return fmt.Errorf("%w: multiplier %v should be at least 1", ErrInvalidPolicy, p.Multiplier)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("%w: jitter %v should be between 0 and 1", ErrInvalidPolicy, p.Jitter)
+	}
+	return nil
+}
+
+// Do calls fn until it succeeds, the error is not retryable, the attempts are used up or the context is done.
+// The error of the last call is returned.
+func (p Policy) Do(ctx context.Context, logger logrus.FieldLogger, fn func(ctx context.Context) error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || isPermanent(err) || !retryable(err) {
+			return unwrapPermanent(err)
+		}
+
+		backoff := p.backoff(attempt)
+		if logger != nil {
+			logger.Warnf("attempt %d/%d failed, retry in %s: %s", attempt, p.MaxAttempts, backoff, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w, last error: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// randFloat64 is replaced in the tests to get a deterministic backoff.
+var randFloat64 = rand.Float64
+
+// backoff returns the wait time after the attempt fails.
+func (p Policy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	d += d * p.Jitter * (2*randFloat64() - 1)
+	return time.Duration(d)
+}
+
+// Retryable is the default classification, which retries all the errors except
+// the permanent errors and the errors of the canceled or expired context.
+func Retryable(err error) bool {
+	if err == nil || isPermanent(err) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// permanentError marks the error that should not be retried.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks the error so that the call is not retried whatever the classification is.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+func unwrapPermanent(err error) error {
+	if p, ok := err.(*permanentError); ok {
+		return p.err
+	}
+	return err
+}
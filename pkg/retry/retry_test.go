@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+func testPolicy(maxAttempts int) Policy {
+	return Policy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Run("succeed after retries", func(t *testing.T) {
+		calls := 0
+		err := testPolicy(3).Do(context.Background(), nil, func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("attempts used up", func(t *testing.T) {
+		calls := 0
+		err := testPolicy(2).Do(context.Background(), nil, func(ctx context.Context) error {
+			calls++
+			return errTransient
+		})
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("error not retryable", func(t *testing.T) {
+		calls := 0
+		p := testPolicy(3)
+		p.Retryable = func(err error) bool { return !errors.Is(err, errTransient) }
+		err := p.Do(context.Background(), nil, func(ctx context.Context) error {
+			calls++
+			return fmt.Errorf("wrapped: %w", errTransient)
+		})
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		calls := 0
+		err := testPolicy(3).Do(context.Background(), nil, func(ctx context.Context) error {
+			calls++
+			return Permanent(errTransient)
+		})
+		assert.Equal(t, errTransient, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("context canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := testPolicy(3)
+		p.InitialBackoff, p.MaxBackoff = time.Hour, time.Hour
+		calls := 0
+		err := p.Do(ctx, nil, func(ctx context.Context) error {
+			calls++
+			cancel()
+			return errTransient
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, errTransient)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	defer func(f func() float64) { randFloat64 = f }(randFloat64)
+
+	p := Policy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+
+	t.Run("no jitter", func(t *testing.T) {
+		randFloat64 = func() float64 { return 1 }
+		testSuites := []struct {
+			attempt  int
+			expected time.Duration
+		}{
+			{attempt: 1, expected: time.Second},
+			{attempt: 2, expected: 2 * time.Second},
+			{attempt: 3, expected: 4 * time.Second},
+			{attempt: 4, expected: 5 * time.Second},
+		}
+		for _, ts := range testSuites {
+			assert.Equal(t, ts.expected, p.backoff(ts.attempt))
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		p := p
+		p.Jitter = 0.5
+		randFloat64 = func() float64 { return 0 }
+		assert.Equal(t, 500*time.Millisecond, p.backoff(1))
+		randFloat64 = func() float64 { return 1 }
+		assert.Equal(t, 1500*time.Millisecond, p.backoff(1))
+	})
+}
+
+func TestRetryable(t *testing.T) {
+	assert.False(t, Retryable(nil))
+	assert.True(t, Retryable(errTransient))
+	assert.False(t, Retryable(fmt.Errorf("wrapped: %w", Permanent(errTransient))))
+	assert.False(t, Retryable(context.Canceled))
+	assert.False(t, Retryable(fmt.Errorf("wrapped: %w", context.DeadlineExceeded)))
+}
+
+func TestValidate(t *testing.T) {
+	p := DefaultPolicy()
+	assert.NoError(t, p.Validate())
+
+	for _, modify := range []func(p *Policy){
+		func(p *Policy) { p.MaxAttempts = 0 },
+		func(p *Policy) { p.InitialBackoff = -time.Second },
+		func(p *Policy) { p.Multiplier = 0.5 },
+		func(p *Policy) { p.Jitter = 1.5 },
+	} {
+		p := DefaultPolicy()
+		modify(&p)
+		assert.ErrorIs(t, p.Validate(), ErrInvalidPolicy)
+	}
+}
@@ -0,0 +1,205 @@
+// Package webhook posts the result of a run to the webhook URLs, so that the custom integrations
+// receive the coverage without new code in gocover. The headers and the body are go templates of the result,
+// and the body is signed with HMAC-SHA256 when the webhook has a secret.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/Azure/gocover/pkg/retry"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureHeader is the header of the signature of the body, in sha256={hex digest} format.
+	SignatureHeader = "X-Gocover-Signature"
+	// EventHeader is the header of the event of the run, such as full or diff.
+	EventHeader = "X-Gocover-Event"
+)
+
+var (
+	ErrNoURL            = errors.New("webhook url is not set")
+	ErrUnexpectedStatus = errors.New("unexpected status code of webhook")
+)
+
+// Hook is a webhook that the result is posted to.
+type Hook struct {
+	URL string
+	// Headers are the headers of the request, the values are templates of the json result,
+	// such as `Bearer {{ env "WEBHOOK_TOKEN" }}`.
+	Headers map[string]string
+	// Body is the template of the body, the json result is posted if it's empty.
+	Body string
+	// Secret is the key to sign the body with HMAC-SHA256, the body is not signed if it's empty.
+	Secret string
+}
+
+// ClientOption contains the webhooks and how they're called.
+type ClientOption struct {
+	Hooks []*Hook
+	// Retry is the retry policy of the calls, the zero value doesn't retry.
+	Retry retry.Policy
+	// HTTPClient is http.DefaultClient if it's nil.
+	HTTPClient *http.Client
+}
+
+// Client posts the results to the webhooks.
+type Client struct {
+	hooks      []*hook
+	retry      retry.Policy
+	httpClient *http.Client
+	logger     logrus.FieldLogger
+}
+
+// hook is the webhook whose templates are parsed.
+type hook struct {
+	url     string
+	headers map[string]*template.Template
+	body    *template.Template // nil posts the result in json
+	secret  string
+}
+
+// templateFuncs are the functions of the templates besides the builtin ones.
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// NewClient creates the client, the templates of the webhooks are parsed so that a malformed one fails early.
+// It returns nil if there are no webhooks.
+func NewClient(o *ClientOption, logger logrus.FieldLogger) (*Client, error) {
+	if o == nil || len(o.Hooks) == 0 {
+		return nil, nil
+	}
+
+	hooks := make([]*hook, 0, len(o.Hooks))
+	for i, h := range o.Hooks {
+		if h.URL == "" {
+			return nil, fmt.Errorf("webhook %d: %w", i, ErrNoURL)
+		}
+		parsed := &hook{url: h.URL, headers: make(map[string]*template.Template), secret: h.Secret}
+		for name, value := range h.Headers {
+			t, err := template.New(name).Funcs(templateFuncs).Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %s: parse header %s: %w", h.URL, name, err)
+			}
+			parsed.headers[name] = t
+		}
+		if h.Body != "" {
+			t, err := template.New("body").Funcs(templateFuncs).Parse(h.Body)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %s: parse body: %w", h.URL, err)
+			}
+			parsed.body = t
+		}
+		hooks = append(hooks, parsed)
+	}
+
+	httpClient := o.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		hooks:      hooks,
+		retry:      o.Retry,
+		httpClient: httpClient,
+		logger:     logger,
+	}, nil
+}
+
+// Publish posts the result in json to each webhook. The data of the templates is the result decoded from json,
+// so that the templates refer to the fields by their json names, such as {{ .coveragePercent }}.
+// All the webhooks are called even if some of them fail, and the errors are joined.
+func (c *Client) Publish(ctx context.Context, event string, result interface{}) error {
+	if c == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+	var fields interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	var errs []error
+	for _, h := range c.hooks {
+		if err := c.post(ctx, h, event, data, fields); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", h.url, err))
+			continue
+		}
+		c.logger.Infof("post %s result to webhook %s", event, h.url)
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) post(ctx context.Context, h *hook, event string, data []byte, fields interface{}) error {
+	body := data
+	if h.body != nil {
+		var b bytes.Buffer
+		if err := h.body.Execute(&b, fields); err != nil {
+			return fmt.Errorf("execute body: %w", err)
+		}
+		body = b.Bytes()
+	}
+
+	headers := make(map[string]string, len(h.headers))
+	for name, t := range h.headers {
+		var b strings.Builder
+		if err := t.Execute(&b, fields); err != nil {
+			return fmt.Errorf("execute header %s: %w", name, err)
+		}
+		headers[name] = b.String()
+	}
+
+	return c.retry.Do(ctx, c.logger, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("new request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(EventHeader, event)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		if h.secret != "" {
+			req.Header.Set(SignatureHeader, Sign(h.secret, body))
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return retry.UnexpectedStatus(ErrUnexpectedStatus, resp)
+		}
+		return nil
+	})
+}
+
+// Sign returns the signature of the body with the secret, in sha256={hex digest} format,
+// the receiver computes it from the raw body and compares it with the SignatureHeader in constant time.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/gocover/pkg/retry"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type result struct {
+	CoveragePercent float64 `json:"coveragePercent"`
+	Branch          string  `json:"branch"`
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("no hooks", func(t *testing.T) {
+		c, err := NewClient(&ClientOption{}, logrus.New())
+		assert.NoError(t, err)
+		assert.Nil(t, c)
+		assert.NoError(t, c.Publish(context.Background(), "full", &result{}))
+	})
+
+	t.Run("no url", func(t *testing.T) {
+		_, err := NewClient(&ClientOption{Hooks: []*Hook{{}}}, logrus.New())
+		assert.ErrorIs(t, err, ErrNoURL)
+	})
+
+	t.Run("malformed template", func(t *testing.T) {
+		_, err := NewClient(&ClientOption{Hooks: []*Hook{{URL: "http://localhost", Body: "{{ .CoveragePercent"}}}, logrus.New())
+		assert.Error(t, err)
+	})
+}
+
+func TestPublish(t *testing.T) {
+	var (
+		requests int
+		body     string
+		header   http.Header
+		code     = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, _ := io.ReadAll(r.Body)
+		body, header = string(data), r.Header
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	policy := retry.Policy{MaxAttempts: 3, Multiplier: 1}
+	publish := func(h *Hook) error {
+		requests = 0
+		c, err := NewClient(&ClientOption{Hooks: []*Hook{h}, Retry: policy}, logrus.New())
+		assert.NoError(t, err)
+		return c.Publish(context.Background(), "diff", &result{CoveragePercent: 82.5, Branch: "main"})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		assert.NoError(t, publish(&Hook{URL: server.URL}))
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, `{"coveragePercent":82.5,"branch":"main"}`, body)
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "diff", header.Get(EventHeader))
+		assert.Empty(t, header.Get(SignatureHeader))
+	})
+
+	t.Run("templates", func(t *testing.T) {
+		t.Setenv("WEBHOOK_TOKEN", "token")
+		err := publish(&Hook{
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": `Bearer {{ env "WEBHOOK_TOKEN" }}`, "X-Branch": "{{ .branch }}"},
+			Body:    `{"text": {{ printf "coverage %.1f%%" .coveragePercent | json }}}`,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"text": "coverage 82.5%"}`, body)
+		assert.Equal(t, "Bearer token", header.Get("Authorization"))
+		assert.Equal(t, "main", header.Get("X-Branch"))
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		assert.NoError(t, publish(&Hook{URL: server.URL, Secret: "secret"}))
+		assert.Equal(t, Sign("secret", []byte(body)), header.Get(SignatureHeader))
+	})
+
+	t.Run("server error is retried", func(t *testing.T) {
+		code = http.StatusBadGateway
+		defer func() { code = http.StatusOK }()
+		err := publish(&Hook{URL: server.URL})
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("client error is not retried", func(t *testing.T) {
+		code = http.StatusUnauthorized
+		defer func() { code = http.StatusOK }()
+		err := publish(&Hook{URL: server.URL})
+		assert.ErrorIs(t, err, ErrUnexpectedStatus)
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestSign(t *testing.T) {
+	// the digest of the example of the GitHub webhook documentation
+	assert.Equal(t,
+		"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		Sign("It's a Secret to Everybody", []byte("Hello, World!")))
+}